@@ -0,0 +1,109 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// Access log attribute keys
+const (
+	HTTPMethodKey   = "http.method"
+	HTTPPathKey     = "http.path"
+	HTTPStatusKey   = "http.status"
+	HTTPBytesKey    = "http.bytes"
+	HTTPClientIPKey = "http.client_ip"
+	DurationKey     = "duration"
+	PanicKey        = "panic"
+)
+
+// AccessLogConfig configures the access log written by the web framework
+// middleware modules. It is read from the access_log section of the config.
+type AccessLogConfig struct {
+	SkipPaths     []string      `yaml:"skip_paths,omitempty"`
+	SlowThreshold time.Duration `yaml:"slow_threshold,omitempty"`
+}
+
+// Skip reports whether requests for the path are left out of the access log.
+func (c *AccessLogConfig) Skip(path string) bool {
+	return slices.Contains(c.SkipPaths, path)
+}
+
+// Access describes a served HTTP request.
+type Access struct {
+	Err      error
+	Method   string
+	Path     string
+	ClientIP string
+	Status   int
+	Bytes    int64
+	Duration time.Duration
+}
+
+// RequestLogger returns a ContextLogger for the request with its method and
+// path attached, for middleware to store in the request context.
+func RequestLogger(logger *Logger, r *http.Request) LoggerInterface {
+	return logger.WithField(HTTPMethodKey, r.Method).WithField(HTTPPathKey, r.URL.Path).WithContext(r.Context())
+}
+
+// AccessLevel returns the level a response with the status is logged at:
+// error for server errors, warn for client errors, and info otherwise.
+func AccessLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// LogAccess logs the request at the level of its status, and as a perf record
+// when it took longer than the slow threshold.
+func LogAccess(ctx context.Context, logger LoggerInterface, cfg *AccessLogConfig, access *Access) {
+	args := []any{
+		slog.String(HTTPMethodKey, access.Method),
+		slog.String(HTTPPathKey, access.Path),
+		slog.Int(HTTPStatusKey, access.Status),
+		slog.Int64(HTTPBytesKey, access.Bytes),
+		slog.String(HTTPClientIPKey, access.ClientIP),
+		slog.Duration(DurationKey, access.Duration),
+	}
+	if access.Err != nil {
+		args = append(args, ErrorAttr(access.Err))
+	}
+
+	const msg = "request completed"
+	switch AccessLevel(access.Status) {
+	case slog.LevelError:
+		logger.Error(msg, args...)
+	case slog.LevelWarn:
+		logger.Warn(msg, args...)
+	default:
+		logger.Info(msg, args...)
+	}
+
+	if cfg.SlowThreshold > 0 && access.Duration > cfg.SlowThreshold {
+		logger.GetLogger().Log(ctx, LevelPerf, "slow request",
+			slog.String(HTTPMethodKey, access.Method),
+			slog.String(HTTPPathKey, access.Path),
+			slog.Duration(DurationKey, access.Duration),
+		)
+	}
+}
+
+// LogPanic logs a value recovered from a panic at the error level, with the
+// stack of the panicking goroutine. Call it from the deferred function that
+// recovered.
+func LogPanic(logger LoggerInterface, recovered any) {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	logger.Error("panic recovered",
+		slog.Any(PanicKey, recovered),
+		slog.String(StacktraceKey, Stacktrace(pcs[0])),
+	)
+}
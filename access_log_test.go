@@ -0,0 +1,107 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordAttrs returns the attributes of the record by key.
+func recordAttrs(r slog.Record) map[string]slog.Value {
+	attrs := map[string]slog.Value{}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	return attrs
+}
+
+func TestLogAccess(t *testing.T) {
+	handler := &recordingHandler{}
+	logger := NewLogger(handler)
+	cfg := &AccessLogConfig{SlowThreshold: time.Second}
+
+	LogAccess(context.Background(), logger, cfg, &Access{
+		Method: "GET", Path: "/orders", ClientIP: "10.0.0.7", Status: 200, Bytes: 512, Duration: time.Millisecond,
+	})
+	LogAccess(context.Background(), logger, cfg, &Access{Method: "GET", Path: "/missing", Status: 404})
+	LogAccess(context.Background(), logger, cfg, &Access{
+		Method: "POST", Path: "/orders", Status: 503, Duration: 2 * time.Second, Err: errors.New("db down"),
+	})
+
+	assert.Equal(t, []string{
+		"info request completed",
+		"warn request completed",
+		"error request completed",
+		"perf slow request",
+	}, handler.messages())
+
+	attrs := recordAttrs(handler.records[0])
+	assert.Equal(t, "GET", attrs[HTTPMethodKey].String())
+	assert.Equal(t, "/orders", attrs[HTTPPathKey].String())
+	assert.Equal(t, int64(200), attrs[HTTPStatusKey].Int64())
+	assert.Equal(t, int64(512), attrs[HTTPBytesKey].Int64())
+	assert.Equal(t, "10.0.0.7", attrs[HTTPClientIPKey].String())
+	assert.Equal(t, time.Millisecond, attrs[DurationKey].Duration())
+	assert.Contains(t, recordAttrs(handler.records[2]), ErrorKey)
+}
+
+func TestAccessLogConfig_Skip(t *testing.T) {
+	cfg := &AccessLogConfig{SkipPaths: []string{"/healthz"}}
+	assert.True(t, cfg.Skip("/healthz"))
+	assert.False(t, cfg.Skip("/orders"))
+
+	config, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: console
+      level: info
+      enabled: true
+  access_log:
+    skip_paths: ["/healthz", "/metrics"]
+    slow_threshold: 500ms
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/healthz", "/metrics"}, config.Multilog.AccessLog.SkipPaths)
+	assert.Equal(t, 500*time.Millisecond, config.Multilog.AccessLog.SlowThreshold)
+}
+
+func TestLogPanic(t *testing.T) {
+	handler := &recordingHandler{}
+	logger := NewLogger(handler)
+
+	func() {
+		defer func() {
+			LogPanic(logger, recover())
+		}()
+		panic("boom")
+	}()
+
+	assert.Equal(t, []string{"error panic recovered"}, handler.messages())
+	attrs := recordAttrs(handler.records[0])
+	assert.Equal(t, "boom", attrs[PanicKey].Any())
+	assert.Contains(t, attrs[StacktraceKey].String(), "multilog.TestLogPanic.func1")
+	assert.NotContains(t, attrs[StacktraceKey].String(), "multilog.LogPanic")
+}
+
+func TestAccessLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelInfo, AccessLevel(204))
+	assert.Equal(t, slog.LevelInfo, AccessLevel(302))
+	assert.Equal(t, slog.LevelWarn, AccessLevel(429))
+	assert.Equal(t, slog.LevelError, AccessLevel(500))
+}
+
+func TestRequestLogger(t *testing.T) {
+	handler := &recordingHandler{}
+	logger := NewLogger(handler)
+	req := httptest.NewRequest(http.MethodDelete, "/orders/7", nil)
+
+	RequestLogger(logger, req).Info("deleting order")
+	assert.Equal(t, []string{"info deleting order"}, handler.messages())
+}
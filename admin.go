@@ -0,0 +1,265 @@
+package multilog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// HandlerStatus represents the current state of a handler exposed by the admin endpoint.
+type HandlerStatus struct {
+	Name     string `json:"name"`
+	Level    string `json:"level"`
+	MaxLevel string `json:"max_level,omitempty"`
+	SubType  string `json:"subtype,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+	File     string `json:"file,omitempty"`
+	Enabled  bool   `json:"enabled"`
+	Dropped  bool   `json:"dropped,omitempty"`
+}
+
+// levelRequest is the request body for changing a handler level.
+type levelRequest struct {
+	Level string `json:"level"`
+}
+
+// AdminHandler is an http.Handler that exposes runtime control over handlers.
+//
+// Routes:
+//
+//	GET  /handlers                 list all handlers
+//	GET  /handlers/{name}          show a single handler
+//	PUT  /handlers/{name}/level    change the level ({"level":"debug"} or ?level=debug)
+//	POST /handlers/{name}/enable   enable the handler, failing for handlers NewLogger dropped
+//	POST /handlers/{name}/disable  disable the handler
+//	POST /handlers/{name}/flush    flush buffered output
+//	POST /handlers/{name}/rotate   rotate the log file
+//	POST /flush                    flush all handlers
+type AdminHandler struct {
+	mux      *http.ServeMux
+	handlers map[string]slog.Handler
+	names    []string
+}
+
+// NewAdminHandler creates an admin handler for the given handlers.
+// Handlers are addressed by their configured name, or by their index if unnamed.
+// A handler whose name is taken by an earlier one gets a numbered suffix, so
+// the second handler named "app" is addressed as "app-2".
+func NewAdminHandler(handlers ...slog.Handler) *AdminHandler {
+	ah := &AdminHandler{
+		mux:      http.NewServeMux(),
+		handlers: make(map[string]slog.Handler, len(handlers)),
+		names:    make([]string, 0, len(handlers)),
+	}
+	for i, h := range handlers {
+		name := ah.uniqueName(i, h)
+		ah.handlers[name] = h
+		ah.names = append(ah.names, name)
+	}
+
+	ah.mux.HandleFunc("GET /handlers", ah.listHandlers)
+	ah.mux.HandleFunc("GET /handlers/{name}", ah.withHandler(ah.showHandler))
+	ah.mux.HandleFunc("PUT /handlers/{name}/level", ah.withHandler(ah.setLevel))
+	ah.mux.HandleFunc("POST /handlers/{name}/enable", ah.withHandler(ah.setEnabled(true)))
+	ah.mux.HandleFunc("POST /handlers/{name}/disable", ah.withHandler(ah.setEnabled(false)))
+	ah.mux.HandleFunc("POST /handlers/{name}/flush", ah.withHandler(ah.flushHandler))
+	ah.mux.HandleFunc("POST /handlers/{name}/rotate", ah.withHandler(ah.rotateHandler))
+	ah.mux.HandleFunc("POST /flush", ah.flushAll)
+
+	return ah
+}
+
+// uniqueName returns the name addressing the handler at index i: its configured
+// name or its index, suffixed if an earlier handler has it.
+func (ah *AdminHandler) uniqueName(i int, h slog.Handler) string {
+	name := strconv.Itoa(i)
+	if ch := GetCustomHandler(h); ch != nil && ch.GetOptions().Name != "" {
+		name = ch.GetOptions().Name
+	}
+	unique := name
+	for n := 2; ; n++ {
+		if _, taken := ah.handlers[unique]; !taken {
+			return unique
+		}
+		unique = name + "-" + strconv.Itoa(n)
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (ah *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ah.mux.ServeHTTP(w, r)
+}
+
+// Status returns the current status of all handlers.
+func (ah *AdminHandler) Status() []HandlerStatus {
+	statuses := make([]HandlerStatus, 0, len(ah.names))
+	for _, name := range ah.names {
+		statuses = append(statuses, handlerStatus(name, ah.handlers[name]))
+	}
+	return statuses
+}
+
+// GetCustomHandler returns the CustomHandlerInterface wrapped by the given handler, or nil.
+func GetCustomHandler(h slog.Handler) CustomHandlerInterface {
+	switch v := h.(type) {
+	case *ConsoleHandler:
+		return v.Handler
+	case *FileHandler:
+		return v.Handler
+	case *JSONHandler:
+		return v.Handler
+	case customHandlerProvider:
+		return v.customHandler()
+	case CustomHandlerInterface:
+		return v
+	default:
+		return nil
+	}
+}
+
+// customHandlerProvider is implemented by handlers that format records with a CustomHandler.
+type customHandlerProvider interface {
+	customHandler() CustomHandlerInterface
+}
+
+// logFileProvider is implemented by handlers that write a log file.
+type logFileProvider interface {
+	logFile() string
+}
+
+// handlerLogFile returns the log file written by the handler, or an empty string.
+func handlerLogFile(h slog.Handler) string {
+	if p, ok := h.(logFileProvider); ok {
+		return p.logFile()
+	}
+	return ""
+}
+
+func handlerStatus(name string, h slog.Handler) HandlerStatus {
+	status := HandlerStatus{Name: name}
+	ch := GetCustomHandler(h)
+	if ch == nil {
+		status.Level = UnknownLevel
+		status.Enabled = true
+		return status
+	}
+	opts := ch.GetOptions()
+	status.Level = getHandlerLevel(ch)
+	status.MaxLevel = opts.MaxLevel
+	status.SubType = opts.SubType
+	status.Pattern = opts.Pattern
+	status.File = handlerLogFile(h)
+	status.Enabled = opts.Enabled
+	if e, ok := ch.(interface{ IsEnabled() bool }); ok {
+		status.Enabled = e.IsEnabled()
+	}
+	if d, ok := ch.(interface{ IsDropped() bool }); ok {
+		status.Dropped = d.IsDropped()
+	}
+	return status
+}
+
+func (ah *AdminHandler) withHandler(
+	fn func(http.ResponseWriter, *http.Request, string, slog.Handler),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		h, ok := ah.handlers[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("unknown handler: %s", name))
+			return
+		}
+		fn(w, r, name, h)
+	}
+}
+
+func (ah *AdminHandler) listHandlers(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, ah.Status())
+}
+
+func (ah *AdminHandler) showHandler(w http.ResponseWriter, _ *http.Request, name string, h slog.Handler) {
+	writeJSON(w, http.StatusOK, handlerStatus(name, h))
+}
+
+func (ah *AdminHandler) setLevel(w http.ResponseWriter, r *http.Request, name string, h slog.Handler) {
+	level := r.URL.Query().Get("level")
+	if level == "" {
+		var req levelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("failed to decode request: %w", err))
+			return
+		}
+		level = req.Level
+	}
+
+	ls, ok := h.(LevelSetter)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, fmt.Errorf("handler does not support level changes"))
+		return
+	}
+	if err := ls.SetLevel(level); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, handlerStatus(name, h))
+}
+
+func (ah *AdminHandler) setEnabled(
+	enabled bool,
+) func(http.ResponseWriter, *http.Request, string, slog.Handler) {
+	return func(w http.ResponseWriter, _ *http.Request, name string, h slog.Handler) {
+		es, ok := GetCustomHandler(h).(interface{ SetEnabled(bool) error })
+		if !ok {
+			writeError(w, http.StatusNotImplemented, fmt.Errorf("handler does not support enable/disable"))
+			return
+		}
+		if err := es.SetEnabled(enabled); err != nil {
+			writeError(w, http.StatusConflict, fmt.Errorf("handler %s: %w", name, err))
+			return
+		}
+		writeJSON(w, http.StatusOK, handlerStatus(name, h))
+	}
+}
+
+func (ah *AdminHandler) flushHandler(w http.ResponseWriter, _ *http.Request, name string, h slog.Handler) {
+	if err := flushHandler(h); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, handlerStatus(name, h))
+}
+
+func (ah *AdminHandler) rotateHandler(w http.ResponseWriter, _ *http.Request, name string, h slog.Handler) {
+	rotator, ok := h.(Rotator)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, fmt.Errorf("handler does not support rotation"))
+		return
+	}
+	if err := rotator.Rotate(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, handlerStatus(name, h))
+}
+
+func (ah *AdminHandler) flushAll(w http.ResponseWriter, _ *http.Request) {
+	for _, name := range ah.names {
+		if err := flushHandler(ah.handlers[name]); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("handler %s: %w", name, err))
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, ah.Status())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,203 @@
+package multilog
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAdminHandler(t *testing.T) (*AdminHandler, slog.Handler) {
+	t.Helper()
+	fileHandler, err := NewFileHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Name:    "app",
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[msg]",
+		},
+		File:    filepath.Join(t.TempDir(), "app.log"),
+		MaxSize: 1,
+	})
+	assert.NoError(t, err)
+	return NewAdminHandler(fileHandler, &mockHandler{}), fileHandler
+}
+
+func doAdminRequest(ah *AdminHandler, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	ah.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminHandler_ListHandlers(t *testing.T) {
+	ah, _ := newTestAdminHandler(t)
+
+	rec := doAdminRequest(ah, http.MethodGet, "/handlers", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var statuses []HandlerStatus
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &statuses))
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, "app", statuses[0].Name)
+	assert.Equal(t, InfoLevel, statuses[0].Level)
+	assert.True(t, statuses[0].Enabled)
+	assert.Equal(t, "1", statuses[1].Name)
+	assert.Equal(t, UnknownLevel, statuses[1].Level)
+}
+
+func TestAdminHandler_DuplicateNames(t *testing.T) {
+	named := func(name string) slog.Handler {
+		return NewConsoleHandler(ConsoleHandlerOptions{
+			CustomHandlerOptions: CustomHandlerOptions{Name: name, Level: InfoLevel, Enabled: true},
+		})
+	}
+	first, second := named("app"), named("app")
+	ah := NewAdminHandler(first, second, named("2"), &mockHandler{})
+
+	var names []string
+	for _, status := range ah.Status() {
+		names = append(names, status.Name)
+	}
+	assert.Equal(t, []string{"app", "app-2", "2", "3"}, names)
+
+	rec := doAdminRequest(ah, http.MethodPut, "/handlers/app-2/level", `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, first.Enabled(context.Background(), slog.LevelDebug))
+	assert.True(t, second.Enabled(context.Background(), slog.LevelDebug))
+
+	ah = NewAdminHandler(&mockHandler{}, named("0"))
+	assert.Equal(t, "0-2", ah.Status()[1].Name)
+}
+
+func TestAdminHandler_SetLevel(t *testing.T) {
+	ah, h := newTestAdminHandler(t)
+
+	rec := doAdminRequest(ah, http.MethodPut, "/handlers/app/level", `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, h.Enabled(context.Background(), slog.LevelDebug))
+
+	rec = doAdminRequest(ah, http.MethodPut, "/handlers/app/level?level=error", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, h.Enabled(context.Background(), slog.LevelWarn))
+
+	rec = doAdminRequest(ah, http.MethodPut, "/handlers/app/level", `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doAdminRequest(ah, http.MethodPut, "/handlers/app/level", `not json`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doAdminRequest(ah, http.MethodPut, "/handlers/1/level?level=debug", "")
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+
+	rec = doAdminRequest(ah, http.MethodPut, "/handlers/missing/level?level=debug", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAdminHandler_EnableDisable(t *testing.T) {
+	ah, h := newTestAdminHandler(t)
+
+	rec := doAdminRequest(ah, http.MethodPost, "/handlers/app/disable", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, h.Enabled(context.Background(), slog.LevelError))
+
+	var status HandlerStatus
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.False(t, status.Enabled)
+
+	rec = doAdminRequest(ah, http.MethodPost, "/handlers/app/enable", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, h.Enabled(context.Background(), slog.LevelError))
+
+	rec = doAdminRequest(ah, http.MethodPost, "/handlers/1/disable", "")
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
+
+func TestAdminHandler_FlushAndRotate(t *testing.T) {
+	ah, h := newTestAdminHandler(t)
+	logger := NewLogger(h)
+	logger.Info("before rotate")
+
+	rec := doAdminRequest(ah, http.MethodPost, "/handlers/app/flush", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doAdminRequest(ah, http.MethodPost, "/flush", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doAdminRequest(ah, http.MethodPost, "/handlers/app/rotate", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	file := handlerLogFile(h)
+	entries, err := os.ReadDir(filepath.Dir(file))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	rec = doAdminRequest(ah, http.MethodPost, "/handlers/1/rotate", "")
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
+
+func TestAdminHandler_EnableDropped(t *testing.T) {
+	fileHandler, err := NewFileHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Name:    "app",
+			Level:   InfoLevel,
+			Pattern: "[msg]",
+		},
+		File: filepath.Join(t.TempDir(), "app.log"),
+	})
+	assert.NoError(t, err)
+	ah := NewAdminHandler(fileHandler)
+
+	// Disabled handlers are left out of the logger and cannot be enabled.
+	NewLogger(fileHandler)
+	rec := doAdminRequest(ah, http.MethodPost, "/handlers/app/enable", "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "handler app: handler was left out of the logger")
+	assert.False(t, fileHandler.Enabled(context.Background(), slog.LevelError))
+
+	var status HandlerStatus
+	rec = doAdminRequest(ah, http.MethodGet, "/handlers/app", "")
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.True(t, status.Dropped)
+	assert.False(t, status.Enabled)
+
+	rec = doAdminRequest(ah, http.MethodPost, "/handlers/app/disable", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doAdminRequest(ah, http.MethodPost, "/handlers/missing/enable", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unknown handler: missing")
+}
+
+func TestCustomHandler_SetEnabledConcurrent(t *testing.T) {
+	h, err := NewFileHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[msg]",
+		},
+		File: filepath.Join(t.TempDir(), "app.log"),
+	})
+	assert.NoError(t, err)
+	ch := GetCustomHandler(h).(*CustomHandler)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 1000 {
+			assert.NoError(t, ch.SetEnabled(i%2 == 0))
+		}
+	}()
+	for range 1000 {
+		_ = h.Enabled(context.Background(), slog.LevelInfo)
+	}
+	<-done
+	assert.False(t, ch.IsDropped())
+}
@@ -2,6 +2,7 @@ package multilog
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"path/filepath"
@@ -45,6 +46,11 @@ func (a Aggregator) Handle(ctx context.Context, r slog.Record) error {
 
 // WithAttrs implements slog.Handler.
 func (a Aggregator) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return a.withAttrs(attrs)
+}
+
+// withAttrs returns an aggregator of the handlers with the attributes added.
+func (a Aggregator) withAttrs(attrs []slog.Attr) Aggregator {
 	handlers := make([]slog.Handler, len(a))
 	for i, h := range a {
 		handlers[i] = h.WithAttrs(attrs)
@@ -54,6 +60,11 @@ func (a Aggregator) WithAttrs(attrs []slog.Attr) slog.Handler {
 
 // WithGroup implements slog.Handler.
 func (a Aggregator) WithGroup(name string) slog.Handler {
+	return a.withGroup(name)
+}
+
+// withGroup returns an aggregator of the handlers with the group opened.
+func (a Aggregator) withGroup(name string) Aggregator {
 	handlers := make([]slog.Handler, len(a))
 	for i, h := range a {
 		handlers[i] = h.WithGroup(name)
@@ -61,6 +72,53 @@ func (a Aggregator) WithGroup(name string) slog.Handler {
 	return NewAggregator(handlers...)
 }
 
+// Use wraps every handler of the aggregator with the middlewares.
+func (a Aggregator) Use(middlewares ...Middleware) Aggregator {
+	handlers := make([]slog.Handler, len(a))
+	for i, h := range a {
+		handlers[i] = Chain(h, middlewares...)
+	}
+	return NewAggregator(handlers...)
+}
+
+// Flush flushes all handlers that buffer output.
+func (a Aggregator) Flush() error {
+	var err error
+	for _, h := range a {
+		err = errors.Join(err, flushHandler(h))
+	}
+	return err
+}
+
+// Close flushes all handlers and releases their resources.
+// Every handler is closed even if an earlier one fails.
+func (a Aggregator) Close() error {
+	var err error
+	for _, h := range a {
+		err = errors.Join(err, closeSlogHandler(h))
+	}
+	return err
+}
+
+// flushHandler flushes the handler if it buffers output.
+func flushHandler(h slog.Handler) error {
+	if f, ok := h.(Flusher); ok {
+		return f.Flush()
+	}
+	if f, ok := GetCustomHandler(h).(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// closeSlogHandler closes the handler if it holds resources, or flushes it otherwise.
+func closeSlogHandler(h slog.Handler) error {
+	if c, ok := h.(Closer); ok {
+		return c.Close()
+	}
+	return flushHandler(h)
+}
+
 // CollectPerfMetrics collects performance metrics.
 func CollectPerfMetrics() *PerfMetrics {
 	importantMetrics := []string{
@@ -148,21 +206,12 @@ func GetPerformanceMetricsUsingRuntime() string {
 
 // GetPerformanceMetricsWithMemStats gathers memory and goroutine metrics.
 func GetPerformanceMetricsWithMemStats() string {
-	pm := CollectPerfMetricsWithMemStats()
-	return fmt.Sprintf(
-		"goroutines:%d,alloc:%f MB,sys:%f MB,heap_alloc:%f MB,heap_sys:%f MB,heap_idle:%f MB,heap_inuse:%f MB,stack_sys:%f MB", // nolint:lll
-		pm.NumGoroutines,
-		pm.Alloc,
-		pm.Sys,
-		pm.HeapAlloc,
-		pm.HeapSys,
-		pm.HeapIdle,
-		pm.HeapInuse,
-		pm.StackSys,
-	)
+	return formatPerfMetrics(nil)
 }
 
 // GetCallerInfo retrieves the caller information from the stack trace.
+//
+// Deprecated: records carry the program counter of their caller, see slog.Record.Source.
 func GetCallerInfo(identifiers ...string) (fn, file string, line int, found bool) {
 	stack := debug.Stack()
 	lines := strings.Split(string(stack), "\n")
@@ -180,11 +229,15 @@ func GetCallerInfo(identifiers ...string) (fn, file string, line int, found bool
 }
 
 // GetPerfCallerInfo returns the caller information for performance logs.
+//
+// Deprecated: records carry the program counter of their caller, see slog.Record.Source.
 func GetPerfCallerInfo() (fn, file string, line int, found bool) {
 	return GetCallerInfo(CallIdentifiers[0], CallIdentifiers[1])
 }
 
 // GetOtherCallerInfo returns the caller information for other logs.
+//
+// Deprecated: records carry the program counter of their caller, see slog.Record.Source.
 func GetOtherCallerInfo() (fn, file string, line int, found bool) {
 	return GetCallerInfo(CallIdentifiers[2:]...)
 }
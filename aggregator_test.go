@@ -421,3 +421,42 @@ func (h *ErrorHandler) WithAttrs([]slog.Attr) slog.Handler {
 func (h *ErrorHandler) WithGroup(string) slog.Handler {
 	return h
 }
+
+type closingHandler struct {
+	mockHandler
+	closeErr error
+	flushed  bool
+	closed   bool
+}
+
+func (c *closingHandler) Flush() error {
+	c.flushed = true
+	return nil
+}
+
+func (c *closingHandler) Close() error {
+	c.closed = true
+	return c.closeErr
+}
+
+func TestAggregator_FlushAndClose(t *testing.T) {
+	first := &closingHandler{closeErr: errors.New("close failed")}
+	second := &closingHandler{}
+	plain := &mockHandler{}
+	aggregator := NewAggregator(first, second, plain)
+
+	if err := aggregator.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+	if !first.flushed || !second.flushed {
+		t.Error("Expected all flushers to be flushed")
+	}
+
+	err := aggregator.Close()
+	if err == nil || err.Error() != "close failed" {
+		t.Errorf("Expected close error, got %v", err)
+	}
+	if !first.closed || !second.closed {
+		t.Error("Expected all handlers to be closed even after an error")
+	}
+}
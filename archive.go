@@ -0,0 +1,225 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Archive settings
+const (
+	DefaultArchiveInterval = time.Minute
+	archiveTimeout         = 5 * time.Minute
+)
+
+// ArchiveObject is an object in an archive store.
+type ArchiveObject struct {
+	Modified time.Time
+	Key      string
+}
+
+// ArchiveStore is the object storage rotated log files are archived to.
+type ArchiveStore interface {
+	Upload(ctx context.Context, key string, body []byte) error
+	List(ctx context.Context, prefix string) ([]ArchiveObject, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// ArchiveStoreFactory creates the store for an archive URL. It defaults to
+// NewObjectStore; replace it to archive through a cloud provider SDK.
+var ArchiveStoreFactory = func(archiveURL string) (ArchiveStore, error) {
+	return NewObjectStore(archiveURL)
+}
+
+// Archiver uploads rotated log files to an archive store. Every ArchiveInterval
+// it uploads the rotated files of the handler that are not yet in the store,
+// only compressed ones when Compress is set, and deletes archived objects older
+// than ArchiveRetention days. Local backups are still pruned by MaxBackups and
+// MaxAge.
+type Archiver struct {
+	store     ArchiveStore
+	current   func() string
+	onError   func(err error)
+	now       func() time.Time
+	stop      chan struct{}
+	file      string
+	prefix    string
+	retention time.Duration
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	closeOnce sync.Once
+	compress  bool
+}
+
+// newArchiver creates an archiver for the files of opts.File and starts its
+// scan loop; current returns the name of the file being written.
+func newArchiver(opts FileHandlerOptions, current func() string) (*Archiver, error) {
+	_, _, prefix, err := ParseArchiveURL(opts.ArchiveURL)
+	if err != nil {
+		return nil, err
+	}
+	store, err := ArchiveStoreFactory(opts.ArchiveURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive store: %w", err)
+	}
+	onError := opts.OnError
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	a := &Archiver{
+		store:     store,
+		current:   current,
+		onError:   onError,
+		now:       time.Now,
+		stop:      make(chan struct{}),
+		file:      opts.File,
+		prefix:    prefix,
+		retention: time.Duration(opts.ArchiveRetention) * day,
+		compress:  opts.Compress,
+	}
+	a.wg.Add(1)
+	go a.run(defaultDuration(opts.ArchiveInterval, DefaultArchiveInterval))
+	return a, nil
+}
+
+// run archives every interval until the archiver is closed.
+func (a *Archiver) run(interval time.Duration) {
+	defer a.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.Archive(); err != nil {
+				a.onError(err)
+			}
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// Archive uploads the rotated files not yet archived and applies the retention.
+func (a *Archiver) Archive() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	files, err := a.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), archiveTimeout)
+	defer cancel()
+	objects, err := a.store.List(ctx, a.prefix)
+	if err != nil {
+		return err
+	}
+	archived := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		archived[obj.Key] = true
+	}
+
+	var errs []error
+	for _, file := range files {
+		key := a.prefix + filepath.Base(file)
+		if archived[key] {
+			continue
+		}
+		body, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read log file: %w", err))
+			continue
+		}
+		if err := a.store.Upload(ctx, key, body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if a.retention > 0 {
+		cutoff := a.now().Add(-a.retention)
+		for _, obj := range objects {
+			if obj.Modified.Before(cutoff) {
+				if err := a.store.Delete(ctx, obj.Key); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops the scan loop and archives the files rotated since the last scan.
+func (a *Archiver) Close() error {
+	var err error
+	a.closeOnce.Do(func() {
+		close(a.stop)
+		a.wg.Wait()
+		err = a.Archive()
+	})
+	return err
+}
+
+// rotatedFiles returns the rotated files of the handler: files in the log
+// directory sharing the static part of its base name and its extension, other
+// than the current file. Files being compressed are left for the next scan.
+func (a *Archiver) rotatedFiles() ([]string, error) {
+	dir, base := filepath.Split(a.file)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	if i := strings.IndexByte(stem, '%'); i >= 0 {
+		// Cut a date template at its first directive.
+		stem = stem[:i]
+	} else {
+		// Size-based backups are named <stem>-<timestamp><ext>.
+		stem += "-"
+	}
+	dir = defaultIfEmpty(dir, ".")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+	current := filepath.Base(a.current())
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || name == current || !strings.HasPrefix(name, stem) {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(name, ext+GzipExt):
+			if _, err := os.Stat(filepath.Join(dir, strings.TrimSuffix(name, GzipExt))); err == nil {
+				continue
+			}
+		case a.compress || !strings.HasSuffix(name, ext):
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files, nil
+}
+
+// newHandlerArchiver creates the archiver of a file handler when opts.ArchiveURL is set.
+func newHandlerArchiver(opts FileHandlerOptions, rotator rotationWriter) (*Archiver, error) {
+	if opts.ArchiveURL == "" {
+		return nil, nil
+	}
+	current := func() string { return opts.File }
+	if tr, ok := rotator.(*timeRotator); ok {
+		current = tr.Filename
+	}
+	return newArchiver(opts, current)
+}
+
+// closeArchiver closes the archiver, if any.
+func closeArchiver(a *Archiver) error {
+	if a == nil {
+		return nil
+	}
+	return a.Close()
+}
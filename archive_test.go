@@ -0,0 +1,198 @@
+package multilog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore is an in-memory ArchiveStore.
+type memoryStore struct {
+	objects map[string]ArchiveObject
+	bodies  map[string]string
+	mu      sync.Mutex
+}
+
+func (s *memoryStore) Upload(_ context.Context, key string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = ArchiveObject{Key: key, Modified: time.Now()}
+	s.bodies[key] = string(body)
+	return nil
+}
+
+func (s *memoryStore) List(_ context.Context, _ string) ([]ArchiveObject, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	objects := make([]ArchiveObject, 0, len(s.objects))
+	for _, obj := range s.objects {
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	delete(s.bodies, key)
+	return nil
+}
+
+func (s *memoryStore) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// useMemoryStore makes archivers use a fresh in-memory store for the test.
+func useMemoryStore(t *testing.T) *memoryStore {
+	t.Helper()
+	store := &memoryStore{objects: map[string]ArchiveObject{}, bodies: map[string]string{}}
+	factory := ArchiveStoreFactory
+	ArchiveStoreFactory = func(string) (ArchiveStore, error) { return store, nil }
+	t.Cleanup(func() { ArchiveStoreFactory = factory })
+	return store
+}
+
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600))
+	}
+}
+
+func TestArchiver_RotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir,
+		"app.log",
+		"app-2024-01-01T00-00-00.000.log",
+		"app-2024-01-02T00-00-00.000.log.gz",
+		"app-2024-01-03T00-00-00.000.log", "app-2024-01-03T00-00-00.000.log.gz",
+		"apple.log", "other.log", "app-notes.txt",
+	)
+	a := &Archiver{file: filepath.Join(dir, "app.log")}
+	a.current = func() string { return a.file }
+
+	files, err := a.rotatedFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "app-2024-01-01T00-00-00.000.log"),
+		filepath.Join(dir, "app-2024-01-02T00-00-00.000.log.gz"),
+		filepath.Join(dir, "app-2024-01-03T00-00-00.000.log"),
+	}, files)
+
+	a.compress = true
+	files, err = a.rotatedFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "app-2024-01-02T00-00-00.000.log.gz")}, files)
+}
+
+func TestArchiver_TimeTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "app-2024-01-01.log", "app-2024-01-02.log", "app.log")
+	a := &Archiver{
+		file:    filepath.Join(dir, "app-%Y-%m-%d.log"),
+		current: func() string { return filepath.Join(dir, "app-2024-01-02.log") },
+	}
+	files, err := a.rotatedFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "app-2024-01-01.log")}, files)
+}
+
+func TestArchiver_ArchiveAndRetention(t *testing.T) {
+	store := useMemoryStore(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, "app.log", "app-1.log.gz")
+	store.objects["logs/app-0.log.gz"] = ArchiveObject{Key: "logs/app-0.log.gz", Modified: time.Now().Add(-10 * day)}
+
+	a, err := newArchiver(FileHandlerOptions{
+		File:             filepath.Join(dir, "app.log"),
+		ArchiveURL:       "s3://bucket/logs",
+		ArchiveInterval:  time.Hour,
+		ArchiveRetention: 7,
+		Compress:         true,
+	}, func() string { return filepath.Join(dir, "app.log") })
+	assert.NoError(t, err)
+	assert.NoError(t, a.Archive())
+	assert.Equal(t, []string{"logs/app-1.log.gz"}, store.keys())
+
+	// Archived files are not uploaded again.
+	store.bodies["logs/app-1.log.gz"] = "uploaded"
+	writeFiles(t, dir, "app-2.log.gz")
+	assert.NoError(t, a.Close())
+	assert.Equal(t, []string{"logs/app-1.log.gz", "logs/app-2.log.gz"}, store.keys())
+	assert.Equal(t, "uploaded", store.bodies["logs/app-1.log.gz"])
+	assert.NoError(t, a.Close())
+}
+
+func TestFileHandler_Archive(t *testing.T) {
+	store := useMemoryStore(t)
+	file := filepath.Join(t.TempDir(), "app.log")
+	handler, err := NewFileHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[msg]",
+		},
+		File:            file,
+		MaxSize:         1,
+		ArchiveURL:      "gs://bucket",
+		ArchiveInterval: time.Hour,
+	})
+	assert.NoError(t, err)
+	fh := handler.(*FileHandler)
+
+	logger := NewLogger(handler)
+	logger.Info("before rotation")
+	assert.NoError(t, fh.Rotate())
+	logger.Info("after rotation")
+	assert.NoError(t, fh.Close())
+
+	keys := store.keys()
+	assert.Len(t, keys, 1)
+	assert.Contains(t, store.bodies[keys[0]], "before rotation")
+
+	_, err = NewFileHandler(FileHandlerOptions{File: file, ArchiveURL: "ftp://bucket"})
+	assert.Error(t, err)
+}
+
+func TestCreateHandler_FileArchive(t *testing.T) {
+	useMemoryStore(t)
+	handlerCfg := HandlerConfig{
+		Type:       FileHandlerType,
+		SubType:    JSONHandlerSubType,
+		Level:      InfoLevel,
+		Enabled:    true,
+		File:       filepath.Join(t.TempDir(), "app.json"),
+		ArchiveURL: "s3:///missing-bucket",
+	}
+	cfg := &Config{Multilog: LogConfig{Handlers: []HandlerConfig{handlerCfg}}}
+	assert.Error(t, validateConfig(cfg))
+
+	cfg.Multilog.Handlers[0].ArchiveURL = "s3://bucket/app"
+	cfg.Multilog.Handlers[0].ArchiveRetention = -1
+	assert.Error(t, validateConfig(cfg))
+
+	cfg.Multilog.Handlers[0].ArchiveRetention = 30
+	assert.NoError(t, validateConfig(cfg))
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	jh, ok := handlers[0].(*JSONHandler)
+	if assert.True(t, ok) {
+		assert.NotNil(t, jh.archiver)
+		assert.Equal(t, "app/", jh.archiver.prefix)
+		assert.NoError(t, jh.Close())
+	}
+}
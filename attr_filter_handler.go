@@ -0,0 +1,79 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// AttrFilterHandler is a Handler that removes attributes by key before they reach
+// the wrapped handler. Keys match top-level attributes and, with groups opened by
+// WithGroup, the dotted path (e.g. "request.id"). Built-in fields (time, level,
+// message, source) are not affected; they are controlled by the pattern.
+type AttrFilterHandler struct {
+	include []string
+	exclude []string
+	group   string
+	wrappedHandler
+}
+
+// NewAttrFilterHandler creates a handler keeping only the include keys (all keys
+// if empty) and then removing the exclude keys.
+func NewAttrFilterHandler(handler slog.Handler, include, exclude []string) *AttrFilterHandler {
+	return &AttrFilterHandler{
+		include:        include,
+		exclude:        exclude,
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// AttrFilterMiddleware wraps handlers with NewAttrFilterHandler.
+func AttrFilterMiddleware(include, exclude []string) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewAttrFilterHandler(next, include, exclude)
+	}
+}
+
+// Handle removes the filtered attributes from the record and writes it.
+func (fh *AttrFilterHandler) Handle(ctx context.Context, record slog.Record) error {
+	filtered := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		if fh.keep(a.Key) {
+			filtered.AddAttrs(a)
+		}
+		return true
+	})
+	return fh.Handler.Handle(ctx, filtered)
+}
+
+// WithAttrs creates a new handler with the attributes that pass the filter.
+func (fh *AttrFilterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	kept := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if fh.keep(a.Key) {
+			kept = append(kept, a)
+		}
+	}
+	clone := *fh
+	clone.Handler = fh.Handler.WithAttrs(kept)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (fh *AttrFilterHandler) WithGroup(name string) slog.Handler {
+	clone := *fh
+	clone.Handler = fh.Handler.WithGroup(name)
+	clone.group = fh.group + name + "."
+	return &clone
+}
+
+// keep reports whether the attribute with the key passes the filter.
+func (fh *AttrFilterHandler) keep(key string) bool {
+	matches := func(keys []string) bool {
+		return slices.Contains(keys, key) || (fh.group != "" && slices.Contains(keys, fh.group+key))
+	}
+	if len(fh.include) > 0 && !matches(fh.include) {
+		return false
+	}
+	return !matches(fh.exclude)
+}
@@ -0,0 +1,68 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func recordKeys(r slog.Record) []string {
+	var keys []string
+	r.Attrs(func(a slog.Attr) bool {
+		keys = append(keys, a.Key)
+		return true
+	})
+	return keys
+}
+
+func TestAttrFilterHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{"no filter", nil, nil, []string{"user", "password", "trace_id"}},
+		{"include", []string{"user", "trace_id"}, nil, []string{"user", "trace_id"}},
+		{"exclude", nil, []string{"password"}, []string{"user", "trace_id"}},
+		{"include and exclude", []string{"user", "password"}, []string{"password"}, []string{"user"}},
+		{"builtins only", []string{"level", "msg"}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &recordingHandler{}
+			h := NewAttrFilterHandler(recorder, tt.include, tt.exclude)
+			r := slog.NewRecord(time.Now(), slog.LevelInfo, "login", 0)
+			r.AddAttrs(slog.String("user", "bob"), slog.String("password", "x"), slog.String("trace_id", "1"))
+			assert.NoError(t, h.Handle(context.Background(), r))
+			assert.Equal(t, tt.expected, recordKeys(recorder.records[0]))
+		})
+	}
+}
+
+func TestAttrFilterHandler_WithAttrsAndGroups(t *testing.T) {
+	var buf bytes.Buffer
+	inner := NewCustomHandler(&CustomHandlerOptions{
+		Level:   InfoLevel,
+		Enabled: true,
+		Pattern: "[level] [msg]",
+	}, bufio.NewWriter(&buf), nil)
+	h := NewAttrFilterHandler(inner, nil, []string{"secret", "request.token"})
+
+	child := h.WithAttrs([]slog.Attr{slog.String("secret", "s"), slog.String("service", "api")}).
+		WithGroup("request")
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "handled", 0)
+	r.AddAttrs(slog.String("token", "t"), slog.String("id", "42"))
+	assert.NoError(t, child.Handle(context.Background(), r))
+
+	output := buf.String()
+	assert.Contains(t, output, "service=api")
+	assert.Contains(t, output, "request.id=42")
+	assert.NotContains(t, output, "secret")
+	assert.NotContains(t, output, "token")
+}
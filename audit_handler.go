@@ -0,0 +1,281 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Keys of the hashes sealing audit records.
+const (
+	AuditPrevHashKey = "prev_hash"
+	AuditHashKey     = "hash"
+)
+
+// auditGenesisHash is the previous hash of the first record of an audit file.
+var auditGenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// auditSuffixLen is the length of the hash field closing a sealed record.
+var auditSuffixLen = len(`,"`+AuditHashKey+`":""}`) + sha256.Size*2
+
+// auditChain is the audit file shared by an audit handler and its derived
+// handlers, with the hash of the last record written to it.
+type auditChain struct {
+	file *os.File
+	prev string
+	mu   sync.Mutex
+}
+
+// AuditHandler is a Handler that appends records as JSON lines to a
+// tamper-evident audit file. Each record is sealed with the SHA-256 hash of the
+// previous record as prev_hash, and its own hash as hash, so that editing,
+// inserting or removing a record breaks the chain checked by VerifyAuditLog.
+// The file is not rotated, and must only be written by one handler at a time.
+type AuditHandler struct {
+	Handler recordFormatter
+	chain   *auditChain
+	file    string
+}
+
+// AuditHandlerOptions are the options of audit handlers: the formatting
+// options, and the audit file with its permissions.
+type AuditHandlerOptions struct {
+	CustomHandlerOptions
+	FilePermissions
+	File string
+}
+
+// NewAuditHandler creates an audit Handler writing to opts.File. Records are
+// always formatted as by the JSON subtype. An existing file is appended to,
+// continuing its chain from its last record.
+func NewAuditHandler(opts AuditHandlerOptions) (slog.Handler, error) {
+	if opts.File == "" {
+		return nil, fmt.Errorf("audit handler requires a file")
+	}
+	opts.SubType = JSONHandlerSubType
+	prev, err := lastAuditHash(opts.File)
+	if err != nil {
+		return nil, err
+	}
+	preparePermissions(opts.File, &opts.FilePermissions, opts.OnError)
+	file, err := os.OpenFile(opts.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &AuditHandler{
+		Handler: newJSONHandler(opts.CustomHandlerOptions, nil, nil),
+		chain:   &auditChain{file: file, prev: prev},
+		file:    opts.File,
+	}, nil
+}
+
+// lastAuditHash returns the hash of the last record of the audit file, or the
+// genesis hash if the file is empty or does not exist.
+func lastAuditHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return auditGenesisHash, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to open audit file: %w", err)
+	}
+	if info.Size() == 0 {
+		return auditGenesisHash, nil
+	}
+
+	// A sealed record ends with its hash field and a newline.
+	tail := make([]byte, auditSuffixLen+1)
+	if info.Size() < int64(len(tail)) {
+		return "", fmt.Errorf("failed to resume audit chain: last record of %s is not sealed", path)
+	}
+	if _, err := file.ReadAt(tail, info.Size()-int64(len(tail))); err != nil {
+		return "", fmt.Errorf("failed to resume audit chain: %w", err)
+	}
+	hash, ok := auditHashField(tail[:len(tail)-1])
+	if !ok || tail[len(tail)-1] != '\n' {
+		return "", fmt.Errorf("failed to resume audit chain: last record of %s is not sealed", path)
+	}
+	return hash, nil
+}
+
+// auditHashField returns the hash of a sealed record, which ends with it.
+func auditHashField(line []byte) (string, bool) {
+	if len(line) < auditSuffixLen {
+		return "", false
+	}
+	suffix := line[len(line)-auditSuffixLen:]
+	prefix := `,"` + AuditHashKey + `":"`
+	if !bytes.HasPrefix(suffix, []byte(prefix)) || !bytes.HasSuffix(suffix, []byte(`"}`)) {
+		return "", false
+	}
+	hash := string(suffix[len(prefix) : len(suffix)-2])
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", false
+	}
+	return hash, true
+}
+
+// sealAuditRecord adds the previous hash and the hash of the result to the
+// JSON document, returning the sealed record and its hash.
+func sealAuditRecord(doc []byte, prev string) ([]byte, string) {
+	body := make([]byte, 0, len(doc)+2*auditSuffixLen)
+	body = append(body, bytes.TrimSuffix(doc, []byte("}"))...)
+	if len(body) > 1 {
+		body = append(body, ',')
+	}
+	body = append(body, `"`+AuditPrevHashKey+`":"`+prev+`"}`...)
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	record := append(body[:len(body)-1], `,"`+AuditHashKey+`":"`+hash+`"}`...)
+	return record, hash
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (ah *AuditHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return ah.Handler.Enabled(ctx, level)
+}
+
+// Handle seals the record and appends it to the audit file, syncing the file
+// so that the record survives a crash once Handle returns.
+func (ah *AuditHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !ah.Enabled(ctx, record.Level) {
+		return nil
+	}
+	doc, err := ah.Handler.Format(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	c := ah.chain
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return fmt.Errorf("audit handler is closed")
+	}
+	line, hash := sealAuditRecord([]byte(doc), c.prev)
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	if err := c.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit file: %w", err)
+	}
+	c.prev = hash
+	return nil
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (ah *AuditHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *ah
+	clone.Handler = ah.Handler.WithAttrs(attrs).(recordFormatter)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (ah *AuditHandler) WithGroup(name string) slog.Handler {
+	clone := *ah
+	clone.Handler = ah.Handler.WithGroup(name).(recordFormatter)
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (ah *AuditHandler) SetLevel(level string) error {
+	return ah.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (ah *AuditHandler) GetLevel() string {
+	return ah.Handler.GetLevel()
+}
+
+// Close closes the audit file.
+func (ah *AuditHandler) Close() error {
+	c := ah.chain
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// customHandler implements customHandlerProvider.
+func (ah *AuditHandler) customHandler() CustomHandlerInterface {
+	return GetCustomHandler(ah.Handler)
+}
+
+// logFile implements logFileProvider.
+func (ah *AuditHandler) logFile() string {
+	return ah.file
+}
+
+// VerifyAuditLog checks the hash chain of the audit records read from r,
+// returning the number of records verified. The error names the first record,
+// counting from 1, that was altered, inserted, removed or partly written.
+// Removing records from the end of the log is not detected.
+func VerifyAuditLog(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	prev := auditGenesisHash
+	n := 0
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) == 0 && errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return n, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		n++
+		if err != nil {
+			return n - 1, fmt.Errorf("audit record %d is incomplete", n)
+		}
+		if err := verifyAuditRecord(line[:len(line)-1], prev); err != nil {
+			return n - 1, fmt.Errorf("audit record %d %w", n, err)
+		}
+		prev, _ = auditHashField(line[:len(line)-1])
+	}
+}
+
+// verifyAuditRecord checks that the record is sealed with its hash and the
+// previous hash.
+func verifyAuditRecord(line []byte, prev string) error {
+	hash, ok := auditHashField(line)
+	if !ok {
+		return errors.New("is not sealed")
+	}
+	body := append(line[:len(line)-auditSuffixLen:len(line)-auditSuffixLen], '}')
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != hash {
+		return errors.New("does not match its hash")
+	}
+	if !bytes.HasSuffix(body, []byte(`"`+AuditPrevHashKey+`":"`+prev+`"}`)) {
+		return errors.New("does not follow the previous record")
+	}
+	return nil
+}
+
+// VerifyAuditFile checks the hash chain of the audit file at path as
+// VerifyAuditLog does.
+func VerifyAuditFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer file.Close()
+	return VerifyAuditLog(file)
+}
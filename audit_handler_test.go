@@ -0,0 +1,134 @@
+package multilog
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeAudit logs the messages to a new audit handler on path and closes it.
+func writeAudit(t *testing.T, path string, msgs ...string) {
+	t.Helper()
+	handler, err := NewAuditHandler(AuditHandlerOptions{CustomHandlerOptions: CustomHandlerOptions{Level: InfoLevel, Enabled: true}, File: path})
+	assert.NoError(t, err)
+	logger := NewLogger(handler)
+	for _, msg := range msgs {
+		logger.Info(msg, "user", "alice")
+	}
+	assert.NoError(t, handler.(*AuditHandler).Close())
+}
+
+func TestSealAuditRecord(t *testing.T) {
+	record, hash := sealAuditRecord([]byte(`{"msg":"a"}`), auditGenesisHash)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, `{"msg":"a","prev_hash":"`+auditGenesisHash+`","hash":"`+hash+`"}`, string(record))
+	assert.NoError(t, verifyAuditRecord(record, auditGenesisHash))
+
+	record, _ = sealAuditRecord([]byte(`{}`), hash)
+	assert.True(t, strings.HasPrefix(string(record), `{"prev_hash":"`+hash+`","hash":"`), string(record))
+}
+
+func TestAuditHandler_Chain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeAudit(t, path, "login", "transfer")
+	// Reopening continues the chain of the existing file.
+	writeAudit(t, path, "logout")
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	assert.Len(t, lines, 3)
+
+	prev := auditGenesisHash
+	for i, line := range lines {
+		var record map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &record), line)
+		assert.Equal(t, "alice", record["user"])
+		assert.Equal(t, prev, record[AuditPrevHashKey], "record %d", i+1)
+		prev = record[AuditHashKey].(string)
+	}
+
+	n, err := VerifyAuditFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestVerifyAuditLog_Tampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeAudit(t, path, "first", "second", "third")
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.SplitAfter(string(data), "\n")[:3]
+
+	verify := func(log string) (int, error) {
+		return VerifyAuditLog(strings.NewReader(log))
+	}
+
+	n, err := verify(strings.Replace(string(data), "second", "Second", 1))
+	assert.EqualError(t, err, "audit record 2 does not match its hash")
+	assert.Equal(t, 1, n)
+
+	n, err = verify(lines[0] + lines[2])
+	assert.EqualError(t, err, "audit record 2 does not follow the previous record")
+	assert.Equal(t, 1, n)
+
+	_, err = verify(lines[1] + lines[0] + lines[2])
+	assert.EqualError(t, err, "audit record 1 does not follow the previous record")
+
+	_, err = verify(lines[0] + `{"msg":"forged"}` + "\n" + lines[1])
+	assert.EqualError(t, err, "audit record 2 is not sealed")
+
+	n, err = verify(lines[0] + strings.TrimSuffix(lines[1], "\n"))
+	assert.EqualError(t, err, "audit record 2 is incomplete")
+	assert.Equal(t, 1, n)
+
+	n, err = verify("")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestNewAuditHandler_UnsealedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"msg":"plain"}`+"\n"), 0o644))
+	_, err := NewAuditHandler(AuditHandlerOptions{CustomHandlerOptions: CustomHandlerOptions{Level: InfoLevel, Enabled: true}, File: path})
+	assert.ErrorContains(t, err, "failed to resume audit chain")
+}
+
+func TestAuditHandler_WithAttrsSharesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	handler, err := NewAuditHandler(AuditHandlerOptions{CustomHandlerOptions: CustomHandlerOptions{Level: InfoLevel, Enabled: true}, File: path})
+	assert.NoError(t, err)
+	logger := NewLogger(handler)
+	logger.Info("parent")
+	slog.New(handler.WithAttrs([]slog.Attr{slog.String("request_id", "r1")})).Info("child")
+	logger.Debug("skipped")
+	assert.NoError(t, handler.(*AuditHandler).Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	n, err := VerifyAuditLog(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestCreateHandler_Audit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	cfg, err := NewBuilder().Audit(path, Level(InfoLevel)).Config()
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	assert.IsType(t, &AuditHandler{}, handlers[0])
+	assert.NoError(t, handlers[0].(*AuditHandler).Close())
+
+	handler := &HandlerConfig{Type: AuditHandlerType, Level: InfoLevel}
+	assert.EqualError(t, validateHandler(handler), "audit handler requires a file")
+
+	handler = &HandlerConfig{Type: AuditHandlerType, Level: InfoLevel, File: path, SubType: TextHandlerSubType}
+	assert.EqualError(t, validateHandler(handler), "invalid audit handler subtype: text")
+}
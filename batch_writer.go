@@ -0,0 +1,130 @@
+package multilog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultFlushSize is the write buffer size of batched file handlers.
+const DefaultFlushSize = 64 << 10
+
+// sizeUnits maps size suffixes to their multipliers.
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a size such as "64KB", "1MB" or "4096" into bytes.
+// Units are binary (1KB = 1024 bytes); an empty size is zero.
+func ParseSize(size string) (int, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	if s == "" {
+		return 0, nil
+	}
+	multiplier := 1
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size: %s", size)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("size must be positive: %s", size)
+	}
+	return n * multiplier, nil
+}
+
+// newFileWriter returns the buffered writer of a file handler, sized to hold a
+// full batch when batching is enabled.
+func newFileWriter(w io.Writer, opts FileHandlerOptions) *bufio.Writer {
+	if opts.FlushSize > 0 {
+		return bufio.NewWriterSize(w, opts.FlushSize)
+	}
+	return bufio.NewWriter(w)
+}
+
+// flushesOnWrite reports whether records of the level are flushed as soon as
+// they are written. Batched handlers only flush error records right away.
+func (o *handlerOutput) flushesOnWrite(level slog.Level) bool {
+	return !o.batched || level >= slog.LevelError
+}
+
+// periodicFlusher flushes a handler on a background goroutine every interval.
+type periodicFlusher struct {
+	flusher Flusher
+	onError func(err error)
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// startBatching switches the handler to batched writes when opts.FlushSize is
+// set: records are flushed when the buffer fills, on error records, and every
+// flush interval. It returns the flusher to stop on close, or nil.
+func startBatching(h *CustomHandler, opts FileHandlerOptions) *periodicFlusher {
+	if opts.FlushSize <= 0 {
+		return nil
+	}
+	h.out.mu.Lock()
+	h.out.batched = true
+	h.out.mu.Unlock()
+	return newPeriodicFlusher(h, opts.FlushInterval, opts.OnError)
+}
+
+// newPeriodicFlusher starts flushing f every interval.
+func newPeriodicFlusher(f Flusher, interval time.Duration, onError func(err error)) *periodicFlusher {
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	p := &periodicFlusher{flusher: f, onError: onError, done: make(chan struct{})}
+	p.wg.Add(1)
+	go p.loop(interval)
+	return p
+}
+
+// loop flushes on every tick until stopped.
+func (p *periodicFlusher) loop(interval time.Duration) {
+	defer p.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.flusher.Flush(); err != nil {
+				p.onError(err)
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// Stop stops the background flushes. It is safe to call on a nil flusher.
+func (p *periodicFlusher) Stop() {
+	if p == nil {
+		return
+	}
+	p.once.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+	})
+}
@@ -0,0 +1,120 @@
+package multilog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"4096", 4096, false},
+		{"512B", 512, false},
+		{"64KB", 64 << 10, false},
+		{"64kb", 64 << 10, false},
+		{"1 MB", 1 << 20, false},
+		{"2GB", 2 << 30, false},
+		{"64XB", 0, true},
+		{"0KB", 0, true},
+		{"-1", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := ParseSize(tt.size)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFileFlushSize(t *testing.T) {
+	size, err := fileFlushSize(&HandlerConfig{Type: FileHandlerType})
+	assert.NoError(t, err)
+	assert.Zero(t, size)
+
+	size, err = fileFlushSize(&HandlerConfig{Type: FileHandlerType, FlushSize: "1MB"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1<<20, size)
+
+	size, err = fileFlushSize(&HandlerConfig{Type: FileHandlerType, FlushInterval: time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultFlushSize, size)
+
+	_, err = fileFlushSize(&HandlerConfig{Type: FileHandlerType, FlushSize: "big"})
+	assert.Error(t, err)
+}
+
+func TestFileHandler_BatchWrites(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewBuilder().
+		File(file, Pattern("[level] [msg]"), BatchWrites("64KB", time.Hour)).
+		Build()
+	assert.NoError(t, err)
+
+	logger.Info("buffered")
+	assert.Empty(t, readFile(t, file))
+
+	logger.Error("failed")
+	lines := strings.Split(strings.TrimSpace(readFile(t, file)), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "INFO buffered"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "ERROR failed"), lines[1])
+
+	logger.Info("pending")
+	assert.NoError(t, logger.Close())
+	lines = strings.Split(strings.TrimSpace(readFile(t, file)), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[2], "INFO pending"), lines[2])
+}
+
+func TestFileHandler_BatchWritesInterval(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	opts := FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   DebugLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+		},
+		File:          file,
+		FlushSize:     DefaultFlushSize,
+		FlushInterval: 10 * time.Millisecond,
+	}
+	handler, err := NewJSONHandler(opts, nil)
+	assert.NoError(t, err)
+	logger := NewLogger(handler)
+
+	logger.Info("tick")
+	assert.Eventually(t, func() bool {
+		return strings.Contains(readFile(t, file), `"msg":"tick"`)
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, logger.Close())
+}
+
+func TestPeriodicFlusher_Stop(t *testing.T) {
+	var p *periodicFlusher
+	p.Stop()
+
+	p = newPeriodicFlusher(&CustomHandler{out: &handlerOutput{}}, time.Millisecond, nil)
+	p.Stop()
+	p.Stop()
+}
+
+func readFile(t *testing.T, file string) string {
+	t.Helper()
+	content, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return ""
+	}
+	assert.NoError(t, err)
+	return string(content)
+}
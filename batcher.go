@@ -0,0 +1,257 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default batching settings
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 5 * time.Second
+)
+
+// BatchOptions are the batching, buffering and retry settings of the handlers
+// that send records over the network.
+type BatchOptions struct {
+	// Retryable reports whether a failed send should be retried.
+	// DefaultRetryable is used if nil.
+	Retryable       func(err error) bool
+	BatchSize       int
+	MaxBufferSize   int
+	MaxRetries      int
+	FlushInterval   time.Duration
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	RetryJitter     bool
+}
+
+// sendReport passes the result of writing a record to the wrapper handlers
+// that registered a callback on it, such as the circuit breaker and the dead
+// letter. Batching handlers defer the report until the batch holding the
+// record is sent; for other handlers the result is what Handle returns.
+type sendReport struct {
+	callbacks []func(err error)
+	deferred  atomic.Bool
+}
+
+type sendReportKey struct{}
+
+// withSendReport registers the callback on the report of the record being
+// handled, adding a report to the context if it has none.
+func withSendReport(ctx context.Context, callback func(err error)) (context.Context, *sendReport) {
+	report, ok := ctx.Value(sendReportKey{}).(*sendReport)
+	if !ok {
+		report = &sendReport{}
+		ctx = context.WithValue(ctx, sendReportKey{}, report)
+	}
+	report.callbacks = append(report.callbacks, callback)
+	return ctx, report
+}
+
+// done calls the callbacks with the result of writing the record.
+func (r *sendReport) done(err error) {
+	for _, callback := range r.callbacks {
+		callback(err)
+	}
+}
+
+// batcher accumulates items and sends them in batches, either when the batch
+// is full or when the flush interval elapses. Sends happen on a background
+// goroutine so logging calls never block on the network.
+type batcher[T any] struct {
+	send     func(items []T) error
+	onError  func(err error)
+	items    []T
+	reports  []*sendReport
+	flushCh  chan struct{}
+	done     chan struct{}
+	wg       sync.WaitGroup
+	size     int
+	maxItems int
+	dropped  int
+	mu       sync.Mutex
+	sendMu   sync.Mutex
+	closed   bool
+}
+
+// batchError is returned by send functions that sent some of the items of a
+// batch, with the indexes of the items that were not sent.
+type batchError struct {
+	err    error
+	failed []int
+}
+
+func (e *batchError) Error() string {
+	return e.err.Error()
+}
+
+func (e *batchError) Unwrap() error {
+	return e.err
+}
+
+// newBatcher creates a batcher and starts its background flush loop.
+// maxItems bounds the number of buffered items; zero means unbounded.
+func newBatcher[T any](
+	size int,
+	interval time.Duration,
+	maxItems int,
+	send func(items []T) error,
+	onError func(err error),
+) *batcher[T] {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	b := &batcher[T]{
+		send:     send,
+		onError:  onError,
+		size:     size,
+		maxItems: maxItems,
+		flushCh:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.loop(interval)
+	return b
+}
+
+// add queues an item, triggering a background flush when the batch is full.
+// The send report in the context, if any, is deferred until the item is sent
+// or dropped.
+func (b *batcher[T]) add(ctx context.Context, item T) error {
+	report, _ := ctx.Value(sendReportKey{}).(*sendReport)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return fmt.Errorf("batcher is closed")
+	}
+	var dropped *sendReport
+	if b.maxItems > 0 && len(b.items) >= b.maxItems {
+		dropped = b.reports[0]
+		b.items, b.reports = b.items[1:], b.reports[1:]
+		b.dropped++
+	}
+	if report != nil {
+		report.deferred.Store(true)
+	}
+	b.items, b.reports = append(b.items, item), append(b.reports, report)
+	if len(b.items) >= b.size {
+		select {
+		case b.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	b.mu.Unlock()
+
+	if dropped != nil {
+		dropped.done(fmt.Errorf("dropped because the buffer was full"))
+	}
+	return nil
+}
+
+// Flush sends all queued items synchronously, in batches of at most size
+// items. A batch that fails to send does not stop the others; the errors of
+// the failed batches are joined, each with the number of items it lost, which
+// is all of them unless the send function returns a batchError.
+func (b *batcher[T]) Flush() error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	b.mu.Lock()
+	items, reports := b.items, b.reports
+	b.items, b.reports = nil, nil
+	b.mu.Unlock()
+
+	var errs []error
+	for len(items) > 0 {
+		n := min(len(items), b.size)
+		err := b.send(items[:n])
+		if err != nil {
+			var partial *batchError
+			if errors.As(err, &partial) {
+				err = fmt.Errorf("failed to send %d of batch of %d: %w", len(partial.failed), n, err)
+			} else {
+				err = fmt.Errorf("failed to send batch of %d: %w", n, err)
+			}
+			errs = append(errs, err)
+		}
+		reportBatch(reports[:n], err)
+		items, reports = items[n:], reports[n:]
+	}
+	return errors.Join(errs...)
+}
+
+// reportBatch passes the result of sending a batch to the reports of its
+// items. Only the items listed by a batchError are reported as failed.
+func reportBatch(reports []*sendReport, err error) {
+	var partial *batchError
+	errors.As(err, &partial)
+	for i, report := range reports {
+		if report == nil {
+			continue
+		}
+		if err != nil && (partial == nil || slices.Contains(partial.failed, i)) {
+			report.done(err)
+		} else {
+			report.done(nil)
+		}
+	}
+}
+
+// Close stops the background loop and flushes the remaining items.
+func (b *batcher[T]) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.done)
+	b.wg.Wait()
+	return b.Flush()
+}
+
+// Dropped returns the number of items dropped because the buffer was full.
+func (b *batcher[T]) Dropped() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+func (b *batcher[T]) loop(interval time.Duration) {
+	defer b.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.flushCh:
+		case <-b.done:
+			return
+		}
+		if err := b.Flush(); err != nil {
+			b.onError(err)
+		}
+	}
+}
+
+// defaultErrorHandler reports asynchronous errors on stderr.
+func defaultErrorHandler(err error) {
+	fmt.Fprintf(os.Stderr, "multilog: %v\n", err)
+}
@@ -0,0 +1,130 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type batchRecorder struct {
+	batches [][]int
+	mu      sync.Mutex
+}
+
+func (r *batchRecorder) send(items []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, append([]int{}, items...))
+	return nil
+}
+
+func (r *batchRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	total := 0
+	for _, b := range r.batches {
+		total += len(b)
+	}
+	return total
+}
+
+func TestBatcher_FlushOnSize(t *testing.T) {
+	rec := &batchRecorder{}
+	b := newBatcher(2, time.Hour, 0, rec.send, nil)
+	defer b.Close()
+
+	assert.NoError(t, b.add(context.Background(), 1))
+	assert.NoError(t, b.add(context.Background(), 2))
+
+	assert.Eventually(t, func() bool { return rec.count() == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestBatcher_FlushOnInterval(t *testing.T) {
+	rec := &batchRecorder{}
+	b := newBatcher(100, 10*time.Millisecond, 0, rec.send, nil)
+	defer b.Close()
+
+	assert.NoError(t, b.add(context.Background(), 1))
+
+	assert.Eventually(t, func() bool { return rec.count() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestBatcher_FlushSplitsBatches(t *testing.T) {
+	rec := &batchRecorder{}
+	b := newBatcher(100, time.Hour, 0, rec.send, nil)
+	b.size = 2
+	for i := range 5 {
+		b.items, b.reports = append(b.items, i), append(b.reports, nil)
+	}
+
+	assert.NoError(t, b.Flush())
+	assert.Equal(t, [][]int{{0, 1}, {2, 3}, {4}}, rec.batches)
+	assert.NoError(t, b.Close())
+}
+
+func TestBatcher_FlushContinuesAfterFailure(t *testing.T) {
+	rec := &batchRecorder{}
+	b := newBatcher(100, time.Hour, 0, func(items []int) error {
+		if items[0] == 2 {
+			return errors.New("send failed")
+		}
+		return rec.send(items)
+	}, nil)
+	b.size = 2
+	for i := range 5 {
+		b.items, b.reports = append(b.items, i), append(b.reports, nil)
+	}
+
+	assert.EqualError(t, b.Flush(), "failed to send batch of 2: send failed")
+	assert.Equal(t, [][]int{{0, 1}, {4}}, rec.batches)
+	assert.NoError(t, b.Close())
+}
+
+func TestBatcher_CloseFlushesAndRejects(t *testing.T) {
+	rec := &batchRecorder{}
+	b := newBatcher(100, time.Hour, 0, rec.send, nil)
+
+	assert.NoError(t, b.add(context.Background(), 1))
+	assert.NoError(t, b.Close())
+	assert.Equal(t, 1, rec.count())
+
+	assert.Error(t, b.add(context.Background(), 2))
+	assert.NoError(t, b.Close())
+}
+
+func TestBatcher_MaxItemsDropsOldest(t *testing.T) {
+	rec := &batchRecorder{}
+	b := newBatcher(100, time.Hour, 2, rec.send, nil)
+
+	for i := range 4 {
+		assert.NoError(t, b.add(context.Background(), i))
+	}
+	assert.Equal(t, 2, b.Dropped())
+	assert.NoError(t, b.Close())
+	assert.Equal(t, [][]int{{2, 3}}, rec.batches)
+}
+
+func TestBatcher_ReportsAsyncErrors(t *testing.T) {
+	errCh := make(chan error, 1)
+	b := newBatcher(1, time.Hour, 0, func(_ []int) error {
+		return errors.New("send failed")
+	}, func(err error) {
+		select {
+		case errCh <- err:
+		default:
+		}
+	})
+
+	assert.NoError(t, b.add(context.Background(), 1))
+	select {
+	case err := <-errCh:
+		assert.EqualError(t, err, "failed to send batch of 1: send failed")
+	case <-time.After(time.Second):
+		t.Fatal("expected async error")
+	}
+	_ = b.Close()
+}
@@ -0,0 +1,8 @@
+// Package benchmarks holds the benchmarks of the logging hot path: the console,
+// file and JSON handlers, Aggregator fan-out, WithAttrs chains and perf-level
+// logging. Allocation budgets of the hot path are asserted by its tests, so
+// changes adding allocations fail "go test ./..." instead of regressing
+// silently. Run the benchmarks with:
+//
+//	go test ./benchmarks -run XXX -bench . -benchmem
+package benchmarks
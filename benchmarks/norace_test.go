@@ -0,0 +1,7 @@
+//go:build !race
+
+package benchmarks
+
+// raceEnabled reports whether the race detector is on, which adds allocations
+// and makes sync.Pool drop buffers.
+const raceEnabled = false
@@ -0,0 +1,369 @@
+package multilog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Binary subtypes, encoding the JSON document of each record as MessagePack
+// or CBOR. Records are written back to back without a delimiter.
+const (
+	MsgpackHandlerSubType = "msgpack"
+	CBORHandlerSubType    = "cbor"
+)
+
+// Content types of binary HTTP payloads
+const (
+	ContentTypeMsgpack = "application/msgpack"
+	ContentTypeCBOR    = "application/cbor"
+)
+
+// BinarySubTypes lists the binary subtypes.
+var BinarySubTypes = []string{MsgpackHandlerSubType, CBORHandlerSubType}
+
+// lineDelimited reports whether records of the subtype are written as lines.
+func lineDelimited(subType string) bool {
+	return newBinaryFormat(subType) == nil && subType != ProtobufHandlerSubType
+}
+
+// binaryHandlerTypes lists the handler types supporting the binary subtypes.
+var binaryHandlerTypes = []string{FileHandlerType, SocketHandlerType, HTTPHandlerType, KafkaHandlerType}
+
+// binaryFormat writes the headers and scalars of a binary encoding.
+type binaryFormat interface {
+	appendNil(buf []byte) []byte
+	appendBool(buf []byte, b bool) []byte
+	appendInt(buf []byte, i int64) []byte
+	appendUint(buf []byte, u uint64) []byte
+	appendFloat(buf []byte, f float64) []byte
+	appendString(buf []byte, s string) []byte
+	appendArrayHeader(buf []byte, n int) []byte
+	appendMapHeader(buf []byte, n int) []byte
+}
+
+// newBinaryFormat returns the format of the subtype, or nil for text subtypes.
+func newBinaryFormat(subType string) binaryFormat {
+	switch subType {
+	case MsgpackHandlerSubType:
+		return msgpackFormat{}
+	case CBORHandlerSubType:
+		return cborFormat{}
+	default:
+		return nil
+	}
+}
+
+// msgpackFormat is the MessagePack encoding.
+type msgpackFormat struct{}
+
+func (msgpackFormat) appendNil(buf []byte) []byte {
+	return append(buf, 0xc0)
+}
+
+func (msgpackFormat) appendBool(buf []byte, b bool) []byte {
+	if b {
+		return append(buf, 0xc3)
+	}
+	return append(buf, 0xc2)
+}
+
+func (f msgpackFormat) appendInt(buf []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return f.appendUint(buf, uint64(i))
+	case i >= -32:
+		return append(buf, byte(i))
+	case i >= math.MinInt8:
+		return append(buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(i))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(i))
+	}
+}
+
+func (msgpackFormat) appendUint(buf []byte, u uint64) []byte {
+	switch {
+	case u < 0x80:
+		return append(buf, byte(u))
+	case u <= math.MaxUint8:
+		return append(buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(u))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcf), u)
+	}
+}
+
+func (msgpackFormat) appendFloat(buf []byte, f float64) []byte {
+	return binary.BigEndian.AppendUint64(append(buf, 0xcb), math.Float64bits(f))
+}
+
+func (msgpackFormat) appendString(buf []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+func (msgpackFormat) appendArrayHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdd), uint32(n))
+	}
+}
+
+func (msgpackFormat) appendMapHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdf), uint32(n))
+	}
+}
+
+// CBOR major types
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborSimple = 7
+)
+
+// cborFormat is the CBOR encoding (RFC 8949).
+type cborFormat struct{}
+
+// appendHead appends the initial byte and argument of a data item.
+func (cborFormat) appendHead(buf []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major|27), n)
+	}
+}
+
+func (cborFormat) appendNil(buf []byte) []byte {
+	return append(buf, 0xf6)
+}
+
+func (cborFormat) appendBool(buf []byte, b bool) []byte {
+	if b {
+		return append(buf, 0xf5)
+	}
+	return append(buf, 0xf4)
+}
+
+func (f cborFormat) appendInt(buf []byte, i int64) []byte {
+	if i >= 0 {
+		return f.appendHead(buf, cborUint, uint64(i))
+	}
+	return f.appendHead(buf, cborNegint, uint64(-1-i))
+}
+
+func (f cborFormat) appendUint(buf []byte, u uint64) []byte {
+	return f.appendHead(buf, cborUint, u)
+}
+
+func (cborFormat) appendFloat(buf []byte, f float64) []byte {
+	return binary.BigEndian.AppendUint64(append(buf, 0xfb), math.Float64bits(f))
+}
+
+func (f cborFormat) appendString(buf []byte, s string) []byte {
+	return append(f.appendHead(buf, cborText, uint64(len(s))), s...)
+}
+
+func (f cborFormat) appendArrayHeader(buf []byte, n int) []byte {
+	return f.appendHead(buf, cborArray, uint64(n))
+}
+
+func (f cborFormat) appendMapHeader(buf []byte, n int) []byte {
+	return f.appendHead(buf, cborMap, uint64(n))
+}
+
+// appendBinary appends the JSON document transcoded to the binary format,
+// keeping the order of object members. Numbers without a fraction or
+// exponent are encoded as integers.
+func appendBinary(buf []byte, format binaryFormat, doc []byte) ([]byte, error) {
+	t := transcoder{format: format, doc: doc}
+	buf, err := t.value(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %w", err)
+	}
+	return buf, nil
+}
+
+// transcoder converts a JSON document to a binary format in a single pass.
+type transcoder struct {
+	format binaryFormat
+	doc    []byte
+	pos    int
+}
+
+func (t *transcoder) skipSpace() {
+	for t.pos < len(t.doc) {
+		switch t.doc[t.pos] {
+		case ' ', '\t', '\n', '\r':
+			t.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (t *transcoder) value(buf []byte) ([]byte, error) {
+	t.skipSpace()
+	if t.pos >= len(t.doc) {
+		return buf, fmt.Errorf("unexpected end of JSON at offset %d", t.pos)
+	}
+	switch c := t.doc[t.pos]; {
+	case c == '{':
+		return t.container(buf, '}', t.format.appendMapHeader)
+	case c == '[':
+		return t.container(buf, ']', t.format.appendArrayHeader)
+	case c == '"':
+		s, err := t.string()
+		return t.format.appendString(buf, s), err
+	case c == 't':
+		return t.literal(buf, "true", t.format.appendBool(buf, true))
+	case c == 'f':
+		return t.literal(buf, "false", t.format.appendBool(buf, false))
+	case c == 'n':
+		return t.literal(buf, "null", t.format.appendNil(buf))
+	default:
+		return t.number(buf)
+	}
+}
+
+func (t *transcoder) literal(buf []byte, literal string, encoded []byte) ([]byte, error) {
+	if len(t.doc)-t.pos < len(literal) || string(t.doc[t.pos:t.pos+len(literal)]) != literal {
+		return buf, fmt.Errorf("invalid JSON literal at offset %d", t.pos)
+	}
+	t.pos += len(literal)
+	return encoded, nil
+}
+
+// container encodes an object or array. The header holds the number of
+// elements, so it is inserted once they have been encoded.
+func (t *transcoder) container(buf []byte, end byte, header func(buf []byte, n int) []byte) ([]byte, error) {
+	t.pos++
+	start, n := len(buf), 0
+	var err error
+	for {
+		t.skipSpace()
+		if t.pos < len(t.doc) && t.doc[t.pos] == end {
+			t.pos++
+			break
+		}
+		if n > 0 {
+			if t.pos >= len(t.doc) || t.doc[t.pos] != ',' {
+				return buf, fmt.Errorf("expected ',' at offset %d", t.pos)
+			}
+			t.pos++
+			t.skipSpace()
+		}
+		if end == '}' {
+			if t.pos >= len(t.doc) || t.doc[t.pos] != '"' {
+				return buf, fmt.Errorf("expected object key at offset %d", t.pos)
+			}
+			var key string
+			if key, err = t.string(); err != nil {
+				return buf, err
+			}
+			buf = t.format.appendString(buf, key)
+			t.skipSpace()
+			if t.pos >= len(t.doc) || t.doc[t.pos] != ':' {
+				return buf, fmt.Errorf("expected ':' at offset %d", t.pos)
+			}
+			t.pos++
+		}
+		if buf, err = t.value(buf); err != nil {
+			return buf, err
+		}
+		n++
+	}
+	return slices.Insert(buf, start, header(nil, n)...), nil
+}
+
+// string decodes the JSON string at the current offset.
+func (t *transcoder) string() (string, error) {
+	start := t.pos
+	escaped := false
+	for t.pos++; t.pos < len(t.doc); t.pos++ {
+		switch t.doc[t.pos] {
+		case '\\':
+			escaped = true
+			t.pos++
+		case '"':
+			t.pos++
+			if !escaped {
+				return string(t.doc[start+1 : t.pos-1]), nil
+			}
+			var s string
+			if err := json.Unmarshal(t.doc[start:t.pos], &s); err != nil {
+				return "", err
+			}
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unterminated JSON string at offset %d", start)
+}
+
+func (t *transcoder) number(buf []byte) ([]byte, error) {
+	start := t.pos
+	float := false
+	for ; t.pos < len(t.doc); t.pos++ {
+		c := t.doc[t.pos]
+		if c == '.' || c == 'e' || c == 'E' {
+			float = true
+		} else if (c < '0' || c > '9') && c != '-' && c != '+' {
+			break
+		}
+	}
+	s := string(t.doc[start:t.pos])
+	if !float {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return t.format.appendInt(buf, i), nil
+		}
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return t.format.appendUint(buf, u), nil
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return buf, fmt.Errorf("invalid JSON number %q at offset %d", s, start)
+	}
+	return t.format.appendFloat(buf, f), nil
+}
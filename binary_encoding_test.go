@@ -0,0 +1,196 @@
+package multilog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendBinary_Msgpack(t *testing.T) {
+	buf, err := appendBinary(nil, msgpackFormat{}, []byte(`{"a":1,"b":[true,null],"c":"x"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x83, 0xa1, 'a', 0x01, 0xa1, 'b', 0x92, 0xc3, 0xc0, 0xa1, 'c', 0xa1, 'x'}, buf)
+}
+
+func TestAppendBinary_CBOR(t *testing.T) {
+	buf, err := appendBinary(nil, cborFormat{}, []byte(`{"a":-1,"b":[false,null],"c":1000}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xa3, 0x61, 'a', 0x20, 0x61, 'b', 0x82, 0xf4, 0xf6, 0x61, 'c', 0x19, 0x03, 0xe8}, buf)
+}
+
+func TestAppendBinary_RoundTrip(t *testing.T) {
+	doc := `{"msg":"line\nbreak \"quoted\" é","n":-200,"big":18446744073709551615,"f":1.5,` +
+		`"e":1e3,"nested":{"list":[1,-40000,"s",{}],"empty":[]},"t":true,"z":null}`
+	expected := map[string]any{
+		"msg": "line\nbreak \"quoted\" é",
+		"n":   int64(-200),
+		"big": uint64(18446744073709551615),
+		"f":   1.5,
+		"e":   1000.0,
+		"nested": map[string]any{
+			"list":  []any{int64(1), int64(-40000), "s", map[string]any{}},
+			"empty": []any{},
+		},
+		"t": true,
+		"z": nil,
+	}
+	for _, subType := range BinarySubTypes {
+		t.Run(subType, func(t *testing.T) {
+			buf, err := appendBinary(nil, newBinaryFormat(subType), []byte(doc))
+			assert.NoError(t, err)
+			reader, err := NewBinaryReader(bytes.NewReader(buf), subType)
+			assert.NoError(t, err)
+			record, err := reader.Next()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, record)
+		})
+	}
+}
+
+func TestAppendBinary_InvalidJSON(t *testing.T) {
+	for _, doc := range []string{`{"a":1`, `{"a" 1}`, `{"a":tru}`, `{"a":"x}`, `{"a":1 "b":2}`, `{"a":-}`} {
+		_, err := appendBinary(nil, msgpackFormat{}, []byte(doc))
+		assert.ErrorContains(t, err, "failed to encode record", doc)
+	}
+}
+
+func TestJSONHandler_BinaryFile(t *testing.T) {
+	for _, subType := range BinarySubTypes {
+		t.Run(subType, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: file
+      subtype: ` + subType + `
+      level: info
+      enabled: true
+      file: ` + path))
+			assert.NoError(t, err)
+			handlers, err := CreateHandlers(cfg)
+			assert.NoError(t, err)
+
+			logger := slog.New(handlers[0])
+			logger.Info("first", "user", "alice")
+			logger.WithGroup("req").Warn("second", "status", 503)
+			assert.NoError(t, handlers[0].(*JSONHandler).Close())
+
+			f, err := os.Open(path)
+			assert.NoError(t, err)
+			defer f.Close()
+			reader, err := NewBinaryReader(f, subType)
+			assert.NoError(t, err)
+
+			record, err := reader.Next()
+			assert.NoError(t, err)
+			assert.Equal(t, "first", record["msg"])
+			assert.Equal(t, "alice", record["user"])
+			record, err = reader.Next()
+			assert.NoError(t, err)
+			assert.Equal(t, "WARN", record["level"])
+			assert.Equal(t, map[string]any{"status": int64(503)}, record["req"])
+			_, err = reader.Next()
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestHTTPHandler_Msgpack(t *testing.T) {
+	server := newIntakeServer(t)
+	handler, err := NewHTTPHandler(HTTPHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			SubType: MsgpackHandlerSubType,
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		URL: server.URL,
+	})
+	assert.NoError(t, err)
+
+	logger := slog.New(handler)
+	logger.Info("one")
+	logger.Info("two")
+	assert.NoError(t, handler.(*HTTPHandler).Close())
+
+	assert.Len(t, server.requests, 1)
+	req := server.requests[0]
+	assert.Equal(t, ContentTypeMsgpack, req.header.Get("Content-Type"))
+	assert.Equal(t, byte(0x92), req.body[0], "batch is a two element array")
+	reader, err := NewBinaryReader(bytes.NewReader([]byte(req.body[1:])), MsgpackHandlerSubType)
+	assert.NoError(t, err)
+	for _, msg := range []string{"one", "two"} {
+		record, err := reader.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, msg, record["msg"])
+	}
+}
+
+func TestSocketHandler_CBOR(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	records := make(chan map[string]any, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader, _ := NewBinaryReader(conn, CBORHandlerSubType)
+		for {
+			record, err := reader.Next()
+			if err != nil {
+				return
+			}
+			records <- record
+		}
+	}()
+
+	handler, err := NewSocketHandler(SocketHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			SubType: CBORHandlerSubType,
+		},
+		Address: listener.Addr().String(),
+	})
+	assert.NoError(t, err)
+	defer handler.(*SocketHandler).Close()
+
+	for _, msg := range []string{"first", "second"} {
+		assert.NoError(t, handler.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)))
+	}
+	for _, msg := range []string{"first", "second"} {
+		select {
+		case record := <-records:
+			assert.Equal(t, msg, record["msg"])
+		case <-time.After(time.Second):
+			t.Fatal("expected a record")
+		}
+	}
+}
+
+func TestValidateHandler_BinarySubType(t *testing.T) {
+	handler := &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log", SubType: MsgpackHandlerSubType}
+	assert.NoError(t, validateHandler(handler))
+
+	handler = &HandlerConfig{Type: HTTPHandlerType, Level: InfoLevel, URL: "http://x", SubType: CBORHandlerSubType}
+	assert.NoError(t, validateHandler(handler))
+
+	handler = &HandlerConfig{Type: ConsoleHandlerType, Level: InfoLevel, SubType: MsgpackHandlerSubType}
+	assert.EqualError(t, validateHandler(handler), "invalid console handler subtype: msgpack")
+
+	handler = &HandlerConfig{Type: ESHandlerType, Level: InfoLevel, URL: "http://x", Index: "logs", SubType: "cbor"}
+	assert.EqualError(t, validateHandler(handler), "cbor subtype requires a file, socket, http or kafka handler")
+}
@@ -0,0 +1,302 @@
+package multilog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// maxBinaryLength bounds the length of decoded strings, arrays and maps, so
+// that a corrupt length does not allocate unbounded memory.
+const maxBinaryLength = 64 << 20
+
+// BinaryReader decodes the records written by handlers with a binary subtype.
+type BinaryReader struct {
+	r      *bufio.Reader
+	decode func(r *bufio.Reader) (any, error)
+}
+
+// NewBinaryReader creates a reader for records encoded with the subtype,
+// "msgpack" or "cbor".
+func NewBinaryReader(r io.Reader, subType string) (*BinaryReader, error) {
+	br := &BinaryReader{r: bufio.NewReader(r)}
+	switch subType {
+	case MsgpackHandlerSubType:
+		br.decode = decodeMsgpack
+	case CBORHandlerSubType:
+		br.decode = decodeCBOR
+	default:
+		return nil, fmt.Errorf("unknown binary subtype: %s", subType)
+	}
+	return br, nil
+}
+
+// Next decodes the next record. Integers are decoded as int64, or uint64 if
+// they do not fit, floats as float64, arrays as []any and maps as
+// map[string]any. It returns io.EOF when there are no more records.
+func (br *BinaryReader) Next() (map[string]any, error) {
+	if _, err := br.r.Peek(1); err != nil {
+		return nil, err
+	}
+	v, err := br.decode(br.r)
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode record: %w", err)
+	}
+	record, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("failed to decode record: expected a map, got %T", v)
+	}
+	return record, nil
+}
+
+// readUint reads a big-endian unsigned integer of n bytes.
+func readUint(r *bufio.Reader, n int) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[8-n:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+// readString reads n bytes as a string.
+func readString(r *bufio.Reader, n uint64) (string, error) {
+	if n > maxBinaryLength {
+		return "", fmt.Errorf("length %d exceeds limit", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// decodeItems decodes n values, or n key-value pairs for a map.
+func decodeItems(r *bufio.Reader, n uint64, isMap bool, decode func(r *bufio.Reader) (any, error)) (any, error) {
+	if n > maxBinaryLength {
+		return nil, fmt.Errorf("length %d exceeds limit", n)
+	}
+	if !isMap {
+		items := make([]any, 0, min(n, 1024))
+		for range n {
+			v, err := decode(r)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	}
+	m := make(map[string]any, min(n, 1024))
+	for range n {
+		k, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key is %T, not a string", k)
+		}
+		if m[key], err = decode(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// intValue returns u as an int64 if it fits.
+func intValue(u uint64) any {
+	if u <= math.MaxInt64 {
+		return int64(u)
+	}
+	return u
+}
+
+// msgpackSizes holds the length in bytes of the argument of each type.
+var msgpackSizes = map[byte]int{
+	0xc4: 1, 0xc5: 2, 0xc6: 4, 0xca: 4, 0xcb: 8, 0xcc: 1, 0xcd: 2, 0xce: 4, 0xcf: 8,
+	0xd0: 1, 0xd1: 2, 0xd2: 4, 0xd3: 8, 0xd9: 1, 0xda: 2, 0xdb: 4,
+	0xdc: 2, 0xdd: 4, 0xde: 2, 0xdf: 4,
+}
+
+// decodeMsgpack decodes a MessagePack value.
+func decodeMsgpack(r *bufio.Reader) (any, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if v, ok, err := decodeMsgpackFixed(r, c); ok || err != nil {
+		return v, err
+	}
+	size, ok := msgpackSizes[c]
+	if !ok {
+		return nil, fmt.Errorf("unsupported msgpack type 0x%02x", c)
+	}
+	n, err := readUint(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return decodeMsgpackSized(r, c, n)
+}
+
+// decodeMsgpackFixed decodes a value of a type without an argument, whose
+// value or length is held by the type byte c. It reports false for other types.
+func decodeMsgpackFixed(r *bufio.Reader, c byte) (any, bool, error) {
+	var v any
+	var err error
+	switch {
+	case c < 0x80:
+		v = int64(c)
+	case c >= 0xe0:
+		v = int64(int8(c))
+	case c&0xf0 == 0x80:
+		v, err = decodeItems(r, uint64(c&0x0f), true, decodeMsgpack)
+	case c&0xf0 == 0x90:
+		v, err = decodeItems(r, uint64(c&0x0f), false, decodeMsgpack)
+	case c&0xe0 == 0xa0:
+		v, err = readString(r, uint64(c&0x1f))
+	case c == 0xc0:
+		v = nil
+	case c == 0xc2:
+		v = false
+	case c == 0xc3:
+		v = true
+	default:
+		return nil, false, nil
+	}
+	return v, true, err
+}
+
+// decodeMsgpackSized decodes a value of type c whose argument n was read.
+func decodeMsgpackSized(r *bufio.Reader, c byte, n uint64) (any, error) {
+	switch c {
+	case 0xc4, 0xc5, 0xc6:
+		s, err := readString(r, n)
+		return []byte(s), err
+	case 0xca:
+		return float64(math.Float32frombits(uint32(n))), nil
+	case 0xcb:
+		return math.Float64frombits(n), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return intValue(n), nil
+	case 0xd0:
+		return int64(int8(n)), nil
+	case 0xd1:
+		return int64(int16(n)), nil
+	case 0xd2:
+		return int64(int32(n)), nil
+	case 0xd3:
+		return int64(n), nil
+	case 0xd9, 0xda, 0xdb:
+		return readString(r, n)
+	case 0xdc, 0xdd:
+		return decodeItems(r, n, false, decodeMsgpack)
+	default:
+		return decodeItems(r, n, true, decodeMsgpack)
+	}
+}
+
+// decodeCBOR decodes a CBOR value. Indefinite lengths and tags other than
+// those of the argument are not supported.
+func decodeCBOR(r *bufio.Reader) (any, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	major, info := c>>5, c&0x1f
+	if major == cborSimple {
+		return decodeCBORSimple(r, info)
+	}
+	n, err := readCBORArgument(r, info)
+	if err != nil {
+		return nil, err
+	}
+	return decodeCBORValue(r, major, n)
+}
+
+// decodeCBORSimple decodes a simple value or float of additional information info.
+func decodeCBORSimple(r *bufio.Reader, info byte) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		n, err := readUint(r, 2)
+		return float16(uint16(n)), err
+	case 26:
+		n, err := readUint(r, 4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 27:
+		n, err := readUint(r, 8)
+		return math.Float64frombits(n), err
+	default:
+		return nil, fmt.Errorf("unsupported cbor simple value %d", info)
+	}
+}
+
+// readCBORArgument reads the argument of additional information info, held
+// by info itself below 24 and by the following 1, 2, 4 or 8 bytes otherwise.
+func readCBORArgument(r *bufio.Reader, info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		return readUint(r, 1<<(info-24))
+	default:
+		return 0, fmt.Errorf("unsupported cbor argument %d", info)
+	}
+}
+
+// decodeCBORValue decodes a value of the major type whose argument n was read.
+func decodeCBORValue(r *bufio.Reader, major byte, n uint64) (any, error) {
+	switch major {
+	case cborUint:
+		return intValue(n), nil
+	case cborNegint:
+		if n <= math.MaxInt64 {
+			return -1 - int64(n), nil
+		}
+		return nil, fmt.Errorf("cbor negative integer out of range")
+	case cborBytes:
+		s, err := readString(r, n)
+		return []byte(s), err
+	case cborText:
+		return readString(r, n)
+	case cborArray:
+		return decodeItems(r, n, false, decodeCBOR)
+	case cborMap:
+		return decodeItems(r, n, true, decodeCBOR)
+	default:
+		return nil, fmt.Errorf("unsupported cbor tag")
+	}
+}
+
+// float16 decodes an IEEE 754 half-precision float.
+func float16(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		f = math.Inf(1)
+		if mant != 0 {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
@@ -0,0 +1,100 @@
+package multilog
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBinaryReader_UnknownSubType(t *testing.T) {
+	_, err := NewBinaryReader(bytes.NewReader(nil), JSONHandlerSubType)
+	assert.EqualError(t, err, "unknown binary subtype: json")
+}
+
+func TestBinaryReader_Msgpack(t *testing.T) {
+	data := []byte{
+		0x86,
+		0xa1, 'a', 0xcc, 0xff,
+		0xa1, 'b', 0xd1, 0xfc, 0x18,
+		0xa1, 'c', 0xca, 0x3f, 0xc0, 0x00, 0x00,
+		0xa1, 'd', 0xc4, 0x02, 0x01, 0x02,
+		0xa1, 'e', 0xd9, 0x01, 'x',
+		0xa1, 'f', 0xdc, 0x00, 0x01, 0xff,
+	}
+	reader, err := NewBinaryReader(bytes.NewReader(data), MsgpackHandlerSubType)
+	assert.NoError(t, err)
+	record, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"a": int64(255),
+		"b": int64(-1000),
+		"c": 1.5,
+		"d": []byte{1, 2},
+		"e": "x",
+		"f": []any{int64(-1)},
+	}, record)
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestBinaryReader_CBOR(t *testing.T) {
+	data := []byte{
+		0xa5,
+		0x61, 'a', 0x39, 0x03, 0xe7,
+		0x61, 'b', 0xf9, 0x3e, 0x00,
+		0x61, 'c', 0xfa, 0x3f, 0xc0, 0x00, 0x00,
+		0x61, 'd', 0x42, 0x01, 0x02,
+		0x61, 'e', 0xf7,
+	}
+	reader, err := NewBinaryReader(bytes.NewReader(data), CBORHandlerSubType)
+	assert.NoError(t, err)
+	record, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"a": int64(-1000),
+		"b": 1.5,
+		"c": 1.5,
+		"d": []byte{1, 2},
+		"e": nil,
+	}, record)
+}
+
+func TestBinaryReader_Errors(t *testing.T) {
+	reader, _ := NewBinaryReader(bytes.NewReader([]byte{0x82, 0xa1, 'a'}), MsgpackHandlerSubType)
+	_, err := reader.Next()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	reader, _ = NewBinaryReader(bytes.NewReader([]byte{0x91, 0x01}), MsgpackHandlerSubType)
+	_, err = reader.Next()
+	assert.EqualError(t, err, "failed to decode record: expected a map, got []interface {}")
+
+	reader, _ = NewBinaryReader(bytes.NewReader([]byte{0x81, 0x01, 0x01}), MsgpackHandlerSubType)
+	_, err = reader.Next()
+	assert.ErrorContains(t, err, "map key is int64")
+
+	reader, _ = NewBinaryReader(bytes.NewReader([]byte{0xc1}), MsgpackHandlerSubType)
+	_, err = reader.Next()
+	assert.ErrorContains(t, err, "unsupported msgpack type 0xc1")
+
+	reader, _ = NewBinaryReader(bytes.NewReader([]byte{0xbf}), CBORHandlerSubType)
+	_, err = reader.Next()
+	assert.ErrorContains(t, err, "unsupported cbor argument 31")
+
+	reader, _ = NewBinaryReader(bytes.NewReader([]byte{0x7b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}),
+		CBORHandlerSubType)
+	_, err = reader.Next()
+	assert.ErrorContains(t, err, "exceeds limit")
+}
+
+func TestFloat16(t *testing.T) {
+	assert.Equal(t, 0.0, float16(0x0000))
+	assert.Equal(t, 1.0, float16(0x3c00))
+	assert.Equal(t, -2.0, float16(0xc000))
+	assert.Equal(t, 65504.0, float16(0x7bff))
+	assert.Equal(t, 5.960464477539063e-8, float16(0x0001))
+	assert.True(t, math.IsInf(float16(0x7c00), 1))
+	assert.True(t, math.IsNaN(float16(0x7e00)))
+}
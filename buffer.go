@@ -0,0 +1,90 @@
+package multilog
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Buffer pool settings
+const (
+	defaultBufferSize = 1024
+	maxPooledBuffer   = 64 << 10
+)
+
+// bufferPool holds the byte buffers used to format records.
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, defaultBufferSize)
+		return &b
+	},
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *[]byte {
+	b, _ := bufferPool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+// putBuffer returns the buffer to the pool; oversized buffers are dropped so
+// that one large record does not pin memory.
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(b)
+}
+
+// formatter is a slog handler writing into its own builder. Formatters are
+// pooled per handler so that records are formatted concurrently instead of
+// taking turns on one shared builder.
+type formatter struct {
+	sb      *strings.Builder
+	handler slog.Handler
+}
+
+// newFormatterPool returns a pool of formatters whose handlers are created by newHandler.
+func newFormatterPool(newHandler func(w io.Writer) slog.Handler) *sync.Pool {
+	return &sync.Pool{
+		New: func() any {
+			sb := &strings.Builder{}
+			return &formatter{sb: sb, handler: newHandler(sb)}
+		},
+	}
+}
+
+// deriveFormatterPool returns a pool of formatters derived from the formatters
+// of the parent pool, as needed for WithAttrs and WithGroup.
+func deriveFormatterPool(parent *sync.Pool, derive func(slog.Handler) slog.Handler) *sync.Pool {
+	if parent == nil {
+		return nil
+	}
+	return &sync.Pool{
+		New: func() any {
+			f, _ := parent.New().(*formatter)
+			f.handler = derive(f.handler)
+			return f
+		},
+	}
+}
+
+// formatterFor returns the builder and slog handler to format one record with,
+// and a function that releases them. Handlers without a formatter pool share
+// their own builder.
+func formatterFor(h CustomHandlerInterface) (*strings.Builder, slog.Handler, func()) {
+	ch, ok := h.(*CustomHandler)
+	if !ok || ch.formatters == nil {
+		sb := h.GetStringBuilder()
+		return sb, h.GetSlogHandler(), sb.Reset
+	}
+	f, _ := ch.formatters.Get().(*formatter)
+	return f.sb, f.handler, func() {
+		if f.sb.Cap() > maxPooledBuffer {
+			return
+		}
+		f.sb.Reset()
+		ch.formatters.Put(f)
+	}
+}
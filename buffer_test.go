@@ -0,0 +1,92 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferPool(t *testing.T) {
+	buf := getBuffer()
+	*buf = append(*buf, "hello"...)
+	putBuffer(buf)
+	assert.Empty(t, *getBuffer())
+
+	large := make([]byte, 0, maxPooledBuffer+1)
+	putBuffer(&large)
+}
+
+func TestFormatterFor(t *testing.T) {
+	ch := NewCustomHandler(testDefaultOptions(), nil, RemoveKeys())
+	sb, handler, release := formatterFor(ch)
+	assert.NotSame(t, ch.sb, sb)
+
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)
+	record.AddAttrs(slog.String("key", "value"))
+	assert.NoError(t, handler.Handle(context.Background(), record))
+	assert.Equal(t, "key=value\n", sb.String())
+	release()
+	assert.Empty(t, sb.String())
+
+	mock := &MockCustomHandler{opts: testDefaultOptions()}
+	sb, _, release = formatterFor(mock)
+	assert.Same(t, mock.GetStringBuilder(), sb)
+	release()
+}
+
+func TestCustomHandler_ConcurrentFormatting(t *testing.T) {
+	text := &bytes.Buffer{}
+	textOpts := &CustomHandlerOptions{Level: InfoLevel, Enabled: true, Pattern: "[msg]"}
+	// A custom replaceAttr formats records through the slog text handler.
+	replaceAttr := RemoveGivenKeys(slog.TimeKey, slog.MessageKey)
+	textLogger := slog.New(NewCustomHandler(textOpts, bufio.NewWriter(text), replaceAttr)).With("app", "api")
+
+	jsonOut := &bytes.Buffer{}
+	jsonLogger := slog.New(newJSONHandler(CustomHandlerOptions{Level: InfoLevel, Enabled: true}, nil, nil))
+	jsonHandler := jsonLogger.Handler().(*JSONHandler)
+	jsonWriter := bufio.NewWriter(jsonOut)
+	mu := sync.Mutex{}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				textLogger.Info(fmt.Sprintf("message %d-%d", i, j), "worker", i)
+
+				record := slog.NewRecord(time.Now(), slog.LevelInfo, "json", 0)
+				record.AddAttrs(slog.Int("worker", i))
+				b, err := jsonHandler.format(context.Background(), record)
+				assert.NoError(t, err)
+				mu.Lock()
+				_, _ = jsonWriter.Write(append(b, '\n'))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, jsonWriter.Flush())
+
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	assert.Len(t, lines, 400)
+	for _, line := range lines {
+		assert.Regexp(t, `^message (\d)-\d+ \[level=INFO app=api worker=(\d)\]$`, line)
+	}
+
+	lines = strings.Split(strings.TrimSpace(jsonOut.String()), "\n")
+	assert.Len(t, lines, 400)
+	for _, line := range lines {
+		var entry map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+	}
+}
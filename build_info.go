@@ -0,0 +1,98 @@
+package multilog
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Build placeholders
+const (
+	VersionPlaceholder  = "[version]"
+	RevisionPlaceholder = "[revision]"
+)
+
+// UnknownVersion is the version of binaries without build info.
+const UnknownVersion = "unknown"
+
+// shortRevisionLength is the length revisions are shortened to.
+const shortRevisionLength = 12
+
+// BuildInfo describes the build of the running binary, as recorded by the Go
+// toolchain in the binary.
+type BuildInfo struct {
+	// Time is the time of the VCS revision.
+	Time time.Time
+	// Version is the version of the main module, or UnknownVersion.
+	Version string
+	// Revision is the VCS revision.
+	Revision string
+	// Dirty reports whether the working tree had local modifications.
+	Dirty bool
+}
+
+// ReadBuildInfo returns the build info of the running binary, read once.
+func ReadBuildInfo() BuildInfo {
+	return buildInfo()
+}
+
+// buildInfo reads the build info once.
+var buildInfo = sync.OnceValue(func() BuildInfo {
+	return newBuildInfo(debug.ReadBuildInfo())
+})
+
+// newBuildInfo extracts the build info from the data of debug.ReadBuildInfo.
+func newBuildInfo(info *debug.BuildInfo, ok bool) BuildInfo {
+	build := BuildInfo{Version: UnknownVersion}
+	if !ok || info == nil {
+		return build
+	}
+	if info.Main.Version != "" {
+		build.Version = info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.Time, _ = time.Parse(time.RFC3339, setting.Value)
+		case "vcs.modified":
+			build.Dirty = setting.Value == "true"
+		}
+	}
+	return build
+}
+
+// ShortRevision returns the revision shortened to 12 characters, followed by
+// "-dirty" when the working tree had local modifications, or "" without VCS info.
+func (b BuildInfo) ShortRevision() string {
+	revision := b.Revision
+	if len(revision) > shortRevisionLength {
+		revision = revision[:shortRevisionLength]
+	}
+	if revision != "" && b.Dirty {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// appVersion returns the application version of the options, or the version
+// of the main module.
+func appVersion(opts *CustomHandlerOptions) string {
+	if opts != nil && opts.AppVersion != "" {
+		return opts.AppVersion
+	}
+	return buildInfo().Version
+}
+
+// buildValue returns the value of a build placeholder.
+func buildValue(opts *CustomHandlerOptions, placeholder string) string {
+	switch placeholder {
+	case VersionPlaceholder:
+		return appVersion(opts)
+	case RevisionPlaceholder:
+		return buildInfo().ShortRevision()
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,101 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "v1.4.2"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.time", Value: "2024-05-06T07:08:09Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	build := newBuildInfo(info, true)
+	assert.Equal(t, BuildInfo{
+		Time:     time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+		Version:  "v1.4.2",
+		Revision: "0123456789abcdef0123",
+		Dirty:    true,
+	}, build)
+	assert.Equal(t, "0123456789ab-dirty", build.ShortRevision())
+
+	build.Dirty = false
+	assert.Equal(t, "0123456789ab", build.ShortRevision())
+	assert.Equal(t, "", BuildInfo{}.ShortRevision())
+
+	assert.Equal(t, BuildInfo{Version: UnknownVersion}, newBuildInfo(nil, false))
+	assert.Equal(t, ReadBuildInfo(), ReadBuildInfo())
+}
+
+func TestVersionPlaceholder(t *testing.T) {
+	opts := CustomHandlerOptions{
+		Level:      DebugLevel,
+		Enabled:    true,
+		Pattern:    "[version] [level] [msg]",
+		AppVersion: "v2.0.0",
+	}
+	direct, text := &bytes.Buffer{}, &bytes.Buffer{}
+	directLogger, textLogger := newTextHandlerPair(opts, direct, text)
+	directLogger.Info("hello")
+	textLogger.Info("hello")
+	assert.Equal(t, "v2.0.0 INFO hello", strings.TrimSpace(direct.String()))
+	assert.Equal(t, "v2.0.0 INFO hello", strings.TrimSpace(text.String()))
+
+	opts.AppVersion = ""
+	direct.Reset()
+	directLogger, _ = newTextHandlerPair(opts, direct, text)
+	directLogger.Info("hello")
+	assert.Equal(t, ReadBuildInfo().Version+" INFO hello", strings.TrimSpace(direct.String()))
+}
+
+func TestVersionPlaceholder_JSON(t *testing.T) {
+	opts := CustomHandlerOptions{
+		Level:               DebugLevel,
+		Enabled:             true,
+		PatternPlaceholders: []string{"[version]", "[revision]", "[msg]"},
+		AppVersion:          "v2.0.0",
+	}
+	direct, slow := &bytes.Buffer{}, &bytes.Buffer{}
+	directLogger, slowLogger := newJSONHandlerPair(opts, direct, slow)
+	directLogger.Info("hello")
+	slowLogger.Info("hello")
+
+	for _, output := range []string{direct.String(), slow.String()} {
+		doc := decodeLines(t, output)[0]
+		assert.Equal(t, "v2.0.0", doc["version"])
+		if revision := ReadBuildInfo().ShortRevision(); revision != "" {
+			assert.Equal(t, revision, doc["revision"])
+		} else {
+			assert.NotContains(t, doc, "revision")
+		}
+	}
+	assert.True(t, strings.HasPrefix(direct.String(), `{"version":"v2.0.0",`))
+}
+
+func TestAppVersion_Config(t *testing.T) {
+	cfg, err := NewBuilder().AppVersion("v3.1.0").Console(Pattern("[version] [msg]")).Config()
+	assert.NoError(t, err)
+	options, err := cfg.GetCustomHandlerOptionsForHandler(cfg.Multilog.Handlers[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "v3.1.0", options.AppVersion)
+
+	buf := &bytes.Buffer{}
+	options.FormatEngine = TemplateFormatEngine
+	options.Pattern = "{{.Version}} {{.Message}}"
+	handler := NewCustomHandler(&options, bufio.NewWriter(buf), nil)
+	slog.New(handler).Info("hello")
+	assert.NoError(t, handler.Flush())
+	assert.Equal(t, "v3.1.0 hello\n", buf.String())
+}
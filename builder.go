@@ -0,0 +1,486 @@
+package multilog
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// HandlerOption configures a handler added with a Builder.
+type HandlerOption func(*HandlerConfig)
+
+// Builder builds a Logger in code, using the same defaults and validation as YAML configs.
+//
+//	logger, err := multilog.NewBuilder().
+//		Console(multilog.Level("info"), multilog.Color()).
+//		File("app.log", multilog.JSON(), multilog.MaxSize(10)).
+//		Build()
+type Builder struct {
+	config Config
+}
+
+// NewBuilder creates an empty builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Console adds a console handler.
+func (b *Builder) Console(opts ...HandlerOption) *Builder {
+	return b.Handler(ConsoleHandlerType, opts...)
+}
+
+// File adds a file handler writing to the given file.
+func (b *Builder) File(file string, opts ...HandlerOption) *Builder {
+	return b.Handler(FileHandlerType, append([]HandlerOption{func(h *HandlerConfig) { h.File = file }}, opts...)...)
+}
+
+// Audit adds an audit handler writing a hash-chained audit trail to the given file.
+func (b *Builder) Audit(file string, opts ...HandlerOption) *Builder {
+	return b.Handler(AuditHandlerType, append([]HandlerOption{func(h *HandlerConfig) { h.File = file }}, opts...)...)
+}
+
+// Handler adds a handler of the given type, e.g. LokiHandlerType with URL(...).
+// Handlers are enabled at the default level unless options say otherwise.
+func (b *Builder) Handler(handlerType string, opts ...HandlerOption) *Builder {
+	handler := HandlerConfig{
+		Type:    handlerType,
+		Level:   DefaultLogLevel,
+		Enabled: true,
+	}
+	for _, opt := range opts {
+		opt(&handler)
+	}
+	b.config.Multilog.Handlers = append(b.config.Multilog.Handlers, handler)
+	return b
+}
+
+// Config returns the validated configuration built so far.
+func (b *Builder) Config() (*Config, error) {
+	config := b.config
+	config.Multilog.Handlers = append([]HandlerConfig{}, b.config.Multilog.Handlers...)
+	if err := validateConfig(&config); err != nil {
+		return nil, fmt.Errorf("invalid config data: %w", err)
+	}
+	return &config, nil
+}
+
+// Build validates the configuration and creates a Logger with its handlers.
+func (b *Builder) Build() (*Logger, error) {
+	config, err := b.Config()
+	if err != nil {
+		return nil, err
+	}
+	return NewLoggerFromConfig(config)
+}
+
+// PerfInterval logs a perf record with the runtime metrics every interval.
+func (b *Builder) PerfInterval(interval time.Duration) *Builder {
+	b.config.Multilog.PerfInterval = interval
+	return b
+}
+
+// Parallel passes records to the handlers concurrently, running at most
+// workers handler calls at once and waiting at most timeout for each handler.
+// Zero values select one worker per handler and no timeout.
+func (b *Builder) Parallel(workers int, timeout time.Duration) *Builder {
+	b.config.Multilog.Parallel = true
+	b.config.Multilog.ParallelWorkers = workers
+	b.config.Multilog.HandlerTimeout = timeout
+	return b
+}
+
+// AppVersion sets the version of the [version] placeholder, which defaults to
+// the version of the main module from the build info.
+func (b *Builder) AppVersion(version string) *Builder {
+	b.config.Multilog.AppVersion = version
+	return b
+}
+
+// Fields attaches the fields, such as the service name and the environment, to
+// every record.
+func (b *Builder) Fields(fields map[string]string) *Builder {
+	b.config.Multilog.Fields = fields
+	return b
+}
+
+// Scrub replaces the PII found in messages and attribute values before any
+// handler writes them, as configured by the scrub config.
+func (b *Builder) Scrub(config ScrubConfig) *Builder {
+	b.config.Multilog.Scrub = config
+	return b
+}
+
+// Name sets the handler name.
+func Name(name string) HandlerOption {
+	return func(h *HandlerConfig) { h.Name = name }
+}
+
+// Level sets the minimum level of the handler.
+func Level(level string) HandlerOption {
+	return func(h *HandlerConfig) { h.Level = level }
+}
+
+// MaxLevel sets the maximum level of the handler.
+func MaxLevel(level string) HandlerOption {
+	return func(h *HandlerConfig) { h.MaxLevel = level }
+}
+
+// StructuredPerf emits performance metrics as perf.* attributes instead of a string.
+func StructuredPerf() HandlerOption {
+	return func(h *HandlerConfig) { h.StructuredPerf = true }
+}
+
+// SelectPerfMetrics selects the performance metrics to emit and the unit of memory metrics.
+func SelectPerfMetrics(unit string, names ...string) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.PerfUnits = unit
+		h.PerfMetrics = names
+	}
+}
+
+// AddStacktrace attaches a stack trace to records at or above the level.
+func AddStacktrace(level string) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.AddStacktrace = true
+		h.StacktraceLevel = level
+	}
+}
+
+// Pattern sets the output pattern of the handler.
+func Pattern(pattern string) HandlerOption {
+	return func(h *HandlerConfig) { h.Pattern = pattern }
+}
+
+// TimeFormat sets the layout of the [time] placeholder, a Go layout or RFC3339, RFC3339Nano, or ISO8601.
+func TimeFormat(layout string) HandlerOption {
+	return func(h *HandlerConfig) { h.TimeFormat = layout }
+}
+
+// DateFormat sets the layout of the [date] placeholder.
+func DateFormat(layout string) HandlerOption {
+	return func(h *HandlerConfig) { h.DateFormat = layout }
+}
+
+// DateTimeFormat sets the layout of the [datetime] placeholder.
+func DateTimeFormat(layout string) HandlerOption {
+	return func(h *HandlerConfig) { h.DateTimeFormat = layout }
+}
+
+// SourceFormat sets the format of the [source] placeholder, built from [file],
+// [path], [relpath], [line] and [func], or full, relative, or short. The trim
+// prefixes are removed from the path of [path].
+func SourceFormat(format string, trimPrefixes ...string) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.SourceFormat = format
+		h.SourceTrimPrefixes = trimPrefixes
+	}
+}
+
+// Timezone renders times in the timezone ("UTC", "Local", or an IANA name).
+func Timezone(timezone string) HandlerOption {
+	return func(h *HandlerConfig) { h.Timezone = timezone }
+}
+
+// LevelPattern sets the pattern of records at the level, overriding Pattern for it.
+func LevelPattern(level, pattern string) HandlerOption {
+	return func(h *HandlerConfig) {
+		if h.Patterns == nil {
+			h.Patterns = map[string]string{}
+		}
+		h.Patterns[level] = pattern
+	}
+}
+
+// Template formats records with a Go text/template instead of a pattern.
+func Template(text string) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.FormatEngine = TemplateFormatEngine
+		h.Pattern = text
+	}
+}
+
+// JSON selects the JSON subtype.
+func JSON() HandlerOption {
+	return func(h *HandlerConfig) { h.SubType = JSONHandlerSubType }
+}
+
+// Indent pretty-prints JSON records over several lines, for development.
+func Indent() HandlerOption {
+	return func(h *HandlerConfig) { h.JSONIndent = true }
+}
+
+// SortKeys sorts the keys of JSON records.
+func SortKeys() HandlerOption {
+	return func(h *HandlerConfig) { h.JSONSortKeys = true }
+}
+
+// ECS writes JSON records with Elastic Common Schema field names.
+func ECS() HandlerOption {
+	return func(h *HandlerConfig) { h.ECS = true }
+}
+
+// GCP writes JSON records in the Google Cloud Logging structured format.
+func GCP() HandlerOption {
+	return func(h *HandlerConfig) { h.GCP = true }
+}
+
+// CSVColumns adds columns for the attributes with the given keys to csv and
+// tsv files.
+func CSVColumns(keys ...string) HandlerOption {
+	return func(h *HandlerConfig) { h.CSVColumns = keys }
+}
+
+// CSVHeader writes a header row at the start of csv and tsv files.
+func CSVHeader() HandlerOption {
+	return func(h *HandlerConfig) { h.CSVHeader = true }
+}
+
+// Device sets the device vendor, product and version of cef and leef records.
+func Device(vendor, product, version string) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.DeviceVendor = vendor
+		h.DeviceProduct = product
+		h.DeviceVersion = version
+	}
+}
+
+// SIEMField writes the attribute with the given key under the CEF or LEEF
+// field name, such as suser or src.
+func SIEMField(key, field string) HandlerOption {
+	return func(h *HandlerConfig) {
+		if h.SIEMFields == nil {
+			h.SIEMFields = map[string]string{}
+		}
+		h.SIEMFields[key] = field
+	}
+}
+
+// SingleLetterLevel renders levels as a single letter.
+func SingleLetterLevel() HandlerOption {
+	return func(h *HandlerConfig) { h.UseSingleLetterLevel = true }
+}
+
+// PriorityPrefix prefixes console lines with their syslog priority for systemd.
+func PriorityPrefix() HandlerOption {
+	return func(h *HandlerConfig) { h.PriorityPrefix = true }
+}
+
+// Pretty renders console error records across multiple lines, with the
+// attributes and the stack trace indented below the message.
+func Pretty() HandlerOption {
+	return func(h *HandlerConfig) { h.Pretty = true }
+}
+
+// Disabled adds the handler disabled.
+func Disabled() HandlerOption {
+	return func(h *HandlerConfig) { h.Enabled = false }
+}
+
+// Color enables colored console output.
+func Color() HandlerOption {
+	return func(h *HandlerConfig) { h.Color = true }
+}
+
+// SplitOutput writes warn and error console records to stderr.
+func SplitOutput() HandlerOption {
+	return func(h *HandlerConfig) { h.SplitOutput = true }
+}
+
+// MaxSize sets the maximum file size in megabytes before rotation.
+func MaxSize(megabytes int) HandlerOption {
+	return func(h *HandlerConfig) { h.MaxSize = megabytes }
+}
+
+// MaxBackups sets the number of rotated files to keep.
+func MaxBackups(backups int) HandlerOption {
+	return func(h *HandlerConfig) { h.MaxBackups = backups }
+}
+
+// MaxAge sets the number of days to keep rotated files.
+func MaxAge(days int) HandlerOption {
+	return func(h *HandlerConfig) { h.MaxAge = days }
+}
+
+// ShardBy writes each record to the file of its key attribute value, keeping at
+// most maxOpen files open (DefaultMaxOpenShards if 0).
+func ShardBy(key string, maxOpen int) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.ShardBy = key
+		h.MaxOpenShards = maxOpen
+	}
+}
+
+// RetentionLimits deletes the oldest log files of the handler, including backups,
+// shards and date directories, beyond maxTotalSize (such as "10GB"), maxFiles
+// files, or older than maxAge. Zero or empty limits are not applied.
+func RetentionLimits(maxTotalSize string, maxFiles int, maxAge time.Duration) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.RetentionTotalSize = maxTotalSize
+		h.RetentionMaxFiles = maxFiles
+		h.RetentionMaxAge = maxAge
+	}
+}
+
+// Sync sets when the log file is committed to stable storage: after every
+// write (AlwaysSync), every interval and after error records (IntervalSync), or
+// when the OS decides (NeverSync). A zero interval uses DefaultSyncInterval.
+func Sync(mode string, interval time.Duration) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.Sync = mode
+		h.SyncInterval = interval
+	}
+}
+
+// DiskGuard watches the disk of a file handler, taking the action (pause, purge
+// or console) while free space is below minFree or the log directory is larger
+// than maxSize. Sizes are such as "1GB"; an empty size is not checked.
+func DiskGuard(action, minFree, maxSize string) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.DiskAction = action
+		h.DiskMinFree = minFree
+		h.DiskMaxSize = maxSize
+	}
+}
+
+// FileMode sets the permissions of log files, e.g. 0o640.
+func FileMode(mode os.FileMode) HandlerOption {
+	return func(h *HandlerConfig) { h.FileMode = fmt.Sprintf("%#o", mode) }
+}
+
+// DirMode sets the permissions of the directories created for log files.
+func DirMode(mode os.FileMode) HandlerOption {
+	return func(h *HandlerConfig) { h.DirMode = fmt.Sprintf("%#o", mode) }
+}
+
+// FileOwner sets the owner and group of log files, as names or numeric IDs. An
+// empty value keeps the current one.
+func FileOwner(owner, group string) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.FileOwner = owner
+		h.FileGroup = group
+	}
+}
+
+// RotateInterval enables time-based rotation ("daily", "hourly", or a duration).
+func RotateInterval(interval string) HandlerOption {
+	return func(h *HandlerConfig) { h.RotateInterval = interval }
+}
+
+// BatchWrites buffers file writes up to size (e.g. "64KB") and flushes them every
+// interval, on error records, and when the buffer fills.
+func BatchWrites(size string, interval time.Duration) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.FlushSize = size
+		h.FlushInterval = interval
+	}
+}
+
+// Compress gzips rotated files (or HTTP request bodies).
+func Compress() HandlerOption {
+	return func(h *HandlerConfig) { h.Compress = true }
+}
+
+// URL sets the endpoint of a network handler.
+func URL(url string) HandlerOption {
+	return func(h *HandlerConfig) { h.URL = url }
+}
+
+// Fallback writes records that fail to the target ("stdout" or "stderr").
+func Fallback(target string) HandlerOption {
+	return func(h *HandlerConfig) { h.Fallback = target }
+}
+
+// DeadLetter appends records that fail to the file at path, for later Replay.
+func DeadLetter(path string) HandlerOption {
+	return func(h *HandlerConfig) { h.DeadLetterFile = path }
+}
+
+// CircuitBreaker skips the handler for the cooldown after failures consecutive errors or timeouts.
+func CircuitBreaker(failures int, cooldown time.Duration) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.BreakerFailures = failures
+		h.BreakerCooldown = cooldown
+	}
+}
+
+// Timeout gives up on a record when the handler takes longer than d to write it.
+func Timeout(d time.Duration) HandlerOption {
+	return func(h *HandlerConfig) { h.Timeout = d }
+}
+
+// Sample keeps the first initial records per level and message each second, then every thereafter-th.
+func Sample(initial, thereafter int) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.SampleInitial = initial
+		h.SampleThereafter = thereafter
+	}
+}
+
+// RateLimit limits the handler to ratePerSecond records per second with bursts of burst.
+func RateLimit(ratePerSecond float64, burst int) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.MaxRecordsPerSecond = ratePerSecond
+		h.Burst = burst
+	}
+}
+
+// Dedup collapses identical records within the window, compared by the given attribute keys.
+func Dedup(window time.Duration, keys ...string) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.DedupWindow = window
+		h.DedupKeys = keys
+	}
+}
+
+// IncludeKeys keeps only the attributes with the given keys.
+func IncludeKeys(keys ...string) HandlerOption {
+	return func(h *HandlerConfig) { h.IncludeKeys = keys }
+}
+
+// ExcludeKeys removes the attributes with the given keys.
+func ExcludeKeys(keys ...string) HandlerOption {
+	return func(h *HandlerConfig) { h.ExcludeKeys = keys }
+}
+
+// Truncate cuts messages longer than maxMsgLen and attribute values longer than
+// maxAttrLen bytes. A zero limit is not applied.
+func Truncate(maxMsgLen, maxAttrLen int) HandlerOption {
+	return func(h *HandlerConfig) {
+		h.MaxMsgLen = maxMsgLen
+		h.MaxAttrLen = maxAttrLen
+	}
+}
+
+// Escape sets how text handlers escape line breaks and control characters in
+// messages and placeholder values: control (default), newlines, or none.
+func Escape(mode string) HandlerOption {
+	return func(h *HandlerConfig) { h.Escape = mode }
+}
+
+// CallerSkip reports as the source of records the caller skip frames above the
+// logging call, as described in CallerSkipHandler.
+func CallerSkip(skip int) HandlerOption {
+	return func(h *HandlerConfig) { h.CallerSkip = skip }
+}
+
+// RedactKeys logs the values of the attributes with the given keys as secrets.
+func RedactKeys(keys ...string) HandlerOption {
+	return func(h *HandlerConfig) { h.RedactKeys = keys }
+}
+
+// Match routes only records whose attribute key matches the pattern to the handler.
+// It can be repeated to require several attributes.
+func Match(key, pattern string) HandlerOption {
+	return func(h *HandlerConfig) {
+		if h.Match == nil {
+			h.Match = map[string]string{}
+		}
+		h.Match[key] = pattern
+	}
+}
+
+// WithHandlerConfig applies arbitrary changes to the handler config, for settings
+// without a dedicated option.
+func WithHandlerConfig(fn func(*HandlerConfig)) HandlerOption {
+	return fn
+}
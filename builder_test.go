@@ -0,0 +1,74 @@
+package multilog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewBuilder().
+		Console(Level("warn"), Color()).
+		File(file, JSON(), MaxSize(10), Level("debug")).
+		Build()
+	assert.NoError(t, err)
+
+	logger.Debug("built")
+	assert.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(content), `"msg":"built"`), string(content))
+}
+
+func TestBuilder_Config(t *testing.T) {
+	config, err := NewBuilder().
+		Console(Name("stdout"), SplitOutput()).
+		File("app.log", RotateInterval("daily"), Compress(), Dedup(time.Second, "host"), Sample(10, 5)).
+		Handler(LokiHandlerType, URL("http://loki:3100"), Disabled()).
+		Config()
+	assert.NoError(t, err)
+
+	handlers := config.Multilog.Handlers
+	assert.Len(t, handlers, 3)
+	assert.Equal(t, "stdout", handlers[0].Name)
+	assert.True(t, handlers[0].SplitOutput)
+	assert.Equal(t, DefaultLogLevel, handlers[1].Level)
+	assert.Equal(t, "app.log", handlers[1].File)
+	assert.Equal(t, []string{"host"}, handlers[1].DedupKeys)
+	assert.False(t, handlers[2].Enabled)
+}
+
+func TestBuilder_Invalid(t *testing.T) {
+	_, err := NewBuilder().Console(Level("loud")).Build()
+	assert.Error(t, err)
+
+	_, err = NewBuilder().Console().Console().Build()
+	assert.Error(t, err)
+
+	_, err = NewBuilder().File("").Build()
+	assert.Error(t, err)
+}
+
+func TestBuilder_LevelPattern(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewBuilder().
+		File(file, Pattern("[level] [msg]"), LevelPattern(WarnLevel, "!! [msg]")).
+		Build()
+	assert.NoError(t, err)
+
+	logger.Info("hello")
+	logger.Warn("careful")
+	assert.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.True(t, strings.HasPrefix(lines[0], "INFO hello"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "!! careful"), lines[1])
+}
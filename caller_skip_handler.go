@@ -0,0 +1,80 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// maxCallerDepth is the number of frames searched for the logging call.
+const maxCallerDepth = 64
+
+// CallerSkipHandler is a Handler reporting as the source of records the caller
+// skip frames above the logging call, for applications logging through helper
+// packages with the slog API or loggers they do not create. The logging call
+// is found by walking the stack of the goroutine handling the record, so the
+// source is only changed when records are handled on the logging goroutine,
+// which is not the case behind a parallel logger. Loggers created by the
+// application use Logger.WithCallerSkip instead.
+type CallerSkipHandler struct {
+	skip int
+	wrappedHandler
+}
+
+// NewCallerSkipHandler creates a handler skipping skip frames above the logging call.
+func NewCallerSkipHandler(handler slog.Handler, skip int) *CallerSkipHandler {
+	return &CallerSkipHandler{
+		skip:           skip,
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// CallerSkipMiddleware wraps handlers with NewCallerSkipHandler.
+func CallerSkipMiddleware(skip int) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewCallerSkipHandler(next, skip)
+	}
+}
+
+// Handle replaces the source of the record and writes it.
+func (ch *CallerSkipHandler) Handle(ctx context.Context, record slog.Record) error {
+	if pc := skipCallers(record.PC, ch.skip); pc != 0 {
+		record.PC = pc
+	}
+	return ch.Handler.Handle(ctx, record)
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (ch *CallerSkipHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *ch
+	clone.Handler = ch.Handler.WithAttrs(attrs)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (ch *CallerSkipHandler) WithGroup(name string) slog.Handler {
+	clone := *ch
+	clone.Handler = ch.Handler.WithGroup(name)
+	return &clone
+}
+
+// skipCallers returns the program counter skip frames above the frame of pc
+// on the stack of the calling goroutine, or 0 when pc is not on the stack.
+// Like the program counters of records, the frames include inlined calls.
+func skipCallers(pc uintptr, skip int) uintptr {
+	if pc == 0 || skip <= 0 {
+		return 0
+	}
+	var pcs [maxCallerDepth]uintptr
+	// Skip runtime.Callers and skipCallers.
+	n := runtime.Callers(2, pcs[:])
+	for i, frame := range pcs[:n] {
+		if frame == pc {
+			if i+skip < n {
+				return pcs[i+skip]
+			}
+			return 0
+		}
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package multilog
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// auditInfo is a helper wrapping a logger, like those of wrapper packages.
+func auditInfo(logger *Logger, msg string) {
+	logger.Info(msg)
+}
+
+// slogInfo is a helper wrapping a slog logger.
+func slogInfo(logger *slog.Logger, msg string) {
+	logger.Info(msg)
+}
+
+func TestLogger_WithCallerSkip(t *testing.T) {
+	handler := &recordingHandler{}
+	logger := NewLogger(handler)
+	assert.Same(t, logger, logger.WithCallerSkip(0))
+
+	_, _, line, _ := runtime.Caller(0)
+	auditInfo(logger.WithCallerSkip(1), "skipped")
+	auditInfo(logger, "not skipped")
+	logger.WithCallerSkip(1).WithContext(t.Context()).Info("context")
+
+	assert.Len(t, handler.records, 3)
+	source := handler.records[0].Source()
+	assert.Equal(t, "caller_skip_handler_test.go", filepath.Base(source.File))
+	assert.Equal(t, line+1, source.Line)
+	assert.True(t, strings.HasSuffix(handler.records[1].Source().Function, ".auditInfo"))
+	// Skips apply to the callers of context loggers too, here the test runner.
+	assert.NotEqual(t, "caller_skip_handler_test.go", filepath.Base(handler.records[2].Source().File))
+}
+
+func TestLogger_NamedCallerSkip(t *testing.T) {
+	handler := &recordingHandler{}
+	logger := NewLogger(handler)
+
+	_, _, line, _ := runtime.Caller(0)
+	auditInfo(logger.WithCallerSkip(1).Named("audit"), "without registry")
+	logger.registry = NewRegistry(logger)
+	auditInfo(logger.WithCallerSkip(1).Named("audit"), "with registry")
+	auditInfo(logger.Named("audit"), "not skipped")
+
+	assert.Len(t, handler.records, 3)
+	assert.Equal(t, line+1, handler.records[0].Source().Line)
+	assert.Equal(t, line+3, handler.records[1].Source().Line)
+	assert.True(t, strings.HasSuffix(handler.records[2].Source().Function, ".auditInfo"))
+}
+
+func TestCallerSkipHandler(t *testing.T) {
+	handler := &recordingHandler{}
+	logger := slog.New(NewCallerSkipHandler(handler, 1))
+
+	_, _, line, _ := runtime.Caller(0)
+	slogInfo(logger, "skipped")
+	assert.Len(t, handler.records, 1)
+	source := handler.records[0].Source()
+	assert.Equal(t, "caller_skip_handler_test.go", filepath.Base(source.File))
+	assert.Equal(t, line+1, source.Line)
+
+	// Records whose logging call is not on the stack keep their source.
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "handled elsewhere", pcs[0]+1)
+	assert.NoError(t, NewCallerSkipHandler(handler, 1).Handle(t.Context(), record))
+	assert.Equal(t, pcs[0]+1, handler.records[1].PC)
+}
+
+func TestCallerSkip_Config(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	builder := NewBuilder().File(file, Pattern("[level] [source] [msg]"), CallerSkip(1))
+	logger, err := builder.Build()
+	assert.NoError(t, err)
+
+	_, _, line, _ := runtime.Caller(0)
+	slogInfo(logger.Logger, "skipped")
+	assert.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content),
+		"INFO caller_skip_handler_test.go:"+strconv.Itoa(line+1)+":multilog.TestCallerSkip_Config skipped")
+
+	_, err = NewBuilder().Console(CallerSkip(-1)).Build()
+	assert.ErrorContains(t, err, "caller_skip must not be negative")
+}
@@ -0,0 +1,190 @@
+package multilog
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SIEM subtypes, writing one ArcSight Common Event Format or QRadar Log Event
+// Extended Format line per record.
+const (
+	CEFHandlerSubType  = "cef"
+	LEEFHandlerSubType = "leef"
+)
+
+// Default device fields of cef and leef headers. The product defaults to the
+// name of the executable.
+const (
+	DefaultDeviceVendor  = "multilog"
+	DefaultDeviceVersion = "1.0"
+)
+
+// SIEMEventIDKey is the key of the attribute holding the CEF Device Event Class
+// ID or the LEEF Event ID of a record. Records without it use their level.
+const SIEMEventIDKey = "event_id"
+
+// leefTimeLayout is the layout of devTime, declared by devTimeFormat in the
+// Java notation QRadar expects.
+const (
+	leefTimeLayout = "Jan 02 2006 15:04:05.000 -0700"
+	leefTimeFormat = "MMM dd yyyy HH:mm:ss.SSS Z"
+)
+
+// siemInvalidKeyChars are the characters replaced with underscores in field
+// names.
+const siemInvalidKeyChars = " =|\\\t\r\n"
+
+var (
+	siemHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefValueEscaper   = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	leefValueEscaper  = strings.NewReplacer("\t", `\t`, "\r", `\r`, "\n", `\n`)
+)
+
+// isSIEMSubType reports whether the subtype writes cef or leef records.
+func isSIEMSubType(subType string) bool {
+	return subType == CEFHandlerSubType || subType == LEEFHandlerSubType
+}
+
+// siemSeverity maps the level to the 0-10 severity scale of CEF and LEEF.
+func siemSeverity(level slog.Level) int {
+	switch {
+	case level < slog.LevelInfo:
+		return 1
+	case level < slog.LevelWarn:
+		return 3
+	case level < slog.LevelError:
+		return 6
+	case level < slog.LevelError+4:
+		return 8
+	default:
+		return 10
+	}
+}
+
+// siemKey returns the key with the characters a field name cannot hold
+// replaced with underscores.
+func siemKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(siemInvalidKeyChars, r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// siemField is an extension field of a record.
+type siemField struct {
+	key   string
+	value string
+}
+
+// appendSIEMHeader appends the vendor, product, version and event ID header
+// fields, each followed by a pipe.
+func appendSIEMHeader(buf []byte, opts *CustomHandlerOptions, eventID string) []byte {
+	product := opts.DeviceProduct
+	if product == "" {
+		product = filepath.Base(os.Args[0])
+	}
+	for _, field := range []string{
+		defaultIfEmpty(opts.DeviceVendor, DefaultDeviceVendor),
+		product,
+		defaultIfEmpty(opts.DeviceVersion, DefaultDeviceVersion),
+		eventID,
+	} {
+		buf = append(buf, siemHeaderEscaper.Replace(field)...)
+		buf = append(buf, '|')
+	}
+	return buf
+}
+
+// siemFields returns the event ID and the attributes of the record as fields.
+// Attribute keys are qualified by their groups with dots as in text output and
+// renamed by SIEMFields.
+func (jh *JSONHandler) siemFields(ch *CustomHandler, record slog.Record) (string, []siemField) {
+	eventID := levelLabel(record.Level, false)
+	var fields []siemField
+	take := func(groups []string, a slog.Attr) bool {
+		key := a.Key
+		if len(groups) > 0 {
+			key = strings.Join(groups, ".") + "." + key
+		}
+		value := a.Value.String()
+		if a.Value.Kind() != slog.KindString {
+			value = string(appendTextValue(nil, a.Value))
+		}
+		switch field, ok := ch.Opts.SIEMFields[key]; {
+		case ok:
+			fields = append(fields, siemField{key: field, value: value})
+		case key == SIEMEventIDKey:
+			eventID = value
+		default:
+			fields = append(fields, siemField{key: siemKey(key), value: value})
+		}
+		return true
+	}
+	for _, b := range ch.boundAttrs {
+		appendTextAttrFunc(nil, b.groups, b.attr, jh.replaceAttr, take)
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		appendTextAttrFunc(nil, ch.groups, a, jh.replaceAttr, take)
+		return true
+	})
+	return eventID, fields
+}
+
+// appendCEF renders the record as a CEF line: the message is the event name,
+// the time is rt and the attributes are extension fields.
+func (jh *JSONHandler) appendCEF(buf []byte, ch *CustomHandler, record slog.Record) []byte {
+	eventID, fields := jh.siemFields(ch, record)
+	buf = append(buf, "CEF:0|"...)
+	buf = appendSIEMHeader(buf, ch.Opts, eventID)
+	buf = append(buf, siemHeaderEscaper.Replace(record.Message)...)
+	buf = append(buf, '|')
+	buf = strconv.AppendInt(buf, int64(siemSeverity(record.Level)), 10)
+	buf = append(buf, '|')
+
+	if !record.Time.IsZero() {
+		fields = append([]siemField{{key: "rt", value: strconv.FormatInt(record.Time.UnixMilli(), 10)}}, fields...)
+	}
+	for i, field := range fields {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, field.key...)
+		buf = append(buf, '=')
+		buf = append(buf, cefValueEscaper.Replace(field.value)...)
+	}
+	return buf
+}
+
+// appendLEEF renders the record as a LEEF 1.0 line of tab-separated fields:
+// devTime, sev, the message as msg, then the attributes.
+func (jh *JSONHandler) appendLEEF(buf []byte, ch *CustomHandler, record slog.Record) []byte {
+	eventID, attrs := jh.siemFields(ch, record)
+	buf = append(buf, "LEEF:1.0|"...)
+	buf = appendSIEMHeader(buf, ch.Opts, eventID)
+
+	fields := make([]siemField, 0, len(attrs)+4)
+	if !record.Time.IsZero() {
+		fields = append(fields,
+			siemField{key: "devTime", value: recordTime(ch.Opts, record.Time).Format(leefTimeLayout)},
+			siemField{key: "devTimeFormat", value: leefTimeFormat},
+		)
+	}
+	fields = append(fields,
+		siemField{key: "sev", value: strconv.Itoa(siemSeverity(record.Level))},
+		siemField{key: "msg", value: record.Message},
+	)
+	for i, field := range append(fields, attrs...) {
+		if i > 0 {
+			buf = append(buf, '\t')
+		}
+		buf = append(buf, field.key...)
+		buf = append(buf, '=')
+		buf = append(buf, leefValueEscaper.Replace(field.value)...)
+	}
+	return buf
+}
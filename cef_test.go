@@ -0,0 +1,145 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSIEMSeverity(t *testing.T) {
+	assert.Equal(t, 1, siemSeverity(slog.LevelDebug))
+	assert.Equal(t, 1, siemSeverity(LevelPerf))
+	assert.Equal(t, 3, siemSeverity(slog.LevelInfo))
+	assert.Equal(t, 6, siemSeverity(slog.LevelWarn))
+	assert.Equal(t, 8, siemSeverity(slog.LevelError))
+	assert.Equal(t, 10, siemSeverity(slog.LevelError+4))
+}
+
+// formatSIEM formats a record logged at a fixed time with the attributes.
+func formatSIEM(t *testing.T, opts CustomHandlerOptions, level slog.Level, msg string, args ...any) string {
+	t.Helper()
+	opts.Level = DebugLevel
+	opts.Enabled = true
+	opts.Location = time.UTC
+	jh := newJSONHandler(opts, nil, nil)
+	record := slog.NewRecord(time.UnixMilli(1700000000123), level, msg, 0)
+	record.Add(args...)
+	out, err := jh.format(context.Background(), record)
+	assert.NoError(t, err)
+	return string(out)
+}
+
+func TestAppendCEF(t *testing.T) {
+	opts := CustomHandlerOptions{
+		SubType:       CEFHandlerSubType,
+		DeviceVendor:  "Acme",
+		DeviceProduct: "Pay|Gate",
+		DeviceVersion: "2.1",
+		SIEMFields:    map[string]string{"user": "suser", "req.ip": "src"},
+	}
+	out := formatSIEM(t, opts, slog.LevelWarn, `login failed\retry`,
+		"event_id", "AUTH-1", "user", "alice", slog.Group("req", "ip", "10.0.0.1", "path", "/a=b"),
+		"note", "two\nlines", "err", errors.New("denied"))
+	assert.Equal(t, `CEF:0|Acme|Pay\|Gate|2.1|AUTH-1|login failed\\retry|6|`+
+		`rt=1700000000123 suser=alice src=10.0.0.1 req.path=/a\=b note=two\nlines err.message=denied err.type=*errors.errorString`, out)
+
+	out = formatSIEM(t, CustomHandlerOptions{SubType: CEFHandlerSubType}, slog.LevelInfo, "started", "bad key", 1)
+	assert.Equal(t, "CEF:0|multilog|"+filepath.Base(os.Args[0])+"|1.0|INFO|started|3|rt=1700000000123 bad_key=1", out)
+}
+
+func TestAppendLEEF(t *testing.T) {
+	opts := CustomHandlerOptions{
+		SubType:       LEEFHandlerSubType,
+		DeviceVendor:  "Acme",
+		DeviceProduct: "PayGate",
+		SIEMFields:    map[string]string{"user": "usrName"},
+	}
+	out := formatSIEM(t, opts, slog.LevelError, "transfer failed", "user", "alice", "detail", "a\tb")
+	assert.Equal(t, "LEEF:1.0|Acme|PayGate|1.0|ERROR|devTime=Nov 14 2023 22:13:20.123 +0000\t"+
+		"devTimeFormat=MMM dd yyyy HH:mm:ss.SSS Z\tsev=8\tmsg=transfer failed\tusrName=alice\tdetail=a\\tb", out)
+}
+
+func TestCEFFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.cef")
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: file
+      subtype: cef
+      level: info
+      enabled: true
+      file: ` + path + `
+      device_vendor: Acme
+      device_product: PayGate
+      siem_fields:
+        user: suser`))
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+
+	logger := slog.New(handlers[0])
+	logger.Info("login", "user", "alice")
+	logger.Info("logout", "user", "bob")
+	assert.NoError(t, handlers[0].(*JSONHandler).Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "CEF:0|Acme|PayGate|1.0|INFO|login|3|rt="), lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], " suser=bob"), lines[1])
+}
+
+func TestSocketHandler_LEEF(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	lines := acceptLines(listener)
+
+	handler, err := NewSocketHandler(SocketHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:         InfoLevel,
+			Enabled:       true,
+			SubType:       LEEFHandlerSubType,
+			DeviceProduct: "PayGate",
+		},
+		Address: listener.Addr().String(),
+	})
+	assert.NoError(t, err)
+	sh := handler.(*SocketHandler)
+	defer sh.Close()
+
+	NewLogger(handler).Warn("disk low", "event_id", "DISK")
+	line := receiveLine(t, lines)
+	assert.True(t, strings.HasPrefix(line, "LEEF:1.0|multilog|PayGate|1.0|DISK|devTime="), line)
+	assert.True(t, strings.HasSuffix(line, "\tsev=6\tmsg=disk low"), line)
+}
+
+func TestValidateHandler_SIEM(t *testing.T) {
+	handler := &HandlerConfig{Type: SocketHandlerType, Level: InfoLevel, Address: "siem:514",
+		SubType: CEFHandlerSubType, DeviceVendor: "Acme", SIEMFields: map[string]string{"user": "suser"}}
+	assert.NoError(t, validateHandler(handler))
+
+	handler = &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log", DeviceVendor: "Acme"}
+	assert.EqualError(t, validateHandler(handler),
+		"device_vendor, device_product, device_version and siem_fields require the cef or leef subtype")
+
+	handler = &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.leef", SubType: LEEFHandlerSubType,
+		SIEMFields: map[string]string{"user": "usr name"}}
+	assert.EqualError(t, validateHandler(handler), `invalid siem field for user: "usr name"`)
+
+	handler = &HandlerConfig{Type: HTTPHandlerType, Level: InfoLevel, URL: "http://siem", SubType: CEFHandlerSubType}
+	assert.EqualError(t, validateHandler(handler), "invalid http handler subtype: cef")
+
+	handler = &HandlerConfig{Type: KafkaHandlerType, Level: InfoLevel, Brokers: []string{"b:9092"}, Topic: "logs",
+		SubType: LEEFHandlerSubType}
+	assert.EqualError(t, validateHandler(handler), "leef subtype requires a file or socket handler")
+}
@@ -0,0 +1,276 @@
+package multilog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Chat handler settings
+const (
+	DefaultChatRate  = 0.2
+	DefaultChatBurst = 5
+	chatMaxFields    = 25
+	chatMaxTitle     = 256
+	chatMaxValue     = 1024
+	chatShortField   = 40
+)
+
+// ChatLevelColors maps level names to the colors of chat messages.
+var ChatLevelColors = map[string]int{
+	DebugLevel: 0x9e9e9e,
+	PerfLevel:  0x9e9e9e,
+	InfoLevel:  0x2eb886,
+	WarnLevel:  0xffa500,
+	ErrorLevel: 0xd00000,
+}
+
+// chatMessage is a record prepared for a chat message.
+type chatMessage struct {
+	time       time.Time
+	title      string
+	level      string
+	fields     [][2]string
+	suppressed uint64
+}
+
+// ChatHandler is a Handler that posts records as rich messages to a Slack or
+// Discord webhook, with the attributes as message fields. Messages are rate
+// limited to MaxRecordsPerSecond with bursts of Burst messages, or
+// DefaultChatRate and DefaultChatBurst, and the next message posted reports
+// how many were suppressed.
+type ChatHandler struct {
+	Handler *CustomHandler
+	client  *http.Client
+	batch   *batcher[[]byte]
+	limiter *rateLimiter
+	encode  func(msg *chatMessage) ([]byte, error)
+	opts    *ChatHandlerOptions
+}
+
+// ChatHandlerOptions are the options of Slack and Discord handlers: the
+// formatting and batching options, the webhook URL with its headers, and the
+// rate limit of the messages.
+type ChatHandlerOptions struct {
+	CustomHandlerOptions
+	BatchOptions
+	Headers             map[string]string
+	URL                 string
+	Burst               int
+	MaxRecordsPerSecond float64
+}
+
+// NewSlackHandler creates a Handler posting records to a Slack incoming webhook
+// as attachments. Messages are rate limited as described on ChatHandler.
+func NewSlackHandler(opts ChatHandlerOptions) (slog.Handler, error) {
+	return newChatHandler(opts, SlackHandlerType, encodeSlackMessage)
+}
+
+// NewDiscordHandler creates a Handler posting records to a Discord webhook as
+// embeds. Messages are rate limited as described on ChatHandler.
+func NewDiscordHandler(opts ChatHandlerOptions) (slog.Handler, error) {
+	return newChatHandler(opts, DiscordHandlerType, encodeDiscordMessage)
+}
+
+// newChatHandler creates a chat Handler posting messages encoded for the platform.
+func newChatHandler(
+	opts ChatHandlerOptions,
+	platform string,
+	encode func(msg *chatMessage) ([]byte, error),
+) (slog.Handler, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("%s handler requires a url", platform)
+	}
+	rate, burst := opts.MaxRecordsPerSecond, opts.Burst
+	if rate <= 0 {
+		rate, burst = DefaultChatRate, defaultIfZero(burst, DefaultChatBurst)
+	}
+
+	ch := &ChatHandler{
+		Handler: NewCustomHandler(&opts.CustomHandlerOptions, bufio.NewWriter(io.Discard), nil),
+		client:  &http.Client{Timeout: DefaultHTTPTimeout},
+		limiter: newRateLimiter(rate, burst),
+		encode:  encode,
+		opts:    &opts,
+	}
+	ch.batch = newBatcher(1, opts.FlushInterval, opts.MaxBufferSize, ch.post, opts.OnError)
+	return ch, nil
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (ch *ChatHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return ch.Handler.Enabled(ctx, level)
+}
+
+// Handle queues a message for the record unless the rate limit is exceeded.
+func (ch *ChatHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !ch.Enabled(ctx, record.Level) {
+		return nil
+	}
+	if ok, _ := ch.limiter.allow(); !ok {
+		return nil
+	}
+	ch.limiter.mu.Lock()
+	suppressed := ch.limiter.takeDropped(ch.limiter.now())
+	ch.limiter.mu.Unlock()
+
+	payload, err := ch.encode(ch.buildMessage(record, suppressed))
+	if err != nil {
+		return fmt.Errorf("failed to encode chat message: %w", err)
+	}
+	return ch.batch.add(ctx, payload)
+}
+
+// WithAttrs creates a new handler with the given attributes; the rate limit is shared.
+func (ch *ChatHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *ch
+	clone.Handler = ch.Handler.WithAttrs(attrs).(*CustomHandler)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name; the rate limit is shared.
+func (ch *ChatHandler) WithGroup(name string) slog.Handler {
+	clone := *ch
+	clone.Handler = ch.Handler.WithGroup(name).(*CustomHandler)
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (ch *ChatHandler) SetLevel(level string) error {
+	return ch.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (ch *ChatHandler) GetLevel() string {
+	return ch.Handler.GetLevel()
+}
+
+// Suppressed returns the total number of messages suppressed by the rate limit.
+func (ch *ChatHandler) Suppressed() uint64 {
+	ch.limiter.mu.Lock()
+	defer ch.limiter.mu.Unlock()
+	return ch.limiter.totalDropped
+}
+
+// Flush sends all queued messages.
+func (ch *ChatHandler) Flush() error {
+	return ch.batch.Flush()
+}
+
+// Close sends all queued messages and stops the background flush loop.
+func (ch *ChatHandler) Close() error {
+	return ch.batch.Close()
+}
+
+// customHandler implements customHandlerProvider.
+func (ch *ChatHandler) customHandler() CustomHandlerInterface {
+	return ch.Handler
+}
+
+// buildMessage prepares the message of the record, with the attributes sorted
+// by key and at most chatMaxFields fields.
+func (ch *ChatHandler) buildMessage(record slog.Record, suppressed uint64) *chatMessage {
+	data := ch.Handler.templateRecord(record)
+	msg := &chatMessage{
+		time:       data.Time,
+		title:      truncate("["+data.Level+"] "+data.Message, chatMaxTitle),
+		level:      GetLevelName(record.Level),
+		suppressed: suppressed,
+	}
+	if data.Source != "" {
+		msg.fields = append(msg.fields, [2]string{slog.SourceKey, data.Source})
+	}
+	keys := make([]string, 0, len(data.Attrs))
+	for key := range data.Attrs {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if len(msg.fields) == chatMaxFields {
+			break
+		}
+		msg.fields = append(msg.fields, [2]string{key, truncate(fmt.Sprint(data.Attrs[key]), chatMaxValue)})
+	}
+	return msg
+}
+
+// footer returns the footer text reporting suppressed messages, or "".
+func (msg *chatMessage) footer() string {
+	if msg.suppressed == 0 {
+		return ""
+	}
+	return strconv.FormatUint(msg.suppressed, 10) + " messages suppressed by rate limit"
+}
+
+// encodeSlackMessage encodes the message as a Slack attachment.
+func encodeSlackMessage(msg *chatMessage) ([]byte, error) {
+	fields := make([]map[string]any, 0, len(msg.fields))
+	for _, f := range msg.fields {
+		fields = append(fields, map[string]any{"title": f[0], "value": f[1], "short": len(f[1]) <= chatShortField})
+	}
+	attachment := map[string]any{
+		"fallback": msg.title,
+		"color":    fmt.Sprintf("#%06x", ChatLevelColors[msg.level]),
+		"title":    msg.title,
+		"fields":   fields,
+		"ts":       msg.time.Unix(),
+	}
+	if footer := msg.footer(); footer != "" {
+		attachment["footer"] = footer
+	}
+	return json.Marshal(map[string]any{"text": msg.title, "attachments": []any{attachment}})
+}
+
+// encodeDiscordMessage encodes the message as a Discord embed.
+func encodeDiscordMessage(msg *chatMessage) ([]byte, error) {
+	fields := make([]map[string]any, 0, len(msg.fields))
+	for _, f := range msg.fields {
+		fields = append(fields, map[string]any{"name": f[0], "value": f[1], "inline": len(f[1]) <= chatShortField})
+	}
+	embed := map[string]any{
+		"title":     msg.title,
+		"color":     ChatLevelColors[msg.level],
+		"fields":    fields,
+		"timestamp": msg.time.Format(time.RFC3339),
+	}
+	if footer := msg.footer(); footer != "" {
+		embed["footer"] = map[string]string{"text": footer}
+	}
+	return json.Marshal(map[string]any{"embeds": []any{embed}})
+}
+
+// post sends the messages one by one, retrying with jitter on failure.
+func (ch *ChatHandler) post(payloads [][]byte) error {
+	policy := ch.opts.retryPolicy()
+	policy.Jitter = true
+	for _, payload := range payloads {
+		err := policy.Do(func() error {
+			_, err := post(ch.client, ch.opts.URL, ContentTypeJSON, payload, ch.opts.Headers)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes, ending it with an ellipsis when cut.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n - 3
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
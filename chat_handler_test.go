@@ -0,0 +1,136 @@
+package multilog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewChatHandler_RequiresURL(t *testing.T) {
+	_, err := NewSlackHandler(ChatHandlerOptions{})
+	assert.ErrorContains(t, err, "slack")
+
+	_, err = NewDiscordHandler(ChatHandlerOptions{})
+	assert.ErrorContains(t, err, "discord")
+}
+
+func TestSlackHandler(t *testing.T) {
+	server := newIntakeServer(t)
+	handler, err := NewSlackHandler(ChatHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   ErrorLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		URL: server.URL,
+	})
+	assert.NoError(t, err)
+
+	logger := NewLogger(handler).WithField("service", "billing")
+	logger.Warn("ignored")
+	logger.Error("payment failed", "order", 42)
+	assert.NoError(t, handler.(*ChatHandler).Close())
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Len(t, server.requests, 1)
+	var msg struct {
+		Text        string `json:"text"`
+		Attachments []struct {
+			Color  string `json:"color"`
+			Title  string `json:"title"`
+			Fields []struct {
+				Title string `json:"title"`
+				Value string `json:"value"`
+			} `json:"fields"`
+		} `json:"attachments"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(server.requests[0].body), &msg))
+	assert.Equal(t, "[ERROR] payment failed", msg.Text)
+	assert.Len(t, msg.Attachments, 1)
+	assert.Equal(t, "#d00000", msg.Attachments[0].Color)
+	assert.Len(t, msg.Attachments[0].Fields, 2)
+	assert.Equal(t, "order", msg.Attachments[0].Fields[0].Title)
+	assert.Equal(t, "42", msg.Attachments[0].Fields[0].Value)
+	assert.Equal(t, "service", msg.Attachments[0].Fields[1].Title)
+}
+
+func TestDiscordHandler_RateLimit(t *testing.T) {
+	server := newIntakeServer(t)
+	handler, err := NewDiscordHandler(ChatHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   ErrorLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		URL:                 server.URL,
+		MaxRecordsPerSecond: 0.001,
+		Burst:               1,
+	})
+	assert.NoError(t, err)
+	ch := handler.(*ChatHandler)
+
+	logger := NewLogger(handler)
+	logger.Error("first")
+	logger.Error("second")
+	logger.Error("third")
+	assert.Equal(t, uint64(2), ch.Suppressed())
+
+	// Refill the bucket so the next message reports the suppressed ones.
+	ch.limiter.mu.Lock()
+	ch.limiter.tokens = 1
+	ch.limiter.mu.Unlock()
+	logger.Error("fourth")
+	assert.NoError(t, ch.Close())
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Len(t, server.requests, 2)
+	var msg struct {
+		Embeds []struct {
+			Footer struct {
+				Text string `json:"text"`
+			} `json:"footer"`
+			Title string `json:"title"`
+			Color int    `json:"color"`
+		} `json:"embeds"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(server.requests[1].body), &msg))
+	assert.Equal(t, "[ERROR] fourth", msg.Embeds[0].Title)
+	assert.Equal(t, 0xd00000, msg.Embeds[0].Color)
+	assert.Equal(t, "2 messages suppressed by rate limit", msg.Embeds[0].Footer.Text)
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "abcd...", truncate("abcdefghij", 7))
+	assert.Equal(t, "a...", truncate("aéééé", 5))
+	assert.LessOrEqual(t, len(truncate(strings.Repeat("x", 2000), chatMaxValue)), chatMaxValue)
+}
+
+func TestCreateHandler_Chat(t *testing.T) {
+	handlerCfg := HandlerConfig{
+		Type:                SlackHandlerType,
+		Level:               ErrorLevel,
+		Enabled:             true,
+		URL:                 "http://localhost",
+		MaxRecordsPerSecond: 1,
+	}
+	cfg := &Config{Multilog: LogConfig{Handlers: []HandlerConfig{handlerCfg}}}
+	assert.NoError(t, validateConfig(cfg))
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	assert.Len(t, handlers, 1)
+	ch, ok := handlers[0].(*ChatHandler)
+	assert.True(t, ok, "chat handlers apply the rate limit themselves")
+	if ok {
+		assert.NoError(t, ch.Close())
+	}
+}
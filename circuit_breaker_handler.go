@@ -0,0 +1,172 @@
+package multilog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultBreakerCooldown is the default time a tripped circuit breaker skips
+// its handler.
+const DefaultBreakerCooldown = 30 * time.Second
+
+// breaker counts consecutive failures and tracks whether the circuit is open.
+type breaker struct {
+	now       func() time.Time
+	openUntil time.Time
+	failures  int
+	threshold int
+	skipped   uint64
+	cooldown  time.Duration
+	probing   bool
+	mu        sync.Mutex
+}
+
+// allow reports whether a record may be passed to the handler. Once the
+// cooldown has passed, a single probe record is let through.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if !b.probing && !b.now().Before(b.openUntil) {
+		b.probing = true
+		return true
+	}
+	b.skipped++
+	return false
+}
+
+// record registers the result of a handler call. It returns whether the
+// circuit opened or closed, and how many records were skipped while it was open.
+func (b *breaker) record(err error) (opened, closed bool, skipped uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		b.failures = 0
+		if b.openUntil.IsZero() {
+			return false, false, 0
+		}
+		skipped = b.skipped
+		b.openUntil, b.probing, b.skipped = time.Time{}, false, 0
+		return false, true, skipped
+	}
+
+	b.failures++
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return false, false, 0
+	}
+	opened = b.openUntil.IsZero()
+	b.openUntil = b.now().Add(b.cooldown)
+	b.probing = false
+	return opened, false, 0
+}
+
+// CircuitBreakerHandler is a Handler that bounds the time spent in the wrapped
+// handler and stops calling it after repeated failures, so a broken sink does
+// not slow down the application. While the circuit is open records are
+// skipped; after the cooldown one record probes the handler and closes the
+// circuit if it succeeds.
+type CircuitBreakerHandler struct {
+	OnError func(err error)
+	breaker *breaker
+	wrappedHandler
+	timeout time.Duration
+}
+
+// NewCircuitBreakerHandler creates a handler that opens the circuit after
+// failures consecutive errors or timeouts, for the cooldown (DefaultBreakerCooldown
+// if zero). Zero failures never opens it. A positive timeout bounds each call
+// to the wrapped handler. Opening and closing the circuit is reported to
+// onError, which writes to stderr if nil.
+func NewCircuitBreakerHandler(
+	handler slog.Handler,
+	failures int,
+	cooldown, timeout time.Duration,
+	onError func(err error),
+) *CircuitBreakerHandler {
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	return &CircuitBreakerHandler{
+		OnError: onError,
+		breaker: &breaker{
+			now:       time.Now,
+			threshold: failures,
+			cooldown:  defaultDuration(cooldown, DefaultBreakerCooldown),
+		},
+		wrappedHandler: wrappedHandler{Handler: handler},
+		timeout:        timeout,
+	}
+}
+
+// Handle passes the record to the wrapped handler unless the circuit is open.
+// Skipped records are not reported as errors. Records queued by batching
+// handlers count as failures or successes once their batch is sent.
+func (cb *CircuitBreakerHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !cb.breaker.allow() {
+		return nil
+	}
+	ctx, report := withSendReport(ctx, cb.record)
+	err := cb.handle(ctx, record)
+	if err != nil || !report.deferred.Load() {
+		cb.record(err)
+	}
+	return err
+}
+
+// record passes the result of writing a record to the breaker, reporting
+// when the circuit opens or closes.
+func (cb *CircuitBreakerHandler) record(err error) {
+	opened, closed, skipped := cb.breaker.record(err)
+	switch {
+	case opened:
+		cb.OnError(fmt.Errorf("circuit opened after %d consecutive failures, skipping records for %s: %w",
+			cb.breaker.threshold, cb.breaker.cooldown, err))
+	case closed:
+		cb.OnError(fmt.Errorf("circuit closed, %d records were skipped", skipped))
+	}
+}
+
+// handle calls the wrapped handler, giving up after the timeout.
+func (cb *CircuitBreakerHandler) handle(ctx context.Context, record slog.Record) error {
+	if cb.timeout <= 0 {
+		return cb.Handler.Handle(ctx, record)
+	}
+	ctx, cancel := context.WithTimeout(ctx, cb.timeout)
+	done := make(chan error, 1)
+	go func(record slog.Record) {
+		defer cancel()
+		done <- cb.Handler.Handle(ctx, record)
+	}(record.Clone())
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("handler timed out after %s: %w", cb.timeout, ctx.Err())
+	}
+}
+
+// Skipped returns the number of records skipped since the circuit last opened.
+func (cb *CircuitBreakerHandler) Skipped() uint64 {
+	cb.breaker.mu.Lock()
+	defer cb.breaker.mu.Unlock()
+	return cb.breaker.skipped
+}
+
+// WithAttrs creates a new handler with the given attributes; the circuit is shared.
+func (cb *CircuitBreakerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *cb
+	clone.Handler = cb.Handler.WithAttrs(attrs)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name; the circuit is shared.
+func (cb *CircuitBreakerHandler) WithGroup(name string) slog.Handler {
+	clone := *cb
+	clone.Handler = cb.Handler.WithGroup(name)
+	return &clone
+}
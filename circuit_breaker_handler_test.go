@@ -0,0 +1,145 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyHandler counts calls and fails while err is set.
+type flakyHandler struct {
+	ErrorHandler
+	calls int
+}
+
+func (h *flakyHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.calls++
+	return h.ErrorHandler.Handle(ctx, r)
+}
+
+func TestCircuitBreakerHandler(t *testing.T) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)
+	writeErr := errors.New("connection refused")
+	inner := &flakyHandler{ErrorHandler: ErrorHandler{err: writeErr}}
+	var reported []string
+	cb := NewCircuitBreakerHandler(inner, 3, time.Minute, 0, func(err error) {
+		reported = append(reported, err.Error())
+	})
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cb.breaker.now = clock.Now
+
+	for range 3 {
+		assert.ErrorIs(t, cb.Handle(context.Background(), record), writeErr)
+	}
+	assert.Equal(t, []string{
+		"circuit opened after 3 consecutive failures, skipping records for 1m0s: connection refused",
+	}, reported)
+
+	// Open: records are skipped without calling the handler.
+	for range 5 {
+		assert.NoError(t, cb.Handle(context.Background(), record))
+	}
+	assert.Equal(t, 3, inner.calls)
+	assert.Equal(t, uint64(5), cb.Skipped())
+
+	// After the cooldown a failing probe reopens the circuit.
+	clock.Add(time.Minute)
+	assert.ErrorIs(t, cb.Handle(context.Background(), record), writeErr)
+	assert.NoError(t, cb.Handle(context.Background(), record))
+	assert.Equal(t, 4, inner.calls)
+
+	// A successful probe closes it.
+	clock.Add(time.Minute)
+	inner.err = nil
+	assert.NoError(t, cb.Handle(context.Background(), record))
+	assert.NoError(t, cb.Handle(context.Background(), record))
+	assert.Equal(t, 6, inner.calls)
+	assert.Equal(t, "circuit closed, 6 records were skipped", reported[len(reported)-1])
+	assert.Zero(t, cb.Skipped())
+}
+
+func TestCircuitBreakerHandler_Timeout(t *testing.T) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)
+	slow := newBlockingHandler()
+	var reported []error
+	cb := NewCircuitBreakerHandler(slow, 2, time.Minute, 5*time.Millisecond, func(err error) {
+		reported = append(reported, err)
+	})
+
+	err := cb.Handle(context.Background(), record)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "handler timed out after 5ms")
+	assert.Error(t, cb.Handle(context.Background(), record))
+	assert.Len(t, reported, 1)
+
+	assert.NoError(t, cb.Handle(context.Background(), record), "open circuit should skip the slow handler")
+}
+
+func TestCircuitBreakerHandler_Wrapped(t *testing.T) {
+	primary := NewConsoleHandler(ConsoleHandlerOptions{CustomHandlerOptions: CustomHandlerOptions{Level: InfoLevel, Enabled: true}})
+	cb := NewCircuitBreakerHandler(primary, 1, 0, 0, nil)
+
+	assert.Equal(t, DefaultBreakerCooldown, cb.breaker.cooldown)
+	assert.NoError(t, cb.SetLevel(DebugLevel))
+	assert.Equal(t, DebugLevel, cb.GetLevel())
+	derived := cb.WithAttrs([]slog.Attr{slog.String("k", "v")}).WithGroup("g")
+	assert.Same(t, cb.breaker, derived.(*CircuitBreakerHandler).breaker)
+
+	config, err := NewBuilder().
+		Console(CircuitBreaker(3, time.Second), Timeout(time.Second)).
+		Config()
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(config)
+	assert.NoError(t, err)
+	assert.IsType(t, &CircuitBreakerHandler{}, handlers[0])
+
+	_, err = NewBuilder().Console(CircuitBreaker(-1, 0)).Config()
+	assert.Error(t, err)
+}
+
+func TestCircuitBreakerHandler_Batching(t *testing.T) {
+	server := newIntakeServer(t)
+	server.fail = 1
+	handler, err := NewHTTPHandler(HTTPHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			OnError: func(error) {},
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		URL: server.URL,
+	})
+	assert.NoError(t, err)
+	var reported []string
+	cb := NewCircuitBreakerHandler(handler, 2, time.Minute, 0, func(err error) {
+		reported = append(reported, err.Error())
+	})
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cb.breaker.now = clock.Now
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)
+
+	// Queued records count once their batch fails.
+	assert.NoError(t, cb.Handle(context.Background(), record))
+	assert.NoError(t, cb.Handle(context.Background(), record))
+	assert.Empty(t, reported)
+	assert.Error(t, cb.Flush())
+	assert.Len(t, reported, 1)
+	assert.Contains(t, reported[0], "circuit opened after 2 consecutive failures")
+
+	assert.NoError(t, cb.Handle(context.Background(), record))
+	assert.Equal(t, uint64(1), cb.Skipped())
+
+	// The probe closes the circuit once its batch is sent.
+	clock.Add(time.Minute)
+	assert.NoError(t, cb.Handle(context.Background(), record))
+	assert.NoError(t, cb.Handle(context.Background(), record))
+	assert.NoError(t, cb.Flush())
+	assert.Equal(t, "circuit closed, 2 records were skipped", reported[len(reported)-1])
+	assert.NoError(t, cb.Close())
+}
@@ -0,0 +1,277 @@
+package multilog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+)
+
+// CloudWatch Logs PutLogEvents limits
+const (
+	CloudWatchMaxBatchEvents = 10000
+	CloudWatchMaxBatchBytes  = 1048576
+	CloudWatchEventOverhead  = 26
+	CloudWatchMaxEventBytes  = 262144 - CloudWatchEventOverhead
+	CloudWatchMaxBatchSpan   = 24 * time.Hour
+)
+
+// CloudWatch Logs error codes handled by the handler
+const (
+	CloudWatchResourceNotFound      = "ResourceNotFoundException"
+	CloudWatchResourceAlreadyExists = "ResourceAlreadyExistsException"
+)
+
+// CloudWatchEvent is a formatted log record to be put to CloudWatch Logs.
+type CloudWatchEvent struct {
+	Timestamp time.Time
+	Message   string
+}
+
+// CloudWatchLogsClient puts log events to CloudWatch Logs.
+// multilog does not depend on the AWS SDK; wrap the cloudwatchlogs client of
+// aws-sdk-go-v2 in this interface. Errors carrying an ErrorCode method, as AWS
+// SDK errors do, are matched by code; an InvalidSequenceTokenException should
+// be returned as a *CloudWatchSequenceTokenError.
+type CloudWatchLogsClient interface {
+	// PutLogEvents puts the events, in chronological order, to the stream and
+	// returns the next sequence token. sequenceToken is empty for the first call.
+	PutLogEvents(ctx context.Context, group, stream, sequenceToken string, events []CloudWatchEvent) (string, error)
+	CreateLogStream(ctx context.Context, group, stream string) error
+}
+
+// CloudWatchClientFactory creates a client for the given region, which is
+// empty to use the default of the AWS configuration. It must be set before
+// creating cloudwatch handlers from configuration.
+var CloudWatchClientFactory func(region string) (CloudWatchLogsClient, error)
+
+// CloudWatchSequenceTokenError is returned by CloudWatchLogsClient when the
+// sequence token was not the one expected by the stream.
+type CloudWatchSequenceTokenError struct {
+	Err           error
+	ExpectedToken string
+}
+
+// Error implements error.
+func (e *CloudWatchSequenceTokenError) Error() string {
+	return fmt.Sprintf("invalid sequence token, expected %q: %v", e.ExpectedToken, e.Err)
+}
+
+// Unwrap returns the underlying client error.
+func (e *CloudWatchSequenceTokenError) Unwrap() error {
+	return e.Err
+}
+
+// cloudWatchErrorCode returns the AWS error code of the error, if any.
+func cloudWatchErrorCode(err error) string {
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+// cloudWatchStream is the sequence token of the stream, shared by a handler
+// and its derived handlers. It is only used by the batcher's send, which is
+// never called concurrently.
+type cloudWatchStream struct {
+	token string
+}
+
+// CloudWatchHandler is a Handler that batches records and puts them to an AWS
+// CloudWatch Logs stream.
+type CloudWatchHandler struct {
+	Handler recordFormatter
+	client  CloudWatchLogsClient
+	batch   *batcher[CloudWatchEvent]
+	stream  *cloudWatchStream
+	opts    *CloudWatchHandlerOptions
+}
+
+// CloudWatchHandlerOptions are the options of CloudWatch handlers: the
+// formatting and batching options, and the log group, stream and region.
+type CloudWatchHandlerOptions struct {
+	CustomHandlerOptions
+	BatchOptions
+	LogGroup  string
+	LogStream string
+	Region    string
+}
+
+// NewCloudWatchHandler creates a CloudWatch Logs Handler with the specified
+// options and client. Records are written as lines formatted with the pattern,
+// or as JSON when SubType is "json", to the LogStream of the LogGroup; the
+// stream defaults to the host name and is created if it does not exist.
+// Batches are split to stay within the PutLogEvents limits, and messages
+// longer than CloudWatchMaxEventBytes are truncated.
+func NewCloudWatchHandler(opts CloudWatchHandlerOptions, client CloudWatchLogsClient) (slog.Handler, error) {
+	if client == nil {
+		return nil, fmt.Errorf("cloudwatch handler requires a client")
+	}
+	if opts.LogGroup == "" {
+		return nil, fmt.Errorf("cloudwatch handler requires a log group")
+	}
+	if opts.LogStream == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get host name for log stream: %w", err)
+		}
+		opts.LogStream = host
+	}
+
+	var handler recordFormatter
+	switch opts.SubType {
+	case "", TextHandlerSubType:
+		handler = NewCustomHandler(&opts.CustomHandlerOptions, bufio.NewWriter(io.Discard), nil)
+	case JSONHandlerSubType:
+		handler = newJSONHandler(opts.CustomHandlerOptions, nil, nil)
+	default:
+		return nil, fmt.Errorf("invalid cloudwatch handler subtype: %s", opts.SubType)
+	}
+
+	ch := &CloudWatchHandler{
+		Handler: handler,
+		client:  client,
+		stream:  &cloudWatchStream{},
+		opts:    &opts,
+	}
+	size := min(defaultIfZero(opts.BatchSize, DefaultBatchSize), CloudWatchMaxBatchEvents)
+	ch.batch = newBatcher(size, opts.FlushInterval, opts.MaxBufferSize, ch.put, opts.OnError)
+	return ch, nil
+}
+
+// newCloudWatchHandlerFromFactory creates a CloudWatch Logs Handler using CloudWatchClientFactory.
+func newCloudWatchHandlerFromFactory(opts CloudWatchHandlerOptions) (slog.Handler, error) {
+	if CloudWatchClientFactory == nil {
+		return nil, fmt.Errorf("cloudwatch handler requires multilog.CloudWatchClientFactory to be set")
+	}
+	client, err := CloudWatchClientFactory(opts.Region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cloudwatch client: %w", err)
+	}
+	return NewCloudWatchHandler(opts, client)
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (ch *CloudWatchHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return ch.Handler.Enabled(ctx, level)
+}
+
+// Handle formats the log record and queues it for the next put.
+func (ch *CloudWatchHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !ch.Enabled(ctx, record.Level) {
+		return nil
+	}
+	line, err := ch.Handler.Format(ctx, record)
+	if err != nil {
+		return err
+	}
+	return ch.batch.add(ctx, CloudWatchEvent{Timestamp: record.Time, Message: truncate(line, CloudWatchMaxEventBytes)})
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (ch *CloudWatchHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *ch
+	clone.Handler = ch.Handler.WithAttrs(attrs).(recordFormatter)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (ch *CloudWatchHandler) WithGroup(name string) slog.Handler {
+	clone := *ch
+	clone.Handler = ch.Handler.WithGroup(name).(recordFormatter)
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (ch *CloudWatchHandler) SetLevel(level string) error {
+	return ch.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (ch *CloudWatchHandler) GetLevel() string {
+	return ch.Handler.GetLevel()
+}
+
+// Flush puts all queued records.
+func (ch *CloudWatchHandler) Flush() error {
+	return ch.batch.Flush()
+}
+
+// Close puts all queued records and stops the background flush loop.
+func (ch *CloudWatchHandler) Close() error {
+	return ch.batch.Close()
+}
+
+// customHandler implements customHandlerProvider.
+func (ch *CloudWatchHandler) customHandler() CustomHandlerInterface {
+	return GetCustomHandler(ch.Handler)
+}
+
+// put sends the events in chronological order, split into requests within
+// the PutLogEvents limits.
+func (ch *CloudWatchHandler) put(events []CloudWatchEvent) error {
+	events = slices.Clone(events)
+	slices.SortStableFunc(events, func(a, b CloudWatchEvent) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	for _, batch := range splitCloudWatchEvents(events) {
+		err := ch.opts.retryPolicy().Do(func() error {
+			return ch.putBatch(batch)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to put %d cloudwatch events: %w", len(batch), err)
+		}
+	}
+	return nil
+}
+
+// putBatch puts one request, creating the stream if it does not exist and
+// retrying once with the expected sequence token if the token was stale.
+func (ch *CloudWatchHandler) putBatch(events []CloudWatchEvent) error {
+	ctx := context.Background()
+	group, stream := ch.opts.LogGroup, ch.opts.LogStream
+	token, err := ch.client.PutLogEvents(ctx, group, stream, ch.stream.token, events)
+	if cloudWatchErrorCode(err) == CloudWatchResourceNotFound {
+		if cerr := ch.client.CreateLogStream(ctx, group, stream); cerr != nil &&
+			cloudWatchErrorCode(cerr) != CloudWatchResourceAlreadyExists {
+			return fmt.Errorf("failed to create log stream: %w", cerr)
+		}
+		ch.stream.token = ""
+		token, err = ch.client.PutLogEvents(ctx, group, stream, "", events)
+	}
+	var tokenErr *CloudWatchSequenceTokenError
+	if errors.As(err, &tokenErr) {
+		token, err = ch.client.PutLogEvents(ctx, group, stream, tokenErr.ExpectedToken, events)
+	}
+	if err != nil {
+		return err
+	}
+	ch.stream.token = token
+	return nil
+}
+
+// splitCloudWatchEvents splits chronologically ordered events into batches
+// within the event count, size and time span limits of PutLogEvents.
+func splitCloudWatchEvents(events []CloudWatchEvent) [][]CloudWatchEvent {
+	var batches [][]CloudWatchEvent
+	start, size := 0, 0
+	for i, event := range events {
+		eventSize := len(event.Message) + CloudWatchEventOverhead
+		if i > start && (i-start >= CloudWatchMaxBatchEvents || size+eventSize > CloudWatchMaxBatchBytes ||
+			event.Timestamp.Sub(events[start].Timestamp) > CloudWatchMaxBatchSpan) {
+			batches = append(batches, events[start:i])
+			start, size = i, 0
+		}
+		size += eventSize
+	}
+	if start < len(events) {
+		batches = append(batches, events[start:])
+	}
+	return batches
+}
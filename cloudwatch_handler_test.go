@@ -0,0 +1,220 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// apiError is an error with an AWS error code.
+type apiError struct {
+	code string
+}
+
+func (e *apiError) Error() string     { return e.code }
+func (e *apiError) ErrorCode() string { return e.code }
+
+type mockCloudWatchClient struct {
+	streams map[string]string
+	batches [][]CloudWatchEvent
+	tokens  []string
+	created []string
+	mu      sync.Mutex
+}
+
+func newMockCloudWatchClient() *mockCloudWatchClient {
+	return &mockCloudWatchClient{streams: map[string]string{}}
+}
+
+func (c *mockCloudWatchClient) PutLogEvents(
+	_ context.Context,
+	group, stream, sequenceToken string,
+	events []CloudWatchEvent,
+) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expected, ok := c.streams[group+"/"+stream]
+	if !ok {
+		return "", &apiError{code: CloudWatchResourceNotFound}
+	}
+	c.tokens = append(c.tokens, sequenceToken)
+	if sequenceToken != expected {
+		return "", &CloudWatchSequenceTokenError{Err: &apiError{code: "InvalidSequenceTokenException"},
+			ExpectedToken: expected}
+	}
+	c.batches = append(c.batches, events)
+	next := strconv.Itoa(len(c.batches))
+	c.streams[group+"/"+stream] = next
+	return next, nil
+}
+
+func (c *mockCloudWatchClient) CreateLogStream(_ context.Context, group, stream string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.created = append(c.created, group+"/"+stream)
+	if _, ok := c.streams[group+"/"+stream]; ok {
+		return &apiError{code: CloudWatchResourceAlreadyExists}
+	}
+	c.streams[group+"/"+stream] = ""
+	return nil
+}
+
+func TestNewCloudWatchHandler_Validation(t *testing.T) {
+	_, err := NewCloudWatchHandler(CloudWatchHandlerOptions{LogGroup: "app"}, nil)
+	assert.EqualError(t, err, "cloudwatch handler requires a client")
+	_, err = NewCloudWatchHandler(CloudWatchHandlerOptions{}, newMockCloudWatchClient())
+	assert.EqualError(t, err, "cloudwatch handler requires a log group")
+	_, err = NewCloudWatchHandler(CloudWatchHandlerOptions{CustomHandlerOptions: CustomHandlerOptions{SubType: "xml"}, LogGroup: "app"}, newMockCloudWatchClient())
+	assert.EqualError(t, err, "invalid cloudwatch handler subtype: xml")
+}
+
+func TestCloudWatchHandler_Put(t *testing.T) {
+	client := newMockCloudWatchClient()
+	handler, err := NewCloudWatchHandler(CloudWatchHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+		},
+		BatchOptions: BatchOptions{
+			BatchSize:     10,
+			FlushInterval: time.Hour,
+		},
+		LogGroup:  "app",
+		LogStream: "web-1",
+	}, client)
+	assert.NoError(t, err)
+	ch := handler.(*CloudWatchHandler)
+
+	logger := slog.New(handler).With("service", "api")
+	logger.Info("first")
+	logger.Error("second")
+	assert.NoError(t, ch.Flush())
+	logger.Info("third")
+	assert.NoError(t, ch.Close())
+
+	assert.Equal(t, []string{"app/web-1"}, client.created, "stream is created on first put")
+	assert.Len(t, client.batches, 2)
+	assert.Len(t, client.batches[0], 2)
+	assert.Contains(t, client.batches[0][0].Message, "first")
+	assert.Contains(t, client.batches[0][0].Message, "service=api")
+	assert.Equal(t, []string{"", "1"}, client.tokens, "next token is passed to the following put")
+}
+
+func TestCloudWatchHandler_SequenceToken(t *testing.T) {
+	client := newMockCloudWatchClient()
+	client.streams["app/web-1"] = "42"
+	handler, err := NewCloudWatchHandler(CloudWatchHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			SubType: JSONHandlerSubType,
+		},
+		LogGroup:  "app",
+		LogStream: "web-1",
+	}, client)
+	assert.NoError(t, err)
+
+	slog.New(handler).Info("hello", "id", 7)
+	assert.NoError(t, handler.(*CloudWatchHandler).Close())
+
+	assert.Empty(t, client.created)
+	assert.Equal(t, []string{"", "42"}, client.tokens)
+	assert.Len(t, client.batches, 1)
+	assert.Contains(t, client.batches[0][0].Message, `"id":7`)
+}
+
+func TestSplitCloudWatchEvents(t *testing.T) {
+	start := time.Now()
+	events := make([]CloudWatchEvent, CloudWatchMaxBatchEvents+1)
+	for i := range events {
+		events[i] = CloudWatchEvent{Timestamp: start, Message: "x"}
+	}
+	batches := splitCloudWatchEvents(events)
+	assert.Len(t, batches, 2)
+	assert.Len(t, batches[0], CloudWatchMaxBatchEvents)
+
+	big := strings.Repeat("x", CloudWatchMaxEventBytes)
+	events = []CloudWatchEvent{
+		{Timestamp: start, Message: big},
+		{Timestamp: start, Message: big},
+		{Timestamp: start, Message: big},
+		{Timestamp: start, Message: big},
+		{Timestamp: start, Message: "x"},
+		{Timestamp: start.Add(25 * time.Hour), Message: "x"},
+	}
+	batches = splitCloudWatchEvents(events)
+	assert.Len(t, batches, 3)
+	assert.Len(t, batches[0], 4, "four maximum events fit in a request")
+	assert.Len(t, batches[1], 1)
+	assert.Len(t, batches[2], 1, "a request spans at most 24 hours")
+}
+
+func TestCloudWatchHandler_OrderAndTruncate(t *testing.T) {
+	client := newMockCloudWatchClient()
+	handler, err := NewCloudWatchHandler(CloudWatchHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[msg]",
+		},
+		LogGroup:  "app",
+		LogStream: "web-1",
+	}, client)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	late := slog.NewRecord(now, slog.LevelInfo, strings.Repeat("é", CloudWatchMaxEventBytes), 0)
+	early := slog.NewRecord(now.Add(-time.Second), slog.LevelInfo, "early", 0)
+	assert.NoError(t, handler.Handle(context.Background(), late))
+	assert.NoError(t, handler.Handle(context.Background(), early))
+	assert.NoError(t, handler.(*CloudWatchHandler).Close())
+
+	events := client.batches[0]
+	assert.Contains(t, events[0].Message, "early")
+	assert.LessOrEqual(t, len(events[1].Message), CloudWatchMaxEventBytes)
+	assert.True(t, strings.HasSuffix(events[1].Message, "..."))
+}
+
+func TestCloudWatchHandler_FromConfig(t *testing.T) {
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: cloudwatch
+      level: info
+      enabled: true
+      log_group: app
+      region: eu-west-1`))
+	assert.NoError(t, err)
+
+	_, err = CreateHandlers(cfg)
+	assert.ErrorContains(t, err, "CloudWatchClientFactory")
+
+	CloudWatchClientFactory = func(region string) (CloudWatchLogsClient, error) {
+		assert.Equal(t, "eu-west-1", region)
+		return newMockCloudWatchClient(), nil
+	}
+	defer func() { CloudWatchClientFactory = nil }()
+
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	ch := handlers[0].(*CloudWatchHandler)
+	assert.NotEmpty(t, ch.opts.LogStream, "stream defaults to the host name")
+	assert.NoError(t, ch.SetLevel(DebugLevel))
+	assert.Equal(t, DebugLevel, ch.GetLevel())
+	assert.NoError(t, ch.Close())
+
+	_, err = NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: cloudwatch
+      level: info
+      enabled: true`))
+	assert.ErrorContains(t, err, "cloudwatch handler requires a log group")
+}
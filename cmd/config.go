@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/phani-kb/multilog"
+)
+
+// defaultConfigPath is the config file used when -config is not set.
+const defaultConfigPath = "config.yml"
+
+// scaffoldConfig is the commented config written by the init command.
+const scaffoldConfig = `# multilog configuration.
+# Values may reference environment variables as ${VAR} or ${VAR:-default}.
+# Check this file with: multilog validate -config config.yml
+multilog:
+  # Attributes added to every record.
+  # fields:
+  #   service: my-service
+
+  handlers:
+    # Human-readable output on stdout.
+    - name: console
+      type: console
+      level: ${LOG_LEVEL:-info} # debug, info, warn, error, or perf
+      enabled: true
+      pattern: "[time] [level] [msg]"
+      # color: true          # colorize levels on terminals
+      # pretty: true         # render error records across multiple lines
+      # split_output: true   # write warn and error records to stderr
+
+    # Rotated log file for later inspection.
+    - name: app
+      type: file
+      subtype: text # or json
+      level: debug
+      enabled: true
+      file: logs/app.log
+      pattern: "[datetime] [level] [source] [msg]"
+      max_size: 5 # megabytes before rotation
+      max_backups: 3
+      max_age: 7 # days
+      # compress: true
+
+  # Per-environment settings, selected with -profile or MULTILOG_PROFILE.
+  profiles:
+    prod:
+      handlers:
+        console:
+          level: warn
+        app:
+          level: info
+`
+
+// runValidate validates config files, reporting every problem found in them.
+func runValidate(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	configPath := flags.String("config", defaultConfigPath, "Path to configuration file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{*configPath}
+	}
+
+	problems := 0
+	for _, path := range paths {
+		err := multilog.ValidateConfigFile(path)
+		if err == nil {
+			_, _ = fmt.Fprintf(stdout, "%s: ok\n", path)
+			continue
+		}
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+		for _, err := range errs {
+			_, _ = fmt.Fprintf(stdout, "%s: %v\n", path, err)
+		}
+		problems += len(errs)
+	}
+	if problems > 0 {
+		return fmt.Errorf("problems found: %d", problems)
+	}
+	return nil
+}
+
+// runInit writes a commented config file to start from.
+func runInit(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	configPath := flags.String("config", defaultConfigPath, "Path of the config file to write, - for stdout")
+	force := flags.Bool("force", false, "Overwrite an existing config file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *configPath == "-" {
+		_, err := io.WriteString(stdout, scaffoldConfig)
+		return err
+	}
+	mode := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(*configPath, mode, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists, use -force to overwrite it", *configPath)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(file, scaffoldConfig); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "%s written\n", *configPath)
+	return nil
+}
+
+// runEffective prints the options the enabled handlers of a config are created
+// with, once includes, profiles, environment overrides, and defaults are applied.
+func runEffective(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("effective", flag.ContinueOnError)
+	configPath := flags.String("config", defaultConfigPath, "Path to configuration file")
+	profile := flags.String("profile", "", "Config profile to apply, overriding "+multilog.ProfileEnvVar)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := multilog.NewConfigWithProfile(*configPath, *profile)
+	if err != nil {
+		return err
+	}
+	handlers, err := cfg.Effective()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Multilog.Profile != "" {
+		_, _ = fmt.Fprintf(stdout, "profile: %s\n", cfg.Multilog.Profile)
+	}
+	for i, handler := range handlers {
+		name := handler.Config.Type
+		if handler.Config.Name != "" {
+			name = handler.Config.Name + " (" + handler.Config.Type + ")"
+		}
+		_, _ = fmt.Fprintf(stdout, "handler %d: %s\n", i+1, name)
+		printOptions(stdout, handler.Options)
+	}
+	return nil
+}
+
+// runSchema prints the JSON Schema of the config file.
+func runSchema(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("schema", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	data, err := multilog.ConfigJSONSchema()
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(data)
+	return err
+}
+
+// secretOptions are the options whose values are not printed.
+var secretOptions = []string{"APIKey", "DSN", "Headers"}
+
+// printOptions prints the options that are set, one per line, in field order.
+// The fields of embedded options, such as the formatting options, are printed
+// in place.
+func printOptions(w io.Writer, options any) {
+	printFields(w, reflect.ValueOf(options))
+}
+
+// printFields prints the fields of the struct value that are set.
+func printFields(w io.Writer, value reflect.Value) {
+	for i := range value.NumField() {
+		field, v := value.Type().Field(i), value.Field(i)
+		if field.Anonymous && v.Kind() == reflect.Struct {
+			printFields(w, v)
+			continue
+		}
+		if v.IsZero() || v.Kind() == reflect.Func {
+			continue
+		}
+		text := "<redacted>"
+		if !multilog.Contains(secretOptions, field.Name) {
+			text = formatOption(v)
+		}
+		_, _ = fmt.Fprintf(w, "  %s: %s\n", field.Name, text)
+	}
+}
+
+// formatOption formats an option value.
+func formatOption(v reflect.Value) string {
+	switch value := v.Interface().(type) {
+	case string:
+		return strconv.Quote(value)
+	case time.Duration:
+		return value.String()
+	case *time.Location:
+		return value.String()
+	case os.FileMode:
+		return fmt.Sprintf("%04o", uint32(value))
+	case []string, map[string]string:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(value)
+	}
+}
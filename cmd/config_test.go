@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunInit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	out := &bytes.Buffer{}
+	assert.NoError(t, runInit([]string{"-config", path}, out))
+	assert.Equal(t, path+" written\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, runValidate([]string{"-config", path}, out))
+	assert.Equal(t, path+": ok\n", out.String())
+
+	err := runInit([]string{"-config", path}, out)
+	assert.ErrorContains(t, err, "already exists, use -force to overwrite it")
+	assert.NoError(t, runInit([]string{"-config", path, "-force"}, out))
+
+	out.Reset()
+	assert.NoError(t, runInit([]string{"-config", "-"}, out))
+	assert.Equal(t, scaffoldConfig, out.String())
+}
+
+func TestRunValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yml")
+	invalid := filepath.Join(dir, "invalid.yml")
+	assert.NoError(t, os.WriteFile(valid, []byte(scaffoldConfig), 0o600))
+	assert.NoError(t, os.WriteFile(invalid, []byte(`
+multilog:
+  handlers:
+    - type: console
+      level: loud
+      escape: html
+`), 0o600))
+
+	out := &bytes.Buffer{}
+	err := runValidate([]string{valid, invalid}, out)
+	assert.EqualError(t, err, "problems found: 2")
+	assert.Equal(t, valid+": ok\n"+
+		invalid+": handler validation failed: handler 1: invalid log level: loud\n"+
+		invalid+": handler validation failed: handler 1: invalid escape mode: html\n", out.String())
+}
+
+func TestRunEffective(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	intake := filepath.Join(filepath.Dir(path), "intake.yml")
+	assert.NoError(t, os.WriteFile(intake, []byte(`
+multilog:
+  handlers:
+    - name: intake
+      type: http
+      level: error
+      url: https://logs.example.com/intake
+      api_key: s3cret
+      enabled: true
+`), 0o600))
+	assert.NoError(t, os.WriteFile(path, []byte("include: intake.yml\n"+scaffoldConfig), 0o600))
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, runEffective([]string{"-config", path, "-profile", "prod"}, out))
+	output := out.String()
+	assert.True(t, strings.HasPrefix(output, "profile: prod\nhandler 1: intake (http)\n"), output)
+	assert.Contains(t, output, "handler 2: console (console)\n")
+	assert.Contains(t, output, "handler 3: app (file)\n")
+	assert.Contains(t, output, "  Level: \"warn\"\n")
+	assert.Contains(t, output, "  MaxAge: 7\n")
+	assert.Contains(t, output, "  FlushInterval: 5s\n")
+	assert.Contains(t, output, "  APIKey: <redacted>\n")
+	assert.NotContains(t, output, "s3cret")
+}
+
+func TestRunSchema(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.NoError(t, runSchema(nil, out))
+	assert.Contains(t, out.String(), `"$schema": "https://json-schema.org/draft/2020-12/schema"`)
+}
@@ -4,24 +4,78 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
+	"os"
+	"sort"
 	"strings"
 
 	"github.com/phani-kb/multilog"
 )
 
+// commands are the subcommands by name; without one, the demo runs.
+var commands = map[string]func(args []string, stdout io.Writer) error{
+	"validate":  runValidate,
+	"init":      runInit,
+	"effective": runEffective,
+	"schema":    runSchema,
+	"view":      runView,
+}
+
 func main() {
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command, ok := commands[os.Args[1]]
+		if !ok {
+			log.Fatalf("Error: unknown command %q, expected one of %s", os.Args[1], commandNames())
+		}
+		if err := command(os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
 	// Parse command line flags
 	configPath := flag.String("config", "config.yml", "Path to configuration file")
+	profile := flag.String("profile", "", "Config profile to apply, overriding "+multilog.ProfileEnvVar)
+	verifyAudit := flag.String("verify-audit", "", "Verify the hash chain of an audit log file and exit")
+	schema := flag.Bool("schema", false, "Print the JSON Schema of the config file and exit")
 	flag.Parse()
 
-	if err := run(*configPath); err != nil {
+	if *schema {
+		data, err := multilog.ConfigJSONSchema()
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		fmt.Print(string(data))
+		return
+	}
+
+	if *verifyAudit != "" {
+		n, err := multilog.VerifyAuditFile(*verifyAudit)
+		if err != nil {
+			log.Fatalf("Error: %s: %v", *verifyAudit, err)
+		}
+		fmt.Printf("%s: %d records verified\n", *verifyAudit, n)
+		return
+	}
+
+	if err := run(*configPath, *profile); err != nil {
 		log.Fatalf("Error: %v", err)
 	}
 }
 
-func run(configPath string) error {
+// commandNames returns the names of the subcommands, sorted.
+func commandNames() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func run(configPath, profile string) error {
 	handler := slog.Default().Handler()
 	if handler != nil {
 		// Check if it's not a default handler by checking for the package name
@@ -33,7 +87,7 @@ func run(configPath string) error {
 	}
 
 	// Parse and validate config using multilog's built-in validation
-	cfg, err := multilog.NewConfig(configPath)
+	cfg, err := multilog.NewConfigWithProfile(configPath, profile)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -48,7 +102,7 @@ func run(configPath string) error {
 	logger := multilog.NewLogger(hs...)
 	slog.SetDefault(logger.Logger)
 	logMessages(logger)
-	return nil
+	return logger.Close()
 }
 
 func logMessages(logger *multilog.Logger) {
@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phani-kb/multilog"
+)
+
+// viewPollInterval is how often a followed file is checked for new records.
+var viewPollInterval = 250 * time.Millisecond
+
+// Keys of the level, message, and time fields of JSON records, covering the
+// default, ECS, and GCP layouts.
+var (
+	viewLevelKeys   = []string{slog.LevelKey, multilog.ECSLevelKey, multilog.GCPSeverityKey}
+	viewMessageKeys = []string{slog.MessageKey, "message"}
+	viewTimeKeys    = []string{slog.TimeKey, "datetime", multilog.ECSTimestampKey, "timestamp"}
+)
+
+// viewLevelAliases maps level names used by other layouts to multilog levels.
+var viewLevelAliases = map[string]string{
+	"warning":   multilog.WarnLevel,
+	"notice":    multilog.InfoLevel,
+	"critical":  multilog.ErrorLevel,
+	"alert":     multilog.ErrorLevel,
+	"emergency": multilog.ErrorLevel,
+}
+
+// Levels in text records: full names, or single letters in brackets.
+var (
+	textLevelPattern  = regexp.MustCompile(`\b(DEBUG|INFO|WARN|ERROR|PERF)\b`)
+	textLetterPattern = regexp.MustCompile(`\[([DIWEP])\]`)
+)
+
+// viewAttr is an attribute a record must have to be shown.
+type viewAttr struct {
+	key   string
+	value string
+}
+
+// viewAttrs is a repeatable key=value flag.
+type viewAttrs []viewAttr
+
+func (a *viewAttrs) String() string {
+	pairs := make([]string, 0, len(*a))
+	for _, attr := range *a {
+		pairs = append(pairs, attr.key+"="+attr.value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (a *viewAttrs) Set(value string) error {
+	key, v, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid attr %q, expected key=value", value)
+	}
+	*a = append(*a, viewAttr{key: key, value: v})
+	return nil
+}
+
+// viewField is a field of a JSON record; nested keys are joined with dots.
+type viewField struct {
+	key   string
+	value string
+}
+
+// viewRecord is a log record: a JSON object, or a text line with the indented
+// lines that follow it, such as stack traces and pretty-printed attributes.
+type viewRecord struct {
+	fields     []viewField
+	lines      []string
+	level      slog.Level
+	levelStart int
+	levelEnd   int
+	hasLevel   bool
+}
+
+// viewer filters records read line by line and writes them to out.
+type viewer struct {
+	out         io.Writer
+	partial     string
+	attrs       viewAttrs
+	pending     []string
+	minLevel    slog.Level
+	filterLevel bool
+	color       bool
+	kept        bool
+}
+
+// runView prints the records of a JSON or text log file written by multilog,
+// filtered by level and attributes, with JSON records pretty-printed.
+func runView(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("view", flag.ContinueOnError)
+	level := flags.String("level", "", "Minimum level of the records shown")
+	follow := flags.Bool("follow", false, "Keep reading records appended to the file")
+	flags.BoolVar(follow, "f", false, "Shorthand for -follow")
+	lines := flags.Int("n", 0, "Start with the last n lines of the file, 0 for all of them")
+	colorMode := flags.String("color", "auto", "Colorize levels: auto, always, or never")
+	v := &viewer{out: stdout}
+	flags.Var(&v.attrs, "attr", "Only show records with the attribute key=value, may be repeated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("view takes one log file")
+	}
+
+	if *level != "" {
+		name := strings.ToLower(*level)
+		if !multilog.Contains(multilog.LogLevels, name) {
+			return fmt.Errorf("invalid log level: %s", *level)
+		}
+		v.minLevel, v.filterLevel = multilog.GetSlogLevel(name), true
+	}
+	switch *colorMode {
+	case "auto":
+		file, ok := stdout.(*os.File)
+		v.color = ok && multilog.ColorEnabled(file)
+	case "always":
+		v.color = true
+	case "never":
+	default:
+		return fmt.Errorf("invalid color mode: %s, expected auto, always, or never", *colorMode)
+	}
+
+	path := flags.Arg(0)
+	if path == "" || path == "-" {
+		err := v.read(bufio.NewReader(os.Stdin), *lines)
+		v.end()
+		return err
+	}
+	if !*follow {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		err = v.read(bufio.NewReader(file), *lines)
+		v.end()
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return v.follow(ctx, path, *lines)
+}
+
+// read passes the complete lines of r to line, only the last n of them when n
+// is positive. The unterminated line at the end of r is kept in partial, to be
+// completed by the next read.
+func (v *viewer) read(r *bufio.Reader, n int) error {
+	var last []string
+	for {
+		text, err := r.ReadString('\n')
+		text, v.partial = v.partial+text, ""
+		if err != nil {
+			for _, line := range last {
+				v.line(line)
+			}
+			v.partial = text
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		text = strings.TrimRight(text, "\r\n")
+		if n <= 0 {
+			v.line(text)
+			continue
+		}
+		if last = append(last, text); len(last) > n {
+			last = last[1:]
+		}
+	}
+}
+
+// end writes the last record, including the unterminated line of the input.
+func (v *viewer) end() {
+	if v.partial != "" {
+		v.line(strings.TrimRight(v.partial, "\r"))
+		v.partial = ""
+	}
+	v.flush()
+}
+
+// follow prints the records of the file and those appended to it until the
+// context is done, reopening the file when it is rotated or truncated.
+func (v *viewer) follow(ctx context.Context, path string, n int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	reader := bufio.NewReader(file)
+	err = v.read(reader, n)
+	for err == nil {
+		v.flush()
+		select {
+		case <-ctx.Done():
+			v.end()
+			return nil
+		case <-time.After(viewPollInterval):
+		}
+
+		if reopened, ok := reopenRotated(file, path); ok {
+			_ = file.Close()
+			file, v.partial = reopened, ""
+			reader.Reset(file)
+		}
+		err = v.read(reader, 0)
+	}
+	return err
+}
+
+// reopenRotated opens the file at path again when it is no longer the open
+// file, or has been truncated below the offset read up to.
+func reopenRotated(file *os.File, path string) (*os.File, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	current, err := file.Stat()
+	if err != nil {
+		return nil, false
+	}
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil || (os.SameFile(info, current) && info.Size() >= offset) {
+		return nil, false
+	}
+	reopened, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	return reopened, true
+}
+
+// line adds a line to the record being read. Indented lines continue the
+// record; other lines start a new one, writing the previous record.
+func (v *viewer) line(text string) {
+	if text != "" && (text[0] == ' ' || text[0] == '\t') {
+		if len(v.pending) > 0 {
+			v.pending = append(v.pending, text)
+		} else if v.kept {
+			_, _ = fmt.Fprintln(v.out, text)
+		}
+		return
+	}
+	v.flush()
+	if text != "" {
+		v.pending = []string{text}
+	}
+}
+
+// flush writes the record being read if it passes the filters.
+func (v *viewer) flush() {
+	if len(v.pending) == 0 {
+		return
+	}
+	record := parseViewRecord(v.pending)
+	v.pending = nil
+	if v.kept = v.matches(record); v.kept {
+		_, _ = io.WriteString(v.out, v.render(record))
+	}
+}
+
+// matches reports whether the record passes the level and attribute filters.
+// Records without a level are only shown when no level is set.
+func (v *viewer) matches(record viewRecord) bool {
+	if v.filterLevel && (!record.hasLevel || record.level < v.minLevel) {
+		return false
+	}
+	for _, attr := range v.attrs {
+		value, ok := record.attr(attr.key)
+		if !ok || value != attr.value {
+			return false
+		}
+	}
+	return true
+}
+
+// render returns the record as written by the viewer: JSON records as a line
+// of time, level, and message followed by a line per field, text records as is.
+func (v *viewer) render(record viewRecord) string {
+	var b strings.Builder
+	if record.fields == nil {
+		line := record.lines[0]
+		if record.hasLevel {
+			level := line[record.levelStart:record.levelEnd]
+			line = line[:record.levelStart] + v.colorize(level, record.level) + line[record.levelEnd:]
+		}
+		b.WriteString(line)
+		for _, line := range record.lines[1:] {
+			b.WriteString("\n" + line)
+		}
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	var header []string
+	if field, ok := findViewField(record.fields, viewTimeKeys); ok {
+		header = append(header, field.value)
+		if v.color {
+			header[0] = multilog.Colorize(field.value, multilog.GetColor(nil, multilog.TimeColorKey))
+		}
+	}
+	if field, ok := findViewField(record.fields, viewLevelKeys); ok {
+		header = append(header, v.colorize(strings.ToUpper(field.value), record.level))
+	}
+	if field, ok := findViewField(record.fields, viewMessageKeys); ok {
+		header = append(header, field.value)
+	}
+	b.WriteString(strings.Join(header, " "))
+	for _, field := range record.fields {
+		if multilog.Contains(viewTimeKeys, field.key) || multilog.Contains(viewLevelKeys, field.key) ||
+			multilog.Contains(viewMessageKeys, field.key) {
+			continue
+		}
+		b.WriteString("\n\t" + field.key + ": " + strings.ReplaceAll(field.value, "\n", "\n\t\t"))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// colorize colors the level text when color is enabled.
+func (v *viewer) colorize(text string, level slog.Level) string {
+	if !v.color {
+		return text
+	}
+	return multilog.Colorize(text, multilog.GetColor(nil, multilog.GetLevelName(level)))
+}
+
+// parseViewRecord parses the lines of a record: JSON when the first line is a
+// JSON object, text otherwise.
+func parseViewRecord(lines []string) viewRecord {
+	record := viewRecord{lines: lines}
+	if strings.HasPrefix(lines[0], "{") {
+		if fields, err := decodeViewFields([]byte(lines[0]), "", nil); err == nil {
+			record.fields = fields
+			if field, ok := findViewField(fields, viewLevelKeys); ok {
+				record.level, record.hasLevel = parseViewLevel(field.value)
+			}
+			return record
+		}
+	}
+
+	match := textLevelPattern.FindStringSubmatchIndex(lines[0])
+	if match == nil {
+		match = textLetterPattern.FindStringSubmatchIndex(lines[0])
+	}
+	if match != nil {
+		record.levelStart, record.levelEnd = match[2], match[3]
+		record.level, record.hasLevel = parseViewLevel(lines[0][match[2]:match[3]])
+	}
+	return record
+}
+
+// parseViewLevel returns the level named by the value: a multilog level name
+// in any case, its first letter, or a level name of the ECS or GCP layouts.
+func parseViewLevel(value string) (slog.Level, bool) {
+	name := strings.ToLower(value)
+	if alias, ok := viewLevelAliases[name]; ok {
+		name = alias
+	}
+	if len(name) == 1 {
+		for _, level := range multilog.LogLevels {
+			if level[:1] == name {
+				name = level
+				break
+			}
+		}
+	}
+	level, ok := multilog.LevelMap[name]
+	return level, ok
+}
+
+// attr returns the value of the attribute: the field of a JSON record, or the
+// key=value pair of a text record, or its "key: value" line in pretty output.
+func (r viewRecord) attr(key string) (string, bool) {
+	if r.fields != nil {
+		field, ok := findViewField(r.fields, []string{key})
+		return field.value, ok
+	}
+	if value, ok := textAttr(r.lines[0], key); ok {
+		return value, true
+	}
+	for _, line := range r.lines[1:] {
+		k, value, ok := strings.Cut(strings.TrimSpace(line), ": ")
+		if ok && k == key {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// textAttr returns the value of the key=value pair in the line, unquoting
+// quoted values.
+func textAttr(line, key string) (string, bool) {
+	prefix := key + "="
+	for offset := 0; ; {
+		i := strings.Index(line[offset:], prefix)
+		if i < 0 {
+			return "", false
+		}
+		start := offset + i
+		offset = start + len(prefix)
+		if start > 0 && line[start-1] != ' ' {
+			continue
+		}
+		rest := line[offset:]
+		if strings.HasPrefix(rest, `"`) {
+			if quoted, err := strconv.QuotedPrefix(rest); err == nil {
+				value, _ := strconv.Unquote(quoted)
+				return value, true
+			}
+		}
+		value, _, _ := strings.Cut(rest, " ")
+		return value, true
+	}
+}
+
+// findViewField returns the first field with one of the keys.
+func findViewField(fields []viewField, keys []string) (viewField, bool) {
+	for _, field := range fields {
+		if multilog.Contains(keys, field.key) {
+			return field, true
+		}
+	}
+	return viewField{}, false
+}
+
+// decodeViewFields appends the fields of the JSON object to fields in order,
+// joining the keys of nested objects to their parent's with dots. String values
+// are unquoted; other values are kept as compact JSON.
+func decodeViewFields(data []byte, prefix string, fields []viewField) ([]viewField, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, errors.New("not a JSON object")
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := prefix + token.(string)
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+		switch raw[0] {
+		case '{':
+			if fields, err = decodeViewFields(raw, key+".", fields); err != nil {
+				return nil, err
+			}
+		case '"':
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, err
+			}
+			fields = append(fields, viewField{key: key, value: value})
+		default:
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, raw); err != nil {
+				return nil, err
+			}
+			fields = append(fields, viewField{key: key, value: compact.String()})
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
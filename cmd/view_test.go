@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/phani-kb/multilog"
+)
+
+// writeLog writes the lines to a log file and returns its path.
+func writeLog(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+	return path
+}
+
+func TestRunView_JSON(t *testing.T) {
+	path := writeLog(t,
+		`{"time":"2024-01-02T03:04:05Z","level":"INFO","msg":"started","component":"api"}`,
+		`{"time":"2024-01-02T03:04:06Z","level":"ERROR","msg":"query failed","component":"db",`+
+			`"err":{"message":"timeout","type":"*net.OpError"},"rows":3,"stacktrace":"main.run\nmain.main"}`,
+		`{"time":"2024-01-02T03:04:07Z","level":"WARN","msg":"slow query","component":"db","rows":3}`,
+	)
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, runView([]string{path}, out))
+	assert.Equal(t, `2024-01-02T03:04:05Z INFO started
+	component: api
+2024-01-02T03:04:06Z ERROR query failed
+	component: db
+	err.message: timeout
+	err.type: *net.OpError
+	rows: 3
+	stacktrace: main.run
+		main.main
+2024-01-02T03:04:07Z WARN slow query
+	component: db
+	rows: 3
+`, out.String())
+
+	out.Reset()
+	assert.NoError(t, runView([]string{"-level", "warn", "-attr", "component=db", "-attr", "rows=3", path}, out))
+	assert.Equal(t, 2, strings.Count(out.String(), "component: db"))
+	assert.NotContains(t, out.String(), "started")
+
+	out.Reset()
+	assert.NoError(t, runView([]string{"-level=error", "-attr", "err.message=timeout", path}, out))
+	assert.True(t, strings.HasPrefix(out.String(), "2024-01-02T03:04:06Z ERROR query failed\n"))
+	assert.NotContains(t, out.String(), "slow query")
+
+	out.Reset()
+	assert.NoError(t, runView([]string{"-n", "1", "-color", "always", path}, out))
+	assert.Equal(t, "\x1b[90m2024-01-02T03:04:07Z\x1b[0m \x1b[33mWARN\x1b[0m slow query\n\tcomponent: db\n\trows: 3\n",
+		out.String())
+}
+
+func TestRunView_Text(t *testing.T) {
+	path := writeLog(t,
+		`2024-01-02 03:04:05 [INFO] started component=api`,
+		`2024-01-02 03:04:06 [ERROR] query failed component=db query="select 1"`,
+		"\tstacktrace:",
+		"\t\tmain.main",
+		`2024-01-02 03:04:07 [W] slow query`,
+		"\tcomponent: db",
+		`not a record`,
+	)
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, runView([]string{"-level", "warn", path}, out))
+	assert.Equal(t, `2024-01-02 03:04:06 [ERROR] query failed component=db query="select 1"
+	stacktrace:
+		main.main
+2024-01-02 03:04:07 [W] slow query
+	component: db
+`, out.String())
+
+	out.Reset()
+	assert.NoError(t, runView([]string{"-attr", "component=db", path}, out))
+	assert.Contains(t, out.String(), "[ERROR] query failed")
+	assert.Contains(t, out.String(), "[W] slow query")
+	assert.NotContains(t, out.String(), "started")
+
+	out.Reset()
+	assert.NoError(t, runView([]string{"-attr", "query=select 1", "-color", "always", path}, out))
+	assert.True(t, strings.HasPrefix(out.String(),
+		"2024-01-02 03:04:06 ["+multilog.Colorize("ERROR", "red")+"] query failed"))
+
+	out.Reset()
+	assert.NoError(t, runView([]string{"-attr", "component=web", path}, out))
+	assert.Empty(t, out.String())
+}
+
+func TestRunView_Errors(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.EqualError(t, runView([]string{"-level", "loud", "app.log"}, out), "invalid log level: loud")
+	assert.EqualError(t, runView([]string{"-color", "sometimes", "app.log"}, out),
+		"invalid color mode: sometimes, expected auto, always, or never")
+	assert.ErrorContains(t, runView([]string{"-attr", "component", "app.log"}, out),
+		`invalid attr "component", expected key=value`)
+	assert.EqualError(t, runView([]string{"a.log", "b.log"}, out), "view takes one log file")
+	assert.ErrorIs(t, runView([]string{filepath.Join(t.TempDir(), "missing.log")}, out), os.ErrNotExist)
+}
+
+// syncBuffer is a buffer safe for concurrent use.
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestViewer_Follow(t *testing.T) {
+	interval := viewPollInterval
+	viewPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { viewPollInterval = interval })
+
+	path := writeLog(t, `{"level":"INFO","msg":"first"}`)
+	out := &syncBuffer{}
+	v := &viewer{out: out}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- v.follow(ctx, path, 0) }()
+
+	wait := func(text string) {
+		t.Helper()
+		assert.Eventually(t, func() bool { return strings.Contains(out.String(), text) },
+			time.Second, time.Millisecond)
+	}
+	wait("INFO first\n")
+
+	// Records written in parts are read once complete.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	assert.NoError(t, err)
+	_, err = file.WriteString(`{"level":"WARN",`)
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = file.WriteString(`"msg":"second"}` + "\n")
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	wait("WARN second\n")
+
+	// Rotated files are read from the start.
+	assert.NoError(t, os.Rename(path, path+".1"))
+	assert.NoError(t, os.WriteFile(path, []byte(`{"level":"ERROR","msg":"third"}`+"\n"), 0o600))
+	wait("ERROR third\n")
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Equal(t, "INFO first\nWARN second\nERROR third\n", out.String())
+}
@@ -0,0 +1,103 @@
+package multilog
+
+import (
+	"os"
+	"strings"
+)
+
+// NoColorEnv is the environment variable that disables colored output when set.
+// See https://no-color.org.
+const NoColorEnv = "NO_COLOR"
+
+// ANSI escape sequences
+const (
+	ansiEscape = "\x1b["
+	ansiReset  = "\x1b[0m"
+)
+
+// Color map keys for non-level segments
+const (
+	TimeColorKey = "time"
+	MsgColorKey  = "msg"
+)
+
+// ColorCodes maps color names to ANSI SGR codes.
+var ColorCodes = map[string]string{
+	"black":   "30",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+	"gray":    "90",
+	"bold":    "1",
+	"faint":   "2",
+}
+
+// DefaultColors maps levels and segments to their default colors.
+var DefaultColors = map[string]string{
+	DebugLevel:   "gray",
+	InfoLevel:    "green",
+	WarnLevel:    "yellow",
+	ErrorLevel:   "red",
+	PerfLevel:    "magenta",
+	TimeColorKey: "gray",
+}
+
+// Colorize wraps the value in the ANSI sequence for the given color.
+// The color may be a name from ColorCodes or a raw SGR code such as "1;31".
+func Colorize(value, color string) string {
+	if value == "" || color == "" {
+		return value
+	}
+	code, ok := ColorCodes[color]
+	if !ok {
+		code = color
+	}
+	return ansiEscape + code + "m" + value + ansiReset
+}
+
+// GetColor returns the configured color for the key, falling back to DefaultColors.
+func GetColor(colors map[string]string, key string) string {
+	if color, ok := colors[key]; ok {
+		return color
+	}
+	return DefaultColors[key]
+}
+
+// colorizeValues colors the level, time, and message placeholder values.
+func colorizeValues(values map[string]string, level string, colors map[string]string) {
+	for placeholder, value := range values {
+		switch placeholder {
+		case LevelPlaceholder:
+			values[placeholder] = Colorize(value, GetColor(colors, level))
+		case TimePlaceholder, DatePlaceholder, DateTimePlaceholder:
+			values[placeholder] = Colorize(value, GetColor(colors, TimeColorKey))
+		case MsgPlaceholder:
+			values[placeholder] = Colorize(value, GetColor(colors, MsgColorKey))
+		}
+	}
+}
+
+// ColorEnabled reports whether colored output should be written to the file.
+// Color is disabled when NO_COLOR is set or the file is not a terminal.
+func ColorEnabled(f *os.File) bool {
+	if strings.TrimSpace(os.Getenv(NoColorEnv)) != "" {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// isTerminal reports whether the file is a character device.
+func isTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
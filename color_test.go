@@ -0,0 +1,58 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColorize(t *testing.T) {
+	assert.Equal(t, "\x1b[31mhello\x1b[0m", Colorize("hello", "red"))
+	assert.Equal(t, "\x1b[1;31mhello\x1b[0m", Colorize("hello", "1;31"))
+	assert.Equal(t, "hello", Colorize("hello", ""))
+	assert.Equal(t, "", Colorize("", "red"))
+}
+
+func TestGetColor(t *testing.T) {
+	assert.Equal(t, "red", GetColor(nil, ErrorLevel))
+	assert.Equal(t, "blue", GetColor(map[string]string{ErrorLevel: "blue"}, ErrorLevel))
+	assert.Equal(t, "", GetColor(nil, MsgColorKey))
+}
+
+func TestCustomHandler_Color(t *testing.T) {
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	opts := &CustomHandlerOptions{
+		Level:   DebugLevel,
+		Enabled: true,
+		Pattern: "[time] [level] [msg]",
+		Color:   true,
+		Colors:  map[string]string{MsgColorKey: "cyan"},
+	}
+	handler := NewCustomHandler(opts, writer, nil)
+
+	record := slog.NewRecord(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), slog.LevelError, "boom", 0)
+	assert.NoError(t, handler.Handle(context.Background(), record))
+
+	output := buf.String()
+	assert.Contains(t, output, "\x1b[31mERROR\x1b[0m")
+	assert.Contains(t, output, "\x1b[36mboom\x1b[0m")
+	assert.Contains(t, output, "\x1b[90m")
+}
+
+func TestColorEnabled(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "color")
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.False(t, ColorEnabled(f))
+	assert.False(t, ColorEnabled(nil))
+
+	t.Setenv(NoColorEnv, "1")
+	assert.False(t, ColorEnabled(os.Stdout))
+}
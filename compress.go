@@ -0,0 +1,64 @@
+package multilog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// GzipExt is the extension of gzip-compressed log files.
+const GzipExt = ".gz"
+
+// compressionLevel returns the gzip level to use; zero selects the default level.
+func compressionLevel(level int) int {
+	if level == 0 {
+		return gzip.DefaultCompression
+	}
+	return level
+}
+
+// CompressFile gzips the file to file.gz with the given level and removes the original.
+// A level of zero selects gzip.DefaultCompression.
+func CompressFile(file string, level int) (err error) {
+	src, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	dst, err := os.OpenFile(file+GzipExt, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return fmt.Errorf("failed to create compressed log file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = dst.Close()
+			_ = os.Remove(file + GzipExt)
+		}
+	}()
+
+	gz, err := gzip.NewWriterLevel(dst, compressionLevel(level))
+	if err != nil {
+		return fmt.Errorf("failed to compress log file: %w", err)
+	}
+	if _, err = io.Copy(gz, src); err != nil {
+		return fmt.Errorf("failed to compress log file: %w", err)
+	}
+	if err = gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress log file: %w", err)
+	}
+	if err = dst.Close(); err != nil {
+		return fmt.Errorf("failed to close compressed log file: %w", err)
+	}
+	_ = src.Close()
+	if err = os.Remove(file); err != nil {
+		return fmt.Errorf("failed to remove log file: %w", err)
+	}
+	return nil
+}
@@ -1,11 +1,16 @@
 package multilog
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -38,10 +43,44 @@ var LogLevels = []string{DebugLevel, InfoLevel, WarnLevel, ErrorLevel, PerfLevel
 
 // Handler types
 const (
-	FileHandlerType    = "file"
-	ConsoleHandlerType = "console"
+	FileHandlerType       = "file"
+	ConsoleHandlerType    = "console"
+	LokiHandlerType       = "loki"
+	KafkaHandlerType      = "kafka"
+	ESHandlerType         = "elasticsearch"
+	GELFHandlerType       = "gelf"
+	HTTPHandlerType       = "http"
+	JournaldHandlerType   = "journald"
+	SocketHandlerType     = "socket"
+	WebhookHandlerType    = "webhook"
+	SlackHandlerType      = "slack"
+	DiscordHandlerType    = "discord"
+	DatabaseHandlerType   = "database"
+	OTLPHandlerType       = "otlp"
+	CloudWatchHandlerType = "cloudwatch"
+	AuditHandlerType      = "audit"
 )
 
+// HandlerTypes contains all supported handler types.
+var HandlerTypes = []string{
+	FileHandlerType,
+	ConsoleHandlerType,
+	LokiHandlerType,
+	KafkaHandlerType,
+	ESHandlerType,
+	GELFHandlerType,
+	HTTPHandlerType,
+	JournaldHandlerType,
+	SocketHandlerType,
+	WebhookHandlerType,
+	SlackHandlerType,
+	DiscordHandlerType,
+	DatabaseHandlerType,
+	OTLPHandlerType,
+	CloudWatchHandlerType,
+	AuditHandlerType,
+}
+
 // Subtypes for file handlers
 const (
 	TextHandlerSubType = "text"
@@ -115,84 +154,236 @@ type Config struct {
 
 // LogConfig represents the logging configuration.
 type LogConfig struct {
-	Handlers []HandlerConfig `yaml:"handlers"`
+	Loggers         map[string]string        `yaml:"loggers,omitempty"`
+	Fields          map[string]string        `yaml:"fields,omitempty"`
+	Profiles        map[string]ProfileConfig `yaml:"profiles,omitempty"`
+	AppVersion      string                   `yaml:"app_version,omitempty"`
+	Profile         string                   `yaml:"profile,omitempty"`
+	Handlers        []HandlerConfig          `yaml:"handlers"`
+	AccessLog       AccessLogConfig          `yaml:"access_log,omitempty"`
+	Scrub           ScrubConfig              `yaml:"scrub,omitempty"`
+	ParallelWorkers int                      `yaml:"parallel_workers,omitempty"`
+	PerfInterval    time.Duration            `yaml:"perf_interval,omitempty"`
+	HandlerTimeout  time.Duration            `yaml:"handler_timeout,omitempty"`
+	Parallel        bool                     `yaml:"parallel,omitempty"`
 }
 
 // HandlerConfig represents the configuration for a specific handler.
 type HandlerConfig struct {
-	Type                 string `yaml:"type"`
-	SubType              string `yaml:"subtype,omitempty"`
-	Level                string `yaml:"level"`
-	Pattern              string `yaml:"pattern,omitempty"`
-	PatternPlaceholders  string `yaml:"pattern_placeholders,omitempty"`
-	ValuePrefixChar      string `yaml:"value_prefix_char,omitempty"`
-	ValueSuffixChar      string `yaml:"value_suffix_char,omitempty"`
-	File                 string `yaml:"file,omitempty"`
-	MaxSize              int    `yaml:"max_size,omitempty"`
-	MaxBackups           int    `yaml:"max_backups,omitempty"`
-	MaxAge               int    `yaml:"max_age,omitempty"`
-	Enabled              bool   `yaml:"enabled"`
-	UseSingleLetterLevel bool   `yaml:"use_single_letter_level,omitempty"`
+	Labels               map[string]string `yaml:"labels,omitempty"`
+	Headers              map[string]string `yaml:"headers,omitempty"`
+	Resource             map[string]string `yaml:"resource,omitempty"`
+	Colors               map[string]string `yaml:"colors,omitempty"`
+	Patterns             map[string]string `yaml:"patterns,omitempty"`
+	Match                map[string]string `yaml:"match,omitempty"`
+	SIEMFields           map[string]string `yaml:"siem_fields,omitempty"`
+	Name                 string            `yaml:"name,omitempty"`
+	Type                 string            `yaml:"type"`
+	SubType              string            `yaml:"subtype,omitempty"`
+	Level                string            `yaml:"level"`
+	MaxLevel             string            `yaml:"max_level,omitempty"`
+	StacktraceLevel      string            `yaml:"stacktrace_level,omitempty"`
+	Pattern              string            `yaml:"pattern,omitempty"`
+	PatternPlaceholders  string            `yaml:"pattern_placeholders,omitempty"`
+	ValuePrefixChar      string            `yaml:"value_prefix_char,omitempty"`
+	ValueSuffixChar      string            `yaml:"value_suffix_char,omitempty"`
+	File                 string            `yaml:"file,omitempty"`
+	URL                  string            `yaml:"url,omitempty"`
+	Topic                string            `yaml:"topic,omitempty"`
+	KeyAttribute         string            `yaml:"key_attribute,omitempty"`
+	Index                string            `yaml:"index,omitempty"`
+	IndexDateFormat      string            `yaml:"index_date_format,omitempty"`
+	Address              string            `yaml:"address,omitempty"`
+	Protocol             string            `yaml:"protocol,omitempty"`
+	SyslogIdentifier     string            `yaml:"syslog_identifier,omitempty"`
+	APIKey               string            `yaml:"api_key,omitempty"`
+	APIKeyHeader         string            `yaml:"api_key_header,omitempty"`
+	RotateInterval       string            `yaml:"rotate_interval,omitempty"`
+	FlushSize            string            `yaml:"flush_size,omitempty"`
+	PerfUnits            string            `yaml:"perf_units,omitempty"`
+	FormatEngine         string            `yaml:"format_engine,omitempty"`
+	PayloadTemplate      string            `yaml:"payload_template,omitempty"`
+	Driver               string            `yaml:"driver,omitempty"`
+	DSN                  string            `yaml:"dsn,omitempty"`
+	Table                string            `yaml:"table,omitempty"`
+	ArchiveURL           string            `yaml:"archive_url,omitempty"`
+	TimeFormat           string            `yaml:"time_format,omitempty"`
+	DateFormat           string            `yaml:"date_format,omitempty"`
+	DateTimeFormat       string            `yaml:"datetime_format,omitempty"`
+	SourceFormat         string            `yaml:"source_format,omitempty"`
+	Escape               string            `yaml:"escape,omitempty"`
+	Timezone             string            `yaml:"timezone,omitempty"`
+	Fallback             string            `yaml:"fallback,omitempty"`
+	DeadLetterFile       string            `yaml:"dead_letter_file,omitempty"`
+	LogGroup             string            `yaml:"log_group,omitempty"`
+	LogStream            string            `yaml:"log_stream,omitempty"`
+	Region               string            `yaml:"region,omitempty"`
+	GCPProject           string            `yaml:"gcp_project,omitempty"`
+	DeviceVendor         string            `yaml:"device_vendor,omitempty"`
+	DeviceProduct        string            `yaml:"device_product,omitempty"`
+	DeviceVersion        string            `yaml:"device_version,omitempty"`
+	FileMode             string            `yaml:"file_mode,omitempty"`
+	DirMode              string            `yaml:"dir_mode,omitempty"`
+	FileOwner            string            `yaml:"file_owner,omitempty"`
+	FileGroup            string            `yaml:"file_group,omitempty"`
+	DiskMinFree          string            `yaml:"disk_min_free,omitempty"`
+	DiskMaxSize          string            `yaml:"disk_max_size,omitempty"`
+	DiskAction           string            `yaml:"disk_action,omitempty"`
+	ShardBy              string            `yaml:"shard_by,omitempty"`
+	Sync                 string            `yaml:"sync,omitempty"`
+	RetentionTotalSize   string            `yaml:"retention_total_size,omitempty"`
+	Brokers              []string          `yaml:"brokers,omitempty"`
+	DedupKeys            []string          `yaml:"dedup_keys,omitempty"`
+	IncludeKeys          []string          `yaml:"include_keys,omitempty"`
+	ExcludeKeys          []string          `yaml:"exclude_keys,omitempty"`
+	RedactKeys           []string          `yaml:"redact_keys,omitempty"`
+	PerfMetrics          []string          `yaml:"perf_metrics,omitempty"`
+	CSVColumns           []string          `yaml:"csv_columns,omitempty"`
+	SourceTrimPrefixes   []string          `yaml:"source_trim_prefixes,omitempty"`
+	MaxSize              int               `yaml:"max_size,omitempty"`
+	MaxBackups           int               `yaml:"max_backups,omitempty"`
+	MaxAge               int               `yaml:"max_age,omitempty"`
+	BatchSize            int               `yaml:"batch_size,omitempty"`
+	MaxBufferSize        int               `yaml:"max_buffer_size,omitempty"`
+	ChunkSize            int               `yaml:"chunk_size,omitempty"`
+	CompressionLevel     int               `yaml:"compression_level,omitempty"`
+	SampleInitial        int               `yaml:"sample_initial,omitempty"`
+	SampleThereafter     int               `yaml:"sample_thereafter,omitempty"`
+	Burst                int               `yaml:"burst,omitempty"`
+	ArchiveRetention     int               `yaml:"archive_retention,omitempty"`
+	BreakerFailures      int               `yaml:"breaker_failures,omitempty"`
+	MaxOpenShards        int               `yaml:"max_open_shards,omitempty"`
+	RetentionMaxFiles    int               `yaml:"retention_max_files,omitempty"`
+	MaxMsgLen            int               `yaml:"max_msg_len,omitempty"`
+	MaxAttrLen           int               `yaml:"max_attr_len,omitempty"`
+	CallerSkip           int               `yaml:"caller_skip,omitempty"`
+	MaxRetries           *int              `yaml:"max_retries,omitempty"`
+	FlushInterval        time.Duration     `yaml:"flush_interval,omitempty"`
+	RetryBackoff         time.Duration     `yaml:"retry_backoff,omitempty"`
+	RetryMaxBackoff      time.Duration     `yaml:"retry_max_backoff,omitempty"`
+	SampleTick           time.Duration     `yaml:"sample_tick,omitempty"`
+	DedupWindow          time.Duration     `yaml:"dedup_window,omitempty"`
+	ArchiveInterval      time.Duration     `yaml:"archive_interval,omitempty"`
+	BreakerCooldown      time.Duration     `yaml:"breaker_cooldown,omitempty"`
+	DiskCheckInterval    time.Duration     `yaml:"disk_check_interval,omitempty"`
+	RetentionMaxAge      time.Duration     `yaml:"retention_max_age,omitempty"`
+	RetentionInterval    time.Duration     `yaml:"retention_interval,omitempty"`
+	SyncInterval         time.Duration     `yaml:"sync_interval,omitempty"`
+	Timeout              time.Duration     `yaml:"timeout,omitempty"`
+	MaxRecordsPerSecond  float64           `yaml:"max_records_per_second,omitempty"`
+	Enabled              bool              `yaml:"enabled"`
+	AddStacktrace        bool              `yaml:"add_stacktrace,omitempty"`
+	StructuredPerf       bool              `yaml:"structured_perf,omitempty"`
+	UseSingleLetterLevel bool              `yaml:"use_single_letter_level,omitempty"`
+	Compress             bool              `yaml:"compress,omitempty"`
+	Color                bool              `yaml:"color,omitempty"`
+	SplitOutput          bool              `yaml:"split_output,omitempty"`
+	TLS                  bool              `yaml:"tls,omitempty"`
+	TLSSkipVerify        bool              `yaml:"tls_skip_verify,omitempty"`
+	RetryJitter          bool              `yaml:"retry_jitter,omitempty"`
+	JSONIndent           bool              `yaml:"json_indent,omitempty"`
+	JSONSortKeys         bool              `yaml:"json_sort_keys,omitempty"`
+	ECS                  bool              `yaml:"ecs,omitempty"`
+	GCP                  bool              `yaml:"gcp,omitempty"`
+	PriorityPrefix       bool              `yaml:"priority_prefix,omitempty"`
+	CSVHeader            bool              `yaml:"csv_header,omitempty"`
+	Pretty               bool              `yaml:"pretty,omitempty"`
 }
 
 // NewConfig loads the configuration from the specified YAML file.
+// Environment variables in values are expanded as described in ExpandEnv, the files
+// listed under include are merged below it, relative to its directory, the
+// profile selected by MULTILOG_PROFILE or the config is applied, and MULTILOG_*
+// overrides are applied as described in ApplyEnvOverrides.
 func NewConfig(filename string) (*Config, error) {
+	return NewConfigWithProfile(filename, "")
+}
+
+// NewConfigWithProfile loads the configuration from the specified YAML file
+// like NewConfig, applying the given profile, e.g. from a command line flag,
+// instead of the one selected by MULTILOG_PROFILE or the config if set.
+func NewConfigWithProfile(filename, profile string) (*Config, error) {
+	config, err := loadConfigFile(filename, profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid config data: %w", err)
+	}
+	return config, nil
+}
+
+// loadConfigFile loads the configuration from the YAML file with the profile
+// and environment overrides applied, without validating it.
+func loadConfigFile(filename, profile string) (*Config, error) {
 	cleanedPath := filepath.Clean(filename)
 	if _, err := os.Stat(cleanedPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("config file does not exist: %s", cleanedPath)
 	}
-	file, err := os.Open(cleanedPath)
+	data, err := os.ReadFile(cleanedPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open config file: %w", err)
 	}
-	defer func() {
-		if cerr := file.Close(); cerr != nil {
-			if err == nil {
-				err = fmt.Errorf("failed to close config file: %w", cerr)
-			}
-		}
-	}()
 
-	decoder := yaml.NewDecoder(file)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var doc yaml.Node
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode config file: %w", err)
+	}
+	expandEnvNode(&doc)
+	stack := []string{cleanedPath}
+	if abs, err := filepath.Abs(cleanedPath); err == nil {
+		stack[0] = abs
+	}
+	root, err := includeConfigs(&doc, filepath.Dir(cleanedPath), stack)
+	if err != nil {
+		return nil, fmt.Errorf("failed to include config files: %w", err)
+	}
 	var config Config
-	if err := decoder.Decode(&config); err != nil {
+	if err := root.Decode(&config); err != nil {
 		return nil, fmt.Errorf("failed to decode config file: %w", err)
 	}
 
-	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("invalid config data: %w", err)
+	if err := ApplyProfile(&config, SelectedProfile(&config, profile)); err != nil {
+		return nil, fmt.Errorf("invalid config profile: %w", err)
 	}
 
+	if err := ApplyEnvOverrides(&config); err != nil {
+		return nil, fmt.Errorf("invalid config overrides: %w", err)
+	}
 	return &config, nil
 }
 
 // NewConfigFromData loads the configuration from the provided YAML data.
+// Included files are resolved relative to the working directory.
 func NewConfigFromData(data []byte) (*Config, error) {
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config data: %w", err)
 	}
+	expandEnvNode(&doc)
+	root, err := includeConfigs(&doc, ".", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to include config files: %w", err)
+	}
+	var config Config
+	if root != nil {
+		if err := root.Decode(&config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config data: %w", err)
+		}
+	}
+	if err := ApplyProfile(&config, SelectedProfile(&config, "")); err != nil {
+		return nil, fmt.Errorf("invalid config profile: %w", err)
+	}
+	if err := ApplyEnvOverrides(&config); err != nil {
+		return nil, fmt.Errorf("invalid config overrides: %w", err)
+	}
 	if err := validateConfig(&config); err != nil {
 		return nil, fmt.Errorf("invalid config data: %w", err)
 	}
 	return &config, nil
 }
 
-func newConsoleHandler(options CustomHandlerOptions) slog.Handler {
-	return NewConsoleHandler(options)
-}
-
-func newFileHandler(options CustomHandlerOptions) (slog.Handler, error) {
-	switch options.SubType {
-	case JSONHandlerSubType:
-		return NewJSONHandler(options, nil)
-	case TextHandlerSubType:
-		return NewFileHandler(options)
-	default:
-		return nil, fmt.Errorf("unknown handler subtype: %s", options.SubType)
-	}
-}
-
 // GetEnabledHandlers returns the list of enabled handlers from the configuration.
 func (c *Config) GetEnabledHandlers() []HandlerConfig {
 	var enabledHandlers []HandlerConfig
@@ -205,15 +396,19 @@ func (c *Config) GetEnabledHandlers() []HandlerConfig {
 	return enabledHandlers
 }
 
-// GetCustomHandlerOptionsForHandler returns the custom handler options for the handler.
+// GetCustomHandlerOptionsForHandler returns the formatting options of the
+// handler, common to all handler types. The options of the handler type embed
+// them; see EffectiveHandler.
 func (c *Config) GetCustomHandlerOptionsForHandler(
 	handlerConfig HandlerConfig,
 ) (CustomHandlerOptions, error) {
 	options := CustomHandlerOptions{
-		Level:   handlerConfig.Level,
-		SubType: defaultIfEmpty(handlerConfig.SubType, TextHandlerSubType),
-		Enabled: handlerConfig.Enabled,
-		Pattern: defaultIfEmpty(handlerConfig.Pattern, DefaultFormat),
+		Name:     handlerConfig.Name,
+		Level:    handlerConfig.Level,
+		MaxLevel: handlerConfig.MaxLevel,
+		SubType:  defaultIfEmpty(handlerConfig.SubType, TextHandlerSubType),
+		Enabled:  handlerConfig.Enabled,
+		Pattern:  defaultIfEmpty(handlerConfig.Pattern, DefaultFormat),
 		PatternPlaceholders: TrimSpaces(
 			strings.Split(
 				defaultIfEmpty(
@@ -223,23 +418,57 @@ func (c *Config) GetCustomHandlerOptionsForHandler(
 				",",
 			),
 		),
-		AddSource:            handlerConfig.Type == FileHandlerType,
+		AddSource:            handlerConfig.Type == FileHandlerType || handlerConfig.GCP,
 		UseSingleLetterLevel: handlerConfig.UseSingleLetterLevel,
+		AddStacktrace:        handlerConfig.AddStacktrace,
+		StructuredPerf:       handlerConfig.StructuredPerf,
+		PerfMetrics:          handlerConfig.PerfMetrics,
+		PerfUnits:            handlerConfig.PerfUnits,
+		FormatEngine:         handlerConfig.FormatEngine,
+		AppVersion:           c.Multilog.AppVersion,
+		TimeFormat:           handlerConfig.TimeFormat,
+		DateFormat:           handlerConfig.DateFormat,
+		DateTimeFormat:       handlerConfig.DateTimeFormat,
+		SourceFormat:         handlerConfig.SourceFormat,
+		Escape:               handlerConfig.Escape,
+		SourceTrimPrefixes:   handlerConfig.SourceTrimPrefixes,
+		StacktraceLevel:      defaultIfEmpty(handlerConfig.StacktraceLevel, DefaultStacktraceLevel),
 		ValuePrefixChar:      defaultIfEmpty(handlerConfig.ValuePrefixChar, DefaultValuePrefixChar),
 		ValueSuffixChar:      defaultIfEmpty(handlerConfig.ValueSuffixChar, DefaultValueSuffixChar),
-		File:                 handlerConfig.File,
-		MaxSize:              defaultIfZero(handlerConfig.MaxSize, DefaultLogFileSize),
-		MaxBackups:           defaultIfZero(handlerConfig.MaxBackups, DefaultLogFileBackups),
-		MaxAge:               defaultIfZero(handlerConfig.MaxAge, DefaultLogFileAge),
+		GCPProject:           handlerConfig.GCPProject,
+		Color:                handlerConfig.Color,
+		Colors:               handlerConfig.Colors,
+		Patterns:             handlerConfig.Patterns,
+		JSONIndent:           handlerConfig.JSONIndent,
+		JSONSortKeys:         handlerConfig.JSONSortKeys,
+		ECS:                  handlerConfig.ECS,
+		GCP:                  handlerConfig.GCP,
+		PriorityPrefix:       handlerConfig.PriorityPrefix,
+		Pretty:               handlerConfig.Pretty,
+		CSVColumns:           handlerConfig.CSVColumns,
+		DeviceVendor:         handlerConfig.DeviceVendor,
+		DeviceProduct:        handlerConfig.DeviceProduct,
+		DeviceVersion:        handlerConfig.DeviceVersion,
+		SIEMFields:           handlerConfig.SIEMFields,
 	}
 
-	if handlerConfig.Type != ConsoleHandlerType && handlerConfig.Type != FileHandlerType {
+	if !Contains(HandlerTypes, handlerConfig.Type) {
 		return CustomHandlerOptions{}, fmt.Errorf(
 			"unknown handlerConfig type: %s",
 			handlerConfig.Type,
 		)
 	}
 
+	if handlerConfig.FormatEngine == TemplateFormatEngine {
+		// Templates fall back to DefaultTemplate rather than the default pattern.
+		options.Pattern = handlerConfig.Pattern
+	}
+
+	var err error
+	options.Location, err = ParseTimezone(handlerConfig.Timezone)
+	if err != nil {
+		return CustomHandlerOptions{}, err
+	}
 	return options, nil
 }
 
@@ -251,6 +480,14 @@ func defaultIfEmpty(value, defaultValue string) string {
 	return value
 }
 
+// defaultDuration returns the default value if the value is zero.
+func defaultDuration(value, defaultValue time.Duration) time.Duration {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
+
 // defaultIfZero returns the default value if the value is zero.
 func defaultIfZero(value, defaultValue int) int {
 	if value == 0 {
@@ -259,55 +496,609 @@ func defaultIfZero(value, defaultValue int) int {
 	return value
 }
 
+// defaultIfNil returns the value, or the default if it is not set.
+func defaultIfNil(value *int, defaultValue int) int {
+	if value == nil {
+		return defaultValue
+	}
+	return *value
+}
+
 // validateConfig validates the configuration and provides detailed error messages.
 func validateConfig(config *Config) error {
-	if err := validateHandlers(config.Multilog.Handlers); err != nil {
-		return fmt.Errorf("handler validation failed: %w", err)
+	if errs := configErrors(config); len(errs) > 0 {
+		return errs[0]
 	}
 	return nil
 }
 
+// configErrors returns all the errors found validating the config.
+func configErrors(config *Config) []error {
+	var errs []error
+	for _, err := range handlersErrors(config.Multilog.Handlers) {
+		errs = append(errs, fmt.Errorf("handler validation failed: %w", err))
+	}
+	errs = append(errs, profileErrors(config)...)
+	if config.Multilog.PerfInterval < 0 {
+		errs = append(errs, fmt.Errorf("invalid perf interval: %s", config.Multilog.PerfInterval))
+	}
+	if config.Multilog.ParallelWorkers < 0 {
+		errs = append(errs, fmt.Errorf("invalid parallel workers: %d", config.Multilog.ParallelWorkers))
+	}
+	if config.Multilog.HandlerTimeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid handler timeout: %s", config.Multilog.HandlerTimeout))
+	}
+	if _, err := NewScrubber(config.Multilog.Scrub); err != nil {
+		errs = append(errs, err)
+	}
+	if _, ok := config.Multilog.Fields[""]; ok {
+		errs = append(errs, fmt.Errorf("field names must not be empty"))
+	}
+	loggers := make([]string, 0, len(config.Multilog.Loggers))
+	for name := range config.Multilog.Loggers {
+		loggers = append(loggers, name)
+	}
+	sort.Strings(loggers)
+	lowest := lowestHandlerLevel(config.GetEnabledHandlers())
+	for _, name := range loggers {
+		level := config.Multilog.Loggers[name]
+		switch {
+		case !Contains(LogLevels, level):
+			errs = append(errs, fmt.Errorf("invalid log level for logger %s: %s", name, level))
+		case lowest != "" && GetSlogLevel(level) < GetSlogLevel(lowest):
+			errs = append(errs, fmt.Errorf(
+				"log level %s of logger %s is below the level of every handler (%s): lower a handler level",
+				level, name, lowest))
+		}
+	}
+	return errs
+}
+
+// lowestHandlerLevel returns the lowest valid level of the handlers, or an
+// empty string if there is none. Logger levels only drop records, so a logger
+// level below it has no effect.
+func lowestHandlerLevel(handlers []HandlerConfig) string {
+	lowest := ""
+	for _, handler := range handlers {
+		if !Contains(LogLevels, handler.Level) {
+			continue
+		}
+		if lowest == "" || GetSlogLevel(handler.Level) < GetSlogLevel(lowest) {
+			lowest = handler.Level
+		}
+	}
+	return lowest
+}
+
 // validateHandlers validates the handlers and provides detailed error messages.
 func validateHandlers(handlers []HandlerConfig) error {
+	if errs := handlersErrors(handlers); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// handlersErrors returns all the errors found validating the handlers, prefixed
+// with the number of the handler.
+func handlersErrors(handlers []HandlerConfig) []error {
+	var errs []error
 	consoleHandlerCount := 0
 	for i := range handlers {
 		handler := &handlers[i]
 		if handler.Type == ConsoleHandlerType {
 			consoleHandlerCount++
 			if consoleHandlerCount > 1 {
-				return fmt.Errorf("handler %d: only one console handler is allowed", i+1)
+				errs = append(errs, fmt.Errorf("handler %d: only one console handler is allowed", i+1))
 			}
 		}
 
-		if err := validateHandler(handler); err != nil {
-			return fmt.Errorf("handler %d: %w", i+1, err)
+		for _, err := range handlerErrors(handler) {
+			errs = append(errs, fmt.Errorf("handler %d: %w", i+1, err))
 		}
 	}
-
-	return nil
+	return errs
 }
 
 // validateHandler validates the handler.
 func validateHandler(handler *HandlerConfig) error {
-	if handler.Type != ConsoleHandlerType && handler.Type != FileHandlerType {
-		return fmt.Errorf("invalid handler type: %s", handler.Type)
+	if errs := handlerErrors(handler); len(errs) > 0 {
+		return errs[0]
 	}
+	return nil
+}
 
-	if !Contains(LogLevels, handler.Level) {
-		return fmt.Errorf("invalid log level: %s", handler.Level)
+// handlerChecks are the validations of a handler of a known type, in the
+// order their errors are reported.
+var handlerChecks = []func(handler *HandlerConfig) error{
+	func(handler *HandlerConfig) error {
+		if !Contains(LogLevels, handler.Level) {
+			return fmt.Errorf("invalid log level: %s", handler.Level)
+		}
+		return nil
+	},
+	validateLevels,
+	validateHandlerRequirements,
+	validateHandlerWrappers,
+	validateRetry,
+	validateFormat,
+	func(handler *HandlerConfig) error {
+		if handler.CompressionLevel < gzip.HuffmanOnly || handler.CompressionLevel > gzip.BestCompression {
+			return fmt.Errorf("invalid compression level: %d", handler.CompressionLevel)
+		}
+		return nil
+	},
+	validateSubType,
+	validateEncodingOptions,
+	validateConsoleOptions,
+	validateSharding,
+	validateSync,
+}
+
+// handlerErrors returns all the errors found validating the handler. Handlers
+// of unknown types are not validated further.
+func handlerErrors(handler *HandlerConfig) []error {
+	if !Contains(HandlerTypes, handler.Type) {
+		return []error{fmt.Errorf("invalid handler type: %s", handler.Type)}
+	}
+	var errs []error
+	for _, check := range handlerChecks {
+		if err := check(handler); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errs
+}
 
-	if handler.Type == FileHandlerType && handler.File == "" {
-		return fmt.Errorf("file handler requires a file")
+// validateConsoleOptions validates the options that only apply to console handlers.
+func validateConsoleOptions(handler *HandlerConfig) error {
+	if handler.PriorityPrefix && handler.Type != ConsoleHandlerType {
+		return fmt.Errorf("priority_prefix requires a console handler")
+	}
+
+	if handler.Pretty && (handler.Type != ConsoleHandlerType || handler.SubType == JSONHandlerSubType ||
+		handler.FormatEngine == TemplateFormatEngine) {
+		return fmt.Errorf("pretty requires a console handler with the text subtype and the pattern format engine")
+	}
+	return nil
+}
+
+// validateSync validates the fsync settings of a handler.
+func validateSync(handler *HandlerConfig) error {
+	if handler.Sync == "" {
+		if handler.SyncInterval != 0 {
+			return fmt.Errorf("sync_interval requires sync: %s", IntervalSync)
+		}
+		return nil
+	}
+	if handler.Type != FileHandlerType {
+		return fmt.Errorf("sync requires a file handler")
+	}
+	if !Contains(SyncModes, handler.Sync) {
+		return fmt.Errorf("invalid sync mode: %s", handler.Sync)
+	}
+	if handler.SyncInterval != 0 && handler.Sync != IntervalSync {
+		return fmt.Errorf("sync_interval requires sync: %s", IntervalSync)
+	}
+	if handler.SyncInterval < 0 {
+		return fmt.Errorf("sync interval must not be negative")
+	}
+	return nil
+}
+
+// validateSharding validates the shard settings of a handler.
+func validateSharding(handler *HandlerConfig) error {
+	if handler.ShardBy == "" {
+		if handler.MaxOpenShards != 0 {
+			return fmt.Errorf("max_open_shards requires shard_by")
+		}
+		return nil
+	}
+	if handler.Type != FileHandlerType {
+		return fmt.Errorf("shard_by requires a file handler")
+	}
+	if handler.MaxOpenShards < 0 {
+		return fmt.Errorf("invalid max open shards: %d", handler.MaxOpenShards)
+	}
+	if strings.Contains(filepath.Dir(handler.File), ShardPlaceholder) {
+		return fmt.Errorf("the %s placeholder must be in the file name", ShardPlaceholder)
+	}
+	return nil
+}
+
+// subTypeHandlerTypes lists the handler types supporting each subtype other
+// than text and json.
+var subTypeHandlerTypes = map[string][]string{
+	MsgpackHandlerSubType:  binaryHandlerTypes,
+	CBORHandlerSubType:     binaryHandlerTypes,
+	ProtobufHandlerSubType: {FileHandlerType, SocketHandlerType},
+	CSVHandlerSubType:      {FileHandlerType},
+	TSVHandlerSubType:      {FileHandlerType},
+	CEFHandlerSubType:      {FileHandlerType, SocketHandlerType},
+	LEEFHandlerSubType:     {FileHandlerType, SocketHandlerType},
+}
+
+// supportsSubType reports whether the handler type supports the subtype.
+func supportsSubType(handlerType, subType string) bool {
+	return subType == "" || subType == TextHandlerSubType || subType == JSONHandlerSubType ||
+		Contains(subTypeHandlerTypes[subType], handlerType)
+}
+
+// validateSubType validates the subtype against the handler types supporting it.
+func validateSubType(handler *HandlerConfig) error {
+	if supportsSubType(handler.Type, handler.SubType) {
+		return nil
+	}
+	if handler.Type == FileHandlerType || handler.Type == ConsoleHandlerType {
+		return fmt.Errorf("invalid %s handler subtype: %s", handler.Type, handler.SubType)
+	}
+	// Subtypes of their own, such as ndjson, are validated with the handler type.
+	if types, ok := subTypeHandlerTypes[handler.SubType]; ok {
+		return fmt.Errorf("%s subtype requires a %s handler", handler.SubType, joinOr(types))
+	}
+	return nil
+}
+
+// joinOr joins the items with commas and a final "or".
+func joinOr(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
+
+// validateEncodingOptions validates the options that only apply to some encodings.
+func validateEncodingOptions(handler *HandlerConfig) error {
+	if (handler.JSONIndent || handler.JSONSortKeys) && (handler.SubType != JSONHandlerSubType ||
+		handler.Type != FileHandlerType && handler.Type != ConsoleHandlerType) {
+		return fmt.Errorf("json_indent and json_sort_keys require a console or file handler with the json subtype")
+	}
+
+	if handler.ECS && !encodesJSON(handler) {
+		return fmt.Errorf("ecs requires a handler that writes JSON documents")
+	}
+
+	if handler.GCP && !encodesJSON(handler) {
+		return fmt.Errorf("gcp requires a handler that writes JSON documents")
+	}
+
+	if handler.ECS && handler.GCP {
+		return fmt.Errorf("ecs and gcp cannot both be set")
+	}
+	return validateRowOptions(handler)
+}
+
+// validateRowOptions validates the options of the subtypes writing a line of
+// fields per record.
+func validateRowOptions(handler *HandlerConfig) error {
+	if (len(handler.CSVColumns) > 0 || handler.CSVHeader) && !isCSVSubType(handler.SubType) {
+		return fmt.Errorf("csv_columns and csv_header require the csv or tsv subtype")
+	}
+
+	if (handler.DeviceVendor != "" || handler.DeviceProduct != "" || handler.DeviceVersion != "" ||
+		len(handler.SIEMFields) > 0) && !isSIEMSubType(handler.SubType) {
+		return fmt.Errorf("device_vendor, device_product, device_version and siem_fields require the cef or leef subtype")
+	}
+	for key, field := range handler.SIEMFields {
+		if field == "" || strings.ContainsAny(field, siemInvalidKeyChars) {
+			return fmt.Errorf("invalid siem field for %s: %q", key, field)
+		}
+	}
+	return nil
+}
+
+// encodesJSON reports whether the handler writes records as JSON documents.
+func encodesJSON(handler *HandlerConfig) bool {
+	switch handler.Type {
+	case ConsoleHandlerType, FileHandlerType, SocketHandlerType, CloudWatchHandlerType:
+		return handler.SubType == JSONHandlerSubType
+	case ESHandlerType, KafkaHandlerType, HTTPHandlerType, AuditHandlerType:
+		return true
+	}
+	return false
+}
+
+// validateFormat validates the options controlling how records are rendered.
+func validateFormat(handler *HandlerConfig) error {
+	if err := ValidatePerfMetrics(handler.PerfMetrics, handler.PerfUnits); err != nil {
+		return err
+	}
+	if _, err := ParseTimezone(handler.Timezone); err != nil {
+		return err
+	}
+	if err := ValidateSourceFormat(handler.SourceFormat); err != nil {
+		return err
+	}
+	if err := ValidateEscape(handler.Escape); err != nil {
+		return err
+	}
+	if handler.FormatEngine == "" || handler.FormatEngine == PatternFormatEngine {
+		return nil
+	}
+	if handler.FormatEngine != TemplateFormatEngine {
+		return fmt.Errorf("invalid format engine: %s", handler.FormatEngine)
+	}
+	if _, err := ParseTemplate(handler.Pattern); err != nil {
+		return err
+	}
+	for _, text := range handler.Patterns {
+		if _, err := ParseTemplate(text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLevels validates the stack trace level and that the maximum level,
+// if set, is not below the level.
+func validateLevels(handler *HandlerConfig) error {
+	if handler.StacktraceLevel != "" && !Contains(LogLevels, handler.StacktraceLevel) {
+		return fmt.Errorf("invalid stacktrace level: %s", handler.StacktraceLevel)
 	}
+	for level := range handler.Patterns {
+		if !Contains(LogLevels, level) {
+			return fmt.Errorf("invalid pattern level: %s", level)
+		}
+	}
+	if handler.MaxLevel == "" {
+		return nil
+	}
+	if !Contains(LogLevels, handler.MaxLevel) {
+		return fmt.Errorf("invalid max log level: %s", handler.MaxLevel)
+	}
+	if GetSlogLevel(handler.MaxLevel) < GetSlogLevel(handler.Level) {
+		return fmt.Errorf("max level %s is below level %s", handler.MaxLevel, handler.Level)
+	}
+	return nil
+}
 
-	if handler.SubType != "" {
-		if handler.Type == FileHandlerType && handler.SubType != TextHandlerSubType &&
-			handler.SubType != JSONHandlerSubType {
-			return fmt.Errorf("invalid file handler subtype: %s", handler.SubType)
+// validateHandlerWrappers validates the settings of the wrappers applied to the handler.
+func validateHandlerWrappers(handler *HandlerConfig) error {
+	for _, wrapper := range handlerWrappers {
+		if wrapper.validate == nil {
+			continue
+		}
+		if err := wrapper.validate(handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRetry validates the retry settings of the network handlers.
+func validateRetry(handler *HandlerConfig) error {
+	if (handler.MaxRetries != nil && *handler.MaxRetries < 0) || handler.RetryBackoff < 0 || handler.RetryMaxBackoff < 0 {
+		return fmt.Errorf("retry settings must not be negative")
+	}
+	return nil
+}
+
+// validateRetention validates the retention settings of a handler.
+func validateRetention(handler *HandlerConfig) error {
+	if !hasRetention(handler) {
+		if handler.RetentionInterval != 0 {
+			return fmt.Errorf("retention_interval requires a retention limit")
+		}
+		if handler.RetentionMaxFiles < 0 || handler.RetentionMaxAge < 0 {
+			return fmt.Errorf("retention limits must not be negative")
+		}
+		return nil
+	}
+	if handler.Type != FileHandlerType {
+		return fmt.Errorf("retention requires a file handler")
+	}
+	if _, err := ParseSize(handler.RetentionTotalSize); err != nil {
+		return fmt.Errorf("invalid retention_total_size: %w", err)
+	}
+	if handler.RetentionMaxFiles < 0 || handler.RetentionMaxAge < 0 || handler.RetentionInterval < 0 {
+		return fmt.Errorf("retention limits must not be negative")
+	}
+	return nil
+}
+
+// validateDiskGuard validates the disk guard settings of a handler.
+func validateDiskGuard(handler *HandlerConfig) error {
+	if handler.DiskMinFree == "" && handler.DiskMaxSize == "" {
+		if handler.DiskAction != "" || handler.DiskCheckInterval != 0 {
+			return fmt.Errorf("disk_action and disk_check_interval require disk_min_free or disk_max_size")
+		}
+		return nil
+	}
+	if handler.Type != FileHandlerType {
+		return fmt.Errorf("disk guard requires a file handler")
+	}
+	if _, err := ParseSize(handler.DiskMinFree); err != nil {
+		return fmt.Errorf("invalid disk_min_free: %w", err)
+	}
+	if _, err := ParseSize(handler.DiskMaxSize); err != nil {
+		return fmt.Errorf("invalid disk_max_size: %w", err)
+	}
+	if handler.DiskAction != "" && !Contains(DiskActions, handler.DiskAction) {
+		return fmt.Errorf("invalid disk action: %s", handler.DiskAction)
+	}
+	if handler.DiskCheckInterval < 0 {
+		return fmt.Errorf("disk check interval must not be negative")
+	}
+	return nil
+}
+
+// validateFileHandler validates the file, rotation, batching and archive settings of a file handler.
+func validateFileHandler(handler *HandlerConfig) error {
+	if handler.File == "" {
+		return fmt.Errorf("file handler requires a file")
+	}
+	if err := checkDateTemplate(handler.File); err != nil {
+		return err
+	}
+	interval, err := ParseRotateInterval(handler.RotateInterval)
+	if err != nil {
+		return err
+	}
+	if err := checkTimeLayoutName(handler.File, interval); err != nil {
+		return err
+	}
+	if _, err := ParseSize(handler.FlushSize); err != nil {
+		return fmt.Errorf("invalid flush_size: %w", err)
+	}
+	if err := validateFileModes(handler); err != nil {
+		return err
+	}
+	if handler.ArchiveInterval < 0 || handler.ArchiveRetention < 0 {
+		return fmt.Errorf("archive settings must not be negative")
+	}
+	if handler.ArchiveURL != "" {
+		if _, _, _, err := ParseArchiveURL(handler.ArchiveURL); err != nil {
+			return err
+		}
+		if hasDateDirectives(filepath.Dir(handler.File)) {
+			return fmt.Errorf("archive_url does not support date directives in the log directory")
 		}
 	}
+	return nil
+}
+
+// handlerValidators maps handler types to the validators of their required fields.
+var handlerValidators = map[string]func(handler *HandlerConfig) error{
+	FileHandlerType:       validateFileHandler,
+	LokiHandlerType:       validateLokiHandler,
+	KafkaHandlerType:      validateKafkaHandler,
+	ESHandlerType:         validateESHandler,
+	GELFHandlerType:       validateGELFHandler,
+	HTTPHandlerType:       validateHTTPHandler,
+	SocketHandlerType:     validateSocketHandler,
+	AuditHandlerType:      validateAuditHandler,
+	WebhookHandlerType:    validateWebhookHandler,
+	SlackHandlerType:      validateWebhookHandler,
+	DiscordHandlerType:    validateWebhookHandler,
+	DatabaseHandlerType:   validateDatabaseHandler,
+	OTLPHandlerType:       validateOTLPHandler,
+	CloudWatchHandlerType: validateCloudWatchHandler,
+}
 
+// validateHandlerRequirements validates the type-specific required fields of the handler.
+func validateHandlerRequirements(handler *HandlerConfig) error {
+	if validate, ok := handlerValidators[handler.Type]; ok {
+		return validate(handler)
+	}
+	return nil
+}
+
+func validateLokiHandler(handler *HandlerConfig) error {
+	if handler.URL == "" {
+		return fmt.Errorf("loki handler requires a url")
+	}
+	return nil
+}
+
+func validateKafkaHandler(handler *HandlerConfig) error {
+	if len(handler.Brokers) == 0 || handler.Topic == "" {
+		return fmt.Errorf("kafka handler requires brokers and a topic")
+	}
+	return nil
+}
+
+func validateESHandler(handler *HandlerConfig) error {
+	if handler.URL == "" || handler.Index == "" {
+		return fmt.Errorf("elasticsearch handler requires a url and an index")
+	}
+	return nil
+}
+
+func validateGELFHandler(handler *HandlerConfig) error {
+	if handler.Address == "" {
+		return fmt.Errorf("gelf handler requires an address")
+	}
+	return nil
+}
+
+func validateHTTPHandler(handler *HandlerConfig) error {
+	if handler.URL == "" {
+		return fmt.Errorf("http handler requires a url")
+	}
+	if handler.SubType != "" && handler.SubType != JSONHandlerSubType && handler.SubType != NDJSONHandlerSubType &&
+		!Contains(subTypeHandlerTypes[handler.SubType], HTTPHandlerType) {
+		return fmt.Errorf("invalid http handler subtype: %s", handler.SubType)
+	}
+	return nil
+}
+
+func validateOTLPHandler(handler *HandlerConfig) error {
+	if handler.URL == "" {
+		return fmt.Errorf("otlp handler requires a url")
+	}
+	if handler.Protocol != "" && handler.Protocol != OTLPHTTPProtocol && handler.Protocol != OTLPGRPCProtocol {
+		return fmt.Errorf("invalid otlp protocol: %s", handler.Protocol)
+	}
+	return nil
+}
+
+func validateCloudWatchHandler(handler *HandlerConfig) error {
+	if handler.LogGroup == "" {
+		return fmt.Errorf("cloudwatch handler requires a log group")
+	}
+	if handler.SubType != "" && handler.SubType != TextHandlerSubType && handler.SubType != JSONHandlerSubType {
+		return fmt.Errorf("invalid cloudwatch handler subtype: %s", handler.SubType)
+	}
+	return nil
+}
+
+// validateFileModes validates the file_mode and dir_mode of a handler writing files.
+func validateFileModes(handler *HandlerConfig) error {
+	if _, err := ParseFileMode(handler.FileMode); err != nil {
+		return err
+	}
+	_, err := ParseFileMode(handler.DirMode)
+	return err
+}
+
+func validateAuditHandler(handler *HandlerConfig) error {
+	if handler.File == "" {
+		return fmt.Errorf("audit handler requires a file")
+	}
+	if err := validateFileModes(handler); err != nil {
+		return err
+	}
+	if handler.SubType != "" && handler.SubType != JSONHandlerSubType {
+		return fmt.Errorf("invalid audit handler subtype: %s", handler.SubType)
+	}
+	return nil
+}
+
+func validateSocketHandler(handler *HandlerConfig) error {
+	if handler.Address == "" {
+		return fmt.Errorf("socket handler requires an address")
+	}
+	if handler.Protocol != "" && !Contains(SocketProtocols, handler.Protocol) {
+		return fmt.Errorf("invalid socket protocol: %s", handler.Protocol)
+	}
+	if handler.TLS && handler.Protocol != "" && handler.Protocol != TCPProtocol {
+		return fmt.Errorf("socket handler supports tls only over tcp")
+	}
+	if !supportsSubType(SocketHandlerType, handler.SubType) {
+		return fmt.Errorf("invalid socket handler subtype: %s", handler.SubType)
+	}
+	return nil
+}
+
+func validateWebhookHandler(handler *HandlerConfig) error {
+	if handler.URL == "" {
+		return fmt.Errorf("%s handler requires a url", handler.Type)
+	}
+	if handler.Type != WebhookHandlerType {
+		return nil
+	}
+	if _, err := ParseTemplate(defaultIfEmpty(handler.PayloadTemplate, DefaultWebhookTemplate)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateDatabaseHandler(handler *HandlerConfig) error {
+	if handler.Driver == "" || handler.DSN == "" {
+		return fmt.Errorf("database handler requires a driver and a dsn")
+	}
+	if handler.Table != "" && !tableNamePattern.MatchString(handler.Table) {
+		return fmt.Errorf("invalid table name: %s", handler.Table)
+	}
 	return nil
 }
 
@@ -332,6 +1123,56 @@ func RemovePlaceholderChars(values map[string]interface{}) map[string]interface{
 	return values
 }
 
+// fieldArgs returns the fields as key-value pairs sorted by key.
+func fieldArgs(fields map[string]string) []any {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	args := make([]any, 0, len(fields)*2)
+	for _, key := range keys {
+		args = append(args, key, fields[key])
+	}
+	return args
+}
+
+// NewLoggerFromConfig creates a Logger with the handlers of the configuration,
+// attaching the fields to every record, scrubbing PII from records when scrub is
+// set and reporting runtime metrics every perf_interval when it is set. Its
+// Named loggers use the levels configured under "loggers".
+func NewLoggerFromConfig(config *Config) (*Logger, error) {
+	handlers, err := CreateHandlers(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create handlers: %w", err)
+	}
+	var logger *Logger
+	if config.Multilog.Parallel {
+		logger = NewParallelLogger(config.Multilog.ParallelWorkers, config.Multilog.HandlerTimeout, handlers...)
+	} else {
+		logger = NewLogger(handlers...)
+	}
+	if config.Multilog.Scrub.enabled() {
+		scrubber, err := NewScrubber(config.Multilog.Scrub)
+		if err != nil {
+			closeHandlers(handlers)
+			return nil, err
+		}
+		logger = logger.Use(ScrubMiddleware(scrubber))
+	}
+	logger = logger.With(fieldArgs(config.Multilog.Fields)...)
+	registry := NewRegistry(logger)
+	if err := registry.SetLevels(config.Multilog.Loggers); err != nil {
+		closeHandlers(handlers)
+		return nil, err
+	}
+	logger.registry = registry
+	if config.Multilog.PerfInterval > 0 {
+		logger.reporter = NewPerfReporter(logger, config.Multilog.PerfInterval)
+	}
+	return logger, nil
+}
+
 // CreateHandlers creates the handlers based on the configuration.
 func CreateHandlers(config *Config) ([]slog.Handler, error) {
 	enabledHandlers := config.GetEnabledHandlers()
@@ -341,11 +1182,19 @@ func CreateHandlers(config *Config) ([]slog.Handler, error) {
 		handlerConfig := &enabledHandlers[i]
 		options, err := config.GetCustomHandlerOptionsForHandler(*handlerConfig)
 		if err != nil {
+			closeHandlers(hs)
 			return nil, err
 		}
 
-		handler, err := createHandler(handlerConfig.Type, options)
+		handler, err := createHandler(handlerConfig, options)
 		if err != nil {
+			closeHandlers(hs)
+			return nil, err
+		}
+
+		handler, err = wrapHandler(handler, handlerConfig, options)
+		if err != nil {
+			closeHandlers(hs)
 			return nil, err
 		}
 
@@ -355,17 +1204,205 @@ func CreateHandlers(config *Config) ([]slog.Handler, error) {
 	return hs, nil
 }
 
-func createHandler(handlerType string, options CustomHandlerOptions) (slog.Handler, error) {
-	switch handlerType {
-	case ConsoleHandlerType:
-		return newConsoleHandler(options), nil
-	case FileHandlerType:
-		handler, err := newFileHandler(options)
+// closeHandlers closes the handlers created for a logger that failed to be
+// created. Their errors are dropped in favor of the one that stopped it.
+func closeHandlers(handlers []slog.Handler) {
+	for _, handler := range handlers {
+		_ = closeSlogHandler(handler)
+	}
+}
+
+// handlerWrapper is a wrapper applied to the handler when its config enables
+// it. validate, if set, checks the settings of the wrapper, enabled or not.
+type handlerWrapper struct {
+	enabled  func(handlerConfig *HandlerConfig) bool
+	validate func(handlerConfig *HandlerConfig) error
+	wrap     func(handler slog.Handler, handlerConfig *HandlerConfig, options CustomHandlerOptions) (slog.Handler, error)
+}
+
+// handlerWrappers are the wrappers configurable for a handler, innermost first.
+var handlerWrappers = []handlerWrapper{
+	{
+		enabled:  hasRetention,
+		validate: validateRetention,
+		wrap:     newRetentionFromConfig,
+	},
+	{
+		enabled: func(c *HandlerConfig) bool {
+			return c.DiskMinFree != "" || c.DiskMaxSize != ""
+		},
+		validate: validateDiskGuard,
+		wrap:     newDiskGuardFromConfig,
+	},
+	{
+		enabled: func(c *HandlerConfig) bool {
+			return len(c.IncludeKeys) > 0 || len(c.ExcludeKeys) > 0
+		},
+		wrap: func(h slog.Handler, c *HandlerConfig, _ CustomHandlerOptions) (slog.Handler, error) {
+			return NewAttrFilterHandler(h, c.IncludeKeys, c.ExcludeKeys), nil
+		},
+	},
+	{
+		enabled: func(c *HandlerConfig) bool {
+			return c.MaxMsgLen > 0 || c.MaxAttrLen > 0
+		},
+		validate: func(c *HandlerConfig) error {
+			if c.MaxMsgLen < 0 || c.MaxAttrLen < 0 {
+				return fmt.Errorf("truncation limits must not be negative")
+			}
+			return nil
+		},
+		wrap: func(h slog.Handler, c *HandlerConfig, _ CustomHandlerOptions) (slog.Handler, error) {
+			return NewTruncateHandler(h, c.MaxMsgLen, c.MaxAttrLen), nil
+		},
+	},
+	{
+		enabled: func(c *HandlerConfig) bool {
+			return c.BreakerFailures > 0 || c.Timeout > 0
+		},
+		validate: func(c *HandlerConfig) error {
+			if c.BreakerFailures < 0 || c.BreakerCooldown < 0 || c.Timeout < 0 {
+				return fmt.Errorf("circuit breaker settings must not be negative")
+			}
+			return nil
+		},
+		wrap: func(h slog.Handler, c *HandlerConfig, o CustomHandlerOptions) (slog.Handler, error) {
+			return NewCircuitBreakerHandler(h, c.BreakerFailures, c.BreakerCooldown, c.Timeout, o.OnError), nil
+		},
+	},
+	{
+		enabled: func(c *HandlerConfig) bool {
+			return c.DeadLetterFile != ""
+		},
+		wrap: func(h slog.Handler, c *HandlerConfig, o CustomHandlerOptions) (slog.Handler, error) {
+			return NewDeadLetterHandler(h, c.DeadLetterFile, o.OnError), nil
+		},
+	},
+	{
+		enabled: func(c *HandlerConfig) bool {
+			return c.Fallback != ""
+		},
+		validate: func(c *HandlerConfig) error {
+			if c.Fallback != "" && !Contains(FallbackTargets, c.Fallback) {
+				return fmt.Errorf("invalid fallback: %s", c.Fallback)
+			}
+			return nil
+		},
+		wrap: func(h slog.Handler, c *HandlerConfig, o CustomHandlerOptions) (slog.Handler, error) {
+			fallback, err := NewConsoleFallback(c.Fallback, o)
+			if err != nil {
+				return nil, err
+			}
+			return NewFallbackHandler(h, fallback, o.OnError), nil
+		},
+	},
+	{
+		enabled: func(c *HandlerConfig) bool {
+			return c.DedupWindow > 0
+		},
+		validate: func(c *HandlerConfig) error {
+			if c.DedupWindow < 0 {
+				return fmt.Errorf("dedup window must not be negative")
+			}
+			return nil
+		},
+		wrap: func(h slog.Handler, c *HandlerConfig, _ CustomHandlerOptions) (slog.Handler, error) {
+			return NewDedupHandler(h, c.DedupWindow, c.DedupKeys...), nil
+		},
+	},
+	{
+		enabled: func(c *HandlerConfig) bool {
+			return c.SampleInitial > 0
+		},
+		validate: func(c *HandlerConfig) error {
+			if c.SampleInitial < 0 || c.SampleThereafter < 0 || c.SampleTick < 0 {
+				return fmt.Errorf("sampling settings must not be negative")
+			}
+			return nil
+		},
+		wrap: func(h slog.Handler, c *HandlerConfig, _ CustomHandlerOptions) (slog.Handler, error) {
+			return NewSamplingHandler(h, c.SampleInitial, c.SampleThereafter, c.SampleTick), nil
+		},
+	},
+	{
+		// Chat handlers apply the rate limit themselves.
+		enabled: func(c *HandlerConfig) bool {
+			return c.MaxRecordsPerSecond > 0 && !isChatHandlerType(c.Type)
+		},
+		validate: func(c *HandlerConfig) error {
+			if c.MaxRecordsPerSecond < 0 || c.Burst < 0 {
+				return fmt.Errorf("rate limit settings must not be negative")
+			}
+			return nil
+		},
+		wrap: func(h slog.Handler, c *HandlerConfig, _ CustomHandlerOptions) (slog.Handler, error) {
+			return NewRateLimitHandler(h, c.MaxRecordsPerSecond, c.Burst), nil
+		},
+	},
+	{
+		enabled: func(c *HandlerConfig) bool {
+			return len(c.Match) > 0
+		},
+		validate: func(c *HandlerConfig) error {
+			for key, pattern := range c.Match {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("invalid match pattern for %s: %s", key, pattern)
+				}
+			}
+			return nil
+		},
+		wrap: func(h slog.Handler, c *HandlerConfig, _ CustomHandlerOptions) (slog.Handler, error) {
+			return NewRouteHandler(h, c.Match), nil
+		},
+	},
+	{
+		// Redact outermost, so no wrapper, such as the dead-letter file, sees the secrets.
+		enabled: func(c *HandlerConfig) bool {
+			return len(c.RedactKeys) > 0
+		},
+		wrap: func(h slog.Handler, c *HandlerConfig, _ CustomHandlerOptions) (slog.Handler, error) {
+			return NewRedactHandler(h, c.RedactKeys...), nil
+		},
+	},
+	{
+		enabled: func(c *HandlerConfig) bool {
+			return c.CallerSkip > 0
+		},
+		validate: func(c *HandlerConfig) error {
+			if c.CallerSkip < 0 {
+				return fmt.Errorf("caller_skip must not be negative")
+			}
+			return nil
+		},
+		wrap: func(h slog.Handler, c *HandlerConfig, _ CustomHandlerOptions) (slog.Handler, error) {
+			return NewCallerSkipHandler(h, c.CallerSkip), nil
+		},
+	},
+}
+
+// wrapHandler applies the wrappers enabled by the handler config, such as the
+// attribute filter, fallback, dedup, sampling, rate limiting, and routing. The
+// handler is closed if a wrapper cannot be created.
+func wrapHandler(
+	handler slog.Handler,
+	handlerConfig *HandlerConfig,
+	options CustomHandlerOptions,
+) (slog.Handler, error) {
+	for _, wrapper := range handlerWrappers {
+		if !wrapper.enabled(handlerConfig) {
+			continue
+		}
+		wrapped, err := wrapper.wrap(handler, handlerConfig, options)
 		if err != nil {
+			_ = closeSlogHandler(handler)
 			return nil, err
 		}
-		return handler, nil
-	default:
-		return nil, fmt.Errorf("unknown handler type: %s", handlerType)
+		handler = wrapped
 	}
+	return handler, nil
+}
+
+// isChatHandlerType reports whether the type is a chat notification handler.
+func isChatHandlerType(handlerType string) bool {
+	return handlerType == SlackHandlerType || handlerType == DiscordHandlerType
 }
@@ -0,0 +1,55 @@
+package multilog
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ValidateConfigFile loads the configuration file like NewConfig and returns
+// all the errors found validating it, joined, rather than the first one. Errors
+// loading the file, such as YAML syntax errors, are returned alone.
+func ValidateConfigFile(path string) error {
+	config, err := loadConfigFile(path, "")
+	if err != nil {
+		return err
+	}
+	return errors.Join(configErrors(config)...)
+}
+
+// EffectiveHandler is an enabled handler of a config: its settings, and the
+// options CreateHandlers creates it with. Options holds the options of the
+// handler type, such as FileHandlerOptions or LokiHandlerOptions.
+type EffectiveHandler struct {
+	Options any
+	Config  HandlerConfig
+}
+
+// Effective returns the enabled handlers of the config with their options,
+// defaults applied, showing what loggers created from the config will do once
+// profiles and environment overrides are applied.
+func (c *Config) Effective() ([]EffectiveHandler, error) {
+	enabledHandlers := c.GetEnabledHandlers()
+	handlers := make([]EffectiveHandler, 0, len(enabledHandlers))
+	for i := range enabledHandlers {
+		options, err := c.effectiveOptions(&enabledHandlers[i])
+		if err != nil {
+			return nil, fmt.Errorf("handler %d: %w", i+1, err)
+		}
+		handlers = append(handlers, EffectiveHandler{Options: options, Config: enabledHandlers[i]})
+	}
+	return handlers, nil
+}
+
+// effectiveOptions returns the options of the handler type the handler is
+// created with.
+func (c *Config) effectiveOptions(handlerConfig *HandlerConfig) (any, error) {
+	options, err := c.GetCustomHandlerOptionsForHandler(*handlerConfig)
+	if err != nil {
+		return nil, err
+	}
+	constructor, ok := handlerConstructors[handlerConfig.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown handler type: %s", handlerConfig.Type)
+	}
+	return constructor.options(handlerConfig, options)
+}
@@ -0,0 +1,110 @@
+package multilog
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfigFile(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"valid.yml": `
+multilog:
+  handlers:
+    - type: console
+      level: info
+      enabled: true
+`,
+		"invalid.yml": `
+multilog:
+  perf_interval: -1s
+  loggers:
+    db: loud
+  handlers:
+    - type: console
+      level: verbose
+      compression_level: 42
+      enabled: true
+    - type: console
+      level: info
+      escape: html
+      enabled: true
+    - type: carrier-pigeon
+      level: info
+  profiles:
+    prod:
+      handlers:
+        kafka:
+          level: warn
+`,
+		"syntax.yml": "multilog: [\n",
+	})
+
+	assert.NoError(t, ValidateConfigFile(filepath.Join(dir, "valid.yml")))
+
+	err := ValidateConfigFile(filepath.Join(dir, "invalid.yml"))
+	var joined interface{ Unwrap() []error }
+	assert.True(t, errors.As(err, &joined))
+	var messages []string
+	for _, err := range joined.Unwrap() {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		"handler validation failed: handler 1: invalid log level: verbose",
+		"handler validation failed: handler 1: invalid compression level: 42",
+		"handler validation failed: handler 2: only one console handler is allowed",
+		"handler validation failed: handler 2: invalid escape mode: html",
+		"handler validation failed: handler 3: invalid handler type: carrier-pigeon",
+		"profile prod: unknown handler: kafka",
+		"invalid perf interval: -1s",
+		"invalid log level for logger db: loud",
+	}, messages)
+
+	// NewConfig reports the first error.
+	_, err = NewConfig(filepath.Join(dir, "invalid.yml"))
+	assert.EqualError(t, err, "invalid config data: "+messages[0])
+
+	err = ValidateConfigFile(filepath.Join(dir, "syntax.yml"))
+	assert.ErrorContains(t, err, "failed to decode config file")
+	assert.ErrorContains(t, ValidateConfigFile(filepath.Join(dir, "missing.yml")), "config file does not exist")
+}
+
+func TestConfig_Effective(t *testing.T) {
+	t.Setenv("MULTILOG_APP_LEVEL", "warn")
+	config, err := NewConfigFromData([]byte(`
+multilog:
+  app_version: 1.2.3
+  handlers:
+    - type: console
+      level: debug
+      enabled: false
+    - name: app
+      type: file
+      level: info
+      file: app.log
+      rotate_interval: daily
+      enabled: true
+`))
+	assert.NoError(t, err)
+
+	handlers, err := config.Effective()
+	assert.NoError(t, err)
+	assert.Len(t, handlers, 1)
+	assert.Equal(t, "app", handlers[0].Config.Name)
+	options, ok := handlers[0].Options.(FileHandlerOptions)
+	assert.True(t, ok)
+	assert.Equal(t, "warn", options.Level)
+	assert.Equal(t, TextHandlerSubType, options.SubType)
+	assert.Equal(t, DefaultFormat, options.Pattern)
+	assert.Equal(t, DefaultLogFileSize, options.MaxSize)
+	assert.Equal(t, 24*time.Hour, options.RotateInterval)
+	assert.Equal(t, "1.2.3", options.AppVersion)
+	assert.True(t, options.AddSource)
+
+	config.Multilog.Handlers[1].RotateInterval = "fortnightly"
+	_, err = config.Effective()
+	assert.ErrorContains(t, err, "handler 1: ")
+}
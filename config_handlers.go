@@ -0,0 +1,334 @@
+package multilog
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// handlerConstructor creates the handlers of a type from their config and
+// their formatting options.
+type handlerConstructor struct {
+	// options returns the options of the handler type, such as
+	// FileHandlerOptions, embedding the formatting options.
+	options func(handlerConfig *HandlerConfig, options CustomHandlerOptions) (any, error)
+	create  func(handlerConfig *HandlerConfig, options CustomHandlerOptions) (slog.Handler, error)
+}
+
+// newHandlerConstructor returns the constructor of the handlers created by
+// create, with the options of the handler type mapped from the config by typed.
+func newHandlerConstructor[O any](
+	typed func(handlerConfig *HandlerConfig, options CustomHandlerOptions) (O, error),
+	create func(options O) (slog.Handler, error),
+) handlerConstructor {
+	return handlerConstructor{
+		options: func(handlerConfig *HandlerConfig, options CustomHandlerOptions) (any, error) {
+			return typed(handlerConfig, options)
+		},
+		create: func(handlerConfig *HandlerConfig, options CustomHandlerOptions) (slog.Handler, error) {
+			opts, err := typed(handlerConfig, options)
+			if err != nil {
+				return nil, err
+			}
+			return create(opts)
+		},
+	}
+}
+
+// handlerConstructors maps handler types to the constructors of their handlers.
+var handlerConstructors = map[string]handlerConstructor{
+	ConsoleHandlerType:    newHandlerConstructor(consoleHandlerOptions, newConsoleHandler),
+	FileHandlerType:       newHandlerConstructor(fileHandlerOptions, newFileHandler),
+	LokiHandlerType:       newHandlerConstructor(lokiHandlerOptions, NewLokiHandler),
+	KafkaHandlerType:      newHandlerConstructor(kafkaHandlerOptions, newKafkaHandlerFromFactory),
+	ESHandlerType:         newHandlerConstructor(elasticsearchHandlerOptions, NewElasticsearchHandler),
+	GELFHandlerType:       newHandlerConstructor(gelfHandlerOptions, NewGELFHandler),
+	HTTPHandlerType:       newHandlerConstructor(httpHandlerOptions, NewHTTPHandler),
+	JournaldHandlerType:   newHandlerConstructor(journaldHandlerOptions, NewJournaldHandler),
+	SocketHandlerType:     newHandlerConstructor(socketHandlerOptions, NewSocketHandler),
+	WebhookHandlerType:    newHandlerConstructor(webhookHandlerOptions, NewWebhookHandler),
+	SlackHandlerType:      newHandlerConstructor(chatHandlerOptions, NewSlackHandler),
+	DiscordHandlerType:    newHandlerConstructor(chatHandlerOptions, NewDiscordHandler),
+	DatabaseHandlerType:   newHandlerConstructor(databaseHandlerOptions, newDatabaseHandlerFromConfig),
+	OTLPHandlerType:       newHandlerConstructor(otlpHandlerOptions, newOTLPHandlerFromConfig),
+	CloudWatchHandlerType: newHandlerConstructor(cloudWatchHandlerOptions, newCloudWatchHandlerFromFactory),
+	AuditHandlerType:      newHandlerConstructor(auditHandlerOptions, NewAuditHandler),
+}
+
+// createHandler creates the handler of the config with the formatting options.
+// Constructors may return typed nil handlers with their errors, so the handler
+// is dropped on error.
+func createHandler(handlerConfig *HandlerConfig, options CustomHandlerOptions) (slog.Handler, error) {
+	constructor, ok := handlerConstructors[handlerConfig.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown handler type: %s", handlerConfig.Type)
+	}
+	handler, err := constructor.create(handlerConfig, options)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+func newConsoleHandler(options ConsoleHandlerOptions) (slog.Handler, error) {
+	return NewConsoleHandler(options), nil
+}
+
+func newFileHandler(options FileHandlerOptions) (slog.Handler, error) {
+	if options.ShardBy != "" {
+		return NewShardedFileHandler(options, newShardFileHandler), nil
+	}
+	return newShardFileHandler(options)
+}
+
+// newShardFileHandler creates a file handler writing a single file.
+func newShardFileHandler(options FileHandlerOptions) (slog.Handler, error) {
+	switch options.SubType {
+	case JSONHandlerSubType:
+		return NewJSONHandler(options, nil)
+	case TextHandlerSubType:
+		return NewFileHandler(options)
+	case MsgpackHandlerSubType, CBORHandlerSubType, ProtobufHandlerSubType, CSVHandlerSubType, TSVHandlerSubType,
+		CEFHandlerSubType, LEEFHandlerSubType:
+		return NewJSONHandler(options, nil)
+	default:
+		return nil, fmt.Errorf("unknown handler subtype: %s", options.SubType)
+	}
+}
+
+// batchOptions returns the batching and retry settings of the handler config,
+// with defaults for those not set.
+func batchOptions(handlerConfig *HandlerConfig) BatchOptions {
+	return BatchOptions{
+		BatchSize:       defaultIfZero(handlerConfig.BatchSize, DefaultBatchSize),
+		MaxBufferSize:   defaultIfZero(handlerConfig.MaxBufferSize, DefaultMaxBufferSize),
+		MaxRetries:      defaultIfNil(handlerConfig.MaxRetries, DefaultMaxRetries),
+		FlushInterval:   defaultDuration(handlerConfig.FlushInterval, DefaultFlushInterval),
+		RetryBackoff:    defaultDuration(handlerConfig.RetryBackoff, DefaultRetryBackoff),
+		RetryMaxBackoff: defaultDuration(handlerConfig.RetryMaxBackoff, DefaultMaxBackoff),
+		RetryJitter:     handlerConfig.RetryJitter,
+	}
+}
+
+// filePermissions returns the permissions of the files of the handler config.
+func filePermissions(handlerConfig *HandlerConfig) (FilePermissions, error) {
+	perms := FilePermissions{FileOwner: handlerConfig.FileOwner, FileGroup: handlerConfig.FileGroup}
+	var err error
+	if perms.FileMode, err = ParseFileMode(handlerConfig.FileMode); err != nil {
+		return FilePermissions{}, err
+	}
+	if perms.DirMode, err = ParseFileMode(handlerConfig.DirMode); err != nil {
+		return FilePermissions{}, err
+	}
+	return perms, nil
+}
+
+// fileFlushSize returns the batch size of a file handler. Setting flush_interval
+// alone enables batching with DefaultFlushSize.
+func fileFlushSize(handlerConfig *HandlerConfig) (int, error) {
+	size, err := ParseSize(handlerConfig.FlushSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid flush_size: %w", err)
+	}
+	if size == 0 && handlerConfig.FlushInterval > 0 {
+		size = DefaultFlushSize
+	}
+	return size, nil
+}
+
+// consoleHandlerOptions maps the config of a console handler to its options.
+func consoleHandlerOptions(handlerConfig *HandlerConfig, options CustomHandlerOptions) (ConsoleHandlerOptions, error) {
+	return ConsoleHandlerOptions{CustomHandlerOptions: options, SplitOutput: handlerConfig.SplitOutput}, nil
+}
+
+// fileHandlerOptions maps the config of a file handler to its options, with the rotation defaults.
+func fileHandlerOptions(handlerConfig *HandlerConfig, options CustomHandlerOptions) (FileHandlerOptions, error) {
+	perms, err := filePermissions(handlerConfig)
+	if err != nil {
+		return FileHandlerOptions{}, err
+	}
+	rotateInterval, err := ParseRotateInterval(handlerConfig.RotateInterval)
+	if err != nil {
+		return FileHandlerOptions{}, err
+	}
+	flushSize, err := fileFlushSize(handlerConfig)
+	if err != nil {
+		return FileHandlerOptions{}, err
+	}
+	return FileHandlerOptions{
+		CustomHandlerOptions: options,
+		FilePermissions:      perms,
+		File:                 handlerConfig.File,
+		ShardBy:              handlerConfig.ShardBy,
+		Sync:                 handlerConfig.Sync,
+		ArchiveURL:           handlerConfig.ArchiveURL,
+		MaxSize:              defaultIfZero(handlerConfig.MaxSize, DefaultLogFileSize),
+		MaxAge:               defaultIfZero(handlerConfig.MaxAge, DefaultLogFileAge),
+		MaxBackups:           defaultIfZero(handlerConfig.MaxBackups, DefaultLogFileBackups),
+		CompressionLevel:     handlerConfig.CompressionLevel,
+		FlushSize:            flushSize,
+		ArchiveRetention:     handlerConfig.ArchiveRetention,
+		MaxOpenShards:        handlerConfig.MaxOpenShards,
+		FlushInterval:        defaultDuration(handlerConfig.FlushInterval, DefaultFlushInterval),
+		RotateInterval:       rotateInterval,
+		ArchiveInterval:      handlerConfig.ArchiveInterval,
+		SyncInterval:         handlerConfig.SyncInterval,
+		Compress:             handlerConfig.Compress,
+		CSVHeader:            handlerConfig.CSVHeader,
+	}, nil
+}
+
+// auditHandlerOptions maps the config of an audit handler to its options.
+func auditHandlerOptions(handlerConfig *HandlerConfig, options CustomHandlerOptions) (AuditHandlerOptions, error) {
+	perms, err := filePermissions(handlerConfig)
+	if err != nil {
+		return AuditHandlerOptions{}, err
+	}
+	return AuditHandlerOptions{CustomHandlerOptions: options, FilePermissions: perms, File: handlerConfig.File}, nil
+}
+
+// lokiHandlerOptions maps the config of a Loki handler to its options.
+func lokiHandlerOptions(handlerConfig *HandlerConfig, options CustomHandlerOptions) (LokiHandlerOptions, error) {
+	return LokiHandlerOptions{
+		CustomHandlerOptions: options,
+		BatchOptions:         batchOptions(handlerConfig),
+		Labels:               handlerConfig.Labels,
+		URL:                  handlerConfig.URL,
+	}, nil
+}
+
+// kafkaHandlerOptions maps the config of a Kafka handler to its options.
+func kafkaHandlerOptions(handlerConfig *HandlerConfig, options CustomHandlerOptions) (KafkaHandlerOptions, error) {
+	return KafkaHandlerOptions{
+		CustomHandlerOptions: options,
+		BatchOptions:         batchOptions(handlerConfig),
+		Topic:                handlerConfig.Topic,
+		KeyAttribute:         handlerConfig.KeyAttribute,
+		Brokers:              handlerConfig.Brokers,
+	}, nil
+}
+
+// elasticsearchHandlerOptions maps the config of an Elasticsearch handler to its options.
+func elasticsearchHandlerOptions(
+	handlerConfig *HandlerConfig,
+	options CustomHandlerOptions,
+) (ElasticsearchHandlerOptions, error) {
+	return ElasticsearchHandlerOptions{
+		CustomHandlerOptions: options,
+		BatchOptions:         batchOptions(handlerConfig),
+		URL:                  handlerConfig.URL,
+		Index:                handlerConfig.Index,
+		IndexDateFormat:      defaultIfEmpty(handlerConfig.IndexDateFormat, DefaultIndexDateFormat),
+	}, nil
+}
+
+// gelfHandlerOptions maps the config of a GELF handler to its options.
+func gelfHandlerOptions(handlerConfig *HandlerConfig, options CustomHandlerOptions) (GELFHandlerOptions, error) {
+	return GELFHandlerOptions{
+		CustomHandlerOptions: options,
+		Address:              handlerConfig.Address,
+		Protocol:             handlerConfig.Protocol,
+		ChunkSize:            defaultIfZero(handlerConfig.ChunkSize, DefaultGELFChunkSize),
+	}, nil
+}
+
+// httpHandlerOptions maps the config of an HTTP handler to its options.
+func httpHandlerOptions(handlerConfig *HandlerConfig, options CustomHandlerOptions) (HTTPHandlerOptions, error) {
+	return HTTPHandlerOptions{
+		CustomHandlerOptions: options,
+		BatchOptions:         batchOptions(handlerConfig),
+		Headers:              handlerConfig.Headers,
+		URL:                  handlerConfig.URL,
+		APIKey:               handlerConfig.APIKey,
+		APIKeyHeader:         defaultIfEmpty(handlerConfig.APIKeyHeader, DefaultAPIKeyHeader),
+		CompressionLevel:     handlerConfig.CompressionLevel,
+		Compress:             handlerConfig.Compress,
+	}, nil
+}
+
+// journaldHandlerOptions maps the config of a journald handler to its options.
+func journaldHandlerOptions(
+	handlerConfig *HandlerConfig,
+	options CustomHandlerOptions,
+) (JournaldHandlerOptions, error) {
+	return JournaldHandlerOptions{
+		CustomHandlerOptions: options,
+		Address:              handlerConfig.Address,
+		SyslogIdentifier:     handlerConfig.SyslogIdentifier,
+	}, nil
+}
+
+// socketHandlerOptions maps the config of a socket handler to its options.
+func socketHandlerOptions(handlerConfig *HandlerConfig, options CustomHandlerOptions) (SocketHandlerOptions, error) {
+	return SocketHandlerOptions{
+		CustomHandlerOptions: options,
+		Address:              handlerConfig.Address,
+		Protocol:             handlerConfig.Protocol,
+		MaxBufferSize:        defaultIfZero(handlerConfig.MaxBufferSize, DefaultMaxBufferSize),
+		RetryBackoff:         defaultDuration(handlerConfig.RetryBackoff, DefaultRetryBackoff),
+		TLS:                  handlerConfig.TLS,
+		TLSSkipVerify:        handlerConfig.TLSSkipVerify,
+	}, nil
+}
+
+// webhookHandlerOptions maps the config of a webhook handler to its options.
+func webhookHandlerOptions(handlerConfig *HandlerConfig, options CustomHandlerOptions) (WebhookHandlerOptions, error) {
+	return WebhookHandlerOptions{
+		CustomHandlerOptions: options,
+		BatchOptions:         batchOptions(handlerConfig),
+		Headers:              handlerConfig.Headers,
+		URL:                  handlerConfig.URL,
+		PayloadTemplate:      handlerConfig.PayloadTemplate,
+	}, nil
+}
+
+// chatHandlerOptions maps the config of a Slack or Discord handler to its options.
+func chatHandlerOptions(handlerConfig *HandlerConfig, options CustomHandlerOptions) (ChatHandlerOptions, error) {
+	return ChatHandlerOptions{
+		CustomHandlerOptions: options,
+		BatchOptions:         batchOptions(handlerConfig),
+		Headers:              handlerConfig.Headers,
+		URL:                  handlerConfig.URL,
+		Burst:                handlerConfig.Burst,
+		MaxRecordsPerSecond:  handlerConfig.MaxRecordsPerSecond,
+	}, nil
+}
+
+// databaseHandlerOptions maps the config of a database handler to its options.
+func databaseHandlerOptions(
+	handlerConfig *HandlerConfig,
+	options CustomHandlerOptions,
+) (DatabaseHandlerOptions, error) {
+	return DatabaseHandlerOptions{
+		CustomHandlerOptions: options,
+		BatchOptions:         batchOptions(handlerConfig),
+		Driver:               handlerConfig.Driver,
+		DSN:                  handlerConfig.DSN,
+		Table:                handlerConfig.Table,
+	}, nil
+}
+
+// otlpHandlerOptions maps the config of an OTLP handler to its options.
+func otlpHandlerOptions(handlerConfig *HandlerConfig, options CustomHandlerOptions) (OTLPHandlerOptions, error) {
+	return OTLPHandlerOptions{
+		CustomHandlerOptions: options,
+		BatchOptions:         batchOptions(handlerConfig),
+		Headers:              handlerConfig.Headers,
+		Resource:             handlerConfig.Resource,
+		URL:                  handlerConfig.URL,
+		Protocol:             handlerConfig.Protocol,
+	}, nil
+}
+
+// cloudWatchHandlerOptions maps the config of a CloudWatch handler to its options.
+func cloudWatchHandlerOptions(
+	handlerConfig *HandlerConfig,
+	options CustomHandlerOptions,
+) (CloudWatchHandlerOptions, error) {
+	return CloudWatchHandlerOptions{
+		CustomHandlerOptions: options,
+		BatchOptions:         batchOptions(handlerConfig),
+		LogGroup:             handlerConfig.LogGroup,
+		LogStream:            handlerConfig.LogStream,
+		Region:               handlerConfig.Region,
+	}, nil
+}
@@ -0,0 +1,152 @@
+package multilog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeKey is the top-level key listing the config files a config includes.
+const includeKey = "include"
+
+// includeConfigs returns the root of the config document with the files it
+// includes merged below it, or nil for an empty document. Included files are
+// merged in order, each over the previous ones, and the document over all of
+// them; they may include other files. Relative paths are resolved against dir,
+// and files already in stack, which are being included, are a cycle.
+func includeConfigs(doc *yaml.Node, dir string, stack []string) (*yaml.Node, error) {
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return root, nil
+	}
+	includes, err := takeIncludes(root)
+	if err != nil || len(includes) == 0 {
+		return root, err
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, include := range includes {
+		path := filepath.Clean(include)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if slices.Contains(stack, path) {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, path), " -> "))
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read included config file: %w", err)
+		}
+		var included yaml.Node
+		if err := yaml.Unmarshal(data, &included); err != nil {
+			return nil, fmt.Errorf("failed to decode included config file %s: %w", include, err)
+		}
+		expandEnvNode(&included)
+		node, err := includeConfigs(&included, filepath.Dir(path), append(stack[:len(stack):len(stack)], path))
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			continue
+		}
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("included config file %s is not a mapping", include)
+		}
+		mergeConfigNodes(merged, node)
+	}
+	mergeConfigNodes(merged, root)
+	return merged, nil
+}
+
+// takeIncludes removes the include key from the root mapping and returns the
+// files it lists, a single file or a sequence of files.
+func takeIncludes(root *yaml.Node) ([]string, error) {
+	i := mappingIndex(root, includeKey)
+	if i < 0 {
+		return nil, nil
+	}
+	value := root.Content[i+1]
+	root.Content = slices.Delete(root.Content, i, i+2)
+
+	var includes []string
+	if value.Kind == yaml.ScalarNode {
+		includes = []string{value.Value}
+	} else if err := value.Decode(&includes); err != nil {
+		return nil, fmt.Errorf("invalid include: %w", err)
+	}
+	return slices.DeleteFunc(includes, func(include string) bool { return include == "" }), nil
+}
+
+// mergeConfigNodes merges the override mapping into the base mapping: mappings
+// are merged key by key, handler lists handler by handler, and other values
+// replaced.
+func mergeConfigNodes(base, override *yaml.Node) {
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+		j := mappingIndex(base, key.Value)
+		if j < 0 {
+			base.Content = append(base.Content, key, value)
+			continue
+		}
+		current := base.Content[j+1]
+		switch {
+		case current.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeConfigNodes(current, value)
+		case key.Value == "handlers" && current.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			mergeHandlerNodes(current, value)
+		default:
+			base.Content[j+1] = value
+		}
+	}
+}
+
+// mergeHandlerNodes merges the override handlers into the base handlers. A
+// handler overrides the base handler with the same name, or for handlers
+// without a name, the same type; other handlers are added.
+func mergeHandlerNodes(base, override *yaml.Node) {
+	for _, handler := range override.Content {
+		key := handlerNodeKey(handler)
+		i := slices.IndexFunc(base.Content, func(n *yaml.Node) bool {
+			return key != "" && handlerNodeKey(n) == key
+		})
+		if i < 0 || handler.Kind != yaml.MappingNode || base.Content[i].Kind != yaml.MappingNode {
+			base.Content = append(base.Content, handler)
+			continue
+		}
+		mergeConfigNodes(base.Content[i], handler)
+	}
+}
+
+// handlerNodeKey returns the name of the handler node, or its type if unnamed.
+func handlerNodeKey(handler *yaml.Node) string {
+	for _, key := range []string{"name", "type"} {
+		if i := mappingIndex(handler, key); i >= 0 && handler.Content[i+1].Value != "" {
+			return key + ":" + handler.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// mappingIndex returns the index of the key in the mapping node, or -1.
+func mappingIndex(node *yaml.Node, key string) int {
+	if node.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,133 @@
+package multilog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeConfigFiles writes the config files by name to a temporary directory
+// and returns it.
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestNewConfig_Include(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"shared/org.yml": `
+multilog:
+  fields:
+    org: acme
+    env: dev
+  handlers:
+    - type: console
+      level: info
+      pattern: "[time] [level] [msg]"
+      enabled: true
+      colors:
+        error: red
+    - name: app
+      type: file
+      level: info
+      file: logs/app.log
+      file_mode: 0640
+      redact_keys: [password]
+      enabled: true
+`,
+		"shared/team.yml": `
+include: org.yml
+multilog:
+  fields:
+    team: payments
+`,
+		"service.yml": `
+include:
+  - shared/team.yml
+multilog:
+  fields:
+    env: prod
+  handlers:
+    - type: console
+      level: warn
+      colors:
+        warn: yellow
+    - name: app
+      redact_keys: [token]
+    - name: audit
+      type: file
+      level: info
+      file: logs/audit.log
+      enabled: true
+`,
+	})
+
+	config, err := NewConfig(filepath.Join(dir, "service.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"org": "acme", "team": "payments", "env": "prod"}, config.Multilog.Fields)
+
+	handlers := config.Multilog.Handlers
+	assert.Len(t, handlers, 3)
+	assert.Equal(t, "warn", handlers[0].Level)
+	assert.Equal(t, "[time] [level] [msg]", handlers[0].Pattern)
+	assert.Equal(t, map[string]string{"error": "red", "warn": "yellow"}, handlers[0].Colors)
+	assert.Equal(t, "logs/app.log", handlers[1].File)
+	assert.Equal(t, "0640", handlers[1].FileMode)
+	assert.Equal(t, []string{"token"}, handlers[1].RedactKeys)
+	assert.Equal(t, "audit", handlers[2].Name)
+}
+
+func TestNewConfig_IncludeErrors(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"a.yml":       "include: b.yml\nmultilog: {handlers: []}\n",
+		"b.yml":       "include: [a.yml]\n",
+		"missing.yml": "include: nowhere.yml\n",
+		"list.yml":    "include: [scalar.yml]\n",
+		"scalar.yml":  "just a string\n",
+		"invalid.yml": "include: {file: a.yml}\n",
+	})
+
+	_, err := NewConfig(filepath.Join(dir, "a.yml"))
+	assert.ErrorContains(t, err, "include cycle: ")
+	assert.ErrorContains(t, err, filepath.Join(dir, "b.yml")+" -> "+filepath.Join(dir, "a.yml"))
+
+	_, err = NewConfig(filepath.Join(dir, "missing.yml"))
+	assert.ErrorContains(t, err, "failed to read included config file")
+
+	_, err = NewConfig(filepath.Join(dir, "list.yml"))
+	assert.ErrorContains(t, err, "included config file scalar.yml is not a mapping")
+
+	_, err = NewConfig(filepath.Join(dir, "invalid.yml"))
+	assert.ErrorContains(t, err, "invalid include")
+}
+
+func TestNewConfigFromData_Include(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"base.yml": `
+multilog:
+  handlers:
+    - type: console
+      level: info
+      enabled: true
+`,
+	})
+
+	config, err := NewConfigFromData([]byte("include: " + filepath.Join(dir, "base.yml") + `
+multilog:
+  handlers:
+    - type: console
+      level: debug
+`))
+	assert.NoError(t, err)
+	assert.Len(t, config.Multilog.Handlers, 1)
+	assert.Equal(t, "debug", config.Multilog.Handlers[0].Level)
+	assert.True(t, config.Multilog.Handlers[0].Enabled)
+}
@@ -0,0 +1,134 @@
+package multilog
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// jsonSchemaDialect is the JSON Schema version of ConfigJSONSchema.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches Go durations, such as 5s or 1h30m.
+const durationPattern = `^-?(0|([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+)$`
+
+// schemaEnums are the values allowed for the config keys that take one of a set.
+var schemaEnums = map[string][]string{
+	"type":             HandlerTypes,
+	"level":            LogLevels,
+	"max_level":        LogLevels,
+	"stacktrace_level": LogLevels,
+	"loggers":          LogLevels,
+	"format_engine":    FormatEngines,
+	"escape":           EscapeModes,
+	"fallback":         FallbackTargets,
+	"disk_action":      DiskActions,
+	"sync":             SyncModes,
+	"protocol":         SocketProtocols,
+	"replace":          ScrubModes,
+	"subtype": {
+		TextHandlerSubType, JSONHandlerSubType, NDJSONHandlerSubType, MsgpackHandlerSubType, CBORHandlerSubType,
+		ProtobufHandlerSubType, CSVHandlerSubType, TSVHandlerSubType, CEFHandlerSubType, LEEFHandlerSubType,
+	},
+}
+
+// schemaScalarKeys are the string config keys whose values may also be written
+// as YAML numbers, such as file modes, sizes, and versions.
+var schemaScalarKeys = []string{
+	"file_mode", "dir_mode", "flush_size", "disk_min_free", "disk_max_size", "retention_total_size",
+	"app_version",
+}
+
+// ConfigJSONSchema returns a JSON Schema of the YAML config, for editors and CI
+// to validate config files with. Unknown keys are rejected, and values may be
+// ${VAR} references, which are expanded when the config is loaded. No key is
+// required, as files may only hold the settings they merge into included files.
+func ConfigJSONSchema() ([]byte, error) {
+	defs := map[string]any{}
+	root := schemaObject(reflect.TypeFor[Config](), defs)
+	root["$schema"] = jsonSchemaDialect
+	root["title"] = "multilog config"
+	root["properties"].(map[string]any)[includeKey] = map[string]any{
+		"anyOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	root["$defs"] = defs
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// schemaObject returns the schema of the struct, from the yaml tags of its
+// fields. The schemas of nested structs are added to defs.
+func schemaObject(t reflect.Type, defs map[string]any) map[string]any {
+	properties := map[string]any{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "" || key == "-" || !field.IsExported() {
+			continue
+		}
+		properties[key] = schemaValue(field.Type, key, defs)
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// schemaValue returns the schema of a value of the type set for the key.
+func schemaValue(t reflect.Type, key string, defs map[string]any) map[string]any {
+	if t == reflect.TypeFor[time.Duration]() {
+		return withEnvReference(map[string]any{"type": "string", "pattern": durationPattern})
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaValue(t.Elem(), key, defs)
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = schemaObject(t, defs)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaValue(t.Elem(), key, defs)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaValue(t.Elem(), key, defs)}
+	case reflect.Bool:
+		return withEnvReference(map[string]any{"type": "boolean"})
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return withEnvReference(map[string]any{"type": "integer"})
+	case reflect.Float64:
+		return withEnvReference(map[string]any{"type": "number"})
+	}
+
+	if values, ok := schemaEnums[key]; ok {
+		return withEnvReference(map[string]any{"enum": values})
+	}
+	if key == "detect" {
+		detectors := make([]string, 0, len(ScrubDetectors))
+		for name := range ScrubDetectors {
+			detectors = append(detectors, name)
+		}
+		sort.Strings(detectors)
+		return withEnvReference(map[string]any{"enum": detectors})
+	}
+	if Contains(schemaScalarKeys, key) {
+		return map[string]any{"type": []string{"string", "number"}}
+	}
+	return map[string]any{"type": "string"}
+}
+
+// withEnvReference allows a ${VAR} reference in place of the value.
+func withEnvReference(schema map[string]any) map[string]any {
+	return map[string]any{
+		"anyOf": []any{schema, map[string]any{"type": "string", "pattern": envVarPattern.String()}},
+	}
+}
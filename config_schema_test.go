@@ -0,0 +1,168 @@
+package multilog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// schemaErrors validates the value against the subset of JSON Schema used by
+// ConfigJSONSchema and returns the errors found.
+func schemaErrors(root, schema map[string]any, value any, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		defs, _ := root["$defs"].(map[string]any)
+		def, _ := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		return schemaErrors(root, def, value, path)
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, option := range anyOf {
+			if len(schemaErrors(root, option.(map[string]any), value, path)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s: %v matches no schema", path, value)}
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return []string{fmt.Sprintf("%s: %v is not one of %v", path, value, enum)}
+	}
+	if types, ok := schema["type"]; ok && !schemaTypeMatches(types, value) {
+		return []string{fmt.Sprintf("%s: %v is not of type %v", path, value, types)}
+	}
+	if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(value.(string)) {
+		return []string{fmt.Sprintf("%s: %v does not match %s", path, value, pattern)}
+	}
+
+	var errs []string
+	switch value := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		for key, v := range value {
+			if property, ok := properties[key].(map[string]any); ok {
+				errs = append(errs, schemaErrors(root, property, v, path+"."+key)...)
+			} else if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+				errs = append(errs, schemaErrors(root, additional, v, path+"."+key)...)
+			} else if schema["additionalProperties"] == false {
+				errs = append(errs, fmt.Sprintf("%s: unknown key %s", path, key))
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, v := range value {
+				errs = append(errs, schemaErrors(root, items, v, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+// schemaTypeMatches reports whether the YAML value has one of the JSON Schema types.
+func schemaTypeMatches(types, value any) bool {
+	names, ok := types.([]any)
+	if !ok {
+		names = []any{types}
+	}
+	for _, name := range names {
+		switch value.(type) {
+		case map[string]any:
+			ok = name == "object"
+		case []any:
+			ok = name == "array"
+		case string:
+			ok = name == "string"
+		case bool:
+			ok = name == "boolean"
+		case int:
+			ok = name == "integer" || name == "number"
+		case float64:
+			ok = name == "number"
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// configSchema returns the decoded JSON Schema of the config.
+func configSchema(t *testing.T) map[string]any {
+	t.Helper()
+	data, err := ConfigJSONSchema()
+	assert.NoError(t, err)
+	var schema map[string]any
+	assert.NoError(t, json.Unmarshal(data, &schema))
+	return schema
+}
+
+// validateYAML validates the YAML config against the schema.
+func validateYAML(t *testing.T, schema map[string]any, config string) []string {
+	t.Helper()
+	var value any
+	assert.NoError(t, yaml.Unmarshal([]byte(config), &value))
+	return schemaErrors(schema, schema, value, "$")
+}
+
+func TestConfigJSONSchema(t *testing.T) {
+	schema := configSchema(t)
+	assert.Equal(t, jsonSchemaDialect, schema["$schema"])
+
+	// Every key of the handler config is described.
+	handler := schema["$defs"].(map[string]any)["HandlerConfig"].(map[string]any)
+	properties := handler["properties"].(map[string]any)
+	fields := reflect.TypeFor[HandlerConfig]()
+	assert.Len(t, properties, fields.NumField())
+	for i := range fields.NumField() {
+		key, _, _ := strings.Cut(fields.Field(i).Tag.Get("yaml"), ",")
+		assert.Contains(t, properties, key)
+	}
+
+	data, err := os.ReadFile("config.yml")
+	assert.NoError(t, err)
+	assert.Empty(t, validateYAML(t, schema, string(data)))
+}
+
+func TestConfigJSONSchema_Validate(t *testing.T) {
+	schema := configSchema(t)
+	assert.Empty(t, validateYAML(t, schema, `
+include: [base.yml]
+multilog:
+  profile: ${APP_ENV:-dev}
+  perf_interval: 1m30s
+  loggers:
+    db: debug
+  handlers:
+    - name: app
+      type: file
+      level: ${LOG_LEVEL:-info}
+      file: logs/app.log
+      file_mode: 0640
+      max_size: ${MAX_SIZE:-10}
+      flush_interval: 5s
+      enabled: true
+  profiles:
+    prod:
+      handlers:
+        app:
+          enabled: false
+`))
+
+	assert.Equal(t, []string{
+		"$.multilog.handlers[0].level: verbose matches no schema",
+	}, validateYAML(t, schema, "multilog: {handlers: [{type: console, level: verbose}]}"))
+	assert.Equal(t, []string{
+		"$.multilog.handlers[0]: unknown key levle",
+	}, validateYAML(t, schema, "multilog: {handlers: [{type: console, levle: info}]}"))
+	assert.Equal(t, []string{
+		"$.multilog.handlers[0].flush_interval: 5 seconds matches no schema",
+	}, validateYAML(t, schema, "multilog: {handlers: [{type: file, flush_interval: 5 seconds}]}"))
+	assert.Equal(t, []string{
+		"$.multilog.loggers.db: loud matches no schema",
+	}, validateYAML(t, schema, "multilog: {loggers: {db: loud}}"))
+}
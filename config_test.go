@@ -3,7 +3,9 @@ package multilog
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -174,9 +176,13 @@ func TestGetCustomHandlerOptionsForHandler(t *testing.T) {
 	assert.True(t, opts.Enabled)
 	assert.Equal(t, handlerCfg.Pattern, opts.Pattern)
 	assert.Equal(t, []string{"[time]", "[level]"}, opts.PatternPlaceholders)
-	assert.Equal(t, "app.log", opts.File)
 	assert.True(t, opts.AddSource)
 	assert.True(t, opts.UseSingleLetterLevel)
+
+	fileOpts, err := fileHandlerOptions(&handlerCfg, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "app.log", fileOpts.File)
+	assert.Equal(t, InfoLevel, fileOpts.Level)
 }
 
 func TestGetCustomHandlerOptionsForHandler_UnknownType(t *testing.T) {
@@ -270,7 +276,7 @@ func TestCreateHandlers_InvalidHandlerSubType(t *testing.T) {
 
 func TestCreateHandler_UnknownType(t *testing.T) {
 	options := CustomHandlerOptions{}
-	_, err := createHandler("unknown", options)
+	_, err := createHandler(&HandlerConfig{Type: "unknown"}, options)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unknown handler type")
 }
@@ -279,7 +285,7 @@ func TestCreateHandler_UnknownSubType(t *testing.T) {
 	options := CustomHandlerOptions{
 		SubType: "unknown",
 	}
-	_, err := createHandler("file", options)
+	_, err := createHandler(&HandlerConfig{Type: FileHandlerType}, options)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unknown handler subtype")
 }
@@ -335,7 +341,9 @@ func TestGetCustomHandlerOptionsForHandler_DefaultValues(t *testing.T) {
 		File:    "test.log",
 	}
 
-	options, err := cfg.GetCustomHandlerOptionsForHandler(handler)
+	common, err := cfg.GetCustomHandlerOptionsForHandler(handler)
+	assert.NoError(t, err)
+	options, err := fileHandlerOptions(&handler, common)
 	assert.NoError(t, err)
 	assert.Equal(t, DefaultLogFileSize, options.MaxSize)
 	assert.Equal(t, DefaultLogFileBackups, options.MaxBackups)
@@ -445,10 +453,428 @@ func TestNewConfig_InvalidConfig(t *testing.T) {
 }
 
 func TestNewFileHandler_UnknownType(t *testing.T) {
-	options := CustomHandlerOptions{
-		SubType: "unknown",
+	options := FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{SubType: "unknown"},
 	}
 	_, err := newFileHandler(options)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unknown handler subtype")
 }
+
+func TestNewConfigFromData_LokiHandler(t *testing.T) {
+	data := []byte(`
+multilog:
+  handlers:
+    - type: loki
+      name: loki
+      level: info
+      enabled: true
+      url: http://localhost:3100/loki/api/v1/push
+      labels:
+        app: api
+        env: prod
+      batch_size: 50
+      flush_interval: 2s
+      max_retries: 5
+      retry_backoff: 100ms
+      retry_max_backoff: 5s
+      retry_jitter: true`)
+
+	cfg, err := NewConfigFromData(data)
+	assert.NoError(t, err)
+
+	handlerCfg := cfg.Multilog.Handlers[0]
+	assert.Equal(t, LokiHandlerType, handlerCfg.Type)
+	assert.Equal(t, map[string]string{"app": "api", "env": "prod"}, handlerCfg.Labels)
+
+	common, err := cfg.GetCustomHandlerOptionsForHandler(handlerCfg)
+	assert.NoError(t, err)
+	opts, err := lokiHandlerOptions(&handlerCfg, common)
+	assert.NoError(t, err)
+	assert.Equal(t, "loki", opts.Name)
+	assert.Equal(t, 50, opts.BatchSize)
+	assert.Equal(t, 2*time.Second, opts.FlushInterval)
+	assert.Equal(t, 5, opts.MaxRetries)
+	assert.Equal(t, 100*time.Millisecond, opts.RetryBackoff)
+	assert.Equal(t, RetryPolicy{MaxRetries: 5, Backoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second, Jitter: true},
+		opts.retryPolicy())
+
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	assert.Len(t, handlers, 1)
+	assert.IsType(t, &LokiHandler{}, handlers[0])
+	assert.NoError(t, handlers[0].(*LokiHandler).Close())
+}
+
+func TestValidateHandler_LokiHandlerNoURL(t *testing.T) {
+	handler := &HandlerConfig{Type: LokiHandlerType, Level: InfoLevel, Enabled: true}
+	err := validateHandler(handler)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "loki handler requires a url")
+}
+
+func TestValidateHandlerRequirements(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler HandlerConfig
+		wantErr bool
+	}{
+		{"kafka missing topic", HandlerConfig{Type: KafkaHandlerType, Brokers: []string{"b:9092"}}, true},
+		{"kafka valid", HandlerConfig{Type: KafkaHandlerType, Brokers: []string{"b:9092"}, Topic: "logs"}, false},
+		{"elasticsearch missing index", HandlerConfig{Type: ESHandlerType, URL: "http://es:9200"}, true},
+		{"elasticsearch valid", HandlerConfig{Type: ESHandlerType, URL: "http://es:9200", Index: "logs"}, false},
+		{"file invalid rotate interval", HandlerConfig{Type: FileHandlerType, File: "a.log", RotateInterval: "x"}, true},
+		{"file daily rotation", HandlerConfig{Type: FileHandlerType, File: "a.log", RotateInterval: "daily"}, false},
+		{"file invalid flush size", HandlerConfig{Type: FileHandlerType, File: "a.log", FlushSize: "64XB"}, true},
+		{"file flush size", HandlerConfig{Type: FileHandlerType, File: "a.log", FlushSize: "64KB"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHandlerRequirements(&tt.handler)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestValidateHandler_CompressionLevel(t *testing.T) {
+	handler := HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "a.log", Compress: true}
+	assert.NoError(t, validateHandler(&handler))
+
+	handler.CompressionLevel = 10
+	assert.Error(t, validateHandler(&handler))
+}
+
+func TestValidateHandler_JSONIndent(t *testing.T) {
+	handler := HandlerConfig{Type: FileHandlerType, SubType: JSONHandlerSubType, Level: InfoLevel, File: "a.log",
+		JSONIndent: true, JSONSortKeys: true}
+	assert.NoError(t, validateHandler(&handler))
+
+	handler.SubType = TextHandlerSubType
+	assert.ErrorContains(t, validateHandler(&handler), "require a console or file handler with the json subtype")
+}
+
+func TestValidateHandler_PriorityPrefix(t *testing.T) {
+	handler := HandlerConfig{Type: ConsoleHandlerType, Level: InfoLevel, PriorityPrefix: true}
+	assert.NoError(t, validateHandler(&handler))
+
+	handler = HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "a.log", PriorityPrefix: true}
+	assert.ErrorContains(t, validateHandler(&handler), "priority_prefix requires a console handler")
+}
+
+func TestCreateHandlers_Fallback(t *testing.T) {
+	data := []byte(`
+multilog:
+  handlers:
+    - name: file
+      type: file
+      level: info
+      file: ` + filepath.Join(t.TempDir(), "app.log") + `
+      fallback: stderr
+      enabled: true
+`)
+	config, err := NewConfigFromData(data)
+	assert.NoError(t, err)
+
+	handlers, err := CreateHandlers(config)
+	assert.NoError(t, err)
+	assert.Len(t, handlers, 1)
+	assert.IsType(t, &FallbackHandler{}, handlers[0])
+
+	config.Multilog.Handlers[0].Fallback = "syslog"
+	assert.Error(t, validateHandler(&config.Multilog.Handlers[0]))
+}
+
+func TestCreateHandlers_Sampling(t *testing.T) {
+	data := []byte(`
+multilog:
+  handlers:
+    - name: console
+      type: console
+      level: debug
+      sample_initial: 100
+      sample_thereafter: 10
+      sample_tick: 1s
+      enabled: true
+`)
+	config, err := NewConfigFromData(data)
+	assert.NoError(t, err)
+
+	handlers, err := CreateHandlers(config)
+	assert.NoError(t, err)
+	assert.IsType(t, &SamplingHandler{}, handlers[0])
+
+	config.Multilog.Handlers[0].SampleThereafter = -1
+	assert.Error(t, validateHandler(&config.Multilog.Handlers[0]))
+}
+
+func TestCreateHandlers_RateLimit(t *testing.T) {
+	data := []byte(`
+multilog:
+  handlers:
+    - name: console
+      type: console
+      level: debug
+      max_records_per_second: 50
+      burst: 100
+      enabled: true
+`)
+	config, err := NewConfigFromData(data)
+	assert.NoError(t, err)
+
+	handlers, err := CreateHandlers(config)
+	assert.NoError(t, err)
+	assert.IsType(t, &RateLimitHandler{}, handlers[0])
+
+	config.Multilog.Handlers[0].Burst = -1
+	assert.Error(t, validateHandler(&config.Multilog.Handlers[0]))
+}
+
+func TestCreateHandlers_Dedup(t *testing.T) {
+	data := []byte(`
+multilog:
+  handlers:
+    - name: console
+      type: console
+      level: debug
+      dedup_window: 5s
+      dedup_keys: [host]
+      enabled: true
+`)
+	config, err := NewConfigFromData(data)
+	assert.NoError(t, err)
+
+	handlers, err := CreateHandlers(config)
+	assert.NoError(t, err)
+	assert.IsType(t, &DedupHandler{}, handlers[0])
+}
+
+func TestNewConfigFromData_Loggers(t *testing.T) {
+	data := []byte(`
+multilog:
+  loggers:
+    db: debug
+    http: warn
+  handlers:
+    - type: console
+      level: debug
+      enabled: true
+`)
+	config, err := NewConfigFromData(data)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"db": "debug", "http": "warn"}, config.Multilog.Loggers)
+
+	_, err = NewConfigFromData([]byte(strings.Replace(string(data), "db: debug", "db: loud", 1)))
+	assert.Error(t, err)
+
+	// A logger level below every handler level would have no effect.
+	_, err = NewConfigFromData([]byte(strings.Replace(string(data), "level: debug", "level: info", 1)))
+	assert.ErrorContains(t, err, "log level debug of logger db is below the level of every handler (info)")
+}
+
+func TestNewConfig_EnvExpansion(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MULTILOG_TEST_LOG_DIR", dir)
+	t.Setenv("MULTILOG_TEST_LEVEL", "warn")
+
+	testFile := filepath.Join(dir, "config.yaml")
+	configData := []byte(`multilog:
+  handlers:
+    - type: file
+      level: ${MULTILOG_TEST_LEVEL:-info}
+      file: ${MULTILOG_TEST_LOG_DIR}/app.log
+      enabled: true
+    - type: console
+      level: ${MULTILOG_TEST_CONSOLE_LEVEL:-debug}
+      enabled: true
+`)
+	assert.NoError(t, os.WriteFile(testFile, configData, 0o600))
+
+	config, err := NewConfig(testFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "warn", config.Multilog.Handlers[0].Level)
+	assert.Equal(t, dir+"/app.log", config.Multilog.Handlers[0].File)
+	assert.Equal(t, "debug", config.Multilog.Handlers[1].Level)
+}
+
+func TestCreateHandlers_AttrFilter(t *testing.T) {
+	config, err := NewBuilder().Console(IncludeKeys("level", "msg")).Config()
+	assert.NoError(t, err)
+
+	handlers, err := CreateHandlers(config)
+	assert.NoError(t, err)
+	assert.IsType(t, &AttrFilterHandler{}, handlers[0])
+}
+
+func TestValidateHandler_MatchPattern(t *testing.T) {
+	handler := HandlerConfig{Type: ConsoleHandlerType, Level: InfoLevel, Match: map[string]string{"component": "pay*"}}
+	assert.NoError(t, validateHandler(&handler))
+
+	handler.Match["component"] = "[pay"
+	assert.Error(t, validateHandler(&handler))
+}
+
+func TestValidateLevels(t *testing.T) {
+	tests := []struct {
+		name            string
+		level           string
+		maxLevel        string
+		stacktraceLevel string
+		wantErr         bool
+	}{
+		{"unset", InfoLevel, "", "", false},
+		{"range", InfoLevel, WarnLevel, "", false},
+		{"same", ErrorLevel, ErrorLevel, "", false},
+		{"invalid", InfoLevel, "fatal", "", true},
+		{"below level", ErrorLevel, InfoLevel, "", true},
+		{"stacktrace level", InfoLevel, "", WarnLevel, false},
+		{"invalid stacktrace level", InfoLevel, "", "fatal", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLevels(&HandlerConfig{
+				Level:           tt.level,
+				MaxLevel:        tt.maxLevel,
+				StacktraceLevel: tt.stacktraceLevel,
+			})
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestCreateHandlers_OutErrSplit(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewBuilder().
+		File(filepath.Join(dir, "out.log"), Pattern("[msg]"), Level(InfoLevel), MaxLevel(WarnLevel)).
+		File(filepath.Join(dir, "err.log"), Pattern("[msg]"), Level(ErrorLevel)).
+		Build()
+	assert.NoError(t, err)
+
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+	assert.NoError(t, logger.Close())
+
+	out, err := os.ReadFile(filepath.Join(dir, "out.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "info")
+	assert.Contains(t, string(out), "warn")
+	assert.NotContains(t, string(out), "error")
+
+	errOut, err := os.ReadFile(filepath.Join(dir, "err.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(errOut), "\n"))
+	assert.Contains(t, string(errOut), "error")
+}
+
+func TestNewLoggerFromConfig_Loggers(t *testing.T) {
+	newLogger := func() (*Logger, string) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		cfg, err := NewConfigFromData([]byte(`
+multilog:
+  loggers:
+    db: debug
+    http: warn
+  handlers:
+    - type: file
+      level: debug
+      enabled: true
+      pattern: "[level] [msg]"
+      file: ` + path))
+		assert.NoError(t, err)
+		logger, err := NewLoggerFromConfig(cfg)
+		assert.NoError(t, err)
+		return logger, path
+	}
+	logRecords := func(logger *Logger, path string) string {
+		logger.Named("http.server").Info("request")
+		logger.Named("http.server").Warn("slow request")
+		logger.Named("db").Debug("query")
+		logger.Named("cache").Debug("miss")
+		assert.NoError(t, logger.Close())
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		return string(data)
+	}
+
+	// Logger levels raise the level of their loggers above the handler level.
+	output := logRecords(newLogger())
+	assert.NotContains(t, output, "INFO request")
+	assert.Contains(t, output, "slow request")
+	assert.Contains(t, output, "query")
+	assert.Contains(t, output, "miss")
+}
+
+func TestCreateHandlers_ClosesCreatedHandlersOnError(t *testing.T) {
+	var producers []*mockKafkaProducer
+	KafkaProducerFactory = func([]string) (KafkaProducer, error) {
+		producer := &mockKafkaProducer{}
+		producers = append(producers, producer)
+		return producer, nil
+	}
+	defer func() { KafkaProducerFactory = nil }()
+
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: kafka
+      level: info
+      enabled: true
+      brokers: ["localhost:9092"]
+      topic: logs
+    - type: cloudwatch
+      level: info
+      enabled: true
+      log_group: app
+      region: eu-west-1`))
+	assert.NoError(t, err)
+
+	_, err = CreateHandlers(cfg)
+	assert.ErrorContains(t, err, "CloudWatchClientFactory")
+	assert.Len(t, producers, 1)
+	assert.True(t, producers[0].closed, "handlers created before the error are closed")
+
+	// The handlers are closed when the logger cannot be created either.
+	cfg.Multilog.Handlers = cfg.Multilog.Handlers[:1]
+	cfg.Multilog.Scrub = ScrubConfig{Detect: []string{"email"}, Replace: "erase"}
+	_, err = NewLoggerFromConfig(cfg)
+	assert.EqualError(t, err, "invalid scrub mode: erase")
+	assert.Len(t, producers, 2)
+	assert.True(t, producers[1].closed)
+}
+
+func TestNewLoggerFromConfig_Fields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  fields:
+    service: payments
+    env: prod
+    region: us-east-1
+  handlers:
+    - type: file
+      subtype: json
+      level: info
+      enabled: true
+      file: ` + path))
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"env", "prod", "region", "us-east-1", "service", "payments"},
+		fieldArgs(cfg.Multilog.Fields))
+
+	logger, err := NewLoggerFromConfig(cfg)
+	assert.NoError(t, err)
+	logger.Info("charged", "amount", 42)
+	logger.WithField("order", "o-1").Info("refunded")
+	assert.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Contains(t, line, `"env":"prod","region":"us-east-1","service":"payments"`)
+	}
+
+	_, err = NewBuilder().Console().Fields(map[string]string{"": "x"}).Config()
+	assert.EqualError(t, err, "invalid config data: field names must not be empty")
+}
@@ -12,10 +12,32 @@ type ConsoleHandler struct {
 	Handler CustomHandlerInterface
 }
 
+// ConsoleHandlerOptions are the options of console handlers: the formatting
+// options, and whether warnings and errors are written to stderr.
+type ConsoleHandlerOptions struct {
+	CustomHandlerOptions
+	SplitOutput bool
+}
+
 // NewConsoleHandler creates a console Handler with the specified options.
-func NewConsoleHandler(opts CustomHandlerOptions) slog.Handler {
+// Colored output is only kept when stdout is a terminal and NO_COLOR is unset.
+// With SplitOutput, warn and error records are written to stderr instead of stdout.
+// With the json SubType, records are written as JSON documents by a JSONHandler.
+func NewConsoleHandler(opts ConsoleHandlerOptions) slog.Handler {
+	if opts.SubType == JSONHandlerSubType {
+		jh := newJSONHandler(opts.CustomHandlerOptions, bufio.NewWriter(os.Stdout), nil)
+		if ch, ok := jh.Handler.(*CustomHandler); ok && opts.SplitOutput {
+			ch.SetErrorWriter(bufio.NewWriter(os.Stderr))
+		}
+		return jh
+	}
+	opts.Color = opts.Color && ColorEnabled(os.Stdout) && (!opts.SplitOutput || ColorEnabled(os.Stderr))
+	handler := NewCustomHandler(&opts.CustomHandlerOptions, bufio.NewWriter(os.Stdout), nil)
+	if opts.SplitOutput {
+		handler.SetErrorWriter(bufio.NewWriter(os.Stderr))
+	}
 	return &ConsoleHandler{
-		Handler: NewCustomHandler(&opts, bufio.NewWriter(os.Stdout), nil),
+		Handler: handler,
 	}
 }
 
@@ -38,3 +60,23 @@ func (ch *ConsoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 func (ch *ConsoleHandler) WithGroup(name string) slog.Handler {
 	return ch.Handler.WithGroup(name)
 }
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (ch *ConsoleHandler) SetLevel(level string) error {
+	return setHandlerLevel(ch.Handler, level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (ch *ConsoleHandler) GetLevel() string {
+	return getHandlerLevel(ch.Handler)
+}
+
+// Flush flushes pending output.
+func (ch *ConsoleHandler) Flush() error {
+	return flushHandler(ch.Handler)
+}
+
+// Close flushes pending output; stdout and stderr are left open.
+func (ch *ConsoleHandler) Close() error {
+	return ch.Flush()
+}
@@ -46,7 +46,7 @@ func TestNewConsoleHandler(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			handler := NewConsoleHandler(tt.opts)
+			handler := NewConsoleHandler(ConsoleHandlerOptions{CustomHandlerOptions: tt.opts})
 
 			if handler == nil {
 				t.Fatal("Handler should not be nil")
@@ -85,7 +85,7 @@ func TestConsoleHandlerOutput(t *testing.T) {
 		PatternPlaceholders: []string{"[msg]"},
 	}
 
-	handler := NewConsoleHandler(opts)
+	handler := NewConsoleHandler(ConsoleHandlerOptions{CustomHandlerOptions: opts})
 
 	// Create a test record with a simple message that won't need escaping
 	record := slog.Record{
@@ -123,7 +123,7 @@ func TestConsoleHandlerWithAttrsAndGroups(t *testing.T) {
 		Enabled: true,
 	}
 
-	handler := NewConsoleHandler(opts)
+	handler := NewConsoleHandler(ConsoleHandlerOptions{CustomHandlerOptions: opts})
 
 	// Test WithAttrs
 	attrsHandler := handler.WithAttrs([]slog.Attr{
@@ -150,7 +150,7 @@ func TestConsoleHandlerLevels(t *testing.T) {
 		Enabled: true,
 	}
 
-	handler := NewConsoleHandler(opts)
+	handler := NewConsoleHandler(ConsoleHandlerOptions{CustomHandlerOptions: opts})
 
 	// Debug should be disabled
 	if handler.Enabled(context.Background(), slog.LevelDebug) {
@@ -172,3 +172,103 @@ func TestConsoleHandlerLevels(t *testing.T) {
 		t.Error("Error level should be enabled when level is set to warn")
 	}
 }
+
+func TestConsoleHandlerSplitOutput(t *testing.T) {
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	os.Stdout, os.Stderr = outW, errW
+	defer func() {
+		os.Stdout, os.Stderr = originalStdout, originalStderr
+	}()
+
+	handler := NewConsoleHandler(ConsoleHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   "debug",
+			Enabled: true,
+			Pattern: "[level] [msg]",
+		},
+		SplitOutput: true,
+	})
+
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+		record := slog.NewRecord(time.Now(), level, "msg-"+GetLevelName(level), 0)
+		if err := handler.Handle(context.Background(), record); err != nil {
+			t.Fatalf("Failed to handle record: %v", err)
+		}
+	}
+	outW.Close()
+	errW.Close()
+
+	var stdout, stderr bytes.Buffer
+	_, _ = io.Copy(&stdout, outR)
+	_, _ = io.Copy(&stderr, errR)
+
+	if stdout.String() != "DEBUG msg-debug\nINFO msg-info\n" {
+		t.Errorf("Unexpected stdout output: %q", stdout.String())
+	}
+	if stderr.String() != "WARN msg-warn\nERROR msg-error\n" {
+		t.Errorf("Unexpected stderr output: %q", stderr.String())
+	}
+}
+
+func TestConsoleHandlerPriorityPrefix(t *testing.T) {
+	originalStdout := os.Stdout
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	os.Stdout = outW
+	defer func() {
+		os.Stdout = originalStdout
+	}()
+
+	text := NewConsoleHandler(ConsoleHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:          "debug",
+			Enabled:        true,
+			Pattern:        "[level] [msg]",
+			PriorityPrefix: true,
+			// Keep line breaks, so every line gets the prefix.
+			Escape: EscapeNone,
+		},
+	})
+	json := NewConsoleHandler(ConsoleHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:               "debug",
+			Enabled:             true,
+			SubType:             JSONHandlerSubType,
+			PatternPlaceholders: []string{"[level]", "[msg]"},
+			PriorityPrefix:      true,
+		},
+	})
+
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+		record := slog.NewRecord(time.Now(), level, GetLevelName(level), 0)
+		if err := text.Handle(context.Background(), record); err != nil {
+			t.Fatalf("Failed to handle record: %v", err)
+		}
+	}
+	if err := text.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "multi\nline", 0)); err != nil {
+		t.Fatalf("Failed to handle record: %v", err)
+	}
+	if err := json.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelWarn, "json", 0)); err != nil {
+		t.Fatalf("Failed to handle record: %v", err)
+	}
+	outW.Close()
+
+	var stdout bytes.Buffer
+	_, _ = io.Copy(&stdout, outR)
+
+	want := "<7>DEBUG debug\n<6>INFO info\n<4>WARN warn\n<3>ERROR error\n<3>ERROR multi\n<3>line\n" +
+		`<4>{"level":"WARN","msg":"json"}` + "\n"
+	if stdout.String() != want {
+		t.Errorf("Unexpected stdout output: %q", stdout.String())
+	}
+}
@@ -0,0 +1,103 @@
+package multilog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Trace correlation attribute keys
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// ContextExtractor extracts attributes from a context to be added to every record.
+type ContextExtractor interface {
+	Extract(ctx context.Context) []slog.Attr
+}
+
+// ContextExtractorFunc is a function adapter for ContextExtractor.
+type ContextExtractorFunc func(ctx context.Context) []slog.Attr
+
+// Extract implements ContextExtractor.
+func (f ContextExtractorFunc) Extract(ctx context.Context) []slog.Attr {
+	return f(ctx)
+}
+
+// SpanContextFunc returns the trace and span IDs carried by the context.
+// ok must be false when the context carries no valid span.
+type SpanContextFunc func(ctx context.Context) (traceID, spanID string, ok bool)
+
+// OTelExtractor emits trace_id and span_id attributes for contexts carrying a span.
+type OTelExtractor struct {
+	spanContext SpanContextFunc
+}
+
+// NewOTelExtractor creates an extractor that correlates records with OpenTelemetry traces.
+// multilog does not depend on OpenTelemetry, so the span lookup is supplied by the caller:
+//
+//	multilog.NewOTelExtractor(func(ctx context.Context) (string, string, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return sc.TraceID().String(), sc.SpanID().String(), sc.IsValid()
+//	})
+func NewOTelExtractor(spanContext SpanContextFunc) *OTelExtractor {
+	return &OTelExtractor{spanContext: spanContext}
+}
+
+// Extract implements ContextExtractor.
+func (e *OTelExtractor) Extract(ctx context.Context) []slog.Attr {
+	if ctx == nil || e.spanContext == nil {
+		return nil
+	}
+	traceID, spanID, ok := e.spanContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String(TraceIDKey, traceID),
+		slog.String(SpanIDKey, spanID),
+	}
+}
+
+// contextKeysExtractor emits the context values stored under its keys.
+type contextKeysExtractor struct {
+	keys  []any
+	names []string
+}
+
+// NewContextKeysExtractor creates an extractor emitting the values stored in
+// the context under the keys, as set with context.WithValue. Attribute keys are
+// the keys formatted with fmt.Sprint, so a key of a string type such as
+// contextKey("user_id") is emitted as user_id.
+func NewContextKeysExtractor(keys ...any) ContextExtractor {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = fmt.Sprint(key)
+	}
+	return &contextKeysExtractor{keys: keys, names: names}
+}
+
+// Extract implements ContextExtractor.
+func (e *contextKeysExtractor) Extract(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	for i, key := range e.keys {
+		if value := ctx.Value(key); value != nil {
+			attrs = append(attrs, slog.Any(e.names[i], value))
+		}
+	}
+	return attrs
+}
+
+// extractContextAttrs returns the request ID of the context followed by the
+// attributes of the extractors.
+func extractContextAttrs(ctx context.Context, extractors []ContextExtractor) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs := requestIDAttrs(ctx)
+	for _, extractor := range extractors {
+		attrs = append(attrs, extractor.Extract(ctx)...)
+	}
+	return attrs
+}
@@ -0,0 +1,110 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type spanKey struct{}
+
+func testSpanContext(ctx context.Context) (traceID, spanID string, ok bool) {
+	ids, ok := ctx.Value(spanKey{}).([2]string)
+	if !ok {
+		return "", "", false
+	}
+	return ids[0], ids[1], true
+}
+
+func TestOTelExtractor_Extract(t *testing.T) {
+	extractor := NewOTelExtractor(testSpanContext)
+
+	assert.Nil(t, extractor.Extract(context.Background()))
+
+	ctx := context.WithValue(context.Background(), spanKey{}, [2]string{"trace-1", "span-1"})
+	attrs := extractor.Extract(ctx)
+	assert.Equal(t, []slog.Attr{
+		slog.String(TraceIDKey, "trace-1"),
+		slog.String(SpanIDKey, "span-1"),
+	}, attrs)
+
+	assert.Nil(t, NewOTelExtractor(nil).Extract(ctx))
+}
+
+func TestContextLogger_ContextExtractors(t *testing.T) {
+	var buf strings.Builder
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: LevelPerf - 4})
+	logger := NewLogger(handler).WithContextExtractors(
+		NewOTelExtractor(testSpanContext),
+		ContextExtractorFunc(func(_ context.Context) []slog.Attr {
+			return []slog.Attr{slog.String("tenant", "acme")}
+		}),
+	)
+
+	ctx := context.WithValue(context.Background(), spanKey{}, [2]string{"abc123", "def456"})
+	ctxLogger := logger.WithContext(ctx)
+
+	tests := []struct {
+		name    string
+		logFunc func()
+	}{
+		{"Info", func() { ctxLogger.Info("info message", "k", "v") }},
+		{"Errorf", func() { ctxLogger.Errorf("error %s", "message") }},
+		{"Perf", func() { ctxLogger.Perf("perf message") }},
+		{"InfoContext", func() { logger.InfoContext(ctx, "info context") }},
+		{"WithField", func() { ctxLogger.WithField("f", 1).Warn("with field") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			tt.logFunc()
+			output := buf.String()
+			assert.Contains(t, output, "trace_id=abc123")
+			assert.Contains(t, output, "span_id=def456")
+			assert.Contains(t, output, "tenant=acme")
+		})
+	}
+
+	buf.Reset()
+	logger.WithContext(context.Background()).Info("no span")
+	assert.NotContains(t, buf.String(), TraceIDKey)
+	assert.Contains(t, buf.String(), "tenant=acme")
+}
+
+type userKey struct{}
+
+func (userKey) String() string {
+	return "user"
+}
+
+func TestContextKeysExtractor_Extract(t *testing.T) {
+	extractor := NewContextKeysExtractor(contextKey("tenant"), userKey{})
+	assert.Nil(t, extractor.Extract(context.Background()))
+
+	ctx := context.WithValue(context.Background(), contextKey("tenant"), "acme")
+	ctx = context.WithValue(ctx, userKey{}, 42)
+	assert.Equal(t, []slog.Attr{
+		slog.String("tenant", "acme"),
+		slog.Int("user", 42),
+	}, extractor.Extract(ctx))
+}
+
+func TestLogger_WithContextKeys(t *testing.T) {
+	var buf strings.Builder
+	logger := NewLogger(slog.NewTextHandler(&buf, nil)).WithContextKeys(contextKey("tenant"))
+	ctx := context.WithValue(context.Background(), contextKey("tenant"), "acme")
+
+	logger.InfoContext(ctx, "info context")
+	logger.WithContext(ctx).WithField("k", "v").Warn("with field")
+	logger.Info("no context")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "tenant=acme")
+	assert.Contains(t, lines[1], "tenant=acme")
+	assert.NotContains(t, lines[2], "tenant")
+}
@@ -0,0 +1,182 @@
+package multilog
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// CSV subtypes, writing one row per record with a column for each placeholder
+// and each key of CSVColumns.
+const (
+	CSVHandlerSubType = "csv"
+	TSVHandlerSubType = "tsv"
+)
+
+// isCSVSubType reports whether the subtype writes delimited rows.
+func isCSVSubType(subType string) bool {
+	return subType == CSVHandlerSubType || subType == TSVHandlerSubType
+}
+
+// csvSeparator returns the field separator of the subtype.
+func csvSeparator(subType string) byte {
+	if subType == TSVHandlerSubType {
+		return '\t'
+	}
+	return ','
+}
+
+// csvColumns returns the column names: the placeholders without brackets, then
+// the CSVColumns keys that are not already columns.
+func csvColumns(opts *CustomHandlerOptions) []string {
+	placeholders := opts.PatternPlaceholders
+	if len(placeholders) == 0 {
+		placeholders = DefaultPatternPlaceholders
+	}
+	columns := make([]string, 0, len(placeholders)+len(opts.CSVColumns))
+	for _, placeholder := range placeholders {
+		columns = append(columns, placeholder[1:len(placeholder)-1])
+	}
+	for _, key := range opts.CSVColumns {
+		if !slices.Contains(columns, key) {
+			columns = append(columns, key)
+		}
+	}
+	return columns
+}
+
+// appendCSVField appends the field, quoting it and doubling its quotes if it
+// contains the separator, a quote or a line break, or starts with a space.
+func appendCSVField(buf []byte, field string, sep byte) []byte {
+	if field == "" || field[0] != ' ' && !strings.ContainsAny(field, string(sep)+"\"\r\n") {
+		return append(buf, field...)
+	}
+	buf = append(buf, '"')
+	for i := range len(field) {
+		if field[i] == '"' {
+			buf = append(buf, '"')
+		}
+		buf = append(buf, field[i])
+	}
+	return append(buf, '"')
+}
+
+// appendCSVRow appends the fields separated by the separator.
+func appendCSVRow(buf []byte, fields []string, sep byte) []byte {
+	for i, field := range fields {
+		if i > 0 {
+			buf = append(buf, sep)
+		}
+		buf = appendCSVField(buf, field, sep)
+	}
+	return buf
+}
+
+// appendCSV renders the record as a row of the columns. Attribute columns hold
+// the value of the attribute with the column name as key, qualified by its
+// groups with dots as in text output, and are empty if there is none.
+func (jh *JSONHandler) appendCSV(buf []byte, ch *CustomHandler, record slog.Record) []byte {
+	opts := ch.Opts
+	columns := csvColumns(opts)
+
+	fields := make(map[string]string, len(columns))
+	take := func(groups []string, a slog.Attr) bool {
+		key := a.Key
+		if len(groups) > 0 {
+			key = strings.Join(groups, ".") + "." + key
+		}
+		if slices.Contains(columns, key) {
+			if a.Value.Kind() == slog.KindString {
+				fields[key] = a.Value.String()
+			} else {
+				fields[key] = string(appendTextValue(nil, a.Value))
+			}
+		}
+		return true
+	}
+	for _, b := range ch.boundAttrs {
+		appendTextAttrFunc(nil, b.groups, b.attr, jh.replaceAttr, take)
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		appendTextAttrFunc(nil, ch.groups, a, jh.replaceAttr, take)
+		return true
+	})
+
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		placeholder := "[" + column + "]"
+		switch placeholder {
+		case DatePlaceholder, TimePlaceholder, DateTimePlaceholder:
+			values[i] = recordTime(opts, record.Time).Format(timeLayout(opts, placeholder))
+		case LevelPlaceholder:
+			values[i] = levelLabel(record.Level, opts.UseSingleLetterLevel)
+		case MsgPlaceholder:
+			values[i] = record.Message
+		case PerfPlaceholder:
+			values[i] = formatPerfMetrics(opts)
+		case SourcePlaceholder:
+			values[i] = resolveSourceValue(opts, record, "")
+		case HostPlaceholder, PIDPlaceholder, GoroutinePlaceholder:
+			values[i] = processString(placeholder)
+		case VersionPlaceholder, RevisionPlaceholder:
+			values[i] = buildValue(opts, placeholder)
+		default:
+			values[i] = fields[column]
+		}
+	}
+	return appendCSVRow(buf, values, csvSeparator(opts.SubType))
+}
+
+// csvHeaderWriter writes the header row at the start of every file written
+// through the rotator, including the files started by rotation.
+type csvHeaderWriter struct {
+	rotationWriter
+	header   []byte
+	maxBytes int64
+}
+
+// newCSVHeaderWriter wraps the rotator to write the header row of the columns.
+func newCSVHeaderWriter(rotator rotationWriter, opts FileHandlerOptions) *csvHeaderWriter {
+	sep := csvSeparator(opts.SubType)
+	return &csvHeaderWriter{
+		rotationWriter: rotator,
+		header:         append(appendCSVRow(nil, csvColumns(&opts.CustomHandlerOptions), sep), '\n'),
+		// lumberjack's default maximum size is 100 megabytes.
+		maxBytes: int64(defaultIfZero(opts.MaxSize, 100)) * 1024 * 1024,
+	}
+}
+
+// Write writes the header before p if p starts a file. Rotations are
+// anticipated: a time rotation that is due, or a size rotation that writing p
+// would trigger, is done first.
+func (hw *csvHeaderWriter) Write(p []byte) (int, error) {
+	var filename string
+	switch w := hw.rotationWriter.(type) {
+	case *timeRotator:
+		if err := w.rotateIfDue(); err != nil {
+			return 0, err
+		}
+		filename = w.Filename()
+	case *lumberjack.Logger:
+		filename = w.Filename
+	}
+
+	info, err := os.Stat(filename)
+	start := err != nil || info.Size() == 0
+	if !start && info.Size()+int64(len(p)) > hw.maxBytes {
+		if err := hw.Rotate(); err != nil {
+			return 0, err
+		}
+		start = true
+	}
+	if !start {
+		return hw.rotationWriter.Write(p)
+	}
+	if _, err := hw.rotationWriter.Write(append(slices.Clip(hw.header), p...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
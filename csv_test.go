@@ -0,0 +1,152 @@
+package multilog
+
+import (
+	"encoding/csv"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func TestAppendCSVField(t *testing.T) {
+	tests := map[string]string{
+		"":           ``,
+		"plain":      `plain`,
+		"a,b":        `"a,b"`,
+		`say "hi"`:   `"say ""hi"""`,
+		"two\nlines": "\"two\nlines\"",
+		" padded":    `" padded"`,
+		"tab\there":  "tab\there",
+	}
+	for field, expected := range tests {
+		assert.Equal(t, expected, string(appendCSVField(nil, field, ',')), field)
+	}
+	assert.Equal(t, "\"tab\there\"", string(appendCSVField(nil, "tab\there", '\t')))
+}
+
+func TestCSVColumns(t *testing.T) {
+	opts := &CustomHandlerOptions{
+		PatternPlaceholders: []string{"[datetime]", "[level]", "[request_id]", "[msg]"},
+		CSVColumns:          []string{"request_id", "user", "req.status"},
+	}
+	assert.Equal(t, []string{"datetime", "level", "request_id", "msg", "user", "req.status"}, csvColumns(opts))
+}
+
+// readCSV parses the file with the separator.
+func readCSV(t *testing.T, path string, sep rune) [][]string {
+	t.Helper()
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	reader := csv.NewReader(f)
+	reader.Comma = sep
+	rows, err := reader.ReadAll()
+	assert.NoError(t, err)
+	return rows
+}
+
+func TestCSVFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.csv")
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: file
+      subtype: csv
+      level: info
+      enabled: true
+      file: ` + path + `
+      pattern_placeholders: "[level],[msg]"
+      csv_columns: [user, req.status, err.message]
+      csv_header: true`))
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+
+	logger := slog.New(handlers[0])
+	logger.Info("hello, \"world\"", "user", "alice", "ignored", 1)
+	logger.With("err", errors.New("boom")).WithGroup("req").Error("failed\nbadly", "status", 503)
+	assert.NoError(t, handlers[0].(*JSONHandler).Close())
+
+	assert.Equal(t, [][]string{
+		{"level", "msg", "user", "req.status", "err.message"},
+		{"INFO", "hello, \"world\"", "alice", "", ""},
+		{"ERROR", "failed\nbadly", "", "503", "boom"},
+	}, readCSV(t, path, ','))
+}
+
+func TestTSVFile_HeaderAfterRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.tsv")
+	handler, err := NewJSONHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:               InfoLevel,
+			Enabled:             true,
+			SubType:             TSVHandlerSubType,
+			PatternPlaceholders: []string{"[msg]"},
+			CSVColumns:          []string{"n"},
+		},
+		File:      path,
+		CSVHeader: true,
+	}, nil)
+	assert.NoError(t, err)
+	jh := handler.(*JSONHandler)
+
+	logger := slog.New(handler)
+	logger.Info("first", "n", 1)
+	assert.NoError(t, jh.Rotate())
+	logger.Info("second", "n", 2)
+	assert.NoError(t, jh.Close())
+
+	assert.Equal(t, [][]string{{"msg", "n"}, {"second", "2"}}, readCSV(t, path, '\t'))
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(path), "app-*.tsv"))
+	assert.NoError(t, err)
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, [][]string{{"msg", "n"}, {"first", "1"}}, readCSV(t, matches[0], '\t'))
+	}
+}
+
+func TestCSVHeaderWriter_SizeRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.csv")
+	logger := &lumberjack.Logger{Filename: path}
+	hw := newCSVHeaderWriter(logger, FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			SubType:             CSVHandlerSubType,
+			PatternPlaceholders: []string{"[msg]"},
+		},
+	})
+	hw.maxBytes = 16
+
+	for _, row := range []string{"aaaaa\n", "bbbbb\n", "ccccc\n"} {
+		n, err := hw.Write([]byte(row))
+		assert.NoError(t, err)
+		assert.Equal(t, len(row), n)
+	}
+	assert.NoError(t, hw.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(data), "msg\n"), file)
+	}
+}
+
+func TestValidateHandler_CSV(t *testing.T) {
+	handler := &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.csv", SubType: CSVHandlerSubType,
+		CSVColumns: []string{"user"}, CSVHeader: true}
+	assert.NoError(t, validateHandler(handler))
+
+	handler = &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log", CSVHeader: true}
+	assert.EqualError(t, validateHandler(handler), "csv_columns and csv_header require the csv or tsv subtype")
+
+	handler = &HandlerConfig{Type: KafkaHandlerType, Level: InfoLevel, Brokers: []string{"b:9092"}, Topic: "logs",
+		SubType: TSVHandlerSubType}
+	assert.EqualError(t, validateHandler(handler), "tsv subtype requires a file handler")
+}
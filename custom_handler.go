@@ -4,38 +4,101 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
-	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-// CustomHandlerOptions contains configuration options for the handler.
+// CustomHandlerOptions contains the formatting options common to all handlers.
+// Each handler type has its own options, such as FileHandlerOptions or
+// LokiHandlerOptions, that embed them with the settings of its destination.
 type CustomHandlerOptions struct {
+	Colors               map[string]string
+	Patterns             map[string]string
+	SIEMFields           map[string]string
+	OnError              func(err error)
+	Location             *time.Location
+	Name                 string
 	Level                string
-	File                 string
+	MaxLevel             string
+	StacktraceLevel      string
 	ValueSuffixChar      string
 	ValuePrefixChar      string
 	Pattern              string
 	SubType              string
+	PerfUnits            string
+	FormatEngine         string
+	GCPProject           string
+	DeviceVendor         string
+	DeviceProduct        string
+	DeviceVersion        string
+	AppVersion           string
+	TimeFormat           string
+	DateFormat           string
+	DateTimeFormat       string
+	SourceFormat         string
+	Escape               string
 	PatternPlaceholders  []string
-	MaxSize              int
-	MaxAge               int
-	MaxBackups           int
+	SourceTrimPrefixes   []string
+	PerfMetrics          []string
+	CSVColumns           []string
 	UseSingleLetterLevel bool
 	AddSource            bool
+	AddStacktrace        bool
+	StructuredPerf       bool
+	Color                bool
+	JSONIndent           bool
+	JSONSortKeys         bool
+	ECS                  bool
+	GCP                  bool
+	PriorityPrefix       bool
+	Pretty               bool
 	Enabled              bool
 }
 
 // CustomHandler is a base handler for logging.
+//
+// Handlers are immutable once created: WithAttrs and WithGroup return copies
+// that share the options, level, enabled flag, and output of the original, but
+// none of its formatting state, so derived loggers can be used concurrently.
 type CustomHandler struct {
 	Opts    *CustomHandlerOptions
 	sb      *strings.Builder
 	handler slog.Handler
-	writer  *bufio.Writer
+	// replaceAttr is set when records are formatted directly from the record
+	// rather than through the slog text handler; see appendRecord.
+	replaceAttr CustomReplaceAttr
+	formatters  *sync.Pool
+	out         *handlerOutput
+	level       *slog.LevelVar
+	enabled     *handlerSwitch
+	groups      []string
+	attrs       []byte
+	// boundAttrs keeps the WithAttrs attributes for custom placeholders and templates.
+	boundAttrs []boundAttr
+}
+
+// boundAttr is an attribute added with WithAttrs, with the groups open at the time.
+type boundAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+// handlerOutput holds the writers shared by a handler and the handlers derived
+// from it, and the mutex that serializes writes to them.
+type handlerOutput struct {
+	writer    *bufio.Writer
+	errWriter *bufio.Writer
+	// sync commits the file to stable storage after error records are flushed.
+	sync    func() error
 	mu      sync.Mutex
+	batched bool
 }
 
 // CustomHandlerInterface is an interface for the custom handler.
@@ -48,10 +111,34 @@ type CustomHandlerInterface interface {
 	GetSlogHandler() slog.Handler
 }
 
+// LevelSetter is implemented by handlers whose level can be changed at runtime.
+type LevelSetter interface {
+	SetLevel(level string) error
+	GetLevel() string
+}
+
+// Flusher is implemented by handlers that buffer output, and by loggers.
+type Flusher interface {
+	Flush() error
+}
+
+// Closer is implemented by handlers that hold resources, such as files,
+// connections, or background goroutines, which must be released on shutdown,
+// and by loggers, which close their handlers.
+type Closer interface {
+	Close() error
+}
+
+// Rotator is implemented by handlers whose log file can be rotated on demand.
+type Rotator interface {
+	Rotate() error
+}
+
 // CustomReplaceAttr is a function type for replacing attributes.
 type CustomReplaceAttr func(groups []string, a slog.Attr) slog.Attr
 
 // NewCustomHandler creates a new handler with a given configuration.
+// The options are copied; they must not be modified through the handler afterwards.
 func NewCustomHandler(
 	customOpts *CustomHandlerOptions,
 	writer *bufio.Writer,
@@ -65,27 +152,155 @@ func NewCustomHandler(
 			AddSource: false,
 		}
 	}
+	opts := *customOpts
+	customOpts = &opts
 
+	// Records are formatted directly unless a custom replaceAttr needs to see
+	// the built-in attributes.
+	fastPath := replaceAttr == nil
 	if replaceAttr == nil {
 		replaceAttr = GenerateDefaultCustomReplaceAttr(*customOpts, slog.TimeKey, slog.MessageKey)
 	}
 
 	sb := &strings.Builder{}
-	return &CustomHandler{
-		Opts: customOpts,
-		sb:   sb,
-		handler: slog.NewTextHandler(sb, &slog.HandlerOptions{
-			Level:       GetSlogLevel(customOpts.Level),
+	level := NewLevelVar(customOpts.Level)
+	newHandler := func(w io.Writer) slog.Handler {
+		return slog.NewTextHandler(w, &slog.HandlerOptions{
+			Level:       level,
 			AddSource:   customOpts.AddSource,
 			ReplaceAttr: replaceAttr,
-		}),
-		writer: writer,
+		})
+	}
+	ch := &CustomHandler{
+		Opts:       customOpts,
+		sb:         sb,
+		handler:    newHandler(sb),
+		formatters: newFormatterPool(newHandler),
+		out:        &handlerOutput{writer: writer},
+		level:      level,
+		enabled:    newHandlerSwitch(customOpts.Enabled),
+	}
+	if fastPath {
+		ch.replaceAttr = replaceAttr
 	}
+	return ch
+}
+
+// handlerSwitch is the enabled state shared by a handler and the handlers
+// derived from it. The flag is read atomically for every record, and changed
+// under mu together with dropped, which NewLogger sets for the handlers it
+// leaves out because they are disabled.
+type handlerSwitch struct {
+	mu      sync.Mutex
+	enabled atomic.Bool
+	dropped bool
+}
+
+// newHandlerSwitch creates a switch initialized to the given value.
+func newHandlerSwitch(enabled bool) *handlerSwitch {
+	s := &handlerSwitch{}
+	s.enabled.Store(enabled)
+	return s
+}
+
+// NewLevelVar creates a slog.LevelVar initialized to the given level string.
+func NewLevelVar(level string) *slog.LevelVar {
+	lv := &slog.LevelVar{}
+	lv.Set(GetSlogLevel(level))
+	return lv
 }
 
 // Enabled determines if a log message should be logged based on its level.
 func (ch *CustomHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return ch.handler.Enabled(ctx, level) && ch.Opts.Enabled
+	return ch.handler.Enabled(ctx, level) && ch.IsEnabled() && ch.belowMaxLevel(level)
+}
+
+// belowMaxLevel reports whether the level does not exceed the configured maximum level.
+func (ch *CustomHandler) belowMaxLevel(level slog.Level) bool {
+	return ch.Opts.MaxLevel == "" || level <= GetSlogLevel(ch.Opts.MaxLevel)
+}
+
+// IsEnabled reports whether the handler is currently enabled.
+func (ch *CustomHandler) IsEnabled() bool {
+	if ch.enabled == nil {
+		return ch.Opts.Enabled
+	}
+	return ch.enabled.enabled.Load()
+}
+
+// SetEnabled enables or disables the handler at runtime.
+// Handlers derived via WithAttrs or WithGroup share the same state. Handlers
+// that NewLogger left out because they were disabled cannot be enabled, as no
+// logger passes records to them.
+func (ch *CustomHandler) SetEnabled(enabled bool) error {
+	if ch.enabled == nil {
+		return fmt.Errorf("handler does not support enable/disable")
+	}
+	ch.enabled.mu.Lock()
+	defer ch.enabled.mu.Unlock()
+	if enabled && ch.enabled.dropped {
+		return fmt.Errorf("handler was left out of the logger because it was disabled when the logger was created")
+	}
+	ch.enabled.enabled.Store(enabled)
+	return nil
+}
+
+// IsDropped reports whether NewLogger left the handler out because it was disabled.
+func (ch *CustomHandler) IsDropped() bool {
+	if ch.enabled == nil {
+		return false
+	}
+	ch.enabled.mu.Lock()
+	defer ch.enabled.mu.Unlock()
+	return ch.enabled.dropped
+}
+
+// dropIfDisabled marks the handler as dropped and returns true when it is
+// disabled, so that it cannot be enabled once loggers leave it out.
+func (ch *CustomHandler) dropIfDisabled() bool {
+	if ch.enabled == nil {
+		return !ch.Opts.Enabled
+	}
+	ch.enabled.mu.Lock()
+	defer ch.enabled.mu.Unlock()
+	if ch.enabled.enabled.Load() {
+		return false
+	}
+	ch.enabled.dropped = true
+	return true
+}
+
+// Flush flushes any buffered output to the underlying writer.
+func (ch *CustomHandler) Flush() error {
+	ch.out.mu.Lock()
+	defer ch.out.mu.Unlock()
+
+	for _, w := range []*bufio.Writer{ch.out.writer, ch.out.errWriter} {
+		if w == nil {
+			continue
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("failed to flush writer: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetErrorWriter routes warn and error records to the given writer.
+// Lower levels keep going to the handler writer; a nil writer disables routing.
+// Handlers derived via WithAttrs or WithGroup share the same writers.
+func (ch *CustomHandler) SetErrorWriter(writer *bufio.Writer) {
+	ch.out.mu.Lock()
+	defer ch.out.mu.Unlock()
+	ch.out.errWriter = writer
+}
+
+// writerFor returns the writer for records of the given level; the caller must hold ch.out.mu.
+func (ch *CustomHandler) writerFor(level slog.Level) *bufio.Writer {
+	if ch.out.errWriter != nil && level >= slog.LevelWarn {
+		return ch.out.errWriter
+	}
+	return ch.out.writer
 }
 
 // Handle processes the log record and outputs it.
@@ -93,61 +308,380 @@ func (ch *CustomHandler) Handle(ctx context.Context, record slog.Record) error {
 	if !ch.Enabled(ctx, record.Level) {
 		return nil
 	}
-	ch.mu.Lock()
-	defer func() {
-		ch.sb.Reset()
-		ch.mu.Unlock()
-	}()
 
-	if err := ch.handler.Handle(ctx, record); err != nil {
-		return fmt.Errorf("failed to handle record: %w", err)
+	if ch.Opts.FormatEngine == TemplateFormatEngine {
+		buf := getBuffer()
+		defer putBuffer(buf)
+		var err error
+		if *buf, err = ch.appendTemplate(ctx, *buf, record); err != nil {
+			return err
+		}
+		*buf = append(*buf, '\n')
+
+		ch.out.mu.Lock()
+		defer ch.out.mu.Unlock()
+		return ch.write(record.Level, *buf)
+	}
+
+	if ch.replaceAttr != nil {
+		buf := getBuffer()
+		defer putBuffer(buf)
+		*buf = append(ch.appendRecord(ctx, *buf, record), '\n')
+
+		ch.out.mu.Lock()
+		defer ch.out.mu.Unlock()
+		return ch.write(record.Level, *buf)
+	}
+
+	output, err := ch.format(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	ch.out.mu.Lock()
+	defer ch.out.mu.Unlock()
+	return ch.write(record.Level, []byte(output+"\n"))
+}
+
+// write writes and flushes the formatted record; the caller must hold ch.out.mu.
+func (ch *CustomHandler) write(level slog.Level, output []byte) error {
+	if ch.Opts.PriorityPrefix {
+		buf := getBuffer()
+		defer putBuffer(buf)
+		*buf = appendPriorityPrefix(*buf, level, output)
+		output = *buf
+	}
+	writer := ch.writerFor(level)
+	if _, err := writer.Write(output); err != nil {
+		return fmt.Errorf("failed to write log message: %w", err)
+	}
+
+	if !ch.out.flushesOnWrite(level) {
+		return nil
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	if ch.out.sync != nil && level >= slog.LevelError {
+		return ch.out.sync()
+	}
+
+	return nil
+}
+
+// Format renders the record using the handler pattern without writing it.
+func (ch *CustomHandler) Format(ctx context.Context, record slog.Record) (string, error) {
+	if ch.Opts.FormatEngine == TemplateFormatEngine {
+		output, err := ch.appendTemplate(ctx, nil, record)
+		return string(output), err
 	}
+	if ch.replaceAttr != nil {
+		buf := getBuffer()
+		defer putBuffer(buf)
+		*buf = ch.appendRecord(ctx, *buf, record)
+		return string(*buf), nil
+	}
+	return ch.format(ctx, record)
+}
+
+// format renders the record through the slog text handler.
+func (ch *CustomHandler) format(ctx context.Context, record slog.Record) (string, error) {
+	sb, handler, release := formatterFor(ch)
+	defer release()
 
-	ch.Opts.Pattern = getPatternForLevel(record.Level, ch.Opts.Pattern)
-	placeholders := GetPlaceholders(ch.Opts.Pattern)
-	valuesInterface := GetPlaceholderValues(ch.sb, record, placeholders, ch.GetKeyValue)
+	compiled := compilePattern(ch.patternFor(record.Level))
+	pattern := compiled.plain
+	placeholders := GetPlaceholders(pattern)
+	record = withPerfMetrics(ch.Opts, record, Contains(placeholders, PerfPlaceholder))
+	if err := handler.Handle(ctx, record); err != nil {
+		return "", fmt.Errorf("failed to handle record: %w", err)
+	}
+
+	valuesInterface := placeholderValues(ch.Opts, sb, record, placeholders, ch.GetKeyValue)
 	// Convert map[string]interface{} to map[string]string for buildOutput
 	values := make(map[string]string, len(valuesInterface))
 	for k, v := range valuesInterface {
 		if str, ok := v.(string); ok {
-			values[k] = str
+			values[k] = escapeText(ch.Opts.Escape, str)
 		} else if v != nil {
-			values[k] = fmt.Sprintf("%v", v)
+			values[k] = escapeText(ch.Opts.Escape, fmt.Sprintf("%v", v))
 		} else {
 			values[k] = ""
 		}
 	}
 
-	output := buildOutput(ch.Opts.Pattern, values, ch.sb, record.Level, ch.Opts)
-	if _, err := ch.writer.WriteString(output + "\n"); err != nil {
-		return fmt.Errorf("failed to write log message: %w", err)
+	for _, segment := range compiled.segments {
+		if v := values[segment.text]; v != "" && (segment.width != 0 || segment.maxWidth != 0) {
+			values[segment.text] = alignValue(v, segment.width, segment.maxWidth)
+		}
 	}
 
-	if err := ch.writer.Flush(); err != nil {
-		return fmt.Errorf("failed to flush writer: %w", err)
+	if ch.Opts.Color {
+		colorizeValues(values, GetLevelName(record.Level), ch.Opts.Colors)
 	}
 
-	return nil
+	output := buildOutput(pattern, values, sb, record.Level, ch.Opts)
+	if stacktraceEnabled(ch.Opts, record.Level) {
+		output += "\n" + indentStacktrace(recordStacktrace(ctx, record.PC))
+	}
+	return output, nil
+}
+
+// appendRecord renders the record directly from its fields and attributes.
+// The output is the same as format produces with the default attribute
+// replacement, without the round trip through the slog text handler.
+func (ch *CustomHandler) appendRecord(ctx context.Context, buf []byte, record slog.Record) []byte {
+	pattern := compilePattern(ch.patternFor(record.Level))
+	record = withPerfMetrics(ch.Opts, record, pattern.hasPerf)
+
+	// Pretty records show the first line of the message in the pattern, and
+	// the other lines, the attributes, and any stack trace below it.
+	pretty := ch.prettyEnabled(record.Level)
+	var below []byte
+	if pretty {
+		var rest string
+		record.Message, rest, _ = strings.Cut(record.Message, "\n")
+		record.Message = strings.TrimSuffix(record.Message, "\r")
+		if rest != "" {
+			below = ch.appendPrettyLines(append(below, "\n\t"...), rest, "\n\t")
+		}
+	}
+
+	attrs := getBuffer()
+	defer putBuffer(attrs)
+	var fields map[string]string
+	if pretty {
+		below, fields = ch.appendPrettyFieldAttrs(below, record, pattern.custom)
+	} else if len(pattern.custom) == 0 {
+		*attrs = append(*attrs, ch.attrs...)
+		record.Attrs(func(a slog.Attr) bool {
+			*attrs = appendTextAttr(*attrs, ch.groups, a, ch.replaceAttr)
+			return true
+		})
+	} else {
+		*attrs, fields = ch.appendFieldAttrs(*attrs, record, pattern.custom)
+	}
+
+	source := recordSource(ch.Opts, record)
+	for _, segment := range pattern.segments {
+		if segment.placeholder {
+			buf = ch.appendPlaceholder(buf, segment, record, source, fields)
+		} else {
+			buf = append(buf, segment.text...)
+		}
+	}
+
+	if record.Level == LevelPerf && !pattern.hasPerf && !ch.Opts.StructuredPerf {
+		buf = append(buf, ' ')
+		buf = append(buf, DefaultPerfStartChar...)
+		buf = append(buf, formatPerfMetrics(ch.Opts)...)
+		buf = append(buf, DefaultPerfEndChar...)
+	}
+
+	buf = ch.appendSuffix(buf, record.Level, pattern, source, *attrs)
+
+	buf = append(buf, below...)
+	if !stacktraceEnabled(ch.Opts, record.Level) {
+		return buf
+	}
+	if pretty {
+		buf = append(buf, "\n\t"+StacktraceKey+":\n"...)
+		buf = append(buf, indentStacktrace(indentStacktrace(recordStacktrace(ctx, record.PC)))...)
+	} else {
+		buf = append(buf, '\n')
+		buf = append(buf, indentStacktrace(recordStacktrace(ctx, record.PC))...)
+	}
+	return buf
+}
+
+// appendFieldAttrs appends the handler and record attributes, except those
+// whose dotted key names one of the custom placeholders: their values are
+// returned by key instead, to be placed by the pattern.
+func (ch *CustomHandler) appendFieldAttrs(
+	buf []byte,
+	record slog.Record,
+	custom []string,
+) ([]byte, map[string]string) {
+	fields := make(map[string]string, len(custom))
+	take := func(groups []string, a slog.Attr) bool {
+		key := a.Key
+		if len(groups) > 0 {
+			key = strings.Join(groups, ".") + "." + key
+		}
+		if !Contains(custom, key) {
+			return false
+		}
+		if a.Value.Kind() == slog.KindString {
+			fields[key] = a.Value.String()
+		} else {
+			fields[key] = string(appendTextValue(nil, a.Value))
+		}
+		return true
+	}
+	for _, b := range ch.boundAttrs {
+		buf = appendTextAttrFunc(buf, b.groups, b.attr, ch.replaceAttr, take)
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		buf = appendTextAttrFunc(buf, ch.groups, a, ch.replaceAttr, take)
+		return true
+	})
+	return buf, fields
+}
+
+// appendPlaceholder appends the value of the placeholder, aligned to its width;
+// placeholders without a value are kept as they are.
+func (ch *CustomHandler) appendPlaceholder(
+	buf []byte,
+	segment patternSegment,
+	record slog.Record,
+	source string,
+	fields map[string]string,
+) []byte {
+	prefix := defaultIfEmpty(ch.Opts.ValuePrefixChar, DefaultValuePrefixChar)
+	suffix := defaultIfEmpty(ch.Opts.ValueSuffixChar, DefaultValueSuffixChar)
+	aligned := segment.width != 0 || segment.maxWidth != 0
+
+	var value, colorKey string
+	switch segment.text {
+	case DatePlaceholder, TimePlaceholder, DateTimePlaceholder:
+		layout := timeLayout(ch.Opts, segment.text)
+		t := recordTime(ch.Opts, record.Time)
+		if !ch.Opts.Color && !aligned {
+			buf = append(buf, prefix...)
+			buf = t.AppendFormat(buf, layout)
+			return append(buf, suffix...)
+		}
+		value, colorKey = t.Format(layout), TimeColorKey
+	case LevelPlaceholder:
+		value = levelLabel(record.Level, ch.Opts.UseSingleLetterLevel)
+		colorKey = GetLevelName(record.Level)
+	case MsgPlaceholder:
+		value, colorKey = record.Message, MsgColorKey
+	case PerfPlaceholder:
+		value = perfMetricsValue(ch.Opts)
+	case SourcePlaceholder:
+		value = resolveSourceValue(ch.Opts, record, source)
+	case HostPlaceholder, PIDPlaceholder, GoroutinePlaceholder:
+		value = processString(segment.text)
+	case VersionPlaceholder, RevisionPlaceholder:
+		value = buildValue(ch.Opts, segment.text)
+	default:
+		value = fields[segment.text[1:len(segment.text)-1]]
+	}
+
+	if value == "" {
+		return append(buf, segment.raw...)
+	}
+	value = escapeText(ch.Opts.Escape, value)
+	if aligned {
+		value = alignValue(value, segment.width, segment.maxWidth)
+	}
+	if ch.Opts.Color && colorKey != "" {
+		value = Colorize(value, GetColor(ch.Opts.Colors, colorKey))
+	}
+	buf = append(buf, prefix...)
+	buf = append(buf, value...)
+	return append(buf, suffix...)
+}
+
+// appendSuffix appends the attributes, and the level and source when the pattern
+// does not place them, as a bracketed key=value list.
+func (ch *CustomHandler) appendSuffix(
+	buf []byte,
+	level slog.Level,
+	pattern *compiledPattern,
+	source string,
+	attrs []byte,
+) []byte {
+	start := len(buf)
+	buf = append(buf, ' ')
+	buf = append(buf, DefaultSuffixStartChar...)
+	body := len(buf)
+
+	if !pattern.hasLevel {
+		buf = append(buf, slog.LevelKey+"="...)
+		buf = appendTextString(buf, levelLabel(level, ch.Opts.UseSingleLetterLevel))
+	}
+	if source != "" && !pattern.hasSource {
+		if len(buf) > body {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, slog.SourceKey+"="...)
+		buf = appendTextString(buf, source)
+	}
+	if len(attrs) > 0 {
+		if len(buf) > body {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, attrs...)
+	}
+
+	if len(buf) == body {
+		return buf[:start]
+	}
+	return append(buf, DefaultSuffixEndChar...)
 }
 
 // WithAttrs adds attributes to the handler.
 func (ch *CustomHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &CustomHandler{
-		Opts:    ch.Opts,
-		sb:      ch.sb,
-		handler: ch.handler.WithAttrs(attrs),
-		writer:  ch.writer,
+	clone := ch.derive(func(h slog.Handler) slog.Handler { return h.WithAttrs(attrs) })
+	if ch.replaceAttr != nil {
+		clone.attrs = append([]byte(nil), ch.attrs...)
+		for _, a := range attrs {
+			clone.attrs = appendTextAttr(clone.attrs, ch.groups, a, ch.replaceAttr)
+		}
 	}
+	clone.boundAttrs = ch.boundAttrs[:len(ch.boundAttrs):len(ch.boundAttrs)]
+	for _, a := range attrs {
+		clone.boundAttrs = append(clone.boundAttrs, boundAttr{groups: ch.groups, attr: a})
+	}
+	return clone
 }
 
 // WithGroup creates a new handler with grouped attributes.
 func (ch *CustomHandler) WithGroup(name string) slog.Handler {
-	return &CustomHandler{
-		Opts:    ch.Opts,
-		sb:      ch.sb,
-		handler: ch.handler.WithGroup(name),
-		writer:  ch.writer,
+	if name == "" {
+		return ch
+	}
+	clone := ch.derive(func(h slog.Handler) slog.Handler { return h.WithGroup(name) })
+	clone.groups = append(ch.groups[:len(ch.groups):len(ch.groups)], name)
+	return clone
+}
+
+// derive returns a copy of the handler whose slog handlers are derived with fn.
+// The copy gets its own formatter pool, and a builder of its own when the
+// handler has a pool to take it from.
+func (ch *CustomHandler) derive(fn func(slog.Handler) slog.Handler) *CustomHandler {
+	clone := *ch
+	if ch.formatters == nil {
+		clone.handler = fn(ch.handler)
+		return &clone
 	}
+	clone.formatters = deriveFormatterPool(ch.formatters, fn)
+	f, _ := clone.formatters.New().(*formatter)
+	clone.sb, clone.handler = f.sb, f.handler
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+// Handlers derived via WithAttrs or WithGroup share the same level.
+func (ch *CustomHandler) SetLevel(level string) error {
+	if !Contains(LogLevels, level) {
+		return fmt.Errorf("invalid log level: %s", level)
+	}
+	if ch.level == nil {
+		return fmt.Errorf("handler does not support level changes")
+	}
+	ch.level.Set(GetSlogLevel(level))
+	return nil
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (ch *CustomHandler) GetLevel() string {
+	if ch.level == nil {
+		return ch.Opts.Level
+	}
+	return GetLevelName(ch.level.Level())
 }
 
 // GetOptions returns the handler options.
@@ -162,7 +696,10 @@ func (ch *CustomHandler) GetStringBuilder() *strings.Builder {
 
 // GetWriter returns the handler writer.
 func (ch *CustomHandler) GetWriter() *bufio.Writer {
-	return ch.writer
+	if ch.out == nil {
+		return nil
+	}
+	return ch.out.writer
 }
 
 // GetSlogHandler returns the handler slog.Handler.
@@ -170,61 +707,147 @@ func (ch *CustomHandler) GetSlogHandler() slog.Handler {
 	return ch.handler
 }
 
+// setHandlerLevel sets the level on the handler if it supports runtime level changes.
+func setHandlerLevel(h CustomHandlerInterface, level string) error {
+	ls, ok := h.(LevelSetter)
+	if !ok {
+		return fmt.Errorf("handler does not support level changes")
+	}
+	return ls.SetLevel(level)
+}
+
+// getHandlerLevel returns the current level of the handler.
+func getHandlerLevel(h CustomHandlerInterface) string {
+	if ls, ok := h.(LevelSetter); ok {
+		return ls.GetLevel()
+	}
+	return h.GetOptions().Level
+}
+
 // GetPlaceholders returns the placeholders from the format.
 func GetPlaceholders(format string) []string {
-	re := regexp.MustCompile(`\[[a-z]+\]`)
-	return re.FindAllString(format, -1)
+	return placeholderPattern.FindAllString(format, -1)
 }
 
-// GetSourceValue returns the source value.
+// GetSourceValue returns the source value, or UnknownSource when the output
+// has no source.
 func GetSourceValue(
-	level slog.Level,
+	_ slog.Level,
 	sb *strings.Builder,
 	getKeyValue func(string, *strings.Builder, bool) string,
 ) string {
-	result := getKeyValue(slog.SourceKey, sb, true) // TODO: optimize to remove source key only once
-	if level == LevelPerf {
-		if fn, file, line, ok := GetPerfCallerInfo(); ok {
-			return GetOtherSourceValue(fn, file, line)
-		}
-		return UnknownSource
-	} else if result == "" || strings.HasSuffix(result, GenericLogFuncName) {
-		if fn, file, line, ok := GetOtherCallerInfo(); ok {
-			return GetOtherSourceValue(fn, file, line)
-		}
-		return UnknownSource
+	if result := getKeyValue(slog.SourceKey, sb, true); result != "" {
+		return result
 	}
+	return UnknownSource
+}
 
-	return result
+// resolveSourceValue returns the formatted source, or the source of the record
+// when the handler does not add sources, or UnknownSource when the record has
+// no program counter. The program counter is captured by the logging methods
+// with runtime.Callers, so the stack is never walked at format time.
+func resolveSourceValue(opts *CustomHandlerOptions, record slog.Record, result string) string {
+	if result != "" {
+		return result
+	}
+	if src := record.Source(); src != nil && src.File != "" {
+		return formatSource(opts, src)
+	}
+	return UnknownSource
 }
 
-// GetKeyValue returns the value of a key.
+// GetKeyValue returns the value of a key in the output of the slog text
+// handler, unquoted, and removes the key=value field when removeKey is set.
 func (ch *CustomHandler) GetKeyValue(key string, sb *strings.Builder, removeKey bool) string {
-	parts := strings.Fields(sb.String())
-	for i, part := range parts {
-		if strings.HasPrefix(part, key+"=") {
-			value := strings.TrimPrefix(part, key+"=")
-			if strings.HasPrefix(value, "\"") {
-				value = strings.TrimPrefix(value, "\"")
-				for j := i + 1; j < len(parts); j++ {
-					value += " " + parts[j]
-					if strings.HasSuffix(parts[j], "\"") {
-						value = strings.TrimSuffix(value, "\"")
-						break
-					}
-				}
-			}
+	return textKeyValue(key, sb, removeKey)
+}
+
+// textKeyValue returns the value of the first field with the key in the output
+// of the slog text handler, in which keys and values containing spaces, quotes,
+// '=' or non-printable characters are quoted. Removing the field leaves the rest
+// of the output untouched.
+func textKeyValue(key string, sb *strings.Builder, removeKey bool) string {
+	text := sb.String()
+	for i := 0; i < len(text); {
+		field, ok := nextTextField(text, i)
+		if !ok {
+			return ""
+		}
+		if field.key == key {
 			if removeKey {
-				parts = append(parts[:i], parts[i+1:]...)
+				start, end := field.start, field.end
+				if start > 0 && text[start-1] == ' ' {
+					start--
+				} else if end < len(text) && text[end] == ' ' {
+					end++
+				}
 				sb.Reset()
-				sb.WriteString(strings.Join(parts, " "))
+				sb.WriteString(text[:start])
+				sb.WriteString(text[end:])
 			}
-			return value
+			return field.value
 		}
+		i = field.end
 	}
 	return ""
 }
 
+// textField is a key=value field of text handler output, with the offsets of
+// its first byte and of the byte after it.
+type textField struct {
+	key   string
+	value string
+	start int
+	end   int
+}
+
+// nextTextField scans the field at or after offset i of text handler output. A
+// token without '=' is returned with an empty key, so it matches no key.
+func nextTextField(text string, i int) (textField, bool) {
+	for i < len(text) && (text[i] == ' ' || text[i] == '\n') {
+		i++
+	}
+	if i >= len(text) {
+		return textField{}, false
+	}
+	field := textField{start: i}
+	key, i := scanTextToken(text, i, true)
+	if i >= len(text) || text[i] != '=' {
+		field.end = i
+		return field, true
+	}
+	field.key = key
+	field.value, field.end = scanTextToken(text, i+1, false)
+	return field, true
+}
+
+// scanTextToken scans a key, ending at '=', or a value, ending at a space or a
+// newline, from offset i. Quoted tokens are unquoted; a token with an
+// unterminated quote is returned as written.
+func scanTextToken(text string, i int, isKey bool) (string, int) {
+	if i < len(text) && text[i] == '"' {
+		if quoted, err := strconv.QuotedPrefix(text[i:]); err == nil {
+			if token, err := strconv.Unquote(quoted); err == nil {
+				return token, i + len(quoted)
+			}
+		}
+	}
+	start := i
+	for i < len(text) && text[i] != ' ' && text[i] != '\n' && (!isKey || text[i] != '=') {
+		i++
+	}
+	return text[start:i], i
+}
+
+// patternFor returns the pattern of the level: its entry in Opts.Patterns, or
+// else Opts.Pattern, or else the default pattern of the level.
+func (ch *CustomHandler) patternFor(level slog.Level) string {
+	if pattern := ch.Opts.Patterns[GetLevelName(level)]; pattern != "" {
+		return pattern
+	}
+	return getPatternForLevel(level, ch.Opts.Pattern)
+}
+
 func getPatternForLevel(level slog.Level, pattern string) string {
 	if pattern != "" {
 		return pattern
@@ -250,19 +873,23 @@ func buildOutput(
 	opts *CustomHandlerOptions,
 ) string {
 	output := &strings.Builder{}
-	result := pattern
-	for placeholder, value := range values {
-		if value != "" {
-			value = AddPrefixSuffix(value, opts)
-			result = strings.ReplaceAll(result, placeholder, value)
+	// Placeholders are replaced in a single pass, so values containing
+	// placeholders, such as a message "[level]", are written as they are.
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringIndex(pattern, -1) {
+		if value := values[pattern[loc[0]:loc[1]]]; value != "" {
+			output.WriteString(pattern[last:loc[0]])
+			output.WriteString(AddPrefixSuffix(value, opts))
+			last = loc[1]
 		}
 	}
-	output.WriteString(result)
+	output.WriteString(pattern[last:])
 
-	if level == LevelPerf && !Contains(GetPlaceholders(pattern), PerfPlaceholder) {
+	structured := opts != nil && opts.StructuredPerf
+	if level == LevelPerf && !structured && !Contains(GetPlaceholders(pattern), PerfPlaceholder) {
 		output.WriteString(" ")
 		output.WriteString(DefaultPerfStartChar)
-		output.WriteString(GetPerformanceMetrics())
+		output.WriteString(formatPerfMetrics(opts))
 		output.WriteString(DefaultPerfEndChar)
 	}
 
@@ -300,27 +927,45 @@ func GetPlaceholderValues(
 	record slog.Record,
 	placeholders []string,
 	getKeyValue func(string, *strings.Builder, bool) string,
+) map[string]interface{} {
+	return placeholderValues(nil, sb, record, placeholders, getKeyValue)
+}
+
+// placeholderValues returns the placeholder values, formatting times and perf
+// metrics as configured in the options.
+func placeholderValues(
+	opts *CustomHandlerOptions,
+	sb *strings.Builder,
+	record slog.Record,
+	placeholders []string,
+	getKeyValue func(string, *strings.Builder, bool) string,
 ) map[string]interface{} {
 	values := make(map[string]interface{}, len(placeholders))
 	for _, placeholder := range placeholders {
 		key := placeholder
 		switch placeholder {
-		case DatePlaceholder:
-			values[key] = record.Time.Format(DefaultDateFormat)
-		case TimePlaceholder:
-			values[key] = record.Time.Format(DefaultTimeFormat)
-		case DateTimePlaceholder:
-			values[key] = record.Time.Format(DefaultDateTimeFormat)
+		case DatePlaceholder, TimePlaceholder, DateTimePlaceholder:
+			values[key] = recordTime(opts, record.Time).Format(timeLayout(opts, placeholder))
 		case LevelPlaceholder:
 			values[key] = getKeyValue(slog.LevelKey, sb, true)
 		case MsgPlaceholder:
 			values[key] = record.Message
 		case PerfPlaceholder:
-			values[key] = GetPerformanceMetrics()
+			values[key] = perfMetricsValue(opts)
 		case SourcePlaceholder:
-			values[key] = GetSourceValue(record.Level, sb, getKeyValue)
+			values[key] = resolveSourceValue(opts, record, getKeyValue(slog.SourceKey, sb, true))
+		case HostPlaceholder, PIDPlaceholder, GoroutinePlaceholder:
+			values[key] = processValue(placeholder)
+		case VersionPlaceholder, RevisionPlaceholder:
+			if v := buildValue(opts, placeholder); v != "" {
+				values[key] = v
+			}
 		default:
 			v := getKeyValue(placeholder, sb, false)
+			if v == "" {
+				// Custom placeholders take their attribute out of the suffix.
+				v = getKeyValue(placeholder[1:len(placeholder)-1], sb, true)
+			}
 			if v != "" {
 				values[key] = v
 			}
@@ -365,7 +1010,12 @@ func GenerateDefaultCustomReplaceAttr(
 	opts CustomHandlerOptions,
 	keysToRemove ...string,
 ) CustomReplaceAttr {
-	return func(_ []string, a slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		a = replaceErrorValue(a)
+		// Built-in attributes are only ever top-level; grouped keys belong to the caller.
+		if len(groups) > 0 {
+			return a
+		}
 		if ContainsKey(keysToRemove, a.Key) {
 			return slog.Attr{}
 		}
@@ -386,12 +1036,7 @@ func GenerateDefaultCustomReplaceAttr(
 			switch source := a.Value.Any().(type) {
 			case *slog.Source:
 				if source.File != "" {
-					f := BaseName(source.File)
-					l := source.Line
-					fn := source.Function
-					fnParts := strings.Split(fn, "/")
-					pkgAndFn := fnParts[len(fnParts)-1]
-					a.Value = slog.StringValue(fmt.Sprintf("%s:%d:%s", f, l, pkgAndFn))
+					a.Value = slog.StringValue(formatSource(&opts, source))
 				}
 			case string:
 				a.Value = slog.StringValue(source)
@@ -403,14 +1048,49 @@ func GenerateDefaultCustomReplaceAttr(
 }
 
 // CreateRotationWriter creates a rotation writer for the given options.
-func CreateRotationWriter(opts CustomHandlerOptions) *bufio.Writer {
-	logWriter := &lumberjack.Logger{
+func CreateRotationWriter(opts FileHandlerOptions) *bufio.Writer {
+	return newFileWriter(newRotator(opts), opts)
+}
+
+// newRotationLogger creates the lumberjack logger backing a rotation writer.
+func newRotationLogger(opts FileHandlerOptions) *lumberjack.Logger {
+	return &lumberjack.Logger{
 		Filename:   opts.File,
 		MaxSize:    opts.MaxSize,
 		MaxBackups: opts.MaxBackups,
 		MaxAge:     opts.MaxAge,
-		Compress:   false,
+		Compress:   opts.Compress,
+	}
+}
+
+// rotateHandler flushes the handler and rotates its log file.
+func rotateHandler(h CustomHandlerInterface, rotator rotationWriter) error {
+	if rotator == nil {
+		return fmt.Errorf("handler does not support rotation")
+	}
+	if f, ok := h.(Flusher); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
 	}
+	if err := rotator.Rotate(); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return nil
+}
 
-	return bufio.NewWriter(logWriter)
+// closeHandler flushes the handler and closes its log file.
+func closeHandler(h CustomHandlerInterface, rotator rotationWriter) error {
+	if f, ok := h.(Flusher); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	if rotator == nil {
+		return nil
+	}
+	if err := rotator.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	return nil
 }
@@ -5,8 +5,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -55,12 +58,15 @@ func TestNewCustomHandler(t *testing.T) {
 	}
 
 	handler = NewCustomHandler(customOpts, writer, nil)
-	if handler.Opts != customOpts {
+	if !reflect.DeepEqual(handler.Opts, customOpts) {
 		t.Error("Expected handler options to match provided options")
 	}
+	if handler.Opts == customOpts {
+		t.Error("Expected handler options to be copied")
+	}
 
 	// Verify the handler was set up correctly
-	if handler.writer != writer {
+	if handler.GetWriter() != writer {
 		t.Error("Expected writer to be set correctly")
 	}
 	if handler.sb == nil {
@@ -124,7 +130,7 @@ func TestCustomHandler_GetMutex(t *testing.T) {
 	opts := &CustomHandlerOptions{}
 	handler := NewCustomHandler(opts, bufio.NewWriter(&strings.Builder{}), nil)
 
-	assert.NotNil(t, &handler.mu)
+	assert.NotNil(t, &handler.out.mu)
 }
 
 func TestCustomHandler_GetStringBuilder(t *testing.T) {
@@ -180,15 +186,14 @@ func TestGetSourceValue(t *testing.T) {
 		return ""
 	}
 
-	// Test for LevelPerf
+	// Test for LevelPerf, which uses the recorded source like other levels
 	result := GetSourceValue(LevelPerf, sb, getKeyValue)
-	assert.Equal(t, "unknown", result)
+	assert.Equal(t, "main.go:42", result)
 
 	// Test for other levels with an empty result
 	sb.Reset()
-	sb.WriteString("source=main.go:42")
 	result = GetSourceValue(slog.LevelInfo, sb, getKeyValue)
-	assert.Equal(t, "main.go:42", result)
+	assert.Equal(t, UnknownSource, result)
 
 	// Test for other levels with a non-empty result
 	sb.Reset()
@@ -425,6 +430,91 @@ func TestGetKeyValue(t *testing.T) {
 	}
 }
 
+func TestGetKeyValue_EdgeCases(t *testing.T) {
+	handler := &CustomHandler{Opts: &CustomHandlerOptions{}}
+
+	tests := []struct {
+		name     string
+		text     string
+		key      string
+		value    string
+		remained string
+	}{
+		{"escaped quotes", `a=1 k="say \"hi\" now" b=2`, "k", `say "hi" now`, "a=1 b=2"},
+		{"equals in value", `k="x=y" b=2`, "k", "x=y", "b=2"},
+		{"key inside a value", `note="source=fake" source=app.go:1`, "source", "app.go:1", `note="source=fake"`},
+		{"spaces kept", `k=1 msg="a  b"`, "k", "1", `msg="a  b"`},
+		{"unicode", `k="héllo wörld" z=✓`, "z", "✓", `k="héllo wörld"`},
+		{"quoted key", `"a key"=v k=1`, "a key", "v", "k=1"},
+		{"unterminated quote", `k="open b=2`, "k", `"open`, "b=2"},
+		{"trailing newline", "k=1 b=2\n", "b", "2", "k=1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &strings.Builder{}
+			sb.WriteString(tt.text)
+			assert.Equal(t, tt.value, handler.GetKeyValue(tt.key, sb, true))
+			assert.Equal(t, tt.remained, sb.String())
+		})
+	}
+}
+
+// FuzzGetKeyValue checks that any attribute written by the slog text handler is
+// read back and removed without changing the rest of the output.
+func FuzzGetKeyValue(f *testing.F) {
+	f.Add("key", "value", "other")
+	f.Add("k", `say "hi"`, "x=y")
+	f.Add("a key", "a  b\n", "source=fake")
+	f.Add("k", "\xff\x00", "héllo")
+	f.Fuzz(func(t *testing.T, key, value, other string) {
+		if key == "" || key == "a" || key == "z" {
+			t.Skip()
+		}
+		format := func(attrs ...slog.Attr) string {
+			sb := &strings.Builder{}
+			handler := slog.NewTextHandler(sb, &slog.HandlerOptions{
+				ReplaceAttr: RemoveGivenKeys(slog.TimeKey, slog.LevelKey, slog.MessageKey),
+			})
+			record := slog.NewRecord(time.Time{}, slog.LevelInfo, "", 0)
+			record.AddAttrs(attrs...)
+			assert.NoError(t, handler.Handle(context.Background(), record))
+			return sb.String()
+		}
+
+		sb := &strings.Builder{}
+		sb.WriteString(format(slog.String("a", other), slog.String(key, value), slog.String("z", other)))
+		got := (&CustomHandler{}).GetKeyValue(key, sb, true)
+		assert.Equal(t, value, got)
+		assert.Equal(t, format(slog.String("a", other), slog.String("z", other)), sb.String())
+	})
+}
+
+// FuzzGetPlaceholders checks that the placeholders found in any pattern are
+// well-formed and compile into segments reproducing the pattern.
+func FuzzGetPlaceholders(f *testing.F) {
+	f.Add("[time] [level] [msg]")
+	f.Add("[[level:-5]] [source:30.30] [req.id]")
+	f.Add("[msg:99999999999999999999] [é] [] [a")
+	f.Fuzz(func(t *testing.T, pattern string) {
+		for _, placeholder := range GetPlaceholders(pattern) {
+			assert.True(t, strings.HasPrefix(placeholder, "[") && strings.HasSuffix(placeholder, "]"))
+			assert.Contains(t, pattern, placeholder)
+		}
+
+		var raw strings.Builder
+		for _, segment := range compilePattern(pattern).segments {
+			if segment.placeholder {
+				raw.WriteString(segment.raw)
+				assert.LessOrEqual(t, max(segment.width, -segment.width), maxPlaceholderWidth)
+				assert.LessOrEqual(t, segment.maxWidth, maxPlaceholderWidth)
+			} else {
+				raw.WriteString(segment.text)
+			}
+		}
+		assert.Equal(t, pattern, raw.String())
+	})
+}
+
 // TestBuildOutput tests the buildOutput function which formats log messages
 func TestBuildOutput(t *testing.T) {
 	tests := []struct {
@@ -447,6 +537,16 @@ func TestBuildOutput(t *testing.T) {
 			level:     slog.LevelInfo,
 			expected:  DefaultValuePrefixChar + "INFO" + DefaultValueSuffixChar + " " + DefaultValuePrefixChar + "Test message" + DefaultValueSuffixChar,
 		},
+		{
+			name:    "Values containing placeholders",
+			pattern: "[level] [msg]",
+			values: map[string]string{
+				LevelPlaceholder: "INFO",
+				MsgPlaceholder:   "[level] [msg]",
+			},
+			level:    slog.LevelInfo,
+			expected: "INFO [level] [msg]",
+		},
 		{
 			name:    "With suffix content",
 			pattern: "[level] [msg]",
@@ -858,3 +958,195 @@ func TestBuildOutputWithCustomPrefixSuffix(t *testing.T) {
 		t.Errorf("buildOutput() with custom prefix/suffix = %v, want %v", result, expected)
 	}
 }
+
+func TestCustomHandler_SetLevel(t *testing.T) {
+	opts := &CustomHandlerOptions{Level: InfoLevel, Enabled: true}
+	handler := NewCustomHandler(opts, bufio.NewWriter(&strings.Builder{}), nil)
+	ctx := context.Background()
+
+	assert.False(t, handler.Enabled(ctx, slog.LevelDebug))
+	assert.Equal(t, InfoLevel, handler.GetLevel())
+
+	derived := handler.WithAttrs([]slog.Attr{slog.String("k", "v")})
+
+	assert.NoError(t, handler.SetLevel(DebugLevel))
+	assert.True(t, handler.Enabled(ctx, slog.LevelDebug))
+	assert.True(t, derived.Enabled(ctx, slog.LevelDebug))
+	assert.Equal(t, DebugLevel, handler.GetLevel())
+
+	assert.NoError(t, handler.SetLevel(ErrorLevel))
+	assert.False(t, handler.Enabled(ctx, slog.LevelWarn))
+
+	assert.Error(t, handler.SetLevel("verbose"))
+	assert.Equal(t, ErrorLevel, handler.GetLevel())
+}
+
+func TestHandlers_SetLevel(t *testing.T) {
+	tempDir := t.TempDir()
+	opts := FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{Level: InfoLevel, Enabled: true},
+		File:                 tempDir + "/test.log",
+	}
+
+	fileHandler, err := NewFileHandler(opts)
+	assert.NoError(t, err)
+	jsonHandler, err := NewJSONHandler(opts, nil)
+	assert.NoError(t, err)
+
+	for _, h := range []slog.Handler{NewConsoleHandler(ConsoleHandlerOptions{CustomHandlerOptions: opts.CustomHandlerOptions}), fileHandler, jsonHandler} {
+		ls, ok := h.(LevelSetter)
+		if !ok {
+			t.Fatalf("%T does not implement LevelSetter", h)
+		}
+		assert.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+		assert.NoError(t, ls.SetLevel(DebugLevel))
+		assert.True(t, h.Enabled(context.Background(), slog.LevelDebug))
+		assert.Equal(t, DebugLevel, ls.GetLevel())
+	}
+}
+
+func TestCustomHandler_MaxLevel(t *testing.T) {
+	opts := testDefaultOptions()
+	opts.Level = InfoLevel
+	opts.MaxLevel = WarnLevel
+	handler := NewCustomHandler(opts, bufio.NewWriter(io.Discard), nil)
+
+	ctx := context.Background()
+	assert.False(t, handler.Enabled(ctx, slog.LevelDebug))
+	assert.True(t, handler.Enabled(ctx, slog.LevelInfo))
+	assert.True(t, handler.Enabled(ctx, slog.LevelWarn))
+	assert.False(t, handler.Enabled(ctx, slog.LevelError))
+}
+
+func TestCustomHandler_Groups(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{Level: DebugLevel, Enabled: true, Pattern: "[level] [msg]"}
+	logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.With("app", "api").WithGroup("req").With("id", 7).
+		Info("hello", "msg", "inner", slog.Group("user", "name", "bob"))
+
+	assert.Equal(t, "INFO hello [app=api req.id=7 req.msg=inner req.user.name=bob]\n", buf.String())
+}
+
+func TestCustomHandler_DerivedHandlersConcurrent(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{Level: InfoLevel, Enabled: true, Pattern: "[msg]"}
+	root := NewCustomHandler(opts, bufio.NewWriter(buf), nil)
+	loggers := []*slog.Logger{
+		slog.New(root),
+		slog.New(root).With("child", 1),
+		slog.New(root).WithGroup("g").With("child", 2),
+		slog.New(root.WithAttrs([]slog.Attr{slog.Int("child", 3)})),
+	}
+
+	var wg sync.WaitGroup
+	for _, logger := range loggers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				logger.Info("record")
+			}
+		}()
+	}
+	wg.Wait()
+
+	counts := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		counts[line]++
+	}
+	assert.Equal(t, map[string]int{
+		"record [level=INFO]":           100,
+		"record [level=INFO child=1]":   100,
+		"record [level=INFO g.child=2]": 100,
+		"record [level=INFO child=3]":   100,
+	}, counts)
+}
+
+func TestCustomHandler_DerivedHandlersIndependent(t *testing.T) {
+	opts := &CustomHandlerOptions{Level: InfoLevel, Enabled: true, Pattern: "[msg]"}
+	root := NewCustomHandler(opts, bufio.NewWriter(io.Discard), RemoveGivenKeys(slog.TimeKey, slog.MessageKey))
+	child := root.WithAttrs([]slog.Attr{slog.Int("child", 1)}).(*CustomHandler)
+	sibling := root.WithGroup("g").(*CustomHandler)
+
+	assert.NotSame(t, root.sb, child.sb)
+	assert.NotSame(t, child.sb, sibling.sb)
+	assert.Same(t, root.Opts, child.Opts)
+	assert.Same(t, root.out, sibling.out)
+
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "record", 0)
+	record.AddAttrs(slog.Int("n", 1))
+	for handler, want := range map[*CustomHandler]string{
+		root:    "record [level=INFO n=1]",
+		child:   "record [level=INFO child=1 n=1]",
+		sibling: "record [level=INFO g.n=1]",
+	} {
+		got, err := handler.Format(context.Background(), record)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestCustomHandler_CustomPlaceholders(t *testing.T) {
+	for _, replaceAttr := range []CustomReplaceAttr{nil, RemoveGivenKeys(slog.TimeKey, slog.MessageKey)} {
+		buf := &bytes.Buffer{}
+		opts := &CustomHandlerOptions{Level: DebugLevel, Enabled: true, Pattern: "[level] [request_id] [msg]"}
+		logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), replaceAttr))
+
+		logger.With("request_id", "r-1").Info("served", "status", 200)
+		logger.Info("served", "request_id", 42, "status", 200)
+		logger.Info("no id")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, "INFO r-1 served [status=200]", lines[0])
+		assert.Equal(t, "INFO 42 served [status=200]", lines[1])
+		assert.Equal(t, "INFO [request_id] no id", lines[2])
+	}
+}
+
+func TestCustomHandler_CustomPlaceholdersGrouped(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{Level: DebugLevel, Enabled: true, Pattern: "[level] [req.id] [msg]"}
+	logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.WithGroup("req").With("id", "r-1").Info("served", "path", "/users")
+	logger.Info("served", slog.Group("req", "id", "r-2", "path", "/health"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "INFO r-1 served [req.path=/users]", lines[0])
+	assert.Equal(t, "INFO r-2 served [req.path=/health]", lines[1])
+}
+
+func TestCompilePattern_Custom(t *testing.T) {
+	cp := compilePattern("[time] [level] [request_id] [user.name] [msg]")
+	assert.Equal(t, []string{"request_id", "user.name"}, cp.custom)
+	assert.True(t, cp.hasLevel)
+	assert.Empty(t, compilePattern(DefaultFormat).custom)
+}
+
+func TestCustomHandler_LevelPatterns(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{
+		Level:    DebugLevel,
+		Enabled:  true,
+		Pattern:  "[level] [msg]",
+		Patterns: map[string]string{ErrorLevel: "[level] [msg] <[code]>"},
+	}
+	logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Info("served", "code", 200)
+	logger.Error("failed", "code", 500)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "INFO served [code=200]", lines[0])
+	assert.Equal(t, "ERROR failed <500>", lines[1])
+}
+
+func TestValidateLevels_Patterns(t *testing.T) {
+	handler := HandlerConfig{Type: ConsoleHandlerType, Level: InfoLevel, Patterns: map[string]string{WarnLevel: "[msg]"}}
+	assert.NoError(t, validateLevels(&handler))
+
+	handler.Patterns["fatal"] = "[msg]"
+	assert.EqualError(t, validateLevels(&handler), "invalid pattern level: fatal")
+}
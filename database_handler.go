@@ -0,0 +1,227 @@
+package multilog
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Database handler settings
+const (
+	DefaultDatabaseTable = "logs"
+	databaseColumns      = 4
+)
+
+// postgresDrivers lists the driver names using $n placeholders and JSONB.
+var postgresDrivers = []string{"postgres", "pgx", "pq"}
+
+// tableNamePattern matches table names, optionally qualified with a schema.
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// databaseRow is a record prepared for insertion.
+type databaseRow struct {
+	time    time.Time
+	level   string
+	message string
+	attrs   string
+}
+
+// DatabaseHandler is a Handler that inserts records into a database table
+// with time, level, message and attrs columns, the attributes as a JSON object.
+type DatabaseHandler struct {
+	Handler  *CustomHandler
+	db       *sql.DB
+	batch    *batcher[databaseRow]
+	opts     *DatabaseHandlerOptions
+	table    string
+	postgres bool
+	ownsDB   bool
+}
+
+// DatabaseHandlerOptions are the options of database handlers: the formatting
+// and batching options, and the driver, data source and table.
+type DatabaseHandlerOptions struct {
+	CustomHandlerOptions
+	BatchOptions
+	Driver string
+	DSN    string
+	Table  string
+}
+
+// NewDatabaseHandler creates a database Handler writing to db, creating the
+// table if it does not exist. opts.Driver selects the SQL dialect: PostgreSQL
+// for "postgres", "pgx" and "pq", and SQLite otherwise. Records are inserted
+// in batches of up to BatchSize rows, every FlushInterval.
+func NewDatabaseHandler(opts DatabaseHandlerOptions, db *sql.DB) (slog.Handler, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database handler requires a database")
+	}
+	table := defaultIfEmpty(opts.Table, DefaultDatabaseTable)
+	if !tableNamePattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name: %s", table)
+	}
+
+	dh := &DatabaseHandler{
+		Handler:  NewCustomHandler(&opts.CustomHandlerOptions, bufio.NewWriter(io.Discard), nil),
+		db:       db,
+		opts:     &opts,
+		table:    table,
+		postgres: Contains(postgresDrivers, opts.Driver),
+	}
+	if err := dh.createTable(); err != nil {
+		return nil, err
+	}
+	dh.batch = newBatcher(opts.BatchSize, opts.FlushInterval, opts.MaxBufferSize, dh.insert, opts.OnError)
+	return dh, nil
+}
+
+// newDatabaseHandlerFromConfig opens opts.DSN with opts.Driver and creates a
+// database Handler owning the connection. The driver must be registered by
+// importing it, e.g. modernc.org/sqlite or github.com/jackc/pgx/v5/stdlib.
+func newDatabaseHandlerFromConfig(opts DatabaseHandlerOptions) (slog.Handler, error) {
+	db, err := sql.Open(opts.Driver, opts.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	handler, err := NewDatabaseHandler(opts, db)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	handler.(*DatabaseHandler).ownsDB = true
+	return handler, nil
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (dh *DatabaseHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return dh.Handler.Enabled(ctx, level)
+}
+
+// Handle queues the log record for the next insert.
+func (dh *DatabaseHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !dh.Enabled(ctx, record.Level) {
+		return nil
+	}
+	data := dh.Handler.templateRecord(record)
+	attrs, err := json.Marshal(data.Attrs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal attributes: %w", err)
+	}
+	return dh.batch.add(ctx, databaseRow{
+		time:    record.Time.UTC(),
+		level:   GetLevelName(record.Level),
+		message: record.Message,
+		attrs:   string(attrs),
+	})
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (dh *DatabaseHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *dh
+	clone.Handler = dh.Handler.WithAttrs(attrs).(*CustomHandler)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (dh *DatabaseHandler) WithGroup(name string) slog.Handler {
+	clone := *dh
+	clone.Handler = dh.Handler.WithGroup(name).(*CustomHandler)
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (dh *DatabaseHandler) SetLevel(level string) error {
+	return dh.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (dh *DatabaseHandler) GetLevel() string {
+	return dh.Handler.GetLevel()
+}
+
+// Flush inserts all queued records.
+func (dh *DatabaseHandler) Flush() error {
+	return dh.batch.Flush()
+}
+
+// Close inserts all queued records, stops the background flush loop, and
+// closes the database if the handler opened it.
+func (dh *DatabaseHandler) Close() error {
+	err := dh.batch.Close()
+	if dh.ownsDB {
+		if closeErr := dh.db.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// customHandler implements customHandlerProvider.
+func (dh *DatabaseHandler) customHandler() CustomHandlerInterface {
+	return dh.Handler
+}
+
+// createTable creates the log table if it does not exist.
+func (dh *DatabaseHandler) createTable() error {
+	timeType, attrsType := "TIMESTAMP", "TEXT"
+	if dh.postgres {
+		timeType, attrsType = "TIMESTAMPTZ", "JSONB"
+	}
+	query := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (time %s NOT NULL, level TEXT NOT NULL, message TEXT NOT NULL, attrs %s)",
+		dh.table, timeType, attrsType,
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultHTTPTimeout)
+	defer cancel()
+	if _, err := dh.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create table %s: %w", dh.table, err)
+	}
+	return nil
+}
+
+// insert writes the rows with a single multi-row insert, retrying on failure.
+func (dh *DatabaseHandler) insert(rows []databaseRow) error {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO " + dh.table + " (time, level, message, attrs) VALUES ")
+	args := make([]any, 0, len(rows)*databaseColumns)
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('(')
+		for c := range databaseColumns {
+			if c > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(dh.placeholder(i*databaseColumns + c + 1))
+		}
+		sb.WriteByte(')')
+		args = append(args, row.time, row.level, row.message, row.attrs)
+	}
+	query := sb.String()
+
+	return dh.opts.retryPolicy().Do(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultHTTPTimeout)
+		defer cancel()
+		if _, err := dh.db.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to insert %d log records: %w", len(rows), err)
+		}
+		return nil
+	})
+}
+
+// placeholder returns the n-th bind parameter of the dialect.
+func (dh *DatabaseHandler) placeholder(n int) string {
+	if dh.postgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
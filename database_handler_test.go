@@ -0,0 +1,196 @@
+package multilog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingDriver is a database/sql driver recording the statements it executes.
+type recordingDriver struct {
+	fail    error
+	queries []string
+	args    [][]driver.NamedValue
+	mu      sync.Mutex
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) {
+	return &recordingConn{driver: d}, nil
+}
+
+func (d *recordingDriver) statements() ([]string, [][]driver.NamedValue) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string{}, d.queries...), append([][]driver.NamedValue{}, d.args...)
+}
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	if c.driver.fail != nil && strings.HasPrefix(query, "INSERT") {
+		return nil, c.driver.fail
+	}
+	c.driver.queries = append(c.driver.queries, query)
+	c.driver.args = append(c.driver.args, args)
+	return driver.RowsAffected(1), nil
+}
+
+var (
+	testDriver     = &recordingDriver{}
+	testDriverOnce sync.Once
+)
+
+// openRecordingDB returns a database backed by a fresh recording driver.
+func openRecordingDB(t *testing.T) (*sql.DB, *recordingDriver) {
+	t.Helper()
+	testDriverOnce.Do(func() { sql.Register("multilogtest", testDriver) })
+	testDriver.mu.Lock()
+	testDriver.fail, testDriver.queries, testDriver.args = nil, nil, nil
+	testDriver.mu.Unlock()
+
+	db, err := sql.Open("multilogtest", "")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, testDriver
+}
+
+func TestNewDatabaseHandler_Validation(t *testing.T) {
+	_, err := NewDatabaseHandler(DatabaseHandlerOptions{}, nil)
+	assert.Error(t, err)
+
+	db, _ := openRecordingDB(t)
+	_, err = NewDatabaseHandler(DatabaseHandlerOptions{Table: "logs; DROP TABLE users"}, db)
+	assert.ErrorContains(t, err, "invalid table name")
+}
+
+func TestDatabaseHandler_SQLite(t *testing.T) {
+	db, recorder := openRecordingDB(t)
+	handler, err := NewDatabaseHandler(DatabaseHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			BatchSize:     10,
+			FlushInterval: time.Hour,
+		},
+		Driver: "sqlite",
+	}, db)
+	assert.NoError(t, err)
+
+	logger := NewLogger(handler).WithField("service", "api")
+	logger.Debug("skipped")
+	logger.Info("first")
+	logger.Error("second", "code", 500)
+	assert.NoError(t, handler.(*DatabaseHandler).Close())
+
+	queries, args := recorder.statements()
+	assert.Len(t, queries, 2)
+	assert.Equal(t,
+		"CREATE TABLE IF NOT EXISTS logs (time TIMESTAMP NOT NULL, level TEXT NOT NULL, message TEXT NOT NULL, attrs TEXT)",
+		queries[0])
+	assert.Equal(t, "INSERT INTO logs (time, level, message, attrs) VALUES (?,?,?,?),(?,?,?,?)", queries[1])
+	assert.Len(t, args[1], 8)
+	assert.IsType(t, time.Time{}, args[1][0].Value)
+	assert.Equal(t, InfoLevel, args[1][1].Value)
+	assert.Equal(t, "first", args[1][2].Value)
+	assert.Equal(t, ErrorLevel, args[1][5].Value)
+
+	var attrs map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(args[1][7].Value.(string)), &attrs))
+	assert.Equal(t, map[string]any{"service": "api", "code": float64(500)}, attrs)
+}
+
+func TestDatabaseHandler_Postgres(t *testing.T) {
+	db, recorder := openRecordingDB(t)
+	handler, err := NewDatabaseHandler(DatabaseHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		Driver: "pgx",
+		Table:  "app.events",
+	}, db)
+	assert.NoError(t, err)
+	dh := handler.(*DatabaseHandler)
+	defer dh.Close()
+
+	NewLogger(handler).Info("hello")
+	assert.NoError(t, dh.Flush())
+
+	queries, _ := recorder.statements()
+	assert.Contains(t, queries[0], "app.events (time TIMESTAMPTZ NOT NULL")
+	assert.Contains(t, queries[0], "attrs JSONB)")
+	assert.Equal(t, "INSERT INTO app.events (time, level, message, attrs) VALUES ($1,$2,$3,$4)", queries[1])
+}
+
+func TestDatabaseHandler_InsertError(t *testing.T) {
+	db, recorder := openRecordingDB(t)
+	handler, err := NewDatabaseHandler(DatabaseHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+			RetryBackoff:  time.Millisecond,
+		},
+	}, db)
+	assert.NoError(t, err)
+	dh := handler.(*DatabaseHandler)
+	defer dh.Close()
+
+	recorder.mu.Lock()
+	recorder.fail = errors.New("disk full")
+	recorder.mu.Unlock()
+
+	NewLogger(handler).Info("lost")
+	assert.ErrorContains(t, dh.Flush(), "disk full")
+}
+
+func TestCreateHandler_Database(t *testing.T) {
+	openRecordingDB(t)
+	cfg := &Config{Multilog: LogConfig{Handlers: []HandlerConfig{
+		{Type: DatabaseHandlerType, Level: InfoLevel, Enabled: true, Driver: "multilogtest"},
+	}}}
+	assert.Error(t, validateConfig(cfg))
+
+	cfg.Multilog.Handlers[0].DSN = "memory"
+	cfg.Multilog.Handlers[0].Table = "bad-name"
+	assert.Error(t, validateConfig(cfg))
+
+	cfg.Multilog.Handlers[0].Table = "app_logs"
+	assert.NoError(t, validateConfig(cfg))
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	dh := handlers[0].(*DatabaseHandler)
+	assert.True(t, dh.ownsDB)
+	assert.NoError(t, dh.Close())
+}
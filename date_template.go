@@ -0,0 +1,278 @@
+package multilog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateDirective is a strftime-style directive of a file name template.
+type dateDirective struct {
+	layout string
+	digits int
+}
+
+// dateDirectives are the directives supported in file name templates.
+var dateDirectives = map[byte]dateDirective{
+	'Y': {layout: "2006", digits: 4},
+	'y': {layout: "06", digits: 2},
+	'm': {layout: "01", digits: 2},
+	'd': {layout: "02", digits: 2},
+	'j': {layout: "002", digits: 3},
+	'H': {layout: "15", digits: 2},
+	'M': {layout: "04", digits: 2},
+	'S': {layout: "05", digits: 2},
+}
+
+// hasDateDirectives reports whether the file name template contains date
+// directives such as %Y.
+func hasDateDirectives(template string) bool {
+	for i := 0; i < len(template)-1; i++ {
+		if template[i] == '%' {
+			if _, ok := dateDirectives[template[i+1]]; ok {
+				return true
+			}
+			i++
+		}
+	}
+	return false
+}
+
+// checkDateTemplate returns an error if the file name template contains an
+// unknown directive. %% stands for a literal %.
+func checkDateTemplate(template string) error {
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' {
+			continue
+		}
+		if i+1 == len(template) {
+			return fmt.Errorf("incomplete date directive in file: %s", template)
+		}
+		i++
+		if _, ok := dateDirectives[template[i]]; !ok && template[i] != '%' {
+			return fmt.Errorf("unknown date directive %%%c in file: %s", template[i], template)
+		}
+	}
+	return nil
+}
+
+// checkTimeLayoutName returns an error if the file name of a time-rotated
+// handler is a Go time layout such as "app-2006-01-02.log": names are formatted
+// with date directives only, so it would be written to as it is.
+func checkTimeLayoutName(file string, interval time.Duration) error {
+	if interval > 0 && !hasDateDirectives(file) && strings.Contains(filepath.Base(file), "2006") {
+		return fmt.Errorf("time layouts are not supported in file names, use date directives such as %%Y-%%m-%%d: %s", file)
+	}
+	return nil
+}
+
+// formatDateTemplate replaces the date directives of the template with the time.
+func formatDateTemplate(template string, t time.Time) string {
+	var sb strings.Builder
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' || i+1 == len(template) {
+			sb.WriteByte(template[i])
+			continue
+		}
+		i++
+		if d, ok := dateDirectives[template[i]]; ok {
+			sb.WriteString(t.Format(d.layout))
+			continue
+		}
+		if template[i] != '%' {
+			sb.WriteByte('%')
+		}
+		sb.WriteByte(template[i])
+	}
+	return sb.String()
+}
+
+// dateTemplateInterval returns the period of the finest directive of the
+// template: a second, minute, hour or day.
+func dateTemplateInterval(template string) time.Duration {
+	switch {
+	case strings.Contains(template, "%S"):
+		return time.Second
+	case strings.Contains(template, "%M"):
+		return time.Minute
+	case strings.Contains(template, "%H"):
+		return time.Hour
+	default:
+		return day
+	}
+}
+
+// dateTemplateRoot returns the directory of the template above its first
+// directive, which holds all the files of the template.
+func dateTemplateRoot(template string) string {
+	template = filepath.Clean(template)
+	if i := strings.IndexByte(template, '%'); i >= 0 {
+		template = template[:i]
+		if j := strings.LastIndexByte(template, filepath.Separator); j >= 0 {
+			return defaultIfEmpty(template[:j], string(filepath.Separator))
+		}
+		return "."
+	}
+	return filepath.Dir(template)
+}
+
+// logDir returns the directory holding the log files of the file template.
+func logDir(file string) string {
+	if hasDateDirectives(file) {
+		return dateTemplateRoot(file)
+	}
+	return filepath.Dir(file)
+}
+
+// datedFile is a log file of a date template and the end of the period it covers.
+type datedFile struct {
+	end time.Time
+	logFile
+}
+
+// dateTemplatePattern returns a pattern matching the files of the template and
+// the directives of its capture groups. Files sharing the template's name up
+// to the extension match, so backups and compressed files are included, and
+// the shard placeholder matches any shard.
+func dateTemplatePattern(template string) (*regexp.Regexp, []byte) {
+	template = filepath.Clean(template)
+	if ext := filepath.Ext(template); !strings.Contains(ext, "%") {
+		template = strings.TrimSuffix(template, ext)
+	}
+	var sb strings.Builder
+	var groups []byte
+	sb.WriteByte('^')
+	for i := 0; i < len(template); i++ {
+		if strings.HasPrefix(template[i:], ShardPlaceholder) {
+			sb.WriteString(`[^` + regexp.QuoteMeta(string(filepath.Separator)) + `]*`)
+			i += len(ShardPlaceholder) - 1
+			continue
+		}
+		if template[i] == '%' && i+1 < len(template) {
+			if d, ok := dateDirectives[template[i+1]]; ok {
+				sb.WriteString(`(\d{` + strconv.Itoa(d.digits) + `})`)
+				groups = append(groups, template[i+1])
+				i++
+				continue
+			}
+			if template[i+1] == '%' {
+				i++
+			}
+		}
+		sb.WriteString(regexp.QuoteMeta(template[i : i+1]))
+	}
+	sb.WriteString(`[^` + regexp.QuoteMeta(string(filepath.Separator)) + `]*$`)
+	return regexp.MustCompile(sb.String()), groups
+}
+
+// parseDateFields returns the start of the period of the directive values in
+// the location.
+func parseDateFields(groups []byte, values []string, loc *time.Location) time.Time {
+	year, month, dayOfMonth, yearDay := time.Now().Year(), 1, 1, 0
+	var hour, minute, second int
+	for i, g := range groups {
+		v, _ := strconv.Atoi(values[i])
+		switch g {
+		case 'Y':
+			year = v
+		case 'y':
+			year = 2000 + v
+		case 'm':
+			month = v
+		case 'd':
+			dayOfMonth = v
+		case 'j':
+			yearDay = v
+		case 'H':
+			hour = v
+		case 'M':
+			minute = v
+		case 'S':
+			second = v
+		}
+	}
+	if yearDay > 0 {
+		month, dayOfMonth = 1, yearDay
+	}
+	return time.Date(year, time.Month(month), dayOfMonth, hour, minute, second, 0, loc)
+}
+
+// datedFiles returns the files of the date template, oldest first, with the
+// periods they cover in the location.
+func datedFiles(template string, loc *time.Location) ([]datedFile, error) {
+	pattern, groups := dateTemplatePattern(template)
+	interval := dateTemplateInterval(template)
+	var files []datedFile
+	err := filepath.WalkDir(dateTemplateRoot(template), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		m := pattern.FindStringSubmatch(path)
+		if m == nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		start := parseDateFields(groups, m[1:], loc)
+		files = append(files, datedFile{
+			end:     nextRotation(start, interval),
+			logFile: logFile{modTime: info.ModTime(), path: path, size: info.Size()},
+		})
+		return nil
+	})
+	slices.SortFunc(files, func(a, b datedFile) int { return a.modTime.Compare(b.modTime) })
+	return files, err
+}
+
+// cleanupDatedFiles deletes the files of the date template whose period ended
+// more than maxAge ago, and the directories left empty by them.
+func cleanupDatedFiles(template string, maxAge time.Duration, now time.Time) error {
+	files, err := datedFiles(template, now.Location())
+	if err != nil {
+		return fmt.Errorf("failed to list log files: %w", err)
+	}
+	root := dateTemplateRoot(template)
+	cutoff := now.Add(-maxAge)
+	var errs []error
+	for _, f := range files {
+		if !f.end.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete log file: %w", err))
+			continue
+		}
+		removeEmptyDirs(filepath.Dir(f.path), root)
+	}
+	return errors.Join(errs...)
+}
+
+// removeEmptyDirs removes dir and its parents below root while they are empty.
+func removeEmptyDirs(dir, root string) {
+	for {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 || os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
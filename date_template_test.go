@@ -0,0 +1,114 @@
+package multilog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDateTemplate(t *testing.T) {
+	at := time.Date(2024, 3, 5, 7, 8, 9, 0, time.UTC)
+	assert.Equal(t, "logs/2024/03/05/app.log", formatDateTemplate("logs/%Y/%m/%d/app.log", at))
+	assert.Equal(t, "logs/24-065/app-07h08m09.log", formatDateTemplate("logs/%y-%j/app-%Hh%Mm%S.log", at))
+	assert.Equal(t, "logs/100%/2024.log", formatDateTemplate("logs/100%%/%Y.log", at))
+
+	assert.True(t, hasDateDirectives("logs/%Y/app.log"))
+	assert.False(t, hasDateDirectives("logs/100%%/app.log"))
+	assert.False(t, hasDateDirectives("logs/app-2006-01-02.log"))
+
+	assert.NoError(t, checkDateTemplate("logs/%Y/%m/%d/app%%.log"))
+	assert.EqualError(t, checkDateTemplate("logs/%Q/app.log"), "unknown date directive %Q in file: logs/%Q/app.log")
+	assert.EqualError(t, checkDateTemplate("logs/app.log%"), "incomplete date directive in file: logs/app.log%")
+}
+
+func TestDateTemplateRootAndInterval(t *testing.T) {
+	assert.Equal(t, filepath.Join("var", "log"), dateTemplateRoot(filepath.Join("var", "log", "%Y", "%m", "app.log")))
+	assert.Equal(t, filepath.Join("var", "log"), dateTemplateRoot(filepath.Join("var", "log", "app-%Y.log")))
+	assert.Equal(t, ".", dateTemplateRoot(filepath.Join("%Y", "app.log")))
+	assert.Equal(t, "logs", logDir(filepath.Join("logs", "app.log")))
+
+	assert.Equal(t, day, dateTemplateInterval("logs/%Y/%m/%d/app.log"))
+	assert.Equal(t, time.Hour, dateTemplateInterval("logs/%Y/%m/%d/app-%H.log"))
+	assert.Equal(t, time.Minute, dateTemplateInterval("logs/%H%M.log"))
+}
+
+func TestTimeRotator_DateDirectories(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2024, 3, 10, 23, 0, 0, 0, time.Local)}
+	tr := newTimeRotator(FileHandlerOptions{File: filepath.Join(dir, "%Y", "%m", "%d", "app.log")}, clock.Now)
+
+	_, err := tr.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	// The name does not change within the day, so the file is not rotated.
+	clock.Add(30 * time.Minute)
+	_, err = tr.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	clock.Add(time.Hour)
+	_, err = tr.Write([]byte("third\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, tr.Close())
+
+	first, err := os.ReadFile(filepath.Join(dir, "2024", "03", "10", "app.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(first))
+	third, err := os.ReadFile(filepath.Join(dir, "2024", "03", "11", "app.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "third\n", string(third))
+
+	entries, err := os.ReadDir(filepath.Join(dir, "2024", "03", "10"))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestTimeRotator_DateDirectoryRetention(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "%Y", "%m", "%d", "app.log")
+	write := func(path string) {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+	}
+	expired := filepath.Join(dir, "2024", "02", "28", "app.log")
+	expiredBackup := filepath.Join(dir, "2024", "02", "28", "app-2024-02-28T10-00-00.000.log.gz")
+	kept := filepath.Join(dir, "2024", "03", "08", "app.log")
+	other := filepath.Join(dir, "2024", "02", "27", "notes.txt")
+	for _, path := range []string{expired, expiredBackup, kept, other} {
+		write(path)
+	}
+
+	clock := &fakeClock{t: time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)}
+	tr := newTimeRotator(FileHandlerOptions{File: file, MaxAge: 3}, clock.Now)
+	_, err := tr.Write([]byte("today\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, tr.Close())
+
+	assert.NoFileExists(t, expired)
+	assert.NoFileExists(t, expiredBackup)
+	assert.NoDirExists(t, filepath.Join(dir, "2024", "02", "28"))
+	assert.FileExists(t, kept)
+	assert.FileExists(t, other)
+	assert.FileExists(t, filepath.Join(dir, "2024", "03", "10", "app.log"))
+}
+
+func TestValidateHandler_DateTemplate(t *testing.T) {
+	handler := &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "logs/%Y/%m/%d/app.log"}
+	assert.NoError(t, validateHandler(handler))
+
+	handler.File = "logs/%Y/%B/app.log"
+	assert.EqualError(t, validateHandler(handler), "unknown date directive %B in file: logs/%Y/%B/app.log")
+
+	handler.File = "logs/%Y/app.log"
+	handler.ArchiveURL = "s3://bucket/logs"
+	assert.EqualError(t, validateHandler(handler), "archive_url does not support date directives in the log directory")
+
+	handler.File = "logs/app-%Y-%m-%d.log"
+	assert.NoError(t, validateHandler(handler))
+
+	handler.ArchiveURL = ""
+	handler.File = "logs/app-2006-01-02.log"
+	handler.RotateInterval = "daily"
+	assert.EqualError(t, validateHandler(handler),
+		"time layouts are not supported in file names, use date directives such as %Y-%m-%d: logs/app-2006-01-02.log")
+}
@@ -0,0 +1,332 @@
+package multilog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// deadLetter is a record written to a dead-letter file, one JSON object per line.
+type deadLetter struct {
+	Message string           `json:"msg"`
+	Error   string           `json:"error,omitempty"`
+	Time    time.Time        `json:"time"`
+	Attrs   []deadLetterAttr `json:"attrs,omitempty"`
+	Level   slog.Level       `json:"level"`
+}
+
+// deadLetterAttr keeps the kind of an attribute value so it can be restored on replay.
+type deadLetterAttr struct {
+	Key   string          `json:"key"`
+	Kind  string          `json:"kind"`
+	Value json.RawMessage `json:"value"`
+}
+
+// deadLetterFile appends dead letters to a file, opening it on first use.
+type deadLetterFile struct {
+	file *os.File
+	path string
+	mu   sync.Mutex
+}
+
+func (f *deadLetterFile) write(line []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open dead-letter file: %w", err)
+		}
+		f.file = file
+	}
+	if _, err := f.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write dead-letter file: %w", err)
+	}
+	return nil
+}
+
+func (f *deadLetterFile) close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// DeadLetterHandler wraps a handler so that records it fails to write are
+// appended to a dead-letter file, from which Replay can resend them later.
+// Records that batching handlers fail to send in the background, or drop
+// because their buffer is full, are appended once the batch fails. Records
+// skipped by an open circuit breaker are not written to the file.
+type DeadLetterHandler struct {
+	OnError func(err error)
+	file    *deadLetterFile
+	wrappedHandler
+	groups []string
+	attrs  []slog.Attr
+}
+
+// NewDeadLetterHandler creates a handler that reports errors returned by handler
+// to onError and appends the failed record to the file at path. A nil onError
+// writes to stderr.
+func NewDeadLetterHandler(handler slog.Handler, path string, onError func(err error)) *DeadLetterHandler {
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	return &DeadLetterHandler{
+		OnError:        onError,
+		file:           &deadLetterFile{path: path},
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// Handle writes the record to the wrapped handler, spilling it to the
+// dead-letter file on failure. The error is only returned if the record could
+// not be written at all.
+func (dh *DeadLetterHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx, report := withSendReport(ctx, dh.spillFailed(record.Clone()))
+	err := dh.Handler.Handle(ctx, record)
+	if err == nil || report.deferred.Load() {
+		return err
+	}
+	dh.OnError(err)
+	if serr := dh.spill(record, err); serr != nil {
+		return errors.Join(err, serr)
+	}
+	return nil
+}
+
+// spillFailed returns the send report callback of a record queued by a
+// batching handler. The error of the batch is already reported by the handler.
+func (dh *DeadLetterHandler) spillFailed(record slog.Record) func(err error) {
+	return func(err error) {
+		if err == nil {
+			return
+		}
+		if serr := dh.spill(record, err); serr != nil {
+			dh.OnError(serr)
+		}
+	}
+}
+
+// spill appends the record, with the attributes and groups of the handler, to the file.
+func (dh *DeadLetterHandler) spill(record slog.Record, cause error) error {
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	attrs = append(dh.attrs[:len(dh.attrs):len(dh.attrs)], nestAttrs(dh.groups, attrs)...)
+
+	line, err := json.Marshal(deadLetter{
+		Message: record.Message,
+		Error:   cause.Error(),
+		Time:    record.Time,
+		Attrs:   encodeDeadLetterAttrs(attrs),
+		Level:   record.Level,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+	return dh.file.write(append(line, '\n'))
+}
+
+// nestAttrs places the attributes inside the groups, outermost first.
+func nestAttrs(groups []string, attrs []slog.Attr) []slog.Attr {
+	if len(attrs) == 0 {
+		return nil
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: groups[i], Value: slog.GroupValue(attrs...)}}
+	}
+	return attrs
+}
+
+func encodeDeadLetterAttrs(attrs []slog.Attr) []deadLetterAttr {
+	encoded := make([]deadLetterAttr, 0, len(attrs))
+	for _, a := range attrs {
+		encoded = append(encoded, encodeDeadLetterAttr(a))
+	}
+	return encoded
+}
+
+// encodeDeadLetterAttr encodes the attribute value, falling back to its string
+// form for values that cannot be marshaled.
+func encodeDeadLetterAttr(a slog.Attr) deadLetterAttr {
+	v := a.Value.Resolve()
+	var value any
+	switch v.Kind() {
+	case slog.KindGroup:
+		value = encodeDeadLetterAttrs(v.Group())
+	case slog.KindDuration:
+		value = int64(v.Duration())
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			value = err.Error()
+		} else {
+			value = v.Any()
+		}
+	default:
+		value = v.Any()
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		raw, _ = json.Marshal(v.String())
+		return deadLetterAttr{Key: a.Key, Kind: slog.KindString.String(), Value: raw}
+	}
+	return deadLetterAttr{Key: a.Key, Kind: v.Kind().String(), Value: raw}
+}
+
+// attr decodes the attribute, restoring the kind of its value.
+func (a deadLetterAttr) attr() (slog.Attr, error) {
+	var value slog.Value
+	var err error
+	switch a.Kind {
+	case slog.KindGroup.String():
+		var group []deadLetterAttr
+		if err = json.Unmarshal(a.Value, &group); err != nil {
+			break
+		}
+		attrs := make([]slog.Attr, 0, len(group))
+		for _, g := range group {
+			attr, gerr := g.attr()
+			if gerr != nil {
+				return slog.Attr{}, gerr
+			}
+			attrs = append(attrs, attr)
+		}
+		value = slog.GroupValue(attrs...)
+	case slog.KindString.String():
+		value, err = decodeValue(a.Value, slog.StringValue)
+	case slog.KindInt64.String():
+		value, err = decodeValue(a.Value, slog.Int64Value)
+	case slog.KindUint64.String():
+		value, err = decodeValue(a.Value, slog.Uint64Value)
+	case slog.KindFloat64.String():
+		value, err = decodeValue(a.Value, slog.Float64Value)
+	case slog.KindBool.String():
+		value, err = decodeValue(a.Value, slog.BoolValue)
+	case slog.KindDuration.String():
+		value, err = decodeValue(a.Value, func(d int64) slog.Value { return slog.DurationValue(time.Duration(d)) })
+	case slog.KindTime.String():
+		value, err = decodeValue(a.Value, slog.TimeValue)
+	default:
+		value, err = decodeValue(a.Value, slog.AnyValue)
+	}
+	if err != nil {
+		return slog.Attr{}, fmt.Errorf("failed to decode attribute %s: %w", a.Key, err)
+	}
+	return slog.Attr{Key: a.Key, Value: value}, nil
+}
+
+func decodeValue[T any](raw json.RawMessage, value func(T) slog.Value) (slog.Value, error) {
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return slog.Value{}, err
+	}
+	return value(v), nil
+}
+
+// decodeDeadLetter decodes a dead-letter line into a record.
+func decodeDeadLetter(line []byte) (slog.Record, error) {
+	var dl deadLetter
+	if err := json.Unmarshal(line, &dl); err != nil {
+		return slog.Record{}, fmt.Errorf("failed to unmarshal dead letter: %w", err)
+	}
+	record := slog.NewRecord(dl.Time, dl.Level, dl.Message, 0)
+	for _, a := range dl.Attrs {
+		attr, err := a.attr()
+		if err != nil {
+			return slog.Record{}, err
+		}
+		record.AddAttrs(attr)
+	}
+	return record, nil
+}
+
+// Replay resends the records in the dead-letter file at path to the handler
+// and flushes it. Records that fail again are kept in the file, which is
+// removed once every record has been sent. A missing file is not an error. If
+// the flush fails, the whole file is kept, so records may be sent twice.
+// Replay must not run while a DeadLetterHandler is writing to the same file.
+// It returns the number of records sent.
+func Replay(path string, handler slog.Handler) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read dead-letter file: %w", err)
+	}
+
+	var kept bytes.Buffer
+	var firstErr error
+	sent, failed := 0, 0
+	for line := range bytes.Lines(data) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		record, err := decodeDeadLetter(line)
+		if err == nil {
+			err = handler.Handle(context.Background(), record)
+		}
+		if err == nil {
+			sent++
+			continue
+		}
+		failed++
+		if firstErr == nil {
+			firstErr = err
+		}
+		kept.Write(bytes.TrimRight(line, "\n"))
+		kept.WriteByte('\n')
+	}
+
+	if err := flushHandler(handler); err != nil {
+		return 0, fmt.Errorf("failed to flush replayed records: %w", err)
+	}
+	if failed == 0 {
+		if err := os.Remove(path); err != nil {
+			return sent, fmt.Errorf("failed to remove dead-letter file: %w", err)
+		}
+		return sent, nil
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, kept.Bytes(), 0o644); err != nil {
+		return sent, fmt.Errorf("failed to write dead-letter file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return sent, fmt.Errorf("failed to write dead-letter file: %w", err)
+	}
+	return sent, fmt.Errorf("failed to replay %d of %d records: %w", failed, failed+sent, firstErr)
+}
+
+// WithAttrs creates a new handler with the given attributes; the file is shared.
+func (dh *DeadLetterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *dh
+	clone.Handler = dh.Handler.WithAttrs(attrs)
+	clone.attrs = append(dh.attrs[:len(dh.attrs):len(dh.attrs)], nestAttrs(dh.groups, attrs)...)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name; the file is shared.
+func (dh *DeadLetterHandler) WithGroup(name string) slog.Handler {
+	clone := *dh
+	clone.Handler = dh.Handler.WithGroup(name)
+	clone.groups = append(dh.groups[:len(dh.groups):len(dh.groups)], name)
+	return &clone
+}
+
+// Close closes the wrapped handler and the dead-letter file.
+func (dh *DeadLetterHandler) Close() error {
+	return errors.Join(dh.wrappedHandler.Close(), dh.file.close())
+}
@@ -0,0 +1,145 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetterHandler_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.dead")
+	writeErr := errors.New("connection refused")
+	var reported []error
+	dh := NewDeadLetterHandler(&ErrorHandler{err: writeErr}, path, func(err error) {
+		reported = append(reported, err)
+	})
+	logger := slog.New(dh).With("service", "api").WithGroup("req")
+
+	logger.Info("first", "id", 7, "took", time.Second, "ok", true, "err", errors.New("boom"))
+	logger.Warn("second", "ratio", 0.5)
+	assert.NoError(t, dh.Close())
+	assert.Equal(t, []error{writeErr, writeErr}, reported)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+	assert.Contains(t, string(data), `"error":"connection refused"`)
+
+	handler := &recordingHandler{}
+	sent, err := Replay(path, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []string{"info first", "warn second"}, handler.messages())
+	assert.NoFileExists(t, path)
+
+	attrs := recordAttrs(handler.records[0])
+	assert.Equal(t, "api", attrs["service"].String())
+	group := map[string]slog.Value{}
+	for _, a := range attrs["req"].Group() {
+		group[a.Key] = a.Value
+	}
+	assert.Equal(t, int64(7), group["id"].Int64())
+	assert.Equal(t, time.Second, group["took"].Duration())
+	assert.True(t, group["ok"].Bool())
+	assert.Equal(t, "boom", group["err"].Any())
+}
+
+func TestReplay_KeepsFailedRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.dead")
+	dh := NewDeadLetterHandler(&ErrorHandler{err: errors.New("down")}, path, func(error) {})
+	record := slog.NewRecord(time.Now(), slog.LevelError, "lost", 0)
+	assert.NoError(t, dh.Handle(context.Background(), record))
+	assert.NoError(t, dh.Close())
+
+	sent, err := Replay(path, &ErrorHandler{err: errors.New("still down")})
+	assert.EqualError(t, err, "failed to replay 1 of 1 records: still down")
+	assert.Zero(t, sent)
+	assert.FileExists(t, path)
+
+	sent, err = Replay(path, &CountingHandler{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	sent, err = Replay(path, &CountingHandler{})
+	assert.NoError(t, err, "missing file is not an error")
+	assert.Zero(t, sent)
+}
+
+func TestDeadLetterHandler_Batching(t *testing.T) {
+	server := newIntakeServer(t)
+	server.fail = 1
+	handler, err := NewHTTPHandler(HTTPHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			OnError: func(error) {},
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		URL: server.URL,
+	})
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "app.dead")
+	var reported []error
+	dh := NewDeadLetterHandler(handler, path, func(err error) {
+		reported = append(reported, err)
+	})
+	logger := slog.New(dh).With("service", "api")
+
+	logger.Info("first")
+	logger.Warn("second")
+	assert.NoFileExists(t, path, "queued records are not spilled")
+	assert.Error(t, dh.Flush())
+
+	// Records of a batch that was sent are not spilled.
+	logger.Info("third")
+	assert.NoError(t, dh.Flush())
+	assert.NoError(t, dh.Close())
+	assert.Empty(t, reported, "batch errors are reported by the handler")
+
+	recorder := &recordingHandler{}
+	sent, err := Replay(path, recorder)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []string{"info first", "warn second"}, recorder.messages())
+	assert.Equal(t, "api", recordAttrs(recorder.records[0])["service"].String())
+}
+
+func TestDeadLetterHandler_Success(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.dead")
+	dh := NewDeadLetterHandler(&CountingHandler{}, path, nil)
+	assert.NoError(t, dh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)))
+	assert.NoError(t, dh.Close())
+	assert.NoFileExists(t, path)
+}
+
+func TestDeadLetterHandler_SpillFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "app.dead")
+	writeErr := errors.New("disk full")
+	dh := NewDeadLetterHandler(&ErrorHandler{err: writeErr}, path, func(error) {})
+	err := dh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0))
+	assert.ErrorIs(t, err, writeErr)
+	assert.ErrorContains(t, err, "failed to open dead-letter file")
+}
+
+func TestDeadLetterHandler_Config(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.dead")
+	config, err := NewBuilder().Console(DeadLetter(path)).Config()
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(config)
+	assert.NoError(t, err)
+	assert.IsType(t, &DeadLetterHandler{}, handlers[0])
+
+	dh := NewDeadLetterHandler(NewConsoleHandler(ConsoleHandlerOptions{CustomHandlerOptions: CustomHandlerOptions{Level: InfoLevel, Enabled: true}}), path, nil)
+	assert.NoError(t, dh.SetLevel(DebugLevel))
+	assert.Equal(t, DebugLevel, dh.GetLevel())
+	assert.IsType(t, &DeadLetterHandler{}, DeadLetterMiddleware(path, nil)(&CountingHandler{}))
+}
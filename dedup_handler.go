@@ -0,0 +1,183 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Dedup settings
+const (
+	RepeatedKey         = "repeated"
+	DefaultMaxDedupKeys = 1000
+	dedupKeySeparator   = "\x00"
+)
+
+// dedupScopes generates the scope ids that keep handlers derived with different
+// attributes from collapsing each other's records.
+var dedupScopes atomic.Uint64
+
+// dedupEntry tracks the duplicates of a record seen within the window.
+type dedupEntry struct {
+	handler slog.Handler
+	last    slog.Record
+	expires time.Time
+	count   int
+}
+
+// deduper holds the dedup state shared by a dedup handler and its derived handlers.
+type deduper struct {
+	now       func() time.Time
+	entries   map[string]*dedupEntry
+	keys      []string
+	nextSweep time.Time
+	window    time.Duration
+	maxKeys   int
+	mu        sync.Mutex
+}
+
+// DedupHandler is a Handler that collapses identical records (same level, message,
+// and key attributes) seen within a window into the first record plus a single
+// summary record with a repeated=N attribute.
+type DedupHandler struct {
+	deduper *deduper
+	wrappedHandler
+	scope uint64
+}
+
+// NewDedupHandler creates a dedup handler with the given window. Records are
+// compared by level, message, and the values of keys; with no keys, all record
+// attributes are compared.
+func NewDedupHandler(handler slog.Handler, window time.Duration, keys ...string) *DedupHandler {
+	return &DedupHandler{
+		deduper: &deduper{
+			now:     time.Now,
+			entries: make(map[string]*dedupEntry),
+			keys:    keys,
+			window:  window,
+			maxKeys: DefaultMaxDedupKeys,
+		},
+		wrappedHandler: wrappedHandler{Handler: handler},
+		scope:          dedupScopes.Add(1),
+	}
+}
+
+// Handle writes the record unless it duplicates a record seen within the window.
+func (dh *DedupHandler) Handle(ctx context.Context, record slog.Record) error {
+	key := dh.key(record)
+	now := dh.deduper.now()
+
+	dh.deduper.mu.Lock()
+	expired := dh.deduper.sweep(now)
+	entry, ok := dh.deduper.entries[key]
+	if ok && now.Before(entry.expires) {
+		entry.count++
+		entry.last = record.Clone()
+		dh.deduper.mu.Unlock()
+		return dh.writeSummaries(ctx, expired)
+	}
+	if ok {
+		expired = append(expired, entry)
+		delete(dh.deduper.entries, key)
+	}
+	if len(dh.deduper.entries) < dh.deduper.maxKeys {
+		dh.deduper.entries[key] = &dedupEntry{handler: dh.Handler, expires: now.Add(dh.deduper.window)}
+	}
+	dh.deduper.mu.Unlock()
+
+	return errors.Join(dh.writeSummaries(ctx, expired), dh.Handler.Handle(ctx, record))
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (dh *DedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *dh
+	clone.Handler = dh.Handler.WithAttrs(attrs)
+	clone.scope = dedupScopes.Add(1)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (dh *DedupHandler) WithGroup(name string) slog.Handler {
+	clone := *dh
+	clone.Handler = dh.Handler.WithGroup(name)
+	clone.scope = dedupScopes.Add(1)
+	return &clone
+}
+
+// Flush writes the summaries of all pending duplicates and flushes the wrapped handler.
+func (dh *DedupHandler) Flush() error {
+	return errors.Join(dh.writeSummaries(context.Background(), dh.drain()), dh.wrappedHandler.Flush())
+}
+
+// Close writes the summaries of all pending duplicates and closes the wrapped handler.
+func (dh *DedupHandler) Close() error {
+	return errors.Join(dh.writeSummaries(context.Background(), dh.drain()), dh.wrappedHandler.Close())
+}
+
+// key returns the identity of the record within the handler scope.
+func (dh *DedupHandler) key(record slog.Record) string {
+	var sb strings.Builder
+	sb.WriteString(strconv.FormatUint(dh.scope, 10))
+	sb.WriteString(dedupKeySeparator)
+	sb.WriteString(record.Level.String())
+	sb.WriteString(dedupKeySeparator)
+	sb.WriteString(record.Message)
+	record.Attrs(func(a slog.Attr) bool {
+		if len(dh.deduper.keys) == 0 || slices.Contains(dh.deduper.keys, a.Key) {
+			sb.WriteString(dedupKeySeparator)
+			sb.WriteString(a.Key)
+			sb.WriteByte('=')
+			sb.WriteString(a.Value.Resolve().String())
+		}
+		return true
+	})
+	return sb.String()
+}
+
+// drain removes and returns all entries.
+func (dh *DedupHandler) drain() []*dedupEntry {
+	dh.deduper.mu.Lock()
+	defer dh.deduper.mu.Unlock()
+	entries := make([]*dedupEntry, 0, len(dh.deduper.entries))
+	for key, entry := range dh.deduper.entries {
+		entries = append(entries, entry)
+		delete(dh.deduper.entries, key)
+	}
+	return entries
+}
+
+// writeSummaries writes a repeated=N record for each entry that suppressed duplicates.
+func (dh *DedupHandler) writeSummaries(ctx context.Context, entries []*dedupEntry) error {
+	var err error
+	for _, entry := range entries {
+		if entry.count == 0 {
+			continue
+		}
+		record := entry.last.Clone()
+		record.AddAttrs(slog.Int(RepeatedKey, entry.count))
+		err = errors.Join(err, entry.handler.Handle(ctx, record))
+	}
+	return err
+}
+
+// sweep removes and returns expired entries at most once per window; the caller must hold d.mu.
+func (d *deduper) sweep(now time.Time) []*dedupEntry {
+	if now.Before(d.nextSweep) {
+		return nil
+	}
+	d.nextSweep = now.Add(d.window)
+	var expired []*dedupEntry
+	for key, entry := range d.entries {
+		if !now.Before(entry.expires) {
+			expired = append(expired, entry)
+			delete(d.entries, key)
+		}
+	}
+	return expired
+}
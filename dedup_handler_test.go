@@ -0,0 +1,74 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func repeatedCount(r slog.Record) int64 {
+	var count int64
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == RepeatedKey {
+			count = a.Value.Int64()
+		}
+		return true
+	})
+	return count
+}
+
+func TestDedupHandler(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	recorder := &recordingHandler{}
+	dh := NewDedupHandler(recorder, 5*time.Second)
+	dh.deduper.now = clock.Now
+
+	ctx := context.Background()
+	for range 4 {
+		assert.NoError(t, dh.Handle(ctx, slog.NewRecord(clock.Now(), slog.LevelError, "retrying", 0)))
+	}
+	assert.NoError(t, dh.Handle(ctx, slog.NewRecord(clock.Now(), slog.LevelWarn, "retrying", 0)))
+	assert.Len(t, recorder.records, 2)
+
+	clock.Add(6 * time.Second)
+	assert.NoError(t, dh.Handle(ctx, slog.NewRecord(clock.Now(), slog.LevelError, "retrying", 0)))
+
+	assert.Len(t, recorder.records, 4)
+	assert.Equal(t, int64(3), repeatedCount(recorder.records[2]))
+	assert.Equal(t, slog.LevelError, recorder.records[2].Level)
+	assert.Equal(t, int64(0), repeatedCount(recorder.records[3]))
+}
+
+func TestDedupHandler_KeyAttrs(t *testing.T) {
+	recorder := &recordingHandler{}
+	dh := NewDedupHandler(recorder, time.Minute, "host")
+	ctx := context.Background()
+
+	for i := range 3 {
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, "down", 0)
+		r.AddAttrs(slog.String("host", "a"), slog.Int("attempt", i))
+		assert.NoError(t, dh.Handle(ctx, r))
+	}
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "down", 0)
+	r.AddAttrs(slog.String("host", "b"))
+	assert.NoError(t, dh.Handle(ctx, r))
+	assert.Len(t, recorder.records, 2)
+
+	assert.NoError(t, dh.Close())
+	assert.Len(t, recorder.records, 3)
+	assert.Equal(t, int64(2), repeatedCount(recorder.records[2]))
+}
+
+func TestDedupHandler_WithAttrsScope(t *testing.T) {
+	recorder := &recordingHandler{}
+	dh := NewDedupHandler(recorder, time.Minute)
+	child := dh.WithAttrs([]slog.Attr{slog.String("k", "v")})
+	ctx := context.Background()
+
+	assert.NoError(t, dh.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)))
+	assert.NoError(t, child.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)))
+	assert.Len(t, recorder.records, 2)
+}
@@ -0,0 +1,405 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Disk guard actions
+const (
+	PauseDiskAction   = "pause"
+	PurgeDiskAction   = "purge"
+	ConsoleDiskAction = "console"
+)
+
+// DiskActions contains the supported disk guard actions.
+var DiskActions = []string{PauseDiskAction, PurgeDiskAction, ConsoleDiskAction}
+
+// DefaultDiskCheckInterval is the default interval between disk checks.
+const DefaultDiskCheckInterval = 30 * time.Second
+
+// DiskLimits are the thresholds of a DiskGuardHandler and the action taken
+// while one is crossed. A zero threshold is not checked.
+type DiskLimits struct {
+	Action   string
+	MinFree  int64
+	MaxSize  int64
+	Interval time.Duration
+}
+
+// diskGuard is the state shared by a disk guard handler and its derived handlers.
+type diskGuard struct {
+	now       func() time.Time
+	freeSpace func(dir string) (int64, error)
+	next      time.Time
+	file      string
+	limits    DiskLimits
+	dropped   int
+	mu        sync.Mutex
+	crossed   bool
+	freeErr   bool
+}
+
+// diskUsage is the result of a disk check. free is only known if the free
+// space could be checked.
+type diskUsage struct {
+	free  int64
+	size  int64
+	known bool
+}
+
+// crosses reports whether the usage crosses a threshold of the limits.
+func (u diskUsage) crosses(limits DiskLimits) bool {
+	return u.known && limits.MinFree > 0 && u.free < limits.MinFree ||
+		limits.MaxSize > 0 && u.size > limits.MaxSize
+}
+
+// DiskGuardHandler wraps a file handler and watches the free space of the
+// file system holding its directory and the total size of the files in the
+// directory. While free space is below MinFree or the size is above MaxSize,
+// records are dropped (pause), written after rotating and deleting the oldest
+// log files until the thresholds are met (purge), or written to the console
+// handler instead (console). An error record is written when a threshold is
+// crossed or old files are purged, and an info record when the disk recovers.
+type DiskGuardHandler struct {
+	Console slog.Handler
+	OnError func(err error)
+	guard   *diskGuard
+	wrappedHandler
+}
+
+// NewDiskGuardHandler creates a handler guarding the disk holding file, the
+// log file of handler. Disk usage is checked at most every Interval, when
+// records are handled. console receives the records under the console action;
+// without it they are dropped. A nil onError writes to stderr.
+func NewDiskGuardHandler(
+	handler, console slog.Handler,
+	file string,
+	limits DiskLimits,
+	onError func(err error),
+) *DiskGuardHandler {
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	limits.Action = defaultIfEmpty(limits.Action, PauseDiskAction)
+	limits.Interval = defaultDuration(limits.Interval, DefaultDiskCheckInterval)
+	return &DiskGuardHandler{
+		Console:        console,
+		OnError:        onError,
+		guard:          &diskGuard{now: time.Now, freeSpace: freeDiskSpace, file: file, limits: limits},
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// Handle checks the disk if it is due, then writes the record as the state of
+// the disk allows.
+func (dh *DiskGuardHandler) Handle(ctx context.Context, record slog.Record) error {
+	dh.check(ctx)
+
+	g := dh.guard
+	g.mu.Lock()
+	crossed := g.crossed
+	toConsole := crossed && g.limits.Action == ConsoleDiskAction && dh.Console != nil
+	drop := crossed && g.limits.Action != PurgeDiskAction && !toConsole
+	if drop {
+		g.dropped++
+	}
+	g.mu.Unlock()
+
+	switch {
+	case drop:
+		return nil
+	case toConsole:
+		return dh.Console.Handle(ctx, record)
+	default:
+		return dh.Handler.Handle(ctx, record)
+	}
+}
+
+// check measures the disk if the interval has passed, purges log files under
+// the purge action, and writes a record when the state changes.
+func (dh *DiskGuardHandler) check(ctx context.Context) {
+	g := dh.guard
+	g.mu.Lock()
+	now := g.now()
+	if now.Before(g.next) {
+		g.mu.Unlock()
+		return
+	}
+	g.next = now.Add(g.limits.Interval)
+	usage := dh.measure()
+	purged := 0
+	if usage.crosses(g.limits) && g.limits.Action == PurgeDiskAction {
+		usage, purged = dh.purge(usage)
+	}
+	crossed := usage.crosses(g.limits)
+	changed := crossed != g.crossed
+	g.crossed = crossed
+	dropped := g.dropped
+	if changed && !crossed {
+		g.dropped = 0
+	}
+	g.mu.Unlock()
+
+	attrs := []slog.Attr{
+		slog.String("dir", logDir(g.file)),
+		slog.Int64("free_bytes", usage.free),
+		slog.Int64("dir_bytes", usage.size),
+		slog.String("action", g.limits.Action),
+	}
+	if purged > 0 && !crossed {
+		record := slog.NewRecord(now, slog.LevelWarn, "disk space limit crossed: purged old log files", 0)
+		record.AddAttrs(append(attrs, slog.Int("deleted", purged))...)
+		dh.warn(ctx, record)
+	}
+	if !changed {
+		return
+	}
+	if crossed {
+		record := slog.NewRecord(now, slog.LevelError, "disk space limit crossed: "+dh.describeAction(), 0)
+		record.AddAttrs(attrs...)
+		dh.warn(ctx, record)
+		return
+	}
+	record := slog.NewRecord(now, slog.LevelInfo, "disk space recovered: resuming file logging", 0)
+	record.AddAttrs(append(attrs, slog.Int("dropped", dropped))...)
+	dh.warn(ctx, record)
+}
+
+// describeAction describes the action taken while a threshold is crossed.
+func (dh *DiskGuardHandler) describeAction() string {
+	switch dh.guard.limits.Action {
+	case PurgeDiskAction:
+		return "purging old log files"
+	case ConsoleDiskAction:
+		return "logging to the console only"
+	default:
+		return "pausing file logging"
+	}
+}
+
+// warn writes the record to the file and, under the console action, to the
+// console, reporting failures to OnError.
+func (dh *DiskGuardHandler) warn(ctx context.Context, record slog.Record) {
+	if err := dh.Handler.Handle(ctx, record.Clone()); err != nil {
+		dh.OnError(fmt.Errorf("failed to write disk guard record: %w", err))
+	}
+	if dh.guard.limits.Action == ConsoleDiskAction && dh.Console != nil {
+		if err := dh.Console.Handle(ctx, record); err != nil {
+			dh.OnError(fmt.Errorf("failed to write disk guard record: %w", err))
+		}
+	}
+}
+
+// measure returns the free space and the size of the log directory; the
+// caller must hold the guard lock.
+func (dh *DiskGuardHandler) measure() diskUsage {
+	g := dh.guard
+	dir := logDir(g.file)
+	var usage diskUsage
+	if g.limits.MinFree > 0 {
+		free, err := g.freeSpace(dir)
+		switch {
+		case err != nil && !g.freeErr:
+			g.freeErr = true
+			dh.OnError(fmt.Errorf("failed to check free disk space: %w", err))
+		case err == nil:
+			usage.free = free
+			usage.known = true
+		}
+	}
+	size, err := dirSize(dir)
+	if err != nil {
+		dh.OnError(fmt.Errorf("failed to check log directory size: %w", err))
+	}
+	usage.size = size
+	return usage
+}
+
+// purge rotates the log file and deletes the oldest log files until the
+// thresholds are met, returning the usage afterwards and the number of files
+// deleted; the caller must hold the guard lock.
+func (dh *DiskGuardHandler) purge(usage diskUsage) (diskUsage, int) {
+	g := dh.guard
+	rotated := time.Now()
+	if err := dh.Flush(); err != nil {
+		dh.OnError(err)
+	}
+	if err := dh.Rotate(); err != nil {
+		dh.OnError(fmt.Errorf("failed to rotate log file: %w", err))
+	}
+	files, err := logFiles(g.file)
+	if err != nil {
+		dh.OnError(fmt.Errorf("failed to list log files: %w", err))
+	}
+	deleted := 0
+	// The newest file, and with sharding every file created by the rotation,
+	// is being written.
+	for i := 0; i < len(files)-1 && usage.crosses(g.limits); i++ {
+		if !files[i].modTime.Before(rotated) {
+			break
+		}
+		if err := os.Remove(files[i].path); err != nil {
+			dh.OnError(fmt.Errorf("failed to delete log file: %w", err))
+			continue
+		}
+		deleted++
+		usage.free += files[i].size
+		usage.size -= files[i].size
+	}
+	return usage, deleted
+}
+
+// dirSize returns the total size of the regular files in the directory tree.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// logFile is a log file found in the log directory.
+type logFile struct {
+	modTime time.Time
+	path    string
+	size    int64
+}
+
+// logFiles returns the log file and its backups, oldest first: the files in
+// its directory whose names start with its name up to the extension, the
+// first digit or the shard placeholder, so that the files of time rotation
+// templates and of shards are included. The files of a date template are
+// looked up in all its directories.
+func logFiles(file string) ([]logFile, error) {
+	if hasDateDirectives(file) {
+		dated, err := datedFiles(file, time.Local)
+		files := make([]logFile, len(dated))
+		for i, f := range dated {
+			files[i] = f.logFile
+		}
+		return files, err
+	}
+	dir := filepath.Dir(file)
+	prefix := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	if i := strings.IndexAny(prefix, "0123456789["); i > 0 {
+		prefix = prefix[:i]
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []logFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{
+			modTime: info.ModTime(),
+			path:    filepath.Join(dir, entry.Name()),
+			size:    info.Size(),
+		})
+	}
+	slices.SortFunc(files, func(a, b logFile) int { return a.modTime.Compare(b.modTime) })
+	return files, nil
+}
+
+// Dropped returns the number of records dropped while file logging was paused.
+func (dh *DiskGuardHandler) Dropped() int {
+	dh.guard.mu.Lock()
+	defer dh.guard.mu.Unlock()
+	return dh.guard.dropped
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (dh *DiskGuardHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *dh
+	clone.Handler = dh.Handler.WithAttrs(attrs)
+	if dh.Console != nil {
+		clone.Console = dh.Console.WithAttrs(attrs)
+	}
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (dh *DiskGuardHandler) WithGroup(name string) slog.Handler {
+	clone := *dh
+	clone.Handler = dh.Handler.WithGroup(name)
+	if dh.Console != nil {
+		clone.Console = dh.Console.WithGroup(name)
+	}
+	return &clone
+}
+
+// Flush flushes the wrapped and console handlers.
+func (dh *DiskGuardHandler) Flush() error {
+	err := dh.wrappedHandler.Flush()
+	if dh.Console != nil {
+		err = errors.Join(err, flushHandler(dh.Console))
+	}
+	return err
+}
+
+// Close closes the wrapped and console handlers.
+func (dh *DiskGuardHandler) Close() error {
+	err := dh.wrappedHandler.Close()
+	if dh.Console != nil {
+		err = errors.Join(err, closeSlogHandler(dh.Console))
+	}
+	return err
+}
+
+// newDiskGuardFromConfig wraps the file handler in a disk guard configured by
+// the handler config, with a stderr console for the console action.
+func newDiskGuardFromConfig(
+	handler slog.Handler,
+	handlerConfig *HandlerConfig,
+	options CustomHandlerOptions,
+) (slog.Handler, error) {
+	minFree, err := ParseSize(handlerConfig.DiskMinFree)
+	if err != nil {
+		return nil, fmt.Errorf("invalid disk_min_free: %w", err)
+	}
+	maxSize, err := ParseSize(handlerConfig.DiskMaxSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid disk_max_size: %w", err)
+	}
+	var console slog.Handler
+	if handlerConfig.DiskAction == ConsoleDiskAction {
+		if console, err = NewConsoleFallback(StderrFallback, options); err != nil {
+			return nil, err
+		}
+	}
+	return NewDiskGuardHandler(handler, console, handlerConfig.File, DiskLimits{
+		Action:   handlerConfig.DiskAction,
+		MinFree:  int64(minFree),
+		MaxSize:  int64(maxSize),
+		Interval: handlerConfig.DiskCheckInterval,
+	}, options.OnError), nil
+}
@@ -0,0 +1,175 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDisk reports a settable amount of free space at a settable time.
+type fakeDisk struct {
+	now  time.Time
+	free int64
+}
+
+// newGuardedFile creates a file handler on dir/app.log wrapped in a disk guard
+// backed by the fake disk.
+func newGuardedFile(
+	t *testing.T,
+	dir string,
+	disk *fakeDisk,
+	console slog.Handler,
+	limits DiskLimits,
+) *DiskGuardHandler {
+	t.Helper()
+	file := filepath.Join(dir, "app.log")
+	handler, err := NewFileHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+		},
+		File: file,
+	})
+	assert.NoError(t, err)
+	dh := NewDiskGuardHandler(handler, console, file, limits, func(err error) { t.Error(err) })
+	dh.guard.now = func() time.Time { return disk.now }
+	dh.guard.freeSpace = func(string) (int64, error) { return disk.free, nil }
+	return dh
+}
+
+// readLines returns the lines of the file.
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestDiskGuardHandler_Pause(t *testing.T) {
+	dir := t.TempDir()
+	disk := &fakeDisk{now: time.Now(), free: 1 << 30}
+	dh := newGuardedFile(t, dir, disk, nil, DiskLimits{MinFree: 1 << 20, Interval: time.Minute})
+	logger := slog.New(dh)
+
+	logger.Info("plenty of space")
+	disk.free = 1 << 10
+	logger.Info("not checked until the interval has passed")
+	disk.now = disk.now.Add(time.Minute)
+	logger.Info("dropped")
+	logger.Info("dropped too")
+	assert.Equal(t, 2, dh.Dropped())
+
+	disk.free = 1 << 30
+	disk.now = disk.now.Add(time.Minute)
+	logger.Info("resumed")
+	assert.Equal(t, 0, dh.Dropped())
+	assert.NoError(t, dh.Close())
+
+	lines := readLines(t, filepath.Join(dir, "app.log"))
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "INFO plenty of space", lines[0])
+	assert.Equal(t, "INFO not checked until the interval has passed", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "ERROR disk space limit crossed: pausing file logging"), lines[2])
+	assert.Contains(t, lines[2], "free_bytes=1024")
+	assert.True(t, strings.HasPrefix(lines[3], "INFO disk space recovered: resuming file logging"), lines[3])
+	assert.Contains(t, lines[3], "dropped=2")
+	assert.Equal(t, "INFO resumed", lines[4])
+}
+
+func TestDiskGuardHandler_Purge(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	for i, name := range []string{"app-2024-01-01T00-00-00.000.log.gz", "app-2024-01-02T00-00-00.000.log.gz"} {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, make([]byte, 4096), 0o644))
+		modTime := old.Add(time.Duration(i) * time.Minute)
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	other := filepath.Join(dir, "other.log")
+	assert.NoError(t, os.WriteFile(other, make([]byte, 1024), 0o644))
+
+	disk := &fakeDisk{now: time.Now(), free: 1 << 30}
+	dh := newGuardedFile(t, dir, disk, nil, DiskLimits{Action: PurgeDiskAction, MaxSize: 6000})
+	slog.New(dh).Info("written after purging")
+	assert.NoError(t, dh.Close())
+
+	// The oldest backup is enough to get under the limit; other files are kept.
+	assert.NoFileExists(t, filepath.Join(dir, "app-2024-01-01T00-00-00.000.log.gz"))
+	assert.FileExists(t, filepath.Join(dir, "app-2024-01-02T00-00-00.000.log.gz"))
+	assert.FileExists(t, other)
+
+	lines := readLines(t, filepath.Join(dir, "app.log"))
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "WARN disk space limit crossed: purged old log files"), lines[0])
+	assert.Contains(t, lines[0], "deleted=1")
+	assert.Equal(t, "INFO written after purging", lines[1])
+}
+
+func TestDiskGuardHandler_Console(t *testing.T) {
+	dir := t.TempDir()
+	console := NewTestHandler(t)
+	disk := &fakeDisk{now: time.Now(), free: 1 << 10}
+	dh := newGuardedFile(t, dir, disk, console, DiskLimits{Action: ConsoleDiskAction, MinFree: 1 << 20})
+
+	assert.NoError(t, dh.Handle(context.Background(), slog.NewRecord(disk.now, slog.LevelInfo, "to console", 0)))
+	assert.Equal(t, "to console", console.LastMessage())
+	assert.Equal(t, 0, dh.Dropped())
+	assert.NoError(t, dh.Close())
+
+	lines := readLines(t, filepath.Join(dir, "app.log"))
+	assert.Len(t, lines, 1)
+	assert.True(t, strings.HasPrefix(lines[0], "ERROR disk space limit crossed: logging to the console only"), lines[0])
+}
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.log"), make([]byte, 100), 0o644))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "old"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "old", "b.log"), make([]byte, 50), 0o644))
+	size, err := dirSize(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), size)
+
+	size, err = dirSize(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), size)
+}
+
+func TestValidateHandler_DiskGuard(t *testing.T) {
+	handler := &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log",
+		DiskMinFree: "1GB", DiskMaxSize: "10GB", DiskAction: PurgeDiskAction}
+	assert.NoError(t, validateHandler(handler))
+
+	handler.DiskAction = "panic"
+	assert.EqualError(t, validateHandler(handler), "invalid disk action: panic")
+
+	handler = &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log", DiskMinFree: "lots"}
+	assert.EqualError(t, validateHandler(handler), "invalid disk_min_free: invalid size: lots")
+
+	handler = &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log", DiskAction: PauseDiskAction}
+	assert.EqualError(t, validateHandler(handler), "disk_action and disk_check_interval require disk_min_free or disk_max_size")
+
+	handler = &HandlerConfig{Type: ConsoleHandlerType, Level: InfoLevel, DiskMaxSize: "1GB"}
+	assert.EqualError(t, validateHandler(handler), "disk guard requires a file handler")
+}
+
+func TestCreateHandlers_DiskGuard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	cfg, err := NewBuilder().File(path, DiskGuard(ConsoleDiskAction, "", "10GB")).Config()
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	dh, ok := handlers[0].(*DiskGuardHandler)
+	assert.True(t, ok)
+	assert.NotNil(t, dh.Console)
+	assert.Equal(t, int64(10<<30), dh.guard.limits.MaxSize)
+	assert.Equal(t, DefaultDiskCheckInterval, dh.guard.limits.Interval)
+	assert.NoError(t, dh.Close())
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd
+
+package multilog
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// freeDiskSpace returns the space available to unprivileged users on the file
+// system holding dir.
+func freeDiskSpace(dir string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, fmt.Errorf("failed to stat file system: %w", err)
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
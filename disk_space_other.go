@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd
+
+package multilog
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// freeDiskSpace is not supported on this platform; disk_min_free is not
+// checked.
+func freeDiskSpace(string) (int64, error) {
+	return 0, fmt.Errorf("free disk space is not supported on %s", runtime.GOOS)
+}
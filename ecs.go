@@ -0,0 +1,69 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ECSVersion is the Elastic Common Schema version of ECS documents.
+const ECSVersion = "8.11.0"
+
+// ECS field names
+const (
+	ECSTimestampKey  = "@timestamp"
+	ECSLevelKey      = "log.level"
+	ECSMessageKey    = "message"
+	ECSVersionKey    = "ecs.version"
+	ECSFileNameKey   = "log.origin.file.name"
+	ECSFileLineKey   = "log.origin.file.line"
+	ECSFunctionKey   = "log.origin.function"
+	ECSStacktraceKey = "error.stack_trace"
+	ECSTraceIDKey    = "trace.id"
+	ECSSpanIDKey     = "span.id"
+)
+
+// appendECS renders the record as an Elastic Common Schema document: the
+// timestamp, level, message and version, the source and trace IDs if present,
+// the remaining attributes, and the stack trace. The pattern is not used.
+func (jh *JSONHandler) appendECS(ctx context.Context, buf []byte, ch *CustomHandler, record slog.Record) []byte {
+	opts := ch.Opts
+	attrs := getBuffer()
+	defer putBuffer(attrs)
+	var fields map[string]string
+	*attrs, fields = jh.appendAttrs(*attrs, ch, record, []string{TraceIDKey, SpanIDKey})
+
+	buf = append(buf, '{')
+	buf = append(appendJSONKey(buf, ECSTimestampKey), '"')
+	buf = recordTime(opts, record.Time).AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, '"')
+	buf = appendJSONString(appendJSONKey(buf, ECSLevelKey), strings.ToLower(levelLabel(record.Level, false)))
+	buf = appendJSONString(appendJSONKey(buf, ECSMessageKey), record.Message)
+	buf = appendJSONString(appendJSONKey(buf, ECSVersionKey), ECSVersion)
+	if opts.AddSource {
+		if src := record.Source(); src != nil && src.File != "" {
+			buf = appendJSONString(appendJSONKey(buf, ECSFileNameKey), src.File)
+			buf = strconv.AppendInt(appendJSONKey(buf, ECSFileLineKey), int64(src.Line), 10)
+			buf = appendJSONString(appendJSONKey(buf, ECSFunctionKey), src.Function)
+		}
+	}
+	if id := fields[TraceIDKey]; id != "" {
+		buf = appendJSONString(appendJSONKey(buf, ECSTraceIDKey), id)
+	}
+	if id := fields[SpanIDKey]; id != "" {
+		buf = appendJSONString(appendJSONKey(buf, ECSSpanIDKey), id)
+	}
+	if len(*attrs) > 0 {
+		buf = append(buf, ',')
+		buf = append(buf, *attrs...)
+	}
+	if record.Level == LevelPerf {
+		buf = appendJSONPerf(buf, opts)
+	}
+	if stacktraceEnabled(opts, record.Level) {
+		buf = appendJSONString(appendJSONKey(buf, ECSStacktraceKey), recordStacktrace(ctx, record.PC))
+	}
+	return append(buf, '}')
+}
@@ -0,0 +1,84 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONHandler_ECS(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := CustomHandlerOptions{
+		Level:           InfoLevel,
+		Enabled:         true,
+		AddSource:       true,
+		AddStacktrace:   true,
+		StacktraceLevel: ErrorLevel,
+		ECS:             true,
+		Location:        time.UTC,
+	}
+	logger := slog.New(newJSONHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.With("service", "api").Info("served", TraceIDKey, "abc", SpanIDKey, "def", "id", 7)
+	logger.WithGroup("req").Error("failed", TraceIDKey, "grouped")
+
+	docs := decodeLines(t, buf.String())
+	assert.Len(t, docs, 2)
+	info := docs[0]
+	_, err := time.Parse(time.RFC3339Nano, info[ECSTimestampKey].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, "info", info[ECSLevelKey])
+	assert.Equal(t, "served", info[ECSMessageKey])
+	assert.Equal(t, ECSVersion, info[ECSVersionKey])
+	assert.True(t, strings.HasSuffix(info[ECSFileNameKey].(string), "ecs_test.go"))
+	assert.Greater(t, info[ECSFileLineKey], 0.0)
+	assert.Contains(t, info[ECSFunctionKey], "TestJSONHandler_ECS")
+	assert.Equal(t, "abc", info[ECSTraceIDKey])
+	assert.Equal(t, "def", info[ECSSpanIDKey])
+	assert.Equal(t, "api", info["service"])
+	assert.Equal(t, 7.0, info["id"])
+	for _, key := range []string{TraceIDKey, slog.LevelKey, slog.MessageKey, slog.SourceKey, ECSStacktraceKey} {
+		assert.NotContains(t, info, key)
+	}
+
+	failed := docs[1]
+	assert.Equal(t, "error", failed[ECSLevelKey])
+	assert.NotEmpty(t, failed[ECSStacktraceKey])
+	assert.NotContains(t, failed, ECSTraceIDKey, "only top-level trace IDs are lifted")
+	assert.Equal(t, map[string]any{TraceIDKey: "grouped"}, failed["req"])
+}
+
+func TestJSONHandler_ECSReplaceAttr(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := CustomHandlerOptions{Level: InfoLevel, Enabled: true, ECS: true}
+	redact := func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key == "password" {
+			a.Value = slog.StringValue("***")
+		}
+		return a
+	}
+	logger := slog.New(newJSONHandler(opts, bufio.NewWriter(buf), redact))
+
+	logger.Info("login", "password", "secret")
+
+	doc := decodeLines(t, buf.String())[0]
+	assert.Equal(t, "***", doc["password"])
+	assert.Equal(t, "login", doc[ECSMessageKey])
+}
+
+func TestValidateHandler_ECS(t *testing.T) {
+	handler := HandlerConfig{Type: FileHandlerType, SubType: JSONHandlerSubType, Level: InfoLevel, File: "a.log", ECS: true}
+	assert.NoError(t, validateHandler(&handler))
+
+	handler.SubType = TextHandlerSubType
+	assert.ErrorContains(t, validateHandler(&handler), "ecs requires a handler that writes JSON documents")
+
+	config, err := NewBuilder().Console(ECS()).Config()
+	assert.Nil(t, config)
+	assert.ErrorContains(t, err, "ecs requires")
+}
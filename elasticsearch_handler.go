@@ -0,0 +1,277 @@
+package multilog
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default Elasticsearch settings
+const (
+	DefaultIndexDateFormat = "2006.01.02"
+	DefaultMaxBufferSize   = 10000
+)
+
+// esDocument is a JSON document waiting to be indexed.
+type esDocument struct {
+	time time.Time
+	body string
+}
+
+// esBulkResponse is the subset of the bulk API response used to find the
+// documents that were not indexed. Items are in the order of the request.
+type esBulkResponse struct {
+	Items []map[string]struct {
+		Error *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+		Status int `json:"status"`
+	} `json:"items"`
+	Errors bool `json:"errors"`
+}
+
+// ElasticsearchHandler is a Handler that ships JSON documents to
+// Elasticsearch or OpenSearch via the bulk API.
+type ElasticsearchHandler struct {
+	Handler *JSONHandler
+	client  *http.Client
+	batch   *batcher[esDocument]
+	opts    *ElasticsearchHandlerOptions
+}
+
+// ElasticsearchHandlerOptions are the options of Elasticsearch handlers: the
+// formatting and batching options, the URL, and the index with its date format.
+type ElasticsearchHandlerOptions struct {
+	CustomHandlerOptions
+	BatchOptions
+	URL             string
+	Index           string
+	IndexDateFormat string
+}
+
+// NewElasticsearchHandler creates an Elasticsearch Handler with the specified options.
+// Documents are written to a daily index named "<Index>-<date>", where the date
+// is formatted with IndexDateFormat (default "2006.01.02").
+func NewElasticsearchHandler(opts ElasticsearchHandlerOptions) (slog.Handler, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("elasticsearch handler requires a url")
+	}
+	if opts.Index == "" {
+		return nil, fmt.Errorf("elasticsearch handler requires an index")
+	}
+	opts.IndexDateFormat = defaultIfEmpty(opts.IndexDateFormat, DefaultIndexDateFormat)
+
+	eh := &ElasticsearchHandler{
+		Handler: newJSONHandler(opts.CustomHandlerOptions, nil, nil),
+		client:  &http.Client{Timeout: DefaultHTTPTimeout},
+		opts:    &opts,
+	}
+	maxBuffer := defaultIfZero(opts.MaxBufferSize, DefaultMaxBufferSize)
+	eh.batch = newBatcher(opts.BatchSize, opts.FlushInterval, maxBuffer, eh.bulk, opts.OnError)
+	return eh, nil
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (eh *ElasticsearchHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return eh.Handler.Enabled(ctx, level)
+}
+
+// Handle serializes the log record and queues it for the next bulk request.
+func (eh *ElasticsearchHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !eh.Enabled(ctx, record.Level) {
+		return nil
+	}
+	body, err := eh.Handler.Format(ctx, record)
+	if err != nil {
+		return err
+	}
+	return eh.batch.add(ctx, esDocument{time: record.Time, body: body})
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (eh *ElasticsearchHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *eh
+	clone.Handler = &JSONHandler{
+		Handler:     eh.Handler.Handler.WithAttrs(attrs).(CustomHandlerInterface),
+		replaceAttr: eh.Handler.replaceAttr,
+		mu:          eh.Handler.mu,
+	}
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (eh *ElasticsearchHandler) WithGroup(name string) slog.Handler {
+	clone := *eh
+	clone.Handler = &JSONHandler{
+		Handler:     eh.Handler.Handler.WithGroup(name).(CustomHandlerInterface),
+		replaceAttr: eh.Handler.replaceAttr,
+		mu:          eh.Handler.mu,
+	}
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (eh *ElasticsearchHandler) SetLevel(level string) error {
+	return eh.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (eh *ElasticsearchHandler) GetLevel() string {
+	return eh.Handler.GetLevel()
+}
+
+// Flush sends all buffered documents.
+func (eh *ElasticsearchHandler) Flush() error {
+	return eh.batch.Flush()
+}
+
+// Close sends all buffered documents and stops the background flush loop.
+func (eh *ElasticsearchHandler) Close() error {
+	return eh.batch.Close()
+}
+
+// Dropped returns the number of documents dropped because the buffer was full.
+func (eh *ElasticsearchHandler) Dropped() int {
+	return eh.batch.Dropped()
+}
+
+// customHandler implements customHandlerProvider.
+func (eh *ElasticsearchHandler) customHandler() CustomHandlerInterface {
+	return eh.Handler.Handler
+}
+
+// IndexName returns the index a record logged at the given time is written to.
+func (eh *ElasticsearchHandler) IndexName(t time.Time) string {
+	return eh.opts.Index + "-" + t.Format(eh.opts.IndexDateFormat)
+}
+
+// bulk sends the documents using the bulk API. Failed requests are retried
+// with all the documents left, and documents the bulk API rejects with a
+// retryable status (429 or 5xx) are retried alone, so that indexed documents
+// are not indexed twice. Documents rejected otherwise, such as on mapping
+// errors, can never be indexed and are not retried. If some documents were
+// indexed, the error is a batchError listing the others.
+func (eh *ElasticsearchHandler) bulk(docs []esDocument) error {
+	url := strings.TrimSuffix(eh.opts.URL, "/") + "/_bulk"
+	pending := make([]int, len(docs))
+	for i := range pending {
+		pending[i] = i
+	}
+	var rejected []int
+	var rejectedErr error
+
+	err := eh.opts.retryPolicy().Do(func() error {
+		body, err := eh.bulkBody(docs, pending)
+		if err != nil {
+			return err
+		}
+		respBody, err := post(eh.client, url, ContentTypeNDJSON, body, nil)
+		if err != nil {
+			return err
+		}
+		var retry []int
+		var retryErr error
+		for _, failure := range bulkItemFailures(respBody) {
+			if failure.index >= len(pending) {
+				continue
+			}
+			doc := pending[failure.index]
+			if failure.retryable() {
+				retry = append(retry, doc)
+				retryErr = cmp.Or(retryErr, failure.err())
+			} else {
+				rejected = append(rejected, doc)
+				rejectedErr = cmp.Or(rejectedErr, failure.err())
+			}
+		}
+		pending = retry
+		if retryErr != nil {
+			return fmt.Errorf("bulk request had %d failed items: %w", len(retry), retryErr)
+		}
+		return nil
+	})
+	if err == nil && rejectedErr == nil {
+		return nil
+	}
+
+	var failed []int
+	if err != nil {
+		failed = pending
+	}
+	failed = append(failed, rejected...)
+	sort.Ints(failed)
+	err = errors.Join(err, rejectedErr)
+	if rejectedErr != nil {
+		err = fmt.Errorf("bulk request had %d rejected items: %w", len(rejected), err)
+	}
+	if len(failed) == len(docs) {
+		return err
+	}
+	return &batchError{err: err, failed: failed}
+}
+
+// bulkBody returns the bulk request indexing the documents at the indexes.
+func (eh *ElasticsearchHandler) bulkBody(docs []esDocument, indexes []int) ([]byte, error) {
+	var body bytes.Buffer
+	for _, i := range indexes {
+		action, err := json.Marshal(map[string]map[string]string{
+			"index": {"_index": eh.IndexName(docs[i].time)},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal bulk action: %w", err)
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.WriteString(docs[i].body)
+		body.WriteByte('\n')
+	}
+	return body.Bytes(), nil
+}
+
+// esItemFailure is a document of a bulk request that was not indexed.
+type esItemFailure struct {
+	reason string
+	index  int
+	status int
+}
+
+// retryable reports whether indexing the document may succeed on retry.
+func (f esItemFailure) retryable() bool {
+	return f.status == http.StatusTooManyRequests || f.status >= http.StatusInternalServerError
+}
+
+// err returns the failure as an error, with its status as an HTTPStatusError.
+func (f esItemFailure) err() error {
+	return fmt.Errorf("%s: %w", f.reason, &HTTPStatusError{StatusCode: f.status})
+}
+
+// bulkItemFailures returns the documents of the bulk response that were not
+// indexed, by their position in the request.
+func bulkItemFailures(respBody []byte) []esItemFailure {
+	var resp esBulkResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil || !resp.Errors {
+		return nil
+	}
+	var failures []esItemFailure
+	for i, item := range resp.Items {
+		for _, result := range item {
+			if result.Error != nil {
+				failures = append(failures, esItemFailure{
+					reason: result.Error.Type + ": " + result.Error.Reason,
+					index:  i,
+					status: result.Status,
+				})
+			}
+		}
+	}
+	return failures
+}
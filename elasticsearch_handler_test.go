@@ -0,0 +1,192 @@
+package multilog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bulkServer struct {
+	*httptest.Server
+	lines     []string
+	responses []string
+	response  string
+	mu        sync.Mutex
+}
+
+func newBulkServer(t *testing.T) *bulkServer {
+	t.Helper()
+	bs := &bulkServer{response: `{"errors":false,"items":[]}`}
+	bs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_bulk", r.URL.Path)
+		assert.Equal(t, ContentTypeNDJSON, r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		bs.mu.Lock()
+		defer bs.mu.Unlock()
+		scanner := bufio.NewScanner(strings.NewReader(string(body)))
+		for scanner.Scan() {
+			bs.lines = append(bs.lines, scanner.Text())
+		}
+		response := bs.response
+		if len(bs.responses) > 0 {
+			response, bs.responses = bs.responses[0], bs.responses[1:]
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(bs.Close)
+	return bs
+}
+
+func TestNewElasticsearchHandler_Validation(t *testing.T) {
+	_, err := NewElasticsearchHandler(ElasticsearchHandlerOptions{Index: "app-logs"})
+	assert.Error(t, err)
+
+	_, err = NewElasticsearchHandler(ElasticsearchHandlerOptions{URL: "http://localhost:9200"})
+	assert.Error(t, err)
+}
+
+func TestElasticsearchHandler_Bulk(t *testing.T) {
+	server := newBulkServer(t)
+	handler, err := NewElasticsearchHandler(ElasticsearchHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:               InfoLevel,
+			Enabled:             true,
+			PatternPlaceholders: []string{"[level]", "[msg]"},
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		URL:   server.URL + "/",
+		Index: "app-logs",
+	})
+	assert.NoError(t, err)
+	eh := handler.(*ElasticsearchHandler)
+
+	logger := NewLogger(handler)
+	logger.Info("first", "user", "john")
+	logger.WithField("request_id", "abc").Error("second")
+
+	assert.NoError(t, eh.Close())
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Len(t, server.lines, 4)
+
+	index := eh.IndexName(time.Now())
+	assert.True(t, strings.HasPrefix(index, "app-logs-"))
+	assert.JSONEq(t, `{"index":{"_index":"`+index+`"}}`, server.lines[0])
+
+	var doc map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(server.lines[3]), &doc))
+	assert.Equal(t, "second", doc["msg"])
+	assert.Equal(t, "abc", doc["request_id"])
+}
+
+func TestElasticsearchHandler_IndexName(t *testing.T) {
+	handler, err := NewElasticsearchHandler(ElasticsearchHandlerOptions{
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		URL:             "http://localhost:9200",
+		Index:           "app-logs",
+		IndexDateFormat: "2006-01",
+	})
+	assert.NoError(t, err)
+	eh := handler.(*ElasticsearchHandler)
+	defer eh.Close()
+
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, "app-logs-2024-05", eh.IndexName(ts))
+}
+
+func TestElasticsearchHandler_BulkItemErrors(t *testing.T) {
+	server := newBulkServer(t)
+	server.response = `{"errors":true,"items":[{"index":{"status":400,` +
+		`"error":{"type":"mapper_parsing_exception","reason":"bad field"}}}]}`
+	handler, err := NewElasticsearchHandler(ElasticsearchHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+			MaxBufferSize: 1,
+		},
+		URL:   server.URL,
+		Index: "app-logs",
+	})
+	assert.NoError(t, err)
+	eh := handler.(*ElasticsearchHandler)
+
+	logger := NewLogger(handler)
+	logger.Info("dropped")
+	logger.Info("kept")
+	assert.Equal(t, 1, eh.Dropped())
+
+	err = eh.Flush()
+	assert.ErrorContains(t, err, "mapper_parsing_exception: bad field")
+	assert.NoError(t, eh.Close())
+}
+
+func TestElasticsearchHandler_BulkItemRetry(t *testing.T) {
+	server := newBulkServer(t)
+	server.responses = []string{
+		`{"errors":true,"items":[{"index":{"status":201}},` +
+			`{"index":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}}},` +
+			`{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad field"}}}]}`,
+	}
+	handler, err := NewElasticsearchHandler(ElasticsearchHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+			MaxRetries:    2,
+			RetryBackoff:  time.Millisecond,
+		},
+		URL:   server.URL,
+		Index: "app-logs",
+	})
+	assert.NoError(t, err)
+	eh := handler.(*ElasticsearchHandler)
+
+	logger := NewLogger(handler)
+	logger.Info("indexed")
+	logger.Info("throttled")
+	logger.Info("rejected")
+
+	err = eh.Flush()
+	assert.ErrorContains(t, err, "failed to send 1 of batch of 3")
+	assert.ErrorContains(t, err, "mapper_parsing_exception: bad field")
+	assert.NotContains(t, err.Error(), "queue full")
+	assert.NoError(t, eh.Close())
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	// Only the throttled document is sent again.
+	assert.Len(t, server.lines, 8)
+	assert.Contains(t, server.lines[7], `"msg":"throttled"`)
+}
+
+func TestBulkItemFailures(t *testing.T) {
+	assert.Empty(t, bulkItemFailures([]byte(`{"errors":false}`)))
+	assert.Empty(t, bulkItemFailures([]byte(`not json`)))
+
+	failures := bulkItemFailures([]byte(`{"errors":true,"items":[{"index":{"status":201}},` +
+		`{"index":{"status":503,"error":{"type":"unavailable","reason":"shard down"}}}]}`))
+	assert.Equal(t, []esItemFailure{{reason: "unavailable: shard down", index: 1, status: 503}}, failures)
+	assert.True(t, failures[0].retryable())
+
+	var statusErr *HTTPStatusError
+	assert.ErrorAs(t, failures[0].err(), &statusErr)
+	assert.Equal(t, 503, statusErr.StatusCode)
+}
@@ -0,0 +1,149 @@
+package multilog
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envVarPattern matches ${VAR} and ${VAR:-default} references.
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ExpandEnv replaces ${VAR} with the value of the environment variable VAR, and
+// ${VAR:-default} with the value of VAR or default if VAR is unset or empty.
+// Unset variables without a default expand to an empty string; a bare $VAR is
+// left untouched.
+func ExpandEnv(data []byte) []byte {
+	return envVarPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := envVarPattern.FindSubmatch(match)
+		if value := os.Getenv(string(groups[1])); value != "" {
+			return []byte(value)
+		}
+		return groups[3]
+	})
+}
+
+// expandEnvNode expands the environment variables in the scalar values of the
+// YAML document with ExpandEnv, so values containing '#', ':' or newlines stay
+// part of the value rather than changing the document. Expanded plain scalars
+// are resolved again, so ${PORT} still decodes as a number.
+func expandEnvNode(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value := string(ExpandEnv([]byte(node.Value)))
+		if value == node.Value {
+			return
+		}
+		node.Value = value
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	case yaml.MappingNode:
+		// Keys are left as written.
+		for i := 1; i < len(node.Content); i += 2 {
+			expandEnvNode(node.Content[i])
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			expandEnvNode(child)
+		}
+	}
+}
+
+// EnvPrefix is the prefix of the environment variables that override config values.
+const EnvPrefix = "MULTILOG_"
+
+// envOverrides maps override keys to the handler config fields they set.
+var envOverrides = map[string]func(handler *HandlerConfig, value string) error{
+	"LEVEL": func(h *HandlerConfig, v string) error {
+		h.Level = v
+		return nil
+	},
+	"ENABLED": func(h *HandlerConfig, v string) error {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean: %s", v)
+		}
+		h.Enabled = enabled
+		return nil
+	},
+	"PATTERN": func(h *HandlerConfig, v string) error {
+		h.Pattern = v
+		return nil
+	},
+	"FILE": func(h *HandlerConfig, v string) error {
+		h.File = v
+		return nil
+	},
+	"URL": func(h *HandlerConfig, v string) error {
+		h.URL = v
+		return nil
+	},
+}
+
+// globalEnvOverrides are the keys that may be set for all handlers at once,
+// e.g. MULTILOG_LEVEL. MULTILOG_FILE only applies to file handlers.
+var globalEnvOverrides = []string{"LEVEL", "PATTERN", "FILE"}
+
+// ApplyEnvOverrides applies MULTILOG_* environment variables on top of the config.
+// Settings are applied from least to most specific:
+//
+//	MULTILOG_<KEY>         all handlers (LEVEL, PATTERN, FILE for file handlers)
+//	MULTILOG_<TYPE>_<KEY>  handlers of the type, e.g. MULTILOG_CONSOLE_ENABLED
+//	MULTILOG_<NAME>_<KEY>  the named handler, e.g. MULTILOG_AUDIT_FILE
+//
+// where KEY is one of LEVEL, ENABLED, PATTERN, FILE, URL. Names and types are
+// upper-cased with non-alphanumeric characters replaced by underscores.
+func ApplyEnvOverrides(config *Config) error {
+	for i := range config.Multilog.Handlers {
+		handler := &config.Multilog.Handlers[i]
+		for _, key := range globalEnvOverrides {
+			if key == "FILE" && handler.Type != FileHandlerType {
+				continue
+			}
+			if err := applyEnvOverride(handler, EnvPrefix+key, key); err != nil {
+				return err
+			}
+		}
+
+		scopes := []string{handler.Type}
+		if handler.Name != "" && !strings.EqualFold(handler.Name, handler.Type) {
+			scopes = append(scopes, handler.Name)
+		}
+		for _, scope := range scopes {
+			for key := range envOverrides {
+				if err := applyEnvOverride(handler, EnvPrefix+envName(scope)+"_"+key, key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// applyEnvOverride sets the field for key from the variable, if it is set.
+func applyEnvOverride(handler *HandlerConfig, variable, key string) error {
+	value, ok := os.LookupEnv(variable)
+	if !ok {
+		return nil
+	}
+	if err := envOverrides[key](handler, value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", variable, err)
+	}
+	return nil
+}
+
+// envName converts a handler name or type to its environment variable form.
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,103 @@
+package multilog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("MULTILOG_TEST_LEVEL", "debug")
+	t.Setenv("MULTILOG_TEST_EMPTY", "")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"level: ${MULTILOG_TEST_LEVEL}", "level: debug"},
+		{"level: ${MULTILOG_TEST_LEVEL:-info}", "level: debug"},
+		{"level: ${MULTILOG_TEST_UNSET:-info}", "level: info"},
+		{"level: ${MULTILOG_TEST_EMPTY:-info}", "level: info"},
+		{"file: ${MULTILOG_TEST_UNSET}", "file: "},
+		{"url: ${MULTILOG_TEST_UNSET:-http://localhost:3100}/push", "url: http://localhost:3100/push"},
+		{"pass: $MULTILOG_TEST_LEVEL", "pass: $MULTILOG_TEST_LEVEL"},
+		{"path: ${MULTILOG_TEST_UNSET:-}", "path: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(ExpandEnv([]byte(tt.input))))
+		})
+	}
+}
+
+func TestNewConfigFromData_ExpandEnvValues(t *testing.T) {
+	t.Setenv("MULTILOG_TEST_PATTERN", "[level] # [msg]")
+	t.Setenv("MULTILOG_TEST_TOKEN", "line1\nenabled: false")
+	t.Setenv("MULTILOG_TEST_SIZE", "20")
+
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: webhook
+      level: info
+      enabled: true
+      url: http://localhost
+      pattern: ${MULTILOG_TEST_PATTERN}
+      headers:
+        Authorization: ${MULTILOG_TEST_TOKEN}
+    - type: file
+      level: info
+      enabled: true
+      file: app.log
+      max_size: ${MULTILOG_TEST_SIZE}
+      pattern: "${MULTILOG_TEST_SIZE}"`))
+	assert.NoError(t, err)
+
+	webhook := cfg.Multilog.Handlers[0]
+	assert.True(t, webhook.Enabled)
+	assert.Equal(t, "[level] # [msg]", webhook.Pattern)
+	assert.Equal(t, "line1\nenabled: false", webhook.Headers["Authorization"])
+	file := cfg.Multilog.Handlers[1]
+	assert.Equal(t, 20, file.MaxSize)
+	assert.Equal(t, "20", file.Pattern)
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	t.Setenv("MULTILOG_LEVEL", "warn")
+	t.Setenv("MULTILOG_FILE", "/var/log/app.log")
+	t.Setenv("MULTILOG_CONSOLE_ENABLED", "false")
+	t.Setenv("MULTILOG_AUDIT_LOG_LEVEL", "error")
+
+	config := &Config{Multilog: LogConfig{Handlers: []HandlerConfig{
+		{Type: ConsoleHandlerType, Level: "debug", Enabled: true},
+		{Name: "audit-log", Type: FileHandlerType, Level: "info", File: "audit.log", Enabled: true},
+		{Name: "app", Type: FileHandlerType, Level: "info", File: "app.log", Enabled: true},
+	}}}
+	assert.NoError(t, ApplyEnvOverrides(config))
+
+	handlers := config.Multilog.Handlers
+	assert.False(t, handlers[0].Enabled)
+	assert.Equal(t, "warn", handlers[0].Level)
+	assert.Equal(t, "", handlers[0].File)
+	assert.Equal(t, "error", handlers[1].Level)
+	assert.Equal(t, "/var/log/app.log", handlers[1].File)
+	assert.Equal(t, "warn", handlers[2].Level)
+}
+
+func TestApplyEnvOverrides_Invalid(t *testing.T) {
+	t.Setenv("MULTILOG_CONSOLE_ENABLED", "maybe")
+	config := &Config{Multilog: LogConfig{Handlers: []HandlerConfig{{Type: ConsoleHandlerType}}}}
+	assert.Error(t, ApplyEnvOverrides(config))
+}
+
+func TestNewConfigFromData_EnvOverrides(t *testing.T) {
+	t.Setenv("MULTILOG_LEVEL", "verbose")
+	_, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: console
+      level: info
+      enabled: true
+`))
+	assert.Error(t, err)
+}
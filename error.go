@@ -0,0 +1,51 @@
+package multilog
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Error attribute keys
+const (
+	ErrorKey        = "err"
+	ErrorMessageKey = "message"
+	ErrorTypeKey    = "type"
+	ErrorChainKey   = "chain"
+)
+
+// ErrorAttr returns an err attribute rendering the error as described in ErrorValue.
+func ErrorAttr(err error) slog.Attr {
+	return slog.Any(ErrorKey, ErrorValue(err))
+}
+
+// ErrorValue renders the error as a group with its message, its type and, if it
+// wraps other errors, the chain of wrapped errors as "type: message" entries.
+func ErrorValue(err error) slog.Value {
+	if err == nil {
+		return slog.AnyValue(nil)
+	}
+	attrs := []slog.Attr{
+		slog.String(ErrorMessageKey, err.Error()),
+		slog.String(ErrorTypeKey, fmt.Sprintf("%T", err)),
+	}
+	var chain []string
+	for wrapped := errors.Unwrap(err); wrapped != nil; wrapped = errors.Unwrap(wrapped) {
+		chain = append(chain, fmt.Sprintf("%T: %s", wrapped, wrapped.Error()))
+	}
+	if len(chain) > 0 {
+		attrs = append(attrs, slog.Any(ErrorChainKey, chain))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// replaceErrorValue renders attributes holding an error with ErrorValue.
+func replaceErrorValue(a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+	if err, ok := a.Value.Any().(error); ok {
+		a.Value = ErrorValue(err)
+	}
+	return a
+}
@@ -0,0 +1,80 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorValue(t *testing.T) {
+	assert.Equal(t, slog.KindAny, ErrorValue(nil).Kind())
+
+	value := ErrorValue(errors.New("boom"))
+	assert.Equal(t, slog.KindGroup, value.Kind())
+	assert.Equal(t, []slog.Attr{
+		slog.String(ErrorMessageKey, "boom"),
+		slog.String(ErrorTypeKey, "*errors.errorString"),
+	}, value.Group())
+
+	wrapped := fmt.Errorf("load config: %w", &fs.PathError{Op: "open", Path: "app.yml", Err: fs.ErrNotExist})
+	group := ErrorValue(wrapped).Group()
+	assert.Len(t, group, 3)
+	assert.Equal(t, "*fmt.wrapError", group[1].Value.String())
+	assert.Equal(t, ErrorChainKey, group[2].Key)
+	assert.Equal(t, []string{
+		"*fs.PathError: open app.yml: file does not exist",
+		"*errors.errorString: file does not exist",
+	}, group[2].Value.Any())
+}
+
+func TestErrorAttr_Text(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{Level: DebugLevel, Enabled: true, Pattern: "[level] [msg]"}
+	logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Error("failed", ErrorAttr(errors.New("boom")))
+	logger.Error("failed", "cause", errors.New("bad"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, `ERROR failed [err.message=boom err.type=*errors.errorString]`, lines[0])
+	assert.Equal(t, `ERROR failed [cause.message=bad cause.type=*errors.errorString]`, lines[1])
+}
+
+func TestErrorAttr_JSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := CustomHandlerOptions{Level: DebugLevel, Enabled: true}
+	logger := slog.New(newJSONHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Error("failed", ErrorKey, fmt.Errorf("wrapped: %w", errors.New("boom")))
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, map[string]any{
+		ErrorMessageKey: "wrapped: boom",
+		ErrorTypeKey:    "*fmt.wrapError",
+		ErrorChainKey:   []any{"*errors.errorString: boom"},
+	}, entry[ErrorKey])
+}
+
+func TestLogger_WithError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{Level: DebugLevel, Enabled: true, Pattern: "[level] [msg]"}
+	logger := NewLogger(NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	assert.Same(t, logger, logger.WithError(nil))
+
+	logger.WithError(errors.New("boom")).Error("failed")
+	logger.WithContext(t.Context()).(ErrorLogger).WithError(errors.New("bad")).Warn("retrying")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, `ERROR failed [err.message=boom err.type=*errors.errorString]`, lines[0])
+	assert.Equal(t, `WARN retrying [err.message=bad err.type=*errors.errorString]`, lines[1])
+}
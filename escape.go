@@ -0,0 +1,83 @@
+package multilog
+
+import (
+	"fmt"
+	"slices"
+	"unicode/utf8"
+)
+
+// Escape modes of text handlers
+const (
+	// EscapeControl escapes line breaks, tabs and other control characters.
+	EscapeControl = "control"
+	// EscapeNewlines only escapes line breaks.
+	EscapeNewlines = "newlines"
+	// EscapeNone writes values as they are.
+	EscapeNone = "none"
+)
+
+// EscapeModes contains the supported escape modes.
+var EscapeModes = []string{EscapeControl, EscapeNewlines, EscapeNone}
+
+// ValidateEscape validates the escape mode; empty selects EscapeControl.
+func ValidateEscape(mode string) error {
+	if mode != "" && !slices.Contains(EscapeModes, mode) {
+		return fmt.Errorf("invalid escape mode: %s", mode)
+	}
+	return nil
+}
+
+// escapeText escapes the characters of s selected by the mode, so messages and
+// placeholder values cannot break line-oriented output or forge log lines:
+// \n, \r and \t are written as such, U+2028 and U+2029 as \u2028 and \u2029,
+// and other control characters as \xNN or \uNNNN. Text without such
+// characters is returned as it is, without allocating.
+func escapeText(mode, s string) string {
+	if mode == EscapeNone {
+		return s
+	}
+	control := mode != EscapeNewlines
+	for i, c := range s {
+		if needsEscape(c, control) {
+			return string(appendEscaped([]byte(s[:i]), s[i:], control))
+		}
+	}
+	return s
+}
+
+// needsEscape reports whether the character is escaped: line breaks, and with
+// control set, the other control characters.
+func needsEscape(c rune, control bool) bool {
+	switch c {
+	case '\n', '\r', '\u2028', '\u2029':
+		return true
+	}
+	return control && (c < ' ' || c == 0x7f || (c >= 0x80 && c <= 0x9f))
+}
+
+// appendEscaped appends s with the characters selected by control escaped.
+// Invalid UTF-8 is copied as it is.
+func appendEscaped(buf []byte, s string, control bool) []byte {
+	const hex = "0123456789abcdef"
+	start := 0
+	for i, c := range s {
+		if !needsEscape(c, control) {
+			continue
+		}
+		buf = append(buf, s[start:i]...)
+		start = i + utf8.RuneLen(c)
+		switch {
+		case c == '\n':
+			buf = append(buf, '\\', 'n')
+		case c == '\r':
+			buf = append(buf, '\\', 'r')
+		case c == '\t':
+			buf = append(buf, '\\', 't')
+		case c < utf8.RuneSelf:
+			buf = append(buf, '\\', 'x', hex[c>>4], hex[c&0xF])
+		default:
+			buf = append(buf, '\\', 'u', hex[c>>12&0xF], hex[c>>8&0xF], hex[c>>4&0xF], hex[c&0xF])
+		}
+	}
+	return append(buf, s[start:]...)
+}
@@ -0,0 +1,117 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		mode     string
+		text     string
+		expected string
+	}{
+		{"", "plain text", "plain text"},
+		{"", "a\nb\r\nc", `a\nb\r\nc`},
+		{EscapeControl, "tab\there \x1b[31mred\x7f \u0085 \u2028", `tab\there \x1b[31mred\x7f \u0085 \u2028`},
+		{EscapeControl, "héllo\n\xff", "héllo\\n\xff"},
+		{EscapeNewlines, "tab\there\nnext\u2029", `tab` + "\t" + `here\nnext\u2029`},
+		{EscapeNone, "a\nb", "a\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+" "+tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, escapeText(tt.mode, tt.text))
+		})
+	}
+
+	assert.NoError(t, ValidateEscape(""))
+	assert.NoError(t, ValidateEscape(EscapeNewlines))
+	assert.EqualError(t, ValidateEscape("html"), "invalid escape mode: html")
+}
+
+func TestCustomHandler_Escape(t *testing.T) {
+	forged := "login ok\n12:00:00 ERROR forged line"
+	opts := CustomHandlerOptions{Level: DebugLevel, Enabled: true, Pattern: "[level] [msg] [user]"}
+	slowPath := GenerateDefaultCustomReplaceAttr(opts, slog.TimeKey, slog.MessageKey)
+	for _, replaceAttr := range []CustomReplaceAttr{nil, slowPath} {
+		buf := &bytes.Buffer{}
+		handler := NewCustomHandler(&opts, bufio.NewWriter(buf), replaceAttr)
+		logger := slog.New(handler)
+
+		logger.Info(forged, "user", "bob\nERROR", "note", "two\nlines")
+		assert.NoError(t, handler.Flush())
+		assert.Equal(t, `INFO login ok\n12:00:00 ERROR forged line bob\nERROR [note="two\nlines"]`+"\n", buf.String())
+	}
+}
+
+func TestCustomHandler_EscapeModes(t *testing.T) {
+	for _, tt := range []struct {
+		mode     string
+		expected string
+	}{
+		{EscapeNewlines, "INFO a\\nb\tc\n"},
+		{EscapeNone, "INFO a\nb\tc\n"},
+	} {
+		buf := &bytes.Buffer{}
+		handler := NewCustomHandler(&CustomHandlerOptions{
+			Level:   DebugLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+			Escape:  tt.mode,
+		}, bufio.NewWriter(buf), nil)
+
+		assert.NoError(t, handler.Handle(t.Context(), slog.NewRecord(time.Now(), slog.LevelInfo, "a\nb\tc", 0)))
+		assert.NoError(t, handler.Flush())
+		assert.Equal(t, tt.expected, buf.String())
+	}
+}
+
+func TestCustomHandler_EscapeTemplate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := NewCustomHandler(&CustomHandlerOptions{
+		Level:        DebugLevel,
+		Enabled:      true,
+		FormatEngine: TemplateFormatEngine,
+		Pattern:      `{{.Level}} {{.Message}} {{index .Attrs "user"}}`,
+	}, bufio.NewWriter(buf), nil)
+
+	slog.New(handler).Info("a\nb", "user", "c\rd")
+	assert.NoError(t, handler.Flush())
+	assert.Equal(t, `INFO a\nb c\rd`+"\n", buf.String())
+}
+
+func TestJSONHandler_SingleLine(t *testing.T) {
+	opts := CustomHandlerOptions{Level: DebugLevel, Enabled: true, PatternPlaceholders: []string{"[level]", "[msg]"}}
+	for _, replaceAttr := range []CustomReplaceAttr{nil, RemoveGivenKeys(slog.TimeKey)} {
+		buf := &bytes.Buffer{}
+		handler := newJSONHandler(opts, bufio.NewWriter(buf), replaceAttr)
+		logger := slog.New(handler)
+
+		logger.Info("a\nb\r\u2028c", "key\nwith\rbreaks", "v\n", slog.Group("g", "x", "y\u2029"))
+		assert.NoError(t, handler.Flush())
+
+		output := buf.String()
+		assert.Equal(t, 1, strings.Count(output, "\n"), output)
+		assert.False(t, strings.ContainsAny(output[:len(output)-1], "\r\u2028\u2029"), output)
+		var entry map[string]any
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "a\nb\r\u2028c", entry["msg"])
+		assert.Equal(t, "v\n", entry["key\nwith\rbreaks"])
+	}
+}
+
+func TestEscape_Config(t *testing.T) {
+	config, err := NewBuilder().Console(Escape(EscapeNewlines)).Config()
+	assert.NoError(t, err)
+	assert.Equal(t, EscapeNewlines, config.Multilog.Handlers[0].Escape)
+
+	_, err = NewBuilder().Console(Escape("html")).Build()
+	assert.ErrorContains(t, err, "invalid escape mode: html")
+}
@@ -9,17 +9,21 @@ import (
 
 func basicExample() {
 	// Create a console handler
-	consoleHandler := multilog.NewConsoleHandler(multilog.CustomHandlerOptions{
-		Level:   "perf",
-		Enabled: true,
-		Pattern: "[time] [level] [msg]",
+	consoleHandler := multilog.NewConsoleHandler(multilog.ConsoleHandlerOptions{
+		CustomHandlerOptions: multilog.CustomHandlerOptions{
+			Level:   "perf",
+			Enabled: true,
+			Pattern: "[time] [level] [msg]",
+		},
 	})
 
 	// Create a file handler with rotation
-	fileHandler, err := multilog.NewFileHandler(multilog.CustomHandlerOptions{
-		Level:      "debug",
-		Enabled:    true,
-		Pattern:    "[datetime] [level] [source] [msg]",
+	fileHandler, err := multilog.NewFileHandler(multilog.FileHandlerOptions{
+		CustomHandlerOptions: multilog.CustomHandlerOptions{
+			Level:   "debug",
+			Enabled: true,
+			Pattern: "[datetime] [level] [source] [msg]",
+		},
 		File:       "logs/app.log",
 		MaxSize:    5,
 		MaxBackups: 3,
@@ -31,11 +35,13 @@ func basicExample() {
 	}
 
 	// Create a JSON handler
-	jsonHandler, err := multilog.NewJSONHandler(multilog.CustomHandlerOptions{
-		Level:   "perf",
-		Enabled: true,
-		Pattern: "[date] [level] [source] [msg]",
-		File:    "logs/app.json",
+	jsonHandler, err := multilog.NewJSONHandler(multilog.FileHandlerOptions{
+		CustomHandlerOptions: multilog.CustomHandlerOptions{
+			Level:   "perf",
+			Enabled: true,
+			Pattern: "[date] [level] [source] [msg]",
+		},
+		File: "logs/app.json",
 	}, nil)
 	if err != nil {
 		slog.Error("Error creating JSON handler")
@@ -47,6 +47,7 @@ multilog:
 	}
 
 	logger := multilog.NewLogger(handlers...)
+	defer logger.Close()
 
 	slog.SetDefault(logger.Logger)
 
@@ -12,11 +12,13 @@ import (
 type contextKey string
 
 func misUsage() {
-	handler := multilog.NewConsoleHandler(multilog.CustomHandlerOptions{
-		Level:                "info",
-		Enabled:              true,
-		Pattern:              "[time] [level] [msg]",
-		UseSingleLetterLevel: true,
+	handler := multilog.NewConsoleHandler(multilog.ConsoleHandlerOptions{
+		CustomHandlerOptions: multilog.CustomHandlerOptions{
+			Level:                "info",
+			Enabled:              true,
+			Pattern:              "[time] [level] [msg]",
+			UseSingleLetterLevel: true,
+		},
 	})
 
 	logger := multilog.NewLogger(handler)
@@ -51,12 +53,14 @@ func misUsage() {
 
 	logger.Info("User login attempt", "user", "john", "password", "secret123")
 
-	handler = multilog.NewConsoleHandler(multilog.CustomHandlerOptions{
-		Level:           "debug",
-		Enabled:         true,
-		Pattern:         "[time] [level] [msg]",
-		ValuePrefixChar: "<",
-		ValueSuffixChar: ">",
+	handler = multilog.NewConsoleHandler(multilog.ConsoleHandlerOptions{
+		CustomHandlerOptions: multilog.CustomHandlerOptions{
+			Level:           "debug",
+			Enabled:         true,
+			Pattern:         "[time] [level] [msg]",
+			ValuePrefixChar: "<",
+			ValueSuffixChar: ">",
+		},
 	})
 
 	logger = multilog.NewLogger(handler)
@@ -7,11 +7,13 @@ import (
 )
 
 func singleLetterLevel() {
-	handler := multilog.NewConsoleHandler(multilog.CustomHandlerOptions{
-		Level:                "info",
-		Enabled:              true,
-		Pattern:              "[time] [level] [msg]",
-		UseSingleLetterLevel: true,
+	handler := multilog.NewConsoleHandler(multilog.ConsoleHandlerOptions{
+		CustomHandlerOptions: multilog.CustomHandlerOptions{
+			Level:                "info",
+			Enabled:              true,
+			Pattern:              "[time] [level] [msg]",
+			UseSingleLetterLevel: true,
+		},
 	})
 
 	logger := multilog.NewLogger(handler)
@@ -0,0 +1,113 @@
+package multilog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Fallback targets
+const (
+	StdoutFallback = "stdout"
+	StderrFallback = "stderr"
+)
+
+// FallbackTargets contains the supported fallback targets.
+var FallbackTargets = []string{StdoutFallback, StderrFallback}
+
+// FallbackHandler wraps a handler so that write failures are reported to an
+// error callback and the failed record is written to a fallback handler.
+type FallbackHandler struct {
+	Fallback slog.Handler
+	OnError  func(err error)
+	wrappedHandler
+}
+
+// NewFallbackHandler creates a handler that reports errors returned by handler to
+// onError and retries the record on fallback. A nil onError writes to stderr; a
+// nil fallback only reports the error.
+func NewFallbackHandler(handler, fallback slog.Handler, onError func(err error)) *FallbackHandler {
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	return &FallbackHandler{
+		wrappedHandler: wrappedHandler{Handler: handler},
+		Fallback:       fallback,
+		OnError:        onError,
+	}
+}
+
+// NewConsoleFallback creates a console handler writing to the target (stdout or
+// stderr) using the given options, for use as a fallback handler.
+func NewConsoleFallback(target string, opts CustomHandlerOptions) (slog.Handler, error) {
+	var out *os.File
+	switch target {
+	case StdoutFallback:
+		out = os.Stdout
+	case StderrFallback:
+		out = os.Stderr
+	default:
+		return nil, fmt.Errorf("invalid fallback: %s", target)
+	}
+	opts.Enabled = true
+	opts.Color = false
+	return NewCustomHandler(&opts, bufio.NewWriter(out), nil), nil
+}
+
+// Handle writes the record to the wrapped handler, falling back on failure.
+// The error is only returned if the record could not be written at all.
+func (fh *FallbackHandler) Handle(ctx context.Context, record slog.Record) error {
+	err := fh.Handler.Handle(ctx, record)
+	if err == nil {
+		return nil
+	}
+	fh.OnError(err)
+	if fh.Fallback == nil {
+		return err
+	}
+	if ferr := fh.Fallback.Handle(ctx, record); ferr != nil {
+		return errors.Join(err, fmt.Errorf("fallback failed: %w", ferr))
+	}
+	return nil
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (fh *FallbackHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *fh
+	clone.Handler = fh.Handler.WithAttrs(attrs)
+	if fh.Fallback != nil {
+		clone.Fallback = fh.Fallback.WithAttrs(attrs)
+	}
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (fh *FallbackHandler) WithGroup(name string) slog.Handler {
+	clone := *fh
+	clone.Handler = fh.Handler.WithGroup(name)
+	if fh.Fallback != nil {
+		clone.Fallback = fh.Fallback.WithGroup(name)
+	}
+	return &clone
+}
+
+// Flush flushes the wrapped and fallback handlers.
+func (fh *FallbackHandler) Flush() error {
+	err := fh.wrappedHandler.Flush()
+	if fh.Fallback != nil {
+		err = errors.Join(err, flushHandler(fh.Fallback))
+	}
+	return err
+}
+
+// Close closes the wrapped and fallback handlers.
+func (fh *FallbackHandler) Close() error {
+	err := fh.wrappedHandler.Close()
+	if fh.Fallback != nil {
+		err = errors.Join(err, closeSlogHandler(fh.Fallback))
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallbackHandler_Handle(t *testing.T) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)
+
+	t.Run("success", func(t *testing.T) {
+		fallback := &CountingHandler{}
+		var reported []error
+		fh := NewFallbackHandler(&CountingHandler{}, fallback, func(err error) { reported = append(reported, err) })
+
+		assert.NoError(t, fh.Handle(context.Background(), record))
+		assert.Equal(t, 0, fallback.callCount)
+		assert.Empty(t, reported)
+	})
+
+	t.Run("failure uses fallback", func(t *testing.T) {
+		writeErr := errors.New("disk full")
+		fallback := &CountingHandler{}
+		var reported []error
+		fh := NewFallbackHandler(&ErrorHandler{err: writeErr}, fallback, func(err error) { reported = append(reported, err) })
+
+		assert.NoError(t, fh.Handle(context.Background(), record))
+		assert.Equal(t, 1, fallback.callCount)
+		assert.Equal(t, []error{writeErr}, reported)
+	})
+
+	t.Run("failure without fallback", func(t *testing.T) {
+		writeErr := errors.New("disk full")
+		fh := NewFallbackHandler(&ErrorHandler{err: writeErr}, nil, func(error) {})
+		assert.ErrorIs(t, fh.Handle(context.Background(), record), writeErr)
+	})
+
+	t.Run("fallback failure", func(t *testing.T) {
+		writeErr := errors.New("disk full")
+		fallbackErr := errors.New("closed pipe")
+		fh := NewFallbackHandler(&ErrorHandler{err: writeErr}, &ErrorHandler{err: fallbackErr}, func(error) {})
+		err := fh.Handle(context.Background(), record)
+		assert.ErrorIs(t, err, writeErr)
+		assert.ErrorIs(t, err, fallbackErr)
+	})
+}
+
+func TestFallbackHandler_Level(t *testing.T) {
+	primary := NewConsoleHandler(ConsoleHandlerOptions{CustomHandlerOptions: CustomHandlerOptions{Level: InfoLevel, Enabled: true}})
+	fh := NewFallbackHandler(primary, nil, nil)
+
+	assert.NoError(t, fh.SetLevel(DebugLevel))
+	assert.Equal(t, DebugLevel, fh.GetLevel())
+	assert.NotNil(t, GetCustomHandler(fh))
+	assert.IsType(t, &FallbackHandler{}, fh.WithAttrs([]slog.Attr{slog.String("k", "v")}))
+	assert.IsType(t, &FallbackHandler{}, fh.WithGroup("g"))
+}
+
+func TestNewConsoleFallback(t *testing.T) {
+	for _, target := range FallbackTargets {
+		h, err := NewConsoleFallback(target, CustomHandlerOptions{Level: InfoLevel})
+		assert.NoError(t, err)
+		assert.True(t, h.Enabled(context.Background(), slog.LevelInfo))
+	}
+
+	_, err := NewConsoleFallback("syslog", CustomHandlerOptions{})
+	assert.Error(t, err)
+}
@@ -3,19 +3,65 @@ package multilog
 import (
 	"context"
 	"log/slog"
+	"time"
 )
 
+// FileHandlerOptions are the options of file handlers: the formatting options,
+// and the file with its rotation, archiving, sharding, sync and write batching
+// settings.
+type FileHandlerOptions struct {
+	CustomHandlerOptions
+	FilePermissions
+	File             string
+	ShardBy          string
+	Sync             string
+	ArchiveURL       string
+	MaxSize          int
+	MaxAge           int
+	MaxBackups       int
+	CompressionLevel int
+	FlushSize        int
+	ArchiveRetention int
+	MaxOpenShards    int
+	FlushInterval    time.Duration
+	RotateInterval   time.Duration
+	ArchiveInterval  time.Duration
+	SyncInterval     time.Duration
+	Compress         bool
+	CSVHeader        bool
+}
+
 // FileHandler is a Handler for file logging.
 type FileHandler struct {
-	Handler CustomHandlerInterface
+	Handler  CustomHandlerInterface
+	rotator  rotationWriter
+	flusher  *periodicFlusher
+	syncer   *periodicFlusher
+	archiver *Archiver
+	file     string
 }
 
 // NewFileHandler creates a file Handler with the specified options.
-func NewFileHandler(opts CustomHandlerOptions) (slog.Handler, error) {
-	writer := CreateRotationWriter(opts)
+func NewFileHandler(opts FileHandlerOptions) (slog.Handler, error) {
+	if err := checkTimeLayoutName(opts.File, opts.RotateInterval); err != nil {
+		return nil, err
+	}
+	rotator := newRotator(opts)
+	archiver, err := newHandlerArchiver(opts, rotator)
+	if err != nil {
+		_ = rotator.Close()
+		return nil, err
+	}
+	writer := newSyncWriter(rotator, opts)
+	handler := NewCustomHandler(&opts.CustomHandlerOptions, newFileWriter(writer, opts), nil)
 
 	return &FileHandler{
-		Handler: NewCustomHandler(&opts, writer, nil),
+		Handler:  handler,
+		rotator:  writer,
+		flusher:  startBatching(handler, opts),
+		syncer:   startSyncing(handler, writer, opts),
+		archiver: archiver,
+		file:     opts.File,
 	}, nil
 }
 
@@ -38,3 +84,40 @@ func (fh *FileHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 func (fh *FileHandler) WithGroup(name string) slog.Handler {
 	return fh.Handler.WithGroup(name)
 }
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (fh *FileHandler) SetLevel(level string) error {
+	return setHandlerLevel(fh.Handler, level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (fh *FileHandler) GetLevel() string {
+	return getHandlerLevel(fh.Handler)
+}
+
+// Rotate flushes pending output and rotates the log file.
+func (fh *FileHandler) Rotate() error {
+	return rotateHandler(fh.Handler, fh.rotator)
+}
+
+// Close flushes pending output, closes the log file, and archives the files
+// rotated since the last archive scan.
+func (fh *FileHandler) Close() error {
+	fh.flusher.Stop()
+	fh.syncer.Stop()
+	err := closeHandler(fh.Handler, fh.rotator)
+	if archiveErr := closeArchiver(fh.archiver); err == nil {
+		err = archiveErr
+	}
+	return err
+}
+
+// Flush flushes pending output.
+func (fh *FileHandler) Flush() error {
+	return flushHandler(fh.Handler)
+}
+
+// logFile implements logFileProvider.
+func (fh *FileHandler) logFile() string {
+	return fh.file
+}
@@ -21,25 +21,29 @@ func TestNewFileHandler(t *testing.T) {
 
 	tests := []struct {
 		name          string
-		opts          CustomHandlerOptions
+		opts          FileHandlerOptions
 		expectEnabled bool
 	}{
 		{
 			name: "basic enabled handler",
-			opts: CustomHandlerOptions{
-				Level:   "info",
-				Enabled: true,
-				File:    filePath,
-				Pattern: "[time] [level] [message]",
+			opts: FileHandlerOptions{
+				CustomHandlerOptions: CustomHandlerOptions{
+					Level:   "info",
+					Enabled: true,
+					Pattern: "[time] [level] [message]",
+				},
+				File: filePath,
 			},
 			expectEnabled: true,
 		},
 		{
 			name: "disabled handler",
-			opts: CustomHandlerOptions{
-				Level:   "info",
-				Enabled: false,
-				File:    filePath,
+			opts: FileHandlerOptions{
+				CustomHandlerOptions: CustomHandlerOptions{
+					Level:   "info",
+					Enabled: false,
+				},
+				File: filePath,
 			},
 			expectEnabled: false,
 		},
@@ -72,12 +76,14 @@ func TestFileHandlerLogging(t *testing.T) {
 
 	filePath := filepath.Join(tempDir, "test.log")
 
-	opts := CustomHandlerOptions{
-		Level:               "info",
-		Enabled:             true,
-		File:                filePath,
-		Pattern:             "[msg]",
-		PatternPlaceholders: []string{"[msg]"},
+	opts := FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:               "info",
+			Enabled:             true,
+			Pattern:             "[msg]",
+			PatternPlaceholders: []string{"[msg]"},
+		},
+		File: filePath,
 	}
 
 	handler, err := NewFileHandler(opts)
@@ -125,10 +131,12 @@ func TestFileHandlerWithAttrsAndGroups(t *testing.T) {
 
 	filePath := filepath.Join(tempDir, "test.log")
 
-	opts := CustomHandlerOptions{
-		Level:   "info",
-		Enabled: true,
-		File:    filePath,
+	opts := FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   "info",
+			Enabled: true,
+		},
+		File: filePath,
 	}
 
 	handler, err := NewFileHandler(opts)
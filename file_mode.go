@@ -0,0 +1,141 @@
+package multilog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Modes lumberjack creates log files and directories with.
+const (
+	defaultFileMode = 0o600
+	defaultDirMode  = 0o755
+)
+
+// FilePermissions are the modes and ownership of the log files and their
+// directories. Zero values keep the defaults of the process.
+type FilePermissions struct {
+	FileOwner string
+	FileGroup string
+	FileMode  os.FileMode
+	DirMode   os.FileMode
+}
+
+// ParseFileMode parses a permission mode in octal, such as "0640" or "0o640".
+// An empty string returns 0, keeping the default mode.
+func ParseFileMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	m, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(mode, "0o"), "0O"), 8, 32)
+	if err != nil || m > 0o777 {
+		return 0, fmt.Errorf("invalid file mode: %s", mode)
+	}
+	return os.FileMode(m), nil
+}
+
+// modeOrDefault returns the mode, or the default mode if it is unset.
+func modeOrDefault(mode, defaultMode os.FileMode) os.FileMode {
+	if mode == 0 {
+		return defaultMode
+	}
+	return mode
+}
+
+// prepareLogFile applies the FileMode, DirMode, FileOwner and FileGroup
+// options to the log file at path and its directory, creating them if needed.
+// lumberjack keeps the mode and, on Unix, the owner of the file it rotates, so
+// the files rotated to get them too. It does nothing if no option is set.
+func prepareLogFile(path string, opts *FilePermissions) error {
+	if opts.FileMode == 0 && opts.DirMode == 0 && opts.FileOwner == "" && opts.FileGroup == "" {
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+	_, err := os.Stat(dir)
+	dirExists := err == nil
+	if err := os.MkdirAll(dir, modeOrDefault(opts.DirMode, defaultDirMode)); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	// The umask applies to created directories and files, so set their modes
+	// explicitly.
+	if opts.DirMode != 0 && !dirExists {
+		if err := os.Chmod(dir, opts.DirMode); err != nil {
+			return fmt.Errorf("failed to set log directory mode: %w", err)
+		}
+	}
+
+	_, err = os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, modeOrDefault(opts.FileMode, defaultFileMode))
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to create log file: %w", err)
+		}
+		if file != nil {
+			_ = file.Close()
+		}
+	}
+	if opts.FileMode != 0 {
+		if err := os.Chmod(path, opts.FileMode); err != nil {
+			return fmt.Errorf("failed to set log file mode: %w", err)
+		}
+	}
+	return chownLogFile(path, opts.FileOwner, opts.FileGroup)
+}
+
+// chownLogFile changes the owner and group of the file, given as names or
+// numeric IDs, if set. It is skipped on Windows.
+func chownLogFile(path, owner, group string) error {
+	if owner == "" && group == "" || runtime.GOOS == "windows" {
+		return nil
+	}
+	uid, gid := -1, -1
+	if owner != "" {
+		id, err := lookupID(owner, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to look up log file owner: %w", err)
+		}
+		uid = id
+	}
+	if group != "" {
+		id, err := lookupID(group, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to look up log file group: %w", err)
+		}
+		gid = id
+	}
+	if err := os.Chown(path, uid, gid); err != nil {
+		return fmt.Errorf("failed to change log file owner: %w", err)
+	}
+	return nil
+}
+
+// lookupID returns the numeric ID, or looks up the ID of the name.
+func lookupID(name string, lookup func(name string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	id, err := lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(id)
+}
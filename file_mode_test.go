@@ -0,0 +1,144 @@
+package multilog
+
+import (
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFileMode(t *testing.T) {
+	tests := map[string]os.FileMode{"": 0, "0640": 0o640, "0o600": 0o600, "755": 0o755}
+	for mode, expected := range tests {
+		m, err := ParseFileMode(mode)
+		assert.NoError(t, err, mode)
+		assert.Equal(t, expected, m, mode)
+	}
+	for _, mode := range []string{"rw-r-----", "0800", "01777"} {
+		_, err := ParseFileMode(mode)
+		assert.EqualError(t, err, "invalid file mode: "+mode)
+	}
+}
+
+// assertMode fails unless the file has the permissions.
+func assertMode(t *testing.T, path string, mode os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, mode, info.Mode().Perm(), path)
+}
+
+func TestFileHandler_FileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not supported on windows")
+	}
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "app.log")
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: file
+      level: info
+      enabled: true
+      file: ` + path + `
+      file_mode: 0640
+      dir_mode: 0750`))
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	assertMode(t, dir, 0o750)
+	assertMode(t, path, 0o640)
+
+	handler := handlers[0].(*FileHandler)
+	slog.New(handler).Info("before rotation")
+	assert.NoError(t, handler.Rotate())
+	slog.New(handler).Info("after rotation")
+	assert.NoError(t, handler.Close())
+
+	// lumberjack keeps the mode of the rotated file for the new one.
+	assertMode(t, path, 0o640)
+	matches, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+	assertMode(t, matches[0], 0o640)
+}
+
+func TestPrepareLogFile_ExistingFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not supported on windows")
+	}
+	path := filepath.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(path, []byte("kept\n"), 0o644))
+	assert.NoError(t, prepareLogFile(path, &FilePermissions{FileMode: 0o600}))
+	assertMode(t, path, 0o600)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "kept\n", string(data))
+
+	// Without options nothing is created.
+	other := filepath.Join(t.TempDir(), "other.log")
+	assert.NoError(t, prepareLogFile(other, &FilePermissions{}))
+	assert.NoFileExists(t, other)
+}
+
+func TestPrepareLogFile_Owner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ownership is not supported on windows")
+	}
+	current, err := user.Current()
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	// Changing to the current owner and group is always allowed.
+	assert.NoError(t, prepareLogFile(path, &FilePermissions{FileOwner: current.Username, FileGroup: current.Gid}))
+	assert.FileExists(t, path)
+
+	err = prepareLogFile(path, &FilePermissions{FileOwner: "no-such-user-multilog"})
+	assert.ErrorContains(t, err, "failed to look up log file owner")
+}
+
+func TestFileHandler_OwnerFailureIsNotFatal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	var errs []error
+	handler, err := NewFileHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			OnError: func(err error) { errs = append(errs, err) },
+		},
+		FilePermissions: FilePermissions{
+			FileOwner: "no-such-user-multilog",
+		},
+		File: path,
+	})
+	assert.NoError(t, err)
+	slog.New(handler).Info("written anyway")
+	assert.NoError(t, handler.(*FileHandler).Close())
+
+	if runtime.GOOS != "windows" {
+		assert.Len(t, errs, 1)
+	}
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "written anyway")
+}
+
+func TestValidateHandler_FileMode(t *testing.T) {
+	handler := &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log", FileMode: "0640", DirMode: "0750"}
+	assert.NoError(t, validateHandler(handler))
+
+	handler.DirMode = "0999"
+	assert.EqualError(t, validateHandler(handler), "invalid file mode: 0999")
+
+	cfg, err := NewBuilder().File("app.log", FileMode(0o640), DirMode(0o750), FileOwner("syslog", "adm")).Config()
+	assert.NoError(t, err)
+	h := cfg.Multilog.Handlers[0]
+	assert.Equal(t, "0640", h.FileMode)
+	assert.Equal(t, "0750", h.DirMode)
+	assert.Equal(t, "syslog", h.FileOwner)
+	assert.Equal(t, "adm", h.FileGroup)
+}
@@ -0,0 +1,110 @@
+package multilog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Sync modes of file handlers
+const (
+	AlwaysSync   = "always"
+	IntervalSync = "interval"
+	NeverSync    = "never"
+)
+
+// SyncModes contains the supported sync modes.
+var SyncModes = []string{AlwaysSync, IntervalSync, NeverSync}
+
+// DefaultSyncInterval is the default interval between fsyncs in interval mode.
+const DefaultSyncInterval = time.Second
+
+// syncWriter is a rotation writer that fsyncs the file being written: after
+// every write in always mode, and when asked in interval mode. lumberjack does
+// not expose its file, so the file is reopened by name to sync it, which syncs
+// the data written through any descriptor.
+type syncWriter struct {
+	rotationWriter
+	syncFile func(path string) error
+	always   bool
+}
+
+// newSyncWriter wraps the rotator according to opts.Sync, or returns it as is
+// when the file is never synced.
+func newSyncWriter(rotator rotationWriter, opts FileHandlerOptions) rotationWriter {
+	if opts.Sync != AlwaysSync && opts.Sync != IntervalSync {
+		return rotator
+	}
+	return &syncWriter{rotationWriter: rotator, syncFile: syncFile, always: opts.Sync == AlwaysSync}
+}
+
+// Write writes to the file, syncing it in always mode.
+func (w *syncWriter) Write(p []byte) (int, error) {
+	n, err := w.rotationWriter.Write(p)
+	if err != nil || !w.always {
+		return n, err
+	}
+	return n, w.Sync()
+}
+
+// Sync commits the file being written to stable storage.
+func (w *syncWriter) Sync() error {
+	return w.syncFile(rotatorFilename(w.rotationWriter))
+}
+
+// syncFile commits the file to stable storage.
+func syncFile(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Nothing has been written yet.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync log file: %w", err)
+	}
+	defer file.Close()
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log file: %w", err)
+	}
+	return nil
+}
+
+// Rotate syncs the file before rotating it.
+func (w *syncWriter) Rotate() error {
+	return errors.Join(w.Sync(), w.rotationWriter.Rotate())
+}
+
+// Close syncs and closes the file.
+func (w *syncWriter) Close() error {
+	return errors.Join(w.Sync(), w.rotationWriter.Close())
+}
+
+// syncFlusher flushes a handler and syncs its file.
+type syncFlusher struct {
+	handler Flusher
+	writer  *syncWriter
+}
+
+// Flush flushes the handler and syncs its file.
+func (f syncFlusher) Flush() error {
+	if err := f.handler.Flush(); err != nil {
+		return err
+	}
+	return f.writer.Sync()
+}
+
+// startSyncing syncs the file of the handler every opts.SyncInterval and after
+// error records in interval mode. It returns the syncer to stop on close, or nil.
+func startSyncing(h *CustomHandler, w rotationWriter, opts FileHandlerOptions) *periodicFlusher {
+	sw, ok := w.(*syncWriter)
+	if !ok || sw.always {
+		return nil
+	}
+	h.out.mu.Lock()
+	h.out.sync = sw.Sync
+	h.out.mu.Unlock()
+	return newPeriodicFlusher(syncFlusher{handler: h, writer: sw}, defaultDuration(opts.SyncInterval, DefaultSyncInterval),
+		opts.OnError)
+}
@@ -0,0 +1,131 @@
+package multilog
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countSyncs replaces the fsync of the handler's sync writer with a counter.
+func countSyncs(t *testing.T, w rotationWriter) *atomic.Int32 {
+	t.Helper()
+	sw, ok := w.(*syncWriter)
+	assert.True(t, ok)
+	var count atomic.Int32
+	sw.syncFile = func(path string) error {
+		count.Add(1)
+		return syncFile(path)
+	}
+	return &count
+}
+
+// newSyncedFile creates a file handler with the sync mode.
+func newSyncedFile(t *testing.T, path, mode string, interval time.Duration) *FileHandler {
+	t.Helper()
+	handler, err := NewFileHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+		},
+		File:         path,
+		Sync:         mode,
+		SyncInterval: interval,
+	})
+	assert.NoError(t, err)
+	return handler.(*FileHandler)
+}
+
+func TestFileHandler_SyncAlways(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	fh := newSyncedFile(t, path, AlwaysSync, 0)
+	syncs := countSyncs(t, fh.rotator)
+	assert.Nil(t, fh.syncer)
+
+	logger := slog.New(fh)
+	logger.Info("first")
+	logger.Info("second")
+	assert.Equal(t, int32(2), syncs.Load())
+	assert.NoError(t, fh.Close())
+	assert.Equal(t, int32(3), syncs.Load())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "INFO first\nINFO second\n", string(data))
+}
+
+func TestFileHandler_SyncInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	fh := newSyncedFile(t, path, IntervalSync, time.Hour)
+	syncs := countSyncs(t, fh.rotator)
+
+	logger := slog.New(fh)
+	logger.Info("not synced")
+	assert.Equal(t, int32(0), syncs.Load())
+	logger.Error("synced")
+	assert.Equal(t, int32(1), syncs.Load())
+	assert.NoError(t, fh.Close())
+
+	// The background syncer flushes and syncs on every tick.
+	fh = newSyncedFile(t, path, IntervalSync, 10*time.Millisecond)
+	syncs = countSyncs(t, fh.rotator)
+	slog.New(fh).Info("later")
+	assert.Eventually(t, func() bool { return syncs.Load() > 0 }, time.Second, 5*time.Millisecond)
+	assert.NoError(t, fh.Close())
+}
+
+func TestFileHandler_SyncNever(t *testing.T) {
+	fh := newSyncedFile(t, filepath.Join(t.TempDir(), "app.log"), NeverSync, 0)
+	_, ok := fh.rotator.(*syncWriter)
+	assert.False(t, ok)
+	assert.Nil(t, fh.syncer)
+	assert.NoError(t, fh.Close())
+}
+
+func TestSyncFile_Missing(t *testing.T) {
+	assert.NoError(t, syncFile(filepath.Join(t.TempDir(), "missing.log")))
+}
+
+func TestJSONHandler_SyncAlways(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.csv")
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: file
+      subtype: csv
+      level: info
+      enabled: true
+      file: ` + path + `
+      csv_header: true
+      sync: always`))
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	jh := handlers[0].(*JSONHandler)
+	syncs := countSyncs(t, jh.rotator)
+
+	slog.New(jh).Info("synced")
+	assert.Equal(t, int32(1), syncs.Load())
+	assert.Equal(t, path, rotatorFilename(jh.rotator))
+	assert.NoError(t, jh.Close())
+}
+
+func TestValidateHandler_Sync(t *testing.T) {
+	handler := &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log", Sync: IntervalSync,
+		SyncInterval: 5 * time.Second}
+	assert.NoError(t, validateHandler(handler))
+
+	handler.Sync = AlwaysSync
+	assert.EqualError(t, validateHandler(handler), "sync_interval requires sync: interval")
+
+	handler = &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log", Sync: "sometimes"}
+	assert.EqualError(t, validateHandler(handler), "invalid sync mode: sometimes")
+
+	handler = &HandlerConfig{Type: ConsoleHandlerType, Level: InfoLevel, Sync: AlwaysSync}
+	assert.EqualError(t, validateHandler(handler), "sync requires a file handler")
+}
@@ -0,0 +1,92 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Google Cloud Logging field names
+const (
+	GCPSeverityKey       = "severity"
+	GCPTimeKey           = "time"
+	GCPMessageKey        = "message"
+	GCPSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	GCPTraceKey          = "logging.googleapis.com/trace"
+	GCPSpanIDKey         = "logging.googleapis.com/spanId"
+)
+
+// GCPProjectEnv is the environment variable holding the default project ID.
+const GCPProjectEnv = "GOOGLE_CLOUD_PROJECT"
+
+// gcpSeverity maps the level to a Cloud Logging severity.
+func gcpSeverity(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "DEBUG"
+	case level < slog.LevelWarn:
+		return "INFO"
+	case level < slog.LevelError:
+		return "WARNING"
+	default:
+		return "ERROR"
+	}
+}
+
+// gcpTrace returns the trace ID in the projects/PROJECT/traces/ID form Cloud
+// Logging links to Cloud Trace, or the bare ID if no project is known.
+func gcpTrace(project, traceID string) string {
+	if project == "" {
+		project = os.Getenv(GCPProjectEnv)
+	}
+	if project == "" {
+		return traceID
+	}
+	return "projects/" + project + "/traces/" + traceID
+}
+
+// appendGCP renders the record as a Google Cloud Logging structured log
+// entry: the severity, time and message, the source location and trace IDs if
+// present, the remaining attributes, and the stack trace. The pattern is not used.
+func (jh *JSONHandler) appendGCP(ctx context.Context, buf []byte, ch *CustomHandler, record slog.Record) []byte {
+	opts := ch.Opts
+	attrs := getBuffer()
+	defer putBuffer(attrs)
+	var fields map[string]string
+	*attrs, fields = jh.appendAttrs(*attrs, ch, record, []string{TraceIDKey, SpanIDKey})
+
+	buf = append(buf, '{')
+	buf = appendJSONString(appendJSONKey(buf, GCPSeverityKey), gcpSeverity(record.Level))
+	buf = append(appendJSONKey(buf, GCPTimeKey), '"')
+	buf = record.Time.UTC().AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, '"')
+	buf = appendJSONString(appendJSONKey(buf, GCPMessageKey), record.Message)
+	if opts.AddSource {
+		if src := record.Source(); src != nil && src.File != "" {
+			buf = append(appendJSONKey(buf, GCPSourceLocationKey), '{')
+			buf = appendJSONString(appendJSONKey(buf, "file"), src.File)
+			buf = appendJSONString(appendJSONKey(buf, "line"), strconv.Itoa(src.Line))
+			buf = appendJSONString(appendJSONKey(buf, "function"), src.Function)
+			buf = append(buf, '}')
+		}
+	}
+	if id := fields[TraceIDKey]; id != "" {
+		buf = appendJSONString(appendJSONKey(buf, GCPTraceKey), gcpTrace(opts.GCPProject, id))
+	}
+	if id := fields[SpanIDKey]; id != "" {
+		buf = appendJSONString(appendJSONKey(buf, GCPSpanIDKey), id)
+	}
+	if len(*attrs) > 0 {
+		buf = append(buf, ',')
+		buf = append(buf, *attrs...)
+	}
+	if record.Level == LevelPerf {
+		buf = appendJSONPerf(buf, opts)
+	}
+	if stacktraceEnabled(opts, record.Level) {
+		buf = appendJSONString(appendJSONKey(buf, StacktraceKey), recordStacktrace(ctx, record.PC))
+	}
+	return append(buf, '}')
+}
@@ -0,0 +1,72 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONHandler_GCP(t *testing.T) {
+	t.Setenv(GCPProjectEnv, "")
+	buf := &bytes.Buffer{}
+	opts := CustomHandlerOptions{Level: DebugLevel, Enabled: true, AddSource: true, GCP: true, GCPProject: "shop"}
+	logger := slog.New(newJSONHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Info("served", TraceIDKey, "abc", SpanIDKey, "def", "id", 7)
+	logger.Warn("slow")
+	logger.Debug("detail")
+	logger.Error("failed")
+
+	docs := decodeLines(t, buf.String())
+	assert.Len(t, docs, 4)
+	info := docs[0]
+	assert.Equal(t, "INFO", info[GCPSeverityKey])
+	assert.Equal(t, "served", info[GCPMessageKey])
+	_, err := time.Parse(time.RFC3339Nano, info[GCPTimeKey].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, "projects/shop/traces/abc", info[GCPTraceKey])
+	assert.Equal(t, "def", info[GCPSpanIDKey])
+	assert.Equal(t, 7.0, info["id"])
+	assert.NotContains(t, info, TraceIDKey)
+
+	location := info[GCPSourceLocationKey].(map[string]any)
+	assert.True(t, strings.HasSuffix(location["file"].(string), "gcp_test.go"))
+	assert.NotEmpty(t, location["line"])
+	assert.Contains(t, location["function"], "TestJSONHandler_GCP")
+
+	var severities []any
+	for _, doc := range docs {
+		severities = append(severities, doc[GCPSeverityKey])
+	}
+	assert.Equal(t, []any{"INFO", "WARNING", "DEBUG", "ERROR"}, severities)
+}
+
+func TestGCPTrace(t *testing.T) {
+	t.Setenv(GCPProjectEnv, "")
+	assert.Equal(t, "abc", gcpTrace("", "abc"))
+	t.Setenv(GCPProjectEnv, "env-project")
+	assert.Equal(t, "projects/env-project/traces/abc", gcpTrace("", "abc"))
+	assert.Equal(t, "projects/shop/traces/abc", gcpTrace("shop", "abc"))
+}
+
+func TestGCP_Config(t *testing.T) {
+	config, err := NewBuilder().Console(JSON(), GCP()).Config()
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(config)
+	assert.NoError(t, err)
+	jh, ok := handlers[0].(*JSONHandler)
+	assert.True(t, ok, "json console handler writes JSON documents")
+	assert.True(t, jh.Handler.GetOptions().AddSource)
+
+	_, err = NewBuilder().Console(GCP()).Config()
+	assert.ErrorContains(t, err, "gcp requires a handler that writes JSON documents")
+	_, err = NewBuilder().Console(JSON(), GCP(), ECS()).Config()
+	assert.ErrorContains(t, err, "ecs and gcp cannot both be set")
+	_, err = NewBuilder().Console(func(h *HandlerConfig) { h.SubType = "xml" }).Config()
+	assert.ErrorContains(t, err, "invalid console handler subtype: xml")
+}
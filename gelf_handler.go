@@ -0,0 +1,302 @@
+package multilog
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+)
+
+// GELF settings
+const (
+	GELFVersion          = "1.1"
+	DefaultGELFChunkSize = 1420
+	gelfMaxChunks        = 128
+	gelfChunkHeaderSize  = 12
+)
+
+// Network protocols
+const (
+	UDPProtocol      = "udp"
+	TCPProtocol      = "tcp"
+	UnixProtocol     = "unix"
+	UnixgramProtocol = "unixgram"
+)
+
+// gelfMagic is the magic prefix of chunked GELF messages.
+var gelfMagic = []byte{0x1e, 0x0f}
+
+// SyslogSeverityMap maps log levels to syslog severities.
+var SyslogSeverityMap = map[slog.Level]int{
+	slog.LevelDebug: 7,
+	LevelPerf:       7,
+	slog.LevelInfo:  6,
+	slog.LevelWarn:  4,
+	slog.LevelError: 3,
+}
+
+// GetSyslogSeverity returns the syslog severity for the given level.
+func GetSyslogSeverity(level slog.Level) int {
+	if severity, ok := SyslogSeverityMap[level]; ok {
+		return severity
+	}
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// gelfConn is the connection shared by a GELF handler and its derived handlers.
+type gelfConn struct {
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+// GELFHandler is a Handler that sends records to Graylog in GELF format.
+type GELFHandler struct {
+	Handler *CustomHandler
+	conn    *gelfConn
+	opts    *GELFHandlerOptions
+	host    string
+	groups  string
+	attrs   []slog.Attr
+}
+
+// GELFHandlerOptions are the options of GELF handlers: the formatting options,
+// and the address, protocol and UDP chunk size.
+type GELFHandlerOptions struct {
+	CustomHandlerOptions
+	Address   string
+	Protocol  string
+	ChunkSize int
+}
+
+// NewGELFHandler creates a GELF Handler with the specified options.
+// UDP messages larger than the chunk size are split into GELF chunks; TCP
+// messages are null-byte delimited.
+func NewGELFHandler(opts GELFHandlerOptions) (slog.Handler, error) {
+	if opts.Address == "" {
+		return nil, fmt.Errorf("gelf handler requires an address")
+	}
+	opts.Protocol = defaultIfEmpty(opts.Protocol, UDPProtocol)
+	if opts.Protocol != UDPProtocol && opts.Protocol != TCPProtocol {
+		return nil, fmt.Errorf("invalid gelf protocol: %s", opts.Protocol)
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = UnknownSource
+	}
+
+	return &GELFHandler{
+		Handler: NewCustomHandler(&opts.CustomHandlerOptions, bufio.NewWriter(io.Discard), nil),
+		conn:    &gelfConn{},
+		opts:    &opts,
+		host:    host,
+	}, nil
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (gh *GELFHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return gh.Handler.Enabled(ctx, level)
+}
+
+// Handle converts the log record to a GELF message and sends it.
+func (gh *GELFHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !gh.Enabled(ctx, record.Level) {
+		return nil
+	}
+	payload, err := json.Marshal(gh.buildMessage(record))
+	if err != nil {
+		return fmt.Errorf("failed to marshal gelf message: %w", err)
+	}
+	return gh.send(payload)
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (gh *GELFHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *gh
+	clone.attrs = append([]slog.Attr{}, gh.attrs...)
+	for _, a := range attrs {
+		a.Key = gh.groups + a.Key
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (gh *GELFHandler) WithGroup(name string) slog.Handler {
+	clone := *gh
+	clone.groups = gh.groups + name + "_"
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (gh *GELFHandler) SetLevel(level string) error {
+	return gh.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (gh *GELFHandler) GetLevel() string {
+	return gh.Handler.GetLevel()
+}
+
+// Close closes the underlying connection.
+func (gh *GELFHandler) Close() error {
+	gh.conn.mu.Lock()
+	defer gh.conn.mu.Unlock()
+	if gh.conn.conn == nil {
+		return nil
+	}
+	err := gh.conn.conn.Close()
+	gh.conn.conn = nil
+	return err
+}
+
+// customHandler implements customHandlerProvider.
+func (gh *GELFHandler) customHandler() CustomHandlerInterface {
+	return gh.Handler
+}
+
+// buildMessage maps the record to a GELF message; attributes become additional fields.
+func (gh *GELFHandler) buildMessage(record slog.Record) map[string]any {
+	msg := map[string]any{
+		"version":       GELFVersion,
+		"host":          gh.host,
+		"short_message": record.Message,
+		"timestamp":     float64(record.Time.UnixNano()) / 1e9,
+		"level":         GetSyslogSeverity(record.Level),
+	}
+	for _, a := range gh.attrs {
+		addGELFField(msg, "", a)
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		addGELFField(msg, gh.groups, a)
+		return true
+	})
+	return msg
+}
+
+// addGELFField adds the attribute as an additional field, flattening groups.
+func addGELFField(msg map[string]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Key == "" && a.Value.Kind() != slog.KindGroup {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix += a.Key + "_"
+		}
+		for _, ga := range a.Value.Group() {
+			addGELFField(msg, groupPrefix, ga)
+		}
+		return
+	}
+
+	key := "_" + prefix + a.Key
+	if key == "_id" {
+		key = "_id_"
+	}
+	switch a.Value.Kind() {
+	case slog.KindInt64:
+		msg[key] = a.Value.Int64()
+	case slog.KindUint64:
+		msg[key] = a.Value.Uint64()
+	case slog.KindFloat64:
+		msg[key] = a.Value.Float64()
+	default:
+		msg[key] = a.Value.String()
+	}
+}
+
+// send writes the payload, dialing or redialing the connection as needed.
+func (gh *GELFHandler) send(payload []byte) error {
+	gh.conn.mu.Lock()
+	defer gh.conn.mu.Unlock()
+
+	if err := gh.write(payload); err != nil {
+		// Drop the connection and retry once with a fresh one.
+		if gh.conn.conn != nil {
+			_ = gh.conn.conn.Close()
+			gh.conn.conn = nil
+		}
+		if err := gh.write(payload); err != nil {
+			return fmt.Errorf("failed to send gelf message: %w", err)
+		}
+	}
+	return nil
+}
+
+// write sends the payload on the current connection; the caller must hold gh.conn.mu.
+func (gh *GELFHandler) write(payload []byte) error {
+	if gh.conn.conn == nil {
+		conn, err := net.DialTimeout(gh.opts.Protocol, gh.opts.Address, DefaultHTTPTimeout)
+		if err != nil {
+			return err
+		}
+		gh.conn.conn = conn
+	}
+
+	if gh.opts.Protocol == TCPProtocol {
+		_, err := gh.conn.conn.Write(append(payload, 0))
+		return err
+	}
+
+	chunks, err := GELFChunks(payload, defaultIfZero(gh.opts.ChunkSize, DefaultGELFChunkSize))
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if _, err := gh.conn.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GELFChunks splits the payload into GELF UDP chunks of at most chunkSize bytes.
+// Payloads that fit in a single datagram are returned unchanged.
+func GELFChunks(payload []byte, chunkSize int) ([][]byte, error) {
+	if len(payload) <= chunkSize {
+		return [][]byte{payload}, nil
+	}
+
+	dataSize := chunkSize - gelfChunkHeaderSize
+	if dataSize <= 0 {
+		return nil, fmt.Errorf("invalid gelf chunk size: %d", chunkSize)
+	}
+	count := (len(payload) + dataSize - 1) / dataSize
+	if count > gelfMaxChunks {
+		return nil, fmt.Errorf("gelf message too large: %d chunks", count)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate gelf message id: %w", err)
+	}
+
+	chunks := make([][]byte, 0, count)
+	for i := range count {
+		end := min((i+1)*dataSize, len(payload))
+		chunk := make([]byte, 0, gelfChunkHeaderSize+end-i*dataSize)
+		chunk = append(chunk, gelfMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, payload[i*dataSize:end]...)
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
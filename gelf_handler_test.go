@@ -0,0 +1,156 @@
+package multilog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSyslogSeverity(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  int
+	}{
+		{slog.LevelDebug, 7},
+		{LevelPerf, 7},
+		{slog.LevelInfo, 6},
+		{slog.LevelWarn, 4},
+		{slog.LevelError, 3},
+		{slog.LevelError + 4, 3},
+		{slog.LevelInfo + 1, 6},
+		{slog.LevelDebug - 4, 7},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, GetSyslogSeverity(tt.level), tt.level.String())
+	}
+}
+
+func TestNewGELFHandler_Validation(t *testing.T) {
+	_, err := NewGELFHandler(GELFHandlerOptions{})
+	assert.Error(t, err)
+
+	_, err = NewGELFHandler(GELFHandlerOptions{Address: "localhost:12201", Protocol: "http"})
+	assert.Error(t, err)
+}
+
+func TestGELFChunks(t *testing.T) {
+	payload := []byte(strings.Repeat("x", 100))
+
+	chunks, err := GELFChunks(payload, 200)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{payload}, chunks)
+
+	chunks, err = GELFChunks(payload, 42)
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 4)
+	var joined []byte
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 42)
+		assert.Equal(t, gelfMagic, chunk[:2])
+		assert.Equal(t, chunks[0][2:10], chunk[2:10])
+		assert.Equal(t, byte(i), chunk[10])
+		assert.Equal(t, byte(4), chunk[11])
+		joined = append(joined, chunk[12:]...)
+	}
+	assert.Equal(t, payload, joined)
+
+	_, err = GELFChunks(payload, 12)
+	assert.Error(t, err)
+
+	_, err = GELFChunks(make([]byte, 200*129), 212)
+	assert.Error(t, err)
+}
+
+func TestGELFHandler_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	handler, err := NewGELFHandler(GELFHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		Address: conn.LocalAddr().String(),
+	})
+	assert.NoError(t, err)
+	gh := handler.(*GELFHandler)
+	defer gh.Close()
+
+	logger := NewLogger(handler)
+	logger.WithField("id", 7).Warn("disk low", "free_mb", 12, slog.Group("req", "path", "/x"))
+
+	buf := make([]byte, 8192)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+
+	var msg map[string]any
+	assert.NoError(t, json.Unmarshal(buf[:n], &msg))
+	assert.Equal(t, GELFVersion, msg["version"])
+	assert.Equal(t, "disk low", msg["short_message"])
+	assert.Equal(t, float64(4), msg["level"])
+	assert.Equal(t, float64(12), msg["_free_mb"])
+	assert.Equal(t, float64(7), msg["_id_"])
+	assert.Equal(t, "/x", msg["_req_path"])
+	assert.NotEmpty(t, msg["host"])
+}
+
+func TestGELFHandler_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			msg, err := reader.ReadString(0)
+			if err != nil {
+				return
+			}
+			received <- strings.TrimSuffix(msg, "\x00")
+		}
+	}()
+
+	handler, err := NewGELFHandler(GELFHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		Address:  listener.Addr().String(),
+		Protocol: TCPProtocol,
+	})
+	assert.NoError(t, err)
+	gh := handler.(*GELFHandler)
+	defer gh.Close()
+
+	grouped := gh.WithGroup("http").WithAttrs([]slog.Attr{slog.String("method", "GET")})
+	assert.NoError(t, grouped.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "failed", 0)))
+	assert.NoError(t, gh.SetLevel(ErrorLevel))
+	assert.NoError(t, gh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "skipped", 0)))
+
+	select {
+	case raw := <-received:
+		var msg map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(raw), &msg))
+		assert.Equal(t, "failed", msg["short_message"])
+		assert.Equal(t, float64(3), msg["level"])
+		assert.Equal(t, "GET", msg["_http_method"])
+	case <-time.After(time.Second):
+		t.Fatal("expected gelf message")
+	}
+}
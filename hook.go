@@ -0,0 +1,119 @@
+package multilog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Attribute keys of the built-in hooks
+const (
+	HostKey    = "host"
+	PIDKey     = "pid"
+	VersionKey = "version"
+)
+
+// Hook runs on every record before it is dispatched to the handlers, typically
+// to add attributes such as the host name, the application version or fields
+// computed per record.
+type Hook func(ctx context.Context, record *slog.Record) error
+
+// AddHook returns a new logger running the hooks, in order, on every record
+// before dispatching it to the handlers. Attributes added by hooks are qualified
+// by the groups of the logger, like the attributes of the record.
+func (l *Logger) AddHook(hooks ...Hook) *Logger {
+	return l.Use(HookMiddleware(hooks...))
+}
+
+// HookHandler is a Handler running hooks on records before passing them to the
+// wrapped handler. A hook error is reported to onError and the record is still
+// written.
+type HookHandler struct {
+	onError func(err error)
+	hooks   []Hook
+	wrappedHandler
+}
+
+// NewHookHandler creates a handler running the hooks. A nil onError writes to stderr.
+func NewHookHandler(handler slog.Handler, onError func(err error), hooks ...Hook) *HookHandler {
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	return &HookHandler{
+		onError:        onError,
+		hooks:          hooks,
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// HookMiddleware wraps handlers with NewHookHandler, reporting hook errors to stderr.
+func HookMiddleware(hooks ...Hook) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewHookHandler(next, nil, hooks...)
+	}
+}
+
+// Handle runs the hooks on a copy of the record and writes it.
+func (hh *HookHandler) Handle(ctx context.Context, record slog.Record) error {
+	record = record.Clone()
+	for _, hook := range hh.hooks {
+		if err := hook(ctx, &record); err != nil {
+			hh.onError(fmt.Errorf("hook failed: %w", err))
+		}
+	}
+	return hh.Handler.Handle(ctx, record)
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (hh *HookHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *hh
+	clone.Handler = hh.Handler.WithAttrs(attrs)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (hh *HookHandler) WithGroup(name string) slog.Handler {
+	clone := *hh
+	clone.Handler = hh.Handler.WithGroup(name)
+	return &clone
+}
+
+// AttrsHook returns a hook adding the attributes to every record.
+func AttrsHook(attrs ...slog.Attr) Hook {
+	return func(_ context.Context, record *slog.Record) error {
+		record.AddAttrs(attrs...)
+		return nil
+	}
+}
+
+// HostHook returns a hook adding the host name as the host attribute.
+func HostHook() Hook {
+	return AttrsHook(slog.String(HostKey, hostname()))
+}
+
+// PIDHook returns a hook adding the process id as the pid attribute.
+func PIDHook() Hook {
+	return AttrsHook(slog.Int(PIDKey, pid()))
+}
+
+// VersionHook returns a hook adding the application version as the version
+// attribute. An empty version uses the version of the main module from the
+// build info.
+func VersionHook(version string) Hook {
+	if version == "" {
+		version = buildInfo().Version
+	}
+	return AttrsHook(slog.String(VersionKey, version))
+}
+
+// EnvHook returns a hook adding the value of the environment variable, such as
+// a Kubernetes pod name exposed with the downward API, as the key attribute.
+// Nothing is added when the variable is not set.
+func EnvHook(key, name string) Hook {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return func(context.Context, *slog.Record) error { return nil }
+	}
+	return AttrsHook(slog.String(key, value))
+}
@@ -0,0 +1,83 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordValues returns the attribute values of the record by key.
+func recordValues(r slog.Record) map[string]any {
+	values := map[string]any{}
+	r.Attrs(func(a slog.Attr) bool {
+		values[a.Key] = a.Value.Any()
+		return true
+	})
+	return values
+}
+
+func TestLogger_AddHook(t *testing.T) {
+	recorder := &recordingHandler{}
+	logger := NewLogger(recorder)
+	hooked := logger.AddHook(
+		AttrsHook(slog.String("service", "api")),
+		func(_ context.Context, record *slog.Record) error {
+			record.AddAttrs(slog.Int("msg_len", len(record.Message)))
+			return nil
+		},
+	)
+
+	hooked.With("user", "bob").Info("hello")
+	logger.Info("plain")
+	assert.Len(t, recorder.records, 2)
+	assert.Equal(t, map[string]any{"service": "api", "msg_len": int64(5)}, recordValues(recorder.records[0]))
+	assert.Equal(t, 0, recorder.records[1].NumAttrs())
+}
+
+func TestHookHandler_Error(t *testing.T) {
+	recorder := &recordingHandler{}
+	var reported []error
+	h := NewHookHandler(recorder, func(err error) { reported = append(reported, err) },
+		func(context.Context, *slog.Record) error { return errors.New("no pod") },
+		AttrsHook(slog.String("zone", "eu")),
+	)
+
+	slog.New(h).Info("hello")
+	assert.Len(t, recorder.records, 1)
+	assert.Equal(t, map[string]any{"zone": "eu"}, recordValues(recorder.records[0]))
+	assert.Len(t, reported, 1)
+	assert.EqualError(t, reported[0], "hook failed: no pod")
+}
+
+func TestBuiltinHooks(t *testing.T) {
+	t.Setenv("MULTILOG_TEST_POD", "orders-7d9f")
+	recorder := &recordingHandler{}
+	logger := NewLogger(recorder).AddHook(
+		HostHook(),
+		PIDHook(),
+		VersionHook("1.4.2"),
+		EnvHook("pod", "MULTILOG_TEST_POD"),
+		EnvHook("node", "MULTILOG_TEST_UNSET"),
+	)
+
+	logger.Info("hello")
+	host, err := os.Hostname()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		HostKey:    host,
+		PIDKey:     int64(os.Getpid()),
+		VersionKey: "1.4.2",
+		"pod":      "orders-7d9f",
+	}, recordValues(recorder.records[0]))
+}
+
+func TestVersionHook_BuildInfo(t *testing.T) {
+	recorder := &recordingHandler{}
+	NewLogger(recorder).AddHook(VersionHook("")).Info("hello")
+	assert.Equal(t, ReadBuildInfo().Version, recordValues(recorder.records[0])[VersionKey])
+	assert.NotEmpty(t, ReadBuildInfo().Version)
+}
@@ -0,0 +1,57 @@
+package multilog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultHTTPTimeout is the default timeout for handlers that push over HTTP.
+const DefaultHTTPTimeout = 10 * time.Second
+
+// Content types used by HTTP handlers
+const (
+	ContentTypeJSON   = "application/json"
+	ContentTypeNDJSON = "application/x-ndjson"
+)
+
+// postJSON posts a JSON body and treats any non-2xx status as an error.
+func postJSON(client *http.Client, url string, body []byte, headers map[string]string) error {
+	_, err := post(client, url, ContentTypeJSON, body, headers)
+	return err
+}
+
+// post sends the body and returns the response body.
+// Any non-2xx status is treated as an error.
+func post(
+	client *http.Client,
+	url, contentType string,
+	body []byte,
+	headers map[string]string,
+) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return respBody, &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+	return respBody, nil
+}
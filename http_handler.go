@@ -0,0 +1,184 @@
+package multilog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strings"
+)
+
+// HTTP handler settings
+const (
+	DefaultAPIKeyHeader  = "X-API-Key"
+	NDJSONHandlerSubType = "ndjson"
+)
+
+// HTTPHandler is a Handler that batches JSON records and posts them to an HTTP
+// intake endpoint such as Datadog Logs or Splunk HEC.
+type HTTPHandler struct {
+	Handler *JSONHandler
+	client  *http.Client
+	batch   *batcher[string]
+	headers map[string]string
+	opts    *HTTPHandlerOptions
+}
+
+// HTTPHandlerOptions are the options of HTTP handlers: the formatting and
+// batching options, the URL with its headers and API key, and the compression
+// of the request bodies.
+type HTTPHandlerOptions struct {
+	CustomHandlerOptions
+	BatchOptions
+	Headers          map[string]string
+	URL              string
+	APIKey           string
+	APIKeyHeader     string
+	CompressionLevel int
+	Compress         bool
+}
+
+// NewHTTPHandler creates an HTTP intake Handler with the specified options.
+// Batches are sent as a JSON array, as newline-delimited JSON when SubType is
+// "ndjson", or as a MessagePack or CBOR array for the binary subtypes. If
+// APIKey is set it is sent in the APIKeyHeader header.
+func NewHTTPHandler(opts HTTPHandlerOptions) (slog.Handler, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("http handler requires a url")
+	}
+
+	headers := make(map[string]string, len(opts.Headers)+2)
+	maps.Copy(headers, opts.Headers)
+	if opts.APIKey != "" {
+		headers[defaultIfEmpty(opts.APIKeyHeader, DefaultAPIKeyHeader)] = opts.APIKey
+	}
+	if opts.Compress {
+		headers["Content-Encoding"] = "gzip"
+	}
+
+	hh := &HTTPHandler{
+		Handler: newJSONHandler(opts.CustomHandlerOptions, nil, nil),
+		client:  &http.Client{Timeout: DefaultHTTPTimeout},
+		headers: headers,
+		opts:    &opts,
+	}
+	hh.batch = newBatcher(opts.BatchSize, opts.FlushInterval, opts.MaxBufferSize, hh.post, opts.OnError)
+	return hh, nil
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (hh *HTTPHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return hh.Handler.Enabled(ctx, level)
+}
+
+// Handle serializes the log record and queues it for the next request.
+func (hh *HTTPHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !hh.Enabled(ctx, record.Level) {
+		return nil
+	}
+	doc, err := hh.Handler.Format(ctx, record)
+	if err != nil {
+		return err
+	}
+	return hh.batch.add(ctx, doc)
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (hh *HTTPHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *hh
+	clone.Handler = &JSONHandler{
+		Handler:     hh.Handler.Handler.WithAttrs(attrs).(CustomHandlerInterface),
+		replaceAttr: hh.Handler.replaceAttr,
+		mu:          hh.Handler.mu,
+	}
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (hh *HTTPHandler) WithGroup(name string) slog.Handler {
+	clone := *hh
+	clone.Handler = &JSONHandler{
+		Handler:     hh.Handler.Handler.WithGroup(name).(CustomHandlerInterface),
+		replaceAttr: hh.Handler.replaceAttr,
+		mu:          hh.Handler.mu,
+	}
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (hh *HTTPHandler) SetLevel(level string) error {
+	return hh.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (hh *HTTPHandler) GetLevel() string {
+	return hh.Handler.GetLevel()
+}
+
+// Flush sends all queued records.
+func (hh *HTTPHandler) Flush() error {
+	return hh.batch.Flush()
+}
+
+// Close sends all queued records and stops the background flush loop.
+func (hh *HTTPHandler) Close() error {
+	return hh.batch.Close()
+}
+
+// customHandler implements customHandlerProvider.
+func (hh *HTTPHandler) customHandler() CustomHandlerInterface {
+	return hh.Handler.Handler
+}
+
+// post sends a batch of documents, retrying with jitter on failure.
+func (hh *HTTPHandler) post(docs []string) error {
+	body, contentType, err := hh.encode(docs)
+	if err != nil {
+		return err
+	}
+	policy := hh.opts.retryPolicy()
+	policy.Jitter = true
+	return policy.Do(func() error {
+		_, err := post(hh.client, hh.opts.URL, contentType, body, hh.headers)
+		return err
+	})
+}
+
+// encode builds the request body for the batch.
+func (hh *HTTPHandler) encode(docs []string) ([]byte, string, error) {
+	var payload string
+	contentType := ContentTypeJSON
+	switch hh.opts.SubType {
+	case NDJSONHandlerSubType:
+		payload = strings.Join(docs, "\n") + "\n"
+		contentType = ContentTypeNDJSON
+	case MsgpackHandlerSubType:
+		payload = string(msgpackFormat{}.appendArrayHeader(nil, len(docs))) + strings.Join(docs, "")
+		contentType = ContentTypeMsgpack
+	case CBORHandlerSubType:
+		payload = string(cborFormat{}.appendArrayHeader(nil, len(docs))) + strings.Join(docs, "")
+		contentType = ContentTypeCBOR
+	default:
+		payload = "[" + strings.Join(docs, ",") + "]"
+	}
+
+	if !hh.opts.Compress {
+		return []byte(payload), contentType, nil
+	}
+
+	var buf bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&buf, compressionLevel(hh.opts.CompressionLevel))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to compress payload: %w", err)
+	}
+	if _, err := gz.Write([]byte(payload)); err != nil {
+		return nil, "", fmt.Errorf("failed to compress payload: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to compress payload: %w", err)
+	}
+	return buf.Bytes(), contentType, nil
+}
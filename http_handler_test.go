@@ -0,0 +1,140 @@
+package multilog
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type intakeRequest struct {
+	header http.Header
+	body   string
+}
+
+type intakeServer struct {
+	*httptest.Server
+	requests []intakeRequest
+	mu       sync.Mutex
+	fail     int
+}
+
+func newIntakeServer(t *testing.T) *intakeServer {
+	t.Helper()
+	is := &intakeServer{}
+	is.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.mu.Lock()
+		defer is.mu.Unlock()
+		if is.fail > 0 {
+			is.fail--
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			reader = gz
+		}
+		body, _ := io.ReadAll(reader)
+		is.requests = append(is.requests, intakeRequest{header: r.Header.Clone(), body: string(body)})
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(is.Close)
+	return is
+}
+
+func TestNewHTTPHandler_RequiresURL(t *testing.T) {
+	_, err := NewHTTPHandler(HTTPHandlerOptions{})
+	assert.Error(t, err)
+}
+
+func TestHTTPHandler_JSONArray(t *testing.T) {
+	server := newIntakeServer(t)
+	handler, err := NewHTTPHandler(HTTPHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:               InfoLevel,
+			Enabled:             true,
+			PatternPlaceholders: []string{"[level]", "[msg]"},
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		URL:          server.URL,
+		APIKey:       "secret",
+		APIKeyHeader: "DD-API-KEY",
+		Headers:      map[string]string{"X-Source": "test"},
+		Compress:     true,
+	})
+	assert.NoError(t, err)
+
+	logger := NewLogger(handler)
+	logger.Info("first", "service", "api")
+	logger.Warn("second")
+	assert.NoError(t, handler.(*HTTPHandler).Close())
+
+	assert.Len(t, server.requests, 1)
+	req := server.requests[0]
+	assert.Equal(t, "secret", req.header.Get("DD-API-KEY"))
+	assert.Equal(t, "test", req.header.Get("X-Source"))
+	assert.Equal(t, ContentTypeJSON, req.header.Get("Content-Type"))
+
+	var docs []map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(req.body), &docs))
+	assert.Len(t, docs, 2)
+	assert.Equal(t, "first", docs[0]["msg"])
+	assert.Equal(t, "api", docs[0]["service"])
+	assert.Equal(t, "WARN", docs[1]["level"])
+}
+
+func TestHTTPHandler_NDJSONWithRetry(t *testing.T) {
+	server := newIntakeServer(t)
+	server.fail = 1
+	handler, err := NewHTTPHandler(HTTPHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			SubType: NDJSONHandlerSubType,
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+			MaxRetries:    2,
+			RetryBackoff:  time.Millisecond,
+		},
+		URL:    server.URL,
+		APIKey: "token",
+	})
+	assert.NoError(t, err)
+
+	logger := NewLogger(handler).WithField("request_id", "abc")
+	logger.Info("one")
+	logger.Info("two")
+	assert.NoError(t, handler.(*HTTPHandler).Flush())
+
+	assert.Len(t, server.requests, 1)
+	req := server.requests[0]
+	assert.Equal(t, "token", req.header.Get(DefaultAPIKeyHeader))
+	assert.Equal(t, ContentTypeNDJSON, req.header.Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(req.body), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], `"request_id":"abc"`)
+	assert.NoError(t, handler.(*HTTPHandler).Close())
+}
+
+func TestValidateHandler_HTTPSubType(t *testing.T) {
+	handler := &HandlerConfig{Type: HTTPHandlerType, Level: InfoLevel, URL: "http://x", SubType: "xml"}
+	assert.Error(t, validateHandler(handler))
+
+	handler.SubType = NDJSONHandlerSubType
+	assert.NoError(t, validateHandler(handler))
+}
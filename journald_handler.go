@@ -0,0 +1,243 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultJournaldSocket is the journald native protocol socket.
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// journaldMaxFieldName is the maximum length of a journal field name.
+const journaldMaxFieldName = 64
+
+// journaldConn is the connection shared by a journald handler and its derived handlers.
+type journaldConn struct {
+	conn *net.UnixConn
+	mu   sync.Mutex
+}
+
+// JournaldHandler is a Handler that sends records to the systemd journal
+// using the native protocol.
+type JournaldHandler struct {
+	Handler    *CustomHandler
+	conn       *journaldConn
+	opts       *JournaldHandlerOptions
+	identifier string
+	groups     string
+	attrs      []slog.Attr
+}
+
+// JournaldHandlerOptions are the options of journald handlers: the formatting
+// options, the socket address, and the syslog identifier.
+type JournaldHandlerOptions struct {
+	CustomHandlerOptions
+	Address          string
+	SyslogIdentifier string
+}
+
+// NewJournaldHandler creates a journald Handler with the specified options.
+// Records are sent to opts.Address, or the default journal socket, with the
+// level mapped to PRIORITY and attributes as upper-case fields. Records that
+// exceed the socket's datagram size are reported as errors.
+func NewJournaldHandler(opts JournaldHandlerOptions) (slog.Handler, error) {
+	opts.Address = defaultIfEmpty(opts.Address, DefaultJournaldSocket)
+	identifier := opts.SyslogIdentifier
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+
+	return &JournaldHandler{
+		Handler:    NewCustomHandler(&opts.CustomHandlerOptions, bufio.NewWriter(io.Discard), nil),
+		conn:       &journaldConn{},
+		opts:       &opts,
+		identifier: identifier,
+	}, nil
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (jh *JournaldHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return jh.Handler.Enabled(ctx, level)
+}
+
+// Handle encodes the log record as a journal entry and sends it.
+func (jh *JournaldHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !jh.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return jh.send(jh.buildEntry(record))
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (jh *JournaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *jh
+	clone.attrs = append([]slog.Attr{}, jh.attrs...)
+	for _, a := range attrs {
+		a.Key = jh.groups + a.Key
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (jh *JournaldHandler) WithGroup(name string) slog.Handler {
+	clone := *jh
+	clone.groups = jh.groups + name + "_"
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (jh *JournaldHandler) SetLevel(level string) error {
+	return jh.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (jh *JournaldHandler) GetLevel() string {
+	return jh.Handler.GetLevel()
+}
+
+// Close closes the underlying connection.
+func (jh *JournaldHandler) Close() error {
+	jh.conn.mu.Lock()
+	defer jh.conn.mu.Unlock()
+	if jh.conn.conn == nil {
+		return nil
+	}
+	err := jh.conn.conn.Close()
+	jh.conn.conn = nil
+	return err
+}
+
+// customHandler implements customHandlerProvider.
+func (jh *JournaldHandler) customHandler() CustomHandlerInterface {
+	return jh.Handler
+}
+
+// buildEntry encodes the record as a native protocol journal entry.
+func (jh *JournaldHandler) buildEntry(record slog.Record) []byte {
+	var buf bytes.Buffer
+	appendJournalField(&buf, "MESSAGE", record.Message)
+	appendJournalField(&buf, "PRIORITY", strconv.Itoa(GetSyslogSeverity(record.Level)))
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", jh.identifier)
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		if frame.File != "" {
+			appendJournalField(&buf, "CODE_FILE", frame.File)
+			appendJournalField(&buf, "CODE_LINE", strconv.Itoa(frame.Line))
+			appendJournalField(&buf, "CODE_FUNC", frame.Function)
+		}
+	}
+	for _, a := range jh.attrs {
+		addJournalAttr(&buf, "", a)
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		addJournalAttr(&buf, jh.groups, a)
+		return true
+	})
+	return buf.Bytes()
+}
+
+// addJournalAttr adds the attribute as a journal field, flattening groups.
+func addJournalAttr(buf *bytes.Buffer, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix += a.Key + "_"
+		}
+		for _, ga := range a.Value.Group() {
+			addJournalAttr(buf, groupPrefix, ga)
+		}
+		return
+	}
+	if name := JournalFieldName(prefix + a.Key); name != "" {
+		appendJournalField(buf, name, a.Value.String())
+	}
+}
+
+// JournalFieldName converts a key to a valid journal field name: upper-case
+// letters, digits and underscores, not starting with an underscore or digit
+// and at most 64 characters. It returns "" if nothing valid remains.
+func JournalFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	trimmed := strings.TrimLeft(string(name), "_0123456789")
+	if len(trimmed) > journaldMaxFieldName {
+		trimmed = trimmed[:journaldMaxFieldName]
+	}
+	return trimmed
+}
+
+// appendJournalField appends a field in the native protocol format. Values
+// containing newlines are written with their little-endian 64-bit length.
+func appendJournalField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// send writes the entry, dialing or redialing the socket as needed.
+func (jh *JournaldHandler) send(entry []byte) error {
+	jh.conn.mu.Lock()
+	defer jh.conn.mu.Unlock()
+
+	if err := jh.write(entry); err != nil {
+		// Drop the connection and retry once with a fresh one.
+		if jh.conn.conn != nil {
+			_ = jh.conn.conn.Close()
+			jh.conn.conn = nil
+		}
+		if err := jh.write(entry); err != nil {
+			return fmt.Errorf("failed to send journal entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// write sends the entry on the current connection; the caller must hold jh.conn.mu.
+func (jh *JournaldHandler) write(entry []byte) error {
+	if jh.conn.conn == nil {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: jh.opts.Address, Net: "unixgram"})
+		if err != nil {
+			return err
+		}
+		jh.conn.conn = conn
+	}
+	_, err := jh.conn.conn.Write(entry)
+	return err
+}
+
+// appendPriorityPrefix appends the output with every line prefixed by the
+// syslog priority of the level, as in <3> for error, so that systemd assigns
+// the priority to lines a service writes to stdout or stderr.
+func appendPriorityPrefix(buf []byte, level slog.Level, output []byte) []byte {
+	prefix := "<" + strconv.Itoa(GetSyslogSeverity(level)) + ">"
+	for line := range bytes.Lines(output) {
+		buf = append(append(buf, prefix...), line...)
+	}
+	return buf
+}
@@ -0,0 +1,126 @@
+package multilog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalFieldName(t *testing.T) {
+	tests := map[string]string{
+		"user":     "USER",
+		"req.path": "REQ_PATH",
+		"_private": "PRIVATE",
+		"1st-try":  "ST_TRY",
+		"__":       "",
+	}
+	for key, want := range tests {
+		assert.Equal(t, want, JournalFieldName(key), key)
+	}
+
+	long := JournalFieldName("a" + string(bytes.Repeat([]byte("b"), 80)))
+	assert.Len(t, long, 64)
+}
+
+func TestAppendJournalField(t *testing.T) {
+	var buf bytes.Buffer
+	appendJournalField(&buf, "MESSAGE", "hello")
+	assert.Equal(t, "MESSAGE=hello\n", buf.String())
+
+	buf.Reset()
+	appendJournalField(&buf, "MESSAGE", "a\nb")
+	want := []byte("MESSAGE\n")
+	want = binary.LittleEndian.AppendUint64(want, 3)
+	want = append(want, "a\nb\n"...)
+	assert.Equal(t, want, buf.Bytes())
+}
+
+func TestJournaldHandler(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	handler, err := NewJournaldHandler(JournaldHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		Address:          socket,
+		SyslogIdentifier: "myapp",
+	})
+	assert.NoError(t, err)
+	jh := handler.(*JournaldHandler)
+	defer jh.Close()
+
+	slog.New(handler).With("user", "alice").Warn("disk low", "free_mb", 12, slog.Group("req", "path", "/x"))
+
+	buf := make([]byte, 8192)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	assert.NoError(t, err)
+
+	entry := string(buf[:n])
+	assert.Contains(t, entry, "MESSAGE=disk low\n")
+	assert.Contains(t, entry, "PRIORITY=4\n")
+	assert.Contains(t, entry, "SYSLOG_IDENTIFIER=myapp\n")
+	assert.Contains(t, entry, "CODE_FILE=")
+	assert.Contains(t, entry, "journald_handler_test.go\n")
+	assert.Contains(t, entry, "CODE_LINE=")
+	assert.Contains(t, entry, "USER=alice\n")
+	assert.Contains(t, entry, "FREE_MB=12\n")
+	assert.Contains(t, entry, "REQ_PATH=/x\n")
+
+	grouped := jh.WithGroup("http").WithAttrs([]slog.Attr{slog.String("method", "GET")})
+	assert.NoError(t, grouped.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "failed", 0)))
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err = conn.Read(buf)
+	assert.NoError(t, err)
+	entry = string(buf[:n])
+	assert.Contains(t, entry, "PRIORITY=3\n")
+	assert.Contains(t, entry, "HTTP_METHOD=GET\n")
+	assert.NotContains(t, entry, "CODE_FILE=")
+
+	assert.NoError(t, jh.SetLevel(ErrorLevel))
+	assert.Equal(t, ErrorLevel, jh.GetLevel())
+	assert.NoError(t, jh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "skipped", 0)))
+}
+
+func TestJournaldHandler_NoSocket(t *testing.T) {
+	handler, err := NewJournaldHandler(JournaldHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		Address: filepath.Join(t.TempDir(), "missing.sock"),
+	})
+	assert.NoError(t, err)
+	err = handler.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "lost", 0))
+	assert.Error(t, err)
+	assert.NoError(t, handler.(*JournaldHandler).Close())
+}
+
+func TestCreateHandler_Journald(t *testing.T) {
+	cfg := &Config{}
+	handlerCfg := HandlerConfig{
+		Type:             JournaldHandlerType,
+		Level:            InfoLevel,
+		Enabled:          true,
+		SyslogIdentifier: "myapp",
+	}
+	opts, err := cfg.GetCustomHandlerOptionsForHandler(handlerCfg)
+	assert.NoError(t, err)
+
+	handler, err := createHandler(&handlerCfg, opts)
+	assert.NoError(t, err)
+	jh := handler.(*JournaldHandler)
+	assert.Equal(t, "myapp", jh.identifier)
+	assert.Equal(t, DefaultJournaldSocket, jh.opts.Address)
+}
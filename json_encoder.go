@@ -0,0 +1,425 @@
+package multilog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// appendRecord renders the record as a JSON document directly from its fields
+// and attributes. The document holds the same members as format produces with
+// the default attribute replacement, without the round trip through the slog
+// JSON handler and encoding/json: the placeholders first, in pattern order,
+// then the level, message and source if not placed, and the attributes.
+func (jh *JSONHandler) appendRecord(ctx context.Context, buf []byte, ch *CustomHandler, record slog.Record) []byte {
+	opts := ch.Opts
+	placeholders, custom := jsonPlaceholders(opts)
+	attrs := getBuffer()
+	defer putBuffer(attrs)
+	var fields map[string]string
+	*attrs, fields = jh.appendAttrs(*attrs, ch, record, custom)
+
+	members := jsonMembers{
+		level:  levelLabel(record.Level, opts.UseSingleLetterLevel),
+		source: recordSource(opts, record),
+		fields: fields,
+	}
+	buf = append(buf, '{')
+	buf = members.appendPlaceholders(buf, opts, record, placeholders)
+	buf = members.appendUnplaced(buf, record)
+	buf = appendJSONMembers(buf, *attrs)
+	if !members.placedPerf && record.Level == LevelPerf {
+		buf = appendJSONPerf(buf, opts)
+	}
+	if stacktraceEnabled(opts, record.Level) {
+		buf = appendJSONString(appendJSONKey(buf, StacktraceKey), recordStacktrace(ctx, record.PC))
+	}
+	return append(buf, '}')
+}
+
+// jsonPlaceholders returns the pattern placeholders of the options, or the
+// default ones, and the keys of the custom placeholders among them.
+func jsonPlaceholders(opts *CustomHandlerOptions) (placeholders, custom []string) {
+	placeholders = opts.PatternPlaceholders
+	if len(placeholders) == 0 {
+		placeholders = DefaultPatternPlaceholders
+	}
+	for _, placeholder := range placeholders {
+		if !builtinPlaceholders[placeholder] {
+			custom = append(custom, placeholder[1:len(placeholder)-1])
+		}
+	}
+	return placeholders, custom
+}
+
+// jsonMembers holds the rendered level and source of a record, the values of
+// its custom placeholders, and which of the default members the pattern placed.
+type jsonMembers struct {
+	level, source                                    string
+	fields                                           map[string]string
+	placedLevel, placedMsg, placedSource, placedPerf bool
+}
+
+// appendPlaceholders appends the members of the placeholders in pattern order.
+func (m *jsonMembers) appendPlaceholders(
+	buf []byte,
+	opts *CustomHandlerOptions,
+	record slog.Record,
+	placeholders []string,
+) []byte {
+	for _, placeholder := range placeholders {
+		key := placeholder[1 : len(placeholder)-1]
+		switch placeholder {
+		case DatePlaceholder, TimePlaceholder, DateTimePlaceholder:
+			buf = appendJSONKey(buf, key)
+			buf = append(buf, '"')
+			buf = recordTime(opts, record.Time).AppendFormat(buf, timeLayout(opts, placeholder))
+			buf = append(buf, '"')
+		case LevelPlaceholder:
+			buf = appendJSONString(appendJSONKey(buf, slog.LevelKey), m.level)
+			m.placedLevel = true
+		case MsgPlaceholder:
+			buf = appendJSONString(appendJSONKey(buf, slog.MessageKey), record.Message)
+			m.placedMsg = true
+		case PerfPlaceholder:
+			buf = appendJSONPerf(buf, opts)
+			m.placedPerf = true
+		case SourcePlaceholder:
+			buf = appendJSONString(appendJSONKey(buf, slog.SourceKey), resolveSourceValue(opts, record, m.source))
+			m.placedSource = true
+		case HostPlaceholder, PIDPlaceholder, GoroutinePlaceholder:
+			buf = appendJSONProcess(buf, placeholder)
+		case VersionPlaceholder, RevisionPlaceholder:
+			if v := buildValue(opts, placeholder); v != "" {
+				buf = appendJSONString(appendJSONKey(buf, key), v)
+			}
+		default:
+			if v := m.fields[key]; v != "" {
+				buf = appendJSONString(appendJSONKey(buf, key), v)
+			}
+		}
+	}
+	return buf
+}
+
+// appendUnplaced appends the level, message and source unless placed.
+func (m *jsonMembers) appendUnplaced(buf []byte, record slog.Record) []byte {
+	if !m.placedLevel {
+		buf = appendJSONString(appendJSONKey(buf, slog.LevelKey), m.level)
+	}
+	if !m.placedMsg {
+		buf = appendJSONString(appendJSONKey(buf, slog.MessageKey), record.Message)
+	}
+	if !m.placedSource && m.source != "" {
+		buf = appendJSONString(appendJSONKey(buf, slog.SourceKey), m.source)
+	}
+	return buf
+}
+
+// appendJSONMembers appends rendered members to the open object, separating
+// them from previous members with a comma.
+func appendJSONMembers(buf, members []byte) []byte {
+	if len(members) == 0 {
+		return buf
+	}
+	if buf[len(buf)-1] != '{' {
+		buf = append(buf, ',')
+	}
+	return append(buf, members...)
+}
+
+// appendAttrs appends the handler and record attributes as JSON members,
+// nesting them in the groups of the handler. Top-level attributes named after
+// custom placeholders are returned by key instead, to be placed by the pattern.
+func (jh *JSONHandler) appendAttrs(
+	buf []byte,
+	ch *CustomHandler,
+	record slog.Record,
+	custom []string,
+) ([]byte, map[string]string) {
+	var fields map[string]string
+	var take func(groups []string, a slog.Attr) bool
+	if len(custom) > 0 {
+		fields = make(map[string]string, len(custom))
+		take = func(groups []string, a slog.Attr) bool {
+			if len(groups) > 0 || !Contains(custom, a.Key) {
+				return false
+			}
+			if a.Value.Kind() == slog.KindString {
+				fields[a.Key] = a.Value.String()
+			} else {
+				fields[a.Key] = string(appendTextValue(nil, a.Value))
+			}
+			return true
+		}
+	}
+
+	// Groups are opened when their first attribute is appended, so that
+	// groups without attributes are elided.
+	open := 0
+	appendAttr := func(groups []string, a slog.Attr) {
+		start, opened := len(buf), open
+		for ; open < len(groups); open++ {
+			buf = append(appendJSONKey(buf, groups[open]), '{')
+		}
+		n := len(buf)
+		buf = appendJSONAttr(buf, groups, a, jh.replaceAttr, take)
+		if len(buf) == n {
+			buf, open = buf[:start], opened
+		}
+	}
+	for _, b := range ch.boundAttrs {
+		appendAttr(b.groups, b.attr)
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		appendAttr(ch.groups, a)
+		return true
+	})
+	for ; open > 0; open-- {
+		buf = append(buf, '}')
+	}
+	return buf, fields
+}
+
+// appendJSONPerf appends the perf metrics, as an object for structured perf.
+func appendJSONPerf(buf []byte, opts *CustomHandlerOptions) []byte {
+	if opts.StructuredPerf {
+		return appendJSONAttr(buf, nil, slog.Any(PerfKey, perfMetricsGroup(opts)), nil, nil)
+	}
+	return appendJSONString(appendJSONKey(buf, PerfKey), formatPerfMetrics(opts))
+}
+
+// appendJSONAttr appends the attribute as a JSON object member, separating it
+// from previous members with a comma. It mirrors slog.JSONHandler: values are
+// resolved, replaceAttr is applied to non-group attributes, empty attributes
+// and groups are elided, and groups become nested objects. The take hook is
+// offered every non-group attribute before it is appended; attributes it takes
+// are skipped.
+func appendJSONAttr(
+	buf []byte,
+	groups []string,
+	a slog.Attr,
+	replaceAttr CustomReplaceAttr,
+	take func(groups []string, a slog.Attr) bool,
+) []byte {
+	a.Value = a.Value.Resolve()
+	if replaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = replaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Key == "" && a.Value.Kind() == slog.KindAny && a.Value.Any() == nil {
+		return buf
+	}
+	if a.Value.Kind() == slog.KindAny {
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			a.Value = sourceGroup(src)
+		}
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if a.Key == "" {
+			for _, ga := range attrs {
+				buf = appendJSONAttr(buf, groups, ga, replaceAttr, take)
+			}
+			return buf
+		}
+		start := len(buf)
+		buf = append(appendJSONKey(buf, a.Key), '{')
+		body := len(buf)
+		groups = append(groups[:len(groups):len(groups)], a.Key)
+		for _, ga := range attrs {
+			buf = appendJSONAttr(buf, groups, ga, replaceAttr, take)
+		}
+		if len(buf) == body {
+			return buf[:start]
+		}
+		return append(buf, '}')
+	}
+	if take != nil && take(groups, a) {
+		return buf
+	}
+	return appendJSONValue(appendJSONKey(buf, a.Key), a.Value)
+}
+
+// sourceGroup returns the source as the group slog.JSONHandler renders it.
+func sourceGroup(src *slog.Source) slog.Value {
+	var attrs []slog.Attr
+	if src.Function != "" {
+		attrs = append(attrs, slog.String("function", src.Function))
+	}
+	if src.File != "" {
+		attrs = append(attrs, slog.String("file", src.File))
+	}
+	if src.Line != 0 {
+		attrs = append(attrs, slog.Int("line", src.Line))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// appendJSONKey appends the quoted key and a colon, preceded by a comma unless
+// it is the first member of the object.
+func appendJSONKey(buf []byte, key string) []byte {
+	if len(buf) > 0 && buf[len(buf)-1] != '{' {
+		buf = append(buf, ',')
+	}
+	return append(appendJSONString(buf, key), ':')
+}
+
+// appendJSONValue appends the value as slog.JSONHandler renders it. Values
+// that cannot be encoded are rendered as an "!ERROR:" string.
+func appendJSONValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendJSONString(buf, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		return appendJSONFloat(buf, v.Float64())
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool())
+	case slog.KindDuration:
+		return strconv.AppendInt(buf, int64(v.Duration()), 10)
+	case slog.KindTime:
+		t := v.Time()
+		if y := t.Year(); y < 0 || y >= 10000 {
+			return appendJSONError(buf, errors.New("time.Time year outside of range [0,9999]"))
+		}
+		buf = append(buf, '"')
+		buf = t.AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, '"')
+	default:
+		value := v.Any()
+		if err, ok := value.(error); ok {
+			if _, marshaler := value.(json.Marshaler); !marshaler {
+				return appendJSONString(buf, err.Error())
+			}
+		}
+		return appendJSONMarshal(buf, value)
+	}
+}
+
+// appendJSONFloat appends the float as encoding/json does.
+func appendJSONFloat(buf []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONError(buf, fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, 64)))
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, 64)
+	if format == 'e' {
+		// Shorten e-09 to e-9, as encoding/json does.
+		if n := len(buf); n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf
+}
+
+// appendJSONMarshal appends the value encoded with encoding/json, without
+// escaping HTML characters.
+func appendJSONMarshal(buf []byte, value any) []byte {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return appendJSONError(buf, err)
+	}
+	return append(buf, bytes.TrimSuffix(b.Bytes(), []byte("\n"))...)
+}
+
+// appendJSONError appends the error as slog.JSONHandler does for values it cannot encode.
+func appendJSONError(buf []byte, err error) []byte {
+	return appendJSONString(buf, "!ERROR:"+err.Error())
+}
+
+// appendJSONString appends the string as a quoted JSON string, escaping
+// quotes, backslashes, control characters, invalid UTF-8 and the line and
+// paragraph separators like slog.JSONHandler.
+func appendJSONString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				buf = append(buf, '\\', b)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if c == '\u2028' || c == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
+// appendPrettyJSON appends the document with the keys of every object sorted,
+// and indented by two spaces per level.
+func appendPrettyJSON(buf, doc []byte, indent, sortKeys bool) ([]byte, error) {
+	if sortKeys {
+		decoder := json.NewDecoder(bytes.NewReader(doc))
+		decoder.UseNumber()
+		var value map[string]any
+		if err := decoder.Decode(&value); err != nil {
+			return buf, fmt.Errorf("failed to sort JSON keys: %w", err)
+		}
+		var out bytes.Buffer
+		encoder := json.NewEncoder(&out)
+		encoder.SetEscapeHTML(false)
+		if indent {
+			encoder.SetIndent("", "  ")
+		}
+		if err := encoder.Encode(value); err != nil {
+			return buf, fmt.Errorf("failed to sort JSON keys: %w", err)
+		}
+		return append(buf, bytes.TrimSuffix(out.Bytes(), []byte("\n"))...), nil
+	}
+	out := bytes.NewBuffer(buf)
+	if err := json.Indent(out, doc, "", "  "); err != nil {
+		return buf, fmt.Errorf("failed to indent JSON: %w", err)
+	}
+	return out.Bytes(), nil
+}
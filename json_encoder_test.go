@@ -0,0 +1,162 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newJSONHandlerPair returns a handler encoding records directly and one
+// formatting them through the slog JSON handler, with the same options.
+func newJSONHandlerPair(opts CustomHandlerOptions, direct, slow io.Writer) (*slog.Logger, *slog.Logger) {
+	replaceAttr := GenerateDefaultCustomReplaceAttr(opts, slog.TimeKey)
+	return slog.New(newJSONHandler(opts, bufio.NewWriter(direct), nil)),
+		slog.New(newJSONHandler(opts, bufio.NewWriter(slow), replaceAttr))
+}
+
+// decodeLines decodes each line of the output as a JSON object.
+func decodeLines(t *testing.T, output string) []map[string]any {
+	t.Helper()
+	var docs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var doc map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &doc), line)
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func TestAppendJSONRecord_MatchesJSONHandler(t *testing.T) {
+	placeholderSets := [][]string{
+		nil,
+		{"[level]", "[msg]"},
+		{"[time]", "[msg]"},
+		{"[date]", "[level]", "[custom]", "[msg]"},
+		{"[datetime]", "[level]", "[source]", "[msg]", "[perf]"},
+	}
+	for _, placeholders := range placeholderSets {
+		for _, addSource := range []bool{false, true} {
+			opts := CustomHandlerOptions{
+				Level:                DebugLevel,
+				Enabled:              true,
+				PatternPlaceholders:  placeholders,
+				AddSource:            addSource,
+				UseSingleLetterLevel: addSource,
+				PerfMetrics:          []string{"goroutines"},
+			}
+			direct, slow := &bytes.Buffer{}, &bytes.Buffer{}
+			directLogger, slowLogger := newJSONHandlerPair(opts, direct, slow)
+			for _, logger := range []*slog.Logger{directLogger, slowLogger} {
+				logger.Info("hello world",
+					"int", 1, "string", "x=y", "float", 1.5, "bool", true, "duration", time.Second,
+					"bytes", []byte("hi"), "text", spacedText{}, "err", errors.New("boom"),
+					"time", "dropped", "custom", "placed", "quote", `with"quote`, "unicode", "ü",
+					"at", time.Date(2020, 1, 2, 3, 4, 5, 6e6, time.UTC), "level", slog.LevelWarn)
+				logger.With("with", "v").WithGroup("g").With("h", 2).
+					Warn("warned", slog.Group("sub", "k", "v"), slog.Group("empty"), "nil", nil)
+				logger.WithGroup("a").With("k1", 1).WithGroup("b").WithGroup("unused").Error("nested", "k2", 2)
+				logger.WithGroup("unused").Debug("")
+				logger.Log(context.Background(), LevelPerf, "metrics")
+			}
+			assert.Equal(t, decodeLines(t, slow.String()), decodeLines(t, direct.String()),
+				"placeholders %v, source %v", placeholders, addSource)
+		}
+	}
+}
+
+func TestAppendJSONRecord_Order(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := CustomHandlerOptions{Level: InfoLevel, Enabled: true, PatternPlaceholders: []string{"[msg]", "[level]"}}
+	logger := slog.New(newJSONHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.With("service", "api").WithGroup("req").Info("served", "id", 7, "path", "/a<b>")
+
+	assert.Equal(t, `{"msg":"served","level":"INFO","service":"api","req":{"id":7,"path":"/a<b>"}}`+"\n", buf.String())
+}
+
+func TestJSONHandler_IndentAndSortKeys(t *testing.T) {
+	tests := []struct {
+		name             string
+		want             string
+		indent, sortKeys bool
+	}{
+		{"indent", "{\n  \"msg\": \"served\",\n  \"level\": \"INFO\",\n  \"req\": {\n    \"path\": \"/a<b>\",\n" +
+			"    \"id\": 7\n  }\n}\n", true, false},
+		{"sort", `{"level":"INFO","msg":"served","req":{"id":7,"path":"/a<b>"}}` + "\n", false, true},
+		{"both", "{\n  \"level\": \"INFO\",\n  \"msg\": \"served\",\n  \"req\": {\n    \"id\": 7,\n" +
+			"    \"path\": \"/a<b>\"\n  }\n}\n", true, true},
+	}
+	for _, tt := range tests {
+		opts := CustomHandlerOptions{Level: InfoLevel, Enabled: true, PatternPlaceholders: []string{"[msg]", "[level]"},
+			JSONIndent: tt.indent, JSONSortKeys: tt.sortKeys}
+		direct, slow := &bytes.Buffer{}, &bytes.Buffer{}
+		directLogger, slowLogger := newJSONHandlerPair(opts, direct, slow)
+		for _, logger := range []*slog.Logger{directLogger, slowLogger} {
+			logger.WithGroup("req").Info("served", "path", "/a<b>", "id", 7)
+		}
+
+		assert.Equal(t, tt.want, direct.String(), tt.name)
+		if tt.sortKeys {
+			assert.Equal(t, tt.want, slow.String(), tt.name)
+		}
+	}
+}
+
+func TestAppendJSONString(t *testing.T) {
+	tests := []string{"plain", `quo"te\`, "tab\tnew\nline\r", "\x00\x1f", "ü \u20ac", "\xff", "\u2028", "<&>"}
+	for _, s := range tests {
+		got := appendJSONString(nil, s)
+		var decoded string
+		assert.NoError(t, json.Unmarshal(got, &decoded), string(got))
+		assert.Equal(t, strings.ToValidUTF8(s, "\ufffd"), decoded)
+	}
+	assert.Equal(t, `"\u2028\u0001\ufffd"`, string(appendJSONString(nil, "\u2028\x01\xff")))
+}
+
+func TestAppendJSONValue(t *testing.T) {
+	tests := []struct {
+		value slog.Value
+		want  string
+	}{
+		{slog.Float64Value(1e21), "1e+21"},
+		{slog.Float64Value(1e-7), "1e-7"},
+		{slog.Float64Value(0.000001), "0.000001"},
+		{slog.Float64Value(math.NaN()), `"!ERROR:json: unsupported value: NaN"`},
+		{slog.Uint64Value(math.MaxUint64), "18446744073709551615"},
+		{slog.Int64Value(1 << 60), "1152921504606846976"},
+		{slog.TimeValue(time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)),
+			`"!ERROR:time.Time year outside of range [0,9999]"`},
+		{slog.AnyValue(map[string]int{"a": 1}), `{"a":1}`},
+		{slog.AnyValue(func() {}), `"!ERROR:json: unsupported type: func()"`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, string(appendJSONValue(nil, tt.value)))
+	}
+}
+
+func BenchmarkJSONHandler_Handle(b *testing.B) {
+	opts := CustomHandlerOptions{Level: InfoLevel, Enabled: true, PatternPlaceholders: []string{"[time]", "[level]", "[msg]"}}
+	direct, slow := newJSONHandlerPair(opts, io.Discard, io.Discard)
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "request handled", 0)
+	record.AddAttrs(slog.String("method", "GET"), slog.Int("status", 200), slog.Duration("took", time.Millisecond))
+
+	for name, logger := range map[string]*slog.Logger{"direct": direct, "json_handler": slow} {
+		handler := logger.Handler().WithAttrs([]slog.Attr{slog.String("service", "api")})
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_ = handler.Handle(context.Background(), record)
+			}
+		})
+	}
+}
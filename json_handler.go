@@ -1,9 +1,12 @@
 package multilog
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"strings"
 	"sync"
@@ -12,15 +15,59 @@ import (
 // JSONHandler is a Handler for JSON logging.
 type JSONHandler struct {
 	Handler CustomHandlerInterface
+	rotator rotationWriter
+	// replaceAttr is set when records are encoded directly from the record
+	// rather than through the slog JSON handler; see appendRecord.
+	replaceAttr CustomReplaceAttr
+	flusher     *periodicFlusher
+	syncer      *periodicFlusher
+	archiver    *Archiver
+	// mu serializes formatting and writing for handlers that are not a
+	// *CustomHandler, which share a single string builder and writer. It is
+	// shared with the handlers derived by WithAttrs and WithGroup.
+	mu *sync.Mutex
+	// file is the log file written by JSON file handlers.
+	file string
 }
 
-// NewJSONHandler creates a JSON Handler with the specified options.
+// NewJSONHandler creates a JSON file Handler with the specified options.
 func NewJSONHandler(
-	opts CustomHandlerOptions,
+	opts FileHandlerOptions,
 	replaceAttr CustomReplaceAttr,
 ) (slog.Handler, error) {
-	writer := CreateRotationWriter(opts)
+	rotator := newRotator(opts)
+	archiver, err := newHandlerArchiver(opts, rotator)
+	if err != nil {
+		_ = rotator.Close()
+		return nil, err
+	}
+	if opts.CSVHeader {
+		rotator = newCSVHeaderWriter(rotator, opts)
+	}
+	rotator = newSyncWriter(rotator, opts)
+	jh := newJSONHandler(opts.CustomHandlerOptions, newFileWriter(rotator, opts), replaceAttr)
+	jh.rotator = rotator
+	jh.archiver = archiver
+	jh.file = opts.File
+	if ch, ok := jh.Handler.(*CustomHandler); ok {
+		jh.flusher = startBatching(ch, opts)
+		jh.syncer = startSyncing(ch, rotator, opts)
+	}
+	return jh, nil
+}
 
+// newJSONHandler creates a JSON Handler writing to the given writer.
+// A nil writer yields a handler that is only used to format records.
+func newJSONHandler(
+	opts CustomHandlerOptions,
+	writer *bufio.Writer,
+	replaceAttr CustomReplaceAttr,
+) *JSONHandler {
+	// Records are encoded directly unless a custom replaceAttr needs to see
+	// the built-in attributes. ECS, GCP and the records of recordEncoder
+	// subtypes are always encoded directly, with replaceAttr applied to the
+	// attributes only.
+	direct := replaceAttr == nil || opts.ECS || opts.GCP || recordEncoder(opts.SubType) != nil
 	if replaceAttr == nil {
 		replaceAttr = GenerateDefaultCustomReplaceAttr(
 			opts,
@@ -29,19 +76,30 @@ func NewJSONHandler(
 	}
 
 	sb := &strings.Builder{}
-	return &JSONHandler{
+	level := NewLevelVar(opts.Level)
+	newHandler := func(w io.Writer) slog.Handler {
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       level,
+			AddSource:   opts.AddSource,
+			ReplaceAttr: replaceAttr,
+		})
+	}
+	jh := &JSONHandler{
 		Handler: &CustomHandler{
-			Opts: &opts,
-			sb:   sb,
-			mu:   sync.Mutex{},
-			handler: slog.NewJSONHandler(sb, &slog.HandlerOptions{
-				Level:       GetSlogLevel(opts.Level),
-				AddSource:   opts.AddSource,
-				ReplaceAttr: replaceAttr,
-			}),
-			writer: writer,
+			Opts:       &opts,
+			sb:         sb,
+			handler:    newHandler(sb),
+			formatters: newFormatterPool(newHandler),
+			out:        &handlerOutput{writer: writer},
+			level:      level,
+			enabled:    newHandlerSwitch(opts.Enabled),
 		},
-	}, nil
+		mu: &sync.Mutex{},
+	}
+	if direct {
+		jh.replaceAttr = replaceAttr
+	}
+	return jh
 }
 
 // Enabled checks if the handler is enabled for the given level.
@@ -55,46 +113,36 @@ func (jh *JSONHandler) Handle(ctx context.Context, record slog.Record) error {
 		return nil
 	}
 
-	mu := sync.Mutex{}
-	mu.Lock()
-	defer mu.Unlock()
-
-	sb := jh.Handler.GetStringBuilder()
-	defer func() {
-		sb.Reset()
-	}()
-
-	if err := jh.Handler.GetSlogHandler().Handle(ctx, record); err != nil {
-		return fmt.Errorf("failed to handle record: %w", err)
-	}
+	// Custom handlers format into pooled buffers and write under the output
+	// lock shared with their flusher and derived handlers
+	if ch, ok := jh.Handler.(*CustomHandler); ok && ch.out.writer != nil {
+		buf := getBuffer()
+		defer putBuffer(buf)
+		var err error
+		if *buf, err = jh.appendJSON(ctx, *buf, record); err != nil {
+			return err
+		}
+		if lineDelimited(ch.Opts.SubType) {
+			*buf = append(*buf, '\n')
+		}
 
-	opts := jh.Handler.GetOptions()
-	patternPlaceHolders := opts.PatternPlaceholders
-	if len(patternPlaceHolders) == 0 {
-		patternPlaceHolders = DefaultPatternPlaceholders
+		ch.out.mu.Lock()
+		defer ch.out.mu.Unlock()
+		return ch.write(record.Level, *buf)
 	}
-	values := GetPlaceholderValues(sb, record, patternPlaceHolders, jh.GetKeyValue)
 
-	if record.Level == LevelPerf && !ContainsKey(opts.PatternPlaceholders, PerfPlaceholder) {
-		values[PerfPlaceholder] = GetPerformanceMetrics()
-	}
+	jh.mu.Lock()
+	defer jh.mu.Unlock()
 
-	keyValues := RemovePlaceholderChars(values)
-	var attrMap map[string]interface{}
-	if err := json.Unmarshal([]byte(sb.String()), &attrMap); err == nil {
-		for k, v := range attrMap {
-			keyValues[k] = v
-		}
-	} else {
-		return fmt.Errorf("failed to unmarshal JSON string: %w", err)
-	}
-
-	b, err := json.Marshal(keyValues)
+	b, err := jh.format(ctx, record)
 	if err != nil {
-		return fmt.Errorf("failed to marshal values: %w", err)
+		return err
 	}
 
-	output := fmt.Sprintf("%s\n", string(b))
+	output := string(b)
+	if lineDelimited(jh.Handler.GetOptions().SubType) {
+		output += "\n"
+	}
 
 	// Get the writer from the handler
 	writer := jh.Handler.GetWriter()
@@ -121,10 +169,137 @@ func (jh *JSONHandler) Handle(ctx context.Context, record slog.Record) error {
 	return nil
 }
 
+// Format renders the record as a JSON document, or its binary encoding for
+// the binary subtypes, without writing it.
+func (jh *JSONHandler) Format(ctx context.Context, record slog.Record) (string, error) {
+	b, err := jh.format(ctx, record)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// format renders the record as JSON.
+func (jh *JSONHandler) format(ctx context.Context, record slog.Record) ([]byte, error) {
+	return jh.appendJSON(ctx, nil, record)
+}
+
+// recordEncoder returns the encoder of the subtypes that encode records
+// directly rather than as JSON documents: protobuf, csv, tsv, cef and leef.
+func recordEncoder(subType string) func(jh *JSONHandler, buf []byte, ch *CustomHandler, record slog.Record) []byte {
+	switch subType {
+	case ProtobufHandlerSubType:
+		return (*JSONHandler).appendProtobuf
+	case CSVHandlerSubType, TSVHandlerSubType:
+		return (*JSONHandler).appendCSV
+	case CEFHandlerSubType:
+		return (*JSONHandler).appendCEF
+	case LEEFHandlerSubType:
+		return (*JSONHandler).appendLEEF
+	default:
+		return nil
+	}
+}
+
+// appendJSON appends the record rendered as JSON, with its keys sorted and
+// indented if the options ask for it, or transcoded for the binary subtypes.
+// The subtypes of recordEncoder encode the record with it instead.
+func (jh *JSONHandler) appendJSON(ctx context.Context, buf []byte, record slog.Record) ([]byte, error) {
+	opts := jh.Handler.GetOptions()
+	if encode := recordEncoder(opts.SubType); encode != nil {
+		ch, ok := jh.Handler.(*CustomHandler)
+		if !ok {
+			return buf, fmt.Errorf("%s subtype requires a custom handler", opts.SubType)
+		}
+		return encode(jh, buf, ch, record), nil
+	}
+	if format := newBinaryFormat(opts.SubType); format != nil {
+		doc, err := jh.appendDocument(ctx, nil, record)
+		if err != nil {
+			return buf, err
+		}
+		return appendBinary(buf, format, doc)
+	}
+	if !opts.JSONIndent && !opts.JSONSortKeys {
+		return jh.appendDocument(ctx, buf, record)
+	}
+	doc, err := jh.appendDocument(ctx, nil, record)
+	if err != nil {
+		return buf, err
+	}
+	return appendPrettyJSON(buf, doc, opts.JSONIndent, opts.JSONSortKeys)
+}
+
+// appendDocument appends the record rendered as JSON, encoding it directly
+// when the handler uses the default attribute replacement.
+func (jh *JSONHandler) appendDocument(ctx context.Context, buf []byte, record slog.Record) ([]byte, error) {
+	if ch, ok := jh.Handler.(*CustomHandler); ok && jh.replaceAttr != nil {
+		if ch.Opts.ECS {
+			return jh.appendECS(ctx, buf, ch, record), nil
+		}
+		if ch.Opts.GCP {
+			return jh.appendGCP(ctx, buf, ch, record), nil
+		}
+		return jh.appendRecord(ctx, buf, ch, record), nil
+	}
+	b, err := jh.formatSlog(ctx, record)
+	if err != nil {
+		return buf, err
+	}
+	return append(buf, b...), nil
+}
+
+// formatSlog renders the record through the slog JSON handler.
+func (jh *JSONHandler) formatSlog(ctx context.Context, record slog.Record) ([]byte, error) {
+	sb, handler, release := formatterFor(jh.Handler)
+	defer release()
+
+	if err := handler.Handle(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to handle record: %w", err)
+	}
+
+	opts := jh.Handler.GetOptions()
+	patternPlaceHolders := opts.PatternPlaceholders
+	if len(patternPlaceHolders) == 0 {
+		patternPlaceHolders = DefaultPatternPlaceholders
+	}
+	values := placeholderValues(opts, sb, record, patternPlaceHolders, jh.GetKeyValue)
+
+	if _, placed := values[PerfPlaceholder]; placed || record.Level == LevelPerf {
+		if opts.StructuredPerf {
+			values[PerfPlaceholder] = perfMetricsMap(opts)
+		} else {
+			values[PerfPlaceholder] = formatPerfMetrics(opts)
+		}
+	}
+
+	keyValues := RemovePlaceholderChars(values)
+	var attrMap map[string]interface{}
+	if err := json.Unmarshal([]byte(sb.String()), &attrMap); err == nil {
+		for k, v := range attrMap {
+			keyValues[k] = v
+		}
+	} else {
+		return nil, fmt.Errorf("failed to unmarshal JSON string: %w", err)
+	}
+	if stacktraceEnabled(opts, record.Level) {
+		keyValues[StacktraceKey] = recordStacktrace(ctx, record.PC)
+	}
+
+	b, err := json.Marshal(keyValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal values: %w", err)
+	}
+	return b, nil
+}
+
 // GetKeyValue retrieves the value associated with the given key from the JSON string.
 func (jh *JSONHandler) GetKeyValue(key string, sb *strings.Builder, removeKey bool) string {
+	// Numbers are kept as written, so large integers do not lose precision.
+	decoder := json.NewDecoder(strings.NewReader(sb.String()))
+	decoder.UseNumber()
 	var m map[string]interface{}
-	if err := json.Unmarshal([]byte(sb.String()), &m); err != nil {
+	if err := decoder.Decode(&m); err != nil {
 		return ""
 	}
 
@@ -137,9 +312,12 @@ func (jh *JSONHandler) GetKeyValue(key string, sb *strings.Builder, removeKey bo
 
 		result = fmt.Sprintf("%v", v)
 
-		if b, err := json.Marshal(m); err == nil {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(m); err == nil {
 			sb.Reset()
-			sb.WriteString(string(b))
+			sb.WriteString(strings.TrimSuffix(buf.String(), "\n"))
 		}
 	}
 
@@ -148,15 +326,68 @@ func (jh *JSONHandler) GetKeyValue(key string, sb *strings.Builder, removeKey bo
 
 // WithAttrs creates a new handler with the given attributes.
 func (jh *JSONHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return jh.Handler.WithAttrs(attrs)
+	return &JSONHandler{
+		Handler:     jh.Handler.WithAttrs(attrs).(CustomHandlerInterface),
+		rotator:     jh.rotator,
+		replaceAttr: jh.replaceAttr,
+		flusher:     jh.flusher,
+		syncer:      jh.syncer,
+		archiver:    jh.archiver,
+		mu:          jh.mu,
+	}
 }
 
 // WithGroup creates a new handler with the given group name.
 func (jh *JSONHandler) WithGroup(name string) slog.Handler {
-	return jh.Handler.WithGroup(name)
+	return &JSONHandler{
+		Handler:     jh.Handler.WithGroup(name).(CustomHandlerInterface),
+		rotator:     jh.rotator,
+		replaceAttr: jh.replaceAttr,
+		flusher:     jh.flusher,
+		syncer:      jh.syncer,
+		archiver:    jh.archiver,
+		mu:          jh.mu,
+	}
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (jh *JSONHandler) SetLevel(level string) error {
+	return setHandlerLevel(jh.Handler, level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (jh *JSONHandler) GetLevel() string {
+	return getHandlerLevel(jh.Handler)
+}
+
+// Rotate flushes pending output and rotates the log file.
+func (jh *JSONHandler) Rotate() error {
+	return rotateHandler(jh.Handler, jh.rotator)
+}
+
+// logFile implements logFileProvider.
+func (jh *JSONHandler) logFile() string {
+	return jh.file
 }
 
 // WriterHandler is an interface for custom write operations.
 type WriterHandler interface {
 	CustomWrite(output string) error
 }
+
+// Close flushes pending output, closes the log file, and archives the files
+// rotated since the last archive scan.
+func (jh *JSONHandler) Close() error {
+	jh.flusher.Stop()
+	jh.syncer.Stop()
+	err := closeHandler(jh.Handler, jh.rotator)
+	if archiveErr := closeArchiver(jh.archiver); err == nil {
+		err = archiveErr
+	}
+	return err
+}
+
+// Flush flushes pending output.
+func (jh *JSONHandler) Flush() error {
+	return flushHandler(jh.Handler)
+}
@@ -2,14 +2,18 @@ package multilog
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestNewJsonHandler(t *testing.T) {
@@ -56,9 +60,7 @@ func TestNewJsonHandler(t *testing.T) {
 			defer os.Remove(f.Name())
 			defer f.Close()
 
-			tt.opts.File = f.Name()
-
-			handler, err := NewJSONHandler(tt.opts, nil)
+			handler, err := NewJSONHandler(FileHandlerOptions{CustomHandlerOptions: tt.opts, File: f.Name()}, nil)
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -82,12 +84,14 @@ func TestJsonHandlerHandle(t *testing.T) {
 	defer os.Remove(f.Name())
 	defer f.Close()
 
-	opts := CustomHandlerOptions{
-		Level:               "debug",
-		Enabled:             true,
-		AddSource:           true,
-		File:                f.Name(),
-		PatternPlaceholders: []string{"[msg]"},
+	opts := FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:               "debug",
+			Enabled:             true,
+			AddSource:           true,
+			PatternPlaceholders: []string{"[msg]"},
+		},
+		File: f.Name(),
 	}
 
 	handler, err := NewJSONHandler(opts, nil)
@@ -144,11 +148,13 @@ func TestJsonHandlerWithAttrsAndGroups(t *testing.T) {
 	defer os.Remove(f.Name())
 	defer f.Close()
 
-	opts := CustomHandlerOptions{
-		Level:     "debug",
-		Enabled:   true,
-		AddSource: true,
-		File:      f.Name(),
+	opts := FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:     "debug",
+			Enabled:   true,
+			AddSource: true,
+		},
+		File: f.Name(),
 	}
 
 	handler, err := NewJSONHandler(opts, nil)
@@ -298,6 +304,14 @@ func TestJsonHandler_GetKeyValue(t *testing.T) {
 			want:      "42",
 			wantJSON:  `{"message":"test message"}`,
 		},
+		{
+			name:      "large integer value",
+			json:      `{"id":12345678901234567890,"message":"test message"}`,
+			key:       "id",
+			removeKey: true,
+			want:      "12345678901234567890",
+			wantJSON:  `{"message":"test message"}`,
+		},
 		{
 			name:      "boolean value",
 			json:      `{"success":true,"message":"test message"}`,
@@ -346,6 +360,45 @@ func TestJsonHandler_GetKeyValue(t *testing.T) {
 	}
 }
 
+func TestJsonHandler_GetKeyValue_KeepsValues(t *testing.T) {
+	sb := &strings.Builder{}
+	sb.WriteString(`{"html":"<b>&</b>","id":12345678901234567890,"level":"INFO"}`)
+
+	if got := (&JSONHandler{}).GetKeyValue("level", sb, true); got != "INFO" {
+		t.Errorf("GetKeyValue() = %v, want INFO", got)
+	}
+	if want := `{"html":"<b>&</b>","id":12345678901234567890}`; sb.String() != want {
+		t.Errorf("JSON after extraction = %v, want %v", sb.String(), want)
+	}
+}
+
+// FuzzJSONHandler_GetKeyValue checks that any string attribute of a JSON
+// document is read back and removed, keeping the other attributes.
+func FuzzJSONHandler_GetKeyValue(f *testing.F) {
+	f.Add("level", "INFO", "other")
+	f.Add("k", `<b>"&"</b>`, "\u2028")
+	f.Fuzz(func(t *testing.T, key, value, other string) {
+		if key == "a" || !utf8.ValidString(key) || !utf8.ValidString(value) || !utf8.ValidString(other) {
+			t.Skip()
+		}
+		data, err := json.Marshal(map[string]string{"a": other, key: value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sb := &strings.Builder{}
+		sb.Write(data)
+
+		if got := (&JSONHandler{}).GetKeyValue(key, sb, true); got != value {
+			t.Errorf("GetKeyValue() = %q, want %q", got, value)
+		}
+		var remained map[string]string
+		if err := json.Unmarshal([]byte(sb.String()), &remained); err != nil || len(remained) != 1 ||
+			remained["a"] != other {
+			t.Errorf("JSON after extraction = %v, want only a=%q", sb.String(), other)
+		}
+	})
+}
+
 func mapsEqual(a, b map[string]interface{}) bool {
 	if len(a) != len(b) {
 		return false
@@ -369,12 +422,14 @@ func TestJsonHandler_Handle_Errors(t *testing.T) {
 	defer os.Remove(f.Name())
 	defer f.Close()
 
-	opts := CustomHandlerOptions{
-		Level:               "debug",
-		Enabled:             true,
-		AddSource:           true,
-		File:                f.Name(),
-		PatternPlaceholders: []string{"[msg]"},
+	opts := FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:               "debug",
+			Enabled:             true,
+			AddSource:           true,
+			PatternPlaceholders: []string{"[msg]"},
+		},
+		File: f.Name(),
 	}
 
 	record := slog.Record{
@@ -387,13 +442,13 @@ func TestJsonHandler_Handle_Errors(t *testing.T) {
 	sb := &strings.Builder{}
 	mockSlogHandler := &MockSlogHandler{err: fmt.Errorf("mock error")}
 	mockHandler := &MockCustomHandler{
-		opts:    &opts,
+		opts:    &opts.CustomHandlerOptions,
 		sb:      sb,
 		handler: mockSlogHandler,
 		writer:  &MockBufferedWriter{},
 	}
 
-	jsonHandler := &JSONHandler{Handler: mockHandler}
+	jsonHandler := &JSONHandler{Handler: mockHandler, mu: &sync.Mutex{}}
 
 	err = jsonHandler.Handle(context.Background(), record)
 	if err == nil || !strings.Contains(err.Error(), "failed to handle record") {
@@ -404,13 +459,13 @@ func TestJsonHandler_Handle_Errors(t *testing.T) {
 	validSb.WriteString(`{"level":"INFO","msg":"test-json-message","key1":"value1"}`)
 
 	mockWriteHandler := &MockCustomHandler{
-		opts:    &opts,
+		opts:    &opts.CustomHandlerOptions,
 		sb:      validSb,
 		handler: &MockSlogHandler{},
 		writer:  &MockBufferedWriter{writeStringErr: fmt.Errorf("mock write error")},
 	}
 
-	jsonHandler = &JSONHandler{Handler: mockWriteHandler}
+	jsonHandler = &JSONHandler{Handler: mockWriteHandler, mu: &sync.Mutex{}}
 
 	err = jsonHandler.Handle(context.Background(), record)
 	if err == nil || !strings.Contains(err.Error(), "failed to write log message") {
@@ -421,13 +476,13 @@ func TestJsonHandler_Handle_Errors(t *testing.T) {
 	validSb2.WriteString(`{"level":"INFO","msg":"test-json-message","key1":"value1"}`)
 
 	mockFlushHandler := &MockCustomHandler{
-		opts:    &opts,
+		opts:    &opts.CustomHandlerOptions,
 		sb:      validSb2,
 		handler: &MockSlogHandler{},
 		writer:  &MockBufferedWriter{flushErr: fmt.Errorf("mock flush error")},
 	}
 
-	jsonHandler = &JSONHandler{Handler: mockFlushHandler}
+	jsonHandler = &JSONHandler{Handler: mockFlushHandler, mu: &sync.Mutex{}}
 
 	err = jsonHandler.Handle(context.Background(), record)
 	if err == nil || !strings.Contains(err.Error(), "failed to flush writer") {
@@ -508,12 +563,14 @@ func TestJsonHandler_Handle_PerfLevel(t *testing.T) {
 	defer os.Remove(f.Name())
 	defer f.Close()
 
-	opts := CustomHandlerOptions{
-		Level:               "debug",
-		Enabled:             true,
-		AddSource:           true,
-		File:                f.Name(),
-		PatternPlaceholders: []string{"[level]", "[msg]"}, // Explicitly exclude [perf]
+	opts := FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:               "debug",
+			Enabled:             true,
+			AddSource:           true,
+			PatternPlaceholders: []string{"[level]", "[msg]"}, // Explicitly exclude [perf]
+		},
+		File: f.Name(),
 	}
 	handler, err := NewJSONHandler(opts, nil)
 	if err != nil {
@@ -562,3 +619,158 @@ func TestJsonHandler_Handle_PerfLevel(t *testing.T) {
 		}
 	}
 }
+
+func TestJsonHandler_Groups(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := CustomHandlerOptions{Level: "debug", Enabled: true}
+	logger := slog.New(newJSONHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.WithGroup("req").With("id", 7).Info("hello", "time", "inner", slog.Group("user", "name", "bob"))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "hello" {
+		t.Errorf("Expected msg hello, got %v", entry["msg"])
+	}
+	want := map[string]any{"id": float64(7), "time": "inner", "user": map[string]any{"name": "bob"}}
+	if fmt.Sprint(entry["req"]) != fmt.Sprint(want) {
+		t.Errorf("Expected req %v, got %v", want, entry["req"])
+	}
+}
+
+// sharedOutputHandler is a CustomHandlerInterface other than *CustomHandler
+// whose derived handlers share its string builder and writer.
+type sharedOutputHandler struct {
+	opts    *CustomHandlerOptions
+	sb      *strings.Builder
+	writer  *bufio.Writer
+	handler slog.Handler
+}
+
+func newSharedOutputHandler(w io.Writer) *sharedOutputHandler {
+	sb := &strings.Builder{}
+	return &sharedOutputHandler{
+		opts:    &CustomHandlerOptions{Level: "debug", Enabled: true},
+		sb:      sb,
+		writer:  bufio.NewWriter(w),
+		handler: slog.NewJSONHandler(sb, nil),
+	}
+}
+
+func (h *sharedOutputHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *sharedOutputHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *sharedOutputHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.handler = h.handler.WithAttrs(attrs)
+	return &clone
+}
+
+func (h *sharedOutputHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.handler = h.handler.WithGroup(name)
+	return &clone
+}
+
+func (h *sharedOutputHandler) GetOptions() *CustomHandlerOptions  { return h.opts }
+func (h *sharedOutputHandler) GetStringBuilder() *strings.Builder { return h.sb }
+func (h *sharedOutputHandler) GetWriter() *bufio.Writer           { return h.writer }
+func (h *sharedOutputHandler) GetSlogHandler() slog.Handler       { return h.handler }
+
+func (h *sharedOutputHandler) GetKeyValue(string, *strings.Builder, bool) string { return "" }
+
+func TestJsonHandler_ConcurrentDerivedHandle(t *testing.T) {
+	var buf bytes.Buffer
+	parent := &JSONHandler{Handler: newSharedOutputHandler(&buf), mu: &sync.Mutex{}}
+	handlers := []slog.Handler{
+		parent,
+		parent.WithAttrs([]slog.Attr{slog.String("service", "api")}),
+		parent.WithGroup("req"),
+	}
+
+	const goroutines, records = 12, 200
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := handlers[g%len(handlers)]
+			for i := range records {
+				record := slog.NewRecord(time.Now(), slog.LevelInfo, "derived", 0)
+				record.AddAttrs(slog.Int("i", i))
+				if err := h.Handle(context.Background(), record); err != nil {
+					t.Errorf("Handle failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != goroutines*records {
+		t.Fatalf("Expected %d lines, got %d", goroutines*records, len(lines))
+	}
+	services := 0
+	for _, line := range lines {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &data); err != nil {
+			t.Fatalf("Interleaved or corrupt JSON line: %v\nLine: %s", err, line)
+		}
+		if data["service"] == "api" {
+			services++
+		}
+	}
+	if want := goroutines / len(handlers) * records; services != want {
+		t.Errorf("Expected %d lines from the WithAttrs handler, got %d", want, services)
+	}
+}
+
+func TestJsonHandler_ConcurrentHandle(t *testing.T) {
+	var buf bytes.Buffer
+	opts := CustomHandlerOptions{Level: "debug", Enabled: true}
+	handler := newJSONHandler(opts, bufio.NewWriter(&buf), nil)
+	handlers := []slog.Handler{
+		handler,
+		handler.WithAttrs([]slog.Attr{slog.String("service", "api")}),
+		handler.WithGroup("req").WithAttrs([]slog.Attr{slog.Int("id", 7)}),
+	}
+
+	const goroutines, records = 16, 200
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := handlers[g%len(handlers)]
+			for i := range records {
+				record := slog.NewRecord(time.Now(), slog.LevelInfo, "concurrent", 0)
+				record.AddAttrs(slog.Int("goroutine", g), slog.Int("i", i))
+				if err := h.Handle(context.Background(), record); err != nil {
+					t.Errorf("Handle failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != goroutines*records {
+		t.Fatalf("Expected %d lines, got %d", goroutines*records, len(lines))
+	}
+	for _, line := range lines {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &data); err != nil {
+			t.Fatalf("Interleaved or corrupt JSON line: %v\nLine: %s", err, line)
+		}
+		if data["msg"] != "concurrent" {
+			t.Fatalf("Unexpected message in line: %s", line)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// KafkaMessage is a serialized log record to be produced to Kafka.
+type KafkaMessage struct {
+	Time  time.Time
+	Topic string
+	Key   []byte
+	Value []byte
+}
+
+// KafkaProducer produces batches of messages to Kafka.
+// multilog does not ship a Kafka client; wrap the client of your choice
+// (sarama, franz-go, confluent-kafka-go, ...) in this interface.
+type KafkaProducer interface {
+	Produce(ctx context.Context, messages []KafkaMessage) error
+	Close() error
+}
+
+// KafkaProducerFactory creates a producer for the given brokers.
+// It must be set before creating kafka handlers from configuration.
+var KafkaProducerFactory func(brokers []string) (KafkaProducer, error)
+
+// KafkaDeliveryError is reported to OnError when a batch could not be delivered.
+type KafkaDeliveryError struct {
+	Err      error
+	Messages []KafkaMessage
+}
+
+// Error implements error.
+func (e *KafkaDeliveryError) Error() string {
+	return fmt.Sprintf("failed to deliver %d kafka messages: %v", len(e.Messages), e.Err)
+}
+
+// Unwrap returns the underlying producer error.
+func (e *KafkaDeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// KafkaHandler is a Handler that serializes records as JSON and produces them to Kafka.
+type KafkaHandler struct {
+	Handler  *JSONHandler
+	producer KafkaProducer
+	batch    *batcher[KafkaMessage]
+	opts     *KafkaHandlerOptions
+	attrs    []slog.Attr
+}
+
+// KafkaHandlerOptions are the options of Kafka handlers: the formatting and
+// batching options, the brokers and topic, and the attribute whose value keys
+// the messages.
+type KafkaHandlerOptions struct {
+	CustomHandlerOptions
+	BatchOptions
+	Topic        string
+	KeyAttribute string
+	Brokers      []string
+}
+
+// NewKafkaHandler creates a Kafka Handler with the specified options and producer.
+// Records are batched and produced asynchronously; if KeyAttribute is set, the
+// value of that attribute is used as the message key for partition selection.
+// Up to MaxBufferSize (DefaultMaxBufferSize if zero) messages are buffered;
+// when the buffer is full the oldest messages are dropped.
+func NewKafkaHandler(opts KafkaHandlerOptions, producer KafkaProducer) (slog.Handler, error) {
+	if producer == nil {
+		return nil, fmt.Errorf("kafka handler requires a producer")
+	}
+	if opts.Topic == "" {
+		return nil, fmt.Errorf("kafka handler requires a topic")
+	}
+
+	kh := &KafkaHandler{
+		Handler:  newJSONHandler(opts.CustomHandlerOptions, nil, nil),
+		producer: producer,
+		opts:     &opts,
+	}
+	maxBuffer := defaultIfZero(opts.MaxBufferSize, DefaultMaxBufferSize)
+	kh.batch = newBatcher(opts.BatchSize, opts.FlushInterval, maxBuffer, kh.produce, opts.OnError)
+	return kh, nil
+}
+
+// newKafkaHandlerFromFactory creates a Kafka Handler using KafkaProducerFactory.
+func newKafkaHandlerFromFactory(opts KafkaHandlerOptions) (slog.Handler, error) {
+	if KafkaProducerFactory == nil {
+		return nil, fmt.Errorf("kafka handler requires multilog.KafkaProducerFactory to be set")
+	}
+	producer, err := KafkaProducerFactory(opts.Brokers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka producer: %w", err)
+	}
+	return NewKafkaHandler(opts, producer)
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (kh *KafkaHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return kh.Handler.Enabled(ctx, level)
+}
+
+// Handle serializes the log record and queues it for the next produce call.
+func (kh *KafkaHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !kh.Enabled(ctx, record.Level) {
+		return nil
+	}
+	value, err := kh.Handler.Format(ctx, record)
+	if err != nil {
+		return err
+	}
+	return kh.batch.add(ctx, KafkaMessage{
+		Time:  record.Time,
+		Topic: kh.opts.Topic,
+		Key:   kh.messageKey(record),
+		Value: []byte(value),
+	})
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (kh *KafkaHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *kh
+	clone.Handler = &JSONHandler{
+		Handler:     kh.Handler.Handler.WithAttrs(attrs).(CustomHandlerInterface),
+		replaceAttr: kh.Handler.replaceAttr,
+		mu:          kh.Handler.mu,
+	}
+	clone.attrs = append(append([]slog.Attr{}, kh.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (kh *KafkaHandler) WithGroup(name string) slog.Handler {
+	clone := *kh
+	clone.Handler = &JSONHandler{
+		Handler:     kh.Handler.Handler.WithGroup(name).(CustomHandlerInterface),
+		replaceAttr: kh.Handler.replaceAttr,
+		mu:          kh.Handler.mu,
+	}
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (kh *KafkaHandler) SetLevel(level string) error {
+	return kh.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (kh *KafkaHandler) GetLevel() string {
+	return kh.Handler.GetLevel()
+}
+
+// Flush produces all queued records.
+func (kh *KafkaHandler) Flush() error {
+	return kh.batch.Flush()
+}
+
+// Close produces all queued records and closes the producer.
+func (kh *KafkaHandler) Close() error {
+	return errors.Join(kh.batch.Close(), kh.producer.Close())
+}
+
+// Dropped returns the number of messages dropped because the buffer was full.
+func (kh *KafkaHandler) Dropped() int {
+	return kh.batch.Dropped()
+}
+
+// customHandler implements customHandlerProvider.
+func (kh *KafkaHandler) customHandler() CustomHandlerInterface {
+	return kh.Handler.Handler
+}
+
+// messageKey returns the value of the key attribute, if present.
+func (kh *KafkaHandler) messageKey(record slog.Record) []byte {
+	if kh.opts.KeyAttribute == "" {
+		return nil
+	}
+	var key []byte
+	record.Attrs(func(a slog.Attr) bool {
+		if a.Key == kh.opts.KeyAttribute {
+			key = []byte(a.Value.String())
+			return false
+		}
+		return true
+	})
+	if key != nil {
+		return key
+	}
+	for _, a := range kh.attrs {
+		if a.Key == kh.opts.KeyAttribute {
+			return []byte(a.Value.String())
+		}
+	}
+	return nil
+}
+
+// produce sends a batch of messages, retrying on failure.
+func (kh *KafkaHandler) produce(messages []KafkaMessage) error {
+	err := kh.opts.retryPolicy().Do(func() error {
+		return kh.producer.Produce(context.Background(), messages)
+	})
+	if err != nil {
+		return &KafkaDeliveryError{Err: err, Messages: messages}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package multilog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockKafkaProducer struct {
+	err      error
+	messages []KafkaMessage
+	mu       sync.Mutex
+	closed   bool
+}
+
+func (p *mockKafkaProducer) Produce(_ context.Context, messages []KafkaMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
+func (p *mockKafkaProducer) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestNewKafkaHandler_Validation(t *testing.T) {
+	_, err := NewKafkaHandler(KafkaHandlerOptions{Topic: "logs"}, nil)
+	assert.Error(t, err)
+
+	_, err = NewKafkaHandler(KafkaHandlerOptions{}, &mockKafkaProducer{})
+	assert.Error(t, err)
+}
+
+func TestKafkaHandler_Produce(t *testing.T) {
+	producer := &mockKafkaProducer{}
+	handler, err := NewKafkaHandler(KafkaHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:               InfoLevel,
+			Enabled:             true,
+			PatternPlaceholders: []string{"[level]", "[msg]"},
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		Topic:        "logs",
+		KeyAttribute: "user_id",
+	}, producer)
+	assert.NoError(t, err)
+
+	logger := NewLogger(handler)
+	logger.Info("login", "user_id", "42")
+	logger.WithField("user_id", "7").Warn("logout")
+	logger.Info("anonymous")
+	logger.Debug("filtered")
+
+	assert.NoError(t, handler.(*KafkaHandler).Close())
+	assert.True(t, producer.closed)
+	assert.Len(t, producer.messages, 3)
+
+	assert.Equal(t, "logs", producer.messages[0].Topic)
+	assert.Equal(t, []byte("42"), producer.messages[0].Key)
+	assert.Equal(t, []byte("7"), producer.messages[1].Key)
+	assert.Nil(t, producer.messages[2].Key)
+
+	var doc map[string]any
+	assert.NoError(t, json.Unmarshal(producer.messages[0].Value, &doc))
+	assert.Equal(t, "login", doc["msg"])
+	assert.Equal(t, "INFO", doc["level"])
+	assert.Equal(t, "42", doc["user_id"])
+}
+
+func TestKafkaHandler_DeliveryError(t *testing.T) {
+	producer := &mockKafkaProducer{err: errors.New("broker down")}
+	errCh := make(chan error, 1)
+	handler, err := NewKafkaHandler(KafkaHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			OnError: func(err error) {
+				select {
+				case errCh <- err:
+				default:
+				}
+			},
+		},
+		BatchOptions: BatchOptions{
+			BatchSize: 1,
+		},
+		Topic: "logs",
+	}, producer)
+	assert.NoError(t, err)
+
+	kh := handler.(*KafkaHandler)
+	assert.NoError(t, kh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "boom", 0)))
+
+	select {
+	case err := <-errCh:
+		var deliveryErr *KafkaDeliveryError
+		assert.ErrorAs(t, err, &deliveryErr)
+		assert.Len(t, deliveryErr.Messages, 1)
+		assert.ErrorContains(t, err, "broker down")
+	case <-time.After(time.Second):
+		t.Fatal("expected delivery error callback")
+	}
+	_ = kh.Close()
+}
+
+func TestKafkaHandler_FromConfig(t *testing.T) {
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: kafka
+      level: info
+      enabled: true
+      brokers: ["localhost:9092"]
+      topic: logs`))
+	assert.NoError(t, err)
+
+	_, err = CreateHandlers(cfg)
+	assert.ErrorContains(t, err, "KafkaProducerFactory")
+
+	producer := &mockKafkaProducer{}
+	KafkaProducerFactory = func(brokers []string) (KafkaProducer, error) {
+		assert.Equal(t, []string{"localhost:9092"}, brokers)
+		return producer, nil
+	}
+	defer func() { KafkaProducerFactory = nil }()
+
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	assert.Len(t, handlers, 1)
+	kh := handlers[0].(*KafkaHandler)
+	assert.NoError(t, kh.SetLevel(DebugLevel))
+	assert.Equal(t, DebugLevel, kh.GetLevel())
+	assert.NotNil(t, kh.WithGroup("g"))
+	assert.NoError(t, kh.Close())
+}
+
+func TestKafkaHandler_MaxBufferSize(t *testing.T) {
+	producer := &mockKafkaProducer{}
+	handler, err := NewKafkaHandler(KafkaHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+			MaxBufferSize: 2,
+		},
+		Topic: "logs",
+	}, producer)
+	assert.NoError(t, err)
+
+	kh := handler.(*KafkaHandler)
+	for _, msg := range []string{"first", "second", "third"} {
+		assert.NoError(t, kh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)))
+	}
+	assert.Equal(t, 1, kh.Dropped())
+	assert.NoError(t, kh.Close())
+	assert.Len(t, producer.messages, 2)
+
+	handler, err = NewKafkaHandler(KafkaHandlerOptions{Topic: "logs"}, producer)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultMaxBufferSize, handler.(*KafkaHandler).batch.maxItems)
+	assert.NoError(t, handler.(*KafkaHandler).Close())
+}
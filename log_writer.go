@@ -0,0 +1,117 @@
+package multilog
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// maxWriterLine is the length at which a line without a newline is logged anyway.
+const maxWriterLine = 64 * 1024
+
+// LogWriter is an io.Writer that logs every line written to it as a record at
+// a fixed level, so libraries writing to an io.Writer can log through multilog
+// handlers. Trailing carriage returns are trimmed and blank lines skipped. A
+// partial line is held until its newline arrives or Flush is called. Records
+// report the caller of Write as their source.
+type LogWriter struct {
+	logger *slog.Logger
+	prefix string
+	buf    []byte
+	level  slog.Level
+	// skip is the number of frames between the reported caller and Write.
+	skip int
+	mu   sync.Mutex
+}
+
+// Writer returns an io.Writer logging each written line at the level.
+func (l *Logger) Writer(level slog.Level) *LogWriter {
+	return l.WriterWithPrefix(level, "")
+}
+
+// WriterWithPrefix returns an io.Writer logging each written line at the
+// level, with the prefix prepended to the message.
+func (l *Logger) WriterWithPrefix(level slog.Level, prefix string) *LogWriter {
+	return &LogWriter{logger: l.Logger, prefix: prefix, level: level}
+}
+
+// StdLogger returns a standard library logger writing through the logger at
+// the level. It adds no timestamp or prefix, since handlers format their own.
+func (l *Logger) StdLogger(level slog.Level) *log.Logger {
+	w := l.Writer(level)
+	w.skip = stdLogCallDepth
+	return log.New(w, "", 0)
+}
+
+// Write logs the complete lines in p and holds the rest. It never fails.
+func (w *LogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.logLine(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) >= maxWriterLine {
+		w.logLine(w.buf)
+		w.buf = w.buf[:0]
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// Flush logs the held partial line, if any.
+func (w *LogWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logLine(w.buf)
+	w.buf = nil
+	return nil
+}
+
+// Close logs the held partial line, if any.
+func (w *LogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logLine(w.buf)
+	w.buf = nil
+	return nil
+}
+
+// logLine logs the line unless it is blank, with the caller of Write, Flush or
+// Close as its source; the caller must hold w.mu.
+func (w *LogWriter) logLine(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+	// Skip logLine, the writer method and the frames above it.
+	logWithCaller(w.logger, 2+w.skip, w.level, w.prefix+string(line))
+}
+
+// logWithCaller logs a record whose source is skip frames above the caller of
+// logWithCaller, like Logger.emit, rather than the caller of slog.Logger.Log.
+func logWithCaller(logger *slog.Logger, skip int, level slog.Level, msg string, args ...any) {
+	ctx := context.Background()
+	handler := logger.Handler()
+	if !handler.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	// Skip runtime.Callers, logWithCaller and the given frames.
+	runtime.Callers(skip+2, pcs[:])
+	record := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	record.Add(args...)
+	_ = handler.Handle(ctx, record)
+}
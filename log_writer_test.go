@@ -0,0 +1,85 @@
+package multilog
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// messages returns the levels and messages of the recorded records.
+func (h *recordingHandler) messages() []string {
+	var msgs []string
+	for _, r := range h.records {
+		msgs = append(msgs, GetLevelName(r.Level)+" "+r.Message)
+	}
+	return msgs
+}
+
+func TestLogWriter_Lines(t *testing.T) {
+	handler := &recordingHandler{}
+	w := NewLogger(handler).Writer(slog.LevelWarn)
+
+	n, err := fmt.Fprint(w, "first line\r\nsecond ")
+	assert.NoError(t, err)
+	assert.Equal(t, 19, n)
+	assert.Equal(t, []string{"warn first line"}, handler.messages())
+
+	_, _ = w.Write([]byte("half\n\n   \nthird"))
+	assert.Equal(t, []string{"warn first line", "warn second half"}, handler.messages())
+
+	assert.NoError(t, w.Close())
+	assert.Equal(t, []string{"warn first line", "warn second half", "warn third"}, handler.messages())
+	assert.NoError(t, w.Flush())
+	assert.Len(t, handler.records, 3)
+}
+
+func TestLogWriter_PrefixAndFields(t *testing.T) {
+	handler := &recordingHandler{}
+	logger := NewLogger(handler).WithField("component", "db").(*Logger)
+	w := logger.WriterWithPrefix(slog.LevelInfo, "[gorm] ")
+
+	_, _ = w.Write([]byte("slow query\n"))
+	assert.Equal(t, []string{"info [gorm] slow query"}, handler.messages())
+}
+
+func TestLogWriter_LongLine(t *testing.T) {
+	handler := &recordingHandler{}
+	w := NewLogger(handler).Writer(slog.LevelInfo)
+
+	_, _ = w.Write([]byte(strings.Repeat("x", maxWriterLine+10)))
+	assert.Len(t, handler.records, 1)
+	assert.Len(t, handler.records[0].Message, maxWriterLine+10)
+	assert.Nil(t, w.buf)
+}
+
+func TestLogWriter_Source(t *testing.T) {
+	handler := &recordingHandler{}
+	logger := NewLogger(handler)
+	w := logger.Writer(slog.LevelInfo)
+
+	_, _, line, _ := runtime.Caller(0)
+	_, _ = w.Write([]byte("written\npartial"))
+	assert.NoError(t, w.Close())
+	logger.StdLogger(slog.LevelInfo).Print("printed")
+
+	assert.Len(t, handler.records, 3)
+	for i, offset := range []int{1, 2, 3} {
+		source := handler.records[i].Source()
+		assert.Equal(t, "log_writer_test.go", filepath.Base(source.File), handler.records[i].Message)
+		assert.Equal(t, line+offset, source.Line, handler.records[i].Message)
+	}
+}
+
+func TestLogger_StdLogger(t *testing.T) {
+	handler := &recordingHandler{}
+	std := NewLogger(handler).StdLogger(slog.LevelError)
+	std.Printf("connection reset: %s", "peer")
+	std.Println("retrying")
+
+	assert.Equal(t, []string{"error connection reset: peer", "error retrying"}, handler.messages())
+}
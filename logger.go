@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"runtime"
+	"time"
 )
 
 // Log level constants
@@ -16,12 +18,18 @@ const (
 )
 
 // PackagePrefix is the prefix used for package-level logging.
+//
+// Deprecated: records carry the program counter of their caller.
 const PackagePrefix = "multilog.(*Logger)."
 
 // GenericLogFuncName is the function name used for generic logging.
+//
+// Deprecated: records carry the program counter of their caller.
 const GenericLogFuncName = "multilog.(*Logger).log"
 
 // CallIdentifiers contains the identifiers for various logging functions.
+//
+// Deprecated: records carry the program counter of their caller.
 var CallIdentifiers = []string{
 	"Perf(",
 	"Perff(",
@@ -80,38 +88,68 @@ type LoggerInterface interface {
 	GetLogger() *slog.Logger
 }
 
-// Ensure Logger implements LoggerInterface
-var _ LoggerInterface = (*Logger)(nil)
+// ErrorLogger is implemented by loggers that attach an error to their records.
+// It is separate from LoggerInterface so that existing implementations of that
+// interface keep compiling; check for it with a type assertion. Loggers also
+// implement Flusher and Closer.
+type ErrorLogger interface {
+	WithError(err error) LoggerInterface
+}
+
+// Ensure Logger implements LoggerInterface, ErrorLogger, Flusher and Closer
+var (
+	_ LoggerInterface = (*Logger)(nil)
+	_ ErrorLogger     = (*Logger)(nil)
+	_ Flusher         = (*Logger)(nil)
+	_ Closer          = (*Logger)(nil)
+	_ ErrorLogger     = (*ContextLogger)(nil)
+)
 
 // Logger wraps slog.Logger and allows configuration of handlers.
 type Logger struct {
-	Logger *slog.Logger
-	attrs  []any
+	Logger     *slog.Logger
+	reporter   *PerfReporter
+	attrs      []any
+	extractors []ContextExtractor
+	callerSkip int
+	registry   *Registry
 }
 
 // NewLogger creates a new logger with the specified handlers.
 func NewLogger(handlers ...slog.Handler) *Logger {
-	var enabledHandlers []slog.Handler
+	return &Logger{Logger: slog.New(NewAggregator(enabledHandlers(handlers)...))}
+}
+
+// NewParallelLogger creates a new logger passing records to the specified
+// handlers concurrently, as described in NewParallelAggregator.
+func NewParallelLogger(workers int, timeout time.Duration, handlers ...slog.Handler) *Logger {
+	return &Logger{Logger: slog.New(NewParallelAggregator(workers, timeout, enabledHandlers(handlers)...))}
+}
+
+// enabledHandlers drops the disabled multilog handlers, marking them as dropped
+// so that they report an error when enabled later.
+func enabledHandlers(handlers []slog.Handler) []slog.Handler {
+	var enabled []slog.Handler
 	for _, handler := range handlers {
 		switch h := handler.(type) {
 		case *ConsoleHandler:
-			if customHandler, ok := h.Handler.(*CustomHandler); ok && customHandler.Opts.Enabled {
-				enabledHandlers = append(enabledHandlers, handler)
+			if customHandler, ok := h.Handler.(*CustomHandler); ok && !customHandler.dropIfDisabled() {
+				enabled = append(enabled, handler)
 			}
 		case *FileHandler:
-			if customHandler, ok := h.Handler.(*CustomHandler); ok && customHandler.Opts.Enabled {
-				enabledHandlers = append(enabledHandlers, handler)
+			if customHandler, ok := h.Handler.(*CustomHandler); ok && !customHandler.dropIfDisabled() {
+				enabled = append(enabled, handler)
 			}
 		case *JSONHandler:
-			if customHandler, ok := h.Handler.(*CustomHandler); ok && customHandler.Opts.Enabled {
-				enabledHandlers = append(enabledHandlers, handler)
+			if customHandler, ok := h.Handler.(*CustomHandler); ok && !customHandler.dropIfDisabled() {
+				enabled = append(enabled, handler)
 			}
 		default:
 			// Accept other handler types directly (useful for testing)
-			enabledHandlers = append(enabledHandlers, handler)
+			enabled = append(enabled, handler)
 		}
 	}
-	return &Logger{Logger: slog.New(NewAggregator(enabledHandlers...))}
+	return enabled
 }
 
 // WithLevel returns a new logger with the specified minimum level
@@ -120,6 +158,41 @@ func (l *Logger) WithLevel(_ slog.Level) *Logger {
 	return &newLogger
 }
 
+// WithCallerSkip returns a new logger reporting as the source of records the
+// caller skip more frames up the stack, for helper packages wrapping the logger:
+// a helper calling Info directly uses WithCallerSkip(1) so that the source is
+// the caller of the helper. Skips add up; negative skips are ignored.
+func (l *Logger) WithCallerSkip(skip int) *Logger {
+	if skip <= 0 {
+		return l
+	}
+	newLogger := *l
+	newLogger.callerSkip += skip
+	return &newLogger
+}
+
+// Use returns a new logger whose records pass through the middlewares before
+// reaching the handlers. The first middleware sees records first.
+func (l *Logger) Use(middlewares ...Middleware) *Logger {
+	newLogger := *l
+	newLogger.Logger = slog.New(Chain(l.Logger.Handler(), middlewares...))
+	return &newLogger
+}
+
+// WithContextExtractors returns a new logger that adds the attributes produced by
+// the extractors to every context-aware record.
+func (l *Logger) WithContextExtractors(extractors ...ContextExtractor) *Logger {
+	newLogger := *l
+	newLogger.extractors = append(append([]ContextExtractor{}, l.extractors...), extractors...)
+	return &newLogger
+}
+
+// WithContextKeys returns a new logger that adds the context values stored
+// under the keys to every context-aware record, as NewContextKeysExtractor.
+func (l *Logger) WithContextKeys(keys ...any) *Logger {
+	return l.WithContextExtractors(NewContextKeysExtractor(keys...))
+}
+
 // WithContext returns a logger with the context attached
 func (l *Logger) WithContext(ctx context.Context) LoggerInterface {
 	return &ContextLogger{
@@ -128,13 +201,27 @@ func (l *Logger) WithContext(ctx context.Context) LoggerInterface {
 	}
 }
 
+// loggerContextKey is the context key NewContext stores loggers under.
+type loggerContextKey struct{}
+
+// NewContext returns a copy of ctx carrying the logger, for middleware handing
+// a request-scoped logger to the code it calls.
+func NewContext(ctx context.Context, logger LoggerInterface) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx by NewContext, or a logger
+// discarding all records if there is none.
+func FromContext(ctx context.Context) LoggerInterface {
+	if logger, ok := ctx.Value(loggerContextKey{}).(LoggerInterface); ok {
+		return logger
+	}
+	return NewLogger().WithContext(ctx)
+}
+
 // WithField returns a logger with the specified field attached to all messages
 func (l *Logger) WithField(key string, value any) LoggerInterface {
-	newLogger := &Logger{
-		Logger: l.Logger.With(key, value),
-		attrs:  append(l.attrs, key, value),
-	}
-	return newLogger
+	return l.With(key, value)
 }
 
 // WithFields returns a logger with the specified fields attached to all messages
@@ -144,12 +231,56 @@ func (l *Logger) WithFields(fields map[string]any) LoggerInterface {
 	for k, v := range fields {
 		args = append(args, k, v)
 	}
+	return l.With(args...)
+}
 
-	newLogger := &Logger{
-		Logger: l.Logger.With(args...),
-		attrs:  append(l.attrs, args...),
+// With returns a logger that includes the given attributes in each record, as
+// slog.Logger.With. Unlike WithField, it returns a *Logger, keeping the context
+// extractors, the perf reporter and the multilog specific methods.
+func (l *Logger) With(args ...any) *Logger {
+	if len(args) == 0 {
+		return l
 	}
-	return newLogger
+	newLogger := *l
+	newLogger.Logger = l.Logger.With(args...)
+	newLogger.attrs = append(l.attrs[:len(l.attrs):len(l.attrs)], args...)
+	return &newLogger
+}
+
+// WithGroup returns a logger that starts a group, as slog.Logger.WithGroup:
+// the attributes added later and those of each record are qualified by name.
+func (l *Logger) WithGroup(name string) *Logger {
+	if name == "" {
+		return l
+	}
+	newLogger := *l
+	newLogger.Logger = l.Logger.WithGroup(name)
+	return &newLogger
+}
+
+// WithError returns a logger with the error attached to all messages as the err attribute
+func (l *Logger) WithError(err error) LoggerInterface {
+	if err == nil {
+		return l
+	}
+	return l.WithField(ErrorKey, ErrorValue(err))
+}
+
+// Named returns the named logger of the registry the logger was created with,
+// such as the one NewLoggerFromConfig sets up with the configured "loggers"
+// levels. Loggers without a registry return a named logger without a level.
+// The named logger keeps the caller skip of l.
+func (l *Logger) Named(name string) *Logger {
+	if l.registry == nil {
+		return NewRegistry(l).GetLogger(name)
+	}
+	named := l.registry.GetLogger(name)
+	if named.callerSkip == l.callerSkip {
+		return named
+	}
+	newLogger := *named
+	newLogger.callerSkip = l.callerSkip
+	return &newLogger
 }
 
 // GetLogger returns the underlying slog.Logger
@@ -157,16 +288,63 @@ func (l *Logger) GetLogger() *slog.Logger {
 	return l.Logger
 }
 
-// log logs a message at the given level.
+// Flush flushes all handlers that buffer output.
+func (l *Logger) Flush() error {
+	return flushHandler(l.Logger.Handler())
+}
+
+// Close flushes all handlers, stops their background goroutines, and closes
+// their files and connections. Call it on the root logger, typically with
+// defer logger.Close(), so no buffered records are lost on exit.
+func (l *Logger) Close() error {
+	l.reporter.Stop()
+	return closeSlogHandler(l.Logger.Handler())
+}
+
+// emit logs a record with the attributes if the level is enabled. The source
+// of the record is the caller of the logging method, found with runtime.Callers
+// rather than by walking the stack at format time, so it is right for handlers
+// formatting records on other goroutines too. skip is the number of frames
+// between the logging method and emit: 0 when the method calls emit directly.
+func (l *Logger) emit(ctx context.Context, skip int, level slog.Level, msg string, args ...any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	handler := l.Logger.Handler()
+	if !handler.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	// Skip runtime.Callers, emit, the logging method and the wrapper frames.
+	runtime.Callers(skip+3+l.callerSkip, pcs[:])
+	record := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	record.Add(args...)
+	_ = handler.Handle(ctx, record)
+}
+
+// log logs a message formatted with the arguments at the given level.
 func (l *Logger) log(level slog.Level, msg string, args ...any) {
-	// Format the message but don't add any attributes
-	l.Logger.Log(context.Background(), level, fmt.Sprintf(msg, args...))
+	l.emit(context.Background(), 1, level, fmt.Sprintf(msg, args...))
 }
 
-// logContext logs a message with context at the given level
+// logContext logs a message formatted with the arguments, with the attributes
+// extracted from the context, at the given level.
 func (l *Logger) logContext(ctx context.Context, level slog.Level, msg string, args ...any) {
-	// Log without level check
-	l.Logger.Log(ctx, level, fmt.Sprintf(msg, args...))
+	l.emit(ctx, 1, level, fmt.Sprintf(msg, args...), l.withContextAttrs(ctx, nil)...)
+}
+
+// withContextAttrs appends the attributes extracted from the context to args.
+func (l *Logger) withContextAttrs(ctx context.Context, args []any) []any {
+	attrs := extractContextAttrs(ctx, l.extractors)
+	if len(attrs) == 0 {
+		return args
+	}
+	result := make([]any, 0, len(args)+len(attrs))
+	result = append(result, args...)
+	for _, attr := range attrs {
+		result = append(result, attr)
+	}
+	return result
 }
 
 // Perff logs performance metrics dynamically.
@@ -176,7 +354,7 @@ func (l *Logger) Perff(msg string, args ...any) {
 
 // Perf logs performance metrics statically.
 func (l *Logger) Perf(msg string, args ...any) {
-	l.Logger.Log(context.Background(), LevelPerf, msg, args...)
+	l.emit(context.Background(), 0, LevelPerf, msg, args...)
 }
 
 // Infof logs an informational message.
@@ -201,22 +379,22 @@ func (l *Logger) Errorf(msg string, args ...any) {
 
 // Debug logs a debug message with structured key-value pairs.
 func (l *Logger) Debug(msg string, args ...any) {
-	l.Logger.Debug(msg, args...)
+	l.emit(context.Background(), 0, slog.LevelDebug, msg, args...)
 }
 
 // Info logs an informational message with structured key-value pairs.
 func (l *Logger) Info(msg string, args ...any) {
-	l.Logger.Info(msg, args...)
+	l.emit(context.Background(), 0, slog.LevelInfo, msg, args...)
 }
 
 // Warn logs a warning message with structured key-value pairs.
 func (l *Logger) Warn(msg string, args ...any) {
-	l.Logger.Warn(msg, args...)
+	l.emit(context.Background(), 0, slog.LevelWarn, msg, args...)
 }
 
 // Error logs an error message with structured key-value pairs.
 func (l *Logger) Error(msg string, args ...any) {
-	l.Logger.Error(msg, args...)
+	l.emit(context.Background(), 0, slog.LevelError, msg, args...)
 }
 
 // PerfContext logs performance metrics dynamically.
@@ -306,7 +484,11 @@ func (l *ContextLogger) Perff(msg string, args ...any) {
 
 // Perf logs performance metrics statically.
 func (l *ContextLogger) Perf(msg string, args ...any) {
-	l.Logger.Logger.LogAttrs(l.ctx, LevelPerf, msg, slog.Any("args", args))
+	attrs := []any{slog.Any("args", args)}
+	for _, attr := range extractContextAttrs(l.ctx, l.extractors) {
+		attrs = append(attrs, attr)
+	}
+	l.emit(l.ctx, 0, LevelPerf, msg, attrs...)
 }
 
 // Infof logs an informational message with structured key-value pairs.
@@ -331,22 +513,22 @@ func (l *ContextLogger) Debugf(msg string, args ...any) {
 
 // Debug logs a debug message with structured key-value pairs.
 func (l *ContextLogger) Debug(msg string, args ...any) {
-	l.Logger.Logger.DebugContext(l.ctx, msg, args...)
+	l.emit(l.ctx, 0, slog.LevelDebug, msg, l.withContextAttrs(l.ctx, args)...)
 }
 
 // Info logs an informational message with structured key-value pairs.
 func (l *ContextLogger) Info(msg string, args ...any) {
-	l.Logger.Logger.InfoContext(l.ctx, msg, args...)
+	l.emit(l.ctx, 0, slog.LevelInfo, msg, l.withContextAttrs(l.ctx, args)...)
 }
 
 // Warn logs a warning message with structured key-value pairs.
 func (l *ContextLogger) Warn(msg string, args ...any) {
-	l.Logger.Logger.WarnContext(l.ctx, msg, args...)
+	l.emit(l.ctx, 0, slog.LevelWarn, msg, l.withContextAttrs(l.ctx, args)...)
 }
 
 // Error logs an error message with structured key-value pairs.
 func (l *ContextLogger) Error(msg string, args ...any) {
-	l.Logger.Logger.ErrorContext(l.ctx, msg, args...)
+	l.emit(l.ctx, 0, slog.LevelError, msg, l.withContextAttrs(l.ctx, args)...)
 }
 
 // PerfContext logs performance metrics dynamically.
@@ -407,3 +589,27 @@ func (l *ContextLogger) WithFields(fields map[string]any) LoggerInterface {
 		ctx:    l.ctx,
 	}
 }
+
+// With returns a context logger that includes the given attributes in each record.
+func (l *ContextLogger) With(args ...any) *ContextLogger {
+	return &ContextLogger{
+		Logger: l.Logger.With(args...),
+		ctx:    l.ctx,
+	}
+}
+
+// WithGroup returns a context logger that starts a group.
+func (l *ContextLogger) WithGroup(name string) *ContextLogger {
+	return &ContextLogger{
+		Logger: l.Logger.WithGroup(name),
+		ctx:    l.ctx,
+	}
+}
+
+// WithError ensures we maintain the context when adding the error
+func (l *ContextLogger) WithError(err error) LoggerInterface {
+	if err == nil {
+		return l
+	}
+	return l.WithField(ErrorKey, ErrorValue(err))
+}
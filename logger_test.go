@@ -1,9 +1,14 @@
 package multilog
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -241,6 +246,22 @@ func TestWithContext(t *testing.T) {
 	}
 }
 
+func TestNewContext(t *testing.T) {
+	handler := &recordingHandler{}
+	logger := NewLogger(handler).WithField("request_id", "r-1")
+
+	ctx := NewContext(context.Background(), logger)
+	if FromContext(ctx) != logger {
+		t.Fatal("FromContext should return the logger stored by NewContext")
+	}
+	FromContext(ctx).Info("handled")
+	FromContext(context.Background()).Info("discarded")
+
+	if len(handler.records) != 1 || handler.records[0].Message != "handled" {
+		t.Errorf("expected only the handled record, got %v", handler.messages())
+	}
+}
+
 func TestContextLoggerMethods(t *testing.T) {
 	var buf strings.Builder
 	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
@@ -428,6 +449,67 @@ func TestContextLoggerWithFields(t *testing.T) {
 	}
 }
 
+func TestLoggerWith(t *testing.T) {
+	var buf strings.Builder
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	logger := NewLogger(handler).WithContextKeys(contextKey("tenant"))
+
+	child := logger.With("service", "orders").WithGroup("req").With("id", 7)
+	if child == logger {
+		t.Fatal("With should return a new logger")
+	}
+
+	ctx := context.WithValue(context.Background(), contextKey("tenant"), "acme")
+	child.InfoContext(ctx, "loaded %s", "order")
+	got := buf.String()
+	for _, want := range []string{"service=orders", "req.id=7", "req.tenant=acme", "msg=\"loaded order\""} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %s in output: %s", want, got)
+		}
+	}
+
+	// The parent logger is unchanged.
+	buf.Reset()
+	logger.Info("parent")
+	if strings.Contains(buf.String(), "service") || strings.Contains(buf.String(), "req") {
+		t.Errorf("Unexpected child attributes in output: %s", buf.String())
+	}
+
+	if logger.With() != logger || logger.WithGroup("") != logger {
+		t.Error("With and WithGroup without attributes should return the logger")
+	}
+}
+
+func TestLoggerWithSharedAttrs(t *testing.T) {
+	var buf strings.Builder
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	parent := NewLogger(handler).With("a", 1)
+
+	// Siblings must not overwrite each other's attributes.
+	first := parent.With("b", 2)
+	second := parent.With("c", 3)
+	if first.attrs[2] != "b" || second.attrs[2] != "c" {
+		t.Errorf("Unexpected attributes: %v %v", first.attrs, second.attrs)
+	}
+}
+
+func TestContextLoggerWith(t *testing.T) {
+	var buf strings.Builder
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	logger := NewLogger(handler).WithContextKeys(contextKey("tenant"))
+
+	ctx := context.WithValue(context.Background(), contextKey("tenant"), "acme")
+	ctxLogger, ok := logger.WithContext(ctx).(*ContextLogger)
+	if !ok {
+		t.Fatal("WithContext should return a *ContextLogger")
+	}
+
+	ctxLogger.WithGroup("req").With("id", 7).Info("child")
+	if !strings.Contains(buf.String(), "req.id=7") || !strings.Contains(buf.String(), "req.tenant=acme") {
+		t.Errorf("Expected attributes not found in output: %s", buf.String())
+	}
+}
+
 func TestContextLoggerContextMethods(t *testing.T) {
 	var buf strings.Builder
 	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
@@ -500,3 +582,85 @@ func TestContextLoggerContextMethods(t *testing.T) {
 		})
 	}
 }
+
+func TestLogger_Close(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	fileHandler, err := NewFileHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   "info",
+			Enabled: true,
+			Pattern: "[level] [msg]",
+		},
+		File: file,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create file handler: %v", err)
+	}
+
+	logger := NewLogger(fileHandler)
+	logger.Info("before close")
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush() returned error: %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if string(content) != "INFO before close\n" {
+		t.Errorf("Unexpected log file content: %q", content)
+	}
+}
+
+func TestLoggerSource(t *testing.T) {
+	handler := &recordingHandler{}
+	logger := NewLogger(handler)
+	ctx := context.Background()
+	ctxLogger := logger.WithContext(ctx)
+
+	_, _, line, _ := runtime.Caller(0)
+	logger.Info("info")
+	logger.Infof("infof %d", 1)
+	logger.Perf("perf")
+	logger.Perff("perff %d", 1)
+	logger.ErrorContext(ctx, "error context")
+	logger.With("k", "v").Warn("with")
+	ctxLogger.Debug("context debug")
+	ctxLogger.Perf("context perf")
+	ctxLogger.Warnf("context warnf %d", 1)
+
+	if len(handler.records) != 9 {
+		t.Fatalf("Expected 9 records, got %d", len(handler.records))
+	}
+	for i, record := range handler.records {
+		source := record.Source()
+		if source == nil || filepath.Base(source.File) != "logger_test.go" || source.Line != line+i+1 ||
+			!strings.HasSuffix(source.Function, ".TestLoggerSource") {
+			t.Errorf("Record %q: unexpected source %+v, want logger_test.go:%d", record.Message, source, line+i+1)
+		}
+	}
+}
+
+func TestLoggerSourcePlaceholder(t *testing.T) {
+	var buf bytes.Buffer
+	// The source placeholder is resolved from the record even without AddSource.
+	handler := NewCustomHandler(&CustomHandlerOptions{
+		Level:   DebugLevel,
+		Enabled: true,
+		Pattern: "[source] [msg]",
+	}, bufio.NewWriter(&buf), nil)
+	logger := NewLogger(handler)
+
+	_, _, line, _ := runtime.Caller(0)
+	logger.Perf("perf")
+	if err := handler.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "logger_test.go:" + strconv.Itoa(line+1) + ":multilog.TestLoggerSourcePlaceholder perf "
+	if !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("Expected %q prefix, got %q", want, buf.String())
+	}
+}
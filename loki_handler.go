@@ -0,0 +1,176 @@
+package multilog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// lokiEntry is a single formatted line waiting to be pushed.
+type lokiEntry struct {
+	time  time.Time
+	line  string
+	level string
+}
+
+// lokiStream is a stream in the Loki push API payload.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// lokiPushRequest is the Loki push API payload.
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+// LokiHandler is a Handler that batches records and pushes them to Grafana Loki.
+type LokiHandler struct {
+	Handler *CustomHandler
+	client  *http.Client
+	batch   *batcher[lokiEntry]
+	labels  map[string]string
+	url     string
+	opts    *LokiHandlerOptions
+}
+
+// LokiHandlerOptions are the options of Loki handlers: the formatting and
+// batching options, the push URL, and the labels of the streams.
+type LokiHandlerOptions struct {
+	CustomHandlerOptions
+	BatchOptions
+	Labels map[string]string
+	URL    string
+}
+
+// NewLokiHandler creates a Loki Handler with the specified options.
+// Each line is rendered with the handler pattern and pushed with the configured
+// labels plus a level label. Up to MaxBufferSize (DefaultMaxBufferSize if zero)
+// lines are buffered; when the buffer is full the oldest lines are dropped.
+func NewLokiHandler(opts LokiHandlerOptions) (slog.Handler, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("loki handler requires a url")
+	}
+
+	lh := &LokiHandler{
+		Handler: NewCustomHandler(&opts.CustomHandlerOptions, bufio.NewWriter(io.Discard), nil),
+		client:  &http.Client{Timeout: DefaultHTTPTimeout},
+		labels:  opts.Labels,
+		url:     opts.URL,
+		opts:    &opts,
+	}
+	maxBuffer := defaultIfZero(opts.MaxBufferSize, DefaultMaxBufferSize)
+	lh.batch = newBatcher(opts.BatchSize, opts.FlushInterval, maxBuffer, lh.push, opts.OnError)
+	return lh, nil
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (lh *LokiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return lh.Handler.Enabled(ctx, level)
+}
+
+// Handle formats the log record and queues it for the next push.
+func (lh *LokiHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !lh.Enabled(ctx, record.Level) {
+		return nil
+	}
+	line, err := lh.Handler.Format(ctx, record)
+	if err != nil {
+		return err
+	}
+	return lh.batch.add(ctx, lokiEntry{
+		time:  record.Time,
+		line:  line,
+		level: GetLevelName(record.Level),
+	})
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (lh *LokiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *lh
+	clone.Handler = lh.Handler.WithAttrs(attrs).(*CustomHandler)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (lh *LokiHandler) WithGroup(name string) slog.Handler {
+	clone := *lh
+	clone.Handler = lh.Handler.WithGroup(name).(*CustomHandler)
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (lh *LokiHandler) SetLevel(level string) error {
+	return lh.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (lh *LokiHandler) GetLevel() string {
+	return lh.Handler.GetLevel()
+}
+
+// Flush pushes all queued records.
+func (lh *LokiHandler) Flush() error {
+	return lh.batch.Flush()
+}
+
+// Close pushes all queued records and stops the background flush loop.
+func (lh *LokiHandler) Close() error {
+	return lh.batch.Close()
+}
+
+// Dropped returns the number of lines dropped because the buffer was full.
+func (lh *LokiHandler) Dropped() int {
+	return lh.batch.Dropped()
+}
+
+// customHandler implements customHandlerProvider.
+func (lh *LokiHandler) customHandler() CustomHandlerInterface {
+	return lh.Handler
+}
+
+// push sends a batch of entries to Loki, retrying on failure.
+func (lh *LokiHandler) push(entries []lokiEntry) error {
+	body, err := json.Marshal(lh.buildPushRequest(entries))
+	if err != nil {
+		return fmt.Errorf("failed to marshal loki payload: %w", err)
+	}
+
+	return lh.opts.retryPolicy().Do(func() error {
+		return postJSON(lh.client, lh.url, body, nil)
+	})
+}
+
+// buildPushRequest groups entries into one stream per level.
+func (lh *LokiHandler) buildPushRequest(entries []lokiEntry) lokiPushRequest {
+	streams := make(map[string]*lokiStream)
+	order := make([]string, 0, len(LogLevels))
+	for _, entry := range entries {
+		stream, ok := streams[entry.level]
+		if !ok {
+			labels := make(map[string]string, len(lh.labels)+1)
+			maps.Copy(labels, lh.labels)
+			labels[slog.LevelKey] = entry.level
+			stream = &lokiStream{Stream: labels}
+			streams[entry.level] = stream
+			order = append(order, entry.level)
+		}
+		stream.Values = append(stream.Values, [2]string{
+			strconv.FormatInt(entry.time.UnixNano(), 10),
+			entry.line,
+		})
+	}
+
+	req := lokiPushRequest{Streams: make([]lokiStream, 0, len(order))}
+	for _, level := range order {
+		req.Streams = append(req.Streams, *streams[level])
+	}
+	return req
+}
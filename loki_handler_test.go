@@ -0,0 +1,212 @@
+package multilog
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type lokiServer struct {
+	*httptest.Server
+	requests []lokiPushRequest
+	mu       sync.Mutex
+	fail     int
+}
+
+func newLokiServer(t *testing.T) *lokiServer {
+	t.Helper()
+	ls := &lokiServer{}
+	ls.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ls.mu.Lock()
+		defer ls.mu.Unlock()
+		if ls.fail > 0 {
+			ls.fail--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var req lokiPushRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		ls.requests = append(ls.requests, req)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(ls.Close)
+	return ls
+}
+
+func (ls *lokiServer) received() []lokiPushRequest {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return append([]lokiPushRequest{}, ls.requests...)
+}
+
+func TestNewLokiHandler_RequiresURL(t *testing.T) {
+	_, err := NewLokiHandler(LokiHandlerOptions{CustomHandlerOptions: CustomHandlerOptions{Level: InfoLevel, Enabled: true}})
+	assert.Error(t, err)
+}
+
+func TestLokiHandler_Push(t *testing.T) {
+	server := newLokiServer(t)
+	handler, err := NewLokiHandler(LokiHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   DebugLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+		},
+		BatchOptions: BatchOptions{
+			BatchSize:     10,
+			FlushInterval: time.Hour,
+		},
+		URL:    server.URL,
+		Labels: map[string]string{"app": "test"},
+	})
+	assert.NoError(t, err)
+
+	logger := NewLogger(handler)
+	logger.Info("first", "user", "john")
+	logger.Error("second")
+	logger.Info("third")
+
+	lh := handler.(*LokiHandler)
+	assert.NoError(t, lh.Close())
+
+	requests := server.received()
+	assert.Len(t, requests, 1)
+	streams := requests[0].Streams
+	assert.Len(t, streams, 2)
+	assert.Equal(t, map[string]string{"app": "test", "level": "info"}, streams[0].Stream)
+	assert.Len(t, streams[0].Values, 2)
+	assert.Equal(t, "INFO first [user=john]", streams[0].Values[0][1])
+	assert.Equal(t, map[string]string{"app": "test", "level": "error"}, streams[1].Stream)
+	assert.Equal(t, "ERROR second", streams[1].Values[0][1])
+}
+
+func TestLokiHandler_Retry(t *testing.T) {
+	server := newLokiServer(t)
+	server.fail = 2
+	handler, err := NewLokiHandler(LokiHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+			MaxRetries:    3,
+			RetryBackoff:  time.Millisecond,
+		},
+		URL: server.URL,
+	})
+	assert.NoError(t, err)
+	lh := handler.(*LokiHandler)
+
+	assert.NoError(t, lh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)))
+	assert.NoError(t, lh.Flush())
+	assert.Len(t, server.received(), 1)
+
+	server.fail = 5
+	assert.NoError(t, lh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)))
+	assert.Error(t, lh.Flush())
+	assert.NoError(t, lh.Close())
+}
+
+func TestLokiHandler_NoRetriesFromConfig(t *testing.T) {
+	server := newLokiServer(t)
+	server.fail = 1
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: loki
+      level: info
+      enabled: true
+      url: ` + server.URL + `
+      flush_interval: 1h
+      max_retries: 0
+      retry_backoff: 1ms`))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, batchOptions(&cfg.Multilog.Handlers[0]).MaxRetries, "an explicit 0 is kept")
+	unset := cfg.Multilog.Handlers[0]
+	unset.MaxRetries = nil
+	assert.Equal(t, DefaultMaxRetries, batchOptions(&unset).MaxRetries)
+
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	lh := handlers[0].(*LokiHandler)
+	assert.NoError(t, lh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)))
+	assert.Error(t, lh.Flush())
+
+	// A retry would have been accepted by the server.
+	server.mu.Lock()
+	assert.Equal(t, 0, server.fail, "the push was attempted once")
+	server.mu.Unlock()
+	assert.Empty(t, server.received())
+	_ = lh.Close()
+}
+
+func TestLokiHandler_WithAttrsAndLevel(t *testing.T) {
+	server := newLokiServer(t)
+	handler, err := NewLokiHandler(LokiHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		URL: server.URL,
+	})
+	assert.NoError(t, err)
+	lh := handler.(*LokiHandler)
+
+	assert.False(t, lh.Enabled(context.Background(), slog.LevelDebug))
+	assert.NoError(t, lh.SetLevel(DebugLevel))
+	assert.Equal(t, DebugLevel, lh.GetLevel())
+
+	child := lh.WithAttrs([]slog.Attr{slog.String("k", "v")}).WithGroup("g")
+	assert.NoError(t, child.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelDebug, "child", 0)))
+	assert.NoError(t, lh.Close())
+
+	requests := server.received()
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "DEBUG child [k=v]", requests[0].Streams[0].Values[0][1])
+	assert.Equal(t, lh.Handler, GetCustomHandler(lh))
+}
+
+func TestLokiHandler_MaxBufferSize(t *testing.T) {
+	server := newLokiServer(t)
+	handler, err := NewLokiHandler(LokiHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[msg]",
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+			MaxBufferSize: 2,
+		},
+		URL: server.URL,
+	})
+	assert.NoError(t, err)
+
+	lh := handler.(*LokiHandler)
+	for _, msg := range []string{"first", "second", "third"} {
+		assert.NoError(t, lh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)))
+	}
+	assert.Equal(t, 1, lh.Dropped())
+	assert.NoError(t, lh.Close())
+	assert.Len(t, server.received()[0].Streams[0].Values, 2)
+
+	handler, err = NewLokiHandler(LokiHandlerOptions{URL: server.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultMaxBufferSize, handler.(*LokiHandler).batch.maxItems)
+	assert.NoError(t, handler.(*LokiHandler).Close())
+}
@@ -0,0 +1,103 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Middleware wraps a handler to add cross-cutting behaviour such as enrichment,
+// redaction, metrics, or filtering.
+type Middleware func(slog.Handler) slog.Handler
+
+// HandleFunc handles a record, typically modifying it before passing it to next.
+// Returning without calling next drops the record.
+type HandleFunc func(ctx context.Context, record slog.Record, next slog.Handler) error
+
+// Chain wraps the handler with the middlewares. The first middleware is the
+// outermost one and sees records first.
+func Chain(handler slog.Handler, middlewares ...Middleware) slog.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// HandlerMiddleware creates a middleware from a record-level function.
+// The resulting handler forwards levels, flushing, closing, and rotation to the
+// handler it wraps.
+func HandlerMiddleware(fn HandleFunc) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return &funcHandler{
+			handle:         fn,
+			wrappedHandler: wrappedHandler{Handler: next},
+		}
+	}
+}
+
+// SamplingMiddleware wraps handlers with NewSamplingHandler.
+func SamplingMiddleware(initial, thereafter int, tick time.Duration) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewSamplingHandler(next, initial, thereafter, tick)
+	}
+}
+
+// RateLimitMiddleware wraps handlers with NewRateLimitHandler.
+func RateLimitMiddleware(ratePerSecond float64, burst int) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewRateLimitHandler(next, ratePerSecond, burst)
+	}
+}
+
+// DedupMiddleware wraps handlers with NewDedupHandler.
+func DedupMiddleware(window time.Duration, keys ...string) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewDedupHandler(next, window, keys...)
+	}
+}
+
+// FallbackMiddleware wraps handlers with NewFallbackHandler.
+func FallbackMiddleware(fallback slog.Handler, onError func(err error)) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewFallbackHandler(next, fallback, onError)
+	}
+}
+
+// DeadLetterMiddleware wraps handlers with NewDeadLetterHandler.
+func DeadLetterMiddleware(path string, onError func(err error)) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewDeadLetterHandler(next, path, onError)
+	}
+}
+
+// CircuitBreakerMiddleware wraps handlers with NewCircuitBreakerHandler.
+func CircuitBreakerMiddleware(failures int, cooldown, timeout time.Duration, onError func(err error)) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewCircuitBreakerHandler(next, failures, cooldown, timeout, onError)
+	}
+}
+
+// funcHandler is the handler created by HandlerMiddleware.
+type funcHandler struct {
+	handle HandleFunc
+	wrappedHandler
+}
+
+// Handle passes the record to the middleware function.
+func (fh *funcHandler) Handle(ctx context.Context, record slog.Record) error {
+	return fh.handle(ctx, record, fh.Handler)
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (fh *funcHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *fh
+	clone.Handler = fh.Handler.WithAttrs(attrs)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (fh *funcHandler) WithGroup(name string) slog.Handler {
+	clone := *fh
+	clone.Handler = fh.Handler.WithGroup(name)
+	return &clone
+}
@@ -0,0 +1,69 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tagMiddleware(tag string) Middleware {
+	return HandlerMiddleware(func(ctx context.Context, record slog.Record, next slog.Handler) error {
+		record.Message = tag + record.Message
+		return next.Handle(ctx, record)
+	})
+}
+
+func TestChain(t *testing.T) {
+	recorder := &recordingHandler{}
+	h := Chain(recorder, tagMiddleware("a:"), tagMiddleware("b:"))
+
+	assert.NoError(t, h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)))
+	assert.Equal(t, "b:a:msg", recorder.records[0].Message)
+	assert.Same(t, recorder, Chain(recorder))
+}
+
+func TestHandlerMiddleware_Filter(t *testing.T) {
+	recorder := &recordingHandler{}
+	dropHealth := HandlerMiddleware(func(ctx context.Context, record slog.Record, next slog.Handler) error {
+		if strings.HasPrefix(record.Message, "health") {
+			return nil
+		}
+		return next.Handle(ctx, record)
+	})
+	h := Chain(recorder, dropHealth).WithAttrs([]slog.Attr{slog.String("k", "v")})
+
+	assert.NoError(t, h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "health check", 0)))
+	assert.NoError(t, h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "request", 0)))
+	assert.Len(t, recorder.records, 1)
+	assert.IsType(t, &funcHandler{}, h)
+}
+
+func TestLogger_Use(t *testing.T) {
+	recorder := &recordingHandler{}
+	logger := NewLogger(recorder)
+	enriched := logger.Use(HandlerMiddleware(func(ctx context.Context, record slog.Record, next slog.Handler) error {
+		record.AddAttrs(slog.String("service", "api"))
+		return next.Handle(ctx, record)
+	}))
+
+	enriched.Info("hello")
+	logger.Info("plain")
+	assert.Len(t, recorder.records, 2)
+	assert.Equal(t, 1, recorder.records[0].NumAttrs())
+	assert.Equal(t, 0, recorder.records[1].NumAttrs())
+}
+
+func TestAggregator_Use(t *testing.T) {
+	first, second := &CountingHandler{}, &CountingHandler{}
+	aggregator := NewAggregator(first, second).Use(SamplingMiddleware(1, 0, time.Minute))
+
+	for range 3 {
+		assert.NoError(t, aggregator.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)))
+	}
+	assert.Equal(t, 1, first.callCount)
+	assert.Equal(t, 1, second.callCount)
+}
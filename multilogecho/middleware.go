@@ -0,0 +1,78 @@
+// Package multilogecho provides Echo middleware writing access logs and
+// recovered panics through multilog. It is a separate module to keep Echo out
+// of multilog's dependencies.
+package multilogecho
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/phani-kb/multilog"
+)
+
+// Middleware returns the access log and recovery middleware in the order they
+// must be installed, configured from the access_log section of the config:
+//
+//	e.Use(multilogecho.Middleware(logger, cfg.Multilog.AccessLog)...)
+func Middleware(logger *multilog.Logger, cfg multilog.AccessLogConfig) []echo.MiddlewareFunc {
+	return []echo.MiddlewareFunc{AccessLog(logger, cfg), Recovery(logger)}
+}
+
+// AccessLog logs every request once it is served, unless its path is skipped.
+// Errors returned by later handlers are passed to the Echo error handler first,
+// so the logged status is the one sent. The request context carries a
+// ContextLogger with the method and path attached, available from
+// multilog.FromContext.
+func AccessLog(logger *multilog.Logger, cfg multilog.AccessLogConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+			c.SetRequest(req.WithContext(multilog.NewContext(req.Context(), multilog.RequestLogger(logger, req))))
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			if cfg.Skip(req.URL.Path) {
+				return err
+			}
+			res := c.Response()
+			multilog.LogAccess(req.Context(), logger.WithContext(req.Context()), &cfg, &multilog.Access{
+				Err:      err,
+				Method:   req.Method,
+				Path:     req.URL.Path,
+				ClientIP: c.RealIP(),
+				Status:   res.Status,
+				Bytes:    res.Size,
+				Duration: time.Since(start),
+			})
+			return err
+		}
+	}
+}
+
+// Recovery recovers panics in later handlers, logs them with their stack trace
+// and returns them as errors, which Echo answers with 500 Internal Server
+// Error.
+func Recovery(logger *multilog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				multilog.LogPanic(multilog.RequestLogger(logger, c.Request()), recovered)
+				err = fmt.Errorf("panic: %v", recovered)
+			}()
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package multilogecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/phani-kb/multilog"
+	"github.com/phani-kb/multilog/multilogtest"
+)
+
+// newServer returns an Echo server logging to a recording logger.
+func newServer(cfg multilog.AccessLogConfig) (*echo.Echo, *multilogtest.RecordingHandler) {
+	logger, recorder := multilogtest.NewRecordingLogger()
+	e := echo.New()
+	e.Use(Middleware(logger, cfg)...)
+	e.GET("/orders", func(c echo.Context) error {
+		multilog.FromContext(c.Request().Context()).Info("listing orders")
+		return c.String(http.StatusOK, "[]")
+	})
+	e.GET("/orders/7", func(echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "no such order")
+	})
+	e.GET("/panic", func(echo.Context) error {
+		panic("boom")
+	})
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	return e, recorder
+}
+
+// serve sends a GET request to the server.
+func serve(server http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "10.0.0.7:5123"
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestAccessLog(t *testing.T) {
+	server, recorder := newServer(multilog.AccessLogConfig{})
+	assert.Equal(t, http.StatusOK, serve(server, "/orders").Code)
+
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "listing orders", multilog.HTTPPathKey, "/orders")
+	entry, _ := recorder.AssertLogged(t, multilog.InfoLevel, "request completed")
+	assert.Equal(t, "GET", entry.Attrs[multilog.HTTPMethodKey])
+	assert.Equal(t, "/orders", entry.Attrs[multilog.HTTPPathKey])
+	assert.EqualValues(t, http.StatusOK, entry.Attrs[multilog.HTTPStatusKey])
+	assert.EqualValues(t, 2, entry.Attrs[multilog.HTTPBytesKey])
+	assert.Equal(t, "10.0.0.7", entry.Attrs[multilog.HTTPClientIPKey])
+
+	assert.Equal(t, http.StatusNotFound, serve(server, "/orders/7").Code)
+	recorder.AssertLoggedWith(t, multilog.WarnLevel, "request completed", multilog.HTTPStatusKey, http.StatusNotFound)
+}
+
+func TestAccessLog_Config(t *testing.T) {
+	server, recorder := newServer(multilog.AccessLogConfig{
+		SkipPaths:     []string{"/healthz"},
+		SlowThreshold: time.Nanosecond,
+	})
+	serve(server, "/healthz")
+	recorder.AssertCount(t, "", 0)
+
+	serve(server, "/orders")
+	recorder.AssertLoggedWith(t, multilog.PerfLevel, "slow request", multilog.HTTPPathKey, "/orders")
+}
+
+func TestRecovery(t *testing.T) {
+	server, recorder := newServer(multilog.AccessLogConfig{})
+	assert.Equal(t, http.StatusInternalServerError, serve(server, "/panic").Code)
+
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "panic recovered", multilog.PanicKey, "boom")
+	found := recorder.Find(multilog.ErrorLevel, "panic recovered")
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "/panic", found[0].Attrs[multilog.HTTPPathKey])
+		assert.Contains(t, found[0].Attrs[multilog.StacktraceKey], "multilogecho.newServer")
+	}
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "request completed", "err.message", "panic: boom")
+}
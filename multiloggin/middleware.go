@@ -0,0 +1,70 @@
+// Package multiloggin provides Gin middleware writing access logs and recovered
+// panics through multilog. It is a separate module to keep Gin out of
+// multilog's dependencies.
+package multiloggin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/phani-kb/multilog"
+)
+
+// Middleware returns the access log and recovery middleware in the order they
+// must be installed, configured from the access_log section of the config:
+//
+//	router.Use(multiloggin.Middleware(logger, cfg.Multilog.AccessLog)...)
+func Middleware(logger *multilog.Logger, cfg multilog.AccessLogConfig) []gin.HandlerFunc {
+	return []gin.HandlerFunc{AccessLog(logger, cfg), Recovery(logger)}
+}
+
+// AccessLog logs every request once it is served, unless its path is skipped.
+// The request context carries a ContextLogger with the method and path
+// attached, available from multilog.FromContext.
+func AccessLog(logger *multilog.Logger, cfg multilog.AccessLogConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		req := c.Request
+		c.Request = req.WithContext(multilog.NewContext(req.Context(), multilog.RequestLogger(logger, req)))
+		c.Next()
+
+		if cfg.Skip(req.URL.Path) {
+			return
+		}
+		access := &multilog.Access{
+			Method:   req.Method,
+			Path:     req.URL.Path,
+			ClientIP: c.ClientIP(),
+			Status:   c.Writer.Status(),
+			Bytes:    int64(max(c.Writer.Size(), 0)),
+			Duration: time.Since(start),
+		}
+		if last := c.Errors.Last(); last != nil {
+			access.Err = last
+		}
+		multilog.LogAccess(req.Context(), logger.WithContext(req.Context()), &cfg, access)
+	}
+}
+
+// Recovery recovers panics in later handlers, logs them with their stack trace
+// and responds with 500 Internal Server Error.
+func Recovery(logger *multilog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			multilog.LogPanic(multilog.RequestLogger(logger, c.Request), recovered)
+			_ = c.Error(fmt.Errorf("panic: %v", recovered))
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,82 @@
+package multiloggin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/phani-kb/multilog"
+	"github.com/phani-kb/multilog/multilogtest"
+)
+
+// newRouter returns a router logging to a recording logger.
+func newRouter(cfg multilog.AccessLogConfig) (*gin.Engine, *multilogtest.RecordingHandler) {
+	logger, recorder := multilogtest.NewRecordingLogger()
+	router := gin.New()
+	router.Use(Middleware(logger, cfg)...)
+	router.GET("/orders", func(c *gin.Context) {
+		multilog.FromContext(c.Request.Context()).Info("listing orders")
+		c.String(http.StatusOK, "[]")
+	})
+	router.GET("/panic", func(*gin.Context) {
+		panic("boom")
+	})
+	router.GET("/healthz", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	return router, recorder
+}
+
+// serve sends a GET request to the router.
+func serve(router http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "10.0.0.7:5123"
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAccessLog(t *testing.T) {
+	router, recorder := newRouter(multilog.AccessLogConfig{})
+	assert.Equal(t, http.StatusOK, serve(router, "/orders").Code)
+
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "listing orders", multilog.HTTPPathKey, "/orders")
+	entry, _ := recorder.AssertLogged(t, multilog.InfoLevel, "request completed")
+	assert.Equal(t, "GET", entry.Attrs[multilog.HTTPMethodKey])
+	assert.Equal(t, "/orders", entry.Attrs[multilog.HTTPPathKey])
+	assert.EqualValues(t, http.StatusOK, entry.Attrs[multilog.HTTPStatusKey])
+	assert.EqualValues(t, 2, entry.Attrs[multilog.HTTPBytesKey])
+	assert.Equal(t, "10.0.0.7", entry.Attrs[multilog.HTTPClientIPKey])
+
+	serve(router, "/missing")
+	recorder.AssertLoggedWith(t, multilog.WarnLevel, "request completed", multilog.HTTPStatusKey, http.StatusNotFound)
+}
+
+func TestAccessLog_Config(t *testing.T) {
+	router, recorder := newRouter(multilog.AccessLogConfig{
+		SkipPaths:     []string{"/healthz"},
+		SlowThreshold: time.Nanosecond,
+	})
+	serve(router, "/healthz")
+	recorder.AssertCount(t, "", 0)
+
+	serve(router, "/orders")
+	recorder.AssertLoggedWith(t, multilog.PerfLevel, "slow request", multilog.HTTPPathKey, "/orders")
+}
+
+func TestRecovery(t *testing.T) {
+	router, recorder := newRouter(multilog.AccessLogConfig{})
+	assert.Equal(t, http.StatusInternalServerError, serve(router, "/panic").Code)
+
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "panic recovered", multilog.PanicKey, "boom")
+	found := recorder.Find(multilog.ErrorLevel, "panic recovered")
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "/panic", found[0].Attrs[multilog.HTTPPathKey])
+		assert.Contains(t, found[0].Attrs[multilog.StacktraceKey], "multiloggin.newRouter")
+	}
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "request completed", "err.message", "panic: boom")
+}
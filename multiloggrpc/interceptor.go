@@ -0,0 +1,227 @@
+// Package multiloggrpc provides gRPC client and server interceptors logging
+// every call through multilog. It is a separate module to keep gRPC out of
+// multilog's dependencies.
+package multiloggrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/phani-kb/multilog"
+)
+
+// Call attribute keys
+const (
+	MethodKey   = "grpc.method"
+	CodeKey     = "grpc.code"
+	DurationKey = "duration"
+	PeerKey     = "peer.address"
+)
+
+// Option configures the interceptors.
+type Option func(o *options)
+
+// SlowThreshold logs a perf record for calls taking longer than d. Slow calls
+// are not reported by default.
+func SlowThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = d
+	}
+}
+
+// options holds the interceptor settings.
+type options struct {
+	slowThreshold time.Duration
+}
+
+// newOptions applies the options.
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// CodeLevel returns the level a call finishing with the code is logged at:
+// info for OK, error for server faults, and warn for the rest.
+func CodeLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Unknown, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// UnaryServerInterceptor logs each unary call. The handler context carries a
+// ContextLogger with the method and peer attached, available from
+// multilog.FromContext.
+func UnaryServerInterceptor(logger *multilog.Logger, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		c := newCall(ctx, logger, info.FullMethod, "server unary call")
+		resp, err := handler(multilog.NewContext(ctx, c.logger), req)
+		o.finish(c, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs each streaming call when the handler returns.
+// The stream context carries a ContextLogger, as for UnaryServerInterceptor.
+func StreamServerInterceptor(logger *multilog.Logger, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		c := newCall(ctx, logger, info.FullMethod, "server streaming call")
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: multilog.NewContext(ctx, c.logger)})
+		o.finish(c, err)
+		return err
+	}
+}
+
+// UnaryClientInterceptor logs each unary call made through the connection.
+func UnaryClientInterceptor(logger *multilog.Logger, opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(opts)
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		callOpts ...grpc.CallOption,
+	) error {
+		c := newCall(ctx, logger, method, "client unary call")
+		p := &peer.Peer{}
+		err := invoker(ctx, method, req, reply, cc, append(callOpts, grpc.Peer(p))...)
+		o.finish(c.withPeer(p), err)
+		return err
+	}
+}
+
+// StreamClientInterceptor logs each streaming call made through the
+// connection once the stream ends, that is when RecvMsg returns an error.
+func StreamClientInterceptor(logger *multilog.Logger, opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(opts)
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		callOpts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		c := newCall(ctx, logger, method, "client streaming call")
+		p := &peer.Peer{}
+		cs, err := streamer(ctx, desc, cc, method, append(callOpts, grpc.Peer(p))...)
+		if err != nil {
+			o.finish(c.withPeer(p), err)
+			return nil, err
+		}
+		return &clientStream{ClientStream: cs, finish: func(err error) { o.finish(c.withPeer(p), err) }}, nil
+	}
+}
+
+// call is an RPC being logged.
+type call struct {
+	ctx    context.Context
+	logger multilog.LoggerInterface
+	name   string
+	start  time.Time
+}
+
+// newCall starts timing a call, with a logger carrying the method and, when
+// the context has one, the peer.
+func newCall(ctx context.Context, logger *multilog.Logger, method, name string) call {
+	callLogger := logger.WithField(MethodKey, method)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		callLogger = callLogger.WithField(PeerKey, p.Addr.String())
+	}
+	return call{ctx: ctx, logger: callLogger.WithContext(ctx), name: name, start: time.Now()}
+}
+
+// withPeer returns the call with the peer a client call was sent to.
+func (c call) withPeer(p *peer.Peer) call {
+	if p.Addr != nil {
+		c.logger = c.logger.WithField(PeerKey, p.Addr.String())
+	}
+	return c
+}
+
+// finish logs the call at the level of its status code, and as a perf record
+// when it was slow.
+func (o *options) finish(c call, err error) {
+	duration := time.Since(c.start)
+	code := status.Code(err)
+	args := []any{slog.String(CodeKey, code.String()), slog.Duration(DurationKey, duration)}
+	if err != nil {
+		args = append(args, multilog.ErrorAttr(err))
+	}
+
+	msg := c.name + " finished"
+	switch CodeLevel(code) {
+	case slog.LevelError:
+		c.logger.Error(msg, args...)
+	case slog.LevelWarn:
+		c.logger.Warn(msg, args...)
+	default:
+		c.logger.Info(msg, args...)
+	}
+
+	if o.slowThreshold > 0 && duration > o.slowThreshold {
+		c.logger.GetLogger().Log(
+			c.ctx, multilog.LevelPerf, "slow "+c.name,
+			slog.Duration(DurationKey, duration), slog.Duration("threshold", o.slowThreshold),
+		)
+	}
+}
+
+// serverStream overrides the context of a server stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context carrying the call logger.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// clientStream logs the call when the stream ends.
+type clientStream struct {
+	grpc.ClientStream
+	finish func(err error)
+	once   sync.Once
+}
+
+// RecvMsg receives a message, logging the call on the first error. io.EOF
+// marks a stream that ended successfully.
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(func() {
+			if errors.Is(err, io.EOF) {
+				s.finish(nil)
+				return
+			}
+			s.finish(err)
+		})
+	}
+	return err
+}
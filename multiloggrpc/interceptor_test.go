@@ -0,0 +1,184 @@
+package multiloggrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/phani-kb/multilog"
+	"github.com/phani-kb/multilog/multilogtest"
+)
+
+// peerContext returns a context carrying a client peer address.
+func peerContext() context.Context {
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 7), Port: 5123}
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+}
+
+// setPeer fills the peer requested with grpc.Peer, as a real connection does.
+func setPeer(opts []grpc.CallOption) {
+	for _, opt := range opts {
+		if p, ok := opt.(grpc.PeerCallOption); ok {
+			p.PeerAddr.Addr = &net.TCPAddr{IP: net.IPv4(10, 0, 0, 9), Port: 443}
+		}
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	logger, recorder := multilogtest.NewRecordingLogger()
+	interceptor := UnaryServerInterceptor(logger)
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}
+
+	resp, err := interceptor(peerContext(), "req", info, func(ctx context.Context, _ any) (any, error) {
+		multilog.FromContext(ctx).Info("loading order")
+		return "order", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "order", resp)
+
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "loading order", MethodKey, "/orders.Orders/Get")
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "loading order", PeerKey, "10.0.0.7:5123")
+	entry, _ := recorder.AssertLogged(t, multilog.InfoLevel, "server unary call finished")
+	assert.Equal(t, "/orders.Orders/Get", entry.Attrs[MethodKey])
+	assert.Equal(t, "OK", entry.Attrs[CodeKey])
+	assert.Contains(t, entry.Attrs, DurationKey)
+
+	_, err = interceptor(context.Background(), "req", info, func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.NotFound, "no such order")
+	})
+	assert.Error(t, err)
+	recorder.AssertLoggedWith(t, multilog.WarnLevel, "server unary call finished", CodeKey, "NotFound")
+}
+
+func TestUnaryServerInterceptor_SlowThreshold(t *testing.T) {
+	logger, recorder := multilogtest.NewRecordingLogger()
+	interceptor := UnaryServerInterceptor(logger, SlowThreshold(time.Millisecond))
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/List"}
+
+	_, err := interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		time.Sleep(5 * time.Millisecond)
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	recorder.AssertLoggedWith(t, multilog.PerfLevel, "slow server unary call", MethodKey, "/orders.Orders/List")
+
+	recorder.Reset()
+	interceptor = UnaryServerInterceptor(logger, SlowThreshold(time.Hour))
+	_, _ = interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return nil, nil
+	})
+	recorder.AssertNotLogged(t, multilog.PerfLevel, "slow")
+}
+
+// testServerStream is a server stream with a fixed context.
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	logger, recorder := multilogtest.NewRecordingLogger()
+	interceptor := StreamServerInterceptor(logger)
+	info := &grpc.StreamServerInfo{FullMethod: "/orders.Orders/Watch", IsServerStream: true}
+
+	err := interceptor(nil, &testServerStream{ctx: peerContext()}, info, func(_ any, ss grpc.ServerStream) error {
+		multilog.FromContext(ss.Context()).Info("watching")
+		return errors.New("broken pipe")
+	})
+	assert.Error(t, err)
+
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "watching", MethodKey, "/orders.Orders/Watch")
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "server streaming call finished", CodeKey, "Unknown")
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "server streaming call finished", "err.message", "broken pipe")
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	logger, recorder := multilogtest.NewRecordingLogger()
+	interceptor := UnaryClientInterceptor(logger)
+
+	err := interceptor(context.Background(), "/orders.Orders/Get", "req", nil, nil,
+		func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+			setPeer(opts)
+			return nil
+		})
+	assert.NoError(t, err)
+
+	entry, _ := recorder.AssertLogged(t, multilog.InfoLevel, "client unary call finished")
+	assert.Equal(t, "/orders.Orders/Get", entry.Attrs[MethodKey])
+	assert.Equal(t, "10.0.0.9:443", entry.Attrs[PeerKey])
+	assert.Equal(t, "OK", entry.Attrs[CodeKey])
+}
+
+// testClientStream is a client stream returning the messages, then err.
+type testClientStream struct {
+	grpc.ClientStream
+	err      error
+	messages int
+}
+
+func (s *testClientStream) RecvMsg(any) error {
+	if s.messages == 0 {
+		return s.err
+	}
+	s.messages--
+	return nil
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	logger, recorder := multilogtest.NewRecordingLogger()
+	interceptor := StreamClientInterceptor(logger)
+	streamer := func(err error) grpc.Streamer {
+		return func(
+			context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption,
+		) (grpc.ClientStream, error) {
+			return &testClientStream{messages: 2, err: err}, nil
+		}
+	}
+
+	cs, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/orders.Orders/Watch", streamer(io.EOF))
+	assert.NoError(t, err)
+	for err == nil {
+		err = cs.RecvMsg(nil)
+	}
+	assert.ErrorIs(t, cs.RecvMsg(nil), io.EOF)
+	recorder.AssertCount(t, "", 1)
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "client streaming call finished", CodeKey, "OK")
+
+	cs, err = interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/orders.Orders/Watch",
+		streamer(status.Error(codes.Unavailable, "connection reset")))
+	for err == nil {
+		err = cs.RecvMsg(nil)
+	}
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "client streaming call finished", CodeKey, "Unavailable")
+
+	_, err = interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/orders.Orders/Watch",
+		func(
+			context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption,
+		) (grpc.ClientStream, error) {
+			return nil, status.Error(codes.PermissionDenied, "denied")
+		})
+	assert.Error(t, err)
+	recorder.AssertLoggedWith(t, multilog.WarnLevel, "client streaming call finished", CodeKey, "PermissionDenied")
+}
+
+func TestCodeLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelInfo, CodeLevel(codes.OK))
+	assert.Equal(t, slog.LevelWarn, CodeLevel(codes.InvalidArgument))
+	assert.Equal(t, slog.LevelWarn, CodeLevel(codes.DeadlineExceeded))
+	assert.Equal(t, slog.LevelError, CodeLevel(codes.Internal))
+	assert.Equal(t, slog.LevelError, CodeLevel(codes.Unknown))
+}
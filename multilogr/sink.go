@@ -0,0 +1,149 @@
+// Package multilogr provides a logr.LogSink writing through multilog, so
+// Kubernetes controllers, client-go and other logr users log through multilog
+// handlers. It is a separate module to keep logr out of multilog's dependencies.
+package multilogr
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/phani-kb/multilog"
+)
+
+// nameSeparator joins the names added with WithName, as multilog named loggers do.
+const nameSeparator = "."
+
+// Option configures a sink.
+type Option func(s *logSink)
+
+// MaxVerbosity discards Info records above the V-level v.
+func MaxVerbosity(v int) Option {
+	return func(s *logSink) {
+		s.maxVerbosity = v
+	}
+}
+
+// logSink implements logr.LogSink on top of a slog handler.
+type logSink struct {
+	handler      slog.Handler
+	name         string
+	callDepth    int
+	maxVerbosity int
+}
+
+var (
+	_ logr.LogSink          = (*logSink)(nil)
+	_ logr.CallDepthLogSink = (*logSink)(nil)
+)
+
+// New returns a logr.Logger writing through the multilog logger. V(0) records
+// are logged at the info level and V(1) and above at the debug level, so
+// handler levels still apply. Error records are logged at the error level with
+// the error as the err attribute, and names added with WithName are joined with
+// dots into the logger attribute.
+func New(logger *multilog.Logger, opts ...Option) logr.Logger {
+	return logr.New(NewLogSink(logger, opts...))
+}
+
+// NewLogSink returns the logr.LogSink used by New.
+func NewLogSink(logger *multilog.Logger, opts ...Option) logr.LogSink {
+	s := &logSink{handler: logger.Logger.Handler(), maxVerbosity: math.MaxInt}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// VerbosityLevel returns the multilog level V-level v is logged at.
+func VerbosityLevel(v int) slog.Level {
+	if v <= 0 {
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug
+}
+
+// Init records the call depth of the logr.Logger.
+func (s *logSink) Init(info logr.RuntimeInfo) {
+	s.callDepth += info.CallDepth
+}
+
+// Enabled reports whether records at the V-level are logged.
+func (s *logSink) Enabled(level int) bool {
+	return level <= s.maxVerbosity && s.handler.Enabled(context.Background(), VerbosityLevel(level))
+}
+
+// Info logs a record at the level of the V-level.
+func (s *logSink) Info(level int, msg string, keysAndValues ...any) {
+	s.log(VerbosityLevel(level), msg, keysAndValues)
+}
+
+// Error logs a record at the error level with the error as the err attribute.
+func (s *logSink) Error(err error, msg string, keysAndValues ...any) {
+	if !s.handler.Enabled(context.Background(), slog.LevelError) {
+		return
+	}
+	if err != nil {
+		keysAndValues = append([]any{multilog.ErrorAttr(err)}, keysAndValues...)
+	}
+	s.log(slog.LevelError, msg, keysAndValues)
+}
+
+// WithValues returns a sink adding the key-value pairs to every record.
+func (s *logSink) WithValues(keysAndValues ...any) logr.LogSink {
+	clone := *s
+	clone.handler = s.handler.WithAttrs(toAttrs(keysAndValues))
+	return &clone
+}
+
+// WithName returns a sink whose logger attribute has the name appended.
+func (s *logSink) WithName(name string) logr.LogSink {
+	clone := *s
+	if s.name == "" {
+		clone.name = name
+	} else {
+		clone.name = s.name + nameSeparator + name
+	}
+	return &clone
+}
+
+// WithCallDepth returns a sink skipping depth more frames to find the caller.
+func (s *logSink) WithCallDepth(depth int) logr.LogSink {
+	clone := *s
+	clone.callDepth += depth
+	return &clone
+}
+
+// log passes the record, with the caller of the logr.Logger as its source,
+// to the handler.
+func (s *logSink) log(level slog.Level, msg string, keysAndValues []any) {
+	var pcs [1]uintptr
+	// Skip runtime.Callers, log, and the sink method.
+	runtime.Callers(s.callDepth+3, pcs[:])
+	record := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	if s.name != "" {
+		record.AddAttrs(slog.String(multilog.LoggerKey, s.name))
+	}
+	record.AddAttrs(toAttrs(keysAndValues)...)
+	_ = s.handler.Handle(context.Background(), record)
+}
+
+// toAttrs converts key-value pairs to attributes, rendering values that
+// implement logr.Marshaler with MarshalLog.
+func toAttrs(keysAndValues []any) []slog.Attr {
+	var record slog.Record
+	record.Add(keysAndValues...)
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		if m, ok := attr.Value.Any().(logr.Marshaler); ok && attr.Value.Kind() == slog.KindAny {
+			attr.Value = slog.AnyValue(m.MarshalLog())
+		}
+		attrs = append(attrs, attr)
+		return true
+	})
+	return attrs
+}
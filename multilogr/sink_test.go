@@ -0,0 +1,80 @@
+package multilogr
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/phani-kb/multilog"
+	"github.com/phani-kb/multilog/multilogtest"
+)
+
+type secret string
+
+func (secret) MarshalLog() any {
+	return "***"
+}
+
+func TestSink_Levels(t *testing.T) {
+	logger, recorder := multilogtest.NewRecordingLogger()
+	log := New(logger).WithName("controller").WithName("pods").WithValues("namespace", "default")
+
+	log.Info("reconciled", "pod", "web-1")
+	log.V(1).Info("requeued")
+	log.V(4).Info("cache hit")
+	log.Error(errors.New("conflict"), "update failed", "token", secret("abc"))
+
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "reconciled", "pod", "web-1")
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "reconciled", multilog.LoggerKey, "controller.pods")
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "reconciled", "namespace", "default")
+	recorder.AssertLogged(t, multilog.DebugLevel, "requeued")
+	recorder.AssertLogged(t, multilog.DebugLevel, "cache hit")
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "update failed", "err.message", "conflict")
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "update failed", "token", "***")
+}
+
+func TestSink_Verbosity(t *testing.T) {
+	logger, recorder := multilogtest.NewRecordingLogger()
+	log := New(logger, MaxVerbosity(2))
+	assert.True(t, log.V(2).Enabled())
+	assert.False(t, log.V(3).Enabled())
+
+	log.V(3).Info("too verbose")
+	recorder.AssertCount(t, "", 0)
+
+	infoOnly := recorder.WithLevel(multilog.InfoLevel)
+	log = New(multilog.NewLogger(infoOnly))
+	assert.True(t, log.Enabled())
+	assert.False(t, log.V(1).Enabled())
+}
+
+func TestSink_Source(t *testing.T) {
+	capture := multilogtest.NewCapture()
+	handler := multilog.NewCustomHandler(&multilog.CustomHandlerOptions{
+		Level:     multilog.InfoLevel,
+		Enabled:   true,
+		Pattern:   "[level] [source] [msg]",
+		AddSource: true,
+	}, capture.Writer(), nil)
+	log := New(multilog.NewLogger(handler))
+
+	log.Info("started")
+	helper(log.WithCallDepth(1))
+
+	assert.Regexp(t,
+		`^INFO sink_test\.go:\d+:multilogr\.TestSink_Source started\n`+
+			`INFO sink_test\.go:\d+:multilogr\.TestSink_Source from helper\n$`,
+		capture.String())
+}
+
+func helper(log interface{ Info(string, ...any) }) {
+	log.Info("from helper")
+}
+
+func TestVerbosityLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelInfo, VerbosityLevel(0))
+	assert.Equal(t, slog.LevelDebug, VerbosityLevel(1))
+	assert.Equal(t, slog.LevelDebug, VerbosityLevel(5))
+}
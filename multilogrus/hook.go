@@ -0,0 +1,90 @@
+// Package multilogrus provides a logrus.Hook sending entries to multilog, so
+// projects migrating from logrus can send all output to multilog handlers. It
+// is a separate module to keep logrus out of multilog's dependencies.
+package multilogrus
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/phani-kb/multilog"
+)
+
+// Hook is a logrus.Hook logging entries through the handlers of a multilog
+// Logger. To stop logrus writing its own output, set it to io.Discard.
+type Hook struct {
+	handler slog.Handler
+	levels  []logrus.Level
+}
+
+var _ logrus.Hook = (*Hook)(nil)
+
+// NewHook creates a hook for the levels, or for all levels if none are given.
+// Logrus levels map to the nearest multilog level: trace to debug, and fatal
+// and panic to error. Entry fields become attributes sorted by key, with an
+// error under logrus.ErrorKey rendered as the multilog err attribute.
+func NewHook(logger *multilog.Logger, levels ...logrus.Level) *Hook {
+	if len(levels) == 0 {
+		levels = logrus.AllLevels
+	}
+	return &Hook{handler: logger.Logger.Handler(), levels: levels}
+}
+
+// Level returns the multilog level a logrus level is logged at.
+func Level(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return slog.LevelDebug
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// Levels returns the levels the hook fires for.
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire logs the entry, with the caller logrus reported as its source.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := Level(entry.Level)
+	if !h.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	var pc uintptr
+	if entry.Caller != nil {
+		pc = entry.Caller.PC
+	}
+	record := slog.NewRecord(entry.Time, level, entry.Message, pc)
+	record.AddAttrs(dataAttrs(entry.Data)...)
+	return h.handler.Handle(ctx, record)
+}
+
+// dataAttrs converts the entry fields to attributes sorted by key.
+func dataAttrs(data logrus.Fields) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(data))
+	for key, value := range data {
+		if err, ok := value.(error); ok && key == logrus.ErrorKey {
+			attrs = append(attrs, multilog.ErrorAttr(err))
+			continue
+		}
+		attrs = append(attrs, slog.Any(key, value))
+	}
+	slices.SortFunc(attrs, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return attrs
+}
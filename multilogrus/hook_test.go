@@ -0,0 +1,75 @@
+package multilogrus
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/phani-kb/multilog"
+	"github.com/phani-kb/multilog/multilogtest"
+)
+
+// newLogrus returns a logrus logger discarding its own output and sending
+// entries to a recording multilog logger.
+func newLogrus(levels ...logrus.Level) (*logrus.Logger, *multilogtest.RecordingHandler) {
+	logger, recorder := multilogtest.NewRecordingLogger()
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	log.SetLevel(logrus.TraceLevel)
+	log.AddHook(NewHook(logger, levels...))
+	return log, recorder
+}
+
+func TestHook(t *testing.T) {
+	log, recorder := newLogrus()
+	log.WithFields(logrus.Fields{"user": "ana", "attempt": 2}).Info("signed in")
+	log.Trace("token refreshed")
+	log.WithError(errors.New("timeout")).Error("sync failed")
+
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "signed in", "user", "ana")
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "signed in", "attempt", 2)
+	recorder.AssertLogged(t, multilog.DebugLevel, "token refreshed")
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "sync failed", "err.message", "timeout")
+
+	entries := recorder.Entries()
+	assert.False(t, entries[0].Time.IsZero())
+}
+
+func TestHook_Levels(t *testing.T) {
+	log, recorder := newLogrus(logrus.WarnLevel, logrus.ErrorLevel)
+	log.Info("ignored")
+	log.Warn("disk almost full")
+
+	recorder.AssertCount(t, "", 1)
+	recorder.AssertLogged(t, multilog.WarnLevel, "disk")
+	assert.Equal(t, logrus.AllLevels, NewHook(multilog.NewLogger()).Levels())
+}
+
+func TestHook_Source(t *testing.T) {
+	capture := multilogtest.NewCapture()
+	handler := multilog.NewCustomHandler(&multilog.CustomHandlerOptions{
+		Level:     multilog.InfoLevel,
+		Enabled:   true,
+		Pattern:   "[level] [source] [msg]",
+		AddSource: true,
+	}, capture.Writer(), nil)
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	log.SetReportCaller(true)
+	log.AddHook(NewHook(multilog.NewLogger(handler)))
+
+	log.Info("started")
+	assert.Regexp(t, `^INFO hook_test\.go:\d+:multilogrus\.TestHook_Source started\n$`, capture.String())
+}
+
+func TestLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, Level(logrus.TraceLevel))
+	assert.Equal(t, slog.LevelDebug, Level(logrus.DebugLevel))
+	assert.Equal(t, slog.LevelInfo, Level(logrus.InfoLevel))
+	assert.Equal(t, slog.LevelWarn, Level(logrus.WarnLevel))
+	assert.Equal(t, slog.LevelError, Level(logrus.PanicLevel))
+}
@@ -0,0 +1,238 @@
+package multilogtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phani-kb/multilog"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden write the
+// golden files instead of comparing against them, e.g.
+// MULTILOG_UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "MULTILOG_UPDATE_GOLDEN"
+
+// GoldenTime is the first time reported by NewGoldenLogger.
+var GoldenTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// stablePC is the program counter deterministic records carry.
+var stablePC = stableCall()
+
+// StableSource returns the source, as file:line:function, that deterministic
+// records report.
+func StableSource() string {
+	frame, _ := runtime.CallersFrames([]uintptr{stablePC}).Next()
+	fn := frame.Function
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	return filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line) + ":" + fn
+}
+
+// Clock is a fake clock that advances by a fixed step on every reading.
+type Clock struct {
+	now  time.Time
+	step time.Duration
+	mu   sync.Mutex
+}
+
+// NewClock creates a clock starting at start and advancing by step.
+func NewClock(start time.Time, step time.Duration) *Clock {
+	return &Clock{now: start, step: step}
+}
+
+// Now returns the current time of the clock and advances it.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// DeterministicHandler wraps a handler so its output does not depend on when
+// or where records are logged: record times come from a Clock, every record
+// reports StableSource as its source, and the attributes of each record and
+// of each WithAttrs call are sorted by key.
+type DeterministicHandler struct {
+	next  slog.Handler
+	clock *Clock
+}
+
+// NewDeterministicHandler wraps next with times taken from clock.
+func NewDeterministicHandler(next slog.Handler, clock *Clock) *DeterministicHandler {
+	return &DeterministicHandler{next: next, clock: clock}
+}
+
+// NewGoldenLogger creates a Logger writing to the handlers through a
+// DeterministicHandler starting at GoldenTime and advancing a second per record.
+func NewGoldenLogger(handlers ...slog.Handler) *multilog.Logger {
+	aggregator := multilog.NewLogger(handlers...).Logger.Handler()
+	return multilog.NewLogger(NewDeterministicHandler(aggregator, NewClock(GoldenTime, time.Second)))
+}
+
+// Enabled reports whether the wrapped handler is enabled for the level.
+func (h *DeterministicHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle passes a deterministic copy of the record to the wrapped handler.
+func (h *DeterministicHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	sortAttrs(attrs)
+
+	stable := slog.NewRecord(h.clock.Now(), record.Level, record.Message, stablePC)
+	stable.AddAttrs(attrs...)
+	return h.next.Handle(ctx, stable)
+}
+
+// WithAttrs creates a new handler with the given attributes, sorted by key.
+func (h *DeterministicHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	sorted := slices.Clone(attrs)
+	sortAttrs(sorted)
+	return &DeterministicHandler{next: h.next.WithAttrs(sorted), clock: h.clock}
+}
+
+// WithGroup creates a new handler with the given group name.
+func (h *DeterministicHandler) WithGroup(name string) slog.Handler {
+	return &DeterministicHandler{next: h.next.WithGroup(name), clock: h.clock}
+}
+
+// sortAttrs sorts the attributes, and those of groups, by key.
+func sortAttrs(attrs []slog.Attr) {
+	for i, attr := range attrs {
+		if attr.Value.Kind() == slog.KindGroup {
+			group := slices.Clone(attr.Value.Group())
+			sortAttrs(group)
+			attrs[i] = slog.Attr{Key: attr.Key, Value: slog.GroupValue(group...)}
+		}
+	}
+	slices.SortStableFunc(attrs, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+}
+
+// Capture collects the output of handlers created with its writer.
+type Capture struct {
+	writer *bufio.Writer
+	buf    bytes.Buffer
+	mu     sync.Mutex
+}
+
+// NewCapture creates an empty capture.
+func NewCapture() *Capture {
+	c := &Capture{}
+	c.writer = bufio.NewWriter(&lockedWriter{capture: c})
+	return c
+}
+
+// Writer returns the writer to create handlers with, e.g.
+// multilog.NewCustomHandler(&opts, capture.Writer(), nil).
+func (c *Capture) Writer() *bufio.Writer {
+	return c.writer
+}
+
+// Bytes flushes the writer and returns the captured output.
+func (c *Capture) Bytes() []byte {
+	_ = c.writer.Flush()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return bytes.Clone(c.buf.Bytes())
+}
+
+// String flushes the writer and returns the captured output.
+func (c *Capture) String() string {
+	return string(c.Bytes())
+}
+
+// Reset discards the captured output.
+func (c *Capture) Reset() {
+	_ = c.writer.Flush()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buf.Reset()
+}
+
+// lockedWriter appends to the buffer of a capture under its lock.
+type lockedWriter struct {
+	capture *Capture
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.capture.mu.Lock()
+	defer w.capture.mu.Unlock()
+	return w.capture.buf.Write(p)
+}
+
+// AssertGolden fails the test unless got matches the golden file at path,
+// reporting the first differing line. With MULTILOG_UPDATE_GOLDEN set, the
+// golden file is written with got instead.
+func AssertGolden(t testing.TB, path string, got []byte) bool {
+	t.Helper()
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Errorf("failed to create golden directory: %v", err)
+			return false
+		}
+		if err := os.WriteFile(path, got, 0o600); err != nil {
+			t.Errorf("failed to write golden file: %v", err)
+			return false
+		}
+		return true
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("failed to read golden file (run with %s=1 to create it): %v", UpdateGoldenEnv, err)
+		return false
+	}
+	if bytes.Equal(want, got) {
+		return true
+	}
+	wantLines := strings.Split(string(want), "\n")
+	gotLines := strings.Split(string(got), "\n")
+	for i := range max(len(wantLines), len(gotLines)) {
+		w, g := lineAt(wantLines, i), lineAt(gotLines, i)
+		if w != g {
+			t.Errorf("output differs from %s at line %d:\n  want: %q\n   got: %q\n(run with %s=1 to update)",
+				path, i+1, w, g, UpdateGoldenEnv)
+			break
+		}
+	}
+	return false
+}
+
+// AssertGoldenFile fails the test unless the log file at logPath matches the
+// golden file at path, as AssertGolden does.
+func AssertGoldenFile(t testing.TB, path, logPath string) bool {
+	t.Helper()
+	got, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Errorf("failed to read log file: %v", err)
+		return false
+	}
+	return AssertGolden(t, path, got)
+}
+
+// lineAt returns the i-th line, or "<missing>" past the end.
+func lineAt(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return "<missing>"
+}
@@ -0,0 +1,103 @@
+package multilogtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/phani-kb/multilog"
+)
+
+func TestClock(t *testing.T) {
+	clock := NewClock(GoldenTime, time.Minute)
+	assert.Equal(t, GoldenTime, clock.Now())
+	assert.Equal(t, GoldenTime.Add(time.Minute), clock.Now())
+}
+
+func TestStableSource(t *testing.T) {
+	assert.Equal(t, "source.go:9:multilogtest.stableCall", StableSource())
+}
+
+func TestDeterministicHandler(t *testing.T) {
+	recorder := NewRecordingHandler()
+	capture := NewCapture()
+	text := multilog.NewCustomHandler(&multilog.CustomHandlerOptions{
+		Level:     multilog.DebugLevel,
+		Enabled:   true,
+		Pattern:   "[datetime] [level] [source] [msg]",
+		AddSource: true,
+	}, capture.Writer(), nil)
+
+	logger := NewGoldenLogger(text, recorder).WithField("service", "api")
+	logger.Info("started", "zone", "eu", "attempt", 1)
+	logger.Error("failed", "code", 500, "cause", "timeout")
+
+	entries := recorder.Entries()
+	assert.Equal(t, GoldenTime, entries[0].Time)
+	assert.Equal(t, GoldenTime.Add(time.Second), entries[1].Time)
+	assert.Equal(t,
+		"2024-01-02 03:04:05 INFO "+StableSource()+" started [service=api attempt=1 zone=eu]\n"+
+			"2024-01-02 03:04:06 ERROR "+StableSource()+" failed [service=api cause=timeout code=500]\n",
+		capture.String())
+
+	capture.Reset()
+	assert.Empty(t, capture.String())
+}
+
+func TestAssertGolden(t *testing.T) {
+	capture := NewCapture()
+	text := multilog.NewCustomHandler(&multilog.CustomHandlerOptions{
+		Level:   multilog.InfoLevel,
+		Enabled: true,
+		Pattern: "[datetime] [level] [msg]",
+	}, capture.Writer(), nil)
+	logger := NewGoldenLogger(text)
+	logger.Info("user created", "id", 7, "name", "ana")
+	logger.Warn("quota low", "remaining", 3)
+
+	AssertGolden(t, filepath.Join("testdata", "text.golden"), capture.Bytes())
+}
+
+func TestAssertGolden_Mismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.golden")
+	assert.NoError(t, os.WriteFile(path, []byte("line one\nline two\n"), 0o600))
+
+	ft := &fakeT{}
+	assert.True(t, AssertGolden(ft, path, []byte("line one\nline two\n")))
+	assert.False(t, AssertGolden(ft, path, []byte("line one\nline 2\n")))
+	assert.False(t, AssertGolden(ft, filepath.Join(t.TempDir(), "missing.golden"), nil))
+	assert.Len(t, ft.errors, 2)
+	assert.Contains(t, ft.errors[0], "at line 2")
+	assert.Contains(t, ft.errors[0], `want: "line two"`)
+	assert.Contains(t, ft.errors[1], UpdateGoldenEnv)
+}
+
+func TestAssertGolden_Update(t *testing.T) {
+	t.Setenv(UpdateGoldenEnv, "1")
+	path := filepath.Join(t.TempDir(), "new", "out.golden")
+	assert.True(t, AssertGolden(t, path, []byte("snapshot\n")))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "snapshot\n", string(data))
+}
+
+func TestAssertGoldenFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.json")
+	handler, err := multilog.NewJSONHandler(multilog.FileHandlerOptions{
+		CustomHandlerOptions: multilog.CustomHandlerOptions{
+			Level:               multilog.InfoLevel,
+			Enabled:             true,
+			PatternPlaceholders: []string{"[datetime]", "[level]", "[msg]"},
+		},
+		File: file,
+	}, nil)
+	assert.NoError(t, err)
+	NewGoldenLogger(handler).Info("order placed", "total", 9.5, "items", 2)
+	assert.NoError(t, handler.(*multilog.JSONHandler).Close())
+
+	AssertGoldenFile(t, filepath.Join("testdata", "json.golden"), file)
+}
@@ -0,0 +1,234 @@
+// Package multilogtest provides a recording handler and assertion helpers for
+// unit-testing code that logs with multilog.
+package multilogtest
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phani-kb/multilog"
+)
+
+// Entry is a record captured by a RecordingHandler. Attributes added with
+// WithAttrs and on the record are flattened into Attrs, with group names joined
+// by dots as in "req.id".
+type Entry struct {
+	Time    time.Time
+	Attrs   map[string]any
+	Level   string
+	Message string
+}
+
+// Attr returns the value of the attribute with the key and whether it is set.
+func (e Entry) Attr(key string) (any, bool) {
+	value, ok := e.Attrs[key]
+	return value, ok
+}
+
+// recording holds the entries shared by a handler and its derived handlers.
+type recording struct {
+	entries []Entry
+	mu      sync.RWMutex
+}
+
+// RecordingHandler is a slog.Handler that stores every record it handles.
+// Handlers derived with WithAttrs and WithGroup record into the same entries.
+type RecordingHandler struct {
+	recording *recording
+	attrs     map[string]any
+	group     string
+	level     slog.Level
+}
+
+// NewRecordingHandler creates a handler recording records at all levels.
+func NewRecordingHandler() *RecordingHandler {
+	return &RecordingHandler{recording: &recording{}, level: slog.Level(math.MinInt)}
+}
+
+// NewRecordingLogger creates a Logger writing to a new RecordingHandler.
+func NewRecordingLogger() (*multilog.Logger, *RecordingHandler) {
+	handler := NewRecordingHandler()
+	return multilog.NewLogger(handler), handler
+}
+
+// WithLevel returns a handler recording only records at or above the level,
+// sharing the entries of h.
+func (h *RecordingHandler) WithLevel(level string) *RecordingHandler {
+	clone := *h
+	clone.level = multilog.GetSlogLevel(level)
+	return &clone
+}
+
+// Enabled reports whether the level is at or above the minimum level.
+func (h *RecordingHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+// Handle records the record.
+func (h *RecordingHandler) Handle(_ context.Context, record slog.Record) error {
+	attrs := make(map[string]any, len(h.attrs)+record.NumAttrs())
+	for key, value := range h.attrs {
+		attrs[key] = value
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addAttr(attrs, h.group, attr)
+		return true
+	})
+
+	h.recording.mu.Lock()
+	defer h.recording.mu.Unlock()
+	h.recording.entries = append(h.recording.entries, Entry{
+		Time:    record.Time,
+		Attrs:   attrs,
+		Level:   multilog.GetLevelName(record.Level),
+		Message: record.Message,
+	})
+	return nil
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (h *RecordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = make(map[string]any, len(h.attrs)+len(attrs))
+	for key, value := range h.attrs {
+		clone.attrs[key] = value
+	}
+	for _, attr := range attrs {
+		addAttr(clone.attrs, h.group, attr)
+	}
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (h *RecordingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.group = h.group + name + "."
+	return &clone
+}
+
+// Entries returns a copy of the recorded entries in the order they were logged.
+func (h *RecordingHandler) Entries() []Entry {
+	h.recording.mu.RLock()
+	defer h.recording.mu.RUnlock()
+	return append([]Entry(nil), h.recording.entries...)
+}
+
+// Len returns the number of recorded entries.
+func (h *RecordingHandler) Len() int {
+	h.recording.mu.RLock()
+	defer h.recording.mu.RUnlock()
+	return len(h.recording.entries)
+}
+
+// Reset discards the recorded entries.
+func (h *RecordingHandler) Reset() {
+	h.recording.mu.Lock()
+	defer h.recording.mu.Unlock()
+	h.recording.entries = nil
+}
+
+// Find returns the entries at the level whose message contains msgContains.
+// An empty level matches every level.
+func (h *RecordingHandler) Find(level, msgContains string) []Entry {
+	var found []Entry
+	for _, entry := range h.Entries() {
+		if (level == "" || entry.Level == level) && strings.Contains(entry.Message, msgContains) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// AssertLogged fails the test unless an entry at the level has a message
+// containing msgContains, and returns the first such entry.
+func (h *RecordingHandler) AssertLogged(t testing.TB, level, msgContains string) (Entry, bool) {
+	t.Helper()
+	found := h.Find(level, msgContains)
+	if len(found) == 0 {
+		t.Errorf("expected a %s entry containing %q, got:\n%s", level, msgContains, h.summary())
+		return Entry{}, false
+	}
+	return found[0], true
+}
+
+// AssertNotLogged fails the test if an entry at the level has a message
+// containing msgContains.
+func (h *RecordingHandler) AssertNotLogged(t testing.TB, level, msgContains string) bool {
+	t.Helper()
+	if found := h.Find(level, msgContains); len(found) > 0 {
+		t.Errorf("expected no %s entry containing %q, got %d", level, msgContains, len(found))
+		return false
+	}
+	return true
+}
+
+// AssertLoggedWith fails the test unless an entry at the level has a message
+// containing msgContains and the attribute key set to value.
+func (h *RecordingHandler) AssertLoggedWith(t testing.TB, level, msgContains, key string, value any) bool {
+	t.Helper()
+	for _, entry := range h.Find(level, msgContains) {
+		if got, ok := entry.Attr(key); ok && equalValues(got, value) {
+			return true
+		}
+	}
+	t.Errorf("expected a %s entry containing %q with %s=%v, got:\n%s", level, msgContains, key, value, h.summary())
+	return false
+}
+
+// AssertCount fails the test unless exactly n entries were recorded at the
+// level; an empty level counts every entry.
+func (h *RecordingHandler) AssertCount(t testing.TB, level string, n int) bool {
+	t.Helper()
+	if got := len(h.Find(level, "")); got != n {
+		t.Errorf("expected %d %s entries, got %d:\n%s", n, level, got, h.summary())
+		return false
+	}
+	return true
+}
+
+// summary lists the recorded entries for failure messages.
+func (h *RecordingHandler) summary() string {
+	entries := h.Entries()
+	if len(entries) == 0 {
+		return "  (no entries)"
+	}
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, "  ["+entry.Level+"] "+entry.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// addAttr adds the resolved attribute to attrs under the group prefix,
+// flattening group values.
+func addAttr(attrs map[string]any, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, a := range attr.Value.Group() {
+			addAttr(attrs, prefix, a)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+	attrs[prefix+attr.Key] = attr.Value.Any()
+}
+
+// equalValues compares attribute values as slog does, so that an int matches
+// the int64 recorded for it.
+func equalValues(a, b any) bool {
+	return reflect.DeepEqual(slog.AnyValue(a).Any(), slog.AnyValue(b).Any())
+}
@@ -0,0 +1,108 @@
+package multilogtest
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/phani-kb/multilog"
+)
+
+// fakeT records the failures reported by the assertion helpers.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecordingHandler(t *testing.T) {
+	logger, handler := NewRecordingLogger()
+	logger.WithField("service", "api").Info("user created", "id", 7)
+	logger.Error("payment failed", "code", 500)
+	logger.Debug("cache miss")
+
+	entries := handler.Entries()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, multilog.InfoLevel, entries[0].Level)
+	assert.Equal(t, "user created", entries[0].Message)
+	assert.Equal(t, map[string]any{"service": "api", "id": int64(7)}, entries[0].Attrs)
+	assert.False(t, entries[0].Time.IsZero())
+
+	entry, ok := handler.AssertLogged(t, multilog.ErrorLevel, "payment")
+	assert.True(t, ok)
+	assert.Equal(t, "payment failed", entry.Message)
+	handler.AssertLoggedWith(t, multilog.ErrorLevel, "payment", "code", 500)
+	handler.AssertNotLogged(t, multilog.WarnLevel, "payment")
+	handler.AssertCount(t, "", 3)
+	handler.AssertCount(t, multilog.DebugLevel, 1)
+
+	handler.Reset()
+	assert.Equal(t, 0, handler.Len())
+	assert.Empty(t, handler.Entries())
+}
+
+func TestRecordingHandler_Groups(t *testing.T) {
+	handler := NewRecordingHandler()
+	logger := slog.New(handler).With("app", "shop").WithGroup("req").With("id", 7)
+	logger.Info("handled", slog.Group("user", "name", "ana"), "status", 200)
+
+	entry, _ := handler.AssertLogged(t, multilog.InfoLevel, "handled")
+	assert.Equal(t, map[string]any{
+		"app":           "shop",
+		"req.id":        int64(7),
+		"req.user.name": "ana",
+		"req.status":    int64(200),
+	}, entry.Attrs)
+	value, ok := entry.Attr("req.user.name")
+	assert.True(t, ok)
+	assert.Equal(t, "ana", value)
+}
+
+func TestRecordingHandler_WithLevel(t *testing.T) {
+	handler := NewRecordingHandler()
+	warnings := handler.WithLevel(multilog.WarnLevel)
+	logger := multilog.NewLogger(warnings)
+	logger.Info("ignored")
+	logger.Warn("disk almost full")
+
+	// Entries are shared with the handler the level was derived from.
+	handler.AssertCount(t, "", 1)
+	handler.AssertLogged(t, multilog.WarnLevel, "disk")
+}
+
+func TestRecordingHandler_Failures(t *testing.T) {
+	logger, handler := NewRecordingLogger()
+	logger.Info("started")
+	ft := &fakeT{}
+
+	_, ok := handler.AssertLogged(ft, multilog.ErrorLevel, "started")
+	assert.False(t, ok)
+	assert.False(t, handler.AssertNotLogged(ft, multilog.InfoLevel, "start"))
+	assert.False(t, handler.AssertLoggedWith(ft, multilog.InfoLevel, "started", "id", 1))
+	assert.False(t, handler.AssertCount(ft, multilog.InfoLevel, 2))
+	assert.Len(t, ft.errors, 4)
+	assert.Contains(t, ft.errors[0], `expected a error entry containing "started"`)
+	assert.Contains(t, ft.errors[0], "[info] started")
+}
+
+func TestRecordingHandler_Concurrent(t *testing.T) {
+	logger, handler := NewRecordingLogger()
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Info("worker", "n", i)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 10, handler.Len())
+}
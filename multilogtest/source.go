@@ -0,0 +1,11 @@
+package multilogtest
+
+import "runtime"
+
+// stableCall is the function deterministic records report as their source.
+// Keep it in this file, on this line, so golden files stay valid.
+func stableCall() uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	return pcs[0]
+}
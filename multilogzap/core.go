@@ -0,0 +1,108 @@
+// Package multilogzap provides a zapcore.Core writing through multilog, so
+// projects migrating from zap can send all output to multilog handlers. It is
+// a separate module to keep zap out of multilog's dependencies.
+package multilogzap
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/phani-kb/multilog"
+)
+
+// core implements zapcore.Core on top of the handler of a multilog Logger.
+type core struct {
+	logger  *multilog.Logger
+	handler slog.Handler
+}
+
+// NewCore returns a zapcore.Core writing zap entries through the logger's
+// handlers. Zap levels map to the nearest multilog level, DPanic and above to
+// error. The logger name is kept as the logger attribute, a stack trace as the
+// stacktrace attribute, and zap namespaces become groups. Sync flushes the
+// logger.
+func NewCore(logger *multilog.Logger) zapcore.Core {
+	return &core{logger: logger, handler: logger.Logger.Handler()}
+}
+
+// Level returns the multilog level a zap level is logged at.
+func Level(level zapcore.Level) slog.Level {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return slog.LevelDebug
+	case level == zapcore.InfoLevel:
+		return slog.LevelInfo
+	case level == zapcore.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// Enabled reports whether the handlers log records at the level.
+func (c *core) Enabled(level zapcore.Level) bool {
+	return c.handler.Enabled(context.Background(), Level(level))
+}
+
+// With returns a core adding the fields to every entry.
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{logger: c.logger, handler: c.handler.WithAttrs(fieldAttrs(fields))}
+}
+
+// Check adds the core to the checked entry if the level is enabled.
+func (c *core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write logs the entry with the fields.
+func (c *core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	record := slog.NewRecord(entry.Time, Level(entry.Level), entry.Message, entry.Caller.PC)
+	if entry.LoggerName != "" {
+		record.AddAttrs(slog.String(multilog.LoggerKey, entry.LoggerName))
+	}
+	record.AddAttrs(fieldAttrs(fields)...)
+	if entry.Stack != "" {
+		record.AddAttrs(slog.String(multilog.StacktraceKey, entry.Stack))
+	}
+	return c.handler.Handle(context.Background(), record)
+}
+
+// Sync flushes the logger.
+func (c *core) Sync() error {
+	return c.logger.Flush()
+}
+
+// fieldAttrs encodes the fields into attributes sorted by key.
+func fieldAttrs(fields []zapcore.Field) []slog.Attr {
+	if len(fields) == 0 {
+		return nil
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return mapAttrs(enc.Fields)
+}
+
+// mapAttrs converts encoded fields to attributes, nested maps to groups.
+func mapAttrs(fields map[string]any) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for key, value := range fields {
+		if nested, ok := value.(map[string]any); ok {
+			attrs = append(attrs, slog.Attr{Key: key, Value: slog.GroupValue(mapAttrs(nested)...)})
+			continue
+		}
+		attrs = append(attrs, slog.Any(key, value))
+	}
+	slices.SortFunc(attrs, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return attrs
+}
@@ -0,0 +1,62 @@
+package multilogzap
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/phani-kb/multilog"
+	"github.com/phani-kb/multilog/multilogtest"
+)
+
+func TestCore(t *testing.T) {
+	logger, recorder := multilogtest.NewRecordingLogger()
+	log := zap.New(NewCore(logger)).Named("billing").With(zap.String("service", "api"))
+
+	log.Info("charged", zap.Int("amount", 42))
+	log.Debug("cache miss")
+	log.Error("charge failed", zap.Error(errors.New("card declined")), zap.Namespace("card"), zap.String("brand", "visa"))
+	assert.NoError(t, log.Sync())
+
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "charged", "amount", 42)
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "charged", "service", "api")
+	recorder.AssertLoggedWith(t, multilog.InfoLevel, "charged", multilog.LoggerKey, "billing")
+	recorder.AssertLogged(t, multilog.DebugLevel, "cache miss")
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "charge failed", "error", "card declined")
+	recorder.AssertLoggedWith(t, multilog.ErrorLevel, "charge failed", "card.brand", "visa")
+}
+
+func TestCore_Enabled(t *testing.T) {
+	recorder := multilogtest.NewRecordingHandler().WithLevel(multilog.WarnLevel)
+	c := NewCore(multilog.NewLogger(recorder))
+	assert.False(t, c.Enabled(zapcore.InfoLevel))
+	assert.True(t, c.Enabled(zapcore.WarnLevel))
+
+	zap.New(c).Info("dropped")
+	recorder.AssertCount(t, "", 0)
+}
+
+func TestCore_Source(t *testing.T) {
+	capture := multilogtest.NewCapture()
+	handler := multilog.NewCustomHandler(&multilog.CustomHandlerOptions{
+		Level:     multilog.InfoLevel,
+		Enabled:   true,
+		Pattern:   "[level] [source] [msg]",
+		AddSource: true,
+	}, capture.Writer(), nil)
+
+	zap.New(NewCore(multilog.NewLogger(handler)), zap.AddCaller()).Warn("disk almost full")
+	assert.Regexp(t, `^WARN core_test\.go:\d+:multilogzap\.TestCore_Source disk almost full\n$`, capture.String())
+}
+
+func TestLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, Level(zapcore.DebugLevel))
+	assert.Equal(t, slog.LevelInfo, Level(zapcore.InfoLevel))
+	assert.Equal(t, slog.LevelWarn, Level(zapcore.WarnLevel))
+	assert.Equal(t, slog.LevelError, Level(zapcore.ErrorLevel))
+	assert.Equal(t, slog.LevelError, Level(zapcore.FatalLevel))
+}
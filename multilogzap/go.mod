@@ -0,0 +1,17 @@
+module github.com/phani-kb/multilog/multilogzap
+
+go 1.25
+
+require (
+	github.com/phani-kb/multilog v0.1.0
+	github.com/stretchr/testify v1.11.1
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/multierr v1.11.0 // indirect
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
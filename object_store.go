@@ -0,0 +1,282 @@
+package multilog
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Archive URL schemes
+const (
+	S3Scheme  = "s3"
+	GCSScheme = "gs"
+)
+
+// Object store settings
+const (
+	DefaultS3Region = "us-east-1"
+	GCSEndpoint     = "https://storage.googleapis.com"
+	gcsRegion       = "auto"
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	amzDateFormat   = "20060102T150405Z"
+)
+
+// ObjectStore is an ArchiveStore for S3 and S3-compatible object storage,
+// including Google Cloud Storage through its XML API with HMAC keys. Requests
+// are signed with AWS Signature Version 4.
+type ObjectStore struct {
+	client       *http.Client
+	now          func() time.Time
+	baseURL      string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// ParseArchiveURL splits an archive URL such as "s3://bucket/logs/app" into its
+// scheme, bucket and key prefix; a non-empty prefix ends with a slash.
+func ParseArchiveURL(archiveURL string) (scheme, bucket, prefix string, err error) {
+	u, err := url.Parse(archiveURL)
+	if err != nil || u.Host == "" {
+		return "", "", "", fmt.Errorf("invalid archive url: %s", archiveURL)
+	}
+	if u.Scheme != S3Scheme && u.Scheme != GCSScheme {
+		return "", "", "", fmt.Errorf("invalid archive url scheme: %s", u.Scheme)
+	}
+	prefix = strings.Trim(u.Path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return u.Scheme, u.Host, prefix, nil
+}
+
+// NewObjectStore creates an object store for the bucket of an s3:// or gs://
+// archive URL. Credentials are read from AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN (HMAC keys for GCS), the region
+// from AWS_REGION or AWS_DEFAULT_REGION, and AWS_ENDPOINT_URL overrides the
+// endpoint for S3-compatible services such as MinIO.
+func NewObjectStore(archiveURL string) (*ObjectStore, error) {
+	scheme, bucket, _, err := ParseArchiveURL(archiveURL)
+	if err != nil {
+		return nil, err
+	}
+	store := &ObjectStore{
+		client:       &http.Client{},
+		now:          time.Now,
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if store.accessKey == "" || store.secretKey == "" {
+		return nil, fmt.Errorf("object store requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	region := defaultIfEmpty(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	endpoint := strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL"), "/")
+	switch {
+	case endpoint != "":
+		store.baseURL = endpoint + "/" + bucket
+	case scheme == GCSScheme:
+		store.baseURL = GCSEndpoint + "/" + bucket
+	default:
+		region = defaultIfEmpty(region, DefaultS3Region)
+		store.baseURL = "https://" + bucket + ".s3." + region + ".amazonaws.com"
+	}
+	if scheme == GCSScheme {
+		region = defaultIfEmpty(region, gcsRegion)
+	}
+	store.region = defaultIfEmpty(region, DefaultS3Region)
+	return store, nil
+}
+
+// Upload stores the body under the key.
+func (s *ObjectStore) Upload(ctx context.Context, key string, body []byte) error {
+	_, err := s.do(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes the object with the key.
+func (s *ObjectStore) Delete(ctx context.Context, key string) error {
+	_, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// listBucketResult is the response of a ListObjectsV2 request.
+type listBucketResult struct {
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		LastModified time.Time `xml:"LastModified"`
+		Key          string    `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated bool `xml:"IsTruncated"`
+}
+
+// List returns the objects whose keys start with the prefix.
+func (s *ObjectStore) List(ctx context.Context, prefix string) ([]ArchiveObject, error) {
+	var objects []ArchiveObject
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		body, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		var result listBucketResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse object list: %w", err)
+		}
+		for _, c := range result.Contents {
+			objects = append(objects, ArchiveObject{Key: c.Key, Modified: c.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// do sends a signed request for the key and returns the response body.
+func (s *ObjectStore) do(ctx context.Context, method, key string, query url.Values, body []byte) ([]byte, error) {
+	u, err := url.Parse(s.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path += "/" + key
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return respBody, nil
+}
+
+// sign adds the Signature Version 4 headers to the request; every header set
+// on the request is signed.
+func (s *ObjectStore) sign(req *http.Request, body []byte) {
+	t := s.now().UTC()
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", t.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := t.Format("20060102")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := sigV4Algorithm + "\n" + t.Format(amzDateFormat) + "\n" + scope + "\n" +
+		sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", sigV4Algorithm+" Credential="+s.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalQuery encodes the query with sorted keys as Signature Version 4 requires.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes every byte except unreserved characters and,
+// unless encodeSlash is set, slashes.
+func uriEncode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			sb.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// sha256Hex returns the hex-encoded SHA-256 of data.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with the key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,378 @@
+package multilog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// OTLP transport protocols
+const (
+	OTLPHTTPProtocol = "http"
+	OTLPGRPCProtocol = "grpc"
+)
+
+// OTLPScopeName is the instrumentation scope of exported log records.
+const OTLPScopeName = "github.com/phani-kb/multilog"
+
+// OTLPExporter sends OTLP log export requests to a collector.
+// The request is an ExportLogsServiceRequest in the OTLP/JSON encoding, which
+// protojson can decode into the generated protobuf type.
+type OTLPExporter interface {
+	Export(ctx context.Context, request []byte) error
+	Close() error
+}
+
+// OTLPExporterFactory creates an exporter for the gRPC protocol.
+// multilog does not depend on gRPC; it must be set before creating otlp
+// handlers with the grpc protocol from configuration:
+//
+//	multilog.OTLPExporterFactory = func(endpoint string, headers map[string]string) (multilog.OTLPExporter, error) {
+//		conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+//		...
+//		// Export: protojson.Unmarshal(request, &req); client.Export(ctx, &req)
+//	}
+var OTLPExporterFactory func(endpoint string, headers map[string]string) (OTLPExporter, error)
+
+// otlpKeyValue is an attribute in the OTLP/JSON encoding.
+type otlpKeyValue struct {
+	Value otlpAnyValue `json:"value"`
+	Key   string       `json:"key"`
+}
+
+// otlpAnyValue is an attribute value in the OTLP/JSON encoding; exactly one
+// field is set. 64-bit integers are encoded as strings.
+type otlpAnyValue struct {
+	StringValue *string        `json:"stringValue,omitempty"`
+	BoolValue   *bool          `json:"boolValue,omitempty"`
+	IntValue    *string        `json:"intValue,omitempty"`
+	DoubleValue *float64       `json:"doubleValue,omitempty"`
+	KvlistValue *otlpKeyValues `json:"kvlistValue,omitempty"`
+	BytesValue  []byte         `json:"bytesValue,omitempty"`
+}
+
+type otlpKeyValues struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+// otlpLogRecord is a LogRecord in the OTLP/JSON encoding.
+type otlpLogRecord struct {
+	Body                 otlpAnyValue   `json:"body"`
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityText         string         `json:"severityText"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	SeverityNumber       int            `json:"severityNumber"`
+}
+
+type otlpExportRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+// httpOTLPExporter posts export requests to an OTLP/HTTP endpoint.
+type httpOTLPExporter struct {
+	client  *http.Client
+	headers map[string]string
+	url     string
+}
+
+func (e *httpOTLPExporter) Export(_ context.Context, request []byte) error {
+	return postJSON(e.client, e.url, request, e.headers)
+}
+
+func (e *httpOTLPExporter) Close() error {
+	return nil
+}
+
+// OTLPHandler is a Handler that converts records to OTLP log records and
+// exports them in batches to an OpenTelemetry collector.
+type OTLPHandler struct {
+	Handler  *CustomHandler
+	exporter OTLPExporter
+	batch    *batcher[otlpLogRecord]
+	opts     *OTLPHandlerOptions
+	resource []otlpKeyValue
+	groups   []string
+	attrs    []slog.Attr
+}
+
+// OTLPHandlerOptions are the options of OTLP handlers: the formatting and
+// batching options, the endpoint URL, protocol and headers, and the resource
+// attributes.
+type OTLPHandlerOptions struct {
+	CustomHandlerOptions
+	BatchOptions
+	Headers  map[string]string
+	Resource map[string]string
+	URL      string
+	Protocol string
+}
+
+// NewOTLPHandler creates an OTLP Handler with the specified options and
+// exporter. A nil exporter posts OTLP/JSON to the URL, such as
+// http://localhost:4318/v1/logs. Resource attributes are taken from Resource;
+// service.name defaults to "unknown_service:" and the executable name.
+func NewOTLPHandler(opts OTLPHandlerOptions, exporter OTLPExporter) (slog.Handler, error) {
+	if exporter == nil {
+		if opts.URL == "" {
+			return nil, fmt.Errorf("otlp handler requires a url")
+		}
+		exporter = &httpOTLPExporter{
+			client:  &http.Client{Timeout: DefaultHTTPTimeout},
+			headers: opts.Headers,
+			url:     opts.URL,
+		}
+	}
+
+	oh := &OTLPHandler{
+		Handler:  NewCustomHandler(&opts.CustomHandlerOptions, bufio.NewWriter(io.Discard), nil),
+		exporter: exporter,
+		opts:     &opts,
+		resource: otlpResourceAttrs(opts.Resource),
+	}
+	oh.batch = newBatcher(opts.BatchSize, opts.FlushInterval, opts.MaxBufferSize, oh.export, opts.OnError)
+	return oh, nil
+}
+
+// newOTLPHandlerFromConfig creates an OTLP Handler for the configured protocol,
+// using OTLPExporterFactory for gRPC.
+func newOTLPHandlerFromConfig(opts OTLPHandlerOptions) (slog.Handler, error) {
+	if opts.Protocol != OTLPGRPCProtocol {
+		return NewOTLPHandler(opts, nil)
+	}
+	if OTLPExporterFactory == nil {
+		return nil, fmt.Errorf("otlp grpc handler requires multilog.OTLPExporterFactory to be set")
+	}
+	exporter, err := OTLPExporterFactory(opts.URL, opts.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+	return NewOTLPHandler(opts, exporter)
+}
+
+// otlpResourceAttrs returns the resource attributes sorted by key, adding a
+// default service.name.
+func otlpResourceAttrs(resource map[string]string) []otlpKeyValue {
+	attrs := make([]otlpKeyValue, 0, len(resource)+1)
+	for _, key := range slices.Sorted(maps.Keys(resource)) {
+		attrs = append(attrs, otlpKeyValue{Key: key, Value: otlpString(resource[key])})
+	}
+	if _, ok := resource["service.name"]; !ok {
+		name := "unknown_service:" + filepath.Base(os.Args[0])
+		attrs = append(attrs, otlpKeyValue{Key: "service.name", Value: otlpString(name)})
+	}
+	return attrs
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (oh *OTLPHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return oh.Handler.Enabled(ctx, level)
+}
+
+// Handle converts the log record and queues it for the next export.
+func (oh *OTLPHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !oh.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return oh.batch.add(ctx, oh.logRecord(record))
+}
+
+// logRecord converts the record to an OTLP log record. Top-level trace_id and
+// span_id attributes become the trace context of the record.
+func (oh *OTLPHandler) logRecord(record slog.Record) otlpLogRecord {
+	lr := otlpLogRecord{
+		Body:                 otlpString(record.Message),
+		TimeUnixNano:         strconv.FormatInt(record.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityText:         levelLabel(record.Level, false),
+		SeverityNumber:       otlpSeverity(record.Level),
+	}
+
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case TraceIDKey:
+			lr.TraceID = a.Value.String()
+		case SpanIDKey:
+			lr.SpanID = a.Value.String()
+		default:
+			attrs = append(attrs, a)
+		}
+		return true
+	})
+	attrs = append(oh.attrs[:len(oh.attrs):len(oh.attrs)], nestAttrs(oh.groups, attrs)...)
+	if oh.opts.AddSource {
+		if src := record.Source(); src != nil && src.File != "" {
+			attrs = append(attrs,
+				slog.String("code.filepath", src.File),
+				slog.Int("code.lineno", src.Line),
+				slog.String("code.function", src.Function))
+		}
+	}
+	lr.Attributes = otlpAttrs(attrs)
+	return lr
+}
+
+// otlpSeverity maps the level to an OTLP severity number: DEBUG is 5, INFO
+// 9, WARN 13 and ERROR 17, with levels in between mapped linearly.
+func otlpSeverity(level slog.Level) int {
+	return min(max(int(level)+9, 1), 24)
+}
+
+func otlpString(s string) otlpAnyValue {
+	return otlpAnyValue{StringValue: &s}
+}
+
+func otlpInt(i int64) otlpAnyValue {
+	s := strconv.FormatInt(i, 10)
+	return otlpAnyValue{IntValue: &s}
+}
+
+// otlpAttrs converts the attributes, eliding empty attributes and groups.
+func otlpAttrs(attrs []slog.Attr) []otlpKeyValue {
+	var kvs []otlpKeyValue
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+		if a.Value.Kind() == slog.KindGroup {
+			group := otlpAttrs(a.Value.Group())
+			if len(group) == 0 {
+				continue
+			}
+			if a.Key == "" {
+				kvs = append(kvs, group...)
+				continue
+			}
+			kvs = append(kvs, otlpKeyValue{Key: a.Key, Value: otlpAnyValue{KvlistValue: &otlpKeyValues{Values: group}}})
+			continue
+		}
+		kvs = append(kvs, otlpKeyValue{Key: a.Key, Value: otlpValue(a.Value)})
+	}
+	return kvs
+}
+
+// otlpValue converts a non-group value. Durations and times become integer
+// nanoseconds, and values without an OTLP equivalent their string form.
+func otlpValue(v slog.Value) otlpAnyValue {
+	switch v.Kind() {
+	case slog.KindString:
+		return otlpString(v.String())
+	case slog.KindInt64:
+		return otlpInt(v.Int64())
+	case slog.KindUint64:
+		if u := v.Uint64(); u <= math.MaxInt64 {
+			return otlpInt(int64(u))
+		}
+		return otlpString(v.String())
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return otlpString(v.String())
+		}
+		return otlpAnyValue{DoubleValue: &f}
+	case slog.KindBool:
+		b := v.Bool()
+		return otlpAnyValue{BoolValue: &b}
+	case slog.KindDuration:
+		return otlpInt(int64(v.Duration()))
+	case slog.KindTime:
+		return otlpInt(v.Time().UnixNano())
+	default:
+		if b, ok := v.Any().([]byte); ok {
+			return otlpAnyValue{BytesValue: b}
+		}
+		return otlpString(v.String())
+	}
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (oh *OTLPHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *oh
+	clone.attrs = append(oh.attrs[:len(oh.attrs):len(oh.attrs)], nestAttrs(oh.groups, attrs)...)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (oh *OTLPHandler) WithGroup(name string) slog.Handler {
+	clone := *oh
+	clone.groups = append(oh.groups[:len(oh.groups):len(oh.groups)], name)
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (oh *OTLPHandler) SetLevel(level string) error {
+	return oh.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (oh *OTLPHandler) GetLevel() string {
+	return oh.Handler.GetLevel()
+}
+
+// Flush exports all queued records.
+func (oh *OTLPHandler) Flush() error {
+	return oh.batch.Flush()
+}
+
+// Close exports all queued records and closes the exporter.
+func (oh *OTLPHandler) Close() error {
+	return errors.Join(oh.batch.Close(), oh.exporter.Close())
+}
+
+// customHandler implements customHandlerProvider.
+func (oh *OTLPHandler) customHandler() CustomHandlerInterface {
+	return oh.Handler
+}
+
+// export sends a batch of log records, retrying on failure.
+func (oh *OTLPHandler) export(records []otlpLogRecord) error {
+	body, err := json.Marshal(otlpExportRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: otlpResource{Attributes: oh.resource},
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      otlpScope{Name: OTLPScopeName},
+				LogRecords: records,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal otlp payload: %w", err)
+	}
+	return oh.opts.retryPolicy().Do(func() error {
+		return oh.exporter.Export(context.Background(), body)
+	})
+}
@@ -0,0 +1,195 @@
+package multilog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockOTLPExporter struct {
+	err      error
+	requests []otlpExportRequest
+	mu       sync.Mutex
+	closed   bool
+}
+
+func (e *mockOTLPExporter) Export(_ context.Context, request []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return e.err
+	}
+	var req otlpExportRequest
+	if err := json.Unmarshal(request, &req); err != nil {
+		return err
+	}
+	e.requests = append(e.requests, req)
+	return nil
+}
+
+func (e *mockOTLPExporter) Close() error {
+	e.closed = true
+	return nil
+}
+
+// otlpAttrMap returns the attributes by key.
+func otlpAttrMap(kvs []otlpKeyValue) map[string]otlpAnyValue {
+	m := make(map[string]otlpAnyValue, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestNewOTLPHandler_RequiresURL(t *testing.T) {
+	_, err := NewOTLPHandler(OTLPHandlerOptions{CustomHandlerOptions: CustomHandlerOptions{Level: InfoLevel, Enabled: true}}, nil)
+	assert.EqualError(t, err, "otlp handler requires a url")
+}
+
+func TestOTLPHandler_Export(t *testing.T) {
+	exporter := &mockOTLPExporter{}
+	handler, err := NewOTLPHandler(OTLPHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   DebugLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			BatchSize:     10,
+			FlushInterval: time.Hour,
+		},
+		Resource: map[string]string{"service.name": "api", "deployment.environment": "prod"},
+	}, exporter)
+	assert.NoError(t, err)
+
+	logger := slog.New(handler).With("service", "api").WithGroup("req")
+	logger.Info("served", TraceIDKey, "0af7651916cd43dd8448eb211c80319c", SpanIDKey, "b7ad6b7169203331",
+		"id", 7, "took", time.Millisecond, "ok", true, "ratio", 0.5, "body", []byte("hi"))
+	logger.Warn("slow")
+	slog.New(handler).Error("failed", "err", errors.New("boom"))
+	assert.NoError(t, handler.(*OTLPHandler).Close())
+	assert.True(t, exporter.closed)
+
+	assert.Len(t, exporter.requests, 1)
+	resourceLogs := exporter.requests[0].ResourceLogs[0]
+	resource := otlpAttrMap(resourceLogs.Resource.Attributes)
+	assert.Equal(t, "api", *resource["service.name"].StringValue)
+	assert.Equal(t, "prod", *resource["deployment.environment"].StringValue)
+	assert.Equal(t, OTLPScopeName, resourceLogs.ScopeLogs[0].Scope.Name)
+
+	records := resourceLogs.ScopeLogs[0].LogRecords
+	assert.Len(t, records, 3)
+	served := records[0]
+	assert.Equal(t, "served", *served.Body.StringValue)
+	assert.Equal(t, 9, served.SeverityNumber)
+	assert.Equal(t, "INFO", served.SeverityText)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", served.TraceID)
+	assert.Equal(t, "b7ad6b7169203331", served.SpanID)
+	assert.NotEmpty(t, served.TimeUnixNano)
+
+	attrs := otlpAttrMap(served.Attributes)
+	assert.Equal(t, "api", *attrs["service"].StringValue)
+	req := otlpAttrMap(attrs["req"].KvlistValue.Values)
+	assert.Equal(t, "7", *req["id"].IntValue)
+	assert.Equal(t, "1000000", *req["took"].IntValue)
+	assert.True(t, *req["ok"].BoolValue)
+	assert.Equal(t, 0.5, *req["ratio"].DoubleValue)
+	assert.Equal(t, []byte("hi"), req["body"].BytesValue)
+	assert.NotContains(t, req, TraceIDKey)
+
+	assert.Equal(t, 13, records[1].SeverityNumber)
+	assert.Len(t, records[1].Attributes, 1, "empty group is elided")
+	assert.Equal(t, 17, records[2].SeverityNumber)
+	assert.Equal(t, "boom", *otlpAttrMap(records[2].Attributes)["err"].StringValue)
+}
+
+func TestOTLPHandler_HTTP(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, ContentTypeJSON, r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+
+	config, err := NewBuilder().Handler(OTLPHandlerType, URL(server.URL), Level(InfoLevel)).Config()
+	assert.NoError(t, err)
+	config.Multilog.Handlers[0].Headers = map[string]string{"Authorization": "secret"}
+	handlers, err := CreateHandlers(config)
+	assert.NoError(t, err)
+
+	slog.New(handlers[0]).Info("hello")
+	assert.NoError(t, handlers[0].(*OTLPHandler).Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, bodies, 1)
+	assert.Contains(t, bodies[0], `"body":{"stringValue":"hello"}`)
+	assert.Contains(t, bodies[0], `"service.name"`)
+}
+
+func TestOTLPHandler_FromConfig(t *testing.T) {
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: otlp
+      level: info
+      enabled: true
+      protocol: grpc
+      url: localhost:4317`))
+	assert.NoError(t, err)
+
+	_, err = CreateHandlers(cfg)
+	assert.ErrorContains(t, err, "OTLPExporterFactory")
+
+	exporter := &mockOTLPExporter{}
+	OTLPExporterFactory = func(endpoint string, _ map[string]string) (OTLPExporter, error) {
+		assert.Equal(t, "localhost:4317", endpoint)
+		return exporter, nil
+	}
+	defer func() { OTLPExporterFactory = nil }()
+
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	oh := handlers[0].(*OTLPHandler)
+	assert.NoError(t, oh.SetLevel(DebugLevel))
+	assert.Equal(t, DebugLevel, oh.GetLevel())
+	assert.NoError(t, oh.Close())
+
+	_, err = NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: otlp
+      level: info
+      enabled: true
+      protocol: udp
+      url: localhost:4317`))
+	assert.ErrorContains(t, err, "invalid otlp protocol: udp")
+}
+
+func TestOTLPSeverity(t *testing.T) {
+	assert.Equal(t, 5, otlpSeverity(slog.LevelDebug))
+	assert.Equal(t, 8, otlpSeverity(LevelPerf))
+	assert.Equal(t, 1, otlpSeverity(slog.Level(-20)))
+	assert.Equal(t, 24, otlpSeverity(slog.Level(20)))
+}
+
+func TestOTLPValue(t *testing.T) {
+	assert.Equal(t, "18446744073709551615", *otlpValue(slog.Uint64Value(math.MaxUint64)).StringValue)
+	assert.Equal(t, "NaN", *otlpValue(slog.Float64Value(math.NaN())).StringValue)
+	at := time.Unix(1, 5)
+	assert.Equal(t, "1000000005", *otlpValue(slog.TimeValue(at)).IntValue)
+}
@@ -0,0 +1,187 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ParallelAggregator is an aggregator that passes each record to its handlers
+// concurrently, so a slow handler does not delay the others. At most workers
+// handler calls run at a time across the aggregator and its derived handlers.
+type ParallelAggregator struct {
+	sem      chan struct{}
+	state    *parallelState
+	handlers Aggregator
+	timeout  time.Duration
+}
+
+// parallelState tracks the handler calls of an aggregator and its derived
+// handlers. Calls are added under a read lock, so that Flush and Close, which
+// wait for them under the write lock, never wait while a call is being added.
+type parallelState struct {
+	mu       sync.RWMutex
+	inflight sync.WaitGroup
+	closed   bool
+}
+
+// NewParallelAggregator creates a parallel aggregator running at most workers
+// handler calls at once, or one per handler if workers is not positive. A
+// positive timeout bounds the time Handle waits for each handler, including the
+// wait for a free worker; the handler's context is cancelled when it expires.
+func NewParallelAggregator(workers int, timeout time.Duration, handlers ...slog.Handler) *ParallelAggregator {
+	if workers <= 0 {
+		workers = max(len(handlers), 1)
+	}
+	return &ParallelAggregator{
+		sem:      make(chan struct{}, workers),
+		state:    &parallelState{},
+		handlers: NewAggregator(handlers...),
+		timeout:  timeout,
+	}
+}
+
+// Enabled implements slog.Handler.
+func (a *ParallelAggregator) Enabled(ctx context.Context, level slog.Level) bool {
+	return a.handlers.Enabled(ctx, level)
+}
+
+// Handle passes a copy of the record to every enabled handler concurrently and
+// waits for them, for at most the timeout or until ctx is done. It returns the
+// first error, including timeouts, and an error after Close.
+func (a *ParallelAggregator) Handle(ctx context.Context, r slog.Record) error {
+	var enabled []slog.Handler
+	for _, h := range a.handlers {
+		if h.Enabled(ctx, r.Level) {
+			enabled = append(enabled, h)
+		}
+	}
+	if !a.add(len(enabled)) {
+		return fmt.Errorf("parallel aggregator is closed")
+	}
+
+	// Stack traces are resolved from the stack of the logging goroutine, not
+	// that of the handler goroutines.
+	ctx = withCallerStack(ctx)
+	errs := make(chan error, len(enabled))
+	for _, h := range enabled {
+		go func(h slog.Handler, r slog.Record) {
+			errs <- a.handle(ctx, h, r)
+		}(h, r.Clone())
+	}
+
+	var firstErr error
+	for range enabled {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// add adds n handler calls, unless the aggregator is closed.
+func (a *ParallelAggregator) add(n int) bool {
+	a.state.mu.RLock()
+	defer a.state.mu.RUnlock()
+	if a.state.closed {
+		return false
+	}
+	a.state.inflight.Add(n)
+	return true
+}
+
+// wait waits for the running handler calls, marking the aggregator closed
+// first if close is set. It reports whether it was closed before.
+func (a *ParallelAggregator) wait(close bool) bool {
+	a.state.mu.Lock()
+	defer a.state.mu.Unlock()
+	closed := a.state.closed
+	a.state.closed = closed || close
+	a.state.inflight.Wait()
+	return closed
+}
+
+// handle runs the handler on a worker, giving up when the timeout expires or
+// ctx is done. The worker stays busy until the handler returns, and the call
+// added by Handle is done then.
+func (a *ParallelAggregator) handle(ctx context.Context, h slog.Handler, r slog.Record) error {
+	var cancel context.CancelFunc
+	if a.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	select {
+	case a.sem <- struct{}{}:
+	case <-ctx.Done():
+		cancel()
+		a.state.inflight.Done()
+		return a.doneErr(ctx)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			cancel()
+			<-a.sem
+			a.state.inflight.Done()
+		}()
+		done <- h.Handle(ctx, r)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return a.doneErr(ctx)
+	}
+}
+
+// doneErr describes why a handler call was given up.
+func (a *ParallelAggregator) doneErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && a.timeout > 0 {
+		return fmt.Errorf("handler timed out after %s: %w", a.timeout, ctx.Err())
+	}
+	return fmt.Errorf("handler cancelled: %w", ctx.Err())
+}
+
+// WithAttrs implements slog.Handler. The new handler shares the workers.
+func (a *ParallelAggregator) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *a
+	clone.handlers = a.handlers.withAttrs(attrs)
+	return &clone
+}
+
+// WithGroup implements slog.Handler. The new handler shares the workers.
+func (a *ParallelAggregator) WithGroup(name string) slog.Handler {
+	clone := *a
+	clone.handlers = a.handlers.withGroup(name)
+	return &clone
+}
+
+// Use wraps every handler of the aggregator with the middlewares.
+func (a *ParallelAggregator) Use(middlewares ...Middleware) *ParallelAggregator {
+	clone := *a
+	clone.handlers = a.handlers.Use(middlewares...)
+	return &clone
+}
+
+// Flush waits for running handler calls and flushes all handlers. Records
+// logged meanwhile wait for it.
+func (a *ParallelAggregator) Flush() error {
+	a.wait(false)
+	return a.handlers.Flush()
+}
+
+// Close rejects further records, waits for running handler calls and closes
+// all handlers. Later calls do nothing.
+func (a *ParallelAggregator) Close() error {
+	if a.wait(true) {
+		return nil
+	}
+	return a.handlers.Close()
+}
@@ -0,0 +1,212 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingHandler blocks in Handle until released or its context is done.
+type blockingHandler struct {
+	release chan struct{}
+	handled atomic.Int32
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{release: make(chan struct{})}
+}
+
+func (h *blockingHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, _ slog.Record) error {
+	running := h.running.Add(1)
+	defer h.running.Add(-1)
+	for {
+		peak := h.peak.Load()
+		if running <= peak || h.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+	select {
+	case <-h.release:
+		h.handled.Add(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *blockingHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *blockingHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// syncRecordingHandler is a recordingHandler safe for concurrent use.
+type syncRecordingHandler struct {
+	recordingHandler
+	mu sync.Mutex
+}
+
+func (h *syncRecordingHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.recordingHandler.Handle(ctx, r)
+}
+
+func newRecord(msg string) slog.Record {
+	return slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)
+}
+
+func TestParallelAggregator_Handle(t *testing.T) {
+	slow := newBlockingHandler()
+	fast := &syncRecordingHandler{}
+	aggregator := NewParallelAggregator(0, 0, slow, fast)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- aggregator.Handle(context.Background(), newRecord("hello"))
+	}()
+
+	assert.Eventually(t, func() bool {
+		fast.mu.Lock()
+		defer fast.mu.Unlock()
+		return len(fast.records) == 1
+	}, time.Second, time.Millisecond, "fast handler should not wait for the slow one")
+	close(slow.release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(1), slow.handled.Load())
+}
+
+func TestParallelAggregator_Timeout(t *testing.T) {
+	slow := newBlockingHandler()
+	fast := &syncRecordingHandler{}
+	aggregator := NewParallelAggregator(0, 10*time.Millisecond, slow, fast)
+
+	err := aggregator.Handle(context.Background(), newRecord("hello"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "handler timed out after 10ms")
+	assert.Len(t, fast.records, 1)
+}
+
+func TestParallelAggregator_ContextDeadline(t *testing.T) {
+	slow := newBlockingHandler()
+	aggregator := NewParallelAggregator(0, time.Hour, slow)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := aggregator.Handle(ctx, newRecord("hello"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestParallelAggregator_Workers(t *testing.T) {
+	handler := newBlockingHandler()
+	aggregator := NewParallelAggregator(2, 0, handler)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = aggregator.Handle(context.Background(), newRecord("hello"))
+		}()
+	}
+	assert.Eventually(t, func() bool { return handler.running.Load() == 2 }, time.Second, time.Millisecond)
+	close(handler.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(5), handler.handled.Load())
+	assert.Equal(t, int32(2), handler.peak.Load())
+}
+
+func TestParallelAggregator_Errors(t *testing.T) {
+	failing := &mockHandler{returnError: true}
+	disabled := &mockHandler{enabledLevel: slog.LevelError}
+	aggregator := NewParallelAggregator(0, 0, failing, disabled)
+
+	err := aggregator.Handle(context.Background(), newRecord("hello"))
+	assert.EqualError(t, err, "mock handler error")
+	assert.False(t, disabled.handleCalled)
+	assert.True(t, aggregator.Enabled(context.Background(), slog.LevelInfo))
+}
+
+func TestParallelAggregator_WithAttrsAndClose(t *testing.T) {
+	first := &closingHandler{}
+	aggregator := NewParallelAggregator(1, 0, first)
+
+	derived := aggregator.WithAttrs([]slog.Attr{slog.String("k", "v")}).WithGroup("g")
+	derivedAggregator, ok := derived.(*ParallelAggregator)
+	assert.True(t, ok)
+	assert.Equal(t, aggregator.sem, derivedAggregator.sem)
+
+	assert.NoError(t, aggregator.Flush())
+	assert.NoError(t, aggregator.Close())
+	assert.True(t, first.flushed)
+	assert.True(t, first.closed)
+}
+
+// countingHandler counts the records it handles, also for derived handlers.
+type countingHandler struct {
+	n atomic.Int64
+}
+
+func (h *countingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *countingHandler) Handle(context.Context, slog.Record) error {
+	h.n.Add(1)
+	return nil
+}
+
+func (h *countingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *countingHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestParallelAggregator_HandleDuringClose(t *testing.T) {
+	handler := &countingHandler{}
+	aggregator := NewParallelAggregator(2, 0, handler)
+	derived := aggregator.WithAttrs([]slog.Attr{slog.String("k", "v")})
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				_ = derived.Handle(context.Background(), newRecord("msg"))
+				_ = aggregator.Flush()
+			}
+		}()
+	}
+	assert.NoError(t, aggregator.Close())
+	wg.Wait()
+
+	logged := handler.n.Load()
+	assert.EqualError(t, derived.Handle(context.Background(), newRecord("late")), "parallel aggregator is closed")
+	assert.Equal(t, logged, handler.n.Load(), "records after Close are not written")
+	assert.NoError(t, aggregator.Close())
+}
+
+func TestNewParallelLogger(t *testing.T) {
+	handler := &syncRecordingHandler{}
+	logger := NewParallelLogger(2, time.Second, handler)
+	logger.Info("hello")
+	assert.Equal(t, []string{"info hello"}, handler.messages())
+
+	logger, err := NewBuilder().Parallel(2, time.Second).Build()
+	assert.NoError(t, err)
+	_, ok := logger.Logger.Handler().(*ParallelAggregator)
+	assert.True(t, ok)
+
+	_, err = NewConfigFromData([]byte("multilog:\n  handlers: []\n  parallel_workers: -1\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,220 @@
+package multilog
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// PerfKey is the key of structured performance metrics.
+const PerfKey = "perf"
+
+// Performance metric names
+const (
+	PerfGoroutines = "goroutines"
+	PerfAlloc      = "alloc"
+	PerfTotalAlloc = "total_alloc"
+	PerfSys        = "sys"
+	PerfHeapAlloc  = "heap_alloc"
+	PerfHeapSys    = "heap_sys"
+	PerfHeapIdle   = "heap_idle"
+	PerfHeapInuse  = "heap_inuse"
+	PerfStackSys   = "stack_sys"
+	PerfNumGC      = "num_gc"
+)
+
+// Performance metric units
+const (
+	PerfUnitB       = "B"
+	PerfUnitKB      = "KB"
+	PerfUnitMB      = "MB"
+	PerfUnitGB      = "GB"
+	DefaultPerfUnit = PerfUnitMB
+)
+
+// DefaultPerfMetrics lists the performance metrics emitted by default.
+var DefaultPerfMetrics = []string{
+	PerfGoroutines,
+	PerfAlloc,
+	PerfSys,
+	PerfHeapAlloc,
+	PerfHeapSys,
+	PerfHeapIdle,
+	PerfHeapInuse,
+	PerfStackSys,
+}
+
+// perfMetricReader reads a metric; memory metrics are read in bytes.
+type perfMetricReader struct {
+	read   func(m *runtime.MemStats) uint64
+	memory bool
+}
+
+// perfMetricReaders maps metric names to their readers.
+var perfMetricReaders = map[string]perfMetricReader{
+	PerfGoroutines: {read: func(*runtime.MemStats) uint64 { return uint64(runtime.NumGoroutine()) }},
+	PerfNumGC:      {read: func(m *runtime.MemStats) uint64 { return uint64(m.NumGC) }},
+	PerfAlloc:      {read: func(m *runtime.MemStats) uint64 { return m.Alloc }, memory: true},
+	PerfTotalAlloc: {read: func(m *runtime.MemStats) uint64 { return m.TotalAlloc }, memory: true},
+	PerfSys:        {read: func(m *runtime.MemStats) uint64 { return m.Sys }, memory: true},
+	PerfHeapAlloc:  {read: func(m *runtime.MemStats) uint64 { return m.HeapAlloc }, memory: true},
+	PerfHeapSys:    {read: func(m *runtime.MemStats) uint64 { return m.HeapSys }, memory: true},
+	PerfHeapIdle:   {read: func(m *runtime.MemStats) uint64 { return m.HeapIdle }, memory: true},
+	PerfHeapInuse:  {read: func(m *runtime.MemStats) uint64 { return m.HeapInuse }, memory: true},
+	PerfStackSys:   {read: func(m *runtime.MemStats) uint64 { return m.StackSys }, memory: true},
+}
+
+// perfUnitSizes maps units to their size in bytes.
+var perfUnitSizes = map[string]uint64{
+	PerfUnitB:  1,
+	PerfUnitKB: 1 << 10,
+	PerfUnitMB: 1 << 20,
+	PerfUnitGB: 1 << 30,
+}
+
+// perfMetric is a collected metric converted to the configured unit.
+type perfMetric struct {
+	name   string
+	unit   string
+	value  slog.Value
+	memory bool
+}
+
+// ValidatePerfMetrics checks the metric names and unit of a perf selection.
+func ValidatePerfMetrics(names []string, unit string) error {
+	for _, name := range names {
+		if _, ok := perfMetricReaders[name]; !ok {
+			return fmt.Errorf("invalid perf metric: %s", name)
+		}
+	}
+	if _, ok := perfUnitSizes[strings.ToUpper(unit)]; unit != "" && !ok {
+		return fmt.Errorf("invalid perf unit: %s", unit)
+	}
+	return nil
+}
+
+// perfSelection returns the metrics and unit configured in the options.
+func perfSelection(opts *CustomHandlerOptions) (names []string, unit string) {
+	names, unit = DefaultPerfMetrics, DefaultPerfUnit
+	if opts == nil {
+		return names, unit
+	}
+	if len(opts.PerfMetrics) > 0 {
+		names = opts.PerfMetrics
+	}
+	return names, defaultIfEmpty(strings.ToUpper(opts.PerfUnits), unit)
+}
+
+// collectPerfMetrics reads the named metrics, converting memory metrics to the
+// unit and rounding them to three decimals. Unknown names are skipped.
+func collectPerfMetrics(names []string, unit string) []perfMetric {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	size, ok := perfUnitSizes[unit]
+	if !ok {
+		unit, size = DefaultPerfUnit, perfUnitSizes[DefaultPerfUnit]
+	}
+	metrics := make([]perfMetric, 0, len(names))
+	for _, name := range names {
+		reader, ok := perfMetricReaders[name]
+		if !ok {
+			continue
+		}
+		value := reader.read(&m)
+		metric := perfMetric{name: name, value: slog.Uint64Value(value), memory: reader.memory}
+		if reader.memory {
+			metric.unit = unit
+			if size > 1 {
+				metric.value = slog.Float64Value(roundPerf(float64(value) / float64(size)))
+			}
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
+
+// roundPerf rounds a metric to three decimals.
+func roundPerf(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+// perfMetricsGroup returns the selected metrics as a group; memory metrics are
+// keyed with their unit, such as heap_alloc_mb.
+func perfMetricsGroup(opts *CustomHandlerOptions) slog.Value {
+	metrics := collectPerfMetrics(perfSelection(opts))
+	attrs := make([]slog.Attr, 0, len(metrics))
+	for _, metric := range metrics {
+		key := metric.name
+		if metric.memory {
+			key += "_" + strings.ToLower(metric.unit)
+		}
+		attrs = append(attrs, slog.Attr{Key: key, Value: metric.value})
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// formatPerfMetrics returns the selected metrics as a "name:value unit" list.
+func formatPerfMetrics(opts *CustomHandlerOptions) string {
+	var sb strings.Builder
+	for i, metric := range collectPerfMetrics(perfSelection(opts)) {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(metric.name)
+		sb.WriteByte(':')
+		if metric.value.Kind() == slog.KindFloat64 {
+			sb.WriteString(strconv.FormatFloat(metric.value.Float64(), 'f', -1, 64))
+		} else {
+			sb.WriteString(strconv.FormatUint(metric.value.Uint64(), 10))
+		}
+		if metric.memory {
+			sb.WriteByte(' ')
+			sb.WriteString(metric.unit)
+		}
+	}
+	return sb.String()
+}
+
+// PerfMetricsValue returns the default performance metrics as a group, with
+// memory sizes in megabytes rounded to three decimals.
+func PerfMetricsValue() slog.Value {
+	return perfMetricsGroup(nil)
+}
+
+// PerfMetricsAttr returns the default performance metrics as a perf group attribute.
+func PerfMetricsAttr() slog.Attr {
+	return slog.Any(PerfKey, PerfMetricsValue())
+}
+
+// perfMetricsMap returns the performance metrics as a map for JSON output.
+func perfMetricsMap(opts *CustomHandlerOptions) map[string]any {
+	attrs := perfMetricsGroup(opts).Group()
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		m[a.Key] = a.Value.Any()
+	}
+	return m
+}
+
+// perfMetricsValue returns the value of the [perf] placeholder.
+func perfMetricsValue(opts *CustomHandlerOptions) string {
+	if opts != nil && opts.StructuredPerf {
+		return string(appendTextAttr(nil, nil, slog.Any("", perfMetricsGroup(opts)), nil))
+	}
+	return formatPerfMetrics(opts)
+}
+
+// withPerfMetrics adds the perf attribute to perf records of handlers emitting
+// structured metrics whose pattern does not place them.
+func withPerfMetrics(opts *CustomHandlerOptions, record slog.Record, placed bool) slog.Record {
+	if record.Level != LevelPerf || placed || opts == nil || !opts.StructuredPerf {
+		return record
+	}
+	record = record.Clone()
+	record.AddAttrs(slog.Any(PerfKey, perfMetricsGroup(opts)))
+	return record
+}
@@ -0,0 +1,131 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerfMetricsValue(t *testing.T) {
+	attrs := PerfMetricsValue().Group()
+	keys := make([]string, 0, len(attrs))
+	for _, a := range attrs {
+		keys = append(keys, a.Key)
+	}
+	assert.Equal(t, []string{
+		"goroutines", "alloc_mb", "sys_mb", "heap_alloc_mb",
+		"heap_sys_mb", "heap_idle_mb", "heap_inuse_mb", "stack_sys_mb",
+	}, keys)
+	assert.Equal(t, slog.KindUint64, attrs[0].Value.Kind())
+	assert.Positive(t, attrs[0].Value.Uint64())
+	assert.Equal(t, slog.KindFloat64, attrs[1].Value.Kind())
+
+	assert.Equal(t, PerfKey, PerfMetricsAttr().Key)
+	assert.InDelta(t, 1.235, roundPerf(1.23456), 1e-9)
+}
+
+func TestValidatePerfMetrics(t *testing.T) {
+	assert.NoError(t, ValidatePerfMetrics(nil, ""))
+	assert.NoError(t, ValidatePerfMetrics([]string{PerfGoroutines, PerfNumGC}, "kb"))
+	assert.EqualError(t, ValidatePerfMetrics([]string{"cpu"}, ""), "invalid perf metric: cpu")
+	assert.EqualError(t, ValidatePerfMetrics(nil, "TB"), "invalid perf unit: TB")
+}
+
+func TestFormatPerfMetrics(t *testing.T) {
+	metrics := formatPerfMetrics(nil)
+	assert.True(t, strings.HasPrefix(metrics, "goroutines:"), metrics)
+	assert.Contains(t, metrics, ",heap_alloc:")
+	assert.Contains(t, metrics, " MB,")
+	assert.Len(t, strings.Split(metrics, ","), len(DefaultPerfMetrics))
+
+	opts := &CustomHandlerOptions{PerfMetrics: []string{PerfHeapAlloc, PerfNumGC}, PerfUnits: "kb"}
+	metrics = formatPerfMetrics(opts)
+	assert.Regexp(t, `^heap_alloc:[0-9.]+ KB,num_gc:[0-9]+$`, metrics)
+
+	opts = &CustomHandlerOptions{PerfMetrics: []string{PerfHeapAlloc}, PerfUnits: PerfUnitB}
+	assert.Regexp(t, `^heap_alloc:[0-9]+ B$`, formatPerfMetrics(opts))
+}
+
+func TestPerfMetricsGroup(t *testing.T) {
+	opts := &CustomHandlerOptions{PerfMetrics: []string{PerfGoroutines, PerfHeapAlloc}, PerfUnits: PerfUnitKB}
+	attrs := perfMetricsGroup(opts).Group()
+	assert.Len(t, attrs, 2)
+	assert.Equal(t, "goroutines", attrs[0].Key)
+	assert.Equal(t, slog.KindUint64, attrs[0].Value.Kind())
+	assert.Equal(t, "heap_alloc_kb", attrs[1].Key)
+	assert.Equal(t, slog.KindFloat64, attrs[1].Value.Kind())
+}
+
+func TestCustomHandler_StructuredPerf(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{
+		Level:          DebugLevel,
+		Enabled:        true,
+		Pattern:        "[level] [msg]",
+		StructuredPerf: true,
+	}
+	logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Log(t.Context(), LevelPerf, "done", "endpoint", "/users")
+
+	line := strings.TrimSpace(buf.String())
+	assert.True(t, strings.HasPrefix(line, "PERF done [endpoint=/users perf.goroutines="), line)
+	assert.Contains(t, line, " perf.heap_alloc_mb=")
+	assert.NotContains(t, line, "MB")
+}
+
+func TestCustomHandler_StructuredPerfPlaceholder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{
+		Level:          DebugLevel,
+		Enabled:        true,
+		Pattern:        "[level] [perf] [msg]",
+		StructuredPerf: true,
+	}
+	logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Log(t.Context(), LevelPerf, "done")
+
+	line := strings.TrimSpace(buf.String())
+	assert.True(t, strings.HasPrefix(line, "PERF goroutines="), line)
+	assert.True(t, strings.HasSuffix(line, " done"), line)
+	assert.NotContains(t, line, "perf.")
+}
+
+func TestCustomHandler_StructuredPerfLegacyFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{
+		Level:          DebugLevel,
+		Enabled:        true,
+		Pattern:        "[level] [msg]",
+		StructuredPerf: true,
+	}
+	replaceAttr := RemoveGivenKeys(slog.TimeKey, slog.MessageKey)
+	logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), replaceAttr))
+
+	logger.Log(t.Context(), LevelPerf, "done")
+
+	line := strings.TrimSpace(buf.String())
+	assert.Contains(t, line, "perf.goroutines=")
+	assert.NotContains(t, line, "MB")
+}
+
+func TestJSONHandler_StructuredPerf(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := CustomHandlerOptions{Level: DebugLevel, Enabled: true, StructuredPerf: true}
+	logger := slog.New(newJSONHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Log(t.Context(), LevelPerf, "done")
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	perf, ok := entry[PerfKey].(map[string]any)
+	assert.True(t, ok, entry[PerfKey])
+	assert.Contains(t, perf, "goroutines")
+	assert.Contains(t, perf, "heap_alloc_mb")
+}
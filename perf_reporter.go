@@ -0,0 +1,60 @@
+package multilog
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// PerfReportMessage is the message of the records emitted by a PerfReporter.
+const PerfReportMessage = "runtime metrics"
+
+// PerfReporter emits a perf record on a schedule, so that handlers accepting
+// the perf level log the current runtime metrics without instrumented call sites.
+type PerfReporter struct {
+	logger *Logger
+	done   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// NewPerfReporter starts logging a perf record to the logger every interval.
+// Call Stop to stop reporting.
+func NewPerfReporter(logger *Logger, interval time.Duration) *PerfReporter {
+	r := &PerfReporter{logger: logger, done: make(chan struct{})}
+	r.wg.Add(1)
+	go r.loop(interval)
+	return r
+}
+
+// loop reports on every tick until stopped.
+func (r *PerfReporter) loop(interval time.Duration) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.Report()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// Report logs a perf record right away.
+func (r *PerfReporter) Report() {
+	r.logger.Logger.Log(context.Background(), LevelPerf, PerfReportMessage)
+}
+
+// Stop stops reporting and waits for the reporting goroutine to exit.
+// It is safe to call on a nil reporter and more than once.
+func (r *PerfReporter) Stop() {
+	if r == nil {
+		return
+	}
+	r.once.Do(func() {
+		close(r.done)
+		r.wg.Wait()
+	})
+}
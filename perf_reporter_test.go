@@ -0,0 +1,74 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestPerfReporter(t *testing.T) {
+	buf := &syncBuffer{}
+	opts := &CustomHandlerOptions{Level: PerfLevel, Enabled: true, Pattern: "[level] [msg]"}
+	logger := NewLogger(NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	reporter := NewPerfReporter(logger, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "PERF "+PerfReportMessage+" [goroutines:")
+	}, time.Second, 5*time.Millisecond)
+
+	reporter.Stop()
+	reporter.Stop()
+	reported := buf.String()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, reported, buf.String())
+
+	var nilReporter *PerfReporter
+	nilReporter.Stop()
+}
+
+func TestNewLoggerFromConfig_PerfInterval(t *testing.T) {
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  perf_interval: 1h
+  handlers:
+    - type: console
+      level: perf
+      enabled: true`))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, cfg.Multilog.PerfInterval)
+
+	logger, err := NewLoggerFromConfig(cfg)
+	assert.NoError(t, err)
+	assert.NotNil(t, logger.reporter)
+	assert.NoError(t, logger.Close())
+
+	logger, err = NewBuilder().Console().Build()
+	assert.NoError(t, err)
+	assert.Nil(t, logger.reporter)
+
+	_, err = NewBuilder().Console().PerfInterval(-time.Second).Build()
+	assert.ErrorContains(t, err, "invalid perf interval")
+}
@@ -0,0 +1,71 @@
+package multilog
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// DefaultPrettyLevel is the minimum level of records rendered across multiple
+// lines by pretty handlers.
+const DefaultPrettyLevel = "error"
+
+// prettyEnabled reports whether records of the level are rendered across
+// multiple lines. Only records formatted directly from their attributes are;
+// the template engine and the slog text handler keep one line per record.
+func (ch *CustomHandler) prettyEnabled(level slog.Level) bool {
+	return ch.Opts.Pretty && ch.replaceAttr != nil && ch.Opts.FormatEngine != TemplateFormatEngine &&
+		level >= GetSlogLevel(DefaultPrettyLevel)
+}
+
+// appendPrettyFieldAttrs appends the handler and record attributes one per
+// indented line, except those whose dotted key names one of the custom
+// placeholders: their values are returned by key, to be placed by the pattern.
+func (ch *CustomHandler) appendPrettyFieldAttrs(
+	buf []byte,
+	record slog.Record,
+	custom []string,
+) ([]byte, map[string]string) {
+	var fields map[string]string
+	take := func(groups []string, a slog.Attr) bool {
+		key := a.Key
+		if len(groups) > 0 {
+			key = strings.Join(groups, ".") + "." + key
+		}
+		value := a.Value.String()
+		if a.Value.Kind() != slog.KindString {
+			value = string(appendTextValue(nil, a.Value))
+		}
+		if Contains(custom, key) {
+			if fields == nil {
+				fields = make(map[string]string, len(custom))
+			}
+			fields[key] = value
+			return true
+		}
+		buf = append(buf, "\n\t"...)
+		buf = append(buf, escapeText(ch.Opts.Escape, key)...)
+		buf = append(buf, ": "...)
+		buf = ch.appendPrettyLines(buf, value, "\n\t\t")
+		return true
+	}
+	for _, b := range ch.boundAttrs {
+		appendTextAttrFunc(nil, b.groups, b.attr, ch.replaceAttr, take)
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		appendTextAttrFunc(nil, ch.groups, a, ch.replaceAttr, take)
+		return true
+	})
+	return buf, fields
+}
+
+// appendPrettyLines appends the lines of the text, escaped, with the indent
+// before every line but the first.
+func (ch *CustomHandler) appendPrettyLines(buf []byte, text, indent string) []byte {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			buf = append(buf, indent...)
+		}
+		buf = append(buf, escapeText(ch.Opts.Escape, strings.TrimSuffix(line, "\r"))...)
+	}
+	return buf
+}
@@ -0,0 +1,93 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomHandler_Pretty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := NewCustomHandler(&CustomHandlerOptions{
+		Level:         DebugLevel,
+		Enabled:       true,
+		Pattern:       "[level] [msg] [request]",
+		Pretty:        true,
+		AddStacktrace: true,
+	}, bufio.NewWriter(buf), nil)
+	logger := slog.New(handler).With("request", "r-1", "service", "billing").WithGroup("payment")
+
+	logger.Info("charged", "order", 42)
+	logger.Error("charge failed\r\nretrying later", "order", 42,
+		"err", errors.New("card declined\nby issuer"), slog.Group("card", "brand", "visa"))
+	assert.NoError(t, handler.Flush())
+
+	lines := strings.Split(buf.String(), "\n")
+	expected := []string{
+		`INFO charged r-1 [service=billing payment.order=42]`,
+		`ERROR charge failed r-1`,
+		"\tretrying later",
+		"\tservice: billing",
+		"\tpayment.order: 42",
+		"\tpayment.err.message: card declined",
+		"\t\tby issuer",
+		"\tpayment.err.type: *errors.errorString",
+		"\tpayment.card.brand: visa",
+		"\tstacktrace:",
+		"\t\tgithub.com/phani-kb/multilog.TestCustomHandler_Pretty",
+	}
+	assert.Equal(t, expected, lines[:len(expected)])
+	assert.True(t, strings.HasPrefix(lines[len(expected)], "\t\t\t"), lines[len(expected)])
+}
+
+func TestCustomHandler_PrettyWithoutStacktrace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := NewCustomHandler(&CustomHandlerOptions{
+		Level:   DebugLevel,
+		Enabled: true,
+		Pattern: "[level] [msg]",
+		Pretty:  true,
+	}, bufio.NewWriter(buf), nil)
+
+	slog.New(handler).Error("charge failed", "order", 42)
+	assert.NoError(t, handler.Flush())
+	assert.Equal(t, "ERROR charge failed\n\torder: 42\n", buf.String())
+}
+
+func TestCustomHandler_PrettyTemplate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := NewCustomHandler(&CustomHandlerOptions{
+		Level:        DebugLevel,
+		Enabled:      true,
+		FormatEngine: TemplateFormatEngine,
+		Pattern:      "{{.Level}} {{.Message}}",
+		Pretty:       true,
+	}, bufio.NewWriter(buf), nil)
+
+	slog.New(handler).Error("a\nb", "key", "value")
+	assert.NoError(t, handler.Flush())
+	assert.Equal(t, `ERROR a\nb`+"\n", buf.String())
+}
+
+func TestPretty_Config(t *testing.T) {
+	config, err := NewBuilder().Console(Pretty()).Config()
+	assert.NoError(t, err)
+	assert.True(t, config.Multilog.Handlers[0].Pretty)
+	opts, err := config.GetCustomHandlerOptionsForHandler(config.Multilog.Handlers[0])
+	assert.NoError(t, err)
+	assert.True(t, opts.Pretty)
+
+	for _, builder := range []*Builder{
+		NewBuilder().File("app.log", Pretty()),
+		NewBuilder().Console(Pretty(), JSON()),
+		NewBuilder().Console(Pretty(), Template("{{.Message}}")),
+	} {
+		_, err = builder.Build()
+		assert.ErrorContains(t, err, "pretty requires a console handler")
+	}
+}
@@ -0,0 +1,93 @@
+package multilog
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// Process placeholders
+const (
+	HostPlaceholder      = "[host]"
+	PIDPlaceholder       = "[pid]"
+	GoroutinePlaceholder = "[goroutine]"
+)
+
+// UnknownHost is the host name used when it cannot be read.
+const UnknownHost = "unknown"
+
+// hostname returns the host name, read once.
+var hostname = sync.OnceValue(func() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return UnknownHost
+	}
+	return host
+})
+
+// pid returns the process id, read once.
+var pid = sync.OnceValue(os.Getpid)
+
+// goroutinePrefix starts the first line of a goroutine stack trace.
+var goroutinePrefix = []byte("goroutine ")
+
+// goroutineID returns the id of the calling goroutine, parsed from the first
+// line of its stack trace ("goroutine 42 [running]:"). It is only called for
+// patterns holding the goroutine placeholder, as it costs a stack capture.
+func goroutineID() uint64 {
+	var buf [64]byte
+	line := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], goroutinePrefix)
+	if i := bytes.IndexByte(line, ' '); i >= 0 {
+		line = line[:i]
+	}
+	id, err := strconv.ParseUint(string(line), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// processValue returns the value of a process placeholder: the host name, the
+// process id or the id of the goroutine handling the record.
+func processValue(placeholder string) any {
+	switch placeholder {
+	case HostPlaceholder:
+		return hostname()
+	case PIDPlaceholder:
+		return pid()
+	case GoroutinePlaceholder:
+		return goroutineID()
+	default:
+		return nil
+	}
+}
+
+// processString returns the value of a process placeholder as text.
+func processString(placeholder string) string {
+	switch v := processValue(placeholder).(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	default:
+		return ""
+	}
+}
+
+// appendJSONProcess appends the process placeholder as a JSON member named
+// after it; the ids are numbers.
+func appendJSONProcess(buf []byte, placeholder string) []byte {
+	buf = appendJSONKey(buf, placeholder[1:len(placeholder)-1])
+	switch v := processValue(placeholder).(type) {
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	default:
+		return appendJSONString(buf, processString(placeholder))
+	}
+}
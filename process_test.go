@@ -0,0 +1,96 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoroutineID(t *testing.T) {
+	id := goroutineID()
+	assert.NotZero(t, id)
+	assert.Equal(t, id, goroutineID())
+
+	var other uint64
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		other = goroutineID()
+	}()
+	wg.Wait()
+	assert.NotZero(t, other)
+	assert.NotEqual(t, id, other)
+}
+
+func TestProcessPlaceholders_Text(t *testing.T) {
+	host, err := os.Hostname()
+	assert.NoError(t, err)
+	expected := fmt.Sprintf("%s %d %d INFO hello", host, os.Getpid(), goroutineID())
+
+	opts := CustomHandlerOptions{
+		Level:   DebugLevel,
+		Enabled: true,
+		Pattern: "[host] [pid] [goroutine] [level] [msg]",
+	}
+	direct, text := &bytes.Buffer{}, &bytes.Buffer{}
+	directLogger, textLogger := newTextHandlerPair(opts, direct, text)
+	directLogger.Info("hello")
+	textLogger.Info("hello")
+	assert.Equal(t, expected, strings.TrimSpace(direct.String()))
+	assert.Equal(t, expected, strings.TrimSpace(text.String()))
+}
+
+func TestProcessPlaceholders_JSON(t *testing.T) {
+	opts := CustomHandlerOptions{
+		Level:               DebugLevel,
+		Enabled:             true,
+		PatternPlaceholders: []string{"[host]", "[pid]", "[goroutine]", "[level]", "[msg]"},
+	}
+	direct, slow := &bytes.Buffer{}, &bytes.Buffer{}
+	directLogger, slowLogger := newJSONHandlerPair(opts, direct, slow)
+	directLogger.Info("hello")
+	slowLogger.Info("hello")
+
+	for _, output := range []string{direct.String(), slow.String()} {
+		doc := decodeLines(t, output)[0]
+		assert.Equal(t, hostname(), doc["host"])
+		assert.Equal(t, float64(os.Getpid()), doc["pid"])
+		assert.Equal(t, float64(goroutineID()), doc["goroutine"])
+	}
+	assert.True(t, strings.HasPrefix(direct.String(), `{"host":"`+hostname()+`","pid":`))
+}
+
+func TestProcessPlaceholders_CSVAndTemplate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	jh := newJSONHandler(CustomHandlerOptions{
+		Level:               DebugLevel,
+		Enabled:             true,
+		SubType:             CSVHandlerSubType,
+		PatternPlaceholders: []string{"[host]", "[pid]", "[msg]"},
+	}, bufio.NewWriter(buf), nil)
+	slog.New(jh).Info("hello")
+	assert.NoError(t, jh.Flush())
+	row, err := csv.NewReader(buf).Read()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{hostname(), fmt.Sprint(os.Getpid()), "hello"}, row)
+
+	buf.Reset()
+	handler := NewCustomHandler(&CustomHandlerOptions{
+		Level:        DebugLevel,
+		Enabled:      true,
+		FormatEngine: TemplateFormatEngine,
+		Pattern:      `{{host}} {{pid}} {{.Message}}`,
+	}, bufio.NewWriter(buf), nil)
+	slog.New(handler).Info("hello")
+	assert.NoError(t, handler.Flush())
+	assert.Equal(t, fmt.Sprintf("%s %d hello\n", hostname(), os.Getpid()), buf.String())
+}
@@ -0,0 +1,121 @@
+package multilog
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ProfileEnvVar is the environment variable selecting the config profile.
+const ProfileEnvVar = EnvPrefix + "PROFILE"
+
+// ProfileConfig holds the handler settings of a config profile, such as dev or
+// prod. Handlers are selected by type, which applies to all handlers of the
+// type, or by name, which is applied after the type.
+type ProfileConfig struct {
+	Handlers map[string]ProfileHandlerConfig `yaml:"handlers"`
+}
+
+// ProfileHandlerConfig holds the settings a profile overrides on a handler;
+// unset fields keep the value of the handler.
+type ProfileHandlerConfig struct {
+	Enabled  *bool  `yaml:"enabled,omitempty"`
+	Level    string `yaml:"level,omitempty"`
+	MaxLevel string `yaml:"max_level,omitempty"`
+	Pattern  string `yaml:"pattern,omitempty"`
+}
+
+// SelectedProfile returns the profile to apply: the given one if set, else the
+// value of MULTILOG_PROFILE, else the profile set in the config.
+func SelectedProfile(config *Config, profile string) string {
+	if profile != "" {
+		return profile
+	}
+	if profile := os.Getenv(ProfileEnvVar); profile != "" {
+		return profile
+	}
+	return config.Multilog.Profile
+}
+
+// ApplyProfile applies the settings of the named profile to the handlers of the
+// config and records it as the selected profile. An empty name applies nothing.
+func ApplyProfile(config *Config, name string) error {
+	if name == "" {
+		return nil
+	}
+	profile, ok := config.Multilog.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile: %s", name)
+	}
+	config.Multilog.Profile = name
+
+	for i := range config.Multilog.Handlers {
+		handler := &config.Multilog.Handlers[i]
+		if settings, ok := profile.Handlers[handler.Type]; ok {
+			settings.apply(handler)
+		}
+		if handler.Name == "" || handler.Name == handler.Type {
+			continue
+		}
+		if settings, ok := profile.Handlers[handler.Name]; ok {
+			settings.apply(handler)
+		}
+	}
+	return nil
+}
+
+// apply sets the fields of the handler overridden by the profile.
+func (p ProfileHandlerConfig) apply(handler *HandlerConfig) {
+	if p.Enabled != nil {
+		handler.Enabled = *p.Enabled
+	}
+	handler.Level = defaultIfEmpty(p.Level, handler.Level)
+	handler.MaxLevel = defaultIfEmpty(p.MaxLevel, handler.MaxLevel)
+	handler.Pattern = defaultIfEmpty(p.Pattern, handler.Pattern)
+}
+
+// profileErrors returns the errors found validating all profiles, including
+// those that are not applied, so a typo fails in every environment.
+func profileErrors(config *Config) []error {
+	names := make([]string, 0, len(config.Multilog.Profiles))
+	for name := range config.Multilog.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		handlers := config.Multilog.Profiles[name].Handlers
+		keys := make([]string, 0, len(handlers))
+		for key := range handlers {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			settings := handlers[key]
+			if !profileSelects(config.Multilog.Handlers, key) {
+				errs = append(errs, fmt.Errorf("profile %s: unknown handler: %s", name, key))
+			}
+			if settings.Level != "" && !Contains(LogLevels, settings.Level) {
+				errs = append(errs, fmt.Errorf("profile %s: invalid log level for handler %s: %s",
+					name, key, settings.Level))
+			}
+			if settings.MaxLevel != "" && !Contains(LogLevels, settings.MaxLevel) {
+				errs = append(errs, fmt.Errorf("profile %s: invalid max log level for handler %s: %s",
+					name, key, settings.MaxLevel))
+			}
+		}
+	}
+	return errs
+}
+
+// profileSelects reports whether a handler has the name or type.
+func profileSelects(handlers []HandlerConfig, key string) bool {
+	for i := range handlers {
+		if handlers[i].Name == key || handlers[i].Type == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package multilog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const profileConfig = `
+multilog:
+  profile: dev
+  handlers:
+    - type: console
+      level: debug
+      enabled: true
+    - name: app
+      type: file
+      level: debug
+      file: app.log
+      enabled: true
+    - name: audit
+      type: file
+      level: info
+      file: audit.log
+      enabled: false
+  profiles:
+    dev:
+      handlers:
+        file:
+          pattern: "[time] [level] [source] [msg]"
+    prod:
+      handlers:
+        console:
+          enabled: false
+        file:
+          level: warn
+        audit:
+          level: info
+          enabled: true
+`
+
+func TestNewConfigFromData_Profiles(t *testing.T) {
+	config, err := NewConfigFromData([]byte(profileConfig))
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", config.Multilog.Profile)
+	handlers := config.Multilog.Handlers
+	assert.True(t, handlers[0].Enabled)
+	assert.Equal(t, "[time] [level] [source] [msg]", handlers[1].Pattern)
+	assert.Equal(t, "[time] [level] [source] [msg]", handlers[2].Pattern)
+	assert.False(t, handlers[2].Enabled)
+
+	t.Setenv(ProfileEnvVar, "prod")
+	config, err = NewConfigFromData([]byte(profileConfig))
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", config.Multilog.Profile)
+	handlers = config.Multilog.Handlers
+	assert.False(t, handlers[0].Enabled)
+	assert.Equal(t, "debug", handlers[0].Level)
+	assert.Equal(t, "warn", handlers[1].Level)
+	assert.Equal(t, "", handlers[1].Pattern)
+	// The name is applied after the type.
+	assert.Equal(t, "info", handlers[2].Level)
+	assert.True(t, handlers[2].Enabled)
+}
+
+func TestNewConfigWithProfile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yml")
+	assert.NoError(t, os.WriteFile(file, []byte(profileConfig), 0o600))
+	t.Setenv(ProfileEnvVar, "dev")
+	// Environment overrides are applied on top of the profile.
+	t.Setenv("MULTILOG_APP_LEVEL", "error")
+
+	config, err := NewConfigWithProfile(file, "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", config.Multilog.Profile)
+	assert.False(t, config.Multilog.Handlers[0].Enabled)
+	assert.Equal(t, "error", config.Multilog.Handlers[1].Level)
+
+	_, err = NewConfigWithProfile(file, "staging")
+	assert.EqualError(t, err, "invalid config profile: unknown profile: staging")
+}
+
+func TestValidateProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles string
+		expected string
+	}{
+		{
+			name:     "unknown handler",
+			profiles: "prod: {handlers: {kafka: {level: warn}}}",
+			expected: "profile prod: unknown handler: kafka",
+		},
+		{
+			name:     "invalid level",
+			profiles: "qa: {handlers: {console: {level: loud}}}",
+			expected: "profile qa: invalid log level for handler console: loud",
+		},
+		{
+			name:     "invalid max level",
+			profiles: "qa: {handlers: {console: {max_level: loud}}}",
+			expected: "profile qa: invalid max log level for handler console: loud",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: console
+      level: info
+      enabled: true
+  profiles:
+    ` + tt.profiles))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package multilog
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+)
+
+// ProtobufHandlerSubType writes each record as a length-delimited LogRecord
+// message of log_record.proto.
+const ProtobufHandlerSubType = "protobuf"
+
+// Protobuf wire types
+const (
+	protoVarint = 0
+	protoI64    = 1
+	protoLen    = 2
+	protoI32    = 5
+)
+
+// LogRecord fields
+const (
+	protoRecordTime = iota + 1
+	protoRecordLevel
+	protoRecordLevelName
+	protoRecordMessage
+	protoRecordAttrs
+	protoRecordSource
+	protoRecordPerf
+)
+
+// Value fields
+const (
+	protoValueString = iota + 1
+	protoValueInt
+	protoValueUint
+	protoValueDouble
+	protoValueBool
+	protoValueDuration
+	protoValueTime
+	protoValueBytes
+	protoValueGroup
+)
+
+func appendProtoTag(buf []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(field)<<3|uint64(wireType))
+}
+
+func appendProtoVarint(buf []byte, field int, v uint64) []byte {
+	return binary.AppendUvarint(appendProtoTag(buf, field, protoVarint), v)
+}
+
+func appendProtoFixed64(buf []byte, field int, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(appendProtoTag(buf, field, protoI64), v)
+}
+
+func appendProtoBytes(buf []byte, field int, b []byte) []byte {
+	buf = binary.AppendUvarint(appendProtoTag(buf, field, protoLen), uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendProtoString(buf []byte, field int, s string) []byte {
+	buf = binary.AppendUvarint(appendProtoTag(buf, field, protoLen), uint64(len(s)))
+	return append(buf, s...)
+}
+
+// zigzag encodes a signed integer as a sint64.
+func zigzag(i int64) uint64 {
+	return uint64(i<<1) ^ uint64(i>>63)
+}
+
+// appendProtobuf appends the record as a LogRecord preceded by its length.
+// Attributes are nested in their groups and encoded like appendAttrs encodes
+// them as JSON; the pattern is not used.
+func (jh *JSONHandler) appendProtobuf(buf []byte, ch *CustomHandler, record slog.Record) []byte {
+	opts := ch.Opts
+	msg := getBuffer()
+	defer putBuffer(msg)
+	m := *msg
+
+	if !record.Time.IsZero() {
+		m = appendProtoFixed64(m, protoRecordTime, uint64(record.Time.UnixNano()))
+	}
+	if record.Level != 0 {
+		m = appendProtoVarint(m, protoRecordLevel, zigzag(int64(record.Level)))
+	}
+	m = appendProtoString(m, protoRecordLevelName, levelLabel(record.Level, false))
+	if record.Message != "" {
+		m = appendProtoString(m, protoRecordMessage, record.Message)
+	}
+
+	// As in appendAttrs, groups are opened when their first attribute is
+	// appended; their headers are inserted when they are closed.
+	var open []int
+	var groups []string
+	appendAttr := func(attrGroups []string, a slog.Attr) {
+		for len(open) < len(attrGroups) {
+			open = append(open, len(m))
+		}
+		groups = attrGroups
+		m = appendProtoAttr(m, protoAttrField(len(groups)), groups, a, jh.replaceAttr)
+	}
+	for _, b := range ch.boundAttrs {
+		appendAttr(b.groups, b.attr)
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		appendAttr(ch.groups, a)
+		return true
+	})
+	for i := len(open) - 1; i >= 0; i-- {
+		m = wrapProtoGroup(m, open[i], protoAttrField(i), groups[i])
+	}
+
+	if opts.AddSource {
+		if src := record.Source(); src != nil && src.File != "" {
+			source := appendProtoString(nil, 1, src.File)
+			source = appendProtoVarint(source, 2, uint64(src.Line))
+			source = appendProtoString(source, 3, src.Function)
+			m = appendProtoBytes(m, protoRecordSource, source)
+		}
+	}
+	if record.Level == LevelPerf {
+		for _, a := range perfMetricsGroup(opts).Group() {
+			m = appendProtoAttr(m, protoRecordPerf, nil, a, nil)
+		}
+	}
+
+	*msg = m
+	buf = binary.AppendUvarint(buf, uint64(len(m)))
+	return append(buf, m...)
+}
+
+// protoAttrField returns the field of attributes nested in depth groups: the
+// attrs of the LogRecord, or the attrs of a Group.
+func protoAttrField(depth int) int {
+	if depth == 0 {
+		return protoRecordAttrs
+	}
+	return 1
+}
+
+// wrapProtoGroup turns the Attr fields appended from start into an Attr field
+// holding them as a group under the key. Empty groups are elided.
+func wrapProtoGroup(buf []byte, start, field int, key string) []byte {
+	size := uint64(len(buf) - start)
+	if size == 0 {
+		return buf
+	}
+	value := binary.AppendUvarint(appendProtoTag(nil, protoValueGroup, protoLen), size)
+	size += uint64(len(value))
+	attr := appendProtoString(nil, 1, key)
+	attr = binary.AppendUvarint(appendProtoTag(attr, 2, protoLen), size)
+	attr = append(attr, value...)
+	size += uint64(len(attr) - len(value))
+	header := binary.AppendUvarint(appendProtoTag(nil, field, protoLen), size)
+	header = append(header, attr...)
+	return slices.Insert(buf, start, header...)
+}
+
+// appendProtoAttr appends the attribute as an Attr field. It mirrors
+// appendJSONAttr: values are resolved, replaceAttr is applied to non-group
+// attributes, and empty attributes and groups are elided.
+func appendProtoAttr(buf []byte, field int, groups []string, a slog.Attr, replaceAttr CustomReplaceAttr) []byte {
+	a.Value = a.Value.Resolve()
+	if replaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = replaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Key == "" && a.Value.Kind() == slog.KindAny && a.Value.Any() == nil {
+		return buf
+	}
+	if a.Value.Kind() == slog.KindAny {
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			a.Value = sourceGroup(src)
+		}
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key == "" {
+			for _, ga := range a.Value.Group() {
+				buf = appendProtoAttr(buf, field, groups, ga, replaceAttr)
+			}
+			return buf
+		}
+		start := len(buf)
+		nested := append(groups[:len(groups):len(groups)], a.Key)
+		for _, ga := range a.Value.Group() {
+			buf = appendProtoAttr(buf, 1, nested, ga, replaceAttr)
+		}
+		return wrapProtoGroup(buf, start, field, a.Key)
+	}
+
+	attr := appendProtoString(nil, 1, a.Key)
+	attr = appendProtoBytes(attr, 2, appendProtoValue(nil, a.Value))
+	return appendProtoBytes(buf, field, attr)
+}
+
+// appendProtoValue appends the fields of a non-group Value. Values without a
+// protobuf equivalent are written as their string form.
+func appendProtoValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendProtoString(buf, protoValueString, v.String())
+	case slog.KindInt64:
+		return appendProtoVarint(buf, protoValueInt, zigzag(v.Int64()))
+	case slog.KindUint64:
+		return appendProtoVarint(buf, protoValueUint, v.Uint64())
+	case slog.KindFloat64:
+		return appendProtoFixed64(buf, protoValueDouble, math.Float64bits(v.Float64()))
+	case slog.KindBool:
+		var b uint64
+		if v.Bool() {
+			b = 1
+		}
+		return appendProtoVarint(buf, protoValueBool, b)
+	case slog.KindDuration:
+		return appendProtoVarint(buf, protoValueDuration, zigzag(int64(v.Duration())))
+	case slog.KindTime:
+		return appendProtoFixed64(buf, protoValueTime, uint64(v.Time().UnixNano()))
+	default:
+		switch x := v.Any().(type) {
+		case []byte:
+			return appendProtoBytes(buf, protoValueBytes, x)
+		case error:
+			return appendProtoString(buf, protoValueString, x.Error())
+		default:
+			return appendProtoString(buf, protoValueString, fmt.Sprint(x))
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package multilog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// ProtobufRecord is a record decoded from the protobuf subtype.
+type ProtobufRecord struct {
+	Source    *slog.Source
+	LevelName string
+	Message   string
+	Time      time.Time
+	Attrs     []slog.Attr
+	Perf      []slog.Attr
+	Level     slog.Level
+}
+
+// ProtobufReader decodes the length-delimited records written by handlers
+// with the protobuf subtype.
+type ProtobufReader struct {
+	r *bufio.Reader
+}
+
+// NewProtobufReader creates a reader for length-delimited LogRecord messages.
+func NewProtobufReader(r io.Reader) *ProtobufReader {
+	return &ProtobufReader{r: bufio.NewReader(r)}
+}
+
+// Next decodes the next record. It returns io.EOF when there are no more
+// records.
+func (pr *ProtobufReader) Next() (*ProtobufRecord, error) {
+	size, err := binary.ReadUvarint(pr.r)
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode record: %w", err)
+	}
+	if size > maxBinaryLength {
+		return nil, fmt.Errorf("failed to decode record: length %d exceeds limit", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(pr.r, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("failed to decode record: %w", err)
+	}
+	return UnmarshalProtobufRecord(data)
+}
+
+// UnmarshalProtobufRecord decodes a LogRecord message without its length prefix.
+// Unknown fields are skipped.
+func UnmarshalProtobufRecord(data []byte) (*ProtobufRecord, error) {
+	record := &ProtobufRecord{}
+	err := decodeProtoFields(data, func(f protoField) error {
+		field, ok := protoRecordFields[f.num]
+		if !ok || f.wire != field.wire {
+			return nil
+		}
+		return field.decode(record, f)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode record: %w", err)
+	}
+	return record, nil
+}
+
+// protoRecordField is a LogRecord field: its wire type and the function
+// storing its value in the record.
+type protoRecordField struct {
+	decode func(record *ProtobufRecord, f protoField) error
+	wire   int
+}
+
+// protoRecordFields maps the LogRecord field numbers to their fields.
+var protoRecordFields = map[int]protoRecordField{
+	protoRecordTime:      {decodeProtoRecordTime, protoI64},
+	protoRecordLevel:     {decodeProtoRecordLevel, protoVarint},
+	protoRecordLevelName: {decodeProtoRecordLevelName, protoLen},
+	protoRecordMessage:   {decodeProtoRecordMessage, protoLen},
+	protoRecordAttrs:     {decodeProtoRecordAttr, protoLen},
+	protoRecordSource:    {decodeProtoRecordSource, protoLen},
+	protoRecordPerf:      {decodeProtoRecordPerf, protoLen},
+}
+
+func decodeProtoRecordTime(record *ProtobufRecord, f protoField) error {
+	record.Time = time.Unix(0, int64(f.v))
+	return nil
+}
+
+func decodeProtoRecordLevel(record *ProtobufRecord, f protoField) error {
+	record.Level = slog.Level(unzigzag(f.v))
+	return nil
+}
+
+func decodeProtoRecordLevelName(record *ProtobufRecord, f protoField) error {
+	record.LevelName = string(f.b)
+	return nil
+}
+
+func decodeProtoRecordMessage(record *ProtobufRecord, f protoField) error {
+	record.Message = string(f.b)
+	return nil
+}
+
+func decodeProtoRecordAttr(record *ProtobufRecord, f protoField) error {
+	a, err := decodeProtoAttr(f.b)
+	if err != nil {
+		return err
+	}
+	record.Attrs = append(record.Attrs, a)
+	return nil
+}
+
+func decodeProtoRecordSource(record *ProtobufRecord, f protoField) error {
+	src, err := decodeProtoSource(f.b)
+	if err != nil {
+		return err
+	}
+	record.Source = src
+	return nil
+}
+
+func decodeProtoRecordPerf(record *ProtobufRecord, f protoField) error {
+	a, err := decodeProtoAttr(f.b)
+	if err != nil {
+		return err
+	}
+	record.Perf = append(record.Perf, a)
+	return nil
+}
+
+// protoField is a decoded field. v holds varint and fixed values, b the
+// contents of length-delimited fields.
+type protoField struct {
+	b    []byte
+	num  int
+	wire int
+	v    uint64
+}
+
+// decodeProtoFields calls fn for each field of the message.
+func decodeProtoFields(data []byte, fn func(f protoField) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("invalid field tag")
+		}
+		data = data[n:]
+		f := protoField{num: int(tag >> 3), wire: int(tag & 7)}
+		switch f.wire {
+		case protoVarint:
+			if f.v, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("invalid varint in field %d", f.num)
+			}
+			data = data[n:]
+		case protoI64:
+			if len(data) < 8 {
+				return fmt.Errorf("truncated field %d", f.num)
+			}
+			f.v, data = binary.LittleEndian.Uint64(data), data[8:]
+		case protoI32:
+			if len(data) < 4 {
+				return fmt.Errorf("truncated field %d", f.num)
+			}
+			f.v, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case protoLen:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || size > uint64(len(data)-n) {
+				return fmt.Errorf("truncated field %d", f.num)
+			}
+			f.b, data = data[n:n+int(size)], data[n+int(size):]
+		default:
+			return fmt.Errorf("unsupported wire type %d in field %d", f.wire, f.num)
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unzigzag decodes a sint64.
+func unzigzag(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+// decodeProtoAttr decodes an Attr message.
+func decodeProtoAttr(data []byte) (slog.Attr, error) {
+	var a slog.Attr
+	err := decodeProtoFields(data, func(f protoField) error {
+		switch {
+		case f.num == 1 && f.wire == protoLen:
+			a.Key = string(f.b)
+		case f.num == 2 && f.wire == protoLen:
+			v, err := decodeProtoValue(f.b)
+			if err != nil {
+				return err
+			}
+			a.Value = v
+		}
+		return nil
+	})
+	return a, err
+}
+
+// decodeProtoValue decodes a Value message.
+func decodeProtoValue(data []byte) (slog.Value, error) {
+	var v slog.Value
+	err := decodeProtoFields(data, func(f protoField) error {
+		switch f.num {
+		case protoValueString:
+			v = slog.StringValue(string(f.b))
+		case protoValueInt:
+			v = slog.Int64Value(unzigzag(f.v))
+		case protoValueUint:
+			v = slog.Uint64Value(f.v)
+		case protoValueDouble:
+			v = slog.Float64Value(math.Float64frombits(f.v))
+		case protoValueBool:
+			v = slog.BoolValue(f.v != 0)
+		case protoValueDuration:
+			v = slog.DurationValue(time.Duration(unzigzag(f.v)))
+		case protoValueTime:
+			v = slog.TimeValue(time.Unix(0, int64(f.v)))
+		case protoValueBytes:
+			v = slog.AnyValue([]byte(string(f.b)))
+		case protoValueGroup:
+			var attrs []slog.Attr
+			err := decodeProtoFields(f.b, func(g protoField) error {
+				if g.num != 1 || g.wire != protoLen {
+					return nil
+				}
+				a, err := decodeProtoAttr(g.b)
+				attrs = append(attrs, a)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			v = slog.GroupValue(attrs...)
+		}
+		return nil
+	})
+	return v, err
+}
+
+// decodeProtoSource decodes a Source message.
+func decodeProtoSource(data []byte) (*slog.Source, error) {
+	src := &slog.Source{}
+	err := decodeProtoFields(data, func(f protoField) error {
+		switch {
+		case f.num == 1 && f.wire == protoLen:
+			src.File = string(f.b)
+		case f.num == 2 && f.wire == protoVarint:
+			src.Line = int(int32(f.v))
+		case f.num == 3 && f.wire == protoLen:
+			src.Function = string(f.b)
+		}
+		return nil
+	})
+	return src, err
+}
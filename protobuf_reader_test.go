@@ -0,0 +1,57 @@
+package multilog
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalProtobufRecord(t *testing.T) {
+	record, err := UnmarshalProtobufRecord([]byte{
+		0x09, 0x00, 0xca, 0x9a, 0x3b, 0x00, 0x00, 0x00, 0x00,
+		0x10, 0x07,
+		0x22, 0x01, 'x',
+		0x32, 0x07, 0x0a, 0x01, 'f', 0x10, 0x07, 0x1a, 0x00,
+		0x45, 0x01, 0x00, 0x00, 0x00,
+		0x52, 0x00,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &ProtobufRecord{
+		Source:  &slog.Source{File: "f", Line: 7},
+		Message: "x",
+		Time:    time.Unix(1, 0),
+		Level:   slog.Level(-4),
+	}, record, "unknown fields are skipped")
+}
+
+func TestUnmarshalProtobufRecord_Errors(t *testing.T) {
+	_, err := UnmarshalProtobufRecord([]byte{0x22, 0x05, 'x'})
+	assert.EqualError(t, err, "failed to decode record: truncated field 4")
+
+	_, err = UnmarshalProtobufRecord([]byte{0x0b})
+	assert.EqualError(t, err, "failed to decode record: unsupported wire type 3 in field 1")
+
+	_, err = UnmarshalProtobufRecord([]byte{0x2a, 0x03, 0x12, 0x01, 0x80})
+	assert.EqualError(t, err, "failed to decode record: invalid field tag")
+}
+
+func TestProtobufReader_Truncated(t *testing.T) {
+	reader := NewProtobufReader(bytes.NewReader([]byte{0x05, 0x22}))
+	_, err := reader.Next()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	reader = NewProtobufReader(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0x7f}))
+	_, err = reader.Next()
+	assert.ErrorContains(t, err, "exceeds limit")
+}
+
+func TestZigzag(t *testing.T) {
+	for _, i := range []int64{0, -1, 1, -4, 1 << 40, -1 << 63} {
+		assert.Equal(t, i, unzigzag(zigzag(i)))
+	}
+	assert.Equal(t, uint64(7), zigzag(-4))
+}
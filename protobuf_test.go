@@ -0,0 +1,215 @@
+package multilog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendProtobuf_Wire(t *testing.T) {
+	jh := newJSONHandler(CustomHandlerOptions{Level: DebugLevel, Enabled: true, SubType: ProtobufHandlerSubType},
+		nil, nil)
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, "hi", 0)
+	record.AddAttrs(slog.Int("n", -1))
+	doc, err := jh.format(context.Background(), record)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x13,
+		0x1a, 0x04, 'I', 'N', 'F', 'O',
+		0x22, 0x02, 'h', 'i',
+		0x2a, 0x07, 0x0a, 0x01, 'n', 0x12, 0x02, 0x10, 0x01,
+	}, doc)
+}
+
+func TestProtobuf_RoundTrip(t *testing.T) {
+	jh := newJSONHandler(CustomHandlerOptions{
+		Level:     DebugLevel,
+		Enabled:   true,
+		SubType:   ProtobufHandlerSubType,
+		AddSource: true,
+	}, nil, nil)
+	now := time.Unix(1700000000, 123456789)
+	handler := jh.WithAttrs([]slog.Attr{slog.String("service", "api")}).
+		WithGroup("req").
+		WithAttrs([]slog.Attr{slog.String("path", "/x")}).
+		WithGroup("empty").
+		WithGroup("inner")
+
+	record := slog.NewRecord(now, slog.LevelWarn, "slow", 0)
+	record.AddAttrs(
+		slog.Int("status", 503),
+		slog.Uint64("bytes", 1<<63),
+		slog.Float64("ratio", 0.5),
+		slog.Bool("retry", true),
+		slog.Duration("took", -time.Second),
+		slog.Time("at", now),
+		slog.Any("raw", []byte{1, 2}),
+		slog.Any("err", errors.New("boom")),
+		slog.Group("g"),
+	)
+	doc, err := handler.(*JSONHandler).Format(context.Background(), record)
+	assert.NoError(t, err)
+
+	decoded, err := NewProtobufReader(bytes.NewReader([]byte(doc))).Next()
+	assert.NoError(t, err)
+	assert.True(t, now.Equal(decoded.Time))
+	assert.Equal(t, slog.LevelWarn, decoded.Level)
+	assert.Equal(t, "WARN", decoded.LevelName)
+	assert.Equal(t, "slow", decoded.Message)
+	assert.Nil(t, decoded.Source, "records without a PC have no source")
+
+	expected := slog.Group("req",
+		slog.String("path", "/x"),
+		slog.Group("empty", slog.Group("inner",
+			slog.Int64("status", 503),
+			slog.Uint64("bytes", 1<<63),
+			slog.Float64("ratio", 0.5),
+			slog.Bool("retry", true),
+			slog.Duration("took", -time.Second),
+			slog.Time("at", now),
+			slog.Any("raw", []byte{1, 2}),
+			slog.Group("err", slog.String(ErrorMessageKey, "boom"), slog.String(ErrorTypeKey, "*errors.errorString")),
+		)),
+	)
+	assert.Len(t, decoded.Attrs, 2)
+	assert.Equal(t, slog.String("service", "api"), decoded.Attrs[0])
+	assert.Equal(t, expected.String(), decoded.Attrs[1].String())
+}
+
+func TestProtobuf_SourceAndPerf(t *testing.T) {
+	jh := newJSONHandler(CustomHandlerOptions{
+		Level:     DebugLevel,
+		Enabled:   true,
+		SubType:   ProtobufHandlerSubType,
+		AddSource: true,
+	}, nil, nil)
+	handler := jh.WithGroup("unused")
+
+	var buf bytes.Buffer
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	for _, level := range []slog.Level{slog.LevelInfo, LevelPerf} {
+		doc, err := handler.(*JSONHandler).Format(context.Background(), slog.NewRecord(time.Now(), level, "msg", pcs[0]))
+		assert.NoError(t, err)
+		buf.WriteString(doc)
+	}
+
+	reader := NewProtobufReader(&buf)
+	record, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Empty(t, record.Attrs, "empty groups are elided")
+	if assert.NotNil(t, record.Source) {
+		assert.Equal(t, "protobuf_test.go", filepath.Base(record.Source.File))
+		assert.NotZero(t, record.Source.Line)
+	}
+	assert.Empty(t, record.Perf)
+
+	record, err = reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "PERF", record.LevelName)
+	assert.NotEmpty(t, record.Perf)
+
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestProtobuf_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.pb")
+	cfg, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: file
+      subtype: protobuf
+      level: info
+      enabled: true
+      file: ` + path))
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+
+	logger := slog.New(handlers[0])
+	logger.Info("first", "user", "alice")
+	logger.Error("second")
+	assert.NoError(t, handlers[0].(*JSONHandler).Close())
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	reader := NewProtobufReader(f)
+	record, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "first", record.Message)
+	assert.Equal(t, []slog.Attr{slog.String("user", "alice")}, record.Attrs)
+	record, err = reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelError, record.Level)
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestProtobuf_Socket(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	records := make(chan *ProtobufRecord, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := NewProtobufReader(conn)
+		for {
+			record, err := reader.Next()
+			if err != nil {
+				return
+			}
+			records <- record
+		}
+	}()
+
+	handler, err := NewSocketHandler(SocketHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			SubType: ProtobufHandlerSubType,
+		},
+		Address: listener.Addr().String(),
+	})
+	assert.NoError(t, err)
+	defer handler.(*SocketHandler).Close()
+
+	logger := slog.New(handler)
+	logger.Info("first")
+	logger.Info("second")
+	for _, msg := range []string{"first", "second"} {
+		select {
+		case record := <-records:
+			assert.Equal(t, msg, record.Message)
+		case <-time.After(time.Second):
+			t.Fatal("expected a record")
+		}
+	}
+}
+
+func TestValidateHandler_Protobuf(t *testing.T) {
+	handler := &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.pb", SubType: ProtobufHandlerSubType}
+	assert.NoError(t, validateHandler(handler))
+
+	handler = &HandlerConfig{Type: SocketHandlerType, Level: InfoLevel, Address: "localhost:1", SubType: "protobuf"}
+	assert.NoError(t, validateHandler(handler))
+
+	handler = &HandlerConfig{Type: KafkaHandlerType, Level: InfoLevel, Brokers: []string{"b:9092"}, Topic: "logs",
+		SubType: ProtobufHandlerSubType}
+	assert.EqualError(t, validateHandler(handler), "protobuf subtype requires a file or socket handler")
+}
@@ -0,0 +1,157 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Rate limiting settings
+const (
+	DefaultRateLimitSummaryInterval = 10 * time.Second
+	RateLimitSummaryMessage         = "log records dropped by rate limiter"
+	DroppedKey                      = "dropped"
+)
+
+// rateLimiter is a token bucket that counts the records it rejects.
+type rateLimiter struct {
+	now          func() time.Time
+	last         time.Time
+	nextSummary  time.Time
+	tokens       float64
+	rate         float64
+	burst        float64
+	dropped      uint64
+	totalDropped uint64
+	interval     time.Duration
+	mu           sync.Mutex
+}
+
+// newRateLimiter creates a full token bucket refilled at rate tokens per second.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = max(1, int(rate))
+	}
+	now := time.Now()
+	return &rateLimiter{
+		now:         time.Now,
+		last:        now,
+		nextSummary: now.Add(DefaultRateLimitSummaryInterval),
+		tokens:      float64(burst),
+		rate:        rate,
+		burst:       float64(burst),
+		interval:    DefaultRateLimitSummaryInterval,
+	}
+}
+
+// allow takes a token if one is available and counts the record as dropped otherwise.
+// It also returns the number of records dropped since the last summary once the
+// summary interval has elapsed, and zero otherwise.
+func (rl *rateLimiter) allow() (ok bool, summary uint64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	if elapsed := now.Sub(rl.last); elapsed > 0 {
+		rl.tokens = min(rl.burst, rl.tokens+elapsed.Seconds()*rl.rate)
+		rl.last = now
+	}
+
+	if rl.tokens >= 1 {
+		rl.tokens--
+		ok = true
+	} else {
+		rl.dropped++
+		rl.totalDropped++
+	}
+
+	if rl.dropped > 0 && !now.Before(rl.nextSummary) {
+		summary = rl.takeDropped(now)
+	}
+	return ok, summary
+}
+
+// takeDropped returns and resets the dropped count; the caller must hold rl.mu.
+func (rl *rateLimiter) takeDropped(now time.Time) uint64 {
+	dropped := rl.dropped
+	rl.dropped = 0
+	rl.nextSummary = now.Add(rl.interval)
+	return dropped
+}
+
+// RateLimitHandler is a Handler that limits the rate of records reaching the
+// wrapped handler with a token bucket. Dropped records are reported in a
+// periodic warn-level summary record.
+type RateLimitHandler struct {
+	limiter *rateLimiter
+	wrappedHandler
+}
+
+// NewRateLimitHandler creates a rate-limited handler allowing ratePerSecond
+// records per second with bursts of up to burst records. A burst below one
+// defaults to the rate.
+func NewRateLimitHandler(handler slog.Handler, ratePerSecond float64, burst int) *RateLimitHandler {
+	return &RateLimitHandler{
+		limiter:        newRateLimiter(ratePerSecond, burst),
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// Handle forwards the record to the wrapped handler if the rate allows it.
+func (rh *RateLimitHandler) Handle(ctx context.Context, record slog.Record) error {
+	ok, dropped := rh.limiter.allow()
+	var err error
+	if dropped > 0 {
+		err = rh.writeSummary(ctx, dropped)
+	}
+	if ok {
+		err = errors.Join(err, rh.Handler.Handle(ctx, record))
+	}
+	return err
+}
+
+// WithAttrs creates a new handler with the given attributes; the rate limit is shared.
+func (rh *RateLimitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *rh
+	clone.Handler = rh.Handler.WithAttrs(attrs)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name; the rate limit is shared.
+func (rh *RateLimitHandler) WithGroup(name string) slog.Handler {
+	clone := *rh
+	clone.Handler = rh.Handler.WithGroup(name)
+	return &clone
+}
+
+// Dropped returns the total number of records dropped by the rate limiter.
+func (rh *RateLimitHandler) Dropped() uint64 {
+	rh.limiter.mu.Lock()
+	defer rh.limiter.mu.Unlock()
+	return rh.limiter.totalDropped
+}
+
+// Close writes a final summary of dropped records and closes the wrapped handler.
+func (rh *RateLimitHandler) Close() error {
+	rh.limiter.mu.Lock()
+	dropped := rh.limiter.takeDropped(rh.limiter.now())
+	rh.limiter.mu.Unlock()
+
+	var err error
+	if dropped > 0 {
+		err = rh.writeSummary(context.Background(), dropped)
+	}
+	return errors.Join(err, rh.wrappedHandler.Close())
+}
+
+// writeSummary writes a record reporting the number of dropped records.
+func (rh *RateLimitHandler) writeSummary(ctx context.Context, dropped uint64) error {
+	if !rh.Handler.Enabled(ctx, slog.LevelWarn) {
+		return nil
+	}
+	record := slog.NewRecord(time.Now(), slog.LevelWarn, RateLimitSummaryMessage, 0)
+	record.AddAttrs(slog.Uint64(DroppedKey, dropped))
+	return rh.Handler.Handle(ctx, record)
+}
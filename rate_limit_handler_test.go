@@ -0,0 +1,85 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingHandler collects the records it handles.
+type recordingHandler struct {
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *recordingHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rl := newRateLimiter(2, 3)
+	rl.now = clock.Now
+	rl.last = clock.Now()
+	rl.nextSummary = clock.Now().Add(rl.interval)
+
+	allowed := 0
+	for range 5 {
+		if ok, _ := rl.allow(); ok {
+			allowed++
+		}
+	}
+	assert.Equal(t, 3, allowed)
+
+	clock.Add(time.Second)
+	allowed = 0
+	for range 5 {
+		if ok, _ := rl.allow(); ok {
+			allowed++
+		}
+	}
+	assert.Equal(t, 2, allowed)
+
+	clock.Add(rl.interval)
+	ok, summary := rl.allow()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(5), summary)
+	assert.Equal(t, uint64(5), rl.totalDropped)
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	recorder := &recordingHandler{}
+	rh := NewRateLimitHandler(recorder, 1, 2)
+
+	for range 10 {
+		assert.NoError(t, rh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "storm", 0)))
+	}
+	assert.Len(t, recorder.records, 2)
+	assert.Equal(t, uint64(8), rh.Dropped())
+
+	assert.NoError(t, rh.Close())
+	assert.Len(t, recorder.records, 3)
+	summary := recorder.records[2]
+	assert.Equal(t, RateLimitSummaryMessage, summary.Message)
+	assert.Equal(t, slog.LevelWarn, summary.Level)
+	summary.Attrs(func(a slog.Attr) bool {
+		assert.Equal(t, DroppedKey, a.Key)
+		assert.Equal(t, uint64(8), a.Value.Uint64())
+		return true
+	})
+}
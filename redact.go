@@ -0,0 +1,131 @@
+package multilog
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+// RedactedValue is logged in place of secret values.
+const RedactedValue = "****"
+
+// Redacted holds a secret value. It is logged as RedactedValue by every handler,
+// and formats as RedactedValue with fmt and encoding/json too, so the secret
+// does not leak when it ends up in a message or a nested structure.
+type Redacted struct {
+	value any
+}
+
+// Secret wraps the value so it is logged as RedactedValue:
+//
+//	logger.Info("login", "user", user, "password", multilog.Secret(password))
+func Secret(v any) Redacted {
+	return Redacted{value: v}
+}
+
+// Reveal returns the secret value.
+func (r Redacted) Reveal() any {
+	return r.value
+}
+
+// LogValue implements slog.LogValuer.
+func (r Redacted) LogValue() slog.Value {
+	return slog.StringValue(RedactedValue)
+}
+
+// String implements fmt.Stringer.
+func (r Redacted) String() string {
+	return RedactedValue
+}
+
+// GoString implements fmt.GoStringer.
+func (r Redacted) GoString() string {
+	return RedactedValue
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r Redacted) MarshalJSON() ([]byte, error) {
+	return json.Marshal(RedactedValue)
+}
+
+// RedactHandler is a Handler that wraps the values of attributes whose key is
+// in a deny-list with Secret before they reach the wrapped handler. Keys are
+// matched case-insensitively against top-level attributes and the dotted path of
+// attributes in groups (e.g. "request.token").
+type RedactHandler struct {
+	keys  map[string]bool
+	group string
+	wrappedHandler
+}
+
+// NewRedactHandler creates a handler redacting the attributes with the keys.
+func NewRedactHandler(handler slog.Handler, keys ...string) *RedactHandler {
+	deny := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		deny[strings.ToLower(key)] = true
+	}
+	return &RedactHandler{
+		keys:           deny,
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// RedactMiddleware wraps handlers with NewRedactHandler.
+func RedactMiddleware(keys ...string) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewRedactHandler(next, keys...)
+	}
+}
+
+// Handle redacts the attributes of the record and writes it.
+func (rh *RedactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(rh.redact(a, rh.group))
+		return true
+	})
+	return rh.Handler.Handle(ctx, redacted)
+}
+
+// WithAttrs creates a new handler with the attributes redacted.
+func (rh *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = rh.redact(a, rh.group)
+	}
+	clone := *rh
+	clone.Handler = rh.Handler.WithAttrs(redacted)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (rh *RedactHandler) WithGroup(name string) slog.Handler {
+	clone := *rh
+	clone.Handler = rh.Handler.WithGroup(name)
+	clone.group = rh.group + name + "."
+	return &clone
+}
+
+// redact wraps the value of the attribute with Secret if its key is denied,
+// looking into groups.
+func (rh *RedactHandler) redact(a slog.Attr, group string) slog.Attr {
+	key := strings.ToLower(a.Key)
+	if rh.keys[key] || (group != "" && rh.keys[strings.ToLower(group)+key]) {
+		return slog.Any(a.Key, Secret(a.Value))
+	}
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		return a
+	}
+	attrs := a.Value.Group()
+	redacted := make([]slog.Attr, len(attrs))
+	prefix := group
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for i, ga := range attrs {
+		redacted[i] = rh.redact(ga, prefix)
+	}
+	return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+}
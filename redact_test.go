@@ -0,0 +1,110 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecret(t *testing.T) {
+	secret := Secret("hunter2")
+	assert.Equal(t, "hunter2", secret.Reveal())
+	assert.Equal(t, RedactedValue, secret.LogValue().String())
+	assert.Equal(t, "****", fmt.Sprint(secret))
+	assert.Equal(t, "password=****", fmt.Sprintf("password=%v", secret))
+	assert.Equal(t, "****", fmt.Sprintf("%#v", secret))
+
+	data, err := json.Marshal(map[string]any{"password": secret})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"password":"****"}`, string(data))
+}
+
+func TestSecret_Handlers(t *testing.T) {
+	var text bytes.Buffer
+	handler := NewCustomHandler(&CustomHandlerOptions{
+		Level:   InfoLevel,
+		Enabled: true,
+		Pattern: "[level] [msg]",
+	}, bufio.NewWriter(&text), nil)
+	slog.New(handler).Info("login", "password", Secret("hunter2"))
+	assert.NoError(t, handler.Flush())
+	assert.Equal(t, "INFO login [password=****]\n", text.String())
+
+	var std bytes.Buffer
+	slog.New(slog.NewJSONHandler(&std, nil)).Info("login", "token", Secret("abc"))
+	assert.Contains(t, std.String(), `"token":"****"`)
+	assert.NotContains(t, std.String(), "abc")
+}
+
+func TestRedactHandler(t *testing.T) {
+	recorder := &recordingHandler{}
+	h := NewRedactHandler(recorder, "Password", "request.token")
+
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "login", 0)
+	r.AddAttrs(
+		slog.String("user", "bob"),
+		slog.String("PASSWORD", "x"),
+		slog.Group("request", slog.String("token", "t"), slog.String("id", "42")),
+	)
+	assert.NoError(t, h.Handle(context.Background(), r))
+
+	values := map[string]string{}
+	recorder.records[0].Attrs(func(a slog.Attr) bool {
+		if a.Value.Kind() == slog.KindGroup {
+			for _, ga := range a.Value.Group() {
+				values[a.Key+"."+ga.Key] = ga.Value.Resolve().String()
+			}
+			return true
+		}
+		values[a.Key] = a.Value.Resolve().String()
+		return true
+	})
+	assert.Equal(t, map[string]string{
+		"user":          "bob",
+		"PASSWORD":      RedactedValue,
+		"request.token": RedactedValue,
+		"request.id":    "42",
+	}, values)
+}
+
+func TestRedactHandler_WithAttrsAndGroups(t *testing.T) {
+	var buf bytes.Buffer
+	inner := NewCustomHandler(&CustomHandlerOptions{
+		Level:   InfoLevel,
+		Enabled: true,
+		Pattern: "[level] [msg]",
+	}, bufio.NewWriter(&buf), nil)
+	logger := slog.New(RedactMiddleware("api_key", "request.token")(inner))
+
+	logger.With("api_key", "k", "service", "api").WithGroup("request").Info("handled", "token", "t", "id", 42)
+	assert.NoError(t, inner.Flush())
+	assert.Equal(t, "INFO handled [api_key=**** service=api request.token=**** request.id=42]\n", buf.String())
+}
+
+func TestRedactKeys_Config(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	cfg, err := NewBuilder().File(path, JSON(), RedactKeys("password")).Config()
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	_, ok := handlers[0].(*RedactHandler)
+	assert.True(t, ok)
+
+	logger := NewLogger(handlers...)
+	logger.Info("login", "user", "bob", "password", "hunter2")
+	assert.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"password":"****"`)
+	assert.NotContains(t, string(data), "hunter2")
+}
@@ -0,0 +1,178 @@
+package multilog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// LoggerKey is the attribute key holding the name of a named logger.
+const LoggerKey = "logger"
+
+// loggerNameSeparator separates the components of hierarchical logger names.
+const loggerNameSeparator = "."
+
+// Registry holds named loggers derived from a root logger and their level overrides.
+// Levels are inherited along dotted names: a level set for "http" applies to
+// "http.server" unless "http.server" has its own level. The levels only drop
+// records: handlers still apply their own levels to the records of named loggers,
+// and config validation rejects a logger level below the level of every handler.
+type Registry struct {
+	root    *Logger
+	levels  map[string]slog.Level
+	loggers map[string]*Logger
+	mu      sync.RWMutex
+}
+
+// NewRegistry creates a registry whose named loggers write to the root logger.
+func NewRegistry(root *Logger) *Registry {
+	return &Registry{
+		root:    root,
+		levels:  make(map[string]slog.Level),
+		loggers: make(map[string]*Logger),
+	}
+}
+
+// defaultRegistry is the registry used by the package-level functions.
+var defaultRegistry = NewRegistry(nil)
+
+// GetLogger returns the named logger from the default registry.
+func GetLogger(name string) *Logger {
+	return defaultRegistry.GetLogger(name)
+}
+
+// SetRootLogger sets the root logger of the default registry.
+func SetRootLogger(root *Logger) {
+	defaultRegistry.SetRoot(root)
+}
+
+// SetLoggerLevel sets the level of a named logger in the default registry.
+func SetLoggerLevel(name, level string) error {
+	return defaultRegistry.SetLevel(name, level)
+}
+
+// SetLoggerLevels sets the levels of named loggers in the default registry,
+// as configured under "loggers".
+func SetLoggerLevels(levels map[string]string) error {
+	return defaultRegistry.SetLevels(levels)
+}
+
+// SetRoot sets the root logger. Loggers returned earlier keep writing to the
+// previous root, so set the root before handing out named loggers.
+func (r *Registry) SetRoot(root *Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.root = root
+	clear(r.loggers)
+}
+
+// GetLogger returns the logger with the given name, creating it on first use.
+// Its records carry a logger=name attribute and are filtered by the level
+// configured for the name or its closest parent.
+func (r *Registry) GetLogger(name string) *Logger {
+	r.mu.RLock()
+	l, ok := r.loggers[name]
+	r.mu.RUnlock()
+	if ok {
+		return l
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l, ok := r.loggers[name]; ok {
+		return l
+	}
+
+	root := r.root
+	if root == nil {
+		root = &Logger{Logger: slog.Default()}
+	}
+	handler := &namedHandler{
+		Handler:  root.Logger.Handler().WithAttrs([]slog.Attr{slog.String(LoggerKey, name)}),
+		registry: r,
+		name:     name,
+	}
+	l = &Logger{
+		Logger:     slog.New(handler),
+		extractors: root.extractors,
+		callerSkip: root.callerSkip,
+	}
+	r.loggers[name] = l
+	return l
+}
+
+// SetLevel sets the level of the named logger and the loggers below it without their own level.
+func (r *Registry) SetLevel(name, level string) error {
+	if !Contains(LogLevels, level) {
+		return fmt.Errorf("invalid log level for logger %s: %s", name, level)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.levels[name] = GetSlogLevel(level)
+	return nil
+}
+
+// SetLevels sets the levels of several named loggers.
+func (r *Registry) SetLevels(levels map[string]string) error {
+	for name, level := range levels {
+		if err := r.SetLevel(name, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Level returns the level in effect for the named logger and whether one is set.
+func (r *Registry) Level(name string) (slog.Level, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for {
+		if level, ok := r.levels[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndex(name, loggerNameSeparator)
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
+
+// namedHandler filters records by the level configured for a named logger.
+type namedHandler struct {
+	Handler  slog.Handler
+	registry *Registry
+	name     string
+}
+
+// Enabled checks the named logger level before the wrapped handler.
+func (nh *namedHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if minLevel, ok := nh.registry.Level(nh.name); ok && level < minLevel {
+		return false
+	}
+	return nh.Handler.Enabled(ctx, level)
+}
+
+// Handle writes the record if the named logger level allows it.
+func (nh *namedHandler) Handle(ctx context.Context, record slog.Record) error {
+	if minLevel, ok := nh.registry.Level(nh.name); ok && record.Level < minLevel {
+		return nil
+	}
+	return nh.Handler.Handle(ctx, record)
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (nh *namedHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *nh
+	clone.Handler = nh.Handler.WithAttrs(attrs)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (nh *namedHandler) WithGroup(name string) slog.Handler {
+	clone := *nh
+	clone.Handler = nh.Handler.WithGroup(name)
+	return &clone
+}
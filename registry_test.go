@@ -0,0 +1,56 @@
+package multilog
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRegistry(t *testing.T) (*Registry, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	root := &Logger{Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+	return NewRegistry(root), &buf
+}
+
+func TestRegistry_GetLogger(t *testing.T) {
+	r, buf := newTestRegistry(t)
+
+	db := r.GetLogger("db")
+	assert.Same(t, db, r.GetLogger("db"))
+
+	db.Info("connected")
+	assert.Contains(t, buf.String(), "logger=db")
+	assert.Contains(t, buf.String(), "connected")
+}
+
+func TestRegistry_Levels(t *testing.T) {
+	r, buf := newTestRegistry(t)
+	assert.NoError(t, r.SetLevels(map[string]string{"db": "debug", "http": "warn"}))
+	assert.Error(t, r.SetLevel("db", "verbose"))
+
+	r.GetLogger("db").Debug("query")
+	r.GetLogger("http.server").Info("request")
+	r.GetLogger("http.server").Warn("slow request")
+	r.GetLogger("cache").Debug("miss")
+
+	output := buf.String()
+	assert.Contains(t, output, "query")
+	assert.NotContains(t, output, "msg=request")
+	assert.Contains(t, output, "slow request")
+	assert.Contains(t, output, "miss")
+
+	assert.NoError(t, r.SetLevel("http.server", "info"))
+	buf.Reset()
+	r.GetLogger("http.server").Info("request")
+	assert.True(t, strings.Contains(buf.String(), "msg=request"))
+
+	level, ok := r.Level("http.client")
+	assert.True(t, ok)
+	assert.Equal(t, slog.LevelWarn, level)
+	_, ok = r.Level("other")
+	assert.False(t, ok)
+}
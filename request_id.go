@@ -0,0 +1,57 @@
+package multilog
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+)
+
+// RequestIDKey is the attribute key of the request ID.
+const RequestIDKey = "request_id"
+
+// RequestIDGenerator generates the IDs added by ContextWithRequestID. It
+// defaults to NewRequestID and can be replaced, for example to use xid.
+var RequestIDGenerator = NewRequestID
+
+// requestIDContextKey is the context key request IDs are stored under.
+type requestIDContextKey struct{}
+
+// NewRequestID returns a random version 4 UUID.
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// ContextWithRequestID returns ctx if it carries a request ID, or a copy of
+// ctx with an ID from RequestIDGenerator otherwise.
+func ContextWithRequestID(ctx context.Context) context.Context {
+	if _, ok := RequestIDFromContext(ctx); ok {
+		return ctx
+	}
+	return WithRequestID(ctx, RequestIDGenerator())
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID, typically one
+// received from a caller.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// requestIDAttrs returns the request_id attribute of a context carrying a
+// request ID. Context-aware records always carry it.
+func requestIDAttrs(ctx context.Context) []slog.Attr {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		return []slog.Attr{slog.String(RequestIDKey, id)}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRequestID(t *testing.T) {
+	id := NewRequestID()
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, id)
+	assert.NotEqual(t, id, NewRequestID())
+}
+
+func TestContextWithRequestID(t *testing.T) {
+	_, ok := RequestIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := ContextWithRequestID(context.Background())
+	id, ok := RequestIDFromContext(ctx)
+	assert.True(t, ok)
+	assert.NotEmpty(t, id)
+	assert.Equal(t, ctx, ContextWithRequestID(ctx))
+
+	ctx = WithRequestID(context.Background(), "req-42")
+	id, _ = RequestIDFromContext(ContextWithRequestID(ctx))
+	assert.Equal(t, "req-42", id)
+}
+
+func TestContextWithRequestID_Generator(t *testing.T) {
+	defer func(g func() string) { RequestIDGenerator = g }(RequestIDGenerator)
+	RequestIDGenerator = func() string { return "xid-1" }
+
+	id, _ := RequestIDFromContext(ContextWithRequestID(context.Background()))
+	assert.Equal(t, "xid-1", id)
+}
+
+func TestContextLogger_RequestID(t *testing.T) {
+	var buf strings.Builder
+	logger := NewLogger(slog.NewTextHandler(&buf, nil))
+	ctx := WithRequestID(context.Background(), "req-42")
+
+	logger.WithContext(ctx).Info("handled")
+	logger.InfoContext(ctx, "handled %s", "again")
+	logger.Info("no context")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "request_id=req-42")
+	assert.Contains(t, lines[1], "request_id=req-42")
+	assert.NotContains(t, lines[2], "request_id")
+}
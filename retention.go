@@ -0,0 +1,264 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// DefaultRetentionInterval is the default interval between retention runs.
+const DefaultRetentionInterval = time.Hour
+
+// RetentionPolicy limits the log files kept by a file handler. A zero limit is
+// not applied.
+type RetentionPolicy struct {
+	// MaxTotalSize caps the total size in bytes of the log files.
+	MaxTotalSize int64
+	// MaxFiles caps the number of log files.
+	MaxFiles int
+	// MaxAge deletes log files last written longer ago.
+	MaxAge time.Duration
+	// Interval is the time between retention runs.
+	Interval time.Duration
+}
+
+// currentFiler is implemented by file handlers to report the files being written.
+type currentFiler interface {
+	currentFiles() []string
+}
+
+// Retention applies a retention policy to the log files of a file handler
+// template on a schedule. Unlike the MaxBackups and MaxAge options, which
+// lumberjack applies to the backups of a single file, the policy covers all
+// the files of the template: backups, compressed files, shards and date
+// directories. Files being written are never deleted.
+type Retention struct {
+	now       func() time.Time
+	current   func() []string
+	onError   func(err error)
+	stop      chan struct{}
+	file      string
+	policy    RetentionPolicy
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	closeOnce sync.Once
+}
+
+// NewRetention creates a retention for the files of the file template and
+// starts its schedule, running a first time right away. current returns the
+// files being written. A nil onError writes to stderr.
+func NewRetention(
+	file string,
+	policy RetentionPolicy,
+	current func() []string,
+	onError func(err error),
+) *Retention {
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	if current == nil {
+		current = func() []string { return nil }
+	}
+	policy.Interval = defaultDuration(policy.Interval, DefaultRetentionInterval)
+	r := &Retention{
+		now:     time.Now,
+		current: current,
+		onError: onError,
+		stop:    make(chan struct{}),
+		file:    file,
+		policy:  policy,
+	}
+	r.wg.Add(1)
+	go r.run()
+	return r
+}
+
+// run enforces the policy every interval until the retention is closed.
+func (r *Retention) run() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.policy.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Enforce(); err != nil {
+			r.onError(err)
+		}
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Enforce deletes the log files exceeding the policy, oldest first, and
+// returns the number of files deleted.
+func (r *Retention) Enforce() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	files, err := logFiles(r.file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list log files: %w", err)
+	}
+	current := make(map[string]bool)
+	for _, file := range r.current() {
+		current[filepath.Clean(file)] = true
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	count := len(files)
+	cutoff := r.now().Add(-r.policy.MaxAge)
+
+	deleted := 0
+	var errs []error
+	for _, f := range files {
+		expired := r.policy.MaxAge > 0 && f.modTime.Before(cutoff)
+		tooMany := r.policy.MaxFiles > 0 && count > r.policy.MaxFiles
+		tooLarge := r.policy.MaxTotalSize > 0 && total > r.policy.MaxTotalSize
+		if !expired && !tooMany && !tooLarge {
+			// Files are sorted oldest first, so the rest are kept too.
+			break
+		}
+		if current[filepath.Clean(f.path)] {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete log file: %w", err))
+			continue
+		}
+		if hasDateDirectives(r.file) {
+			removeEmptyDirs(filepath.Dir(f.path), dateTemplateRoot(r.file))
+		}
+		deleted++
+		count--
+		total -= f.size
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// Close stops the schedule.
+func (r *Retention) Close() error {
+	r.closeOnce.Do(func() { close(r.stop) })
+	r.wg.Wait()
+	return nil
+}
+
+// RetentionHandler wraps a file handler with a Retention of its files.
+type RetentionHandler struct {
+	Retention *Retention
+	wrappedHandler
+}
+
+// NewRetentionHandler wraps the file handler writing the file template with a
+// retention of the policy.
+func NewRetentionHandler(
+	handler slog.Handler,
+	file string,
+	policy RetentionPolicy,
+	onError func(err error),
+) *RetentionHandler {
+	var current func() []string
+	if cf, ok := handler.(currentFiler); ok {
+		current = cf.currentFiles
+	}
+	return &RetentionHandler{
+		Retention:      NewRetention(file, policy, current, onError),
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// Handle writes the record to the wrapped handler.
+func (rh *RetentionHandler) Handle(ctx context.Context, record slog.Record) error {
+	return rh.Handler.Handle(ctx, record)
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (rh *RetentionHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *rh
+	clone.Handler = rh.Handler.WithAttrs(attrs)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (rh *RetentionHandler) WithGroup(name string) slog.Handler {
+	clone := *rh
+	clone.Handler = rh.Handler.WithGroup(name)
+	return &clone
+}
+
+// Close stops the retention and closes the wrapped handler.
+func (rh *RetentionHandler) Close() error {
+	return errors.Join(rh.Retention.Close(), rh.wrappedHandler.Close())
+}
+
+// newRetentionFromConfig wraps the file handler in a retention configured by
+// the handler config.
+func newRetentionFromConfig(
+	handler slog.Handler,
+	handlerConfig *HandlerConfig,
+	options CustomHandlerOptions,
+) (slog.Handler, error) {
+	totalSize, err := ParseSize(handlerConfig.RetentionTotalSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retention_total_size: %w", err)
+	}
+	return NewRetentionHandler(handler, handlerConfig.File, RetentionPolicy{
+		MaxTotalSize: int64(totalSize),
+		MaxFiles:     handlerConfig.RetentionMaxFiles,
+		MaxAge:       handlerConfig.RetentionMaxAge,
+		Interval:     handlerConfig.RetentionInterval,
+	}, options.OnError), nil
+}
+
+// hasRetention reports whether the handler config sets a retention limit.
+func hasRetention(handlerConfig *HandlerConfig) bool {
+	return handlerConfig.RetentionTotalSize != "" || handlerConfig.RetentionMaxFiles > 0 ||
+		handlerConfig.RetentionMaxAge > 0
+}
+
+// rotatorFilename returns the name of the file the rotator is writing.
+func rotatorFilename(w rotationWriter) string {
+	switch w := w.(type) {
+	case *timeRotator:
+		return w.Filename()
+	case *lumberjack.Logger:
+		return w.Filename
+	case *csvHeaderWriter:
+		return rotatorFilename(w.rotationWriter)
+	case *syncWriter:
+		return rotatorFilename(w.rotationWriter)
+	default:
+		return ""
+	}
+}
+
+// currentFiles returns the file being written.
+func (fh *FileHandler) currentFiles() []string {
+	return []string{rotatorFilename(fh.rotator)}
+}
+
+// currentFiles returns the file being written.
+func (jh *JSONHandler) currentFiles() []string {
+	return []string{rotatorFilename(jh.rotator)}
+}
+
+// currentFiles returns the files of the open shards.
+func (sh *ShardedFileHandler) currentFiles() []string {
+	var files []string
+	_ = sh.set.each(func(h slog.Handler) error {
+		if cf, ok := h.(currentFiler); ok {
+			files = append(files, cf.currentFiles()...)
+		}
+		return nil
+	})
+	return files
+}
@@ -0,0 +1,143 @@
+package multilog
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeLogFiles creates the files with the size, each a minute older than the
+// next one.
+func writeLogFiles(t *testing.T, size int, paths ...string) {
+	t.Helper()
+	start := time.Now().Add(-time.Hour)
+	for i, path := range paths {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, make([]byte, size), 0o644))
+		modTime := start.Add(time.Duration(i) * time.Minute)
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+}
+
+// newTestRetention creates a retention without starting its schedule.
+func newTestRetention(file string, policy RetentionPolicy, current ...string) *Retention {
+	return &Retention{
+		now:     time.Now,
+		current: func() []string { return current },
+		file:    file,
+		policy:  policy,
+	}
+}
+
+func TestRetention_MaxFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+	backups := []string{
+		filepath.Join(dir, "app-2024-01-01T00-00-00.000.log.gz"),
+		filepath.Join(dir, "app-2024-01-02T00-00-00.000.log.gz"),
+		filepath.Join(dir, "app-2024-01-03T00-00-00.000.log"),
+	}
+	// The current file is the oldest, and is kept anyway.
+	writeLogFiles(t, 10, append([]string{file}, backups...)...)
+
+	deleted, err := newTestRetention(file, RetentionPolicy{MaxFiles: 2}, file).Enforce()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	assert.FileExists(t, file)
+	assert.NoFileExists(t, backups[0])
+	assert.NoFileExists(t, backups[1])
+	assert.FileExists(t, backups[2])
+}
+
+func TestRetention_MaxTotalSizeAndAge(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.json")
+	paths := []string{
+		filepath.Join(dir, "app-2024-01-01T00-00-00.000.json"),
+		filepath.Join(dir, "app-2024-01-02T00-00-00.000.json"),
+		filepath.Join(dir, "app-2024-01-03T00-00-00.000.json"),
+		file,
+	}
+	writeLogFiles(t, 100, paths...)
+
+	deleted, err := newTestRetention(file, RetentionPolicy{MaxTotalSize: 250}, file).Enforce()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	assert.FileExists(t, paths[2])
+
+	// The remaining backup is an hour old.
+	deleted, err = newTestRetention(file, RetentionPolicy{MaxAge: 30 * time.Minute}, file).Enforce()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.NoFileExists(t, paths[2])
+	assert.FileExists(t, file)
+}
+
+func TestRetention_DateDirectories(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "%Y", "%m", "%d", "app.log")
+	paths := []string{
+		filepath.Join(dir, "2024", "01", "30", "app.log"),
+		filepath.Join(dir, "2024", "01", "31", "app.log"),
+		filepath.Join(dir, "2024", "02", "01", "app.log"),
+	}
+	writeLogFiles(t, 10, paths...)
+
+	deleted, err := newTestRetention(file, RetentionPolicy{MaxFiles: 1}, paths[2]).Enforce()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	assert.NoDirExists(t, filepath.Join(dir, "2024", "01"))
+	assert.FileExists(t, paths[2])
+}
+
+func TestRetentionHandler_Sharded(t *testing.T) {
+	dir := t.TempDir()
+	old := []string{
+		filepath.Join(dir, "tenant-acme-2024-01-01T00-00-00.000.log"),
+		filepath.Join(dir, "tenant-globex-2024-01-01T00-00-00.000.log"),
+	}
+	writeLogFiles(t, 10, old...)
+
+	cfg, err := NewBuilder().File(filepath.Join(dir, "tenant-[shard].log"),
+		ShardBy("tenant_id", 0), RetentionLimits("", 2, 0)).Config()
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	rh, ok := handlers[0].(*RetentionHandler)
+	assert.True(t, ok)
+
+	logger := slog.New(rh)
+	logger.Info("first", "tenant_id", "acme")
+	logger.Info("second", "tenant_id", "globex")
+	// The first scheduled run may already have deleted some of the files.
+	_, err = rh.Retention.Enforce()
+	assert.NoError(t, err)
+	assert.NoError(t, rh.Close())
+
+	assert.NoFileExists(t, old[0])
+	assert.NoFileExists(t, old[1])
+	assert.FileExists(t, filepath.Join(dir, "tenant-acme.log"))
+	assert.FileExists(t, filepath.Join(dir, "tenant-globex.log"))
+}
+
+func TestValidateHandler_Retention(t *testing.T) {
+	handler := &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log",
+		RetentionTotalSize: "10GB", RetentionMaxFiles: 100, RetentionMaxAge: 720 * time.Hour}
+	assert.NoError(t, validateHandler(handler))
+
+	handler.RetentionTotalSize = "huge"
+	assert.EqualError(t, validateHandler(handler), "invalid retention_total_size: invalid size: huge")
+
+	handler = &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log", RetentionInterval: time.Minute}
+	assert.EqualError(t, validateHandler(handler), "retention_interval requires a retention limit")
+
+	handler = &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log", RetentionMaxFiles: -1}
+	assert.EqualError(t, validateHandler(handler), "retention limits must not be negative")
+
+	handler = &HandlerConfig{Type: ConsoleHandlerType, Level: InfoLevel, RetentionMaxFiles: 5}
+	assert.EqualError(t, validateHandler(handler), "retention requires a file handler")
+}
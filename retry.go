@@ -0,0 +1,94 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// Default retry settings
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff   = 30 * time.Second
+)
+
+// HTTPStatusError is returned by HTTP handlers when the server responds with a
+// non-2xx status.
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// RetryPolicy controls how network handlers retry failed sends.
+type RetryPolicy struct {
+	// Retryable reports whether a failed attempt should be retried.
+	// DefaultRetryable is used if nil.
+	Retryable func(err error) bool
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// Backoff is the wait before the first retry. It doubles after each
+	// retry, up to MaxBackoff (DefaultMaxBackoff if zero).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter adds up to 50% random jitter to each backoff so that many
+	// clients do not retry in lockstep.
+	Jitter bool
+}
+
+// DefaultRetryable retries all errors except cancelled contexts and HTTP
+// statuses that will not change on retry: 4xx other than 408 and 429.
+func DefaultRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode < http.StatusInternalServerError {
+		return statusErr.StatusCode == http.StatusRequestTimeout || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// Do calls fn until it succeeds, returns an error that is not retryable, or
+// MaxRetries retries have been made.
+func (p RetryPolicy) Do(fn func() error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	maxBackoff := defaultDuration(p.MaxBackoff, DefaultMaxBackoff)
+	backoff := min(p.Backoff, maxBackoff)
+
+	err := fn()
+	for attempt := 0; err != nil && attempt < p.MaxRetries && retryable(err); attempt++ {
+		time.Sleep(withJitter(backoff, p.Jitter))
+		backoff = min(backoff*2, maxBackoff)
+		err = fn()
+	}
+	return err
+}
+
+// retryPolicy returns the retry policy configured in the options.
+func (o *BatchOptions) retryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Retryable:  o.Retryable,
+		MaxRetries: o.MaxRetries,
+		Backoff:    defaultDuration(o.RetryBackoff, DefaultRetryBackoff),
+		MaxBackoff: o.RetryMaxBackoff,
+		Jitter:     o.RetryJitter,
+	}
+}
+
+// withJitter returns the backoff with up to 50% random jitter added.
+func withJitter(backoff time.Duration, jitter bool) time.Duration {
+	if !jitter || backoff <= 1 {
+		return backoff
+	}
+	return backoff + rand.N(backoff/2)
+}
@@ -0,0 +1,118 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		retries   int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"succeeds first time", 0, 3, 1, false},
+		{"succeeds after retries", 2, 3, 3, false},
+		{"exhausts retries", 5, 2, 3, true},
+		{"no retries", 1, 0, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			policy := RetryPolicy{MaxRetries: tt.retries, Backoff: time.Millisecond}
+			err := policy.Do(func() error {
+				calls++
+				if calls <= tt.failures {
+					return errors.New("failed")
+				}
+				return nil
+			})
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	assert.Equal(t, 100*time.Millisecond, withJitter(100*time.Millisecond, false))
+	assert.Equal(t, time.Duration(0), withJitter(0, true))
+
+	for range 100 {
+		d := withJitter(100*time.Millisecond, true)
+		assert.GreaterOrEqual(t, d, 100*time.Millisecond)
+		assert.Less(t, d, 150*time.Millisecond)
+	}
+}
+
+func TestRetryWithJitter(t *testing.T) {
+	calls := 0
+	policy := RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond, Jitter: true}
+	err := policy.Do(func() error {
+		calls++
+		return errors.New("failed")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryPolicy_Retryable(t *testing.T) {
+	calls := 0
+	policy := RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond}
+	err := policy.Do(func() error {
+		calls++
+		return &HTTPStatusError{StatusCode: http.StatusBadRequest}
+	})
+	assert.EqualError(t, err, "unexpected status code: 400")
+	assert.Equal(t, 1, calls, "client errors are not retried")
+
+	calls = 0
+	policy.Retryable = func(error) bool { return true }
+	_ = policy.Do(func() error {
+		calls++
+		return &HTTPStatusError{StatusCode: http.StatusBadRequest}
+	})
+	assert.Equal(t, 4, calls)
+}
+
+func TestRetryPolicy_MaxBackoff(t *testing.T) {
+	calls := 0
+	start := time.Now()
+	policy := RetryPolicy{MaxRetries: 3, Backoff: time.Hour, MaxBackoff: time.Millisecond}
+	_ = policy.Do(func() error {
+		calls++
+		return errors.New("failed")
+	})
+	assert.Equal(t, 4, calls)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestDefaultRetryable(t *testing.T) {
+	assert.True(t, DefaultRetryable(errors.New("connection refused")))
+	assert.True(t, DefaultRetryable(&HTTPStatusError{StatusCode: http.StatusServiceUnavailable}))
+	assert.True(t, DefaultRetryable(fmt.Errorf("push: %w", &HTTPStatusError{StatusCode: http.StatusTooManyRequests})))
+	assert.True(t, DefaultRetryable(&HTTPStatusError{StatusCode: http.StatusRequestTimeout}))
+	assert.False(t, DefaultRetryable(&HTTPStatusError{StatusCode: http.StatusUnauthorized}))
+	assert.False(t, DefaultRetryable(context.Canceled))
+}
+
+func TestRetryPolicy_Config(t *testing.T) {
+	_, err := NewConfigFromData([]byte(`
+multilog:
+  handlers:
+    - type: loki
+      level: info
+      enabled: true
+      url: http://localhost:3100/loki/api/v1/push
+      retry_max_backoff: -1s
+`))
+	assert.ErrorContains(t, err, "retry settings must not be negative")
+}
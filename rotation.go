@@ -0,0 +1,286 @@
+package multilog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Rotation intervals
+const (
+	DailyRotation  = "daily"
+	HourlyRotation = "hourly"
+	day            = 24 * time.Hour
+)
+
+// rotationWriter is a log file writer that can be rotated on demand.
+type rotationWriter interface {
+	io.WriteCloser
+	Rotate() error
+}
+
+// ParseRotateInterval parses a rotation interval: "daily", "hourly", or a duration such as "30m".
+// An empty string disables time-based rotation.
+func ParseRotateInterval(interval string) (time.Duration, error) {
+	switch strings.ToLower(strings.TrimSpace(interval)) {
+	case "":
+		return 0, nil
+	case DailyRotation:
+		return day, nil
+	case HourlyRotation:
+		return time.Hour, nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rotate interval: %s", interval)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("rotate interval must be positive: %s", interval)
+	}
+	return d, nil
+}
+
+// newRotator creates the rotation writer for the given options: time-based when
+// RotateInterval is set or the file name has date directives, size-based otherwise.
+func newRotator(opts FileHandlerOptions) rotationWriter {
+	if opts.RotateInterval > 0 || hasDateDirectives(opts.File) {
+		return newTimeRotator(opts, time.Now)
+	}
+	logger := newRotationLogger(opts)
+	preparePermissions(logger.Filename, &opts.FilePermissions, opts.OnError)
+	return logger
+}
+
+// preparePermissions applies the permissions to the log file, reporting
+// failures to onError: they are not fatal, and the file is still written.
+func preparePermissions(path string, perms *FilePermissions, onError func(err error)) {
+	if err := prepareLogFile(path, perms); err != nil {
+		if onError == nil {
+			onError = defaultErrorHandler
+		}
+		onError(err)
+	}
+}
+
+// timeRotator rotates the log file at interval boundaries, in the time zone of
+// Location or the local one. The date directives of the file name (e.g.
+// "app-%Y-%m-%d.log") are replaced with the current time; if the name does not
+// change between intervals, the file is rotated like a size-based rotation
+// instead. Size limits still apply within an interval. With Compress set, files
+// left behind by a name change are gzipped in the background.
+//
+// Directives may appear anywhere in the path (e.g. "logs/%Y/%m/%d/app.log"), so
+// files are organized in date directories. Without a RotateInterval, the file
+// is switched when the name changes, checked at the period of the finest
+// directive, and files of the template older than MaxAge days are deleted in
+// every directory.
+type timeRotator struct {
+	logger       *lumberjack.Logger
+	opts         *FileHandlerOptions
+	now          func() time.Time
+	onError      func(err error)
+	stop         chan struct{}
+	next         time.Time
+	background   sync.WaitGroup
+	mu           sync.Mutex
+	closeOnce    sync.Once
+	onNameChange bool
+}
+
+// newTimeRotator creates a time rotator for the options and starts its rotation scheduler.
+func newTimeRotator(opts FileHandlerOptions, now func() time.Time) *timeRotator {
+	onError := opts.OnError
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	onNameChange := opts.RotateInterval == 0
+	if onNameChange {
+		opts.RotateInterval = dateTemplateInterval(opts.File)
+	}
+	if loc := opts.Location; loc != nil {
+		clock := now
+		now = func() time.Time { return clock().In(loc) }
+	}
+	t := now()
+	tr := &timeRotator{
+		logger:       newTimeRotationLogger(opts, t),
+		opts:         &opts,
+		now:          now,
+		onError:      onError,
+		stop:         make(chan struct{}),
+		next:         nextRotation(t, opts.RotateInterval),
+		onNameChange: onNameChange,
+	}
+	tr.cleanupInBackground(t)
+	go tr.schedule()
+	return tr
+}
+
+// Write writes to the current file, rotating first if an interval boundary has passed.
+func (tr *timeRotator) Write(p []byte) (int, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if t := tr.now(); !t.Before(tr.next) {
+		if err := tr.advance(t); err != nil {
+			return 0, err
+		}
+	}
+	return tr.logger.Write(p)
+}
+
+// rotateIfDue rotates the log file if an interval boundary has passed.
+func (tr *timeRotator) rotateIfDue() error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if t := tr.now(); !t.Before(tr.next) {
+		return tr.advance(t)
+	}
+	return nil
+}
+
+// Rotate rotates the log file immediately.
+func (tr *timeRotator) Rotate() error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.rotate(tr.now())
+}
+
+// Close stops the rotation scheduler, closes the current file, and waits for
+// pending compressions.
+func (tr *timeRotator) Close() error {
+	tr.closeOnce.Do(func() { close(tr.stop) })
+	tr.mu.Lock()
+	err := tr.logger.Close()
+	tr.mu.Unlock()
+	tr.background.Wait()
+	return err
+}
+
+// Filename returns the name of the current log file.
+func (tr *timeRotator) Filename() string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.logger.Filename
+}
+
+// advance moves to the interval of time t, rotating the file unless only name
+// changes switch files and the name is unchanged; the caller must hold tr.mu.
+func (tr *timeRotator) advance(t time.Time) error {
+	if tr.onNameChange && formatFilename(tr.opts.File, t) == tr.logger.Filename {
+		tr.next = nextRotation(t, tr.opts.RotateInterval)
+		return nil
+	}
+	return tr.rotate(t)
+}
+
+// rotate switches to the file for time t; the caller must hold tr.mu.
+func (tr *timeRotator) rotate(t time.Time) error {
+	tr.next = nextRotation(t, tr.opts.RotateInterval)
+	name := formatFilename(tr.opts.File, t)
+	if name == tr.logger.Filename {
+		return tr.logger.Rotate()
+	}
+	if err := tr.logger.Close(); err != nil {
+		return err
+	}
+	if tr.opts.Compress {
+		tr.compressInBackground(tr.logger.Filename)
+	}
+	// lumberjack reads Filename from its own goroutines, so start a new logger
+	// rather than renaming the current one.
+	tr.logger = newTimeRotationLogger(*tr.opts, t)
+	tr.cleanupInBackground(t)
+	return nil
+}
+
+// newTimeRotationLogger creates the lumberjack logger for the file of time t.
+func newTimeRotationLogger(opts FileHandlerOptions, t time.Time) *lumberjack.Logger {
+	logger := newRotationLogger(opts)
+	logger.Filename = formatFilename(opts.File, t)
+	preparePermissions(logger.Filename, &opts.FilePermissions, opts.OnError)
+	return logger
+}
+
+// compressInBackground gzips the file without blocking writers.
+func (tr *timeRotator) compressInBackground(file string) {
+	tr.background.Add(1)
+	go func() {
+		defer tr.background.Done()
+		if err := CompressFile(file, tr.opts.CompressionLevel); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			tr.onError(err)
+		}
+	}()
+}
+
+// cleanupInBackground deletes the files of a date template older than MaxAge
+// days without blocking writers. lumberjack only cleans up the directory of the
+// current file, which misses the other date directories.
+func (tr *timeRotator) cleanupInBackground(t time.Time) {
+	if tr.opts.MaxAge <= 0 || !hasDateDirectives(tr.opts.File) {
+		return
+	}
+	tr.background.Add(1)
+	go func() {
+		defer tr.background.Done()
+		if err := cleanupDatedFiles(tr.opts.File, time.Duration(tr.opts.MaxAge)*day, t); err != nil {
+			tr.onError(err)
+		}
+	}()
+}
+
+// schedule rotates the file at each interval boundary until the rotator is closed.
+func (tr *timeRotator) schedule() {
+	for {
+		tr.mu.Lock()
+		wait := tr.next.Sub(tr.now())
+		tr.mu.Unlock()
+
+		timer := time.NewTimer(max(wait, 0))
+		select {
+		case <-tr.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			tr.mu.Lock()
+			if t := tr.now(); !t.Before(tr.next) {
+				if err := tr.advance(t); err != nil {
+					tr.onError(fmt.Errorf("failed to rotate log file: %w", err))
+				}
+			}
+			tr.mu.Unlock()
+		}
+	}
+}
+
+// nextRotation returns the first interval boundary after t, aligned to midnight
+// in the location of t: whole-day intervals start at midnight, and shorter
+// intervals at multiples of the interval since midnight, starting over each day.
+func nextRotation(t time.Time, interval time.Duration) time.Time {
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	if interval%day == 0 {
+		return midnight.AddDate(0, 0, int(interval/day))
+	}
+	next := midnight.Add(t.Sub(midnight).Truncate(interval) + interval)
+	if tomorrow := midnight.AddDate(0, 0, 1); interval < day && next.After(tomorrow) {
+		return tomorrow
+	}
+	return next
+}
+
+// formatFilename replaces the date directives of the file template with the
+// given time. Names without directives are used as they are.
+func formatFilename(template string, t time.Time) string {
+	if hasDateDirectives(template) {
+		return formatDateTemplate(template, t)
+	}
+	return template
+}
@@ -0,0 +1,246 @@
+package multilog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a manually advanced clock for rotation tests.
+type fakeClock struct {
+	t  time.Time
+	mu sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func TestParseRotateInterval(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"", 0, false},
+		{"daily", 24 * time.Hour, false},
+		{"Hourly", time.Hour, false},
+		{"30m", 30 * time.Minute, false},
+		{"weekly", 0, true},
+		{"-1h", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			d, err := ParseRotateInterval(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+}
+
+func TestNextRotation(t *testing.T) {
+	now := time.Date(2024, 3, 10, 13, 45, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), nextRotation(now, 24*time.Hour))
+	assert.Equal(t, time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC), nextRotation(now, time.Hour))
+	assert.Equal(t, time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC), nextRotation(now, 30*time.Minute))
+	assert.Equal(t, time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC), nextRotation(now, 7*time.Hour))
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), nextRotation(now.Add(8*time.Hour), 7*time.Hour),
+		"intervals start over at midnight")
+
+	// Boundaries are aligned in the location of the time, not in UTC.
+	india := time.FixedZone("IST", 5*3600+1800)
+	now = time.Date(2024, 3, 10, 13, 45, 0, 0, india)
+	assert.Equal(t, time.Date(2024, 3, 10, 14, 0, 0, 0, india), nextRotation(now, time.Hour))
+	assert.Equal(t, time.Date(2024, 3, 10, 16, 0, 0, 0, india), nextRotation(now, 4*time.Hour))
+}
+
+func TestTimeRotator_Template(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2024, 3, 10, 23, 59, 0, 0, time.Local)}
+	tr := newTimeRotator(FileHandlerOptions{File: filepath.Join(dir, "app-%Y-%m-%d.log"), RotateInterval: 24 * time.Hour}, clock.Now)
+	defer tr.Close()
+
+	_, err := tr.Write([]byte("first\n"))
+	assert.NoError(t, err)
+
+	clock.Add(2 * time.Minute)
+	_, err = tr.Write([]byte("second\n"))
+	assert.NoError(t, err)
+
+	first, err := os.ReadFile(filepath.Join(dir, "app-2024-03-10.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "first\n", string(first))
+
+	second, err := os.ReadFile(filepath.Join(dir, "app-2024-03-11.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "second\n", string(second))
+	assert.Equal(t, filepath.Join(dir, "app-2024-03-11.log"), tr.Filename())
+}
+
+func TestTimeRotator_FixedName(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+	clock := &fakeClock{t: time.Date(2024, 3, 10, 10, 30, 0, 0, time.UTC)}
+	tr := newTimeRotator(FileHandlerOptions{File: file, RotateInterval: time.Hour}, clock.Now)
+	defer tr.Close()
+
+	_, err := tr.Write([]byte("first\n"))
+	assert.NoError(t, err)
+
+	clock.Add(time.Hour)
+	_, err = tr.Write([]byte("second\n"))
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "second\n", string(content))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestTimeRotator_LayoutTokensInName(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "Monitor.log")
+	clock := &fakeClock{t: time.Date(2024, 3, 10, 10, 30, 0, 0, time.UTC)}
+	tr := newTimeRotator(FileHandlerOptions{File: file, RotateInterval: time.Hour}, clock.Now)
+	defer tr.Close()
+
+	_, err := tr.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, file, tr.Filename(), "names without directives are not time layouts")
+	assert.FileExists(t, file)
+}
+
+func TestTimeRotator_Location(t *testing.T) {
+	dir := t.TempDir()
+	tokyo := time.FixedZone("JST", 9*3600)
+	clock := &fakeClock{t: time.Date(2024, 3, 10, 14, 59, 0, 0, time.UTC)}
+	tr := newTimeRotator(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Location: tokyo,
+		},
+		File:           filepath.Join(dir, "app-%Y-%m-%d.log"),
+		RotateInterval: 24 * time.Hour,
+	}, clock.Now)
+	defer tr.Close()
+
+	_, err := tr.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "app-2024-03-10.log"), tr.Filename())
+
+	// Midnight in Tokyo is 15:00 UTC.
+	clock.Add(time.Minute)
+	_, err = tr.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "app-2024-03-11.log"), tr.Filename())
+}
+
+func TestTimeRotator_Scheduler(t *testing.T) {
+	dir := t.TempDir()
+	tr := newTimeRotator(FileHandlerOptions{File: filepath.Join(dir, "app.log"), RotateInterval: 50 * time.Millisecond}, time.Now)
+	defer tr.Close()
+
+	_, err := tr.Write([]byte("first\n"))
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		entries, err := os.ReadDir(dir)
+		return err == nil && len(entries) >= 2
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestFileHandler_TimeRotation(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFileHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   "info",
+			Enabled: true,
+			Pattern: "[msg]",
+		},
+		File:           filepath.Join(dir, "app-%Y-%m-%d.log"),
+		RotateInterval: 24 * time.Hour,
+	})
+	assert.NoError(t, err)
+
+	fh := handler.(*FileHandler)
+	assert.NoError(t, fh.Rotate())
+	assert.NoError(t, fh.Close())
+}
+
+func TestFileHandler_TimeLayoutName(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app-2006-01-02.log")
+	_, err := NewFileHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   "info",
+			Enabled: true,
+		},
+		File:           file,
+		RotateInterval: 24 * time.Hour,
+	})
+	assert.EqualError(t, err, "time layouts are not supported in file names, use date directives such as %Y-%m-%d: "+file)
+	assert.NoFileExists(t, file)
+}
+
+func TestTimeRotator_Compress(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2024, 3, 10, 10, 30, 0, 0, time.UTC)}
+	tr := newTimeRotator(FileHandlerOptions{
+		File:             filepath.Join(dir, "app-%Y%m%d%H.log"),
+		RotateInterval:   time.Hour,
+		Compress:         true,
+		CompressionLevel: gzip.BestCompression,
+	}, clock.Now)
+
+	_, err := tr.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	clock.Add(time.Hour)
+	_, err = tr.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, tr.Close())
+
+	_, err = os.Stat(filepath.Join(dir, "app-2024031010.log"))
+	assert.True(t, os.IsNotExist(err))
+
+	f, err := os.Open(filepath.Join(dir, "app-2024031010.log.gz"))
+	assert.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	content, err := io.ReadAll(gz)
+	assert.NoError(t, err)
+	assert.Equal(t, "first\n", string(content))
+}
+
+func TestCompressFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(file, []byte("hello\n"), 0o600))
+
+	assert.NoError(t, CompressFile(file, 0))
+	_, err := os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(file + GzipExt)
+	assert.NoError(t, err)
+
+	assert.Error(t, CompressFile(file, 0))
+	assert.Error(t, CompressFile(file+GzipExt, 42))
+}
@@ -0,0 +1,88 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"path"
+)
+
+// RouteHandler is a Handler that only writes records whose attributes match its
+// rules, so one logger can feed per-subsystem handlers. Attributes are taken from
+// the record and from WithAttrs; keys inside groups use their dotted path.
+type RouteHandler struct {
+	match map[string]string
+	bound map[string]string
+	group string
+	wrappedHandler
+}
+
+// NewRouteHandler creates a handler that writes records where every key in match
+// has a matching value. Values are path.Match patterns, e.g. "payments" or "pay*".
+func NewRouteHandler(handler slog.Handler, match map[string]string) *RouteHandler {
+	return &RouteHandler{
+		match:          match,
+		bound:          map[string]string{},
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// RouteMiddleware wraps handlers with NewRouteHandler.
+func RouteMiddleware(match map[string]string) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewRouteHandler(next, match)
+	}
+}
+
+// Handle writes the record if it matches the rules.
+func (rh *RouteHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !rh.matches(record) {
+		return nil
+	}
+	return rh.Handler.Handle(ctx, record)
+}
+
+// WithAttrs creates a new handler with the given attributes, remembering those used by the rules.
+func (rh *RouteHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *rh
+	clone.Handler = rh.Handler.WithAttrs(attrs)
+	clone.bound = maps.Clone(rh.bound)
+	for _, a := range attrs {
+		key := rh.group + a.Key
+		if _, ok := rh.match[key]; ok {
+			clone.bound[key] = a.Value.Resolve().String()
+		}
+	}
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (rh *RouteHandler) WithGroup(name string) slog.Handler {
+	clone := *rh
+	clone.Handler = rh.Handler.WithGroup(name)
+	clone.group = rh.group + name + "."
+	return &clone
+}
+
+// matches reports whether the record, combined with the bound attributes, satisfies every rule.
+func (rh *RouteHandler) matches(record slog.Record) bool {
+	values := maps.Clone(rh.bound)
+	record.Attrs(func(a slog.Attr) bool {
+		key := rh.group + a.Key
+		if _, ok := rh.match[key]; ok {
+			values[key] = a.Value.Resolve().String()
+		}
+		return true
+	})
+
+	for key, pattern := range rh.match {
+		value, ok := values[key]
+		if !ok {
+			return false
+		}
+		if matched, err := path.Match(pattern, value); err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
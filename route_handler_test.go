@@ -0,0 +1,73 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteHandler(t *testing.T) {
+	ctx := context.Background()
+	recorder := &recordingHandler{}
+	h := NewRouteHandler(recorder, map[string]string{"component": "pay*"})
+
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "charged", 0)
+	r.AddAttrs(slog.String("component", "payments"))
+	assert.NoError(t, h.Handle(ctx, r))
+
+	r = slog.NewRecord(time.Now(), slog.LevelInfo, "shipped", 0)
+	r.AddAttrs(slog.String("component", "shipping"))
+	assert.NoError(t, h.Handle(ctx, r))
+
+	assert.NoError(t, h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "untagged", 0)))
+
+	child := h.WithAttrs([]slog.Attr{slog.String("component", "payments")})
+	assert.NoError(t, child.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "refunded", 0)))
+
+	assert.Len(t, recorder.records, 2)
+	assert.Equal(t, "charged", recorder.records[0].Message)
+	assert.Equal(t, "refunded", recorder.records[1].Message)
+}
+
+func TestRouteHandler_Groups(t *testing.T) {
+	recorder := &recordingHandler{}
+	h := NewRouteHandler(recorder, map[string]string{"http.status": "5*"}).WithGroup("http")
+
+	r := slog.NewRecord(time.Now(), slog.LevelError, "failed", 0)
+	r.AddAttrs(slog.Int("status", 503))
+	assert.NoError(t, h.Handle(context.Background(), r))
+
+	r = slog.NewRecord(time.Now(), slog.LevelError, "ok", 0)
+	r.AddAttrs(slog.Int("status", 200))
+	assert.NoError(t, h.Handle(context.Background(), r))
+
+	assert.Len(t, recorder.records, 1)
+}
+
+func TestRouteHandler_PerSubsystemFiles(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewBuilder().
+		File(filepath.Join(dir, "payments.log"), Pattern("[msg]"), Match("component", "payments")).
+		File(filepath.Join(dir, "app.log"), Pattern("[msg]")).
+		Build()
+	assert.NoError(t, err)
+
+	logger.Info("charged", "component", "payments")
+	logger.Info("started")
+	assert.NoError(t, logger.Close())
+
+	payments, err := os.ReadFile(filepath.Join(dir, "payments.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(payments), "\n"))
+	assert.Contains(t, string(payments), "charged")
+
+	app, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(app), "\n"))
+}
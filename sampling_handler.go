@@ -0,0 +1,104 @@
+package multilog
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Sampling settings
+const (
+	DefaultSampleTick = time.Second
+	sampleBuckets     = 4096
+)
+
+// sampleCounter counts the records seen for a bucket in the current tick.
+type sampleCounter struct {
+	resetAt time.Time
+	count   int
+}
+
+// sampler decides which records to keep. Like zap, it keeps the first Initial
+// records with the same level and message in each tick, then every Thereafter-th.
+// Records are counted in a fixed number of hashed buckets to bound memory.
+type sampler struct {
+	now        func() time.Time
+	counters   [sampleBuckets]sampleCounter
+	tick       time.Duration
+	initial    int
+	thereafter int
+	mu         sync.Mutex
+}
+
+// newSampler creates a sampler; a zero tick defaults to one second.
+func newSampler(initial, thereafter int, tick time.Duration) *sampler {
+	return &sampler{
+		now:        time.Now,
+		tick:       defaultDuration(tick, DefaultSampleTick),
+		initial:    initial,
+		thereafter: thereafter,
+	}
+}
+
+// allow reports whether a record with the level and message should be kept.
+func (s *sampler) allow(level slog.Level, msg string) bool {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(msg))
+	bucket := (h.Sum64() ^ uint64(int64(level))) % sampleBuckets
+
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := &s.counters[bucket]
+	if !now.Before(c.resetAt) {
+		c.resetAt = now.Add(s.tick)
+		c.count = 0
+	}
+	c.count++
+	if c.count <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (c.count-s.initial)%s.thereafter == 0
+}
+
+// SamplingHandler is a Handler that drops records exceeding the sampling rate
+// before they reach the wrapped handler.
+type SamplingHandler struct {
+	sampler *sampler
+	wrappedHandler
+}
+
+// NewSamplingHandler creates a sampling handler. In each tick, the first initial
+// records with the same level and message are kept, then every thereafter-th one;
+// a thereafter of zero drops the rest. A zero tick defaults to one second.
+func NewSamplingHandler(handler slog.Handler, initial, thereafter int, tick time.Duration) *SamplingHandler {
+	return &SamplingHandler{
+		sampler:        newSampler(initial, thereafter, tick),
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// Handle forwards the record to the wrapped handler if it is sampled.
+func (sh *SamplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !sh.sampler.allow(record.Level, record.Message) {
+		return nil
+	}
+	return sh.Handler.Handle(ctx, record)
+}
+
+// WithAttrs creates a new handler with the given attributes; the sampling state is shared.
+func (sh *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *sh
+	clone.Handler = sh.Handler.WithAttrs(attrs)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name; the sampling state is shared.
+func (sh *SamplingHandler) WithGroup(name string) slog.Handler {
+	clone := *sh
+	clone.Handler = sh.Handler.WithGroup(name)
+	return &clone
+}
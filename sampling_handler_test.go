@@ -0,0 +1,55 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampler_Allow(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := newSampler(2, 3, time.Second)
+	s.now = clock.Now
+
+	var kept []int
+	for i := 1; i <= 10; i++ {
+		if s.allow(slog.LevelDebug, "noisy") {
+			kept = append(kept, i)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 5, 8}, kept)
+
+	// Other messages and levels are counted separately.
+	assert.True(t, s.allow(slog.LevelDebug, "other"))
+	assert.True(t, s.allow(slog.LevelInfo, "noisy"))
+
+	// Counters reset after the tick.
+	clock.Add(time.Second)
+	assert.True(t, s.allow(slog.LevelDebug, "noisy"))
+}
+
+func TestSampler_DropThereafter(t *testing.T) {
+	s := newSampler(1, 0, time.Minute)
+	assert.True(t, s.allow(slog.LevelInfo, "msg"))
+	assert.False(t, s.allow(slog.LevelInfo, "msg"))
+	assert.False(t, s.allow(slog.LevelInfo, "msg"))
+}
+
+func TestSamplingHandler(t *testing.T) {
+	counter := &CountingHandler{}
+	sh := NewSamplingHandler(counter, 5, 0, time.Minute)
+
+	for range 20 {
+		record := slog.NewRecord(time.Now(), slog.LevelDebug, "msg", 0)
+		assert.NoError(t, sh.Handle(context.Background(), record))
+	}
+	assert.Equal(t, 5, counter.callCount)
+
+	child := sh.WithAttrs([]slog.Attr{slog.String("k", "v")}).(*SamplingHandler)
+	assert.Same(t, sh.sampler, child.sampler)
+	assert.NoError(t, child.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelDebug, "msg", 0)))
+	assert.Equal(t, 5, counter.callCount)
+}
@@ -0,0 +1,20 @@
+#!/bin/bash
+
+# Create a go.work file that builds the adapter modules against the multilog
+# module in this checkout instead of the released version they require.
+MODULES=("multilogecho" "multiloggin" "multiloggrpc" "multilogr" "multilogrus" "multilogzap")
+
+cd "$(dirname "$0")/.." || exit 1
+
+MULTILOG_VERSION=$(awk '$1 == "github.com/phani-kb/multilog" {print $2}' multilogr/go.mod)
+
+rm -f go.work go.work.sum
+go work init .
+go work edit -go="$(go mod edit -json | awk -F'"' '$2 == "Go" {print $4}')"
+for module in "${MODULES[@]}"; do
+  go work use "./$module"
+done
+# Workspace modules replace the required version, whose go.mod is still loaded
+# until it is released.
+go work edit -replace "github.com/phani-kb/multilog@$MULTILOG_VERSION=./"
+echo "Created go.work with: . ${MODULES[*]}"
@@ -0,0 +1,238 @@
+package multilog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Replacement modes of scrubbed text
+const (
+	MaskScrub = "mask"
+	HashScrub = "hash"
+)
+
+// ScrubModes contains the supported replacement modes.
+var ScrubModes = []string{MaskScrub, HashScrub}
+
+// ScrubDetectors maps the names of the built-in PII detectors to their patterns.
+// When a pattern has a capturing group, only the group is replaced, so that a
+// bearer token is scrubbed but the "Bearer" scheme is kept.
+var ScrubDetectors = map[string]string{
+	"email":        `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	"credit_card":  `\b(?:\d[ \-]?){12,18}\d\b`,
+	"ssn":          `\b\d{3}-\d{2}-\d{4}\b`,
+	"bearer_token": `(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`,
+}
+
+// ScrubConfig configures the scrubbing of PII in messages and attribute values.
+// Scrubbing is enabled when a detector or a pattern is set.
+type ScrubConfig struct {
+	// Patterns maps names to custom regular expressions to scrub.
+	Patterns map[string]string `yaml:"patterns,omitempty"`
+	// Replace is the replacement mode: mask (default) or hash.
+	Replace string `yaml:"replace,omitempty"`
+	// Detect lists the built-in detectors to use, see ScrubDetectors.
+	Detect []string `yaml:"detect,omitempty"`
+}
+
+// enabled reports whether the config sets a detector or a pattern.
+func (c *ScrubConfig) enabled() bool {
+	return len(c.Detect) > 0 || len(c.Patterns) > 0
+}
+
+// scrubRule is a pattern to scrub.
+type scrubRule struct {
+	pattern *regexp.Regexp
+	// valid filters out matches that are not PII, when set.
+	valid func(match string) bool
+}
+
+// Scrubber replaces the PII found in text by masks or hashes.
+type Scrubber struct {
+	rules []scrubRule
+	hash  bool
+}
+
+// NewScrubber creates a scrubber with the detectors and patterns of the config.
+func NewScrubber(config ScrubConfig) (*Scrubber, error) {
+	if config.Replace != "" && !slices.Contains(ScrubModes, config.Replace) {
+		return nil, fmt.Errorf("invalid scrub mode: %s", config.Replace)
+	}
+	s := &Scrubber{hash: config.Replace == HashScrub}
+	for _, name := range config.Detect {
+		pattern, ok := ScrubDetectors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown scrub detector: %s", name)
+		}
+		rule := scrubRule{pattern: regexp.MustCompile(pattern)}
+		if name == "credit_card" {
+			rule.valid = luhnValid
+		}
+		s.rules = append(s.rules, rule)
+	}
+	// Apply the custom patterns in a stable order.
+	names := make([]string, 0, len(config.Patterns))
+	for name := range config.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pattern, err := regexp.Compile(config.Patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid scrub pattern %s: %w", name, err)
+		}
+		s.rules = append(s.rules, scrubRule{pattern: pattern})
+	}
+	return s, nil
+}
+
+// Scrub returns the text with the PII replaced.
+func (s *Scrubber) Scrub(text string) string {
+	for _, rule := range s.rules {
+		text = s.scrubRule(rule, text)
+	}
+	return text
+}
+
+// scrubRule replaces the matches of the rule in the text.
+func (s *Scrubber) scrubRule(rule scrubRule, text string) string {
+	matches := rule.pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) > 2 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if rule.valid != nil && !rule.valid(text[start:end]) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(s.replacement(text[start:end]))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// replacement returns the mask or the hash of the match. Hashes keep equal
+// values correlated across records without logging them.
+func (s *Scrubber) replacement(match string) string {
+	if !s.hash {
+		return RedactedValue
+	}
+	sum := sha256.Sum256([]byte(match))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// luhnValid reports whether the digits of the number pass the Luhn checksum
+// of payment card numbers.
+func luhnValid(number string) bool {
+	sum, digits := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// ScrubHandler is a Handler that scrubs the PII in the message and the string
+// attribute values of records before they reach the wrapped handler. Errors and
+// fmt.Stringer values are scrubbed as the strings they format to.
+type ScrubHandler struct {
+	scrubber *Scrubber
+	wrappedHandler
+}
+
+// NewScrubHandler creates a handler scrubbing records with the scrubber.
+func NewScrubHandler(handler slog.Handler, scrubber *Scrubber) *ScrubHandler {
+	return &ScrubHandler{
+		scrubber:       scrubber,
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// ScrubMiddleware wraps handlers with NewScrubHandler.
+func ScrubMiddleware(scrubber *Scrubber) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewScrubHandler(next, scrubber)
+	}
+}
+
+// Handle scrubs the record and writes it.
+func (sh *ScrubHandler) Handle(ctx context.Context, record slog.Record) error {
+	scrubbed := slog.NewRecord(record.Time, record.Level, sh.scrubber.Scrub(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		scrubbed.AddAttrs(sh.scrub(a))
+		return true
+	})
+	return sh.Handler.Handle(ctx, scrubbed)
+}
+
+// WithAttrs creates a new handler with the attributes scrubbed.
+func (sh *ScrubHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scrubbed := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		scrubbed[i] = sh.scrub(a)
+	}
+	clone := *sh
+	clone.Handler = sh.Handler.WithAttrs(scrubbed)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (sh *ScrubHandler) WithGroup(name string) slog.Handler {
+	clone := *sh
+	clone.Handler = sh.Handler.WithGroup(name)
+	return &clone
+}
+
+// scrub scrubs the value of the attribute, looking into groups.
+func (sh *ScrubHandler) scrub(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(sh.scrubber.Scrub(a.Value.String()))
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		scrubbed := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			scrubbed[i] = sh.scrub(ga)
+		}
+		a.Value = slog.GroupValue(scrubbed...)
+	case slog.KindAny:
+		var text string
+		switch v := a.Value.Any().(type) {
+		case error:
+			text = v.Error()
+		case fmt.Stringer:
+			text = v.String()
+		default:
+			return a
+		}
+		if scrubbed := sh.scrubber.Scrub(text); scrubbed != text {
+			a.Value = slog.StringValue(scrubbed)
+		}
+	}
+	return a
+}
@@ -0,0 +1,321 @@
+package multilog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+// DefaultMaxOpenShards is the default number of shard files kept open.
+const DefaultMaxOpenShards = 64
+
+// ShardPlaceholder is replaced by the shard value in the file name of a sharded file handler.
+const ShardPlaceholder = "[shard]"
+
+// DefaultShard is the shard of records without the shard attribute.
+const DefaultShard = "default"
+
+// shardFile is an open shard and the time it was last written, as a tick of
+// the shard set's clock.
+type shardFile struct {
+	handler  slog.Handler
+	lastUsed atomic.Int64
+}
+
+// shardSet is the state shared by a sharded file handler and its derived handlers.
+type shardSet struct {
+	newShard func(opts FileHandlerOptions) (slog.Handler, error)
+	shards   map[string]*shardFile
+	level    *slog.LevelVar
+	onError  func(err error)
+	opts     FileHandlerOptions
+	clock    atomic.Int64
+	mu       sync.RWMutex
+	closed   bool
+}
+
+// shardCache holds the handlers a derived sharded handler made from the shards.
+type shardCache struct {
+	handlers map[*shardFile]slog.Handler
+	mu       sync.Mutex
+}
+
+// ShardedFileHandler is a file Handler that writes each record to the file of
+// its ShardBy attribute value, e.g. logs/tenant-acme.log for tenant_id=acme.
+// The value replaces ShardPlaceholder in the file name, or is appended to the
+// name before the extension. Every shard is a file handler of its own, rotated
+// and archived independently. At most MaxOpenShards files are kept open; the
+// least recently written one is closed to open another.
+type ShardedFileHandler struct {
+	set   *shardSet
+	cache *shardCache
+	ops   []func(slog.Handler) slog.Handler
+	bound string
+	group string
+}
+
+// NewShardedFileHandler creates a sharded file handler whose shards are created
+// by newShard with the options, File set to the shard's file. Shard files are
+// opened when their first record is written.
+func NewShardedFileHandler(
+	opts FileHandlerOptions,
+	newShard func(opts FileHandlerOptions) (slog.Handler, error),
+) *ShardedFileHandler {
+	onError := opts.OnError
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	opts.MaxOpenShards = defaultIfZero(opts.MaxOpenShards, DefaultMaxOpenShards)
+	return &ShardedFileHandler{
+		set: &shardSet{
+			newShard: newShard,
+			shards:   map[string]*shardFile{},
+			level:    NewLevelVar(opts.Level),
+			onError:  onError,
+			opts:     opts,
+		},
+	}
+}
+
+// ShardFileName returns the file name of the shard for the file template.
+func ShardFileName(template, shard string) string {
+	if strings.Contains(template, ShardPlaceholder) {
+		return strings.ReplaceAll(template, ShardPlaceholder, shard)
+	}
+	ext := filepath.Ext(template)
+	return strings.TrimSuffix(template, ext) + "-" + shard + ext
+}
+
+// shardName turns an attribute value into a shard name that is safe in a file
+// name: characters other than letters, digits, '-' and '_' become '_'.
+func shardName(value string) string {
+	if value == "" {
+		return DefaultShard
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, value)
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (sh *ShardedFileHandler) Enabled(_ context.Context, level slog.Level) bool {
+	opts := &sh.set.opts
+	return opts.Enabled && level >= sh.set.level.Level() &&
+		(opts.MaxLevel == "" || level <= GetSlogLevel(opts.MaxLevel))
+}
+
+// Handle writes the record to the file of its shard, opening it if needed.
+func (sh *ShardedFileHandler) Handle(ctx context.Context, record slog.Record) error {
+	return sh.set.with(sh.shardOf(record), func(shard *shardFile) error {
+		return sh.derived(shard).Handle(ctx, record)
+	})
+}
+
+// shardOf returns the shard of the record: its ShardBy attribute, or the one
+// bound with WithAttrs.
+func (sh *ShardedFileHandler) shardOf(record slog.Record) string {
+	value := sh.bound
+	record.Attrs(func(a slog.Attr) bool {
+		if sh.group+a.Key == sh.set.opts.ShardBy {
+			value = a.Value.Resolve().String()
+			return false
+		}
+		return true
+	})
+	return shardName(value)
+}
+
+// derived returns the shard's handler with the attributes and groups of this
+// handler applied.
+func (sh *ShardedFileHandler) derived(shard *shardFile) slog.Handler {
+	if len(sh.ops) == 0 {
+		return shard.handler
+	}
+	sh.cache.mu.Lock()
+	defer sh.cache.mu.Unlock()
+	if h, ok := sh.cache.handlers[shard]; ok {
+		return h
+	}
+	// Closed shards are never looked up again, so drop them all at once.
+	if len(sh.cache.handlers) >= sh.set.opts.MaxOpenShards {
+		clear(sh.cache.handlers)
+	}
+	h := shard.handler
+	for _, op := range sh.ops {
+		h = op(h)
+	}
+	sh.cache.handlers[shard] = h
+	return h
+}
+
+// with calls fn with the open shard of the name, opening it and closing the
+// least recently used shard if needed.
+func (s *shardSet) with(name string, fn func(shard *shardFile) error) error {
+	s.mu.RLock()
+	if shard, ok := s.shards[name]; ok {
+		defer s.mu.RUnlock()
+		shard.lastUsed.Store(s.clock.Add(1))
+		return fn(shard)
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	shard, ok := s.shards[name]
+	if !ok {
+		if s.closed {
+			return fmt.Errorf("sharded file handler is closed")
+		}
+		var err error
+		if shard, err = s.open(name); err != nil {
+			return err
+		}
+	}
+	shard.lastUsed.Store(s.clock.Add(1))
+	return fn(shard)
+}
+
+// open opens the shard of the name; the caller must hold the write lock.
+func (s *shardSet) open(name string) (*shardFile, error) {
+	if len(s.shards) >= s.opts.MaxOpenShards {
+		s.evict()
+	}
+	opts := s.opts
+	opts.File = ShardFileName(s.opts.File, name)
+	opts.Level = GetLevelName(s.level.Level())
+	handler, err := s.newShard(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shard %s: %w", name, err)
+	}
+	shard := &shardFile{handler: handler}
+	s.shards[name] = shard
+	return shard, nil
+}
+
+// evict closes the least recently used shard; the caller must hold the write lock.
+func (s *shardSet) evict() {
+	var oldest string
+	var oldestUsed int64
+	for name, shard := range s.shards {
+		if used := shard.lastUsed.Load(); oldest == "" || used < oldestUsed {
+			oldest, oldestUsed = name, used
+		}
+	}
+	if err := closeSlogHandler(s.shards[oldest].handler); err != nil {
+		s.onError(fmt.Errorf("failed to close shard %s: %w", oldest, err))
+	}
+	delete(s.shards, oldest)
+}
+
+// each calls fn for every open shard, joining the errors.
+func (s *shardSet) each(fn func(h slog.Handler) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var err error
+	for _, shard := range s.shards {
+		err = errors.Join(err, fn(shard.handler))
+	}
+	return err
+}
+
+// Shards returns the number of open shard files.
+func (sh *ShardedFileHandler) Shards() int {
+	sh.set.mu.RLock()
+	defer sh.set.mu.RUnlock()
+	return len(sh.set.shards)
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (sh *ShardedFileHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return sh
+	}
+	clone := sh.derive(func(h slog.Handler) slog.Handler { return h.WithAttrs(attrs) })
+	for _, a := range attrs {
+		if sh.group+a.Key == sh.set.opts.ShardBy {
+			clone.bound = a.Value.Resolve().String()
+		}
+	}
+	return clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (sh *ShardedFileHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return sh
+	}
+	clone := sh.derive(func(h slog.Handler) slog.Handler { return h.WithGroup(name) })
+	clone.group = sh.group + name + "."
+	return clone
+}
+
+// derive returns a copy of the handler applying op to the shard handlers.
+func (sh *ShardedFileHandler) derive(op func(slog.Handler) slog.Handler) *ShardedFileHandler {
+	clone := *sh
+	clone.ops = append(sh.ops[:len(sh.ops):len(sh.ops)], op)
+	clone.cache = &shardCache{handlers: map[*shardFile]slog.Handler{}}
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler and its shards at runtime.
+func (sh *ShardedFileHandler) SetLevel(level string) error {
+	if !Contains(LogLevels, level) {
+		return fmt.Errorf("invalid log level: %s", level)
+	}
+	sh.set.level.Set(GetSlogLevel(level))
+	return sh.set.each(func(h slog.Handler) error {
+		if ls, ok := h.(LevelSetter); ok {
+			return ls.SetLevel(level)
+		}
+		return nil
+	})
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (sh *ShardedFileHandler) GetLevel() string {
+	return GetLevelName(sh.set.level.Level())
+}
+
+// logFile implements logFileProvider, returning the file name template.
+func (sh *ShardedFileHandler) logFile() string {
+	return sh.set.opts.File
+}
+
+// Rotate rotates the files of the open shards.
+func (sh *ShardedFileHandler) Rotate() error {
+	return sh.set.each(func(h slog.Handler) error {
+		rotator, ok := h.(Rotator)
+		if !ok {
+			return fmt.Errorf("handler does not support rotation")
+		}
+		return rotator.Rotate()
+	})
+}
+
+// Flush flushes the open shards.
+func (sh *ShardedFileHandler) Flush() error {
+	return sh.set.each(flushHandler)
+}
+
+// Close closes the open shards. Records written afterwards are rejected.
+func (sh *ShardedFileHandler) Close() error {
+	s := sh.set
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	for name, shard := range s.shards {
+		err = errors.Join(err, closeSlogHandler(shard.handler))
+		delete(s.shards, name)
+	}
+	s.closed = true
+	return err
+}
@@ -0,0 +1,165 @@
+package multilog
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestShards creates a text sharded file handler on dir/tenant-[shard].log.
+func newTestShards(t *testing.T, dir string, maxOpen int) *ShardedFileHandler {
+	t.Helper()
+	return NewShardedFileHandler(FileHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+			OnError: func(err error) { t.Error(err) },
+		},
+		File:          filepath.Join(dir, "tenant-"+ShardPlaceholder+".log"),
+		ShardBy:       "tenant_id",
+		MaxOpenShards: maxOpen,
+	}, NewFileHandler)
+}
+
+// readShard returns the content of the shard's file.
+func readShard(t *testing.T, dir, shard string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "tenant-"+shard+".log"))
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestShardFileName(t *testing.T) {
+	assert.Equal(t, "logs/tenant-acme.log", ShardFileName("logs/tenant-[shard].log", "acme"))
+	assert.Equal(t, "logs/app-acme.log", ShardFileName("logs/app.log", "acme"))
+	assert.Equal(t, "logs/app-acme", ShardFileName("logs/app", "acme"))
+	assert.Equal(t, "a_b_c", shardName("a/b.c"))
+	assert.Equal(t, "ünïcode-1", shardName("ünïcode-1"))
+	assert.Equal(t, DefaultShard, shardName(""))
+}
+
+func TestShardedFileHandler_Handle(t *testing.T) {
+	dir := t.TempDir()
+	sh := newTestShards(t, dir, 0)
+	logger := slog.New(sh)
+
+	logger.Info("first", "tenant_id", "acme")
+	logger.Info("second", "tenant_id", "globex")
+	logger.With("tenant_id", "acme").Info("bound")
+	logger.WithGroup("req").Info("grouped", "tenant_id", "acme")
+	logger.Info("no tenant")
+	logger.Debug("below level", "tenant_id", "acme")
+	assert.Equal(t, 3, sh.Shards())
+	assert.NoError(t, sh.Close())
+
+	assert.Equal(t, "INFO first [tenant_id=acme]\nINFO bound [tenant_id=acme]\n", readShard(t, dir, "acme"))
+	assert.Equal(t, "INFO second [tenant_id=globex]\n", readShard(t, dir, "globex"))
+	assert.Equal(t, "INFO grouped [req.tenant_id=acme]\nINFO no tenant\n", readShard(t, dir, DefaultShard))
+
+	assert.EqualError(t, sh.Handle(t.Context(), slog.Record{}), "sharded file handler is closed")
+}
+
+func TestShardedFileHandler_Eviction(t *testing.T) {
+	dir := t.TempDir()
+	sh := newTestShards(t, dir, 2)
+	logger := slog.New(sh)
+
+	logger.Info("one", "tenant_id", "a")
+	logger.Info("one", "tenant_id", "b")
+	logger.Info("two", "tenant_id", "a")
+	// b is the least recently used shard, so it is closed to open c.
+	logger.Info("one", "tenant_id", "c")
+	assert.Equal(t, 2, sh.Shards())
+	_, open := sh.set.shards["b"]
+	assert.False(t, open)
+
+	// Reopening b appends to its file.
+	logger.Info("two", "tenant_id", "b")
+	assert.NoError(t, sh.Close())
+	assert.Equal(t, "INFO one [tenant_id=b]\nINFO two [tenant_id=b]\n", readShard(t, dir, "b"))
+	assert.Equal(t, "INFO one [tenant_id=a]\nINFO two [tenant_id=a]\n", readShard(t, dir, "a"))
+}
+
+func TestShardedFileHandler_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	sh := newTestShards(t, dir, 2)
+	logger := slog.New(sh).With("service", "api")
+
+	var wg sync.WaitGroup
+	for _, tenant := range []string{"a", "b", "c", "d"} {
+		wg.Go(func() {
+			for range 50 {
+				logger.Info("record", "tenant_id", tenant)
+			}
+		})
+	}
+	wg.Wait()
+	assert.NoError(t, sh.Close())
+
+	for _, tenant := range []string{"a", "b", "c", "d"} {
+		lines := readLines(t, filepath.Join(dir, "tenant-"+tenant+".log"))
+		assert.Len(t, lines, 50, tenant)
+	}
+}
+
+func TestShardedFileHandler_RotateAndLevel(t *testing.T) {
+	dir := t.TempDir()
+	sh := newTestShards(t, dir, 0)
+	logger := slog.New(sh)
+
+	logger.Info("before rotation", "tenant_id", "acme")
+	assert.NoError(t, sh.Rotate())
+	matches, err := filepath.Glob(filepath.Join(dir, "tenant-acme-*.log"))
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	assert.NoError(t, sh.SetLevel(WarnLevel))
+	assert.Equal(t, WarnLevel, sh.GetLevel())
+	logger.Info("filtered", "tenant_id", "acme")
+	logger.Warn("written", "tenant_id", "acme")
+	logger.Info("filtered", "tenant_id", "new")
+	assert.Equal(t, 1, sh.Shards())
+	assert.EqualError(t, sh.SetLevel("loud"), "invalid log level: loud")
+	assert.NoError(t, sh.Close())
+
+	assert.Equal(t, "WARN written [tenant_id=acme]\n", readShard(t, dir, "acme"))
+}
+
+func TestCreateHandlers_Sharded(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := NewBuilder().File(filepath.Join(dir, "app.log"), JSON(), ShardBy("tenant_id", 8)).Config()
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	sh, ok := handlers[0].(*ShardedFileHandler)
+	assert.True(t, ok)
+	assert.Equal(t, 8, sh.set.opts.MaxOpenShards)
+
+	slog.New(sh).Info("sharded", "tenant_id", "acme")
+	assert.NoError(t, sh.Close())
+	data, err := os.ReadFile(filepath.Join(dir, "app-acme.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"msg":"sharded"`)
+}
+
+func TestValidateHandler_Sharding(t *testing.T) {
+	handler := &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "logs/[shard].log", ShardBy: "tenant_id"}
+	assert.NoError(t, validateHandler(handler))
+
+	handler.MaxOpenShards = -1
+	assert.EqualError(t, validateHandler(handler), "invalid max open shards: -1")
+
+	handler = &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "logs/[shard]/app.log", ShardBy: "tenant_id"}
+	assert.EqualError(t, validateHandler(handler), "the [shard] placeholder must be in the file name")
+
+	handler = &HandlerConfig{Type: FileHandlerType, Level: InfoLevel, File: "app.log", MaxOpenShards: 4}
+	assert.EqualError(t, validateHandler(handler), "max_open_shards requires shard_by")
+
+	handler = &HandlerConfig{Type: ConsoleHandlerType, Level: InfoLevel, ShardBy: "tenant_id"}
+	assert.EqualError(t, validateHandler(handler), "shard_by requires a file handler")
+}
@@ -0,0 +1,259 @@
+package multilog
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxSocketBackoff caps the delay between reconnection attempts.
+const maxSocketBackoff = 30 * time.Second
+
+// SocketProtocols lists the protocols supported by the socket handler.
+var SocketProtocols = []string{TCPProtocol, UDPProtocol, UnixProtocol, UnixgramProtocol}
+
+// recordFormatter is a handler that renders records without writing them.
+type recordFormatter interface {
+	slog.Handler
+	LevelSetter
+	Format(ctx context.Context, record slog.Record) (string, error)
+}
+
+// socketConn is the connection shared by a socket handler and its derived
+// handlers. Records are queued in pending, bounded by maxPending, and written
+// in order whenever the connection is up.
+type socketConn struct {
+	conn       net.Conn
+	onError    func(err error)
+	retryAt    time.Time
+	pending    [][]byte
+	backoff    time.Duration
+	maxPending int
+	dropped    int
+	mu         sync.Mutex
+	down       bool
+}
+
+// SocketHandler is a Handler that streams records as text or JSON lines to a
+// TCP, UDP or Unix socket, reconnecting when the connection is lost.
+type SocketHandler struct {
+	Handler recordFormatter
+	conn    *socketConn
+	opts    *SocketHandlerOptions
+}
+
+// SocketHandlerOptions are the options of socket handlers: the formatting
+// options, the address and protocol with TLS, and the buffer and backoff used
+// while reconnecting.
+type SocketHandlerOptions struct {
+	CustomHandlerOptions
+	Address       string
+	Protocol      string
+	MaxBufferSize int
+	RetryBackoff  time.Duration
+	TLS           bool
+	TLSSkipVerify bool
+}
+
+// NewSocketHandler creates a socket Handler with the specified options.
+// Records are written as lines formatted with the pattern, or as JSON when
+// SubType is "json"; binary subtypes are written without a delimiter, and
+// protobuf records are length-delimited. While
+// the endpoint is unreachable, up to MaxBufferSize records are held and the
+// oldest are dropped; reconnection is attempted with exponential backoff
+// starting at RetryBackoff.
+func NewSocketHandler(opts SocketHandlerOptions) (slog.Handler, error) {
+	if opts.Address == "" {
+		return nil, fmt.Errorf("socket handler requires an address")
+	}
+	opts.Protocol = defaultIfEmpty(opts.Protocol, TCPProtocol)
+	if !Contains(SocketProtocols, opts.Protocol) {
+		return nil, fmt.Errorf("invalid socket protocol: %s", opts.Protocol)
+	}
+	if opts.TLS && opts.Protocol != TCPProtocol {
+		return nil, fmt.Errorf("socket handler supports tls only over tcp")
+	}
+
+	var handler recordFormatter
+	switch opts.SubType {
+	case "", TextHandlerSubType:
+		handler = NewCustomHandler(&opts.CustomHandlerOptions, bufio.NewWriter(io.Discard), nil)
+	case JSONHandlerSubType, MsgpackHandlerSubType, CBORHandlerSubType, ProtobufHandlerSubType,
+		CEFHandlerSubType, LEEFHandlerSubType:
+		handler = newJSONHandler(opts.CustomHandlerOptions, nil, nil)
+	default:
+		return nil, fmt.Errorf("invalid socket handler subtype: %s", opts.SubType)
+	}
+
+	onError := opts.OnError
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+	return &SocketHandler{
+		Handler: handler,
+		conn:    &socketConn{onError: onError, maxPending: opts.MaxBufferSize},
+		opts:    &opts,
+	}, nil
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (sh *SocketHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return sh.Handler.Enabled(ctx, level)
+}
+
+// Handle formats the log record and writes it, holding it while disconnected.
+func (sh *SocketHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !sh.Enabled(ctx, record.Level) {
+		return nil
+	}
+	line, err := sh.Handler.Format(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	data := []byte(line)
+	if lineDelimited(sh.opts.SubType) {
+		data = append(data, '\n')
+	}
+
+	sh.conn.mu.Lock()
+	defer sh.conn.mu.Unlock()
+	sh.conn.hold(data)
+	if sh.conn.conn == nil && time.Now().Before(sh.conn.retryAt) {
+		return nil
+	}
+	sh.drain()
+	return nil
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (sh *SocketHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *sh
+	clone.Handler = sh.Handler.WithAttrs(attrs).(recordFormatter)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (sh *SocketHandler) WithGroup(name string) slog.Handler {
+	clone := *sh
+	clone.Handler = sh.Handler.WithGroup(name).(recordFormatter)
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (sh *SocketHandler) SetLevel(level string) error {
+	return sh.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (sh *SocketHandler) GetLevel() string {
+	return sh.Handler.GetLevel()
+}
+
+// Flush reconnects if needed and writes the held records. It returns an error
+// if records are still held afterwards.
+func (sh *SocketHandler) Flush() error {
+	sh.conn.mu.Lock()
+	defer sh.conn.mu.Unlock()
+	sh.drain()
+	if n := len(sh.conn.pending); n > 0 {
+		return fmt.Errorf("socket handler is disconnected: %d records held", n)
+	}
+	return nil
+}
+
+// Close writes the held records and closes the connection.
+func (sh *SocketHandler) Close() error {
+	err := sh.Flush()
+
+	sh.conn.mu.Lock()
+	defer sh.conn.mu.Unlock()
+	if sh.conn.conn != nil {
+		err = errors.Join(err, sh.conn.conn.Close())
+		sh.conn.conn = nil
+	}
+	return err
+}
+
+// Dropped returns the number of records dropped because the buffer was full.
+func (sh *SocketHandler) Dropped() int {
+	sh.conn.mu.Lock()
+	defer sh.conn.mu.Unlock()
+	return sh.conn.dropped
+}
+
+// customHandler implements customHandlerProvider.
+func (sh *SocketHandler) customHandler() CustomHandlerInterface {
+	return GetCustomHandler(sh.Handler)
+}
+
+// hold queues the line, dropping the oldest line when the buffer is full.
+func (c *socketConn) hold(line []byte) {
+	if c.maxPending > 0 && len(c.pending) >= c.maxPending {
+		c.pending[0] = nil
+		c.pending = c.pending[1:]
+		c.dropped++
+	}
+	c.pending = append(c.pending, line)
+}
+
+// drain connects if needed and writes the held lines in order, stopping at the
+// first failure; the caller must hold sh.conn.mu.
+func (sh *SocketHandler) drain() {
+	c := sh.conn
+	if len(c.pending) == 0 {
+		return
+	}
+	if c.conn == nil {
+		conn, err := dialSocket(sh.opts)
+		if err != nil {
+			sh.disconnect(err)
+			return
+		}
+		c.conn, c.backoff, c.down = conn, 0, false
+	}
+	for len(c.pending) > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(DefaultHTTPTimeout))
+		if _, err := c.conn.Write(c.pending[0]); err != nil {
+			sh.disconnect(err)
+			return
+		}
+		c.pending[0] = nil
+		c.pending = c.pending[1:]
+	}
+}
+
+// disconnect drops the connection and schedules the next attempt; the caller
+// must hold sh.conn.mu. The error is reported once per outage.
+func (sh *SocketHandler) disconnect(err error) {
+	c := sh.conn
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+	if !c.down {
+		c.onError(fmt.Errorf("socket handler disconnected from %s: %w", sh.opts.Address, err))
+		c.down = true
+	}
+	c.backoff = min(max(2*c.backoff, defaultDuration(sh.opts.RetryBackoff, DefaultRetryBackoff)), maxSocketBackoff)
+	c.retryAt = time.Now().Add(c.backoff)
+}
+
+// dialSocket connects to the handler address, over TLS if enabled.
+func dialSocket(opts *SocketHandlerOptions) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: DefaultHTTPTimeout}
+	if opts.TLS {
+		return tls.DialWithDialer(dialer, opts.Protocol, opts.Address, &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: opts.TLSSkipVerify,
+		})
+	}
+	return dialer.Dial(opts.Protocol, opts.Address)
+}
@@ -0,0 +1,243 @@
+package multilog
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// acceptLines accepts one connection on the listener and sends the lines read from it.
+func acceptLines(listener net.Listener) <-chan string {
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+// receiveLine returns the next line or fails after a second.
+func receiveLine(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(time.Second):
+		t.Fatal("expected a line")
+		return ""
+	}
+}
+
+func TestNewSocketHandler_Validation(t *testing.T) {
+	_, err := NewSocketHandler(SocketHandlerOptions{})
+	assert.Error(t, err)
+
+	_, err = NewSocketHandler(SocketHandlerOptions{Address: "localhost:5170", Protocol: "http"})
+	assert.Error(t, err)
+
+	_, err = NewSocketHandler(SocketHandlerOptions{Address: "localhost:5170", Protocol: UDPProtocol, TLS: true})
+	assert.Error(t, err)
+
+	_, err = NewSocketHandler(SocketHandlerOptions{CustomHandlerOptions: CustomHandlerOptions{SubType: "xml"}, Address: "localhost:5170"})
+	assert.Error(t, err)
+}
+
+func TestSocketHandler_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	lines := acceptLines(listener)
+
+	handler, err := NewSocketHandler(SocketHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+		},
+		Address: listener.Addr().String(),
+	})
+	assert.NoError(t, err)
+	sh := handler.(*SocketHandler)
+	defer sh.Close()
+
+	logger := NewLogger(handler)
+	logger.Info("first", "user", "alice")
+	logger.Debug("skipped")
+	logger.Warn("second")
+
+	assert.Equal(t, `INFO first [user=alice]`, receiveLine(t, lines))
+	assert.Equal(t, `WARN second`, receiveLine(t, lines))
+}
+
+func TestSocketHandler_JSONOverUnix(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "logs.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	defer listener.Close()
+	lines := acceptLines(listener)
+
+	handler, err := NewSocketHandler(SocketHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			SubType: JSONHandlerSubType,
+		},
+		Address:  socket,
+		Protocol: UnixProtocol,
+	})
+	assert.NoError(t, err)
+	defer handler.(*SocketHandler).Close()
+
+	grouped := handler.WithGroup("req").WithAttrs([]slog.Attr{slog.String("path", "/x")})
+	assert.NoError(t, grouped.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "failed", 0)))
+
+	var doc map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(receiveLine(t, lines)), &doc))
+	assert.Equal(t, "failed", doc["msg"])
+	assert.Equal(t, map[string]any{"path": "/x"}, doc["req"])
+}
+
+func TestSocketHandler_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	handler, err := NewSocketHandler(SocketHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+		},
+		Address:  conn.LocalAddr().String(),
+		Protocol: UDPProtocol,
+	})
+	assert.NoError(t, err)
+	defer handler.(*SocketHandler).Close()
+
+	assert.NoError(t, handler.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "hello", 0)))
+
+	buf := make([]byte, 1024)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "INFO hello\n", string(buf[:n]))
+}
+
+func TestSocketHandler_Reconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	address := listener.Addr().String()
+	assert.NoError(t, listener.Close())
+
+	var reported []error
+	handler, err := NewSocketHandler(SocketHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+			OnError: func(err error) { reported = append(reported, err) },
+		},
+		Address:       address,
+		MaxBufferSize: 2,
+		RetryBackoff:  time.Millisecond,
+	})
+	assert.NoError(t, err)
+	sh := handler.(*SocketHandler)
+	defer sh.Close()
+
+	for _, msg := range []string{"one", "two", "three"} {
+		assert.NoError(t, sh.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)))
+	}
+	assert.Len(t, reported, 1)
+	assert.Equal(t, 1, sh.Dropped())
+	assert.Error(t, sh.Flush())
+
+	listener, err = net.Listen("tcp", address)
+	assert.NoError(t, err)
+	defer listener.Close()
+	lines := acceptLines(listener)
+
+	assert.NoError(t, sh.Flush())
+	assert.Equal(t, "INFO two", receiveLine(t, lines))
+	assert.Equal(t, "INFO three", receiveLine(t, lines))
+}
+
+func TestSocketHandler_TLS(t *testing.T) {
+	cert := selfSignedCert(t)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	})
+	assert.NoError(t, err)
+	defer listener.Close()
+	lines := acceptLines(listener)
+
+	handler, err := NewSocketHandler(SocketHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+			Pattern: "[level] [msg]",
+		},
+		Address:       listener.Addr().String(),
+		TLS:           true,
+		TLSSkipVerify: true,
+	})
+	assert.NoError(t, err)
+	defer handler.(*SocketHandler).Close()
+
+	assert.NoError(t, handler.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "secret", 0)))
+	assert.Equal(t, "INFO secret", receiveLine(t, lines))
+}
+
+func TestCreateHandler_Socket(t *testing.T) {
+	cfg := &Config{Multilog: LogConfig{Handlers: []HandlerConfig{
+		{Type: SocketHandlerType, Level: InfoLevel, Enabled: true, Protocol: UDPProtocol, TLS: true, Address: ":1"},
+	}}}
+	err := validateConfig(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "tls")
+
+	cfg.Multilog.Handlers[0].TLS = false
+	assert.NoError(t, validateConfig(cfg))
+	opts, err := cfg.GetCustomHandlerOptionsForHandler(cfg.Multilog.Handlers[0])
+	assert.NoError(t, err)
+	handler, err := createHandler(&cfg.Multilog.Handlers[0], opts)
+	assert.NoError(t, err)
+	assert.IsType(t, &SocketHandler{}, handler)
+}
+
+// selfSignedCert returns a certificate for 127.0.0.1.
+func selfSignedCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
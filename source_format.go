@@ -0,0 +1,131 @@
+package multilog
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Source placeholders besides FileSource, LineSource and FuncSource
+const (
+	PathSource    = "[path]"
+	RelPathSource = "[relpath]"
+)
+
+// Named source formats accepted by the source format option
+const (
+	FullSourceFormat     = "full"
+	RelativeSourceFormat = "relative"
+	ShortSourceFormat    = "short"
+)
+
+// namedSourceFormats maps the named source formats to source formats.
+var namedSourceFormats = map[string]string{
+	FullSourceFormat:     PathSource + ":" + LineSource + ":" + FuncSource,
+	RelativeSourceFormat: RelPathSource + ":" + LineSource + ":" + FuncSource,
+	ShortSourceFormat:    FileSource + ":" + LineSource,
+}
+
+// ResolveSourceFormat returns the source format for a named format, or the
+// format itself.
+func ResolveSourceFormat(format string) string {
+	if named, ok := namedSourceFormats[format]; ok {
+		return named
+	}
+	return format
+}
+
+// ValidateSourceFormat validates that the source format, after resolving named
+// formats, places the file with [file], [path] or [relpath].
+func ValidateSourceFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	format = ResolveSourceFormat(format)
+	for _, placeholder := range []string{FileSource, PathSource, RelPathSource} {
+		if strings.Contains(format, placeholder) {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid source format: %s", format)
+}
+
+// recordSource returns the formatted source of the record when the handler
+// adds sources, or an empty string.
+func recordSource(opts *CustomHandlerOptions, record slog.Record) string {
+	if !opts.AddSource {
+		return ""
+	}
+	if src := record.Source(); src != nil && src.File != "" {
+		return formatSource(opts, src)
+	}
+	return ""
+}
+
+// formatSource renders the source with the source format of the options,
+// file:line:pkg.Func by default. The trim prefixes, such as the module path,
+// are removed from the path of [path].
+func formatSource(opts *CustomHandlerOptions, source *slog.Source) string {
+	format := DefaultSourceFormat
+	var trimPrefixes []string
+	if opts != nil {
+		format = ResolveSourceFormat(defaultIfEmpty(opts.SourceFormat, DefaultSourceFormat))
+		trimPrefixes = opts.SourceTrimPrefixes
+	}
+	if format == DefaultSourceFormat {
+		return BaseName(source.File) + ":" + strconv.Itoa(source.Line) + ":" + sourceFunc(source)
+	}
+
+	var sb strings.Builder
+	for format != "" {
+		start := strings.IndexByte(format, '[')
+		end := -1
+		if start >= 0 {
+			end = strings.IndexByte(format[start:], ']')
+		}
+		if end < 0 {
+			sb.WriteString(format)
+			break
+		}
+		end += start
+		sb.WriteString(format[:start])
+		switch placeholder := format[start : end+1]; placeholder {
+		case FileSource:
+			sb.WriteString(BaseName(source.File))
+		case PathSource:
+			sb.WriteString(trimSourcePath(source.File, trimPrefixes))
+		case RelPathSource:
+			sb.WriteString(path.Join(path.Base(path.Dir(source.File)), path.Base(source.File)))
+		case LineSource:
+			sb.WriteString(strconv.Itoa(source.Line))
+		case FuncSource:
+			sb.WriteString(sourceFunc(source))
+		default:
+			sb.WriteString(placeholder)
+		}
+		format = format[end+1:]
+	}
+	return sb.String()
+}
+
+// sourceFunc returns the function of the source qualified by the package name.
+func sourceFunc(source *slog.Source) string {
+	fn := source.Function
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	return fn
+}
+
+// trimSourcePath removes the first matching prefix from the path of a source
+// file.
+func trimSourcePath(file string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if trimmed, ok := strings.CutPrefix(file, prefix); ok {
+			return strings.TrimPrefix(trimmed, "/")
+		}
+	}
+	return file
+}
@@ -0,0 +1,103 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSourceFormat(t *testing.T) {
+	assert.NoError(t, ValidateSourceFormat(""))
+	assert.NoError(t, ValidateSourceFormat(ShortSourceFormat))
+	assert.NoError(t, ValidateSourceFormat("[path]:[line]"))
+	assert.EqualError(t, ValidateSourceFormat("[line]:[func]"), "invalid source format: [line]:[func]")
+}
+
+func TestFormatSource(t *testing.T) {
+	source := &slog.Source{
+		Function: "github.com/acme/shop/orders.(*Service).Place",
+		File:     "github.com/acme/shop/orders/service.go",
+		Line:     42,
+	}
+
+	tests := []struct {
+		name     string
+		opts     *CustomHandlerOptions
+		expected string
+	}{
+		{"nil options", nil, "service.go:42:orders.(*Service).Place"},
+		{"default", &CustomHandlerOptions{}, "service.go:42:orders.(*Service).Place"},
+		{"full", &CustomHandlerOptions{SourceFormat: FullSourceFormat},
+			"github.com/acme/shop/orders/service.go:42:orders.(*Service).Place"},
+		{"relative", &CustomHandlerOptions{SourceFormat: RelativeSourceFormat},
+			"orders/service.go:42:orders.(*Service).Place"},
+		{"short", &CustomHandlerOptions{SourceFormat: ShortSourceFormat}, "service.go:42"},
+		{"trimmed path", &CustomHandlerOptions{
+			SourceFormat:       "[path]:[line]",
+			SourceTrimPrefixes: []string{"github.com/other", "github.com/acme/shop"},
+		}, "orders/service.go:42"},
+		{"literal text", &CustomHandlerOptions{SourceFormat: "([file] [line]) [x"}, "(service.go 42) [x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatSource(tt.opts, source))
+		})
+	}
+}
+
+func TestSourceFormat_Handlers(t *testing.T) {
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "hello", 0)
+	opts := CustomHandlerOptions{
+		Level:        DebugLevel,
+		Enabled:      true,
+		Pattern:      "[level] [source] [msg]",
+		SourceFormat: ShortSourceFormat,
+	}
+
+	// A record without a program counter has no source.
+	buf := &bytes.Buffer{}
+	handler := NewCustomHandler(&opts, bufio.NewWriter(buf), nil)
+	assert.NoError(t, handler.Handle(t.Context(), record))
+	assert.NoError(t, handler.Flush())
+	assert.Equal(t, "INFO "+UnknownSource+" hello\n", buf.String())
+
+	logger := NewLogger(handler)
+	buf.Reset()
+	logger.Info("hello")
+	assert.NoError(t, handler.Flush())
+	assert.Regexp(t, `^INFO source_format_test\.go:\d+ hello\n$`, buf.String())
+
+	buf.Reset()
+	jsonHandler := newJSONHandler(opts, bufio.NewWriter(buf), nil)
+	NewLogger(jsonHandler).Info("hello")
+	assert.NoError(t, jsonHandler.Flush())
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Regexp(t, `^source_format_test\.go:\d+$`, entry["source"])
+}
+
+func TestLogger_SourceFormat(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewBuilder().
+		File(file, Pattern("[level] [source] [msg]"), SourceFormat(RelativeSourceFormat)).
+		Build()
+	assert.NoError(t, err)
+
+	logger.Info("hello")
+	assert.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	// The directory of the test file, whatever the checkout is named, precedes it.
+	assert.Regexp(t, `^INFO [^/ ]+/source_format_test\.go:\d+:multilog\.TestLogger_SourceFormat hello\n$`, string(content))
+
+	_, err = NewBuilder().Console(SourceFormat("[func]")).Build()
+	assert.ErrorContains(t, err, "invalid source format: [func]")
+}
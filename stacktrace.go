@@ -0,0 +1,120 @@
+package multilog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Stack trace settings
+const (
+	StacktraceKey          = "stacktrace"
+	DefaultStacktraceLevel = "error"
+	maxStacktraceDepth     = 64
+)
+
+// loggerFramePrefix identifies frames of the multilog Logger convenience methods.
+const loggerFramePrefix = "github.com/phani-kb/multilog.(*Logger)."
+
+// stacktraceEnabled reports whether a stack trace should be captured for the level.
+func stacktraceEnabled(opts *CustomHandlerOptions, level slog.Level) bool {
+	if opts == nil || !opts.AddStacktrace {
+		return false
+	}
+	return level >= GetSlogLevel(defaultIfEmpty(opts.StacktraceLevel, DefaultStacktraceLevel))
+}
+
+// Stacktrace returns the stack of the calling goroutine, starting at the frame
+// that logged the record with the given program counter. Frames of the logging
+// machinery are omitted; if pc is zero or not on the stack, the full stack of
+// the caller is returned.
+func Stacktrace(pc uintptr) string {
+	return formatStacktrace(callerStack(3), pc)
+}
+
+// callerStackKey is the context key of the stack captured by withCallerStack.
+type callerStackKey struct{}
+
+// callerStack returns the program counters of the calling goroutine, skipping
+// skip frames as runtime.Callers does.
+func callerStack(skip int) []uintptr {
+	pcs := make([]uintptr, maxStacktraceDepth)
+	return pcs[:runtime.Callers(skip, pcs)]
+}
+
+// withCallerStack returns a copy of ctx carrying the stack of the calling
+// goroutine, for handlers formatting the record on another goroutine. A stack
+// captured earlier on the way to the handlers is kept.
+func withCallerStack(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(callerStackKey{}).([]uintptr); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, callerStackKey{}, callerStack(3))
+}
+
+// recordStacktrace returns the stack trace of the record with the program
+// counter, from the stack captured in ctx by withCallerStack if there is one,
+// or the stack of the calling goroutine.
+func recordStacktrace(ctx context.Context, pc uintptr) string {
+	if ctx == nil {
+		return formatStacktrace(callerStack(3), pc)
+	}
+	if pcs, ok := ctx.Value(callerStackKey{}).([]uintptr); ok {
+		return formatStacktrace(pcs, pc)
+	}
+	return formatStacktrace(callerStack(3), pc)
+}
+
+// formatStacktrace formats the stack, as described in Stacktrace.
+func formatStacktrace(pcs []uintptr, pc uintptr) string {
+	frames := collectFrames(pcs)
+
+	start := 0
+	if pc != 0 {
+		origin, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		for i, f := range frames {
+			if f.Function == origin.Function && f.Line == origin.Line {
+				start = i
+				break
+			}
+		}
+	}
+	for start < len(frames)-1 && strings.HasPrefix(frames[start].Function, loggerFramePrefix) {
+		start++
+	}
+
+	sb := &strings.Builder{}
+	for i, f := range frames[start:] {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(f.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(f.File)
+		sb.WriteString(":")
+		sb.WriteString(strconv.Itoa(f.Line))
+	}
+	return sb.String()
+}
+
+// collectFrames resolves the program counters, dropping runtime frames.
+func collectFrames(pcs []uintptr) []runtime.Frame {
+	frames := make([]runtime.Frame, 0, len(pcs))
+	iter := runtime.CallersFrames(pcs)
+	for {
+		f, more := iter.Next()
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			frames = append(frames, f)
+		}
+		if !more {
+			return frames
+		}
+	}
+}
+
+// indentStacktrace indents every line of the stack trace for text output.
+func indentStacktrace(stack string) string {
+	return "\t" + strings.ReplaceAll(stack, "\n", "\n\t")
+}
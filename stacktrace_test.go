@@ -0,0 +1,115 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStacktraceEnabled(t *testing.T) {
+	assert.False(t, stacktraceEnabled(nil, slog.LevelError))
+	assert.False(t, stacktraceEnabled(&CustomHandlerOptions{}, slog.LevelError))
+
+	opts := &CustomHandlerOptions{AddStacktrace: true}
+	assert.True(t, stacktraceEnabled(opts, slog.LevelError))
+	assert.False(t, stacktraceEnabled(opts, slog.LevelWarn))
+
+	opts.StacktraceLevel = WarnLevel
+	assert.True(t, stacktraceEnabled(opts, slog.LevelWarn))
+	assert.False(t, stacktraceEnabled(opts, slog.LevelInfo))
+}
+
+func TestStacktrace(t *testing.T) {
+	stack := Stacktrace(0)
+	lines := strings.Split(stack, "\n")
+	assert.Equal(t, "github.com/phani-kb/multilog.TestStacktrace", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "\t"), lines[1])
+	assert.Contains(t, lines[1], "stacktrace_test.go:")
+	assert.NotContains(t, stack, "runtime.")
+}
+
+func TestCustomHandler_Stacktrace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{
+		Level:         DebugLevel,
+		Enabled:       true,
+		Pattern:       "[level] [msg]",
+		AddStacktrace: true,
+	}
+	logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Info("quiet")
+	logger.Error("boom")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "INFO quiet", lines[0])
+	assert.Equal(t, "ERROR boom", lines[1])
+	assert.Equal(t, "\tgithub.com/phani-kb/multilog.TestCustomHandler_Stacktrace", lines[2])
+	assert.Contains(t, lines[3], "stacktrace_test.go:")
+	assert.True(t, strings.HasPrefix(lines[3], "\t\t"), lines[3])
+}
+
+func TestJSONHandler_Stacktrace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := CustomHandlerOptions{
+		Level:           DebugLevel,
+		Enabled:         true,
+		AddStacktrace:   true,
+		StacktraceLevel: WarnLevel,
+	}
+	logger := slog.New(newJSONHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Info("quiet")
+	logger.Warn("careful")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var info, warn map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &info))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &warn))
+	assert.NotContains(t, info, StacktraceKey)
+	stack, ok := warn[StacktraceKey].(string)
+	assert.True(t, ok)
+	assert.True(t, strings.HasPrefix(stack, "github.com/phani-kb/multilog.TestJSONHandler_Stacktrace\n"), stack)
+}
+
+func TestLogger_Stacktrace(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewBuilder().File(file, Pattern("[level] [msg]"), AddStacktrace(ErrorLevel)).Build()
+	assert.NoError(t, err)
+
+	logger.Errorf("failed %d", 1)
+	assert.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	lines := strings.Split(string(content), "\n")
+	assert.Equal(t, "\tgithub.com/phani-kb/multilog.TestLogger_Stacktrace", lines[1], string(content))
+}
+
+func TestParallelLogger_Stacktrace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{
+		Level:         DebugLevel,
+		Enabled:       true,
+		Pattern:       "[level] [msg]",
+		AddStacktrace: true,
+	}
+	logger := NewParallelLogger(2, 0, NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Error("boom")
+	assert.NoError(t, logger.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "ERROR boom", lines[0])
+	assert.Equal(t, "\tgithub.com/phani-kb/multilog.TestParallelLogger_Stacktrace", lines[1], buf.String())
+	assert.NotContains(t, buf.String(), "ParallelAggregator")
+}
@@ -0,0 +1,106 @@
+package multilog
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// StdLogCallerKey is the attribute holding the file:line the standard logger
+// reported when its Lshortfile or Llongfile flag is set.
+const StdLogCallerKey = "caller"
+
+// stdLogCallDepth is the number of frames of the standard logger between the
+// caller of its printing functions and the Write of its output: the function
+// and the output method.
+const stdLogCallDepth = 2
+
+// Lengths of the headers added by the standard logger flags
+const (
+	stdLogDateLen  = len("2006/01/02 ")
+	stdLogTimeLen  = len("15:04:05 ")
+	stdLogMicroLen = len(".000000")
+)
+
+// RedirectStdLog makes log.Default() write through the logger at the info
+// level and returns a function restoring its previous output. The prefix,
+// timestamp and file the standard logger adds according to its flags are
+// removed from the message, the file reported as the caller attribute.
+func RedirectStdLog(logger *Logger) func() {
+	std := log.Default()
+	previous := std.Writer()
+	std.SetOutput(&stdLogWriter{logger: logger.Logger, std: std})
+	return func() { std.SetOutput(previous) }
+}
+
+// InstallAsDefault creates a logger from the configuration and makes it the
+// slog default, which also routes the standard log package through it. The
+// returned cleanup function restores the previous defaults and closes the
+// logger.
+func InstallAsDefault(cfg *Config) (func() error, error) {
+	logger, err := NewLoggerFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	previous := slog.Default()
+	previousWriter, previousFlags := log.Writer(), log.Flags()
+	slog.SetDefault(logger.Logger)
+
+	return func() error {
+		slog.SetDefault(previous)
+		log.SetOutput(previousWriter)
+		log.SetFlags(previousFlags)
+		if err := logger.Close(); err != nil {
+			return fmt.Errorf("failed to close default logger: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// stdLogWriter logs the messages of a standard logger.
+type stdLogWriter struct {
+	logger *slog.Logger
+	std    *log.Logger
+}
+
+// Write logs the message of one standard logger call.
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	msg, caller := parseStdLogLine(strings.TrimSuffix(string(p), "\n"), w.std.Prefix(), w.std.Flags())
+	var args []any
+	if caller != "" {
+		args = append(args, StdLogCallerKey, caller)
+	}
+	// Skip Write and the standard logger frames to report the caller.
+	logWithCaller(w.logger, 1+stdLogCallDepth, slog.LevelInfo, msg, args...)
+	return len(p), nil
+}
+
+// parseStdLogLine removes the header the standard logger adds for the prefix
+// and flags, returning the message and the reported file:line, if any.
+func parseStdLogLine(line, prefix string, flags int) (msg, caller string) {
+	if flags&log.Lmsgprefix == 0 {
+		line = strings.TrimPrefix(line, prefix)
+	}
+	if flags&log.Ldate != 0 && len(line) >= stdLogDateLen {
+		line = line[stdLogDateLen:]
+	}
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		n := stdLogTimeLen
+		if flags&log.Lmicroseconds != 0 {
+			n += stdLogMicroLen
+		}
+		if len(line) >= n {
+			line = line[n:]
+		}
+	}
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		if i := strings.Index(line, ": "); i >= 0 {
+			caller, line = line[:i], line[i+2:]
+		}
+	}
+	if flags&log.Lmsgprefix != 0 {
+		line = strings.TrimPrefix(line, prefix)
+	}
+	return line, caller
+}
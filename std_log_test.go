@@ -0,0 +1,97 @@
+package multilog
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStdLogLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		prefix string
+		msg    string
+		caller string
+		flags  int
+	}{
+		{"no flags", "hello", "", "hello", "", 0},
+		{"standard", "2024/01/02 03:04:05 hello", "", "hello", "", log.LstdFlags},
+		{"micro", "03:04:05.123456 hello", "", "hello", "", log.Lmicroseconds},
+		{"prefix", "app: 2024/01/02 hello", "app: ", "hello", "", log.Ldate},
+		{"msgprefix", "2024/01/02 app: hello", "app: ", "hello", "", log.Ldate | log.Lmsgprefix},
+		{"shortfile", "main.go:12: hello: world", "", "hello: world", "main.go:12", log.Lshortfile},
+		{
+			"all", "[x] 2024/01/02 03:04:05.000001 /src/main.go:7: done", "[x] ", "done", "/src/main.go:7",
+			log.LstdFlags | log.Lmicroseconds | log.Llongfile,
+		},
+		{"short line", "hi", "", "hi", "", log.LstdFlags},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, caller := parseStdLogLine(tt.line, tt.prefix, tt.flags)
+			assert.Equal(t, tt.msg, msg)
+			assert.Equal(t, tt.caller, caller)
+		})
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	flags, prefix := log.Flags(), log.Prefix()
+	defer func() {
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	}()
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("legacy: ")
+
+	handler := &recordingHandler{}
+	restore := RedirectStdLog(NewLogger(handler))
+	_, file, line, _ := runtime.Caller(0)
+	log.Printf("cache warmed in %dms", 12)
+	restore()
+	assert.Equal(t, os.Stderr, log.Writer())
+
+	assert.Len(t, handler.records, 1)
+	record := handler.records[0]
+	assert.Equal(t, slog.LevelInfo, record.Level)
+	assert.Equal(t, "cache warmed in 12ms", record.Message)
+	source := record.Source()
+	assert.Equal(t, file, source.File)
+	assert.Equal(t, line+1, source.Line)
+	record.Attrs(func(a slog.Attr) bool {
+		assert.Equal(t, StdLogCallerKey, a.Key)
+		assert.Regexp(t, `^std_log_test\.go:\d+$`, a.Value.String())
+		return true
+	})
+}
+
+func TestInstallAsDefault(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	cfg := &Config{Multilog: LogConfig{Handlers: []HandlerConfig{
+		{Type: FileHandlerType, Level: InfoLevel, Enabled: true, File: file, Pattern: "[level] [msg]"},
+	}}}
+	previous := slog.Default()
+
+	cleanup, err := InstallAsDefault(cfg)
+	assert.NoError(t, err)
+	slog.Info("from slog", "user", "ana")
+	log.Print("from log")
+	assert.NoError(t, cleanup())
+
+	assert.Same(t, previous, slog.Default())
+	assert.Equal(t, os.Stderr, log.Writer())
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "INFO from slog [")
+	assert.Contains(t, string(data), "user=ana]")
+	assert.Contains(t, string(data), "INFO from log")
+
+	_, err = InstallAsDefault(&Config{Multilog: LogConfig{Handlers: []HandlerConfig{{Type: "unknown", Enabled: true}}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+package multilog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Format engines
+const (
+	PatternFormatEngine  = "pattern"
+	TemplateFormatEngine = "template"
+)
+
+// DefaultTemplate is the template used when the template engine has no pattern.
+const DefaultTemplate = `{{.Time.Format "15:04:05"}} {{.Level}} {{.Message}}` +
+	`{{range $k, $v := .Attrs}} {{$k}}={{$v}}{{end}}`
+
+// FormatEngines lists the supported format engines.
+var FormatEngines = []string{PatternFormatEngine, TemplateFormatEngine}
+
+// TemplateRecord is the data a format template is executed with. Attrs holds
+// the attributes by key, with grouped keys joined by dots; Source is set when
+// AddSource is enabled. Version is the application version. The message, the
+// source and string attributes are escaped as set by the escape option.
+type TemplateRecord struct {
+	Time    time.Time
+	Attrs   map[string]any
+	Level   string
+	Message string
+	Source  string
+	Version string
+}
+
+// templateFuncs are the functions available to format templates.
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"pad": func(width int, value any) string {
+		return alignValue(fmt.Sprint(value), width, 0)
+	},
+	"json": func(value any) (string, error) {
+		b, err := json.Marshal(value)
+		return string(b), err
+	},
+	"host":      hostname,
+	"pid":       pid,
+	"goroutine": goroutineID,
+	"revision": func() string {
+		return buildInfo().ShortRevision()
+	},
+}
+
+// templateCache caches parsed templates by text.
+var templateCache sync.Map
+
+// ParseTemplate parses a format template with the template functions.
+func ParseTemplate(text string) (*template.Template, error) {
+	if t, ok := templateCache.Load(text); ok {
+		return t.(*template.Template), nil
+	}
+	t, err := template.New("format").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	actual, _ := templateCache.LoadOrStore(text, t)
+	return actual.(*template.Template), nil
+}
+
+// appendTemplate appends the record rendered with the template of its level.
+func (ch *CustomHandler) appendTemplate(ctx context.Context, buf []byte, record slog.Record) ([]byte, error) {
+	text := ch.Opts.Patterns[GetLevelName(record.Level)]
+	if text == "" {
+		text = defaultIfEmpty(ch.Opts.Pattern, DefaultTemplate)
+	}
+	t, err := ParseTemplate(text)
+	if err != nil {
+		return buf, err
+	}
+
+	w := bytes.NewBuffer(buf)
+	if err := t.Execute(w, ch.templateRecord(record)); err != nil {
+		return buf, fmt.Errorf("failed to execute template: %w", err)
+	}
+	buf = w.Bytes()
+	if stacktraceEnabled(ch.Opts, record.Level) {
+		buf = append(buf, '\n')
+		buf = append(buf, indentStacktrace(recordStacktrace(ctx, record.PC))...)
+	}
+	return buf, nil
+}
+
+// templateRecord returns the template data of the record.
+func (ch *CustomHandler) templateRecord(record slog.Record) TemplateRecord {
+	data := TemplateRecord{
+		Time:    recordTime(ch.Opts, record.Time),
+		Attrs:   ch.templateAttrs(record),
+		Level:   levelLabel(record.Level, ch.Opts.UseSingleLetterLevel),
+		Message: escapeText(ch.Opts.Escape, record.Message),
+		Version: appVersion(ch.Opts),
+	}
+	if ch.Opts.AddSource {
+		if src := record.Source(); src != nil && src.File != "" {
+			data.Source = escapeText(ch.Opts.Escape, formatSource(ch.Opts, src))
+		}
+	}
+	return data
+}
+
+// templateAttrs returns the handler and record attributes by dotted key.
+func (ch *CustomHandler) templateAttrs(record slog.Record) map[string]any {
+	attrs := make(map[string]any, len(ch.boundAttrs)+record.NumAttrs())
+	for _, b := range ch.boundAttrs {
+		ch.addTemplateAttr(attrs, b.groups, b.attr)
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		ch.addTemplateAttr(attrs, ch.groups, a)
+		return true
+	})
+	return attrs
+}
+
+// addTemplateAttr adds the attribute, or the attributes of a group, to attrs.
+func (ch *CustomHandler) addTemplateAttr(attrs map[string]any, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if ch.replaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = ch.replaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			ch.addTemplateAttr(attrs, groups, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	key := a.Key
+	if len(groups) > 0 {
+		key = strings.Join(groups, ".") + "." + key
+	}
+	if a.Value.Kind() == slog.KindString {
+		attrs[key] = escapeText(ch.Opts.Escape, a.Value.String())
+		return
+	}
+	attrs[key] = a.Value.Any()
+}
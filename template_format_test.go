@@ -0,0 +1,98 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("{{.Level}}")
+	assert.NoError(t, err)
+	cached, err := ParseTemplate("{{.Level}}")
+	assert.NoError(t, err)
+	assert.Same(t, tmpl, cached)
+
+	_, err = ParseTemplate("{{.Level")
+	assert.ErrorContains(t, err, "failed to parse template")
+}
+
+func TestCustomHandler_Template(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{
+		Level:        DebugLevel,
+		Enabled:      true,
+		FormatEngine: TemplateFormatEngine,
+		Pattern: `{{.Time.Format "15:04"}} {{pad -5 .Level}} {{.Message | upper}}` +
+			`{{with index .Attrs "req.id"}} id={{.}}{{end}}{{if .Attrs.err}} err={{json .Attrs.err}}{{end}}`,
+		Location: time.UTC,
+	}
+	handler := NewCustomHandler(opts, bufio.NewWriter(buf), nil)
+	logger := slog.New(handler)
+
+	logger.WithGroup("req").With("id", "r-1").Info("served")
+	logger.Warn("retry", "err", "timeout")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Regexp(t, `^\d{2}:\d{2} INFO  SERVED id=r-1$`, lines[0])
+	assert.Regexp(t, `^\d{2}:\d{2} WARN  RETRY err="timeout"$`, lines[1])
+
+	record := slog.NewRecord(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC), slog.LevelError, "boom", 0)
+	output, err := handler.Format(t.Context(), record)
+	assert.NoError(t, err)
+	assert.Equal(t, "07:08 ERROR BOOM", output)
+}
+
+func TestCustomHandler_DefaultTemplate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{Level: DebugLevel, Enabled: true, FormatEngine: TemplateFormatEngine}
+	logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Info("served", "status", 200, "path", "/")
+
+	assert.Regexp(t, `^\d{2}:\d{2}:\d{2} INFO served path=/ status=200\n$`, buf.String())
+}
+
+func TestCustomHandler_TemplateError(t *testing.T) {
+	opts := &CustomHandlerOptions{
+		Level:        DebugLevel,
+		Enabled:      true,
+		FormatEngine: TemplateFormatEngine,
+		Pattern:      "{{.Missing}}",
+	}
+	handler := NewCustomHandler(opts, bufio.NewWriter(&bytes.Buffer{}), nil)
+
+	err := handler.Handle(t.Context(), slog.NewRecord(time.Now(), slog.LevelInfo, "hi", 0))
+	assert.ErrorContains(t, err, "failed to execute template")
+}
+
+func TestValidateFormat(t *testing.T) {
+	handler := HandlerConfig{FormatEngine: TemplateFormatEngine, Pattern: "{{.Message}}"}
+	assert.NoError(t, validateFormat(&handler))
+
+	handler.Patterns = map[string]string{ErrorLevel: "{{.Message"}
+	assert.ErrorContains(t, validateFormat(&handler), "failed to parse template")
+
+	handler = HandlerConfig{FormatEngine: "jinja"}
+	assert.EqualError(t, validateFormat(&handler), "invalid format engine: jinja")
+}
+
+func TestBuilder_Template(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewBuilder().File(file, Template("{{.Level}}|{{.Message}}")).Build()
+	assert.NoError(t, err)
+
+	logger.Info("hello")
+	assert.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "INFO|hello\n", string(content))
+}
@@ -7,7 +7,8 @@ import (
 	"testing"
 )
 
-// TestHandler implements slog.Handler for testing purposes
+// TestHandler implements slog.Handler for testing purposes. It only keeps the
+// last record; the multilogtest package records all of them with their attributes.
 type TestHandler struct {
 	t           *testing.T
 	lastMessage string
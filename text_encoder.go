@@ -0,0 +1,370 @@
+package multilog
+
+import (
+	"encoding"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// placeholderPattern matches the placeholders of a pattern: the built-in ones
+// and custom ones named after attribute keys, such as [request_id] or [req.id],
+// optionally followed by a width modifier such as [level:-5] or [source:30.30].
+var placeholderPattern = regexp.MustCompile(`\[[a-zA-Z_][a-zA-Z0-9_.]*(?::-?[0-9]+(?:\.[0-9]+)?)?\]`)
+
+// maxPlaceholderWidth bounds the widths of placeholders, so a pattern such as
+// [msg:1000000000] cannot make every record allocate a huge padding.
+const maxPlaceholderWidth = 1024
+
+// builtinPlaceholders lists the placeholders that are not resolved from attributes.
+var builtinPlaceholders = map[string]bool{
+	DatePlaceholder:      true,
+	TimePlaceholder:      true,
+	DateTimePlaceholder:  true,
+	LevelPlaceholder:     true,
+	MsgPlaceholder:       true,
+	PerfPlaceholder:      true,
+	SourcePlaceholder:    true,
+	HostPlaceholder:      true,
+	PIDPlaceholder:       true,
+	GoroutinePlaceholder: true,
+	VersionPlaceholder:   true,
+	RevisionPlaceholder:  true,
+}
+
+// patternSegment is a literal or a placeholder of a compiled pattern. The text
+// of a placeholder is its name without modifier, and raw the placeholder as written.
+type patternSegment struct {
+	text        string
+	raw         string
+	width       int
+	maxWidth    int
+	placeholder bool
+}
+
+// compiledPattern is a pattern split into segments, with the placeholders it
+// uses. custom holds the attribute keys of its custom placeholders, and plain
+// the pattern without width modifiers.
+type compiledPattern struct {
+	plain     string
+	segments  []patternSegment
+	custom    []string
+	hasLevel  bool
+	hasSource bool
+	hasPerf   bool
+}
+
+// patternCache caches compiled patterns by pattern string.
+var patternCache sync.Map
+
+// compilePattern splits the pattern into literal and placeholder segments.
+func compilePattern(pattern string) *compiledPattern {
+	if cp, ok := patternCache.Load(pattern); ok {
+		return cp.(*compiledPattern)
+	}
+	cp := &compiledPattern{}
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringIndex(pattern, -1) {
+		if loc[0] > last {
+			cp.segments = append(cp.segments, patternSegment{text: pattern[last:loc[0]]})
+		}
+		raw := pattern[loc[0]:loc[1]]
+		placeholder, width, maxWidth := parsePlaceholder(raw)
+		cp.segments = append(cp.segments, patternSegment{
+			text:        placeholder,
+			raw:         raw,
+			width:       width,
+			maxWidth:    maxWidth,
+			placeholder: true,
+		})
+		switch placeholder {
+		case LevelPlaceholder:
+			cp.hasLevel = true
+		case SourcePlaceholder:
+			cp.hasSource = true
+		case PerfPlaceholder:
+			cp.hasPerf = true
+		default:
+			if !builtinPlaceholders[placeholder] {
+				cp.custom = append(cp.custom, placeholder[1:len(placeholder)-1])
+			}
+		}
+		last = loc[1]
+	}
+	if last < len(pattern) {
+		cp.segments = append(cp.segments, patternSegment{text: pattern[last:]})
+	}
+	var plain strings.Builder
+	for _, segment := range cp.segments {
+		plain.WriteString(segment.text)
+	}
+	cp.plain = plain.String()
+	actual, _ := patternCache.LoadOrStore(pattern, cp)
+	return actual.(*compiledPattern)
+}
+
+// parsePlaceholder splits a placeholder such as [source:-30.30] into its name
+// [source], its width, and its maximum width. A negative width left-aligns the
+// value and a positive one right-aligns it; zero means no width. Widths are
+// bounded by maxPlaceholderWidth.
+func parsePlaceholder(placeholder string) (name string, width, maxWidth int) {
+	colon := strings.IndexByte(placeholder, ':')
+	if colon < 0 {
+		return placeholder, 0, 0
+	}
+	modifier := placeholder[colon+1 : len(placeholder)-1]
+	name = placeholder[:colon] + "]"
+	if dot := strings.IndexByte(modifier, '.'); dot >= 0 {
+		maxWidth, _ = strconv.Atoi(modifier[dot+1:])
+		modifier = modifier[:dot]
+	}
+	width, _ = strconv.Atoi(modifier)
+	return name, max(-maxPlaceholderWidth, min(width, maxPlaceholderWidth)), min(maxWidth, maxPlaceholderWidth)
+}
+
+// alignValue truncates the value to maxWidth runes and pads it with spaces to
+// the absolute width, on the right for negative widths and on the left otherwise.
+func alignValue(value string, width, maxWidth int) string {
+	n := utf8.RuneCountInString(value)
+	if maxWidth > 0 && n > maxWidth {
+		runes := []rune(value)
+		value, n = string(runes[:maxWidth]), maxWidth
+	}
+	switch {
+	case width < 0 && n < -width:
+		return value + strings.Repeat(" ", -width-n)
+	case width > 0 && n < width:
+		return strings.Repeat(" ", width-n) + value
+	}
+	return value
+}
+
+// upperLevelNames maps levels to the names used in text output.
+var upperLevelNames = map[slog.Level]string{
+	slog.LevelDebug: "DEBUG",
+	slog.LevelInfo:  "INFO",
+	slog.LevelWarn:  "WARN",
+	slog.LevelError: "ERROR",
+	LevelPerf:       "PERF",
+}
+
+// levelLabel returns the level as rendered by the default attribute replacement.
+func levelLabel(level slog.Level, singleLetter bool) string {
+	label, ok := upperLevelNames[level]
+	if !ok {
+		label = "UNKNOWN"
+	}
+	if singleLetter {
+		return label[:1]
+	}
+	return label
+}
+
+// appendTextAttr appends the attribute in slog text format, separating it from
+// previous attributes with a space. It mirrors slog.TextHandler: values are
+// resolved, replaceAttr is applied to non-group attributes, empty attributes and
+// groups are elided, and grouped keys are joined with dots.
+func appendTextAttr(buf []byte, groups []string, a slog.Attr, replaceAttr CustomReplaceAttr) []byte {
+	return appendTextAttrFunc(buf, groups, a, replaceAttr, nil)
+}
+
+// appendTextAttrFunc is appendTextAttr with a hook that is offered every
+// non-group attribute before it is appended; attributes it takes are skipped.
+func appendTextAttrFunc(
+	buf []byte,
+	groups []string,
+	a slog.Attr,
+	replaceAttr CustomReplaceAttr,
+	take func(groups []string, a slog.Attr) bool,
+) []byte {
+	a, ok := resolveTextAttr(groups, a, replaceAttr)
+	if !ok {
+		return buf
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		return appendTextGroup(buf, groups, a, replaceAttr, take)
+	}
+	if take != nil && take(groups, a) {
+		return buf
+	}
+
+	if len(buf) > 0 {
+		buf = append(buf, ' ')
+	}
+	buf = appendTextKey(buf, groups, a.Key)
+	buf = append(buf, '=')
+	return appendTextValue(buf, a.Value)
+}
+
+// resolveTextAttr resolves the value of the attribute and applies replaceAttr
+// to non-group attributes. Sources become their file:line. It reports false
+// for attributes that are elided: empty ones and empty sources.
+func resolveTextAttr(groups []string, a slog.Attr, replaceAttr CustomReplaceAttr) (slog.Attr, bool) {
+	a.Value = a.Value.Resolve()
+	if replaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = replaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Value.Kind() != slog.KindAny {
+		return a, true
+	}
+	if a.Key == "" && a.Value.Any() == nil {
+		return a, false
+	}
+	if src, ok := a.Value.Any().(*slog.Source); ok {
+		if src.File == "" && src.Line == 0 && src.Function == "" {
+			return a, false
+		}
+		a.Value = slog.StringValue(src.File + ":" + strconv.Itoa(src.Line))
+	}
+	return a, true
+}
+
+// appendTextGroup appends the attributes of the group attribute, qualified by
+// its key unless it is inlined. Empty groups are elided.
+func appendTextGroup(
+	buf []byte,
+	groups []string,
+	a slog.Attr,
+	replaceAttr CustomReplaceAttr,
+	take func(groups []string, a slog.Attr) bool,
+) []byte {
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return buf
+	}
+	if a.Key != "" {
+		groups = append(groups[:len(groups):len(groups)], a.Key)
+	}
+	for _, ga := range attrs {
+		buf = appendTextAttrFunc(buf, groups, ga, replaceAttr, take)
+	}
+	return buf
+}
+
+// appendTextKey appends the key qualified by its groups, quoting it if needed.
+func appendTextKey(buf []byte, groups []string, key string) []byte {
+	quote := needsQuoting(key)
+	for _, g := range groups {
+		quote = quote || needsQuoting(g)
+	}
+	if quote {
+		qualified := key
+		for i := len(groups) - 1; i >= 0; i-- {
+			qualified = groups[i] + "." + qualified
+		}
+		return strconv.AppendQuote(buf, qualified)
+	}
+	for _, g := range groups {
+		buf = append(buf, g...)
+		buf = append(buf, '.')
+	}
+	return append(buf, key...)
+}
+
+// appendTextValue appends the value as slog.TextHandler renders it.
+func appendTextValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendTextString(buf, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		return strconv.AppendFloat(buf, v.Float64(), 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool())
+	case slog.KindDuration:
+		return append(buf, v.Duration().String()...)
+	case slog.KindTime:
+		return appendRFC3339Millis(buf, v.Time())
+	case slog.KindAny:
+		return appendTextAny(buf, v.Any())
+	default:
+		return fmt.Append(buf, v.Any())
+	}
+}
+
+// appendTextAny appends an arbitrary value, preferring its text encoding.
+func appendTextAny(buf []byte, value any) (result []byte) {
+	n := len(buf)
+	defer func() {
+		if r := recover(); r != nil {
+			if rv := reflect.ValueOf(value); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				result = appendTextString(buf[:n], "<nil>")
+				return
+			}
+			result = appendTextString(buf[:n], fmt.Sprintf("!PANIC: %v", r))
+		}
+	}()
+
+	switch v := value.(type) {
+	case encoding.TextAppender:
+		text, err := v.AppendText(nil)
+		if err != nil {
+			return appendTextString(buf, fmt.Sprintf("!ERROR:%v", err))
+		}
+		return appendTextString(buf, string(text))
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err != nil {
+			return appendTextString(buf, fmt.Sprintf("!ERROR:%v", err))
+		}
+		return appendTextString(buf, string(text))
+	case []byte:
+		return strconv.AppendQuote(buf, string(v))
+	}
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		return strconv.AppendQuote(buf, string(rv.Bytes()))
+	}
+	return appendTextString(buf, fmt.Sprintf("%+v", value))
+}
+
+// appendTextString appends the string, quoting it if it contains spaces, '=',
+// quotes, or non-printable characters.
+func appendTextString(buf []byte, s string) []byte {
+	if needsQuoting(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+// needsQuoting reports whether the string must be quoted in text output.
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b < ' ' || b == ' ' || b == '=' || b == '"' {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// appendRFC3339Millis appends the time in RFC 3339 format with millisecond precision.
+func appendRFC3339Millis(buf []byte, t time.Time) []byte {
+	const prefixLen = len("2006-01-02T15:04:05.000")
+	n := len(buf)
+	t = t.Truncate(time.Millisecond).Add(time.Millisecond / 10)
+	buf = t.AppendFormat(buf, time.RFC3339Nano)
+	return append(buf[:n+prefixLen], buf[n+prefixLen+1:]...)
+}
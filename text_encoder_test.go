@@ -0,0 +1,171 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// spacedText is a TextMarshaler whose text needs quoting.
+type spacedText struct{}
+
+func (spacedText) MarshalText() ([]byte, error) { return []byte("a b"), nil }
+
+// newTextHandlerPair returns a handler formatting records directly and one
+// formatting them through the slog text handler, with the same options.
+func newTextHandlerPair(opts CustomHandlerOptions, direct, text io.Writer) (*slog.Logger, *slog.Logger) {
+	directOpts, textOpts := opts, opts
+	replaceAttr := GenerateDefaultCustomReplaceAttr(textOpts, slog.TimeKey, slog.MessageKey)
+	return slog.New(NewCustomHandler(&directOpts, bufio.NewWriter(direct), nil)),
+		slog.New(NewCustomHandler(&textOpts, bufio.NewWriter(text), replaceAttr))
+}
+
+func TestAppendRecord_MatchesTextHandler(t *testing.T) {
+	patterns := []string{
+		"[level] [msg]",
+		"[time] [level] [msg]",
+		"[msg]",
+		"[datetime] [level] [source] [msg]",
+		"[date] [custom] [msg]",
+	}
+	for _, pattern := range patterns {
+		for _, addSource := range []bool{false, true} {
+			opts := CustomHandlerOptions{
+				Level:                DebugLevel,
+				Enabled:              true,
+				Pattern:              pattern,
+				AddSource:            addSource,
+				UseSingleLetterLevel: addSource,
+			}
+			direct, text := &bytes.Buffer{}, &bytes.Buffer{}
+			directLogger, textLogger := newTextHandlerPair(opts, direct, text)
+			for _, logger := range []*slog.Logger{directLogger, textLogger} {
+				logger.Info("hello world",
+					"int", 1, "string", "x=y", "float", 1.5, "bool", true, "duration", time.Second,
+					"bytes", []byte("hi"), "text", spacedText{}, "err", errors.New("boom"),
+					"time", "dropped", "msg", "dropped", "quote", `with"quote`, "unicode", "ü",
+					"at", time.Date(2020, 1, 2, 3, 4, 5, 6e6, time.UTC))
+				logger.With("with", "v").WithGroup("g").With("h", 2).
+					Warn("warned", slog.Group("sub", "k", "v"), slog.Group("empty"), "nil", nil)
+				logger.WithGroup("unused").Debug("")
+			}
+			assert.Equal(t, text.String(), direct.String(), "pattern %q", pattern)
+		}
+	}
+}
+
+func TestNeedsQuoting(t *testing.T) {
+	for _, s := range []string{"", "a b", "a=b", `a"b`, "a\nb", "a\u00a0b", "\xff"} {
+		assert.True(t, needsQuoting(s), s)
+	}
+	for _, s := range []string{"abc", `a\b`, "ü", "file.go:12:pkg.(*T).Fn"} {
+		assert.False(t, needsQuoting(s), s)
+	}
+}
+
+func TestAppendTextKey(t *testing.T) {
+	assert.Equal(t, "a.b.key", string(appendTextKey(nil, []string{"a", "b"}, "key")))
+	assert.Equal(t, `"a b.key"`, string(appendTextKey(nil, []string{"a b"}, "key")))
+	assert.Equal(t, `""`, string(appendTextKey(nil, nil, "")))
+}
+
+func TestCompilePattern(t *testing.T) {
+	cp := compilePattern("<[level:-5]> [msg] [custom]!")
+	assert.Equal(t, []patternSegment{
+		{text: "<"},
+		{text: "[level]", raw: "[level:-5]", width: -5, placeholder: true},
+		{text: "> "},
+		{text: "[msg]", raw: "[msg]", placeholder: true},
+		{text: " "},
+		{text: "[custom]", raw: "[custom]", placeholder: true},
+		{text: "!"},
+	}, cp.segments)
+	assert.True(t, cp.hasLevel)
+	assert.False(t, cp.hasSource)
+	assert.Same(t, cp, compilePattern("<[level:-5]> [msg] [custom]!"))
+}
+
+func TestParsePlaceholder(t *testing.T) {
+	tests := []struct {
+		placeholder string
+		name        string
+		width       int
+		maxWidth    int
+	}{
+		{"[level]", "[level]", 0, 0},
+		{"[level:-5]", "[level]", -5, 0},
+		{"[source:30]", "[source]", 30, 0},
+		{"[source:-20.20]", "[source]", -20, 20},
+		{"[req.id:8]", "[req.id]", 8, 0},
+		{"[msg:-1000000.1000000]", "[msg]", -maxPlaceholderWidth, maxPlaceholderWidth},
+		{"[msg:99999999999999999999]", "[msg]", maxPlaceholderWidth, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.placeholder, func(t *testing.T) {
+			name, width, maxWidth := parsePlaceholder(tt.placeholder)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.width, width)
+			assert.Equal(t, tt.maxWidth, maxWidth)
+		})
+	}
+}
+
+func TestAlignValue(t *testing.T) {
+	assert.Equal(t, "INFO ", alignValue("INFO", -5, 0))
+	assert.Equal(t, " INFO", alignValue("INFO", 5, 0))
+	assert.Equal(t, "ERROR", alignValue("ERROR", -3, 0))
+	assert.Equal(t, "ERR", alignValue("ERROR", -3, 3))
+	assert.Equal(t, "héllo ", alignValue("héllo", -6, 0))
+	assert.Equal(t, "hé", alignValue("héllo", 0, 2))
+}
+
+func TestCustomHandler_PlaceholderWidth(t *testing.T) {
+	for _, replaceAttr := range []CustomReplaceAttr{nil, RemoveGivenKeys(slog.TimeKey, slog.MessageKey)} {
+		buf := &bytes.Buffer{}
+		opts := &CustomHandlerOptions{Level: DebugLevel, Enabled: true, Pattern: "[level:-5]|[user:6]|[msg:-8.8]|"}
+		logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), replaceAttr))
+
+		logger.Info("served", "user", "bob")
+		logger.Error("request failed", "user", "alice")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, "INFO |   bob|served  |", lines[0])
+		assert.Equal(t, "ERROR| alice|request |", lines[1])
+	}
+}
+
+func TestCustomHandler_PlaceholderWidthColor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := &CustomHandlerOptions{Level: DebugLevel, Enabled: true, Pattern: "[level:-5] [msg]", Color: true}
+	logger := slog.New(NewCustomHandler(opts, bufio.NewWriter(buf), nil))
+
+	logger.Info("served")
+
+	assert.Equal(t, Colorize("INFO ", GetColor(nil, "info"))+" "+Colorize("served", GetColor(nil, MsgColorKey))+"\n", buf.String())
+}
+
+func BenchmarkCustomHandler_Handle(b *testing.B) {
+	opts := CustomHandlerOptions{Level: InfoLevel, Enabled: true, Pattern: "[time] [level] [msg]"}
+	direct, text := newTextHandlerPair(opts, io.Discard, io.Discard)
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "request handled", 0)
+	record.AddAttrs(slog.String("method", "GET"), slog.Int("status", 200), slog.Duration("took", time.Millisecond))
+
+	for name, logger := range map[string]*slog.Logger{"direct": direct, "text_handler": text} {
+		handler := logger.Handler().WithAttrs([]slog.Attr{slog.String("service", "api")})
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_ = handler.Handle(context.Background(), record)
+			}
+		})
+	}
+}
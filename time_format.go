@@ -0,0 +1,87 @@
+package multilog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Named time layouts accepted by the time format options
+const (
+	RFC3339Layout     = "RFC3339"
+	RFC3339NanoLayout = "RFC3339Nano"
+	ISO8601Layout     = "ISO8601"
+)
+
+// Timezones accepted besides IANA names
+const (
+	UTCTimezone   = "UTC"
+	LocalTimezone = "Local"
+)
+
+// namedTimeLayouts maps the named layouts to Go time layouts. ISO8601 is
+// RFC 3339 with millisecond precision.
+var namedTimeLayouts = map[string]string{
+	RFC3339Layout:     time.RFC3339,
+	RFC3339NanoLayout: time.RFC3339Nano,
+	ISO8601Layout:     "2006-01-02T15:04:05.000Z07:00",
+}
+
+// ResolveTimeLayout returns the Go time layout for a named layout, or the
+// layout itself.
+func ResolveTimeLayout(layout string) string {
+	if named, ok := namedTimeLayouts[layout]; ok {
+		return named
+	}
+	return layout
+}
+
+// ParseTimezone returns the location for "UTC", "Local", or an IANA name such
+// as "Europe/Berlin". An empty timezone returns nil, keeping record times as
+// they are.
+func ParseTimezone(timezone string) (*time.Location, error) {
+	switch {
+	case timezone == "":
+		return nil, nil
+	case strings.EqualFold(timezone, UTCTimezone):
+		return time.UTC, nil
+	case strings.EqualFold(timezone, LocalTimezone):
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %s", timezone)
+	}
+	return loc, nil
+}
+
+// timeLayout returns the layout of the time placeholder.
+func timeLayout(opts *CustomHandlerOptions, placeholder string) string {
+	var layout, defaultLayout string
+	switch placeholder {
+	case DatePlaceholder:
+		defaultLayout = DefaultDateFormat
+		if opts != nil {
+			layout = opts.DateFormat
+		}
+	case TimePlaceholder:
+		defaultLayout = DefaultTimeFormat
+		if opts != nil {
+			layout = opts.TimeFormat
+		}
+	default:
+		defaultLayout = DefaultDateTimeFormat
+		if opts != nil {
+			layout = opts.DateTimeFormat
+		}
+	}
+	return ResolveTimeLayout(defaultIfEmpty(layout, defaultLayout))
+}
+
+// recordTime returns the time in the configured timezone.
+func recordTime(opts *CustomHandlerOptions, t time.Time) time.Time {
+	if opts == nil || opts.Location == nil {
+		return t
+	}
+	return t.In(opts.Location)
+}
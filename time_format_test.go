@@ -0,0 +1,101 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveTimeLayout(t *testing.T) {
+	assert.Equal(t, time.RFC3339, ResolveTimeLayout(RFC3339Layout))
+	assert.Equal(t, "2006-01-02T15:04:05.000Z07:00", ResolveTimeLayout(ISO8601Layout))
+	assert.Equal(t, "15:04", ResolveTimeLayout("15:04"))
+}
+
+func TestParseTimezone(t *testing.T) {
+	loc, err := ParseTimezone("")
+	assert.NoError(t, err)
+	assert.Nil(t, loc)
+
+	loc, err = ParseTimezone("utc")
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	loc, err = ParseTimezone(LocalTimezone)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Local, loc)
+
+	_, err = ParseTimezone("Mars/Olympus_Mons")
+	assert.EqualError(t, err, "invalid timezone: Mars/Olympus_Mons")
+}
+
+func TestTimeLayout(t *testing.T) {
+	assert.Equal(t, DefaultTimeFormat, timeLayout(nil, TimePlaceholder))
+	assert.Equal(t, DefaultDateFormat, timeLayout(&CustomHandlerOptions{}, DatePlaceholder))
+	assert.Equal(t, DefaultDateTimeFormat, timeLayout(&CustomHandlerOptions{}, DateTimePlaceholder))
+
+	opts := &CustomHandlerOptions{TimeFormat: "15:04:05.000", DateFormat: "02/01/2006", DateTimeFormat: ISO8601Layout}
+	assert.Equal(t, "15:04:05.000", timeLayout(opts, TimePlaceholder))
+	assert.Equal(t, "02/01/2006", timeLayout(opts, DatePlaceholder))
+	assert.Equal(t, "2006-01-02T15:04:05.000Z07:00", timeLayout(opts, DateTimePlaceholder))
+}
+
+func TestCustomHandler_TimeFormat(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	record := slog.NewRecord(time.Date(2024, 5, 6, 7, 8, 9, 123456789, loc), slog.LevelInfo, "hello", 0)
+
+	for _, replaceAttr := range []CustomReplaceAttr{nil, RemoveGivenKeys(slog.TimeKey, slog.MessageKey)} {
+		buf := &bytes.Buffer{}
+		opts := &CustomHandlerOptions{
+			Level:          DebugLevel,
+			Enabled:        true,
+			Pattern:        "[datetime] [time] [level] [msg]",
+			TimeFormat:     "15:04:05.000",
+			DateTimeFormat: ISO8601Layout,
+			Location:       time.UTC,
+		}
+		handler := NewCustomHandler(opts, bufio.NewWriter(buf), replaceAttr)
+
+		assert.NoError(t, handler.Handle(t.Context(), record))
+		assert.True(t, strings.HasPrefix(buf.String(), "2024-05-06T05:08:09.123Z 05:08:09.123 INFO hello"), buf.String())
+	}
+}
+
+func TestJSONHandler_TimeFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := CustomHandlerOptions{Level: DebugLevel, Enabled: true, DateTimeFormat: RFC3339Layout, Location: time.UTC}
+	handler := newJSONHandler(opts, bufio.NewWriter(buf), nil)
+
+	record := slog.NewRecord(time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("", -3600)), slog.LevelInfo, "hi", 0)
+	assert.NoError(t, handler.Handle(t.Context(), record))
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "2024-05-06T08:08:09Z", entry["datetime"])
+}
+
+func TestLogger_Timezone(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewBuilder().
+		File(file, Pattern("[datetime] [msg]"), DateTimeFormat(ISO8601Layout), Timezone(UTCTimezone)).
+		Build()
+	assert.NoError(t, err)
+
+	logger.Info("hello")
+	assert.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z hello`, string(content))
+
+	_, err = NewBuilder().Console(Timezone("Nowhere/Place")).Build()
+	assert.ErrorContains(t, err, "invalid timezone")
+}
@@ -0,0 +1,141 @@
+package multilog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// TruncatedKey is the attribute marking records with truncated values.
+const TruncatedKey = "truncated"
+
+// Ellipsis ends truncated values.
+const Ellipsis = "..."
+
+// TruncateHandler is a Handler that truncates messages and attribute values
+// longer than a limit before they reach the wrapped handler, so a single huge
+// payload cannot blow up log files and downstream parsers. Truncated values
+// end with Ellipsis and records with a truncated value get truncated=true.
+// String values are truncated, as well as errors, fmt.Stringer values and byte
+// slices, which are replaced by their truncated text. Limits are in bytes and
+// values are cut on a UTF-8 character boundary.
+type TruncateHandler struct {
+	maxMsgLen  int
+	maxAttrLen int
+	// truncated is set when attributes added with WithAttrs were truncated.
+	truncated bool
+	wrappedHandler
+}
+
+// NewTruncateHandler creates a handler truncating messages longer than
+// maxMsgLen and attribute values longer than maxAttrLen. A zero limit is not
+// applied.
+func NewTruncateHandler(handler slog.Handler, maxMsgLen, maxAttrLen int) *TruncateHandler {
+	return &TruncateHandler{
+		maxMsgLen:      maxMsgLen,
+		maxAttrLen:     maxAttrLen,
+		wrappedHandler: wrappedHandler{Handler: handler},
+	}
+}
+
+// TruncateMiddleware wraps handlers with NewTruncateHandler.
+func TruncateMiddleware(maxMsgLen, maxAttrLen int) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return NewTruncateHandler(next, maxMsgLen, maxAttrLen)
+	}
+}
+
+// Handle truncates the message and the attributes of the record and writes it.
+func (th *TruncateHandler) Handle(ctx context.Context, record slog.Record) error {
+	msg, truncated := truncateString(record.Message, th.maxMsgLen)
+	out := slog.NewRecord(record.Time, record.Level, msg, record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		a, attrTruncated := th.truncate(a)
+		truncated = truncated || attrTruncated
+		out.AddAttrs(a)
+		return true
+	})
+	if truncated || th.truncated {
+		out.AddAttrs(slog.Bool(TruncatedKey, true))
+	}
+	return th.Handler.Handle(ctx, out)
+}
+
+// WithAttrs creates a new handler with the attributes truncated.
+func (th *TruncateHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *th
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		var truncated bool
+		out[i], truncated = th.truncate(a)
+		clone.truncated = clone.truncated || truncated
+	}
+	clone.Handler = th.Handler.WithAttrs(out)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (th *TruncateHandler) WithGroup(name string) slog.Handler {
+	clone := *th
+	clone.Handler = th.Handler.WithGroup(name)
+	return &clone
+}
+
+// truncate truncates the value of the attribute, looking into groups, and
+// reports whether it was truncated.
+func (th *TruncateHandler) truncate(a slog.Attr) (slog.Attr, bool) {
+	if th.maxAttrLen <= 0 {
+		return a, false
+	}
+	a.Value = a.Value.Resolve()
+	var text string
+	switch a.Value.Kind() {
+	case slog.KindString:
+		text = a.Value.String()
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		out := make([]slog.Attr, len(attrs))
+		truncated := false
+		for i, ga := range attrs {
+			var gaTruncated bool
+			out[i], gaTruncated = th.truncate(ga)
+			truncated = truncated || gaTruncated
+		}
+		if truncated {
+			a.Value = slog.GroupValue(out...)
+		}
+		return a, truncated
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			text = v.Error()
+		case fmt.Stringer:
+			text = v.String()
+		case []byte:
+			text = string(v)
+		default:
+			return a, false
+		}
+	default:
+		return a, false
+	}
+	text, truncated := truncateString(text, th.maxAttrLen)
+	if truncated {
+		a.Value = slog.StringValue(text)
+	}
+	return a, truncated
+}
+
+// truncateString cuts s to at most limit bytes followed by Ellipsis, on a UTF-8
+// character boundary, and reports whether it was cut. A zero limit is not applied.
+func truncateString(s string, limit int) (string, bool) {
+	if limit <= 0 || len(s) <= limit {
+		return s, false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + Ellipsis, true
+}
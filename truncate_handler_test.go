@@ -0,0 +1,98 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		expected  string
+		limit     int
+		truncated bool
+	}{
+		{"short", "abc", "abc", 5, false},
+		{"exact", "abcde", "abcde", 5, false},
+		{"long", "abcdefgh", "abcde...", 5, true},
+		{"no limit", "abcdefgh", "abcdefgh", 0, false},
+		{"utf-8 boundary", "héllo", "h...", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, truncated := truncateString(tt.s, tt.limit)
+			assert.Equal(t, tt.expected, s)
+			assert.Equal(t, tt.truncated, truncated)
+		})
+	}
+}
+
+// newTruncateLogger creates a logger truncating into the returned buffer.
+func newTruncateLogger(maxMsgLen, maxAttrLen int) (*slog.Logger, *CustomHandler, *bytes.Buffer) {
+	var buf bytes.Buffer
+	inner := NewCustomHandler(&CustomHandlerOptions{
+		Level:   InfoLevel,
+		Enabled: true,
+		Pattern: "[level] [msg]",
+	}, bufio.NewWriter(&buf), nil)
+	return slog.New(NewTruncateHandler(inner, maxMsgLen, maxAttrLen)), inner, &buf
+}
+
+func TestTruncateHandler(t *testing.T) {
+	logger, inner, buf := newTruncateLogger(10, 4)
+
+	logger.Info("a very long message", "body", "0123456789", "err", errors.New("failure"),
+		"payload", []byte("abcdefgh"), "count", 123456789, slog.Group("req", "path", "/orders/42"))
+	logger.Info("short", "id", "abc")
+	assert.NoError(t, inner.Flush())
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Equal(t, "INFO a very lon... [body=0123... err=fail... payload=abcd... count=123456789 "+
+		"req.path=/ord... truncated=true]", lines[0])
+	assert.Equal(t, "INFO short [id=abc]", lines[1])
+}
+
+func TestTruncateHandler_WithAttrs(t *testing.T) {
+	logger, inner, buf := newTruncateLogger(0, 4)
+
+	logger.With("body", "0123456789").Info("message")
+	assert.NoError(t, inner.Flush())
+	assert.Equal(t, "INFO message [body=0123... truncated=true]\n", buf.String())
+}
+
+func TestTruncate_Config(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	cfg, err := NewBuilder().File(path, JSON(), Truncate(8, 5)).Config()
+	assert.NoError(t, err)
+	handlers, err := CreateHandlers(cfg)
+	assert.NoError(t, err)
+	_, ok := handlers[0].(*TruncateHandler)
+	assert.True(t, ok)
+
+	logger := NewLogger(handlers...)
+	logger.Info("an oversized message", "body", strings.Repeat("x", 1000))
+	assert.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"an overs..."`)
+	assert.Contains(t, string(data), `"body":"xxxxx..."`)
+	assert.Contains(t, string(data), `"truncated":true`)
+}
+
+func TestValidateHandler_Truncate(t *testing.T) {
+	handler := &HandlerConfig{Type: ConsoleHandlerType, Level: InfoLevel, MaxMsgLen: 1024, MaxAttrLen: 256}
+	assert.NoError(t, validateHandler(handler))
+
+	handler.MaxAttrLen = -1
+	assert.EqualError(t, validateHandler(handler), "truncation limits must not be negative")
+}
@@ -0,0 +1,151 @@
+package multilog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"text/template"
+)
+
+// DefaultWebhookTemplate is the payload template used when none is configured:
+// an object with the records of the batch.
+const DefaultWebhookTemplate = `{"records":[{{range $i, $r := .Records}}{{if $i}},{{end}}` +
+	`{"time":{{json $r.Time}},"level":{{json $r.Level}},"msg":{{json $r.Message}},"attrs":{{json $r.Attrs}}}` +
+	`{{end}}]}`
+
+// WebhookPayload is the data a webhook payload template is executed with.
+// The fields of the first record are promoted, so templates for single-record
+// batches can use {{.Message}}; batched templates range over .Records.
+type WebhookPayload struct {
+	TemplateRecord
+	Records []TemplateRecord
+}
+
+// WebhookHandler is a Handler that posts records to an HTTP webhook, such as a
+// Slack, Teams or PagerDuty endpoint, with a JSON body rendered from a template.
+type WebhookHandler struct {
+	Handler  *CustomHandler
+	client   *http.Client
+	batch    *batcher[TemplateRecord]
+	template *template.Template
+	headers  map[string]string
+	opts     *WebhookHandlerOptions
+}
+
+// WebhookHandlerOptions are the options of webhook handlers: the formatting and
+// batching options, the URL with its headers, and the payload template.
+type WebhookHandlerOptions struct {
+	CustomHandlerOptions
+	BatchOptions
+	Headers         map[string]string
+	URL             string
+	PayloadTemplate string
+}
+
+// NewWebhookHandler creates a webhook Handler with the specified options.
+// Records are batched up to BatchSize per request; use a BatchSize of 1 to post
+// each record on its own. The body is rendered from PayloadTemplate, or
+// DefaultWebhookTemplate, and must be valid JSON.
+func NewWebhookHandler(opts WebhookHandlerOptions) (slog.Handler, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("webhook handler requires a url")
+	}
+	t, err := ParseTemplate(defaultIfEmpty(opts.PayloadTemplate, DefaultWebhookTemplate))
+	if err != nil {
+		return nil, err
+	}
+
+	wh := &WebhookHandler{
+		Handler:  NewCustomHandler(&opts.CustomHandlerOptions, bufio.NewWriter(io.Discard), nil),
+		client:   &http.Client{Timeout: DefaultHTTPTimeout},
+		template: t,
+		headers:  opts.Headers,
+		opts:     &opts,
+	}
+	wh.batch = newBatcher(opts.BatchSize, opts.FlushInterval, opts.MaxBufferSize, wh.post, opts.OnError)
+	return wh, nil
+}
+
+// Enabled checks if the handler is enabled for the given level.
+func (wh *WebhookHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return wh.Handler.Enabled(ctx, level)
+}
+
+// Handle queues the log record for the next request.
+func (wh *WebhookHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !wh.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return wh.batch.add(ctx, wh.Handler.templateRecord(record))
+}
+
+// WithAttrs creates a new handler with the given attributes.
+func (wh *WebhookHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *wh
+	clone.Handler = wh.Handler.WithAttrs(attrs).(*CustomHandler)
+	return &clone
+}
+
+// WithGroup creates a new handler with the given group name.
+func (wh *WebhookHandler) WithGroup(name string) slog.Handler {
+	clone := *wh
+	clone.Handler = wh.Handler.WithGroup(name).(*CustomHandler)
+	return &clone
+}
+
+// SetLevel changes the minimum level of the handler at runtime.
+func (wh *WebhookHandler) SetLevel(level string) error {
+	return wh.Handler.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the handler.
+func (wh *WebhookHandler) GetLevel() string {
+	return wh.Handler.GetLevel()
+}
+
+// Flush sends all queued records.
+func (wh *WebhookHandler) Flush() error {
+	return wh.batch.Flush()
+}
+
+// Close sends all queued records and stops the background flush loop.
+func (wh *WebhookHandler) Close() error {
+	return wh.batch.Close()
+}
+
+// customHandler implements customHandlerProvider.
+func (wh *WebhookHandler) customHandler() CustomHandlerInterface {
+	return wh.Handler
+}
+
+// post renders the payload of a batch and sends it, retrying with jitter on failure.
+func (wh *WebhookHandler) post(records []TemplateRecord) error {
+	body, err := wh.render(records)
+	if err != nil {
+		return err
+	}
+	policy := wh.opts.retryPolicy()
+	policy.Jitter = true
+	return policy.Do(func() error {
+		_, err := post(wh.client, wh.opts.URL, ContentTypeJSON, body, wh.headers)
+		return err
+	})
+}
+
+// render executes the payload template for the batch.
+func (wh *WebhookHandler) render(records []TemplateRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	payload := WebhookPayload{TemplateRecord: records[0], Records: records}
+	if err := wh.template.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("failed to execute payload template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("webhook payload is not valid json: %s", buf.String())
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,121 @@
+package multilog
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWebhookHandler_Validation(t *testing.T) {
+	_, err := NewWebhookHandler(WebhookHandlerOptions{})
+	assert.Error(t, err)
+
+	_, err = NewWebhookHandler(WebhookHandlerOptions{URL: "http://localhost", PayloadTemplate: "{{.Message"})
+	assert.Error(t, err)
+}
+
+func TestWebhookHandler_SingleRecord(t *testing.T) {
+	server := newIntakeServer(t)
+	handler, err := NewWebhookHandler(WebhookHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   ErrorLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			BatchSize:     1,
+			FlushInterval: time.Hour,
+		},
+		URL:             server.URL,
+		Headers:         map[string]string{"Authorization": "Bearer token"},
+		PayloadTemplate: `{"text":{{json (printf "%s: %s (%v)" .Level .Message (index .Attrs "order"))}}}`,
+	})
+	assert.NoError(t, err)
+
+	logger := NewLogger(handler)
+	logger.Warn("ignored")
+	logger.Error("payment failed", "order", 42)
+	assert.NoError(t, handler.(*WebhookHandler).Close())
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Len(t, server.requests, 1)
+	assert.Equal(t, `{"text":"ERROR: payment failed (42)"}`, server.requests[0].body)
+	assert.Equal(t, "Bearer token", server.requests[0].header.Get("Authorization"))
+	assert.Equal(t, ContentTypeJSON, server.requests[0].header.Get("Content-Type"))
+}
+
+func TestWebhookHandler_Batched(t *testing.T) {
+	server := newIntakeServer(t)
+	handler, err := NewWebhookHandler(WebhookHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			BatchSize:     10,
+			FlushInterval: time.Hour,
+		},
+		URL: server.URL,
+	})
+	assert.NoError(t, err)
+
+	logger := NewLogger(handler).WithField("service", "api")
+	logger.Info("first")
+	logger.Warn("second", "attempt", 2)
+	assert.NoError(t, handler.(*WebhookHandler).Flush())
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Len(t, server.requests, 1)
+	var payload struct {
+		Records []struct {
+			Attrs map[string]any `json:"attrs"`
+			Level string         `json:"level"`
+			Msg   string         `json:"msg"`
+		} `json:"records"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(server.requests[0].body), &payload))
+	assert.Len(t, payload.Records, 2)
+	assert.Equal(t, "first", payload.Records[0].Msg)
+	assert.Equal(t, "WARN", payload.Records[1].Level)
+	assert.Equal(t, map[string]any{"service": "api", "attempt": float64(2)}, payload.Records[1].Attrs)
+}
+
+func TestWebhookHandler_InvalidJSON(t *testing.T) {
+	server := newIntakeServer(t)
+	handler, err := NewWebhookHandler(WebhookHandlerOptions{
+		CustomHandlerOptions: CustomHandlerOptions{
+			Level:   InfoLevel,
+			Enabled: true,
+		},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+		URL:             server.URL,
+		PayloadTemplate: `{"text": {{.Message}}}`,
+	})
+	assert.NoError(t, err)
+	wh := handler.(*WebhookHandler)
+	defer wh.Close()
+
+	NewLogger(handler).Info("unquoted")
+	assert.ErrorContains(t, wh.Flush(), "not valid json")
+	assert.Empty(t, server.requests)
+}
+
+func TestCreateHandler_Webhook(t *testing.T) {
+	cfg := &Config{Multilog: LogConfig{Handlers: []HandlerConfig{
+		{Type: WebhookHandlerType, Level: ErrorLevel, Enabled: true, PayloadTemplate: `{"text":{{json .Message}}}`},
+	}}}
+	assert.Error(t, validateConfig(cfg))
+
+	cfg.Multilog.Handlers[0].URL = "http://localhost"
+	assert.NoError(t, validateConfig(cfg))
+	opts, err := cfg.GetCustomHandlerOptionsForHandler(cfg.Multilog.Handlers[0])
+	assert.NoError(t, err)
+	handler, err := createHandler(&cfg.Multilog.Handlers[0], opts)
+	assert.NoError(t, err)
+	assert.NoError(t, handler.(*WebhookHandler).Close())
+}
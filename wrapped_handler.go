@@ -0,0 +1,68 @@
+package multilog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// wrappedHandler forwards the optional handler capabilities (levels, flushing,
+// closing, rotation) to the handler it wraps. It is embedded by handlers that
+// add behaviour around another handler.
+type wrappedHandler struct {
+	Handler slog.Handler
+}
+
+// Enabled checks if the wrapped handler is enabled for the given level.
+func (wh wrappedHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return wh.Handler.Enabled(ctx, level)
+}
+
+// SetLevel changes the minimum level of the wrapped handler at runtime.
+func (wh wrappedHandler) SetLevel(level string) error {
+	ls, ok := wh.Handler.(LevelSetter)
+	if !ok {
+		return fmt.Errorf("handler does not support level changes")
+	}
+	return ls.SetLevel(level)
+}
+
+// GetLevel returns the current minimum level of the wrapped handler.
+func (wh wrappedHandler) GetLevel() string {
+	if ls, ok := wh.Handler.(LevelSetter); ok {
+		return ls.GetLevel()
+	}
+	if ch := GetCustomHandler(wh.Handler); ch != nil {
+		return getHandlerLevel(ch)
+	}
+	return UnknownLevel
+}
+
+// Flush flushes the wrapped handler.
+func (wh wrappedHandler) Flush() error {
+	return flushHandler(wh.Handler)
+}
+
+// Close closes the wrapped handler.
+func (wh wrappedHandler) Close() error {
+	return closeSlogHandler(wh.Handler)
+}
+
+// Rotate rotates the log file of the wrapped handler.
+func (wh wrappedHandler) Rotate() error {
+	rotator, ok := wh.Handler.(Rotator)
+	if !ok {
+		return fmt.Errorf("handler does not support rotation")
+	}
+	return rotator.Rotate()
+}
+
+// customHandler implements customHandlerProvider.
+func (wh wrappedHandler) customHandler() CustomHandlerInterface {
+	return GetCustomHandler(wh.Handler)
+}
+
+// logFile implements logFileProvider.
+func (wh wrappedHandler) logFile() string {
+	return handlerLogFile(wh.Handler)
+}